@@ -18,6 +18,7 @@ package bind
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -27,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
@@ -152,6 +154,26 @@ func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend Co
 	return c.address, tx, c, nil
 }
 
+// revertData extracts the raw revert data from the data of an RPC error, which
+// is either the hex encoded data itself or a structured revert object carrying
+// the raw data in its data field.
+func revertData(errorData interface{}) ([]byte, bool) {
+	if dataString, ok := errorData.(string); ok {
+		return common.FromHex(dataString), true
+	}
+	blob, err := json.Marshal(errorData)
+	if err != nil {
+		return nil, false
+	}
+	var structured struct {
+		Data *hexutil.Bytes `json:"data"`
+	}
+	if err := json.Unmarshal(blob, &structured); err != nil || structured.Data == nil {
+		return nil, false
+	}
+	return *structured.Data, true
+}
+
 // parseError takes in an RPC error and attempts to parse any "execution reverted"
 // data as an ABI error, returning an improved error if possible.
 func (c *BoundContract) parseError(originalError error) error {
@@ -159,12 +181,8 @@ func (c *BoundContract) parseError(originalError error) error {
 	if !errors.As(originalError, &dataErr) {
 		return originalError
 	}
-	dataString, ok := dataErr.ErrorData().(string)
-	if !ok {
-		return originalError
-	}
-	data := common.FromHex(dataString)
-	if len(data) < 4 {
+	data, ok := revertData(dataErr.ErrorData())
+	if !ok || len(data) < 4 {
 		return originalError
 	}
 	errAbi, _ := c.abi.ErrorByID([4]byte(data[:4]))
@@ -0,0 +1,63 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockAccessListResult is the result of an arb_getBlockAccessList call
+type BlockAccessListResult struct {
+	BlockNumber hexutil.Uint64   `json:"blockNumber"`
+	BlockHash   common.Hash      `json:"blockHash"`
+	Accounts    hexutil.Uint64   `json:"accounts"`
+	Slots       hexutil.Uint64   `json:"slots"`
+	AccessList  types.AccessList `json:"accessList"`
+}
+
+type ArbBlockAccessListAPI struct {
+	b *APIBackend
+}
+
+func NewArbBlockAccessListAPI(b *APIBackend) *ArbBlockAccessListAPI {
+	return &ArbBlockAccessListAPI{b}
+}
+
+// GetBlockAccessList re-executes the given block on top of its parent state and
+// returns the union of the accounts and storage slots accessed by all of its
+// transactions, including the accesses of reverted calls.
+func (api *ArbBlockAccessListAPI) GetBlockAccessList(ctx context.Context, blockNr rpc.BlockNumber) (*BlockAccessListResult, error) {
+	block, err := api.b.BlockByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis block has no access list")
+	}
+	statedb, _, err := api.b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(block.NumberU64()-1))
+	if err != nil {
+		return nil, err
+	}
+	if _, _, _, err := api.b.BlockChain().Processor().Process(block, statedb, vm.Config{}); err != nil {
+		return nil, err
+	}
+	list := statedb.BlockAccessList()
+	result := &BlockAccessListResult{
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		Accounts:    hexutil.Uint64(len(list)),
+		AccessList:  list,
+	}
+	for _, tuple := range list {
+		result.Slots += hexutil.Uint64(len(tuple.StorageKeys))
+	}
+	return result, nil
+}
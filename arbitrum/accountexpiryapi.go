@@ -0,0 +1,113 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var errAccountExpiryDisabled = errors.New("account inactivity tracking is disabled")
+
+// AccountExpiryStats is the result of a debug_accountExpiryStats call
+type AccountExpiryStats struct {
+	Epoch       hexutil.Uint64                    `json:"epoch"`
+	EpochLength hexutil.Uint64                    `json:"epochLength"`
+	Tracked     hexutil.Uint64                    `json:"tracked"`
+	Inactive    hexutil.Uint64                    `json:"inactive"`
+	LastAccess  map[hexutil.Uint64]hexutil.Uint64 `json:"lastAccess"`
+}
+
+// InactivityResult is the result of a debug_markInactiveAccounts call
+type InactivityResult struct {
+	Epoch     hexutil.Uint64 `json:"epoch"`
+	Threshold hexutil.Uint64 `json:"threshold"`
+	Scanned   hexutil.Uint64 `json:"scanned"`
+	Marked    hexutil.Uint64 `json:"marked"`
+}
+
+// AccountActivity is the result of a debug_accountActivity call
+type AccountActivity struct {
+	LastAccess    *hexutil.Uint64 `json:"lastAccess"`
+	InactiveSince *hexutil.Uint64 `json:"inactiveSince"`
+}
+
+// AccountExpiryAPI exposes the epochs the accounts were last accessed at, and
+// marks the long inactive ones, to simulate state expiry proposals.
+type AccountExpiryAPI struct {
+	b *APIBackend
+}
+
+func NewAccountExpiryAPI(b *APIBackend) *AccountExpiryAPI {
+	return &AccountExpiryAPI{b}
+}
+
+func (api *AccountExpiryAPI) expiry() (*core.AccountExpiry, error) {
+	expiry := api.b.BlockChain().AccountExpiry()
+	if expiry == nil {
+		return nil, errAccountExpiryDisabled
+	}
+	return expiry, nil
+}
+
+// AccountExpiryStats reports the number of tracked and inactive accounts, and
+// the number of accounts by epoch of last access.
+func (api *AccountExpiryAPI) AccountExpiryStats() (*AccountExpiryStats, error) {
+	expiry, err := api.expiry()
+	if err != nil {
+		return nil, err
+	}
+	stats, err := expiry.Stats()
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountExpiryStats{
+		Epoch:       hexutil.Uint64(stats.Epoch),
+		EpochLength: hexutil.Uint64(stats.EpochLength),
+		Tracked:     hexutil.Uint64(stats.Tracked),
+		Inactive:    hexutil.Uint64(stats.Inactive),
+		LastAccess:  make(map[hexutil.Uint64]hexutil.Uint64, len(stats.LastAccess)),
+	}
+	for epoch, count := range stats.LastAccess {
+		result.LastAccess[hexutil.Uint64(epoch)] = hexutil.Uint64(count)
+	}
+	return result, nil
+}
+
+// AccountActivity returns the epoch the given account was last accessed at and
+// the epoch it was marked inactive at, each null if unknown.
+func (api *AccountExpiryAPI) AccountActivity(addr common.Address) (*AccountActivity, error) {
+	expiry, err := api.expiry()
+	if err != nil {
+		return nil, err
+	}
+	result := new(AccountActivity)
+	last, tracked, since, inactive := expiry.LastAccess(addr)
+	if tracked {
+		result.LastAccess = (*hexutil.Uint64)(&last)
+	}
+	if inactive {
+		result.InactiveSince = (*hexutil.Uint64)(&since)
+	}
+	return result, nil
+}
+
+// MarkInactiveAccounts marks the accounts not accessed for at least the given
+// number of epochs as inactive.
+func (api *AccountExpiryAPI) MarkInactiveAccounts(threshold hexutil.Uint64) (*InactivityResult, error) {
+	expiry, err := api.expiry()
+	if err != nil {
+		return nil, err
+	}
+	result, err := expiry.MarkInactive(uint64(threshold))
+	if err != nil {
+		return nil, err
+	}
+	return &InactivityResult{
+		Epoch:     hexutil.Uint64(result.Epoch),
+		Threshold: hexutil.Uint64(result.Threshold),
+		Scanned:   hexutil.Uint64(result.Scanned),
+		Marked:    hexutil.Uint64(result.Marked),
+	}, nil
+}
@@ -43,6 +43,10 @@ var (
 	recreatedStatesDereferencedCounter = metrics.NewRegisteredCounter("arb/apibackend/states/recreated/dereferenced", nil)
 )
 
+// intraBlockStateReexec is the number of blocks re-executed to regenerate the
+// parent state of a block replayed up to a transaction.
+const intraBlockStateReexec = 128
+
 type APIBackend struct {
 	b *Backend
 
@@ -50,6 +54,15 @@ type APIBackend struct {
 
 	fallbackClient types.FallbackClient
 	sync           SyncProgressBackend
+
+	txFilterLog *txFilterLog
+	pending     pendingState
+
+	historicalProvider state.HistoricalStateProvider // Serves the states unavailable locally, nil if none
+	historicalState    state.Database                // Local state database falling back to the provider
+
+	stateRepairSource state.TrieNodeSource // Serves the trie nodes missing locally to debug_repairState, nil if none
+	receiptsSource    core.ReceiptsSource  // Serves the receipts pruned locally to debug_backfillReceipts, nil if none
 }
 
 type errorFilteredFallbackClient struct {
@@ -141,6 +154,28 @@ func createRegisterAPIBackend(backend *Backend, filterConfig filters.Config, fal
 		b:              backend,
 		dbForAPICalls:  dbForAPICalls,
 		fallbackClient: fallbackClient,
+		txFilterLog:    newTxFilterLog(int(backend.config.ArbDebug.FilteredTxLogSize)),
+	}
+	if url := backend.config.HistoricalStateURL; url != "" {
+		provider, err := NewRPCHistoricalState(url, backend.config.HistoricalStateTimeout)
+		if err != nil {
+			return nil, err
+		}
+		backend.apiBackend.SetHistoricalStateProvider(provider)
+	}
+	if url := backend.config.StateRepairURL; url != "" {
+		source, err := NewRPCTrieNodeSource(url, backend.config.StateRepairTimeout)
+		if err != nil {
+			return nil, err
+		}
+		backend.apiBackend.stateRepairSource = source
+	}
+	if url := backend.config.ReceiptsBackfillURL; url != "" {
+		source, err := NewRPCReceiptsSource(url, backend.config.ReceiptsBackfillTimeout)
+		if err != nil {
+			return nil, err
+		}
+		backend.apiBackend.receiptsSource = source
 	}
 	filterSystem := filters.NewFilterSystem(backend.apiBackend, filterConfig)
 	backend.stack.RegisterAPIs(backend.apiBackend.GetAPIs(filterSystem))
@@ -172,6 +207,41 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 		Public:    true,
 	})
 
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbFeeHistoryAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbBlockAccessListAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbStylusStatsAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbReverseDiffAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbSlotWatchAPI(a),
+		Public:    true,
+	})
+
 	apis = append(apis, rpc.API{
 		Namespace: "net",
 		Version:   "1.0",
@@ -186,6 +256,110 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 		Public:    true,
 	})
 
+	apis = append(apis, rpc.API{
+		Namespace: "arbdebug",
+		Version:   "1.0",
+		Service:   NewTxFilterAPI(a, a.b.config.ArbDebug.BlockRangeBound),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   eth.NewSnapshotAdminAPI(a.BlockChain()),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   NewStylusRecompileAPI(a.b.AsmRecompiler()),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   NewCompactionAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewWasmStoreAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewUserWasmsAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewSnapshotIteratorAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewStateStatsAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewHeavyContractsAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewAccountExpiryAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewStateRepairAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewLogIndexAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewReceiptsRetentionAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewStateMigrationAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewTrieDBAPI(a),
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewStateHistoryAPI(a),
+	})
+
+	if a.b.config.DBAccess {
+		apis = append(apis, rpc.API{
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   NewDBDebugAPI(a),
+		})
+	}
+
 	apis = append(apis, tracers.APIs(a)...)
 
 	return apis
@@ -574,11 +748,22 @@ func StateAndHeaderFromHeader(ctx context.Context, chainDb ethdb.Database, bc *c
 }
 
 func (a *APIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	if number == rpc.PendingBlockNumber {
+		if statedb, header := a.pendingStateAndHeader(); statedb != nil {
+			return statedb, header, nil
+		}
+	}
 	header, err := a.HeaderByNumber(ctx, number)
-	return StateAndHeaderFromHeader(ctx, a.ChainDb(), a.b.arb.BlockChain(), a.b.config.MaxRecreateStateDepth, header, err)
+	statedb, resHeader, err := StateAndHeaderFromHeader(ctx, a.ChainDb(), a.b.arb.BlockChain(), a.b.config.MaxRecreateStateDepth, header, err)
+	return a.historicalStateAndHeader(header, statedb, resHeader, err)
 }
 
 func (a *APIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if number, isnum := blockNrOrHash.Number(); isnum && number == rpc.PendingBlockNumber {
+		if statedb, header := a.pendingStateAndHeader(); statedb != nil {
+			return statedb, header, nil
+		}
+	}
 	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
 	hash, ishash := blockNrOrHash.Hash()
 	bc := a.BlockChain()
@@ -586,26 +771,57 @@ func (a *APIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	if ishash && header != nil && header.Number.Cmp(bc.CurrentBlock().Number) > 0 && bc.GetCanonicalHash(header.Number.Uint64()) != hash {
 		return nil, nil, errors.New("requested block ahead of current block and the hash is not currently canonical")
 	}
-	return StateAndHeaderFromHeader(ctx, a.ChainDb(), a.b.arb.BlockChain(), a.b.config.MaxRecreateStateDepth, header, err)
+	statedb, resHeader, err := StateAndHeaderFromHeader(ctx, a.ChainDb(), a.b.arb.BlockChain(), a.b.config.MaxRecreateStateDepth, header, err)
+	return a.historicalStateAndHeader(header, statedb, resHeader, err)
 }
 
 func (a *APIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, checkLive bool, preferDisk bool) (statedb *state.StateDB, release tracers.StateReleaseFunc, err error) {
 	if !a.BlockChain().Config().IsArbitrumNitro(block.Number()) {
 		return nil, nil, types.ErrUseFallback
 	}
+	a.recordHistoricalHeader(block.Header())
 	// DEV: This assumes that `StateAtBlock` only accesses the blockchain and chainDb fields
-	return eth.NewArbEthereum(a.b.arb.BlockChain(), a.ChainDb()).StateAtBlock(ctx, block, reexec, base, nil, checkLive, preferDisk)
+	return eth.NewArbEthereum(a.b.arb.BlockChain(), a.ChainDb()).WithHistoricalState(a.historicalState).StateAtBlock(ctx, block, reexec, base, nil, checkLive, preferDisk)
 }
 
 func (a *APIBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*types.Transaction, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
 	if !a.BlockChain().Config().IsArbitrumNitro(block.Number()) {
 		return nil, vm.BlockContext{}, nil, nil, types.ErrUseFallback
 	}
+	if block.NumberU64() > 0 {
+		a.recordHistoricalHeader(a.BlockChain().GetHeader(block.ParentHash(), block.NumberU64()-1))
+	}
 	// DEV: This assumes that `StateAtTransaction` only accesses the blockchain and chainDb fields
-	return eth.NewArbEthereum(a.b.arb.BlockChain(), a.ChainDb()).StateAtTransaction(ctx, block, txIndex, reexec)
+	return eth.NewArbEthereum(a.b.arb.BlockChain(), a.ChainDb()).WithHistoricalState(a.historicalState).StateAtTransaction(ctx, block, txIndex, reexec)
+}
+
+// StateAtTransactionIndex returns the state of the given block right before the
+// execution of the transaction at the given index, from the cache of the
+// intra-block states if enabled.
+func (a *APIBackend) StateAtTransactionIndex(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error) {
+	states := a.BlockChain().IntraBlockStates()
+	if states != nil {
+		if statedb := states.Get(block.Hash(), txIndex); statedb != nil {
+			return statedb, nil
+		}
+	}
+	_, _, statedb, release, err := a.StateAtTransaction(ctx, block, txIndex, intraBlockStateReexec)
+	if err != nil {
+		return nil, err
+	}
+	if states == nil {
+		statedb.SetArbFinalizer(func(*state.ArbitrumExtraData) { release() })
+		return statedb, nil
+	}
+	return states.Add(block.Hash(), txIndex, statedb, release), nil
 }
 
 func (a *APIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if number := rawdb.ReadHeaderNumber(a.ChainDb(), hash); number != nil {
+		if err := a.BlockChain().CheckReceiptsRetained(*number); err != nil {
+			return nil, err
+		}
+	}
 	return a.BlockChain().GetReceiptsByHash(hash), nil
 }
 
@@ -697,6 +913,9 @@ func (a *APIBackend) BloomStatus() (uint64, uint64) {
 }
 
 func (a *APIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
+	if err := a.BlockChain().CheckReceiptsRetained(number); err != nil {
+		return nil, err
+	}
 	return rawdb.ReadLogs(a.ChainDb(), hash, number), nil
 }
 
@@ -715,6 +934,14 @@ func (a *APIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Sub
 	return a.SubscribeLogsEvent(ch)
 }
 
+func (a *APIBackend) SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription {
+	return a.BlockChain().SubscribeStateChangesEvent(ch)
+}
+
+func (a *APIBackend) SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription {
+	return a.BlockChain().SubscribeAccountLifecycleEvent(ch)
+}
+
 func (a *APIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return a.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
@@ -728,7 +955,11 @@ func (a *APIBackend) Engine() consensus.Engine {
 }
 
 func (b *APIBackend) Pending() (*types.Block, types.Receipts, *state.StateDB) {
-	return nil, nil, nil
+	statedb, header := b.pendingStateAndHeader()
+	if statedb == nil {
+		return nil, nil, nil
+	}
+	return types.NewBlockWithHeader(header), nil, statedb
 }
 
 func (b *APIBackend) FallbackClient() types.FallbackClient {
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -31,6 +32,9 @@ type Backend struct {
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
 	shutdownTracker *shutdowncheck.ShutdownTracker
+	asmRecompiler   *state.AsmRecompiler // Backfills the wasm store for additional targets
+
+	compaction *CompactionController // Defers the heavy database compactions under load
 
 	chanTxs      chan *types.Transaction
 	chanClose    chan struct{} //close coroutine
@@ -40,6 +44,13 @@ type Backend struct {
 }
 
 func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publisher ArbInterface, filterConfig filters.Config) (*Backend, *filters.FilterSystem, error) {
+	balanceDeltaCheck, err := state.ParseBalanceDeltaCheckMode(config.ArbDebug.BalanceDeltaCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.SetBalanceDeltaCheck(balanceDeltaCheck)
+
+	wasmStore, _ := chainDb.WasmDataBase()
 	backend := &Backend{
 		arb:     publisher,
 		stack:   stack,
@@ -50,6 +61,9 @@ func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publis
 		bloomIndexer:  core.NewBloomIndexer(chainDb, config.BloomBitsBlocks, config.BloomConfirms),
 
 		shutdownTracker: shutdowncheck.NewShutdownTracker(chainDb),
+		asmRecompiler:   state.NewAsmRecompiler(wasmStore),
+
+		compaction: NewCompactionController(&config.Compaction, chainDb, publisher.BlockChain()),
 
 		chanTxs:      make(chan *types.Transaction, 100),
 		chanClose:    make(chan struct{}),
@@ -73,21 +87,31 @@ func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publis
 	return backend, filterSystem, nil
 }
 
-func (b *Backend) AccountManager() *accounts.Manager { return b.stack.AccountManager() }
-func (b *Backend) APIBackend() *APIBackend           { return b.apiBackend }
-func (b *Backend) APIs() []rpc.API                   { return b.apiBackend.GetAPIs(b.filterSystem) }
-func (b *Backend) ArbInterface() ArbInterface        { return b.arb }
-func (b *Backend) BlockChain() *core.BlockChain      { return b.arb.BlockChain() }
-func (b *Backend) BloomIndexer() *core.ChainIndexer  { return b.bloomIndexer }
-func (b *Backend) ChainDb() ethdb.Database           { return b.chainDb }
-func (b *Backend) Engine() consensus.Engine          { return b.arb.BlockChain().Engine() }
-func (b *Backend) Stack() *node.Node                 { return b.stack }
+func (b *Backend) AccountManager() *accounts.Manager   { return b.stack.AccountManager() }
+func (b *Backend) APIBackend() *APIBackend             { return b.apiBackend }
+func (b *Backend) APIs() []rpc.API                     { return b.apiBackend.GetAPIs(b.filterSystem) }
+func (b *Backend) ArbInterface() ArbInterface          { return b.arb }
+func (b *Backend) AsmRecompiler() *state.AsmRecompiler { return b.asmRecompiler }
+func (b *Backend) BlockChain() *core.BlockChain        { return b.arb.BlockChain() }
+func (b *Backend) BloomIndexer() *core.ChainIndexer    { return b.bloomIndexer }
+func (b *Backend) ChainDb() ethdb.Database             { return b.chainDb }
+func (b *Backend) Engine() consensus.Engine            { return b.arb.BlockChain().Engine() }
+func (b *Backend) Stack() *node.Node                   { return b.stack }
+
+// CompactionController returns the controller of the database compactions, to
+// back the MaxConcurrentCompactions option of the pebble database.
+func (b *Backend) CompactionController() *CompactionController {
+	return b.compaction
+}
 
 func (b *Backend) ResetWithGenesisBlock(gb *types.Block) {
 	b.arb.BlockChain().ResetWithGenesisBlock(gb)
 }
 
 func (b *Backend) EnqueueL2Message(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	if prewarmer := b.arb.BlockChain().StatePrewarmer(); prewarmer != nil {
+		prewarmer.Prewarm(tx)
+	}
 	return b.arb.PublishTransaction(ctx, tx, options)
 }
 
@@ -100,6 +124,7 @@ func (b *Backend) Start() error {
 	b.startBloomHandlers(b.config.BloomBitsBlocks)
 	b.shutdownTracker.MarkStartup()
 	b.shutdownTracker.Start()
+	b.compaction.Start()
 
 	return nil
 }
@@ -108,6 +133,8 @@ func (b *Backend) Stop() error {
 	b.scope.Close()
 	b.bloomIndexer.Close()
 	b.shutdownTracker.Stop()
+	b.asmRecompiler.Close()
+	b.compaction.Stop()
 	b.chainDb.Close()
 	close(b.chanClose)
 	return nil
@@ -0,0 +1,338 @@
+package arbitrum
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	compactionDeferredGauge = metrics.NewRegisteredGauge("arb/compaction/deferred", nil)
+	compactionRangesMeter   = metrics.NewRegisteredMeter("arb/compaction/ranges", nil)
+	compactionTimer         = metrics.NewRegisteredTimer("arb/compaction/time", nil)
+)
+
+// Reasons for deferring the compactions
+const (
+	compactionPaused          = "paused"
+	compactionRPCLatency      = "rpc-latency"
+	compactionBlockProduction = "block-production"
+)
+
+// compactionInterval is the interval at which the compaction controller checks
+// whether the compactions are to be deferred, and runs the next queued range.
+const compactionInterval = time.Second
+
+// rpcIdleTimeout is the time after which the RPC latency is no longer considered
+// if no call was served.
+const rpcIdleTimeout = 10 * time.Second
+
+type CompactionConfig struct {
+	// LatencySLO is the moving average of the RPC serving time above which the
+	// compactions are deferred (0 = not considered)
+	LatencySLO time.Duration `koanf:"latency-slo"`
+
+	// BlockQuietPeriod is the time since the last block written, produced by the
+	// sequencer or imported, before the compactions resume (0 = not considered)
+	BlockQuietPeriod time.Duration `koanf:"block-quiet-period"`
+
+	// MaxDeferral bounds the time the compactions are deferred for by the RPC
+	// latency and the block production, operator pauses excepted (0 = unbounded)
+	MaxDeferral time.Duration `koanf:"max-deferral"`
+
+	// DeferredConcurrency is the number of concurrent automatic compactions of
+	// the database allowed while deferring
+	DeferredConcurrency int `koanf:"deferred-concurrency"`
+}
+
+func CompactionConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".latency-slo", DefaultCompactionConfig.LatencySLO, "average RPC serving time above which heavy database compactions are deferred (0 = not considered)")
+	f.Duration(prefix+".block-quiet-period", DefaultCompactionConfig.BlockQuietPeriod, "time without new blocks before deferred database compactions resume (0 = not considered)")
+	f.Duration(prefix+".max-deferral", DefaultCompactionConfig.MaxDeferral, "maximum time database compactions are deferred for by the RPC latency and block production (0 = unbounded)")
+	f.Int(prefix+".deferred-concurrency", DefaultCompactionConfig.DeferredConcurrency, "number of concurrent automatic database compactions allowed while deferring")
+}
+
+var DefaultCompactionConfig = CompactionConfig{
+	LatencySLO:          500 * time.Millisecond,
+	BlockQuietPeriod:    0,
+	MaxDeferral:         time.Hour,
+	DeferredConcurrency: 1,
+}
+
+// compactionRange is a key range of the database queued for compaction.
+type compactionRange struct {
+	start, limit []byte
+}
+
+// CompactionStatus is the state of the compaction controller, as returned by
+// the admin_compactionStatus call
+type CompactionStatus struct {
+	Deferred    bool          `json:"deferred"`
+	Reason      string        `json:"reason,omitempty"`
+	LoadedSince *time.Time    `json:"loadedSince,omitempty"`
+	Overdue     bool          `json:"overdue"`
+	PausedUntil *time.Time    `json:"pausedUntil,omitempty"`
+	RPCLatency  time.Duration `json:"rpcLatency"`
+	LastBlock   *time.Time    `json:"lastBlock,omitempty"`
+	Queued      int           `json:"queued"`
+	Compacted   uint64        `json:"compacted"`
+	Running     bool          `json:"running"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
+
+// CompactionController schedules the heavy compactions of the chain database
+// away from the periods of high RPC latency and of block production. Range
+// compactions requested over the admin namespace are queued and run one key
+// range at a time while not deferred, and the automatic compactions of pebble
+// can be throttled through MaxConcurrentCompactions.
+//
+// The compactions are deferred while the operator paused them, while the moving
+// average of the RPC serving time exceeds the latency SLO, or while blocks are
+// being written. The deferral by the load is bounded by the maximum deferral,
+// so that the compaction debt can't grow unbounded on a node always under load.
+type CompactionController struct {
+	config *CompactionConfig
+	db     ethdb.Compacter
+	chain  *core.BlockChain
+
+	lock        sync.Mutex
+	queue       []compactionRange
+	running     bool
+	compacted   uint64
+	elapsed     time.Duration
+	reason      string    // Reason the compactions are deferred for, empty if not
+	loadedSince time.Time // Time the load deferring the compactions has been sustained since
+	overdue     bool      // Whether the load has been sustained for longer than the maximum deferral
+	pausedUntil time.Time // Time the operator paused the compactions until
+	lastBlock   time.Time // Time the last block was written at
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewCompactionController(config *CompactionConfig, db ethdb.Compacter, chain *core.BlockChain) *CompactionController {
+	return &CompactionController{
+		config: config,
+		db:     db,
+		chain:  chain,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start launches the loop tracking the blocks written and running the queued
+// compactions.
+func (c *CompactionController) Start() {
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Stop terminates the loop, waiting for the compaction of the current range.
+func (c *CompactionController) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+func (c *CompactionController) loop() {
+	defer c.wg.Done()
+
+	events := make(chan core.ChainEvent, 16)
+	sub := c.chain.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-events:
+			c.lock.Lock()
+			c.lastBlock = time.Now()
+			c.lock.Unlock()
+		case <-ticker.C:
+			if next, ok := c.next(time.Now()); ok {
+				c.compact(next)
+			}
+		case <-sub.Err():
+			return
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// loadReason returns the load deferring the compactions at the given time,
+// empty if there is none. The lock must be held.
+func (c *CompactionController) loadReason(now time.Time) string {
+	if latency, last := rpc.ServeTimeStats(); c.config.LatencySLO > 0 && latency > c.config.LatencySLO && now.Sub(last) < rpcIdleTimeout {
+		return compactionRPCLatency
+	}
+	if c.config.BlockQuietPeriod > 0 && now.Sub(c.lastBlock) < c.config.BlockQuietPeriod {
+		return compactionBlockProduction
+	}
+	return ""
+}
+
+// update re-evaluates whether the compactions are deferred at the given time.
+// A load sustained for longer than the maximum deferral no longer defers them,
+// until it's relieved. The lock must be held.
+func (c *CompactionController) update(now time.Time) {
+	reason := compactionPaused
+	if !now.Before(c.pausedUntil) {
+		reason = c.loadReason(now)
+		switch {
+		case reason == "":
+			c.loadedSince = time.Time{}
+		case c.loadedSince.IsZero():
+			c.loadedSince = now
+		}
+		c.overdue = reason != "" && c.config.MaxDeferral > 0 && now.Sub(c.loadedSince) >= c.config.MaxDeferral
+		if c.overdue {
+			reason = ""
+		}
+	}
+	if reason != c.reason {
+		log.Debug("Updated database compaction deferral", "reason", reason, "previous", c.reason, "overdue", c.overdue)
+	}
+	c.reason = reason
+	if reason != "" {
+		compactionDeferredGauge.Update(1)
+	} else {
+		compactionDeferredGauge.Update(0)
+	}
+}
+
+// next pops the next range to compact, if the compactions are not deferred.
+func (c *CompactionController) next(now time.Time) (compactionRange, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.update(now)
+	if c.reason != "" || len(c.queue) == 0 {
+		return compactionRange{}, false
+	}
+	next := c.queue[0]
+	c.queue = c.queue[1:]
+	c.running = true
+	return next, true
+}
+
+// compact compacts the given range of the database.
+func (c *CompactionController) compact(r compactionRange) {
+	start := time.Now()
+	err := c.db.Compact(r.start, r.limit)
+	elapsed := time.Since(start)
+
+	c.lock.Lock()
+	c.running = false
+	c.compacted++
+	c.elapsed += elapsed
+	c.lock.Unlock()
+
+	compactionRangesMeter.Mark(1)
+	compactionTimer.Update(elapsed)
+	if err != nil {
+		log.Error("Database compaction failed", "range", fmt.Sprintf("%#x-%#x", r.start, r.limit), "err", err)
+		return
+	}
+	log.Info("Compacted database range", "range", fmt.Sprintf("%#x-%#x", r.start, r.limit), "elapsed", common.PrettyDuration(elapsed))
+}
+
+// Schedule queues the compaction of the whole database, split into the ranges
+// of the leading key bytes so that it can be deferred between them. It returns
+// the number of ranges queued.
+func (c *CompactionController) Schedule() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for b := 0; b <= 255; b++ {
+		r := compactionRange{start: []byte{byte(b)}, limit: []byte{byte(b + 1)}}
+		if b == 255 {
+			r.limit = nil
+		}
+		c.queue = append(c.queue, r)
+	}
+	return 256
+}
+
+// Pause defers the compactions for the given duration, regardless of the load.
+func (c *CompactionController) Pause(duration time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pausedUntil = time.Now().Add(duration)
+	c.update(time.Now())
+}
+
+// Resume lifts a pause of the compactions.
+func (c *CompactionController) Resume() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pausedUntil = time.Time{}
+	c.update(time.Now())
+}
+
+// Cancel drops the queued compactions, returning the number of ranges dropped.
+func (c *CompactionController) Cancel() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	dropped := len(c.queue)
+	c.queue = nil
+	return dropped
+}
+
+// MaxConcurrentCompactions limits the given number of concurrent automatic
+// compactions while the compactions are deferred. It's meant to back the
+// MaxConcurrentCompactions option of pebble, which is polled as compactions
+// are scheduled.
+func (c *CompactionController) MaxConcurrentCompactions(base int) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.reason == "" || c.config.DeferredConcurrency <= 0 {
+		return base
+	}
+	return min(base, c.config.DeferredConcurrency)
+}
+
+// Status returns the state of the controller.
+func (c *CompactionController) Status() *CompactionStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	c.update(now)
+	latency, _ := rpc.ServeTimeStats()
+	status := &CompactionStatus{
+		Deferred:   c.reason != "",
+		Reason:     c.reason,
+		RPCLatency: latency,
+		Queued:     len(c.queue),
+		Compacted:  c.compacted,
+		Running:    c.running,
+		Overdue:    c.overdue,
+		Elapsed:    c.elapsed,
+	}
+	if !c.loadedSince.IsZero() {
+		since := c.loadedSince
+		status.LoadedSince = &since
+	}
+	if now.Before(c.pausedUntil) {
+		until := c.pausedUntil
+		status.PausedUntil = &until
+	}
+	if !c.lastBlock.IsZero() {
+		last := c.lastBlock
+		status.LastBlock = &last
+	}
+	return status
+}
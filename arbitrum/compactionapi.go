@@ -0,0 +1,50 @@
+package arbitrum
+
+import (
+	"time"
+)
+
+// CompactionAPI controls the deferral of the heavy database compactions, and
+// schedules compactions of the whole database.
+type CompactionAPI struct {
+	b *APIBackend
+}
+
+func NewCompactionAPI(b *APIBackend) *CompactionAPI {
+	return &CompactionAPI{b}
+}
+
+// CompactionStatus returns whether the compactions are deferred and why, along
+// with the progress of the scheduled compactions.
+func (api *CompactionAPI) CompactionStatus() *CompactionStatus {
+	return api.b.b.CompactionController().Status()
+}
+
+// ScheduleCompaction queues the compaction of the whole database, run one key
+// range at a time while the compactions are not deferred. It returns the number
+// of ranges queued.
+func (api *CompactionAPI) ScheduleCompaction() int {
+	return api.b.b.CompactionController().Schedule()
+}
+
+// CancelCompaction drops the queued compactions, returning the number of ranges
+// dropped. The compaction of the current range, if any, runs to completion.
+func (api *CompactionAPI) CancelCompaction() int {
+	return api.b.b.CompactionController().Cancel()
+}
+
+// PauseCompactions defers the compactions for the given duration, in the format
+// of time.ParseDuration, regardless of the load.
+func (api *CompactionAPI) PauseCompactions(duration string) error {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return err
+	}
+	api.b.b.CompactionController().Pause(d)
+	return nil
+}
+
+// ResumeCompactions lifts a pause of the compactions.
+func (api *CompactionAPI) ResumeCompactions() {
+	api.b.b.CompactionController().Resume()
+}
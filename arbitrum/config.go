@@ -29,21 +29,51 @@ type Config struct {
 	FilterLogCacheSize int           `koanf:"filter-log-cache-size"`
 	FilterTimeout      time.Duration `koanf:"filter-timeout"`
 
+	// Limits of the pages of eth_getLogsPage
+	FilterLogPageSize    int           `koanf:"filter-log-page-size"`
+	FilterLogPageTimeout time.Duration `koanf:"filter-log-page-timeout"`
+
+	// PendingStateMaxAge bounds how stale the sequencer's published pending state may be
+	// to serve "pending" queries (0 = serve them from the latest block)
+	PendingStateMaxAge time.Duration `koanf:"pending-state-max-age"`
+
 	// FeeHistoryMaxBlockCount limits the number of historical blocks a fee history request may cover
 	FeeHistoryMaxBlockCount uint64 `koanf:"feehistory-max-block-count"`
 
 	ArbDebug ArbDebugConfig `koanf:"arbdebug"`
 
+	// Compaction configures the deferral of the heavy database compactions under load
+	Compaction CompactionConfig `koanf:"compaction"`
+
+	// DBAccess exposes the raw key-value stores over the debug namespace
+	DBAccess bool `koanf:"db-access"`
+
 	ClassicRedirect        string        `koanf:"classic-redirect"`
 	ClassicRedirectTimeout time.Duration `koanf:"classic-redirect-timeout"`
 	MaxRecreateStateDepth  int64         `koanf:"max-recreate-state-depth"`
 
+	// HistoricalStateURL is the archive node to read the states unavailable locally from
+	HistoricalStateURL     string        `koanf:"historical-state-url"`
+	HistoricalStateTimeout time.Duration `koanf:"historical-state-timeout"`
+
+	// StateRepairURL is the node to fetch the trie nodes missing locally from, for debug_repairState
+	StateRepairURL     string        `koanf:"state-repair-url"`
+	StateRepairTimeout time.Duration `koanf:"state-repair-timeout"`
+
+	// ReceiptsBackfillURL is the archive node to restore the pruned receipts from, for debug_backfillReceipts
+	ReceiptsBackfillURL     string        `koanf:"receipts-backfill-url"`
+	ReceiptsBackfillTimeout time.Duration `koanf:"receipts-backfill-timeout"`
+
 	AllowMethod []string `koanf:"allow-method"`
 }
 
 type ArbDebugConfig struct {
 	BlockRangeBound   uint64 `koanf:"block-range-bound"`
 	TimeoutQueueBound uint64 `koanf:"timeout-queue-bound"`
+	FilteredTxLogSize uint64 `koanf:"filtered-tx-log-size"`
+
+	// BalanceDeltaCheck checks the unexpected balance delta against the journal at the end of every transaction
+	BalanceDeltaCheck string `koanf:"balance-delta-check"`
 }
 
 func ConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -53,16 +83,29 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".evm-timeout", DefaultConfig.RPCEVMTimeout, "timeout used for eth_call (0=infinite)")
 	f.Uint64(prefix+".bloom-bits-blocks", DefaultConfig.BloomBitsBlocks, "number of blocks a single bloom bit section vector holds")
 	f.Uint64(prefix+".bloom-confirms", DefaultConfig.BloomConfirms, "number of confirmation blocks before a bloom section is considered final")
+	f.Duration(prefix+".pending-state-max-age", DefaultConfig.PendingStateMaxAge, "max age of the sequencer's pending state to serve \"pending\" queries from (0 = serve them from the latest block)")
 	f.Uint64(prefix+".feehistory-max-block-count", DefaultConfig.FeeHistoryMaxBlockCount, "max number of blocks a fee history request may cover")
 	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "url to redirect classic requests, use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
 	f.Duration(prefix+".classic-redirect-timeout", DefaultConfig.ClassicRedirectTimeout, "timeout for forwarded classic requests, where 0 = no timeout")
 	f.Int(prefix+".filter-log-cache-size", DefaultConfig.FilterLogCacheSize, "log filter system maximum number of cached blocks")
 	f.Duration(prefix+".filter-timeout", DefaultConfig.FilterTimeout, "log filter system maximum time filters stay active")
+	f.Int(prefix+".filter-log-page-size", DefaultConfig.FilterLogPageSize, "maximum number of logs returned per page by eth_getLogsPage")
+	f.Duration(prefix+".filter-log-page-timeout", DefaultConfig.FilterLogPageTimeout, "maximum time spent searching logs per page by eth_getLogsPage, before returning a cursor")
 	f.Int64(prefix+".max-recreate-state-depth", DefaultConfig.MaxRecreateStateDepth, "maximum depth for recreating state, measured in l2 gas (0=don't recreate state, -1=infinite, -2=use default value for archive or non-archive node (whichever is configured))")
+	f.String(prefix+".historical-state-url", DefaultConfig.HistoricalStateURL, "url of an archive node to read the states unavailable locally from, for eth_call, debug_trace* and other state queries (empty = disabled)")
+	f.Duration(prefix+".historical-state-timeout", DefaultConfig.HistoricalStateTimeout, "timeout of the state reads from the historical state archive node, where 0 = no timeout")
+	f.String(prefix+".state-repair-url", DefaultConfig.StateRepairURL, "url of a hash scheme node exposing debug_dbGet, to fetch the trie nodes missing locally from for debug_repairState (empty = regenerate from the snapshot only)")
+	f.Duration(prefix+".state-repair-timeout", DefaultConfig.StateRepairTimeout, "timeout of the trie node fetches from the state repair node, where 0 = no timeout")
+	f.String(prefix+".receipts-backfill-url", DefaultConfig.ReceiptsBackfillURL, "url of an archive node exposing eth_getBlockReceipts, to restore the pruned receipts from for debug_backfillReceipts (empty = disabled)")
+	f.Duration(prefix+".receipts-backfill-timeout", DefaultConfig.ReceiptsBackfillTimeout, "timeout of the receipt fetches from the receipts backfill node, where 0 = no timeout")
+	f.Bool(prefix+".db-access", DefaultConfig.DBAccess, "expose raw access to the chain and wasm databases through debug_dbGet, debug_dbAncient, debug_dbAncients, debug_dbKeys and debug_dbStats")
 	f.StringSlice(prefix+".allow-method", DefaultConfig.AllowMethod, "list of whitelisted rpc methods")
+	CompactionConfigAddOptions(prefix+".compaction", f)
 	arbDebug := DefaultConfig.ArbDebug
 	f.Uint64(prefix+".arbdebug.block-range-bound", arbDebug.BlockRangeBound, "bounds the number of blocks arbdebug calls may return")
 	f.Uint64(prefix+".arbdebug.timeout-queue-bound", arbDebug.TimeoutQueueBound, "bounds the length of timeout queues arbdebug calls may return")
+	f.Uint64(prefix+".arbdebug.filtered-tx-log-size", arbDebug.FilteredTxLogSize, "number of recently filtered transactions and per-block filter counters arbdebug calls may return")
+	f.String(prefix+".arbdebug.balance-delta-check", arbDebug.BalanceDeltaCheck, "check the unexpected balance delta against the balance changes of every transaction, to catch fee-accounting bugs: off, log (at error level) or panic")
 }
 
 const (
@@ -81,12 +124,24 @@ var DefaultConfig = Config{
 	BloomConfirms:           params.BloomConfirms,
 	FilterLogCacheSize:      32,
 	FilterTimeout:           5 * time.Minute,
+	FilterLogPageSize:       10000,
+	FilterLogPageTimeout:    5 * time.Second,
+	PendingStateMaxAge:      time.Second,
 	FeeHistoryMaxBlockCount: 1024,
 	ClassicRedirect:         "",
 	MaxRecreateStateDepth:   UninitializedMaxRecreateStateDepth, // default value should be set for depending on node type (archive / non-archive)
+	HistoricalStateURL:      "",
+	HistoricalStateTimeout:  10 * time.Second,
+	StateRepairURL:          "",
+	StateRepairTimeout:      10 * time.Second,
+	ReceiptsBackfillURL:     "",
+	ReceiptsBackfillTimeout: 10 * time.Second,
 	AllowMethod:             []string{},
+	DBAccess:                false,
+	Compaction:              DefaultCompactionConfig,
 	ArbDebug: ArbDebugConfig{
 		BlockRangeBound:   256,
 		TimeoutQueueBound: 512,
+		FilteredTxLogSize: 4096,
 	},
 }
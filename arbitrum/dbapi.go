@@ -0,0 +1,138 @@
+package arbitrum
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// maxDBKeysLimit bounds the number of keys a single debug_dbKeys call may return
+const maxDBKeysLimit = 1024
+
+// DBKeysResult is the result of a debug_dbKeys call. Next is the position
+// relative to the prefix to continue the iteration from, if any.
+type DBKeysResult struct {
+	Keys []hexutil.Bytes `json:"keys"`
+	Next hexutil.Bytes   `json:"next,omitempty"`
+}
+
+// DBPrefixStats holds the number and total size of the entries under a key prefix
+type DBPrefixStats struct {
+	Prefix    hexutil.Bytes  `json:"prefix"`
+	Count     hexutil.Uint64 `json:"count"`
+	KeySize   hexutil.Uint64 `json:"keySize"`
+	ValueSize hexutil.Uint64 `json:"valueSize"`
+}
+
+// DBDebugAPI provides operators raw read access to the chain database and the
+// separate wasm store. It is only registered if explicitly enabled, and takes
+// the place of the upstream debug_dbGet, debug_dbAncient and debug_dbAncients,
+// which Arbitrum nodes don't register otherwise.
+type DBDebugAPI struct {
+	b *APIBackend
+}
+
+func NewDBDebugAPI(b *APIBackend) *DBDebugAPI {
+	return &DBDebugAPI{b}
+}
+
+// store resolves the key-value store selected by name, defaulting to the chain database
+func (api *DBDebugAPI) store(name *string) (ethdb.KeyValueStore, error) {
+	if name == nil || *name == "" || *name == "chain" {
+		return api.b.ChainDb(), nil
+	}
+	if *name == "wasm" {
+		wasmStore, _ := api.b.ChainDb().WasmDataBase()
+		return wasmStore, nil
+	}
+	return nil, fmt.Errorf("unknown database %q, expected \"chain\" or \"wasm\"", *name)
+}
+
+// DbGet returns the raw value of a key stored in the database.
+func (api *DBDebugAPI) DbGet(key string, store *string) (hexutil.Bytes, error) {
+	blob, err := common.ParseHexOrString(key)
+	if err != nil {
+		return nil, err
+	}
+	db, err := api.store(store)
+	if err != nil {
+		return nil, err
+	}
+	return db.Get(blob)
+}
+
+// DbAncient retrieves an ancient binary blob from the append-only immutable files.
+func (api *DBDebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error) {
+	return api.b.ChainDb().Ancient(kind, number)
+}
+
+// DbAncients returns the ancient item numbers in the ancient store.
+func (api *DBDebugAPI) DbAncients() (uint64, error) {
+	return api.b.ChainDb().Ancients()
+}
+
+// DbKeys returns up to limit keys with the given prefix, starting at the given
+// position relative to the prefix.
+func (api *DBDebugAPI) DbKeys(ctx context.Context, prefix hexutil.Bytes, start hexutil.Bytes, limit int, store *string) (*DBKeysResult, error) {
+	if limit <= 0 || limit > maxDBKeysLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxDBKeysLimit)
+	}
+	db, err := api.store(store)
+	if err != nil {
+		return nil, err
+	}
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	result := &DBKeysResult{Keys: []hexutil.Bytes{}}
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(result.Keys) == limit {
+			result.Next = common.CopyBytes(it.Key()[len(prefix):])
+			break
+		}
+		result.Keys = append(result.Keys, common.CopyBytes(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DbStats reports the number and total size of the entries under each of the
+// given prefixes. An empty prefix covers the whole database.
+func (api *DBDebugAPI) DbStats(ctx context.Context, prefixes []hexutil.Bytes, store *string) ([]DBPrefixStats, error) {
+	db, err := api.store(store)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		prefixes = []hexutil.Bytes{{}}
+	}
+	results := make([]DBPrefixStats, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		stats := DBPrefixStats{Prefix: prefix}
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			if err := ctx.Err(); err != nil {
+				it.Release()
+				return nil, err
+			}
+			stats.Count++
+			stats.KeySize += hexutil.Uint64(len(it.Key()))
+			stats.ValueSize += hexutil.Uint64(len(it.Value()))
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, stats)
+	}
+	return results, nil
+}
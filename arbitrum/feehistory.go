@@ -0,0 +1,97 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ArbFeeHistoryResult extends the eth_feeHistory result with the L1 data fee
+// components of the Arbitrum gas model. The L1 fields hold one entry per block
+// of the range, while baseFeePerGas also predicts the next block's base fee.
+type ArbFeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+
+	// L1BaseFeeEstimate is ArbOS's estimate of the L1 base fee, in wei per
+	// unit of L1 calldata.
+	L1BaseFeeEstimate []*hexutil.Big `json:"l1BaseFeeEstimate"`
+	// L1CalldataPrice is the price charged per byte of compressed transaction
+	// data posted to L1.
+	L1CalldataPrice []*hexutil.Big `json:"l1CalldataPricePerByte"`
+	// L2GasPerL1Byte is the amount of L2 gas a transaction is charged per byte
+	// of compressed data at the block's base fee.
+	L2GasPerL1Byte []hexutil.Uint64 `json:"l2GasPerL1Byte"`
+}
+
+type ArbFeeHistoryAPI struct {
+	b *APIBackend
+}
+
+func NewArbFeeHistoryAPI(b *APIBackend) *ArbFeeHistoryAPI {
+	return &ArbFeeHistoryAPI{b}
+}
+
+// FeeHistory returns the fee market history of the given block range like
+// eth_feeHistory, together with the L1 pricing ArbOS applied in each block, so
+// clients can predict both the L2 execution and the L1 posting cost of a tx.
+func (api *ArbFeeHistoryAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*ArbFeeHistoryResult, error) {
+	if core.GetL1PricingInfo == nil {
+		return nil, errors.New("ArbOS not installed")
+	}
+	oldest, reward, baseFee, gasUsed, _, _, err := api.b.FeeHistory(ctx, uint64(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	results := &ArbFeeHistoryResult{
+		OldestBlock:       (*hexutil.Big)(oldest),
+		GasUsedRatio:      gasUsed,
+		L1BaseFeeEstimate: make([]*hexutil.Big, len(gasUsed)),
+		L1CalldataPrice:   make([]*hexutil.Big, len(gasUsed)),
+		L2GasPerL1Byte:    make([]hexutil.Uint64, len(gasUsed)),
+	}
+	if reward != nil {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, w := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				results.Reward[i][j] = (*hexutil.Big)(v)
+			}
+		}
+	}
+	if baseFee != nil {
+		results.BaseFee = make([]*hexutil.Big, len(baseFee))
+		for i, v := range baseFee {
+			results.BaseFee[i] = (*hexutil.Big)(v)
+		}
+	}
+	for i := range gasUsed {
+		number := rpc.BlockNumber(oldest.Int64() + int64(i))
+		statedb, header, err := api.b.StateAndHeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		info, err := core.GetL1PricingInfo(statedb, header)
+		if err != nil {
+			return nil, err
+		}
+		calldataPrice := new(big.Int).Mul(info.PricePerUnit, new(big.Int).SetUint64(info.UnitsPerByte))
+		results.L1BaseFeeEstimate[i] = (*hexutil.Big)(info.PricePerUnit)
+		results.L1CalldataPrice[i] = (*hexutil.Big)(calldataPrice)
+		if header.BaseFee != nil && header.BaseFee.Sign() > 0 {
+			gasPerByte := new(big.Int).Div(calldataPrice, header.BaseFee)
+			if !gasPerByte.IsUint64() {
+				return nil, errors.New("L2 gas per L1 byte overflows")
+			}
+			results.L2GasPerL1Byte[i] = hexutil.Uint64(gasPerByte.Uint64())
+		}
+	}
+	return results, nil
+}
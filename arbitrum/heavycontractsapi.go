@@ -0,0 +1,65 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// heavyContractsDefault is the number of contracts returned by
+// debug_heavyContracts unless specified.
+const heavyContractsDefault = 20
+
+var errHeavyContractsDisabled = errors.New("storage write tracking is disabled")
+
+// ContractWrites is a contract with its storage writes over the recent blocks
+type ContractWrites struct {
+	Address common.Address `json:"address"`
+	Writes  hexutil.Uint64 `json:"writes"`
+	Blocks  hexutil.Uint64 `json:"blocks"`
+}
+
+// HeavyContractsResult is the result of a debug_heavyContracts call
+type HeavyContractsResult struct {
+	Window    hexutil.Uint64   `json:"window"`
+	Blocks    hexutil.Uint64   `json:"blocks"`
+	Contracts []ContractWrites `json:"contracts"`
+}
+
+// HeavyContractsAPI exposes the contracts writing the most storage over the
+// recent blocks, driving the state growth.
+type HeavyContractsAPI struct {
+	b *APIBackend
+}
+
+func NewHeavyContractsAPI(b *APIBackend) *HeavyContractsAPI {
+	return &HeavyContractsAPI{b}
+}
+
+// HeavyContracts returns the given number of contracts with the most storage
+// writes over the rolling window of recent blocks, by decreasing writes.
+func (api *HeavyContractsAPI) HeavyContracts(count *hexutil.Uint64) (*HeavyContractsResult, error) {
+	tracker := api.b.BlockChain().HeavyContracts()
+	if tracker == nil {
+		return nil, errHeavyContractsDisabled
+	}
+	n := heavyContractsDefault
+	if count != nil {
+		n = int(*count)
+	}
+	window, blocks := tracker.Window()
+	result := &HeavyContractsResult{
+		Window:    hexutil.Uint64(window),
+		Blocks:    hexutil.Uint64(blocks),
+		Contracts: []ContractWrites{},
+	}
+	for _, contract := range tracker.Top(n) {
+		result.Contracts = append(result.Contracts, ContractWrites{
+			Address: contract.Address,
+			Writes:  hexutil.Uint64(contract.Writes),
+			Blocks:  hexutil.Uint64(contract.Blocks),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package arbitrum
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+// historicalStateBlocks is the number of state roots whose block hashes are
+// remembered to query the remote archive with.
+const historicalStateBlocks = 1024
+
+// headerRecorder is implemented by the historical state providers that need to
+// know the block a state root belongs to, to query their backend with.
+type headerRecorder interface {
+	RecordHeader(header *types.Header)
+}
+
+// RPCHistoricalState is a historical state provider proxying the state reads
+// to a remote archive node over JSON-RPC (HTTP, WebSocket or IPC), using the
+// standard eth_getProof, eth_getStorageAt and eth_getCode methods.
+type RPCHistoricalState struct {
+	client  *rpc.Client
+	timeout time.Duration
+	blocks  *lru.Cache[common.Hash, common.Hash] // Block hashes of the state roots served
+}
+
+// NewRPCHistoricalState dials the archive node at the given URL. A zero timeout
+// means state reads don't time out.
+func NewRPCHistoricalState(url string, timeout time.Duration) (*RPCHistoricalState, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating historical state connection: %w", err)
+	}
+	return &RPCHistoricalState{
+		client:  client,
+		timeout: timeout,
+		blocks:  lru.NewCache[common.Hash, common.Hash](historicalStateBlocks),
+	}, nil
+}
+
+// RecordHeader remembers the block of the state root of the given header.
+func (p *RPCHistoricalState) RecordHeader(header *types.Header) {
+	p.blocks.Add(header.Root, header.Hash())
+}
+
+func (p *RPCHistoricalState) call(root common.Hash, result interface{}, method string, args ...interface{}) error {
+	hash, ok := p.blocks.Get(root)
+	if !ok {
+		return fmt.Errorf("no block known for historical state %x", root)
+	}
+	ctx := context.Background()
+	if p.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return p.client.CallContext(ctx, result, method, append(args, rpc.BlockNumberOrHashWithHash(hash, true))...)
+}
+
+func (p *RPCHistoricalState) Account(root common.Hash, addr common.Address) (*types.StateAccount, error) {
+	var res struct {
+		Balance     *hexutil.Big   `json:"balance"`
+		Nonce       hexutil.Uint64 `json:"nonce"`
+		CodeHash    common.Hash    `json:"codeHash"`
+		StorageHash common.Hash    `json:"storageHash"`
+	}
+	if err := p.call(root, &res, "eth_getProof", addr, []string{}); err != nil {
+		return nil, err
+	}
+	if res.Balance == nil {
+		return nil, fmt.Errorf("historical account %v without balance", addr)
+	}
+	balance, overflow := uint256.FromBig(res.Balance.ToInt())
+	if overflow {
+		return nil, fmt.Errorf("historical account %v balance overflow", addr)
+	}
+	account := &types.StateAccount{
+		Nonce:    uint64(res.Nonce),
+		Balance:  balance,
+		Root:     res.StorageHash,
+		CodeHash: res.CodeHash.Bytes(),
+	}
+	if account.Root == (common.Hash{}) {
+		account.Root = types.EmptyRootHash
+	}
+	if res.CodeHash == (common.Hash{}) {
+		account.CodeHash = types.EmptyCodeHash.Bytes()
+	}
+	// Proofs of missing accounts report an empty account
+	if account.Nonce == 0 && account.Balance.IsZero() && account.Root == types.EmptyRootHash && res.CodeHash == (common.Hash{}) {
+		return nil, nil
+	}
+	return account, nil
+}
+
+func (p *RPCHistoricalState) Storage(root common.Hash, addr common.Address, slot common.Hash) (common.Hash, error) {
+	var res hexutil.Bytes
+	if err := p.call(root, &res, "eth_getStorageAt", addr, slot); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(res), nil
+}
+
+func (p *RPCHistoricalState) Code(root common.Hash, addr common.Address, codeHash common.Hash) ([]byte, error) {
+	var res hexutil.Bytes
+	if err := p.call(root, &res, "eth_getCode", addr); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetHistoricalStateProvider makes the states unavailable locally be served by
+// the given provider, for eth_call, debug_trace* and the other state queries.
+func (a *APIBackend) SetHistoricalStateProvider(provider state.HistoricalStateProvider) {
+	a.historicalProvider = provider
+	a.historicalState = state.NewHistoricalDatabase(a.BlockChain().StateCache(), provider)
+}
+
+// recordHistoricalHeader makes the historical state provider aware of the
+// block of the given header, before its state is opened.
+func (a *APIBackend) recordHistoricalHeader(header *types.Header) {
+	if recorder, ok := a.historicalProvider.(headerRecorder); ok && header != nil {
+		recorder.RecordHeader(header)
+	}
+}
+
+// historicalStateAndHeader serves the state of the given header from the
+// historical state provider, if the local lookup failed with the given error.
+func (a *APIBackend) historicalStateAndHeader(header *types.Header, statedb *state.StateDB, resHeader *types.Header, err error) (*state.StateDB, *types.Header, error) {
+	if err == nil || a.historicalState == nil || header == nil || err == types.ErrUseFallback {
+		return statedb, resHeader, err
+	}
+	a.recordHistoricalHeader(header)
+	historical, herr := state.New(header.Root, a.historicalState, nil)
+	if herr != nil {
+		return nil, nil, err
+	}
+	return historical, header, nil
+}
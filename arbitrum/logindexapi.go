@@ -0,0 +1,87 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var errLogIndexDisabled = errors.New("log index is disabled")
+
+// LogIndexStatus is the result of the debug_logIndexStatus, debug_backfillLogIndex
+// and debug_pruneLogIndex calls
+type LogIndexStatus struct {
+	Tail    hexutil.Uint64 `json:"tail"`
+	Head    hexutil.Uint64 `json:"head"`
+	Blocks  hexutil.Uint64 `json:"blocks"`
+	Entries hexutil.Uint64 `json:"entries"`
+}
+
+// LogIndexAPI exposes the range covered by the exact log index, and backfills
+// and prunes it.
+type LogIndexAPI struct {
+	b *APIBackend
+}
+
+func NewLogIndexAPI(b *APIBackend) *LogIndexAPI {
+	return &LogIndexAPI{b}
+}
+
+func (api *LogIndexAPI) index() (*core.LogIndex, error) {
+	index := api.b.BlockChain().LogIndex()
+	if index == nil {
+		return nil, errLogIndexDisabled
+	}
+	return index, nil
+}
+
+func newLogIndexStatus(status *core.LogIndexStatus) *LogIndexStatus {
+	return &LogIndexStatus{
+		Tail:    hexutil.Uint64(status.Tail),
+		Head:    hexutil.Uint64(status.Head),
+		Blocks:  hexutil.Uint64(status.Blocks),
+		Entries: hexutil.Uint64(status.Entries),
+	}
+}
+
+// LogIndexStatus returns the range of blocks covered by the log index.
+func (api *LogIndexAPI) LogIndexStatus() (*LogIndexStatus, error) {
+	index, err := api.index()
+	if err != nil {
+		return nil, err
+	}
+	status, err := index.Status()
+	if err != nil {
+		return nil, err
+	}
+	return newLogIndexStatus(status), nil
+}
+
+// BackfillLogIndex indexes the canonical blocks below the tail of the log index
+// down to the given block.
+func (api *LogIndexAPI) BackfillLogIndex(ctx context.Context, from hexutil.Uint64) (*LogIndexStatus, error) {
+	index, err := api.index()
+	if err != nil {
+		return nil, err
+	}
+	status, err := index.Backfill(ctx, uint64(from))
+	if err != nil {
+		return nil, err
+	}
+	return newLogIndexStatus(status), nil
+}
+
+// PruneLogIndex deletes the log index entries of the blocks below the given one.
+func (api *LogIndexAPI) PruneLogIndex(before hexutil.Uint64) (*LogIndexStatus, error) {
+	index, err := api.index()
+	if err != nil {
+		return nil, err
+	}
+	status, err := index.Prune(uint64(before))
+	if err != nil {
+		return nil, err
+	}
+	return newLogIndexStatus(status), nil
+}
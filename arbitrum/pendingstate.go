@@ -0,0 +1,64 @@
+package arbitrum
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// pendingState holds the in-flight state of the block the sequencer is
+// currently building, so that "pending" queries can observe transactions
+// which were already sequenced but not yet included in a block.
+type pendingState struct {
+	mu        sync.Mutex
+	header    *types.Header
+	statedb   *state.StateDB
+	published time.Time
+}
+
+// PublishPendingState makes the state of the block being built visible to
+// RPC calls using the "pending" block tag. The header describes the block
+// being built. The state is copied, so the caller may keep modifying it.
+func (a *APIBackend) PublishPendingState(header *types.Header, statedb *state.StateDB) {
+	if a.b.config.PendingStateMaxAge == 0 {
+		return
+	}
+	header = types.CopyHeader(header)
+	statedb = statedb.Copy()
+
+	a.pending.mu.Lock()
+	defer a.pending.mu.Unlock()
+	a.pending.header = header
+	a.pending.statedb = statedb
+	a.pending.published = time.Now()
+}
+
+// ClearPendingState drops the published pending state, e.g. once the block
+// being built has been sealed.
+func (a *APIBackend) ClearPendingState() {
+	a.pending.mu.Lock()
+	defer a.pending.mu.Unlock()
+	a.pending.header = nil
+	a.pending.statedb = nil
+}
+
+// pendingStateAndHeader returns a copy of the published pending state, or nil
+// if there is none or it is stale. A pending state is stale if it is older
+// than the configured bound or doesn't build on top of the current head.
+func (a *APIBackend) pendingStateAndHeader() (*state.StateDB, *types.Header) {
+	maxAge := a.b.config.PendingStateMaxAge
+	if maxAge == 0 {
+		return nil, nil
+	}
+	a.pending.mu.Lock()
+	defer a.pending.mu.Unlock()
+	if a.pending.statedb == nil || time.Since(a.pending.published) > maxAge {
+		return nil, nil
+	}
+	if a.pending.header.ParentHash != a.BlockChain().CurrentBlock().Hash() {
+		return nil, nil
+	}
+	return a.pending.statedb.Copy(), types.CopyHeader(a.pending.header)
+}
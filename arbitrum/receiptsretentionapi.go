@@ -0,0 +1,133 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errReceiptsRetentionDisabled = errors.New("receipts retention is disabled")
+	errNoReceiptsBackfillSource  = errors.New("no receipts backfill source configured")
+)
+
+// RPCReceiptsSource fetches the receipts pruned locally from an archive node,
+// through its eth_getBlockReceipts method.
+type RPCReceiptsSource struct {
+	client  *rpc.Client
+	timeout time.Duration
+}
+
+// NewRPCReceiptsSource dials the node at the given URL. A zero timeout means
+// receipt fetches don't time out.
+func NewRPCReceiptsSource(url string, timeout time.Duration) (*RPCReceiptsSource, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating receipts backfill connection: %w", err)
+	}
+	return &RPCReceiptsSource{client: client, timeout: timeout}, nil
+}
+
+func (s *RPCReceiptsSource) BlockReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if s.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	var receipts types.Receipts
+	if err := s.client.CallContext(ctx, &receipts, "eth_getBlockReceipts", hash.Hex()); err != nil {
+		return nil, err
+	}
+	if receipts == nil {
+		return nil, fmt.Errorf("block %v not found", hash)
+	}
+	return receipts, nil
+}
+
+// ReceiptsRetentionStatus is the result of the debug_receiptsRetentionStatus,
+// debug_pruneReceipts and debug_backfillReceipts calls
+type ReceiptsRetentionStatus struct {
+	Tail   hexutil.Uint64 `json:"tail"`
+	Head   hexutil.Uint64 `json:"head"`
+	Cutoff hexutil.Uint64 `json:"cutoff"`
+	Blocks hexutil.Uint64 `json:"blocks"`
+}
+
+// ReceiptsRetentionAPI exposes the range of blocks with retained receipts, and
+// prunes and backfills it.
+type ReceiptsRetentionAPI struct {
+	b *APIBackend
+}
+
+func NewReceiptsRetentionAPI(b *APIBackend) *ReceiptsRetentionAPI {
+	return &ReceiptsRetentionAPI{b}
+}
+
+func (api *ReceiptsRetentionAPI) retention() (*core.ReceiptsRetention, error) {
+	retention := api.b.BlockChain().ReceiptsRetention()
+	if retention == nil {
+		return nil, errReceiptsRetentionDisabled
+	}
+	return retention, nil
+}
+
+func newReceiptsRetentionStatus(retention *core.ReceiptsRetention, status *core.ReceiptsRetentionStatus) *ReceiptsRetentionStatus {
+	return &ReceiptsRetentionStatus{
+		Tail:   hexutil.Uint64(status.Tail),
+		Head:   hexutil.Uint64(status.Head),
+		Cutoff: hexutil.Uint64(retention.Cutoff()),
+		Blocks: hexutil.Uint64(status.Blocks),
+	}
+}
+
+// ReceiptsRetentionStatus returns the range of blocks with retained receipts,
+// and the oldest block within the retention window.
+func (api *ReceiptsRetentionAPI) ReceiptsRetentionStatus() (*ReceiptsRetentionStatus, error) {
+	retention, err := api.retention()
+	if err != nil {
+		return nil, err
+	}
+	return newReceiptsRetentionStatus(retention, retention.Status()), nil
+}
+
+// PruneReceipts deletes the receipts of the blocks below the given one, or
+// below the retention window if none is given.
+func (api *ReceiptsRetentionAPI) PruneReceipts(before *hexutil.Uint64) (*ReceiptsRetentionStatus, error) {
+	retention, err := api.retention()
+	if err != nil {
+		return nil, err
+	}
+	tail := retention.Cutoff()
+	if before != nil {
+		tail = uint64(*before)
+	}
+	status, err := retention.Prune(tail)
+	if err != nil {
+		return nil, err
+	}
+	return newReceiptsRetentionStatus(retention, status), nil
+}
+
+// BackfillReceipts restores the receipts of the canonical blocks below the
+// receipts tail down to the given block, from the configured archive node.
+func (api *ReceiptsRetentionAPI) BackfillReceipts(ctx context.Context, from hexutil.Uint64) (*ReceiptsRetentionStatus, error) {
+	retention, err := api.retention()
+	if err != nil {
+		return nil, err
+	}
+	if api.b.receiptsSource == nil {
+		return nil, errNoReceiptsBackfillSource
+	}
+	status, err := retention.Backfill(ctx, api.b.receiptsSource, uint64(from))
+	if err != nil {
+		return nil, err
+	}
+	return newReceiptsRetentionStatus(retention, status), nil
+}
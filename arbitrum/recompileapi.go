@@ -0,0 +1,50 @@
+package arbitrum
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// StylusRecompileStatus is the result of an admin_stylusRecompileStatus call
+type StylusRecompileStatus struct {
+	Running   bool           `json:"running"`
+	Pending   hexutil.Uint64 `json:"pending"`
+	Compiled  hexutil.Uint64 `json:"compiled"`
+	Failed    hexutil.Uint64 `json:"failed"`
+	LastError string         `json:"lastError,omitempty"`
+}
+
+// StylusRecompileAPI backfills the wasm store with the asm of the activated
+// Stylus modules for additional targets.
+type StylusRecompileAPI struct {
+	recompiler *state.AsmRecompiler
+}
+
+func NewStylusRecompileAPI(recompiler *state.AsmRecompiler) *StylusRecompileAPI {
+	return &StylusRecompileAPI{recompiler}
+}
+
+// RecompileStylusTargets queues the compilation of the given modules, or of all
+// the activated modules if none is given, for the targets missing from the wasm
+// store. It returns the number of compilations queued.
+func (api *StylusRecompileAPI) RecompileStylusTargets(targets []ethdb.WasmTarget, moduleHashes []common.Hash) (hexutil.Uint64, error) {
+	queued, err := api.recompiler.Enqueue(targets, moduleHashes)
+	return hexutil.Uint64(queued), err
+}
+
+// StylusRecompileStatus reports the backlog of the recompilation.
+func (api *StylusRecompileAPI) StylusRecompileStatus() (*StylusRecompileStatus, error) {
+	status, err := api.recompiler.Status()
+	if err != nil {
+		return nil, err
+	}
+	return &StylusRecompileStatus{
+		Running:   status.Running,
+		Pending:   hexutil.Uint64(status.Pending),
+		Compiled:  hexutil.Uint64(status.Compiled),
+		Failed:    hexutil.Uint64(status.Failed),
+		LastError: status.LastError,
+	}, nil
+}
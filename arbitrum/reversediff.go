@@ -0,0 +1,76 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errReverseDiffNotRetained = errors.New("state diff of the block is not retained")
+
+type ArbReverseDiffAPI struct {
+	b *APIBackend
+}
+
+func NewArbReverseDiffAPI(b *APIBackend) *ArbReverseDiffAPI {
+	return &ArbReverseDiffAPI{b}
+}
+
+// GetReverseDiff returns the original and resulting values of the accounts and
+// storage slots mutated by the given block, as retained in memory by the chain.
+func (api *ArbReverseDiffAPI) GetReverseDiff(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.ReverseDiff, error) {
+	header, err := api.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	diff := api.b.BlockChain().ReverseDiff(header.Hash())
+	if diff == nil {
+		return nil, errReverseDiffNotRetained
+	}
+	return diff, nil
+}
+
+// ReverseDiffNotification is a state diff notified by a reverse diffs
+// subscription, flagged as removed if the block was reorged out.
+type ReverseDiffNotification struct {
+	*state.ReverseDiff
+	Removed bool `json:"removed,omitempty"`
+}
+
+// ReverseDiffs creates a subscription that fires with the state diff of every
+// canonical block imported, so that replicas can follow the chain by applying
+// the diffs, and again flagged as removed for the blocks reorged out, for the
+// replicas to revert them.
+func (api *ArbReverseDiffAPI) ReverseDiffs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := make(chan core.StateChangesEvent, 16)
+		changesSub := api.b.BlockChain().SubscribeStateChangesEvent(changes)
+		defer changesSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-changes:
+				notifier.Notify(rpcSub.ID, &ReverseDiffNotification{
+					ReverseDiff: ev.Changes.ReverseDiff(ev.Header.Number.Uint64(), ev.Header.Hash()),
+					Removed:     ev.Removed,
+				})
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
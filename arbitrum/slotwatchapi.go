@@ -0,0 +1,125 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errUnknownSlotWatch = errors.New("unknown slot watch")
+
+// WatchedSlot is a storage slot of a contract to watch
+type WatchedSlot struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+}
+
+// SlotChange is a change of a watched storage slot by a canonical block,
+// notified again as removed if the block is reorged out
+type SlotChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Removed     bool           `json:"removed,omitempty"`
+	Address     common.Address `json:"address"`
+	Slot        common.Hash    `json:"slot"`
+	Old         common.Hash    `json:"old"`
+	New         common.Hash    `json:"new"`
+}
+
+// ArbSlotWatchAPI lets the clients watch storage slots across blocks, notifying
+// the changes of the slots with their old and new values as the canonical
+// blocks are imported, without going through the logs.
+type ArbSlotWatchAPI struct {
+	b *APIBackend
+}
+
+func NewArbSlotWatchAPI(b *APIBackend) *ArbSlotWatchAPI {
+	return &ArbSlotWatchAPI{b}
+}
+
+// WatchSlots registers a watch of the given storage slots, returning its
+// identifier to subscribe to the changes of the slots with.
+func (api *ArbSlotWatchAPI) WatchSlots(slots []WatchedSlot) (hexutil.Uint64, error) {
+	watched := make([]core.WatchedSlot, len(slots))
+	for i, slot := range slots {
+		watched[i] = core.WatchedSlot{Address: slot.Address, Slot: slot.Slot}
+	}
+	id, err := api.b.BlockChain().SlotWatches().Watch(watched)
+	return hexutil.Uint64(id), err
+}
+
+// UnwatchSlots removes the given watch, returning whether it existed.
+func (api *ArbSlotWatchAPI) UnwatchSlots(id hexutil.Uint64) bool {
+	return api.b.BlockChain().SlotWatches().Unwatch(uint64(id))
+}
+
+// WatchedSlots returns the storage slots of the given watch.
+func (api *ArbSlotWatchAPI) WatchedSlots(id hexutil.Uint64) ([]WatchedSlot, error) {
+	watched := api.b.BlockChain().SlotWatches().Slots(uint64(id))
+	if watched == nil {
+		return nil, errUnknownSlotWatch
+	}
+	slots := make([]WatchedSlot, len(watched))
+	for i, slot := range watched {
+		slots[i] = WatchedSlot{Address: slot.Address, Slot: slot.Slot}
+	}
+	return slots, nil
+}
+
+// SlotChanges creates a subscription notifying the changes of the slots of the
+// given watch by the canonical blocks, a notification per changed slot, and
+// again flagged as removed if the block is reorged out. The watch outlives the
+// subscription until removed.
+func (api *ArbSlotWatchAPI) SlotChanges(ctx context.Context, id hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	watches := api.b.BlockChain().SlotWatches()
+	if watches.Slots(uint64(id)) == nil {
+		return nil, errUnknownSlotWatch
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.SlotChangesEvent, 128)
+		sub := watches.SubscribeSlotChangesEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				for _, change := range ev.Changes {
+					if !slices.Contains(change.Watches, uint64(id)) {
+						continue
+					}
+					notification := &SlotChange{
+						BlockNumber: hexutil.Uint64(ev.Header.Number.Uint64()),
+						BlockHash:   ev.Header.Hash(),
+						Removed:     ev.Removed,
+						Address:     change.Address,
+						Slot:        change.Slot,
+						Old:         change.Old,
+						New:         change.New,
+					}
+					if err := notifier.Notify(rpcSub.ID, notification); err != nil {
+						log.Debug("Failed to notify slot change", "err", err)
+						return
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -0,0 +1,184 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultSnapshotBatchSize is the number of accounts per notification of a
+	// snapshot accounts subscription, unless configured otherwise.
+	defaultSnapshotBatchSize = 256
+
+	// maxSnapshotBatchSize is the upper bound on the accounts per notification.
+	maxSnapshotBatchSize = 4096
+)
+
+var errSnapshotsDisabled = errors.New("snapshots are disabled")
+
+// SnapshotAccountsOptions configures a debug_subscribe("snapshotAccounts") call
+type SnapshotAccountsOptions struct {
+	Start     common.Hash    `json:"start"`     // Account hash to start (or resume) the iteration from
+	Storage   bool           `json:"storage"`   // Whether to include the storage of the accounts
+	BatchSize hexutil.Uint64 `json:"batchSize"` // Number of accounts per notification
+	RateLimit hexutil.Uint64 `json:"rateLimit"` // Maximum number of accounts per second, 0 for no limit
+}
+
+// SnapshotAccount is an account of the flat snapshot
+type SnapshotAccount struct {
+	Hash     common.Hash                   `json:"hash"`
+	Address  *common.Address               `json:"address,omitempty"` // Absent if the preimage is unknown
+	Nonce    hexutil.Uint64                `json:"nonce"`
+	Balance  *hexutil.Big                  `json:"balance"`
+	Root     common.Hash                   `json:"root"`
+	CodeHash common.Hash                   `json:"codeHash"`
+	Storage  map[common.Hash]hexutil.Bytes `json:"storage,omitempty"` // Values by slot hash
+}
+
+// SnapshotAccountsBatch is a notification of a snapshot accounts subscription.
+// The last notification has no next account, or carries the error the iteration
+// failed with, along with the account to resume from if known.
+type SnapshotAccountsBatch struct {
+	Accounts []*SnapshotAccount `json:"accounts"`
+	Next     *common.Hash       `json:"next"`            // Hash of the next account, nil if complete
+	Error    string             `json:"error,omitempty"` // Reason the iteration stopped early
+}
+
+// SnapshotIteratorAPI streams the accounts of the flat snapshot, so the full
+// state inventory can be taken straight from a live node.
+type SnapshotIteratorAPI struct {
+	b *APIBackend
+}
+
+func NewSnapshotIteratorAPI(b *APIBackend) *SnapshotIteratorAPI {
+	return &SnapshotIteratorAPI{b}
+}
+
+// SnapshotAccounts creates a subscription iterating the accounts of the flat
+// snapshot at the given state root, by increasing account hash, optionally with
+// their storage. The accounts are notified in batches, at most at the configured
+// rate; the subscription can be resumed from the next account of any batch.
+func (api *SnapshotIteratorAPI) SnapshotAccounts(ctx context.Context, root common.Hash, opts *SnapshotAccountsOptions) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if opts == nil {
+		opts = new(SnapshotAccountsOptions)
+	}
+	snaps := api.b.BlockChain().Snapshots()
+	if snaps == nil {
+		return nil, errSnapshotsDisabled
+	}
+	it, err := snaps.AccountIterator(root, opts.Start)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := int(opts.BatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultSnapshotBatchSize
+	}
+	batchSize = min(batchSize, maxSnapshotBatchSize)
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer it.Release()
+
+		var (
+			db       = api.b.ChainDb()
+			start    = time.Now()
+			accounts uint64
+			pending  = it.Next()
+		)
+		for {
+			batch := &SnapshotAccountsBatch{Accounts: []*SnapshotAccount{}}
+			for pending && len(batch.Accounts) < batchSize {
+				account, err := newSnapshotAccount(db, snaps, root, it.Hash(), it.Account(), opts.Storage)
+				if err != nil {
+					batch.Error = err.Error()
+					break
+				}
+				batch.Accounts = append(batch.Accounts, account)
+				pending = it.Next()
+			}
+			if err := it.Error(); err != nil && batch.Error == "" {
+				batch.Error = err.Error()
+			}
+			if pending {
+				next := it.Hash()
+				batch.Next = &next
+			}
+			if err := notifier.Notify(rpcSub.ID, batch); err != nil {
+				log.Debug("Failed to notify snapshot accounts", "err", err)
+				return
+			}
+			if !pending || batch.Error != "" {
+				return
+			}
+			accounts += uint64(len(batch.Accounts))
+
+			// Throttle the iteration to the configured rate
+			var wait time.Duration
+			if opts.RateLimit > 0 {
+				wait = time.Duration(accounts)*time.Second/time.Duration(opts.RateLimit) - time.Since(start)
+			}
+			select {
+			case <-time.After(max(wait, 0)):
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// newSnapshotAccount decodes the given slim account of the snapshot, along with
+// its storage if requested.
+func newSnapshotAccount(db ethdb.KeyValueReader, snaps *snapshot.Tree, root common.Hash, hash common.Hash, blob []byte, storage bool) (*SnapshotAccount, error) {
+	data, err := types.FullAccount(blob)
+	if err != nil {
+		return nil, err
+	}
+	account := &SnapshotAccount{
+		Hash:     hash,
+		Nonce:    hexutil.Uint64(data.Nonce),
+		Balance:  (*hexutil.Big)(data.Balance.ToBig()),
+		Root:     data.Root,
+		CodeHash: common.BytesToHash(data.CodeHash),
+	}
+	if preimage := rawdb.ReadPreimage(db, hash); len(preimage) == common.AddressLength {
+		addr := common.BytesToAddress(preimage)
+		account.Address = &addr
+	}
+	if !storage || data.Root == types.EmptyRootHash {
+		return account, nil
+	}
+	it, err := snaps.StorageIterator(root, hash, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	account.Storage = make(map[common.Hash]hexutil.Bytes)
+	for it.Next() {
+		_, content, _, err := rlp.Split(it.Slot())
+		if err != nil {
+			return nil, err
+		}
+		account.Storage[it.Hash()] = common.CopyBytes(content)
+	}
+	return account, it.Error()
+}
@@ -0,0 +1,100 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var errFlatHistoryDisabled = errors.New("flat history is disabled")
+
+// StateHistoryEntry is the value of a storage slot from a block on, as returned
+// by the debug_getStateHistory call
+type StateHistoryEntry struct {
+	Block hexutil.Uint64 `json:"block"`
+	Value common.Hash    `json:"value"`
+}
+
+// FlatHistoryStatus is the result of the debug_flatHistoryStatus and
+// debug_pruneFlatHistory calls
+type FlatHistoryStatus struct {
+	Tail    hexutil.Uint64 `json:"tail"`
+	Head    hexutil.Uint64 `json:"head"`
+	Blocks  hexutil.Uint64 `json:"blocks"`
+	Entries hexutil.Uint64 `json:"entries"`
+}
+
+// StateHistoryAPI answers queries on past states from the flat history or the
+// state history of the path scheme, without re-executing the blocks, and
+// manages the range covered by the flat history.
+type StateHistoryAPI struct {
+	b *APIBackend
+}
+
+func NewStateHistoryAPI(b *APIBackend) *StateHistoryAPI {
+	return &StateHistoryAPI{b}
+}
+
+// GetStateHistory returns the value of the storage slot after fromBlock, and
+// its new values after each block up to toBlock modifying it. The value of the
+// slot at any block in the range is the one of the last entry not after it.
+func (api *StateHistoryAPI) GetStateHistory(address common.Address, slot common.Hash, fromBlock, toBlock hexutil.Uint64) ([]StateHistoryEntry, error) {
+	history, err := api.b.BlockChain().StorageHistory(address, slot, uint64(fromBlock), uint64(toBlock))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StateHistoryEntry, 0, len(history))
+	for _, entry := range history {
+		entries = append(entries, StateHistoryEntry{
+			Block: hexutil.Uint64(entry.Block),
+			Value: entry.Value,
+		})
+	}
+	return entries, nil
+}
+
+func (api *StateHistoryAPI) flatHistory() (*core.FlatHistory, error) {
+	history := api.b.BlockChain().FlatHistory()
+	if history == nil {
+		return nil, errFlatHistoryDisabled
+	}
+	return history, nil
+}
+
+func newFlatHistoryStatus(status *core.FlatHistoryStatus) *FlatHistoryStatus {
+	return &FlatHistoryStatus{
+		Tail:    hexutil.Uint64(status.Tail),
+		Head:    hexutil.Uint64(status.Head),
+		Blocks:  hexutil.Uint64(status.Blocks),
+		Entries: hexutil.Uint64(status.Entries),
+	}
+}
+
+// FlatHistoryStatus returns the range of blocks covered by the flat history.
+func (api *StateHistoryAPI) FlatHistoryStatus() (*FlatHistoryStatus, error) {
+	history, err := api.flatHistory()
+	if err != nil {
+		return nil, err
+	}
+	status, err := history.Status()
+	if err != nil {
+		return nil, err
+	}
+	return newFlatHistoryStatus(status), nil
+}
+
+// PruneFlatHistory deletes the flat history entries of the blocks below the
+// given one.
+func (api *StateHistoryAPI) PruneFlatHistory(before hexutil.Uint64) (*FlatHistoryStatus, error) {
+	history, err := api.flatHistory()
+	if err != nil {
+		return nil, err
+	}
+	status, err := history.Prune(uint64(before))
+	if err != nil {
+		return nil, err
+	}
+	return newFlatHistoryStatus(status), nil
+}
@@ -0,0 +1,84 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var errStateMigrationDisabled = errors.New("state migration is disabled")
+
+// StateMigrationStatus is the result of the debug_stateMigrationStatus and
+// debug_cutoverStateMigration calls
+type StateMigrationStatus struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Root       common.Hash    `json:"root"`
+	Head       hexutil.Uint64 `json:"head"`
+	Progress   float64        `json:"progress"`
+	Backfilled bool           `json:"backfilled"`
+	CutOver    bool           `json:"cutOver"`
+	Accounts   hexutil.Uint64 `json:"accounts"`
+	Nodes      hexutil.Uint64 `json:"nodes"`
+}
+
+// StateMigrationAPI reports the progress of the migration of the state to the
+// path scheme, and cuts it over once backfilled.
+type StateMigrationAPI struct {
+	b *APIBackend
+}
+
+func NewStateMigrationAPI(b *APIBackend) *StateMigrationAPI {
+	return &StateMigrationAPI{b}
+}
+
+func (api *StateMigrationAPI) migration() (*core.StateMigration, error) {
+	migration := api.b.BlockChain().StateMigration()
+	if migration == nil {
+		return nil, errStateMigrationDisabled
+	}
+	return migration, nil
+}
+
+func newStateMigrationStatus(status *core.StateMigrationStatus) *StateMigrationStatus {
+	return &StateMigrationStatus{
+		Number:     hexutil.Uint64(status.Number),
+		Root:       status.Root,
+		Head:       hexutil.Uint64(status.Head),
+		Progress:   status.Progress,
+		Backfilled: status.Backfilled,
+		CutOver:    status.CutOver,
+		Accounts:   hexutil.Uint64(status.Accounts),
+		Nodes:      hexutil.Uint64(status.Nodes),
+	}
+}
+
+// StateMigrationStatus returns the progress of the state migration.
+func (api *StateMigrationAPI) StateMigrationStatus() (*StateMigrationStatus, error) {
+	migration, err := api.migration()
+	if err != nil {
+		return nil, err
+	}
+	status, err := migration.Status()
+	if err != nil {
+		return nil, err
+	}
+	return newStateMigrationStatus(status), nil
+}
+
+// CutoverStateMigration catches the migrated state up with the chain head and
+// makes it the persistent state. The node is to be restarted with the path
+// scheme afterwards.
+func (api *StateMigrationAPI) CutoverStateMigration(ctx context.Context) (*StateMigrationStatus, error) {
+	migration, err := api.migration()
+	if err != nil {
+		return nil, err
+	}
+	status, err := migration.Cutover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newStateMigrationStatus(status), nil
+}
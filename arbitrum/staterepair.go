@@ -0,0 +1,77 @@
+package arbitrum
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RPCTrieNodeSource fetches the trie nodes missing locally from a remote node
+// holding the same state, through its debug_dbGet method. The remote node must
+// use the hash scheme and expose its database.
+type RPCTrieNodeSource struct {
+	client  *rpc.Client
+	timeout time.Duration
+}
+
+// NewRPCTrieNodeSource dials the node at the given URL. A zero timeout means
+// node fetches don't time out.
+func NewRPCTrieNodeSource(url string, timeout time.Duration) (*RPCTrieNodeSource, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating state repair connection: %w", err)
+	}
+	return &RPCTrieNodeSource{client: client, timeout: timeout}, nil
+}
+
+func (s *RPCTrieNodeSource) TrieNode(hash common.Hash) ([]byte, error) {
+	ctx := context.Background()
+	if s.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	var blob hexutil.Bytes
+	if err := s.client.CallContext(ctx, &blob, "debug_dbGet", hash.Hex()); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// StateRepairResult is the result of a debug_repairState call
+type StateRepairResult struct {
+	Root        common.Hash    `json:"root"`
+	Accounts    hexutil.Uint64 `json:"accounts"`
+	Fetched     hexutil.Uint64 `json:"fetched"`
+	Regenerated bool           `json:"regenerated"`
+}
+
+// StateRepairAPI restores the trie nodes missing after a partial database
+// corruption, without resyncing the whole state.
+type StateRepairAPI struct {
+	b *APIBackend
+}
+
+func NewStateRepairAPI(b *APIBackend) *StateRepairAPI {
+	return &StateRepairAPI{b}
+}
+
+// RepairState walks the state with the given root, restoring the missing trie
+// nodes from the configured remote node, or regenerating the state from the
+// snapshot if they can't be fetched.
+func (api *StateRepairAPI) RepairState(ctx context.Context, root common.Hash) (*StateRepairResult, error) {
+	stats, err := api.b.BlockChain().RepairState(ctx, root, api.b.stateRepairSource)
+	if err != nil {
+		return nil, err
+	}
+	return &StateRepairResult{
+		Root:        root,
+		Accounts:    hexutil.Uint64(stats.Accounts),
+		Fetched:     hexutil.Uint64(stats.Fetched),
+		Regenerated: stats.Regenerated,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var (
+	errStateStatsDisabled = errors.New("state statistics are disabled")
+	errStateStatsPending  = errors.New("state statistics not gathered yet")
+)
+
+// ContractSlots is a contract with the number of its storage slots
+type ContractSlots struct {
+	Hash    common.Hash     `json:"hash"`
+	Address *common.Address `json:"address,omitempty"`
+	Slots   hexutil.Uint64  `json:"slots"`
+}
+
+// StateStats is the result of a debug_stateStats call
+type StateStats struct {
+	Root            common.Hash                       `json:"root"`
+	Accounts        hexutil.Uint64                    `json:"accounts"`
+	Contracts       hexutil.Uint64                    `json:"contracts"`
+	StylusContracts hexutil.Uint64                    `json:"stylusContracts"`
+	EVMContracts    hexutil.Uint64                    `json:"evmContracts"`
+	Slots           hexutil.Uint64                    `json:"slots"`
+	Codes           hexutil.Uint64                    `json:"codes"`
+	CodeBytes       hexutil.Uint64                    `json:"codeBytes"`
+	StylusCodes     hexutil.Uint64                    `json:"stylusCodes"`
+	StylusCodeBytes hexutil.Uint64                    `json:"stylusCodeBytes"`
+	EVMCodes        hexutil.Uint64                    `json:"evmCodes"`
+	EVMCodeBytes    hexutil.Uint64                    `json:"evmCodeBytes"`
+	CodeSizes       map[hexutil.Uint64]hexutil.Uint64 `json:"codeSizes"`
+	TopContracts    []ContractSlots                   `json:"topContracts"`
+	Time            hexutil.Uint64                    `json:"time"`
+	Elapsed         string                            `json:"elapsed"`
+}
+
+// StateStatsAPI exposes the state-wide statistics gathered in the background,
+// for capacity planning and research.
+type StateStatsAPI struct {
+	b *APIBackend
+}
+
+func NewStateStatsAPI(b *APIBackend) *StateStatsAPI {
+	return &StateStatsAPI{b}
+}
+
+// StateStats returns the statistics of the last walk of the flat state: the
+// number of accounts, contracts and slots, the size of the distinct codes with
+// their Stylus and EVM split and size histogram, and the contracts with the
+// most storage slots.
+func (api *StateStatsAPI) StateStats() (*StateStats, error) {
+	stats := api.b.BlockChain().StateStats()
+	if stats == nil {
+		return nil, errStateStatsDisabled
+	}
+	last := stats.Last()
+	if last == nil {
+		return nil, errStateStatsPending
+	}
+	return newStateStats(last), nil
+}
+
+func newStateStats(result *core.StateStatsResult) *StateStats {
+	stats := &StateStats{
+		Root:            result.Root,
+		Accounts:        hexutil.Uint64(result.Accounts),
+		Contracts:       hexutil.Uint64(result.Contracts),
+		StylusContracts: hexutil.Uint64(result.StylusContracts),
+		EVMContracts:    hexutil.Uint64(result.Contracts - result.StylusContracts),
+		Slots:           hexutil.Uint64(result.Slots),
+		Codes:           hexutil.Uint64(result.Codes),
+		CodeBytes:       hexutil.Uint64(result.CodeBytes),
+		StylusCodes:     hexutil.Uint64(result.StylusCodes),
+		StylusCodeBytes: hexutil.Uint64(result.StylusCodeBytes),
+		EVMCodes:        hexutil.Uint64(result.Codes - result.StylusCodes),
+		EVMCodeBytes:    hexutil.Uint64(result.CodeBytes - result.StylusCodeBytes),
+		CodeSizes:       make(map[hexutil.Uint64]hexutil.Uint64, len(result.CodeSizes)),
+		TopContracts:    make([]ContractSlots, 0, len(result.TopContracts)),
+		Time:            hexutil.Uint64(result.Time.Unix()),
+		Elapsed:         result.Elapsed.String(),
+	}
+	for size, count := range result.CodeSizes {
+		stats.CodeSizes[hexutil.Uint64(size)] = hexutil.Uint64(count)
+	}
+	for _, contract := range result.TopContracts {
+		stats.TopContracts = append(stats.TopContracts, ContractSlots{
+			Hash:    contract.Hash,
+			Address: contract.Address,
+			Slots:   hexutil.Uint64(contract.Slots),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockStylusStatsResult is the result of an arb_getBlockStylusStats call
+type BlockStylusStatsResult struct {
+	BlockNumber  hexutil.Uint64 `json:"blockNumber"`
+	BlockHash    common.Hash    `json:"blockHash"`
+	Calls        hexutil.Uint64 `json:"calls"`
+	Modules      hexutil.Uint64 `json:"modules"`
+	Ink          hexutil.Uint64 `json:"ink"`
+	PagesOpened  hexutil.Uint64 `json:"pagesOpened"`
+	MaxPagesEver hexutil.Uint64 `json:"maxPagesEver"`
+}
+
+type ArbStylusStatsAPI struct {
+	b *APIBackend
+}
+
+func NewArbStylusStatsAPI(b *APIBackend) *ArbStylusStatsAPI {
+	return &ArbStylusStatsAPI{b}
+}
+
+// GetBlockStylusStats returns the summary of the Stylus executions of the given
+// block, recorded as it was written: the number of program calls and distinct
+// modules executed, the ink used, and the wasm pages opened. The counts are zero
+// for the blocks running no program, and for those written before the tracking.
+func (api *ArbStylusStatsAPI) GetBlockStylusStats(ctx context.Context, blockNr rpc.BlockNumber) (*BlockStylusStatsResult, error) {
+	header, err := api.b.HeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	result := &BlockStylusStatsResult{
+		BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		BlockHash:   header.Hash(),
+	}
+	if stats := rawdb.ReadBlockStylusStats(api.b.ChainDb(), result.BlockHash, header.Number.Uint64()); stats != nil {
+		result.Calls = hexutil.Uint64(stats.Calls)
+		result.Modules = hexutil.Uint64(stats.Modules)
+		result.Ink = hexutil.Uint64(stats.Ink)
+		result.PagesOpened = hexutil.Uint64(stats.PagesOpened)
+		result.MaxPagesEver = hexutil.Uint64(stats.MaxPagesEver)
+	}
+	return result, nil
+}
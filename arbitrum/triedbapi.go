@@ -0,0 +1,84 @@
+package arbitrum
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// TrieDBLayer is a layer of the path scheme layer tree, as returned by the
+// debug_trieDbStats call
+type TrieDBLayer struct {
+	Root    common.Hash    `json:"root"`
+	Parent  common.Hash    `json:"parent"`
+	StateID hexutil.Uint64 `json:"stateId"`
+	Block   hexutil.Uint64 `json:"block"`
+	Size    hexutil.Uint64 `json:"size"`
+	Disk    bool           `json:"disk"`
+}
+
+// TrieDBStats is the result of the debug_trieDbStats call. The layer tree, the
+// persistent state id and the state history range are only reported by the
+// path scheme.
+type TrieDBStats struct {
+	Scheme            string          `json:"scheme"`
+	DiffsSize         hexutil.Uint64  `json:"diffsSize"`
+	DirtySize         hexutil.Uint64  `json:"dirtySize"`
+	PreimagesSize     hexutil.Uint64  `json:"preimagesSize"`
+	DiffLayers        hexutil.Uint64  `json:"diffLayers"`
+	Layers            []TrieDBLayer   `json:"layers,omitempty"`
+	PersistentStateID *hexutil.Uint64 `json:"persistentStateId,omitempty"`
+	HistoryFirst      *hexutil.Uint64 `json:"historyFirst,omitempty"`
+	HistoryLast       *hexutil.Uint64 `json:"historyLast,omitempty"`
+}
+
+// TrieDBAPI exposes the state of the trie database, to monitor the growth of the
+// layers kept in memory and the pruning of the state history.
+type TrieDBAPI struct {
+	b *APIBackend
+}
+
+func NewTrieDBAPI(b *APIBackend) *TrieDBAPI {
+	return &TrieDBAPI{b}
+}
+
+// TrieDbStats returns the memory used by the trie database, along with the layer
+// tree and the range of the state history for the path scheme.
+func (api *TrieDBAPI) TrieDbStats() (*TrieDBStats, error) {
+	triedb := api.b.BlockChain().TrieDB()
+	diffs, nodes, preimages := triedb.Size()
+	stats := &TrieDBStats{
+		Scheme:        triedb.Scheme(),
+		DiffsSize:     hexutil.Uint64(diffs),
+		DirtySize:     hexutil.Uint64(nodes),
+		PreimagesSize: hexutil.Uint64(preimages),
+	}
+	if stats.Scheme != rawdb.PathScheme {
+		return stats, nil
+	}
+	layers, err := triedb.Layers()
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		if layer.Disk {
+			id := hexutil.Uint64(layer.StateID)
+			stats.PersistentStateID = &id
+		} else {
+			stats.DiffLayers++
+		}
+		stats.Layers = append(stats.Layers, TrieDBLayer{
+			Root:    layer.Root,
+			Parent:  layer.Parent,
+			StateID: hexutil.Uint64(layer.StateID),
+			Block:   hexutil.Uint64(layer.Block),
+			Size:    hexutil.Uint64(layer.Size),
+			Disk:    layer.Disk,
+		})
+	}
+	// The history is empty if disabled or not written yet
+	if first, last, err := triedb.HistoryRange(); err == nil {
+		stats.HistoryFirst, stats.HistoryLast = (*hexutil.Uint64)(&first), (*hexutil.Uint64)(&last)
+	}
+	return stats, nil
+}
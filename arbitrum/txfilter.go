@@ -0,0 +1,140 @@
+package arbitrum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var filteredTxsCounter = metrics.NewRegisteredCounter("arb/txfilter/filtered", nil)
+
+// FilteredTx describes a transaction the sequencer dropped because it was filtered
+type FilteredTx struct {
+	TxHash      common.Hash    `json:"txHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"` // block being built when the tx was filtered
+	Reason      string         `json:"reason"`
+	Time        hexutil.Uint64 `json:"time"`
+}
+
+// FilteredTxCount is the number of transactions filtered while building a block
+type FilteredTxCount struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Count       hexutil.Uint64 `json:"count"`
+}
+
+// txFilterLog keeps the most recently filtered transactions and per-block counters in memory
+type txFilterLog struct {
+	mu     sync.Mutex
+	txs    lru.BasicLRU[common.Hash, *FilteredTx]
+	counts lru.BasicLRU[uint64, uint64]
+}
+
+func newTxFilterLog(size int) *txFilterLog {
+	return &txFilterLog{
+		txs:    lru.NewBasicLRU[common.Hash, *FilteredTx](size),
+		counts: lru.NewBasicLRU[uint64, uint64](size),
+	}
+}
+
+func (l *txFilterLog) record(blockNumber uint64, txHash common.Hash, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.txs.Add(txHash, &FilteredTx{
+		TxHash:      txHash,
+		BlockNumber: hexutil.Uint64(blockNumber),
+		Reason:      reason,
+		Time:        hexutil.Uint64(time.Now().Unix()),
+	})
+	count, _ := l.counts.Peek(blockNumber)
+	l.counts.Add(blockNumber, count+1)
+	filteredTxsCounter.Inc(1)
+}
+
+func (l *txFilterLog) get(txHash common.Hash) *FilteredTx {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tx, _ := l.txs.Peek(txHash)
+	return tx
+}
+
+func (l *txFilterLog) count(blockNumber uint64) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count, _ := l.counts.Peek(blockNumber)
+	return count
+}
+
+// RecordFilteredTx is called by the sequencer whenever it drops a transaction which
+// was filtered during execution, making the decision auditable over the arbdebug API
+func (a *APIBackend) RecordFilteredTx(blockNumber uint64, txHash common.Hash, statedb vm.StateDB) {
+	a.txFilterLog.record(blockNumber, txHash, statedb.TxFilterReason())
+}
+
+type TxFilterAPI struct {
+	b               *APIBackend
+	blockRangeBound uint64
+}
+
+func NewTxFilterAPI(b *APIBackend, blockRangeBound uint64) *TxFilterAPI {
+	return &TxFilterAPI{b, blockRangeBound}
+}
+
+// FilteredTransaction returns whether the transaction with the given hash was filtered
+// by this node's sequencer and why, or nil if it's not among the recently filtered ones
+func (api *TxFilterAPI) FilteredTransaction(ctx context.Context, txHash common.Hash) *FilteredTx {
+	return api.b.txFilterLog.get(txHash)
+}
+
+// FilteredTransactionCounts returns the number of transactions filtered while building
+// each block of the given range, omitting the blocks without any
+func (api *TxFilterAPI) FilteredTransactionCounts(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) ([]FilteredTxCount, error) {
+	from, err := api.b.resolveBlockNumber(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.b.resolveBlockNumber(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	if to-from >= api.blockRangeBound {
+		return nil, fmt.Errorf("block range %d-%d exceeds the bound of %d blocks", from, to, api.blockRangeBound)
+	}
+	counts := []FilteredTxCount{}
+	for number := from; number <= to; number++ {
+		if count := api.b.txFilterLog.count(number); count > 0 {
+			counts = append(counts, FilteredTxCount{
+				BlockNumber: hexutil.Uint64(number),
+				Count:       hexutil.Uint64(count),
+			})
+		}
+	}
+	return counts, nil
+}
+
+func (a *APIBackend) resolveBlockNumber(ctx context.Context, number rpc.BlockNumber) (uint64, error) {
+	if number >= 0 {
+		return uint64(number), nil
+	}
+	header, err := a.HeaderByNumber(ctx, number)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("block %v not found", number)
+	}
+	return header.Number.Uint64(), nil
+}
@@ -1,5 +1,7 @@
 package arbitrum
 
+import "github.com/ethereum/go-ethereum/internal/ethapi"
+
 // PublicNetAPI offers network related RPC methods
 type PublicTxPoolAPI struct{}
 
@@ -15,3 +17,8 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*struct{} {
 		"queued":  make(map[string]map[string]*struct{}),
 	}
 }
+
+// ContentFiltered mirrors Content, as Arbitrum nodes don't keep a local pool.
+func (s *PublicTxPoolAPI) ContentFiltered(filter ethapi.TxPoolFilter) map[string]map[string]map[string]*struct{} {
+	return s.Content()
+}
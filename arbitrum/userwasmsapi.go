@@ -0,0 +1,143 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// UserWasmModule is a Stylus module executed in a block, with the targets its
+// asm was recorded for
+type UserWasmModule struct {
+	ModuleHash common.Hash        `json:"moduleHash"`
+	Targets    []ethdb.WasmTarget `json:"targets"`
+}
+
+// UserWasmProgram is a Stylus program called in a transaction
+type UserWasmProgram struct {
+	Address  common.Address `json:"address"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// TxUserWasms are the Stylus programs called and the modules loaded by a transaction
+type TxUserWasms struct {
+	TxHash   common.Hash       `json:"txHash"`
+	Programs []UserWasmProgram `json:"programs"`
+	Modules  []common.Hash     `json:"modules"`
+}
+
+// BlockUserWasmsResult is the result of a debug_blockUserWasms call
+type BlockUserWasmsResult struct {
+	BlockNumber  hexutil.Uint64   `json:"blockNumber"`
+	BlockHash    common.Hash      `json:"blockHash"`
+	Modules      []UserWasmModule `json:"modules"`
+	Transactions []TxUserWasms    `json:"transactions"`
+}
+
+// UserWasmsAPI exposes the Stylus modules and programs executed by the blocks,
+// for the validators to extract the wasms needed to prove them.
+type UserWasmsAPI struct {
+	b *APIBackend
+}
+
+func NewUserWasmsAPI(b *APIBackend) *UserWasmsAPI {
+	return &UserWasmsAPI{b}
+}
+
+// BlockUserWasms re-executes the given block on top of its parent state while
+// recording the Stylus programs, and returns the modules executed by the block,
+// along with the programs called and the modules loaded by each transaction.
+func (api *UserWasmsAPI) BlockUserWasms(ctx context.Context, blockNr rpc.BlockNumber) (*BlockUserWasmsResult, error) {
+	block, err := api.b.BlockByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis block executes no program")
+	}
+	statedb, _, err := api.b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(block.NumberU64()-1))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txs      []TxUserWasms
+		programs map[common.Address]common.Hash
+		modules  map[common.Hash]struct{}
+	)
+	hooks := &tracing.Hooks{
+		OnTxStart: func(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+			txs = append(txs, TxUserWasms{TxHash: tx.Hash()})
+			programs = make(map[common.Address]common.Hash)
+			modules = make(map[common.Hash]struct{})
+		},
+		OnEnter: func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+			if programs == nil {
+				return
+			}
+			if op := vm.OpCode(typ); op == vm.CREATE || op == vm.CREATE2 {
+				return
+			}
+			if _, ok := programs[to]; !ok && state.IsStylusProgram(statedb.GetCode(to)) {
+				programs[to] = statedb.GetCodeHash(to)
+			}
+		},
+		CaptureStylusModule: func(moduleHash common.Hash, activated bool) {
+			if modules != nil && !activated {
+				modules[moduleHash] = struct{}{}
+			}
+		},
+		OnTxEnd: func(receipt *types.Receipt, err error) {
+			tx := &txs[len(txs)-1]
+			for addr, codeHash := range programs {
+				tx.Programs = append(tx.Programs, UserWasmProgram{Address: addr, CodeHash: codeHash})
+			}
+			slices.SortFunc(tx.Programs, func(a, b UserWasmProgram) int { return a.Address.Cmp(b.Address) })
+			for moduleHash := range modules {
+				tx.Modules = append(tx.Modules, moduleHash)
+			}
+			slices.SortFunc(tx.Modules, func(a, b common.Hash) int { return a.Cmp(b) })
+			programs, modules = nil, nil
+		},
+	}
+	statedb.StartRecording()
+	statedb.SetLogger(hooks)
+	if _, _, _, err := api.b.BlockChain().Processor().Process(block, statedb, vm.Config{Tracer: hooks}); err != nil {
+		return nil, err
+	}
+	result := &BlockUserWasmsResult{
+		BlockNumber:  hexutil.Uint64(block.NumberU64()),
+		BlockHash:    block.Hash(),
+		Modules:      []UserWasmModule{},
+		Transactions: []TxUserWasms{},
+	}
+	for moduleHash, asmMap := range statedb.UserWasms() {
+		module := UserWasmModule{ModuleHash: moduleHash, Targets: []ethdb.WasmTarget{}}
+		for target := range asmMap {
+			module.Targets = append(module.Targets, target)
+		}
+		slices.Sort(module.Targets)
+		result.Modules = append(result.Modules, module)
+	}
+	slices.SortFunc(result.Modules, func(a, b UserWasmModule) int { return a.ModuleHash.Cmp(b.ModuleHash) })
+
+	// Only report the transactions running Stylus programs
+	for _, tx := range txs {
+		if len(tx.Programs) > 0 || len(tx.Modules) > 0 {
+			result.Transactions = append(result.Transactions, tx)
+		}
+	}
+	return result, nil
+}
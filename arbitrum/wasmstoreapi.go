@@ -0,0 +1,172 @@
+package arbitrum
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// WasmTargetStats holds the number and total size of the asm stored for a target
+type WasmTargetStats struct {
+	Modules hexutil.Uint64 `json:"modules"`
+	Size    hexutil.Uint64 `json:"size"`
+}
+
+// WasmGCResult is the outcome of a garbage collection of the wasm store
+type WasmGCResult struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Modules    hexutil.Uint64 `json:"modules"`
+	Referenced hexutil.Uint64 `json:"referenced"`
+	Retained   hexutil.Uint64 `json:"retained"`
+	Collected  hexutil.Uint64 `json:"collected"`
+	Freed      hexutil.Uint64 `json:"freed"`
+	Time       hexutil.Uint64 `json:"time"`
+	Elapsed    string         `json:"elapsed"`
+}
+
+// WasmFreezeResult is the outcome of a freezing of the cold Stylus modules
+type WasmFreezeResult struct {
+	Number  hexutil.Uint64 `json:"number"`
+	Before  hexutil.Uint64 `json:"before"`
+	Frozen  hexutil.Uint64 `json:"frozen"`
+	Size    hexutil.Uint64 `json:"size"`
+	Elapsed string         `json:"elapsed"`
+}
+
+// WasmStoreStats is the result of a debug_wasmStoreStats call
+type WasmStoreStats struct {
+	Targets      map[ethdb.WasmTarget]WasmTargetStats `json:"targets"`
+	Frozen       hexutil.Uint64                       `json:"frozen"`
+	Unreferenced hexutil.Uint64                       `json:"unreferenced"`
+	Retention    hexutil.Uint64                       `json:"retention"`
+	LastGC       *WasmGCResult                        `json:"lastGC"`
+}
+
+func newWasmGCResult(result *core.WasmGCResult) *WasmGCResult {
+	if result == nil {
+		return nil
+	}
+	return &WasmGCResult{
+		Number:     hexutil.Uint64(result.Number),
+		Modules:    hexutil.Uint64(result.Modules),
+		Referenced: hexutil.Uint64(result.Referenced),
+		Retained:   hexutil.Uint64(result.Retained),
+		Collected:  hexutil.Uint64(result.Collected),
+		Freed:      hexutil.Uint64(result.Freed),
+		Time:       hexutil.Uint64(result.Time.Unix()),
+		Elapsed:    result.Elapsed.String(),
+	}
+}
+
+// WasmStoreAPI exposes the content of the wasm store, the collection of the
+// Stylus modules no longer referenced by any program and the recent programs
+// cache.
+type WasmStoreAPI struct {
+	b *APIBackend
+}
+
+func NewWasmStoreAPI(b *APIBackend) *WasmStoreAPI {
+	return &WasmStoreAPI{b}
+}
+
+// WasmStoreStats reports the asm stored for each target, the number of modules
+// awaiting collection and the outcome of the last collection.
+func (api *WasmStoreAPI) WasmStoreStats() (*WasmStoreStats, error) {
+	stats, err := api.b.BlockChain().WasmStoreGC().Stats()
+	if err != nil {
+		return nil, err
+	}
+	result := &WasmStoreStats{
+		Targets:      make(map[ethdb.WasmTarget]WasmTargetStats),
+		Frozen:       hexutil.Uint64(stats.Frozen),
+		Unreferenced: hexutil.Uint64(stats.Unreferenced),
+		Retention:    hexutil.Uint64(stats.Retention),
+		LastGC:       newWasmGCResult(stats.LastGC),
+	}
+	for target, targetStats := range stats.Targets {
+		result.Targets[target] = WasmTargetStats{
+			Modules: hexutil.Uint64(targetStats.Modules),
+			Size:    hexutil.Uint64(targetStats.Size),
+		}
+	}
+	return result, nil
+}
+
+// CollectWasmStore deletes the Stylus modules which stayed unreferenced for
+// longer than the retention period.
+func (api *WasmStoreAPI) CollectWasmStore() (*WasmGCResult, error) {
+	result, err := api.b.BlockChain().WasmStoreGC().Collect()
+	if err != nil {
+		return nil, err
+	}
+	return newWasmGCResult(result), nil
+}
+
+// CompactWasmStore collects the unreferenced Stylus modules, then compacts the
+// wasm store to reclaim their space.
+func (api *WasmStoreAPI) CompactWasmStore() (*WasmGCResult, error) {
+	result, err := api.b.BlockChain().WasmStoreGC().Compact()
+	if err != nil {
+		return nil, err
+	}
+	return newWasmGCResult(result), nil
+}
+
+// FreezeWasmStore moves the Stylus modules activated at least the given number
+// of blocks ago into the wasm freezer, if the wasm store has one.
+func (api *WasmStoreAPI) FreezeWasmStore(age hexutil.Uint64) (*WasmFreezeResult, error) {
+	result, err := api.b.BlockChain().WasmStoreGC().Freeze(uint64(age))
+	if err != nil {
+		return nil, err
+	}
+	return &WasmFreezeResult{
+		Number:  hexutil.Uint64(result.Number),
+		Before:  hexutil.Uint64(result.Before),
+		Frozen:  hexutil.Uint64(result.Frozen),
+		Size:    hexutil.Uint64(result.Size),
+		Elapsed: result.Elapsed.String(),
+	}, nil
+}
+
+// RecentWasmEntry is a program retained by the recent programs cache
+type RecentWasmEntry struct {
+	Hash common.Hash    `json:"hash"`
+	Age  hexutil.Uint64 `json:"age"`
+}
+
+// RecentWasms is the result of a debug_recentWasms call
+type RecentWasms struct {
+	Number   hexutil.Uint64    `json:"number"`
+	Hash     common.Hash       `json:"hash"`
+	Legacy   bool              `json:"legacy"`
+	Capacity hexutil.Uint64    `json:"capacity"`
+	MaxAge   hexutil.Uint64    `json:"maxAge"`
+	Entries  []RecentWasmEntry `json:"entries"`
+}
+
+// RecentWasms dumps the recent Stylus programs cache at the end of the last
+// block written, along with the number of program calls since each entry was
+// last used, to help tuning the caching policy.
+func (api *WasmStoreAPI) RecentWasms() (*RecentWasms, error) {
+	recent := api.b.BlockChain().RecentWasms()
+	if recent == nil {
+		return nil, errors.New("no block processed yet")
+	}
+	result := &RecentWasms{
+		Number:  hexutil.Uint64(recent.Number),
+		Hash:    recent.Hash,
+		Legacy:  recent.Config == nil,
+		Entries: make([]RecentWasmEntry, 0, len(recent.Entries)),
+	}
+	if recent.Config != nil {
+		result.Capacity = hexutil.Uint64(recent.Config.Capacity)
+		result.MaxAge = hexutil.Uint64(recent.Config.MaxAge)
+	}
+	for _, entry := range recent.Entries {
+		result.Entries = append(result.Entries, RecentWasmEntry{Hash: entry.Hash, Age: hexutil.Uint64(entry.Age)})
+	}
+	return result, nil
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -92,8 +93,9 @@ Remove blockchain and state databases`,
 		Flags: flags.Merge([]cli.Flag{
 			utils.SyncModeFlag,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
-		Usage:       "Inspect the storage size for each type of data in the database",
-		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.`,
+		Usage: "Inspect the storage size for each type of data in the database",
+		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.
+The wasm database is inspected as well if present, with the Arbitrum specific entries sized per category.`,
 	}
 	dbCheckStateContentCmd = &cli.Command{
 		Action:    checkStateContent,
@@ -348,6 +350,16 @@ func inspect(ctx *cli.Context) error {
 	defer stack.Close()
 
 	db := utils.MakeChainDatabase(ctx, stack, true)
+
+	// Arbitrum: inspect the wasm store too, if kept in a separate database
+	if rawdb.PreexistingDatabase(stack.ResolveDatabase(node.WasmDatabaseName)) != "" {
+		wasmDb, err := stack.OpenWasmDatabase(0, 0, "", true, nil)
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("failed to open wasm database: %v", err)
+		}
+		db = rawdb.WrapDatabaseWithWasm(db, wasmDb, 0, nil)
+	}
 	defer db.Close()
 
 	return rawdb.InspectDatabase(db, prefix, start)
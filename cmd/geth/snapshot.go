@@ -40,6 +40,16 @@ import (
 )
 
 var (
+	planRetainFlag = &cli.Uint64Flag{
+		Name:  "retain",
+		Usage: "Number of most recent canonical state roots to retain",
+		Value: 128,
+	}
+	planDryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "If set, only reports the reclaimable space without deleting anything",
+	}
+
 	snapshotCommand = &cli.Command{
 		Name:        "snapshot",
 		Usage:       "A set of commands based on the snapshot",
@@ -62,6 +72,30 @@ two version states are available: genesis and the specific one.
 
 The default pruning target is the HEAD-127 state.
 
+WARNING: it's only supported in hash mode(--state.scheme=hash)".
+`,
+			},
+			{
+				Name:   "plan-prune-state",
+				Usage:  "Prune the state data not reachable from the most recent state roots",
+				Action: planPruneState,
+				Flags: flags.Merge([]cli.Flag{
+					utils.BloomFilterSizeFlag,
+					planRetainFlag,
+					planDryRunFlag,
+				}, utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot plan-prune-state --retain 128 --dry-run
+scans the trie database for the trie nodes and contract codes which are not
+reachable from the last --retain canonical state roots or the genesis state,
+and reports the space they occupy. Without --dry-run, these entries are
+deleted as well. A bloom filter built during a dry run is reused by the next
+run as long as the chain head didn't change.
+
+The progress is checkpointed into the datadir, so an interrupted run resumes
+where it left off when the command is run again. Once deletion has started,
+it has to be completed before the node is started again.
+
 WARNING: it's only supported in hash mode(--state.scheme=hash)".
 `,
 			},
@@ -209,6 +243,34 @@ func pruneState(ctx *cli.Context) error {
 	return nil
 }
 
+func planPruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	defer chaindb.Close()
+
+	config := pruner.PlanConfig{
+		Config: pruner.Config{
+			Datadir:   stack.ResolvePath(""),
+			BloomSize: ctx.Uint64(utils.BloomFilterSizeFlag.Name),
+		},
+		Retain: ctx.Uint64(planRetainFlag.Name),
+		DryRun: ctx.Bool(planDryRunFlag.Name),
+	}
+	report, err := pruner.PlanPruning(chaindb, config)
+	if err != nil {
+		log.Error("Failed to plan state pruning", "err", err)
+		return err
+	}
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 func verifyState(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	defer stack.Close()
@@ -47,6 +47,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/urfave/cli/v2"
 )
 
@@ -305,6 +306,9 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 				if err != nil {
 					return fmt.Errorf("error reading receipts %d: %w", it.Number(), err)
 				}
+				if err := verifyHistory(chain.Config(), block, receipts); err != nil {
+					return fmt.Errorf("error verifying block %d: %w", it.Number(), err)
+				}
 				if status, err := chain.HeaderChain().InsertHeaderChain([]*types.Header{block.Header()}, start, forker); err != nil {
 					return fmt.Errorf("error inserting header %d: %w", it.Number(), err)
 				} else if status != core.CanonStatTy {
@@ -332,6 +336,23 @@ func ImportHistory(chain *core.BlockChain, db ethdb.Database, dir string, networ
 	return nil
 }
 
+// verifyHistory checks the transactions and the receipts of an imported block
+// against the roots of its header, so that the era files need not be trusted
+// beyond the headers. The receipts fields not part of the encoding they were
+// read from are derived from the block.
+func verifyHistory(config *params.ChainConfig, block *types.Block, receipts types.Receipts) error {
+	if root := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); root != block.TxHash() {
+		return fmt.Errorf("transaction root mismatch: have %x, want %x", root, block.TxHash())
+	}
+	if err := receipts.DeriveFields(config, block.Hash(), block.NumberU64(), block.Time(), block.BaseFee(), nil, block.Transactions()); err != nil {
+		return err
+	}
+	if root := types.DeriveSha(receipts, trie.NewStackTrie(nil)); root != block.ReceiptHash() {
+		return fmt.Errorf("receipt root mismatch: have %x, want %x", root, block.ReceiptHash())
+	}
+	return nil
+}
+
 func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block {
 	head := chain.CurrentBlock()
 	for i, block := range blocks {
@@ -402,7 +423,8 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 }
 
 // ExportHistory exports blockchain history into the specified directory,
-// following the Era format.
+// following the Era format. The receipts of Arbitrum chains are exported in
+// their storage encoding, see era.NewArbitrumBuilder.
 func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
 	log.Info("Exporting blockchain history", "dir", dir)
 	if head := bc.CurrentBlock().Number.Uint64(); head < last {
@@ -432,7 +454,11 @@ func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) er
 			}
 			defer f.Close()
 
+			// Arbitrum: keep the receipt fields missing from the consensus encoding
 			w := era.NewBuilder(f)
+			if bc.Config().IsArbitrum() {
+				w = era.NewArbitrumBuilder(f)
+			}
 			for j := uint64(0); j < step && j <= last-i; j++ {
 				var (
 					n     = i + j
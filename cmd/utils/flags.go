@@ -110,6 +110,16 @@ var (
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	WasmDirFlag = &flags.DirectoryFlag{
+		Name:     "datadir.wasm",
+		Usage:    "Directory for the wasm store of the Stylus programs (default = inside the datadir)",
+		Category: flags.EthCategory,
+	}
+	WasmAncientFlag = &flags.DirectoryFlag{
+		Name:     "datadir.wasm.ancient",
+		Usage:    "Directory for the freezer of the cold Stylus programs (default = no freezer)",
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -970,6 +980,8 @@ var (
 	DatabaseFlags = []cli.Flag{
 		DataDirFlag,
 		AncientFlag,
+		WasmDirFlag,
+		WasmAncientFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
 		StateSchemeFlag,
@@ -1420,6 +1432,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.String(KeyStoreDirFlag.Name)
 	}
+	if ctx.IsSet(WasmDirFlag.Name) {
+		cfg.WasmDir = ctx.String(WasmDirFlag.Name)
+	}
+	if ctx.IsSet(WasmAncientFlag.Name) {
+		cfg.WasmAncientDir = ctx.String(WasmAncientFlag.Name)
+	}
 	if ctx.IsSet(DeveloperFlag.Name) {
 		cfg.UseLightweightKDF = true
 	}
@@ -20,3 +20,30 @@ package lru
 func (c *BasicLRU[K, V]) Capacity() int {
 	return c.cap
 }
+
+// RemoveOldest drops the least recently used item.
+func (c *Cache[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cache.RemoveOldest()
+}
+
+// Shrink evicts the least recently used items until at least the given number
+// of bytes is released, or the cache is empty. It returns the number of bytes
+// released.
+func (c *SizeConstrainedCache[K, V]) Shrink(bytes uint64) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var released uint64
+	for released < bytes {
+		_, v, ok := c.lru.RemoveOldest()
+		if !ok {
+			break
+		}
+		released += uint64(len(v))
+	}
+	c.size -= released
+	return released
+}
@@ -100,3 +100,11 @@ func (c *SizeConstrainedCache[K, V]) Clear() {
 	c.lru.Purge()
 	c.size = 0
 }
+
+// Size returns the total size of the values held by the cache.
+func (c *SizeConstrainedCache[K, V]) Size() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.size
+}
@@ -153,3 +153,26 @@ func TestSizeConstrainedCacheEmpties(t *testing.T) {
 		}
 	}
 }
+
+func TestSizeConstrainedCacheShrink(t *testing.T) {
+	cache := NewSizeConstrainedCache[int, []byte](100)
+	for i := 0; i < 10; i++ {
+		cache.Add(i, make([]byte, 10))
+	}
+	cache.Get(0) // bump the oldest item
+
+	if released := cache.Shrink(25); released != 30 {
+		t.Fatalf("released size mismatch: have %d, want 30", released)
+	}
+	if size := cache.Size(); size != 70 {
+		t.Fatalf("cache size mismatch: have %d, want 70", size)
+	}
+	if _, ok := cache.Get(0); !ok {
+		t.Error("recently used item evicted")
+	}
+	for i := 1; i <= 3; i++ {
+		if _, ok := cache.Get(i); ok {
+			t.Errorf("item %d not evicted", i)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	accountExpiryTouchedMeter     = metrics.NewRegisteredMeter("chain/expiry/touched", nil)
+	accountExpiryReactivatedMeter = metrics.NewRegisteredMeter("chain/expiry/reactivated", nil)
+	accountExpiryMarkedMeter      = metrics.NewRegisteredMeter("chain/expiry/marked", nil)
+)
+
+// accountExpiryMarkBatch is the number of inactive accounts marked at once,
+// holding the chain mutex.
+const accountExpiryMarkBatch = 1024
+
+// AccountExpiryStats describes the access recency of the tracked accounts.
+type AccountExpiryStats struct {
+	Epoch       uint64            // Epoch of the chain head
+	EpochLength uint64            // Number of blocks per epoch
+	Tracked     uint64            // Number of accounts with a recorded access
+	Inactive    uint64            // Number of accounts marked inactive
+	LastAccess  map[uint64]uint64 // Number of accounts by epoch of last access
+}
+
+// InactivityResult is the outcome of a marking of the inactive accounts.
+type InactivityResult struct {
+	Epoch     uint64 // Epoch of the chain head the inactivity was measured from
+	Threshold uint64 // Number of epochs without access for an account to be inactive
+	Scanned   uint64 // Number of tracked accounts scanned
+	Marked    uint64 // Number of accounts newly marked inactive
+}
+
+// AccountExpiry tracks the epoch each account was last accessed at, over the
+// blocks written by the chain, and marks the accounts that were not accessed
+// for long as inactive. It doesn't alter the state in any way: it's meant to
+// simulate state rent and expiry proposals with the real access patterns.
+//
+// Accounts marked inactive are reactivated as soon as they're accessed again.
+// Accesses of side chain blocks are recorded too.
+type AccountExpiry struct {
+	bc          *BlockChain
+	epochLength uint64 // Number of blocks per epoch
+
+	lock sync.Mutex // Serializes markings
+}
+
+func newAccountExpiry(bc *BlockChain, epochLength uint64) *AccountExpiry {
+	if epochLength == 0 {
+		return nil
+	}
+	return &AccountExpiry{bc: bc, epochLength: epochLength}
+}
+
+// AccountExpiry returns the account inactivity tracker, or nil if the tracking
+// is disabled.
+func (bc *BlockChain) AccountExpiry() *AccountExpiry {
+	return bc.accountExpiry
+}
+
+// Epoch returns the epoch of the block with the given number.
+func (e *AccountExpiry) Epoch(number uint64) uint64 {
+	return number / e.epochLength
+}
+
+// recordAccountAccesses updates the last access epochs of the accounts accessed
+// by the given block, if the tracking is enabled.
+func (bc *BlockChain) recordAccountAccesses(batch ethdb.KeyValueWriter, block *types.Block, statedb *state.StateDB) {
+	if bc.accountExpiry == nil {
+		return
+	}
+	epoch := bc.accountExpiry.Epoch(block.NumberU64())
+	for _, tuple := range statedb.BlockAccessList() {
+		if last, ok := rawdb.ReadAccountAccessEpoch(bc.db, tuple.Address); ok && last >= epoch {
+			continue
+		}
+		rawdb.WriteAccountAccessEpoch(batch, tuple.Address, epoch)
+		accountExpiryTouchedMeter.Mark(1)
+
+		if _, inactive := rawdb.ReadInactiveAccount(bc.db, tuple.Address); inactive {
+			rawdb.DeleteInactiveAccount(batch, tuple.Address)
+			accountExpiryReactivatedMeter.Mark(1)
+		}
+	}
+}
+
+// LastAccess returns the epoch the given account was last accessed at, and the
+// epoch it was marked inactive at, if any.
+func (e *AccountExpiry) LastAccess(addr common.Address) (last uint64, tracked bool, inactiveSince uint64, inactive bool) {
+	last, tracked = rawdb.ReadAccountAccessEpoch(e.bc.db, addr)
+	inactiveSince, inactive = rawdb.ReadInactiveAccount(e.bc.db, addr)
+	return last, tracked, inactiveSince, inactive
+}
+
+// Stats walks the tracked accounts and reports their access recency.
+func (e *AccountExpiry) Stats() (*AccountExpiryStats, error) {
+	stats := &AccountExpiryStats{
+		Epoch:       e.Epoch(e.bc.CurrentBlock().Number.Uint64()),
+		EpochLength: e.epochLength,
+		LastAccess:  make(map[uint64]uint64),
+	}
+	it := rawdb.IterateAccountAccessEpochs(e.bc.db)
+	for it.Next() {
+		if value := it.Value(); len(value) == 8 {
+			stats.Tracked++
+			stats.LastAccess[binary.BigEndian.Uint64(value)]++
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	it = rawdb.IterateInactiveAccounts(e.bc.db)
+	defer it.Release()
+	for it.Next() {
+		stats.Inactive++
+	}
+	return stats, it.Error()
+}
+
+// MarkInactive marks the tracked accounts not accessed for at least the given
+// number of epochs as inactive, as of the epoch of the chain head.
+func (e *AccountExpiry) MarkInactive(threshold uint64) (*InactivityResult, error) {
+	if threshold == 0 {
+		return nil, errors.New("inactivity threshold must be at least one epoch")
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	result := &InactivityResult{
+		Epoch:     e.Epoch(e.bc.CurrentBlock().Number.Uint64()),
+		Threshold: threshold,
+	}
+	var candidates []common.Address
+
+	it := rawdb.IterateAccountAccessEpochs(e.bc.db)
+	defer it.Release()
+	for it.Next() {
+		value := it.Value()
+		if len(value) != 8 {
+			continue
+		}
+		result.Scanned++
+		if last := binary.BigEndian.Uint64(value); last+threshold > result.Epoch {
+			continue
+		}
+		candidates = append(candidates, common.BytesToAddress(it.Key()[len(rawdb.AccountAccessPrefix):]))
+		if len(candidates) == accountExpiryMarkBatch {
+			if err := e.mark(candidates, result); err != nil {
+				return nil, err
+			}
+			candidates = candidates[:0]
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if err := e.mark(candidates, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mark marks the given candidates as inactive. The chain mutex is held so that
+// accounts accessed since they were picked as candidates are not marked.
+func (e *AccountExpiry) mark(candidates []common.Address, result *InactivityResult) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	if !e.bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer e.bc.chainmu.Unlock()
+
+	var (
+		batch  = e.bc.db.NewBatch()
+		marked uint64
+	)
+	for _, addr := range candidates {
+		if last, ok := rawdb.ReadAccountAccessEpoch(e.bc.db, addr); !ok || last+result.Threshold > result.Epoch {
+			continue
+		}
+		if _, inactive := rawdb.ReadInactiveAccount(e.bc.db, addr); inactive {
+			continue
+		}
+		rawdb.WriteInactiveAccount(batch, addr, result.Epoch)
+		marked++
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	result.Marked += marked
+	accountExpiryMarkedMeter.Mark(int64(marked))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the last access epochs of the accounts are tracked over the chain,
+// and that inactive accounts are marked and reactivated on access.
+func TestAccountExpiry(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0xaa")
+		engine    = ethash.NewFaker()
+		genesis   = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 5, func(i int, b *BlockGen) {
+		if i == 0 || i == 4 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), recipient, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	config.AccountAccessEpoch = 2
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:4]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	expiry := chain.AccountExpiry()
+	if last, tracked, _, _ := expiry.LastAccess(recipient); !tracked || last != 0 {
+		t.Fatalf("unexpected recipient access epoch: have %d %v, want 0", last, tracked)
+	}
+	if last, tracked, _, _ := expiry.LastAccess(blocks[0].Coinbase()); !tracked || last != 2 {
+		t.Fatalf("unexpected coinbase access epoch: have %d %v, want 2", last, tracked)
+	}
+	// The sender and recipient weren't accessed since epoch 0
+	if _, err := expiry.MarkInactive(0); err == nil {
+		t.Fatal("zero inactivity threshold accepted")
+	}
+	result, err := expiry.MarkInactive(2)
+	if err != nil {
+		t.Fatalf("failed to mark inactive accounts: %v", err)
+	}
+	if result.Epoch != 2 || result.Scanned != 3 || result.Marked != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, _, since, inactive := expiry.LastAccess(recipient); !inactive || since != 2 {
+		t.Fatalf("recipient not marked inactive: %d %v", since, inactive)
+	}
+	// Accessing the accounts again reactivates them
+	if _, err := chain.InsertChain(blocks[4:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	stats, err := expiry.Stats()
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	if stats.Tracked != 3 || stats.Inactive != 0 || stats.LastAccess[2] != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
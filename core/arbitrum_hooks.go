@@ -18,6 +18,7 @@ package core
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -51,6 +52,34 @@ var RPCPostingGasHook = func(msg *Message, header *types.Header, statedb *state.
 // Renders a solidity error in human-readable form
 var RenderRPCError func(data []byte) error
 
+// StylusProgramInfo holds the activation parameters ArbOS keeps for a Stylus program
+type StylusProgramInfo struct {
+	Version    uint16      // Stylus version the program was activated with
+	ModuleHash common.Hash // hash of the activated module, keying the wasm store
+	InitCost   uint64      // gas charged when the program is first called
+	Footprint  uint16      // number of wasm pages the program starts with
+}
+
+// Gets the activation parameters of the Stylus program with the given code hash from ArbOS
+var GetStylusProgramInfo func(statedb *state.StateDB, header *types.Header, codeHash common.Hash) (*StylusProgramInfo, error)
+
+// Gets the module hashes of the Stylus programs ArbOS keeps activated (and not expired) at the given
+// state, along with the number of programs sharing each module
+var GetStylusModuleRefs func(statedb *state.StateDB, header *types.Header) (map[common.Hash]uint64, error)
+
+// Gets the Stylus version the modules ArbOS keeps activated (and not expired) at the given state were
+// activated with, as found in the info of the programs referencing them
+var GetStylusModuleVersions func(statedb *state.StateDB, header *types.Header) (map[common.Hash]uint16, error)
+
+// L1PricingInfo holds the parameters ArbOS uses to charge for posting a transaction's data to L1
+type L1PricingInfo struct {
+	PricePerUnit *big.Int // estimated L1 base fee, in wei per unit of L1 calldata
+	UnitsPerByte uint64   // units charged per byte of compressed transaction data
+}
+
+// Gets the L1 pricing parameters ArbOS applied at the given state
+var GetL1PricingInfo func(statedb *state.StateDB, header *types.Header) (*L1PricingInfo, error)
+
 type NodeInterfaceBackendAPI interface {
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Header
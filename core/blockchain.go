@@ -142,6 +142,7 @@ type CacheConfig struct {
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
+	StateHistoryBytes   uint64        // Arbitrum: maximum size of the state histories reserved (0 = unlimited)
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 
 	SnapshotRestoreMaxGas uint64 // Rollback up to this much gas to restore snapshot (otherwise snapshot recalculated from nothing)
@@ -153,13 +154,81 @@ type CacheConfig struct {
 	MaxNumberOfBlocksToSkipStateSaving uint32
 	MaxAmountOfGasToSkipStateSaving    uint64
 
+	// Arbitrum: wasm store garbage collection
+	WasmGCInterval  time.Duration // Interval between collections of unreferenced Stylus modules (0 = manual only)
+	WasmGCRetention uint64        // Number of blocks an unreferenced Stylus module is retained for
+	WasmFreezeAge   uint64        // Number of blocks after activation a Stylus module is moved to the wasm freezer at (0 = never)
+
+	// Arbitrum: memory allowance (MB) to use for caching activated Stylus asm (0 = default)
+	StylusAsmCacheLimit int
+
+	// Arbitrum: store the state in a verkle tree rather than a merkle patricia
+	// trie. Experimental, requires the path scheme and disables the snapshot.
+	StateVerkle bool
+
+	// Arbitrum: snapshot diff layer retention, independent of TriesInMemory
+	SnapshotDiffLayers      int // Number of snapshot diff layers kept in memory (0 = default)
+	SnapshotAggregatorLimit int // Memory allowance (MB) of the bottom-most snapshot diff layer (0 = default)
+
+	// Arbitrum: number of recent blocks whose state diffs are retained in memory (0 = disabled)
+	ReverseDiffRetention int
+
+	// Arbitrum: number of blocks per account access epoch (0 = inactivity tracking disabled)
+	AccountAccessEpoch uint64
+
+	// Arbitrum: number of hash scheme state flushes allowed to run behind the
+	// block production (0 = flush synchronously)
+	CommitPipelineDepth int
+
+	// Arbitrum: persist the snapshot diff layers as they're created, to recover
+	// them after an unclean shutdown
+	SnapshotAsyncJournal bool
+
+	// Arbitrum: memory allowance (MB) shared by the state caches, evicting the
+	// account, code and asm caches by priority once exceeded (0 = disabled)
+	MemoryBudget int
+
+	// Arbitrum: maintain an exact per-address and per-topic log index at block
+	// import, used by the log filters over the range of blocks it covers
+	LogIndex bool
+
+	// Arbitrum: retention window of the receipts and logs, in blocks and in
+	// time, the older ones being pruned (0 = retained forever)
+	ReceiptsRetentionBlocks uint64
+	ReceiptsRetentionPeriod time.Duration
+
+	// Arbitrum: migrate the state to the path scheme in the background, while
+	// running on the hash scheme, to be cut over to
+	StateMigration bool
+
+	// Arbitrum: index the original values of the accounts and slots modified
+	// by every canonical block by address and block, for historical queries
+	FlatHistory bool
+
+	// Arbitrum: number of the storage slots of the recipient contract, the most
+	// accessed by the recent blocks, prewarmed along with the accounts of the
+	// transactions entering the pool (0 = prewarming disabled)
+	PrewarmSlots int
+
+	// Arbitrum: interval between the walks of the flat state gathering the
+	// state-wide statistics (0 = disabled)
+	StateStatsInterval time.Duration
+
+	// Arbitrum: number of recent blocks the storage writes of the contracts are
+	// counted over, to identify the heaviest writers (0 = disabled)
+	HeavyContractsWindow uint64
+
+	// Arbitrum: number of states materialized at transaction boundaries kept
+	// for the queries of the state within a block (0 = disabled)
+	IntraBlockStates int
+
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
 
 // arbitrum: exposing CacheConfig.triedbConfig to be used by Nitro when initializing arbos in database
 func (c *CacheConfig) TriedbConfig() *triedb.Config {
-	return c.triedbConfig(false)
+	return c.triedbConfig(c.StateVerkle)
 }
 
 // triedbConfig derives the configures for trie database.
@@ -175,9 +244,10 @@ func (c *CacheConfig) triedbConfig(isVerkle bool) *triedb.Config {
 	}
 	if c.StateScheme == rawdb.PathScheme {
 		config.PathDB = &pathdb.Config{
-			StateHistory:   c.StateHistory,
-			CleanCacheSize: c.TrieCleanLimit * 1024 * 1024,
-			DirtyCacheSize: c.TrieDirtyLimit * 1024 * 1024,
+			StateHistory:      c.StateHistory,
+			StateHistoryBytes: c.StateHistoryBytes,
+			CleanCacheSize:    c.TrieCleanLimit * 1024 * 1024,
+			DirtyCacheSize:    c.TrieDirtyLimit * 1024 * 1024,
 		}
 	}
 	return config
@@ -192,6 +262,7 @@ var defaultCacheConfig = &CacheConfig{
 	TrieRetention:                      30 * time.Minute,
 	MaxNumberOfBlocksToSkipStateSaving: 0,
 	MaxAmountOfGasToSkipStateSaving:    0,
+	WasmGCRetention:                    100_000,
 
 	TrieCleanLimit: 256,
 	TrieDirtyLimit: 256,
@@ -234,25 +305,44 @@ type BlockChain struct {
 	chainConfig *params.ChainConfig // Chain & network configuration
 	cacheConfig *CacheConfig        // Cache configuration for pruning
 
-	db            ethdb.Database                   // Low level persistent database to store final content in
-	snaps         *snapshot.Tree                   // Snapshot tree for fast trie leaf access
-	triegc        *prque.Prque[int64, trieGcEntry] // Priority queue mapping block numbers to tries to gc
-	gcproc        time.Duration                    // Accumulates canonical block processing for trie dumping
-	lastWrite     uint64                           // Last block when the state was flushed
-	flushInterval atomic.Int64                     // Time interval (processing time) after which to flush a state
-	triedb        *triedb.Database                 // The database handler for maintaining trie nodes.
-	stateCache    state.Database                   // State database to reuse between imports (contains state cache)
-	txIndexer     *txIndexer                       // Transaction indexer, might be nil if not enabled
-
-	hc            *HeaderChain
-	rmLogsFeed    event.Feed
-	chainFeed     event.Feed
-	chainSideFeed event.Feed
-	chainHeadFeed event.Feed
-	logsFeed      event.Feed
-	blockProcFeed event.Feed
-	scope         event.SubscriptionScope
-	genesisBlock  *types.Block
+	db               ethdb.Database                   // Low level persistent database to store final content in
+	snaps            *snapshot.Tree                   // Snapshot tree for fast trie leaf access
+	triegc           *prque.Prque[int64, trieGcEntry] // Priority queue mapping block numbers to tries to gc
+	gcproc           time.Duration                    // Accumulates canonical block processing for trie dumping
+	lastWrite        uint64                           // Last block when the state was flushed
+	flushInterval    atomic.Int64                     // Time interval (processing time) after which to flush a state
+	triedb           *triedb.Database                 // The database handler for maintaining trie nodes.
+	stateCache       state.Database                   // State database to reuse between imports (contains state cache)
+	txIndexer        *txIndexer                       // Transaction indexer, might be nil if not enabled
+	wasmGC           *WasmStoreGC                     // Garbage collector of the wasm store
+	accountExpiry    *AccountExpiry                   // Account inactivity tracker, nil if disabled
+	logIndex         *LogIndex                        // Exact log index, nil if disabled
+	stateMigration   *StateMigration                  // Hash to path scheme state migration, nil if disabled
+	flatHistory      *FlatHistory                     // Indexed state changes of the blocks, nil if disabled
+	statePrewarmer   *StatePrewarmer                  // Prewarmer of the state of the pending transactions, nil if disabled
+	stateStats       *StateStats                      // State-wide statistics, nil if disabled
+	heavyContracts   *HeavyContracts                  // Storage writes of the contracts over the recent blocks, nil if disabled
+	slotWatches      *SlotWatches                     // Storage slots watched across blocks
+	intraBlockStates *IntraBlockStates                // States materialized at transaction boundaries, nil if disabled
+	reorgChanges     *reorgChanges                    // Changes of the recent blocks, notified again on reorgs
+	commits          *state.CommitScheduler           // Background state flushes, nil if flushed synchronously
+	memoryBudget     *state.MemoryBudget              // Memory allowance shared by the state caches, nil if disabled
+	recentWasms      atomic.Pointer[RecentWasms]      // Recent programs cache at the end of the last written block
+
+	receiptsRetention *ReceiptsRetention // Receipts pruner, nil if the receipts are retained forever
+	receiptsTail      atomic.Uint64      // Number of the oldest block with retained receipts
+
+	hc               *HeaderChain
+	rmLogsFeed       event.Feed
+	chainFeed        event.Feed
+	chainSideFeed    event.Feed
+	chainHeadFeed    event.Feed
+	logsFeed         event.Feed
+	blockProcFeed    event.Feed
+	stateChangesFeed event.Feed
+	lifecycleFeed    event.Feed
+	scope            event.SubscriptionScope
+	genesisBlock     *types.Block
 
 	// This mutex synchronizes chain write operations.
 	// Readers don't need to take it, they can just read the database.
@@ -267,6 +357,7 @@ type BlockChain struct {
 	bodyRLPCache  *lru.Cache[common.Hash, rlp.RawValue]
 	receiptsCache *lru.Cache[common.Hash, []*types.Receipt]
 	blockCache    *lru.Cache[common.Hash, *types.Block]
+	reverseDiffs  *lru.Cache[common.Hash, *state.ReverseDiff] // Arbitrum: diffs of recent blocks, nil if not retained
 
 	txLookupLock  sync.RWMutex
 	txLookupCache *lru.Cache[common.Hash, txLookup]
@@ -300,8 +391,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
+	if cacheConfig.StateVerkle && cacheConfig.StateScheme != rawdb.PathScheme {
+		return nil, errors.New("verkle state requires the path scheme")
+	}
 	// Open trie database with provided config
-	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig(genesis != nil && genesis.IsVerkle()))
+	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig(cacheConfig.StateVerkle || (genesis != nil && genesis.IsVerkle())))
 
 	var genesisHash common.Hash
 	var genesisErr error
@@ -328,6 +422,17 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	log.Info(strings.Repeat("-", 153))
 	log.Info("")
 
+	// Arbitrum: check the chain's schedule of the EIPs and overrides of the opcode gas costs
+	if err := vm.ValidateEIPActivations(chainConfig); err != nil {
+		return nil, err
+	}
+	if err := vm.ValidateOpcodeGas(chainConfig); err != nil {
+		return nil, err
+	}
+	if overrides := chainConfig.ArbitrumChainParams.OpcodeGas; chainConfig.IsArbitrum() && overrides != nil && len(overrides.Gas) > 0 {
+		log.Warn("Opcode gas costs overridden by the chain config", "arbosVersion", overrides.ArbosVersion, "overrides", overrides.Gas)
+	}
+
 	bc := &BlockChain{
 		chainConfig:   chainConfig,
 		cacheConfig:   cacheConfig,
@@ -346,8 +451,19 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		logger:        vmConfig.Tracer,
 	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
+	if cacheConfig.ReverseDiffRetention > 0 {
+		bc.reverseDiffs = lru.NewCache[common.Hash, *state.ReverseDiff](cacheConfig.ReverseDiffRetention)
+	}
 	bc.forker = NewForkChoice(bc, shouldPreserve)
-	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
+	asmCacheSize := state.DefaultActivatedAsmCacheSize
+	if cacheConfig.StylusAsmCacheLimit > 0 {
+		asmCacheSize = cacheConfig.StylusAsmCacheLimit * 1024 * 1024
+	}
+	if cacheConfig.MemoryBudget > 0 {
+		bc.memoryBudget = state.NewMemoryBudget(uint64(cacheConfig.MemoryBudget) * 1024 * 1024)
+		bc.memoryBudget.Set("trie/clean", uint64(cacheConfig.TrieCleanLimit)*1024*1024)
+	}
+	bc.stateCache = state.NewDatabaseWithNodeDBAndBudget(bc.db, bc.triedb, asmCacheSize, bc.memoryBudget)
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -477,8 +593,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		}
 	}
 
-	// Load any existing snapshot, regenerating it if loading failed
-	if bc.cacheConfig.SnapshotLimit > 0 {
+	// Load any existing snapshot, regenerating it if loading failed. The
+	// snapshot only supports merkle patricia tries.
+	if bc.cacheConfig.SnapshotLimit > 0 && !bc.triedb.IsVerkle() {
 		// If the chain was rewound past the snapshot persistent layer (causing
 		// a recovery block number to be persisted to disk), check if we're still
 		// in recovery mode and in that case, don't invalidate the snapshot on a
@@ -495,8 +612,15 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			Recovery:   recover,
 			NoBuild:    bc.cacheConfig.SnapshotNoBuild,
 			AsyncBuild: !bc.cacheConfig.SnapshotWait,
+
+			DiffLayers:      bc.cacheConfig.SnapshotDiffLayers,
+			AggregatorLimit: uint64(bc.cacheConfig.SnapshotAggregatorLimit) * 1024 * 1024,
+			AsyncJournal:    bc.cacheConfig.SnapshotAsyncJournal,
 		}
 		bc.snaps, _ = snapshot.New(snapconfig, bc.db, bc.triedb, head.Root)
+		if bc.memoryBudget != nil {
+			bc.memoryBudget.Set("snapshot/cache", uint64(bc.cacheConfig.SnapshotLimit)*1024*1024)
+		}
 	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -513,6 +637,48 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if txLookupLimit != nil {
 		bc.txIndexer = newTxIndexer(*txLookupLimit, bc)
 	}
+	// Arbitrum: start collecting the unreferenced Stylus modules if requested
+	bc.wasmGC = newWasmStoreGC(bc, cacheConfig.WasmGCRetention, cacheConfig.WasmFreezeAge)
+	bc.accountExpiry = newAccountExpiry(bc, cacheConfig.AccountAccessEpoch)
+	bc.logIndex = newLogIndex(bc, cacheConfig.LogIndex)
+	bc.flatHistory = newFlatHistory(bc, cacheConfig.FlatHistory)
+	if tail := rawdb.ReadReceiptsTail(db); tail != nil {
+		bc.receiptsTail.Store(*tail)
+	}
+	bc.receiptsRetention = newReceiptsRetention(bc, cacheConfig.ReceiptsRetentionBlocks, cacheConfig.ReceiptsRetentionPeriod)
+	if bc.receiptsRetention != nil {
+		bc.wg.Add(1)
+		go bc.receiptsRetentionLoop()
+	}
+	if cacheConfig.WasmGCInterval > 0 {
+		bc.wg.Add(1)
+		go bc.wasmGCLoop(cacheConfig.WasmGCInterval)
+	}
+	bc.stateMigration = newStateMigration(bc, cacheConfig.StateMigration)
+	if bc.stateMigration != nil {
+		bc.wg.Add(1)
+		go bc.stateMigrationLoop()
+	}
+	bc.statePrewarmer = newStatePrewarmer(bc, cacheConfig.PrewarmSlots)
+	if bc.statePrewarmer != nil {
+		bc.wg.Add(1)
+		go bc.statePrewarmLoop()
+	}
+	bc.heavyContracts = newHeavyContracts(cacheConfig.HeavyContractsWindow)
+	bc.slotWatches = newSlotWatches()
+	bc.reorgChanges = newReorgChanges()
+	bc.intraBlockStates = newIntraBlockStates(cacheConfig.IntraBlockStates)
+	bc.stateStats = newStateStats(bc, cacheConfig.StateStatsInterval)
+	if bc.stateStats != nil {
+		bc.wg.Add(1)
+		go bc.stateStatsLoop(cacheConfig.StateStatsInterval)
+	}
+	// Arbitrum: overlap the state flushes with the following blocks if requested
+	if cacheConfig.CommitPipelineDepth > 0 && bc.triedb.Scheme() == rawdb.HashScheme {
+		if bc.commits, err = state.NewCommitScheduler(cacheConfig.CommitPipelineDepth); err != nil {
+			return nil, err
+		}
+	}
 	return bc, nil
 }
 
@@ -1162,6 +1328,7 @@ func (bc *BlockChain) stopWithoutSaving() {
 	}
 	// Unsubscribe all subscriptions registered from blockchain.
 	bc.scope.Close()
+	bc.slotWatches.scope.Close()
 
 	// Signal shutdown to all goroutines.
 	close(bc.quit)
@@ -1175,6 +1342,13 @@ func (bc *BlockChain) stopWithoutSaving() {
 	// returned.
 	bc.chainmu.Close()
 	bc.wg.Wait()
+
+	// Wait for the state flushes still running behind the chain modifications
+	if bc.commits != nil {
+		if err := bc.commits.Close(); err != nil {
+			log.Error("Failed to flush state", "err", err)
+		}
+	}
 }
 
 // Stop stops the blockchain service. If any imports are currently in progress
@@ -1238,6 +1412,10 @@ func (bc *BlockChain) Stop() {
 				log.Error("Dangling trie nodes after full cleanup")
 			}
 		}
+		// Arbitrum: bring the state migrated to the path scheme up to the head
+		if bc.stateMigration != nil {
+			bc.stateMigration.flush(bc.CurrentBlock())
+		}
 	}
 	// Allow tracers to clean-up and release resources.
 	if bc.logger != nil && bc.logger.OnClose != nil {
@@ -1557,14 +1735,32 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	rawdb.WriteBlock(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 	rawdb.WritePreimages(blockBatch, statedb.Preimages())
+	bc.recordAccountAccesses(blockBatch, block, statedb)
+	bc.recordSlotAccesses(statedb)
+	bc.recordLogIndex(blockBatch, block, receipts)
+	// Arbitrum: summarize the Stylus executions, for the blocks running programs
+	if stats := statedb.StylusStats(); !stats.Empty() {
+		rawdb.WriteBlockStylusStats(blockBatch, block.Hash(), block.NumberU64(), &stats)
+	}
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
+	// Arbitrum: retain the recent programs cache of the block for introspection
+	bc.recordRecentWasms(block, statedb)
+
 	// Commit all cached state changes into underlying memory database.
 	root, err := statedb.Commit(block.NumberU64(), bc.chainConfig.IsEIP158(block.Number()))
 	if err != nil {
 		return err
 	}
+	// Arbitrum: retain the state diff of the block for replicas
+	bc.recordReverseDiff(block, statedb)
+	bc.recordStorageWrites(statedb)
+	if changes := statedb.CommittedChanges(); changes != nil {
+		bc.reorgChanges.retain(block.Header(), changes, statedb.CommittedAccountEvents())
+	}
+	bc.trackSnapshotMemory()
+
 	// If node is running in path mode, skip explicit gc operation
 	// which is unnecessary in this mode.
 	if bc.triedb.Scheme() == rawdb.PathScheme {
@@ -1596,7 +1792,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		if !maySkipCommiting || blockLimitReached || gasLimitReached {
 			bc.numberOfBlocksToSkipStateSaving = bc.cacheConfig.MaxNumberOfBlocksToSkipStateSaving
 			bc.amountOfGasInBlocksToSkipStateSaving = bc.cacheConfig.MaxAmountOfGasToSkipStateSaving
-			return bc.triedb.Commit(root, false)
+			return bc.flushState(root, func() error {
+				return bc.triedb.Commit(root, false)
+			})
 		}
 		// we are skipping saving the trie to diskdb, so we need to keep the trie in memory and garbage collect it later
 	}
@@ -1609,17 +1807,13 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	timeLimit := time.Now().Unix() - int64(bc.cacheConfig.TrieRetention.Seconds()) // only cleared if less than that
 
 	if blockLimit > 0 && timeLimit > 0 {
-		// If we exceeded our memory allowance, flush matured singleton nodes to disk
+		// Collect the tries to garbage collect below our required write retention,
+		// the trie database is only touched by the flush itself
 		var (
-			_, nodes, imgs = bc.triedb.Size() // all memory is contained within the nodes return for hashdb
-			limit          = common.StorageSize(bc.cacheConfig.TrieDirtyLimit) * 1024 * 1024
+			stale     []common.Hash
+			prevEntry *trieGcEntry
+			prevNum   uint64
 		)
-		if nodes > limit || imgs > 4*1024*1024 {
-			bc.triedb.Cap(limit - ethdb.IdealBatchSize)
-		}
-		var prevEntry *trieGcEntry
-		var prevNum uint64
-		// Garbage collect anything below our required write retention
 		for !bc.triegc.Empty() {
 			triegcEntry, number := bc.triegc.Pop()
 			if uint64(-number) > uint64(blockLimit) || triegcEntry.Timestamp > uint64(timeLimit) {
@@ -1627,11 +1821,12 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 				break
 			}
 			if prevEntry != nil {
-				bc.triedb.Dereference(prevEntry.Root)
+				stale = append(stale, prevEntry.Root)
 			}
 			prevEntry = &triegcEntry
 			prevNum = uint64(-number)
 		}
+		var commitRoot common.Hash
 		flushInterval := time.Duration(bc.flushInterval.Load())
 		// If we exceeded out time allowance, flush an entire trie to disk
 		// In case of archive node that skips some trie commits we don't flush tries here
@@ -1648,13 +1843,36 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(prevNum-bc.lastWrite)/float64(bc.cacheConfig.TriesInMemory))
 				}
 				// Flush an entire trie and restart the counters
-				bc.triedb.Commit(header.Root, true)
+				commitRoot = header.Root
 				bc.lastWrite = prevNum
 				bc.gcproc = 0
 			}
 		}
 		if prevEntry != nil {
-			bc.triedb.Dereference(prevEntry.Root)
+			stale = append(stale, prevEntry.Root)
+		}
+		err := bc.flushState(root, func() error {
+			// If we exceeded our memory allowance, flush matured singleton nodes to disk
+			var (
+				_, nodes, imgs = bc.triedb.Size() // all memory is contained within the nodes return for hashdb
+				limit          = common.StorageSize(bc.cacheConfig.TrieDirtyLimit) * 1024 * 1024
+			)
+			if nodes > limit || imgs > 4*1024*1024 {
+				bc.triedb.Cap(limit - ethdb.IdealBatchSize)
+			}
+			// Garbage collect the stale tries, keeping the one to flush until flushed
+			for i, hash := range stale {
+				if i == len(stale)-1 && commitRoot != (common.Hash{}) {
+					if err := bc.triedb.Commit(commitRoot, true); err != nil {
+						return err
+					}
+				}
+				bc.triedb.Dereference(hash)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
@@ -1665,6 +1883,15 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	return nil
 }
 
+// flushState runs the disk flush stage of a block commit, in the background if
+// the commit pipeline is enabled.
+func (bc *BlockChain) flushState(root common.Hash, flush func() error) error {
+	if bc.commits == nil {
+		return flush()
+	}
+	return bc.commits.Schedule(root, flush)
+}
+
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
 func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
@@ -1690,12 +1917,15 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
+		// Arbitrum: index the state changes of the canonical block
+		bc.recordFlatHistory(block, state)
 	}
 	if status == CanonStatTy {
 		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
 		if len(logs) > 0 {
 			bc.logsFeed.Send(logs)
 		}
+		bc.notifyChanges(block.Header(), state.CommittedChanges(), state.CommittedAccountEvents())
 		// In theory, we should fire a ChainHeadEvent when we inject
 		// a canonical block, but sometimes we can insert a batch of
 		// canonical blocks. Avoid firing too many ChainHeadEvents,
@@ -2461,6 +2691,12 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 	// logs from the new canon chain. The number of logs can be very
 	// high, so the events are sent in batches of size around 512.
 
+	// Arbitrum: notify the removal of the state changes of the old canon
+	// chain, from the old head down, for the changes to be reverted in order
+	for _, block := range oldChain {
+		bc.notifyRemovedChanges(block)
+	}
+
 	// Deleted logs + blocks:
 	var deletedLogs []*types.Log
 	for i := len(oldChain) - 1; i >= 0; i-- {
@@ -2494,6 +2730,11 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 	if len(rebirthLogs) > 0 {
 		bc.logsFeed.Send(rebirthLogs)
 	}
+	// Arbitrum: notify the state changes of the blocks joining the canonical
+	// chain, except for the new head notified by the caller
+	for i := len(newChain) - 1; i >= 1; i-- {
+		bc.notifyAddedChanges(newChain[i])
+	}
 	return nil
 }
 
@@ -2528,6 +2769,10 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 		}
 		log.Info("Recovered head state", "number", head.Number(), "hash", head.Hash())
 	}
+	// Arbitrum: the changes of the head are only notified if it joins the
+	// canonical chain, rather than being rewound to
+	canonical := bc.GetCanonicalHash(head.NumberU64()) == head.Hash()
+
 	// Run the reorg if necessary and set the given block as new head.
 	start := time.Now()
 	if head.ParentHash() != bc.CurrentBlock().Hash() {
@@ -2543,6 +2788,9 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	if len(logs) > 0 {
 		bc.logsFeed.Send(logs)
 	}
+	if !canonical {
+		bc.notifyAddedChanges(head)
+	}
 	bc.chainHeadFeed.Send(ChainHeadEvent{Block: head})
 
 	context := []interface{}{
@@ -18,6 +18,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -39,6 +40,12 @@ func (bc *BlockChain) FlushTrieDB(capLimit common.StorageSize) error {
 	}
 	defer bc.chainmu.Unlock()
 
+	// Flush on top of the state flushes still running behind the chain
+	if bc.commits != nil {
+		if err := bc.commits.Wait(); err != nil {
+			return err
+		}
+	}
 	if !bc.triegc.Empty() {
 		_, triegcBlockNumber := bc.triegc.Peek()
 		blockNumber := uint64(-triegcBlockNumber)
@@ -65,6 +72,29 @@ func (bc *BlockChain) FlushTrieDB(capLimit common.StorageSize) error {
 	return nil
 }
 
+// RepairState restores the trie nodes of the state with the given root missing
+// from the database, fetching them from the given source if not nil, or
+// regenerating them from the snapshot otherwise.
+func (bc *BlockChain) RepairState(ctx context.Context, root common.Hash, source state.TrieNodeSource) (*state.RepairStats, error) {
+	return state.RepairState(ctx, bc.db, bc.triedb, bc.snaps, root, source)
+}
+
+// trackSnapshotMemory accounts the memory used by the snapshot diff layers
+// against the memory budget, if any.
+func (bc *BlockChain) trackSnapshotMemory() {
+	if bc.memoryBudget == nil || bc.snaps == nil {
+		return
+	}
+	diffs, buf := bc.snaps.Size()
+	bc.memoryBudget.Set("snapshot/diffs", uint64(diffs+buf))
+}
+
+// MemoryBudget returns the memory allowance shared by the state caches, nil if
+// disabled.
+func (bc *BlockChain) MemoryBudget() *state.MemoryBudget {
+	return bc.memoryBudget
+}
+
 // WriteBlockAndSetHeadWithTime also counts processTime, which will cause intermittent TrieDirty cache writes
 func (bc *BlockChain) WriteBlockAndSetHeadWithTime(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool, processTime time.Duration) (status WriteStatus, err error) {
 	if !bc.chainmu.TryLock() {
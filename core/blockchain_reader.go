@@ -215,7 +215,7 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 		return receipts
 	}
 	number := rawdb.ReadHeaderNumber(bc.db, hash)
-	if number == nil {
+	if number == nil || *number < bc.receiptsTail.Load() {
 		return nil
 	}
 	header := bc.GetHeader(hash, *number)
@@ -443,6 +443,16 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
 }
 
+// SubscribeStateChangesEvent registers a subscription of StateChangesEvent.
+func (bc *BlockChain) SubscribeStateChangesEvent(ch chan<- StateChangesEvent) event.Subscription {
+	return bc.scope.Track(bc.stateChangesFeed.Subscribe(ch))
+}
+
+// SubscribeAccountLifecycleEvent registers a subscription of AccountLifecycleEvent.
+func (bc *BlockChain) SubscribeAccountLifecycleEvent(ch chan<- AccountLifecycleEvent) event.Subscription {
+	return bc.scope.Track(bc.lifecycleFeed.Subscribe(ch))
+}
+
 // SubscribeBlockProcessingEvent registers a subscription of bool where true means
 // block processing has started while false means it has stopped.
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
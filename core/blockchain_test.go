@@ -4221,3 +4221,75 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+func TestVerkleStateRequiresPathScheme(t *testing.T) {
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.StateVerkle = true
+
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	if _, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil); err == nil {
+		t.Fatal("verkle state accepted with the hash scheme")
+	}
+}
+
+// Tests that the states flushed through the commit pipeline end up on disk, for
+// both archive and garbage collecting nodes.
+func TestCommitPipeline(t *testing.T) {
+	t.Run("archive", func(t *testing.T) { testCommitPipeline(t, true) })
+	t.Run("gc", func(t *testing.T) { testCommitPipeline(t, false) })
+}
+
+func testCommitPipeline(t *testing.T, archive bool) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		signer  = types.LatestSigner(params.TestChainConfig)
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 32, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i)}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	config.CommitPipelineDepth = 2
+	config.TrieDirtyDisabled = archive
+	config.TriesInMemory = 4
+	config.TrieRetention = 0
+	config.TrieTimeLimit = time.Nanosecond
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := chain.commits.Wait(); err != nil {
+		t.Fatalf("failed to flush states: %v", err)
+	}
+	// Archive nodes flush every state, the others the ones out of the window
+	for _, block := range blocks {
+		flushed := rawdb.HasLegacyTrieNode(db, block.Root())
+		if archive && !flushed {
+			t.Fatalf("state of block %d not flushed", block.NumberU64())
+		}
+		if !archive && block.NumberU64() == uint64(len(blocks))-config.TriesInMemory && !flushed {
+			t.Fatalf("state of block %d not flushed", block.NumberU64())
+		}
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open head state: %v", err)
+	}
+	if nonce := statedb.GetNonce(sender); nonce != uint64(len(blocks)) {
+		t.Fatalf("sender nonce mismatch: have %d, want %d", nonce, len(blocks))
+	}
+}
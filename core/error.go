@@ -107,4 +107,8 @@ var (
 
 	// ErrBlobTxCreate is returned if a blob transaction has no explicit to field.
 	ErrBlobTxCreate = errors.New("blob transaction of type create")
+
+	// ErrBlobsUnsupported is returned if a message carries blobs in a block without
+	// a blob fee market, as Arbitrum blocks, outside of the RPC simulations.
+	ErrBlobsUnsupported = errors.New("blobs not supported outside of simulations")
 )
@@ -18,6 +18,7 @@ package core
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -41,3 +42,22 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// StateChangesEvent is posted when a canonical block has been imported, carrying
+// the account and storage changes committed by it. It's posted again with
+// Removed set if the block is dropped from the canonical chain by a reorg.
+type StateChangesEvent struct {
+	Header  *types.Header
+	Changes *state.StateChanges
+	Removed bool
+}
+
+// AccountLifecycleEvent is posted when a canonical block has been imported that
+// created, destructed, resurrected or replaced the code of accounts. It's posted
+// again with Removed set if the block is dropped from the canonical chain by a
+// reorg.
+type AccountLifecycleEvent struct {
+	Header  *types.Header
+	Events  []state.AccountEvent
+	Removed bool
+}
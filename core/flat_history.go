@@ -0,0 +1,239 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	flatHistoryEntriesMeter = metrics.NewRegisteredMeter("chain/flathistory/entries", nil)
+	flatHistoryUnwoundMeter = metrics.NewRegisteredMeter("chain/flathistory/unwound", nil)
+	flatHistoryPrunedMeter  = metrics.NewRegisteredMeter("chain/flathistory/pruned", nil)
+)
+
+var (
+	errFlatHistoryEmpty     = errors.New("flat history is empty")
+	errFlatHistoryUncovered = errors.New("block range not covered by the flat history")
+)
+
+// FlatHistoryStatus describes the range of blocks covered by the flat history.
+type FlatHistoryStatus struct {
+	Tail    uint64 // Number of the oldest indexed block
+	Head    uint64 // Number of the newest indexed block
+	Blocks  uint64 // Number of blocks unindexed by the operation
+	Entries uint64 // Number of index entries deleted by the operation
+}
+
+// FlatHistory indexes the original values of the accounts and storage slots
+// modified by every canonical block, keyed by address (and slot) and block
+// number. The value of an account or slot after any indexed block, or the one
+// preceding them, is the original value recorded by the first block modifying
+// it afterwards, found with a single seek, or its value in the state of the
+// newest indexed block if there is none. Archive nodes can so answer historical
+// point queries without opening the state of the queried block.
+//
+// The changes of every canonical block written with its state are indexed as
+// it's imported. Blocks not extending the indexed chain unwind the indexed
+// blocks at and above their height, and the range restarts from them unless
+// they connect to the remaining indexed chain. Storage deletions deferred to
+// the storage deleter are not indexed, like in the state history.
+type FlatHistory struct {
+	bc *BlockChain
+
+	lock sync.Mutex // Serializes prunings
+}
+
+func newFlatHistory(bc *BlockChain, enabled bool) *FlatHistory {
+	if !enabled {
+		return nil
+	}
+	return &FlatHistory{bc: bc}
+}
+
+// FlatHistory returns the flat state history, or nil if the indexing is
+// disabled.
+func (bc *BlockChain) FlatHistory() *FlatHistory {
+	return bc.flatHistory
+}
+
+// recordFlatHistory indexes the state changes committed by the given canonical
+// block, if the indexing is enabled.
+func (bc *BlockChain) recordFlatHistory(block *types.Block, statedb *state.StateDB) {
+	if bc.flatHistory == nil {
+		return
+	}
+	changes := statedb.CommittedChanges()
+	if changes == nil {
+		return
+	}
+	var (
+		number = block.NumberU64()
+		batch  = bc.db.NewBatch()
+	)
+	tail, head, hash, ok := rawdb.ReadFlatHistoryRange(bc.db)
+	if ok && number <= head {
+		// Unwind the indexed blocks reorged out, and connect to the block below
+		var unwound int
+		for n := head + 1; n > max(number, tail); n-- {
+			unwound += rawdb.DeleteFlatHistory(bc.db, batch, n-1)
+		}
+		flatHistoryUnwoundMeter.Mark(int64(unwound))
+
+		head, hash = number-1, common.Hash{}
+		if number > tail {
+			hash, _ = rawdb.ReadFlatHistoryHash(bc.db, head)
+		}
+	}
+	if !ok || number <= tail || number != head+1 || block.ParentHash() != hash {
+		rawdb.WriteFlatHistoryTail(batch, number)
+	}
+	flatHistoryEntriesMeter.Mark(int64(rawdb.WriteFlatHistory(batch, number, block.Hash(), changes.AccountsOrigin, changes.StoragesOrigin)))
+	rawdb.WriteFlatHistoryHead(batch, number, block.Hash())
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write flat history", "err", err)
+	}
+}
+
+// Status returns the range of blocks covered by the flat history.
+func (fh *FlatHistory) Status() (*FlatHistoryStatus, error) {
+	tail, head, _, ok := rawdb.ReadFlatHistoryRange(fh.bc.db)
+	if !ok {
+		return nil, errFlatHistoryEmpty
+	}
+	return &FlatHistoryStatus{Tail: tail, Head: head}, nil
+}
+
+// Prune deletes the entries of all the blocks below the given one, including
+// the leftovers of the blocks below a restarted range.
+func (fh *FlatHistory) Prune(before uint64) (*FlatHistoryStatus, error) {
+	fh.lock.Lock()
+	defer fh.lock.Unlock()
+
+	tail, head, _, ok := rawdb.ReadFlatHistoryRange(fh.bc.db)
+	if !ok {
+		return nil, errFlatHistoryEmpty
+	}
+	status := &FlatHistoryStatus{Tail: tail, Head: head}
+
+	// Move the tail first, so that the entries being deleted are not relied on.
+	// The head is kept indexed, as it anchors the queries.
+	before = min(before, head)
+	if before > tail {
+		if !fh.bc.chainmu.TryLock() {
+			return nil, errChainStopped
+		}
+		if tail, _, _, ok = rawdb.ReadFlatHistoryRange(fh.bc.db); ok && before > tail {
+			rawdb.WriteFlatHistoryTail(fh.bc.db, before)
+			status.Blocks, status.Tail = before-tail, before
+		}
+		fh.bc.chainmu.Unlock()
+	}
+	numbers, err := rawdb.ReadFlatHistoryChangesets(fh.bc.db, before)
+	if err != nil {
+		return status, err
+	}
+	batch := fh.bc.db.NewBatch()
+	for _, number := range numbers {
+		status.Entries += uint64(rawdb.DeleteFlatHistory(fh.bc.db, batch, number))
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return status, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return status, err
+	}
+	flatHistoryPrunedMeter.Mark(int64(status.Entries))
+	log.Info("Pruned flat history", "before", before, "entries", status.Entries)
+	return status, nil
+}
+
+// StorageHistory returns the value of the given storage slot after the first
+// block of the range, followed by its new values after each of the blocks of
+// the range modifying it, like BlockChain.StorageHistory.
+func (fh *FlatHistory) StorageHistory(address common.Address, slot common.Hash, from, to uint64) ([]StorageHistoryEntry, error) {
+	tail, head, hash, ok := rawdb.ReadFlatHistoryRange(fh.bc.db)
+	if !ok || from+1 < tail || to > head || rawdb.ReadCanonicalHash(fh.bc.db, head) != hash {
+		return nil, errFlatHistoryUncovered
+	}
+	numbers, origins, err := rawdb.ReadFlatStorageHistory(fh.bc.db, address, crypto.Keccak256Hash(slot.Bytes()), from, to)
+	if err != nil {
+		return nil, err
+	}
+	// The value after the last modification is the one of the indexed head
+	value := func(i int) (common.Hash, error) {
+		if i < len(origins) {
+			return decodeStorageOrigin(origins[i])
+		}
+		header := fh.bc.GetHeaderByHash(hash)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("header of block %d unavailable", head)
+		}
+		statedb, err := fh.bc.StateAt(header.Root)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("state of block %d unavailable: %w", head, err)
+		}
+		return statedb.GetState(address, slot), nil
+	}
+	current, err := value(0)
+	if err != nil {
+		return nil, err
+	}
+	entries := []StorageHistoryEntry{{Block: from, Value: current}}
+	for i, number := range numbers {
+		if number > to {
+			break
+		}
+		next, err := value(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		if next != current {
+			entries = append(entries, StorageHistoryEntry{Block: number, Value: next})
+		}
+		current = next
+	}
+	return entries, nil
+}
+
+// decodeStorageOrigin decodes the original value of a storage slot, encoded in
+// prefix-zero trimmed RLP and empty if the slot was not present.
+func decodeStorageOrigin(blob []byte) (common.Hash, error) {
+	if len(blob) == 0 {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
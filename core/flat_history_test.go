@@ -0,0 +1,160 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the flat history answers the storage history of the canonical
+// chain, across reorgs unwinding the indexed blocks and prunings.
+func TestFlatHistory(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		storer = common.HexToAddress("0xee")
+		slot   = common.BigToHash(big.NewInt(1))
+		engine = ethash.NewFaker()
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+			storer: {Code: []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}, Storage: map[common.Hash]common.Hash{slot: {0x01}}},
+		},
+	}
+	// The slot is set every third block and cleared every fourth, the fork
+	// setting it every fifth block only
+	generate := func(fork bool) func(int, *BlockGen) {
+		return func(i int, b *BlockGen) {
+			number := b.Number().Uint64()
+			value := common.BigToHash(new(big.Int).SetUint64(number))
+			switch {
+			case fork && number%5 != 0:
+				return
+			case fork:
+				value[0] = 0xff
+			case number%4 == 0:
+				value = common.Hash{}
+			case number%3 != 0:
+				return
+			}
+			data := append(value.Bytes(), slot.Bytes()...)
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), storer, nil, 100000, b.BaseFee(), data), signer, key)
+			b.AddTx(tx)
+		}
+	}
+	genDb, blocks, _ := GenerateChainWithGenesis(genesis, engine, 20, generate(false))
+	fork, _ := GenerateChain(genesis.Config, blocks[9], engine, genDb, 10, generate(true))
+
+	config.TrieDirtyDisabled = true
+	config.FlatHistory = true
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	history := chain.FlatHistory()
+	check := func(from, to uint64) {
+		t.Helper()
+
+		// Derive the expected history from the archived states
+		var want []StorageHistoryEntry
+		for number := from; number <= to; number++ {
+			statedb, err := chain.StateAt(chain.GetHeaderByNumber(number).Root)
+			if err != nil {
+				t.Fatalf("failed to open state %d: %v", number, err)
+			}
+			value := statedb.GetState(storer, slot)
+			if len(want) == 0 || want[len(want)-1].Value != value {
+				want = append(want, StorageHistoryEntry{Block: number, Value: value})
+			}
+		}
+		have, err := history.StorageHistory(storer, slot, from, to)
+		if err != nil {
+			t.Fatalf("failed to retrieve history %d-%d: %v", from, to, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Fatalf("history %d-%d mismatch:\nhave %v\nwant %v", from, to, have, want)
+		}
+	}
+	checkStatus := func(tail, head uint64) {
+		t.Helper()
+
+		status, err := history.Status()
+		if err != nil {
+			t.Fatalf("failed to get status: %v", err)
+		}
+		if status.Tail != tail || status.Head != head {
+			t.Fatalf("range mismatch: have %d-%d, want %d-%d", status.Tail, status.Head, tail, head)
+		}
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	checkStatus(1, 20)
+	check(0, 20)
+	check(6, 13)
+	check(20, 20)
+
+	// Reimport the blocks above 10 from the fork, unwinding the original ones
+	if err := chain.SetHead(10); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	checkStatus(1, 20)
+	check(0, 20)
+	check(9, 15)
+
+	// Prune the oldest blocks, the block preceding the tail remaining queryable
+	status, err := history.Prune(5)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if status.Tail != 5 || status.Blocks != 4 || status.Entries == 0 {
+		t.Fatalf("unexpected prune status: %+v", status)
+	}
+	check(4, 20)
+	if _, err := history.StorageHistory(storer, slot, 3, 20); !errors.Is(err, errFlatHistoryUncovered) {
+		t.Fatalf("pruned range served: %v", err)
+	}
+	if numbers, err := rawdb.ReadFlatHistoryChangesets(chain.db, 5); err != nil || len(numbers) != 0 {
+		t.Fatalf("pruned changesets left: %v %v", numbers, err)
+	}
+	// Storage histories are served from the flat history regardless of the scheme
+	if _, err := chain.StorageHistory(storer, slot, 4, 20); err != nil {
+		t.Fatalf("failed to retrieve history through the chain: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	heavyContractsWritesMeter = metrics.NewRegisteredMeter("chain/heavy/writes", nil)
+	heavyContractsGauge       = metrics.NewRegisteredGauge("chain/heavy/contracts", nil)
+	heavyContractsTopGauge    = metrics.NewRegisteredGauge("chain/heavy/top", nil)
+)
+
+// ContractWrites is the number of storage writes of a contract over the recent
+// blocks.
+type ContractWrites struct {
+	Address common.Address
+	Writes  uint64 // Number of storage slots modified, counted once per block
+	Blocks  uint64 // Number of blocks modifying the storage of the contract
+}
+
+// HeavyContracts counts the storage writes of every contract over a rolling
+// window of the recent blocks written, to identify the contracts driving the
+// state growth. The writes of side chain blocks are counted too.
+type HeavyContracts struct {
+	window int // Number of recent blocks the writes are counted over
+
+	lock   sync.Mutex
+	blocks []map[common.Address]int           // Writes of the blocks in the window, oldest first
+	totals map[common.Address]*ContractWrites // Writes of the contracts over the window
+}
+
+func newHeavyContracts(window uint64) *HeavyContracts {
+	if window == 0 {
+		return nil
+	}
+	return &HeavyContracts{
+		window: int(window),
+		totals: make(map[common.Address]*ContractWrites),
+	}
+}
+
+// HeavyContracts returns the tracker of the contracts writing the most storage,
+// or nil if the tracking is disabled.
+func (bc *BlockChain) HeavyContracts() *HeavyContracts {
+	return bc.heavyContracts
+}
+
+// recordStorageWrites counts the storage writes committed by the state of the
+// block written, if the tracking is enabled.
+func (bc *BlockChain) recordStorageWrites(statedb *state.StateDB) {
+	if bc.heavyContracts == nil {
+		return
+	}
+	if changes := statedb.CommittedChanges(); changes != nil {
+		bc.heavyContracts.add(changes.StorageWrites())
+	}
+}
+
+func (h *HeavyContracts) add(writes map[common.Address]int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var total int
+	for addr, n := range writes {
+		contract := h.totals[addr]
+		if contract == nil {
+			contract = &ContractWrites{Address: addr}
+			h.totals[addr] = contract
+		}
+		contract.Writes += uint64(n)
+		contract.Blocks++
+		total += n
+	}
+	h.blocks = append(h.blocks, writes)
+
+	// Drop the writes of the blocks leaving the window
+	for len(h.blocks) > h.window {
+		for addr, n := range h.blocks[0] {
+			contract := h.totals[addr]
+			if contract.Blocks--; contract.Blocks == 0 {
+				delete(h.totals, addr)
+			} else {
+				contract.Writes -= uint64(n)
+			}
+		}
+		h.blocks[0] = nil
+		h.blocks = h.blocks[1:]
+	}
+	heavyContractsWritesMeter.Mark(int64(total))
+	heavyContractsGauge.Update(int64(len(h.totals)))
+	if top := h.top(1); len(top) > 0 {
+		heavyContractsTopGauge.Update(int64(top[0].Writes))
+	} else {
+		heavyContractsTopGauge.Update(0)
+	}
+}
+
+// Window returns the number of recent blocks the writes are counted over, and
+// the number of blocks counted so far.
+func (h *HeavyContracts) Window() (window int, blocks int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.window, len(h.blocks)
+}
+
+// Top returns the given number of contracts with the most storage writes over
+// the window, by decreasing number of writes.
+func (h *HeavyContracts) Top(n int) []ContractWrites {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.top(n)
+}
+
+func (h *HeavyContracts) top(n int) []ContractWrites {
+	contracts := make([]ContractWrites, 0, len(h.totals))
+	for _, contract := range h.totals {
+		contracts = append(contracts, *contract)
+	}
+	slices.SortFunc(contracts, func(a, b ContractWrites) int {
+		if a.Writes != b.Writes {
+			if a.Writes > b.Writes {
+				return -1
+			}
+			return 1
+		}
+		return a.Address.Cmp(b.Address)
+	})
+	return contracts[:min(n, len(contracts))]
+}
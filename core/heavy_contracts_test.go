@@ -0,0 +1,96 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the storage writes of the contracts are counted over the rolling
+// window of the recent blocks.
+func TestHeavyContracts(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		heavy  = common.HexToAddress("0xee")
+		light  = common.HexToAddress("0xef")
+		engine = ethash.NewFaker()
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+		storer = []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			heavy:  {Code: storer},
+			light:  {Code: storer},
+		},
+	}
+	store := func(b *BlockGen, to common.Address, slot uint64, value byte) {
+		data := append(common.Hash{value}.Bytes(), common.BigToHash(new(big.Int).SetUint64(slot)).Bytes()...)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(tx)
+	}
+	// The heavy contract writes two slots per block, the light one a slot in
+	// the first block only
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		store(b, heavy, 1, byte(i+1))
+		store(b, heavy, 2, byte(i+1))
+		if i == 0 {
+			store(b, light, 1, 1)
+		}
+	})
+	config.HeavyContractsWindow = 3
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	tracker := chain.HeavyContracts()
+	want := []ContractWrites{{Address: heavy, Writes: 4, Blocks: 2}, {Address: light, Writes: 1, Blocks: 1}}
+	if have := tracker.Top(10); !slices.Equal(have, want) {
+		t.Fatalf("top contracts mismatch: have %v, want %v", have, want)
+	}
+	// The first block leaves the window
+	if _, err := chain.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	want = []ContractWrites{{Address: heavy, Writes: 6, Blocks: 3}}
+	if have := tracker.Top(10); !slices.Equal(have, want) {
+		t.Fatalf("top contracts mismatch: have %v, want %v", have, want)
+	}
+	if window, blocks := tracker.Window(); window != 3 || blocks != 3 {
+		t.Fatalf("window mismatch: have %d of %d blocks", blocks, window)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	intraBlockStateHitMeter  = metrics.NewRegisteredMeter("chain/intrablock/hit", nil)
+	intraBlockStateMissMeter = metrics.NewRegisteredMeter("chain/intrablock/miss", nil)
+)
+
+// intraBlockKey identifies the state of a block right before the execution of
+// one of its transactions.
+type intraBlockKey struct {
+	hash  common.Hash
+	index int
+}
+
+// intraBlockState is a materialized intra-block state, released once evicted
+// and no longer used by any of the copies handed out.
+type intraBlockState struct {
+	statedb *state.StateDB
+	release func()
+	refs    int
+}
+
+// IntraBlockStates caches the states of the blocks at transaction boundaries,
+// materialized by replaying the blocks up to the transactions, so that the
+// successive queries of a state within a block don't replay it each time.
+//
+// The states handed out are copies of the cached ones, keeping the underlying
+// state referenced until garbage collected, as the states of the API backends.
+type IntraBlockStates struct {
+	lock   sync.Mutex
+	states lru.BasicLRU[intraBlockKey, *intraBlockState]
+}
+
+func newIntraBlockStates(size int) *IntraBlockStates {
+	if size <= 0 {
+		return nil
+	}
+	return &IntraBlockStates{
+		states: lru.NewBasicLRU[intraBlockKey, *intraBlockState](size),
+	}
+}
+
+// IntraBlockStates returns the cache of the materialized intra-block states, or
+// nil if the caching is disabled.
+func (bc *BlockChain) IntraBlockStates() *IntraBlockStates {
+	return bc.intraBlockStates
+}
+
+// Get returns a copy of the state of the given block right before the execution
+// of the transaction at the given index, or nil if it's not cached.
+func (c *IntraBlockStates) Get(hash common.Hash, index int) *state.StateDB {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.states.Get(intraBlockKey{hash, index})
+	if !ok {
+		intraBlockStateMissMeter.Mark(1)
+		return nil
+	}
+	intraBlockStateHitMeter.Mark(1)
+	return c.copy(entry)
+}
+
+// Add caches the given state of the block right before the execution of the
+// transaction at the given index, taking ownership of it along with its release
+// function, and returns a copy of it.
+func (c *IntraBlockStates) Add(hash common.Hash, index int, statedb *state.StateDB, release func()) *state.StateDB {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := intraBlockKey{hash, index}
+	if entry, ok := c.states.Get(key); ok {
+		// Materialized concurrently, keep the cached one
+		if release != nil {
+			release()
+		}
+		return c.copy(entry)
+	}
+	if c.states.Len() >= c.states.Capacity() {
+		if _, evicted, ok := c.states.RemoveOldest(); ok {
+			c.unref(evicted)
+		}
+	}
+	entry := &intraBlockState{statedb: statedb, release: release, refs: 1}
+	c.states.Add(key, entry)
+	return c.copy(entry)
+}
+
+// copy returns a copy of the cached state, referencing it until the copy is
+// garbage collected. The lock must be held.
+func (c *IntraBlockStates) copy(entry *intraBlockState) *state.StateDB {
+	entry.refs++
+	statedb := entry.statedb.Copy()
+	statedb.SetArbFinalizer(func(*state.ArbitrumExtraData) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.unref(entry)
+	})
+	return statedb
+}
+
+// unref drops a reference to the cached state, releasing it once unused. The
+// lock must be held.
+func (c *IntraBlockStates) unref(entry *intraBlockState) {
+	if entry.refs--; entry.refs == 0 && entry.release != nil {
+		entry.release()
+	}
+}
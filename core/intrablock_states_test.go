@@ -0,0 +1,91 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the intra-block states are cached by block and transaction index,
+// and only released once evicted and no longer used.
+func TestIntraBlockStates(t *testing.T) {
+	var (
+		db     = state.NewDatabase(rawdb.NewMemoryDatabase())
+		states = newIntraBlockStates(1)
+		addr   = common.HexToAddress("0xaa")
+		hash   = common.HexToHash("0x01")
+	)
+	newState := func(balance uint64) *state.StateDB {
+		statedb, _ := state.New(types.EmptyRootHash, db, nil)
+		statedb.SetBalance(addr, uint256.NewInt(balance), 0)
+		return statedb
+	}
+	var released [2]atomic.Bool
+
+	if states.Get(hash, 0) != nil {
+		t.Fatal("uncached state returned")
+	}
+	copied := states.Add(hash, 0, newState(1), func() { released[0].Store(true) })
+	if balance := copied.GetBalance(addr); balance.Uint64() != 1 {
+		t.Fatalf("balance mismatch: have %v, want 1", balance)
+	}
+	// The copies are independent of the cached state
+	copied.SetBalance(addr, uint256.NewInt(2), 0)
+	cached := states.Get(hash, 0)
+	if balance := cached.GetBalance(addr); balance.Uint64() != 1 {
+		t.Fatalf("cached balance mismatch: have %v, want 1", balance)
+	}
+	if states.Get(hash, 1) != nil {
+		t.Fatal("state of another transaction returned")
+	}
+	// A state materialized concurrently is released in favour of the cached one
+	var duplicate atomic.Bool
+	states.Add(hash, 0, newState(3), func() { duplicate.Store(true) })
+	if !duplicate.Load() {
+		t.Fatal("duplicate state not released")
+	}
+	// Evicted states are not released while their copies are in use
+	states.Add(hash, 1, newState(4), func() { released[1].Store(true) })
+	if states.Get(hash, 0) != nil {
+		t.Fatal("evicted state returned")
+	}
+	if released[0].Load() {
+		t.Fatal("state released while in use")
+	}
+	runtime.KeepAlive(copied)
+	runtime.KeepAlive(cached)
+
+	for deadline := time.Now().Add(5 * time.Second); !released[0].Load(); {
+		if time.Now().After(deadline) {
+			t.Fatal("evicted state not released once unused")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if released[1].Load() {
+		t.Fatal("cached state released")
+	}
+}
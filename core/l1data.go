@@ -0,0 +1,65 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// L1DataCompressor estimates the size of the given transaction data once
+// compressed at the given brotli level, as posted to L1 by the batch poster.
+// It is set by the node, nil leaving the compressed size unmetered.
+var L1DataCompressor func(data []byte, level uint64) (uint64, error)
+
+// meterL1Data meters the L1 data of the transaction being applied, given the
+// gas charged to cover its poster cost, on top of the pricing recorded by
+// ArbOS, if enabled by the EVM config or requested by the tracer.
+func (st *StateTransition) meterL1Data(gasForL1 uint64) {
+	var (
+		config = &st.evm.Config
+		tracer = config.Tracer
+	)
+	if !config.MeterL1Data && (tracer == nil || tracer.OnL1DataUsage == nil) {
+		return
+	}
+	if st.msg.Tx == nil {
+		return
+	}
+	data, err := st.msg.Tx.MarshalBinary()
+	if err != nil {
+		log.Debug("Failed to encode the transaction for the L1 data metering", "tx", st.msg.Tx.Hash(), "err", err)
+		return
+	}
+	usage := &types.L1DataUsage{
+		CalldataSize: uint64(len(data)),
+		GasForL1:     gasForL1,
+	}
+	if L1DataCompressor != nil {
+		size, err := L1DataCompressor(data, config.L1DataCompressionLevel)
+		if err != nil {
+			log.Debug("Failed to compress the transaction for the L1 data metering", "tx", st.msg.Tx.Hash(), "err", err)
+		} else {
+			usage.CompressedSize, usage.CompressionLevel = size, config.L1DataCompressionLevel
+		}
+	}
+	st.state.RecordL1DataUsage(usage)
+
+	if tracer != nil && tracer.OnL1DataUsage != nil {
+		tracer.OnL1DataUsage(st.state.L1DataUsage())
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestL1DataMetering(t *testing.T) {
+	L1DataCompressor = func(data []byte, level uint64) (uint64, error) {
+		return uint64(len(data)) / 2, nil
+	}
+	defer func() { L1DataCompressor = nil }()
+
+	var (
+		key, _     = crypto.GenerateKey()
+		addr       = crypto.PubkeyToAddress(key.PublicKey)
+		config     = params.TestChainConfig
+		signer     = types.LatestSigner(config)
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		header     = &types.Header{
+			Number:     big.NewInt(1),
+			GasLimit:   params.GenesisGasLimit,
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+			Difficulty: common.Big0,
+		}
+		gp      = new(GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	statedb.SetBalance(addr, uint256.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+
+	apply := func(nonce uint64, cfg vm.Config, record *types.L1DataUsage) (*types.Receipt, []byte) {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     nonce,
+			To:        &common.Address{0xaa},
+			Gas:       100000,
+			GasFeeCap: big.NewInt(params.InitialBaseFee),
+			Data:      make([]byte, 100),
+		})
+		statedb.SetTxContext(tx.Hash(), int(nonce))
+		if record != nil {
+			// As ArbOS records the pricing of the data while charging it
+			statedb.RecordL1DataUsage(record)
+		}
+		receipt, _, err := ApplyTransaction(config, nil, &common.Address{}, gp, statedb, header, tx, &usedGas, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := tx.MarshalBinary()
+		return receipt, data
+	}
+	// Unmetered unless enabled
+	if receipt, _ := apply(0, vm.Config{}, nil); receipt.L1Data != nil {
+		t.Fatalf("unmetered transaction has L1 data usage: %+v", receipt.L1Data)
+	}
+	// Metered, along with the pricing recorded
+	var (
+		pricing = &types.L1DataUsage{Units: 1600, PricePerUnit: big.NewInt(7), PosterCost: big.NewInt(11200)}
+		metered *types.L1DataUsage
+		tracer  = &tracing.Hooks{OnL1DataUsage: func(usage *types.L1DataUsage) { metered = usage }}
+	)
+	receipt, data := apply(1, vm.Config{MeterL1Data: true, L1DataCompressionLevel: 11, Tracer: tracer}, pricing)
+	usage := receipt.L1Data
+	if usage == nil {
+		t.Fatal("metered transaction has no L1 data usage")
+	}
+	if usage.CalldataSize != uint64(len(data)) || usage.CompressedSize != uint64(len(data))/2 || usage.CompressionLevel != 11 {
+		t.Errorf("sizes mismatch: have %d %d level %d, want %d %d level 11", usage.CalldataSize, usage.CompressedSize, usage.CompressionLevel, len(data), len(data)/2)
+	}
+	if usage.Units != pricing.Units || usage.PricePerUnit.Cmp(pricing.PricePerUnit) != 0 || usage.PosterCost.Cmp(pricing.PosterCost) != 0 {
+		t.Errorf("pricing mismatch: have %+v, want %+v", usage, pricing)
+	}
+	if metered == nil || metered.CalldataSize != usage.CalldataSize || metered.Units != usage.Units {
+		t.Errorf("tracer notified %+v, want %+v", metered, usage)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	logIndexEntriesMeter    = metrics.NewRegisteredMeter("chain/logindex/entries", nil)
+	logIndexBackfilledMeter = metrics.NewRegisteredMeter("chain/logindex/backfilled", nil)
+	logIndexPrunedMeter     = metrics.NewRegisteredMeter("chain/logindex/pruned", nil)
+)
+
+var errLogIndexEmpty = errors.New("log index is empty")
+
+// logIndexBackfillBatch is the number of blocks indexed at once by a backfill,
+// holding the chain mutex.
+const logIndexBackfillBatch = 1024
+
+// LogIndexStatus describes the range of blocks covered by the log index.
+type LogIndexStatus struct {
+	Tail    uint64 // Number of the oldest indexed block
+	Head    uint64 // Number of the newest indexed block
+	Blocks  uint64 // Number of blocks indexed or unindexed by the operation
+	Entries uint64 // Number of index entries written or deleted by the operation
+}
+
+// LogIndex maintains an exact index of the blocks emitting logs from each
+// address and with each topic, supplementing the bloombits. Unlike the bloom
+// filters it has no false positives, making the log queries for a single
+// contract over wide ranges only touch the blocks with matching logs.
+//
+// The logs of every block written with its state are indexed as it's imported,
+// extending the contiguous range of indexed blocks. Older blocks can be indexed
+// with Backfill, and the index pruned below a given block with Prune.
+//
+// Entries are keyed by block number: the entries of blocks reorged out are not
+// removed, but only cause the blocks at the same height to be checked.
+type LogIndex struct {
+	bc *BlockChain
+
+	lock sync.Mutex // Serializes backfills and prunings
+}
+
+func newLogIndex(bc *BlockChain, enabled bool) *LogIndex {
+	if !enabled {
+		return nil
+	}
+	return &LogIndex{bc: bc}
+}
+
+// LogIndex returns the log index, or nil if the indexing is disabled.
+func (bc *BlockChain) LogIndex() *LogIndex {
+	return bc.logIndex
+}
+
+// recordLogIndex indexes the logs of the given block, if the indexing is
+// enabled. Blocks not continuing the indexed range start a new one, leaving the
+// blocks in between to be backfilled.
+func (bc *BlockChain) recordLogIndex(batch ethdb.KeyValueWriter, block *types.Block, receipts types.Receipts) {
+	if bc.logIndex == nil {
+		return
+	}
+	number := block.NumberU64()
+	logIndexEntriesMeter.Mark(int64(rawdb.WriteLogIndexEntries(batch, number, receipts)))
+
+	tail, head, ok := rawdb.ReadLogIndexRange(bc.db)
+	switch {
+	case !ok || tail > head || number > head+1:
+		rawdb.WriteLogIndexTail(batch, number)
+		rawdb.WriteLogIndexHead(batch, number)
+	case number == head+1:
+		rawdb.WriteLogIndexHead(batch, number)
+	}
+}
+
+// Status returns the range of blocks covered by the index.
+func (li *LogIndex) Status() (*LogIndexStatus, error) {
+	tail, head, ok := rawdb.ReadLogIndexRange(li.bc.db)
+	if !ok {
+		return nil, errLogIndexEmpty
+	}
+	return &LogIndexStatus{Tail: tail, Head: head}, nil
+}
+
+// Backfill indexes the canonical blocks from the tail of the index down to the
+// given block. If nothing was indexed yet, the index starts at the chain head.
+func (li *LogIndex) Backfill(ctx context.Context, from uint64) (*LogIndexStatus, error) {
+	li.lock.Lock()
+	defer li.lock.Unlock()
+
+	status := new(LogIndexStatus)
+	for {
+		if err := ctx.Err(); err != nil {
+			return status, err
+		}
+		done, err := li.backfill(from, status)
+		if err != nil || done {
+			return status, err
+		}
+	}
+}
+
+// backfill indexes a batch of blocks below the tail of the index, holding the
+// chain mutex so that the blocks being imported don't move the range meanwhile.
+func (li *LogIndex) backfill(from uint64, status *LogIndexStatus) (bool, error) {
+	if !li.bc.chainmu.TryLock() {
+		return false, errChainStopped
+	}
+	defer li.bc.chainmu.Unlock()
+
+	batch := li.bc.db.NewBatch()
+	tail, head, ok := rawdb.ReadLogIndexRange(li.bc.db)
+	if !ok || tail > head {
+		head = li.bc.CurrentBlock().Number.Uint64()
+		tail = head + 1
+		rawdb.WriteLogIndexHead(batch, head)
+	}
+	status.Tail, status.Head = tail, head
+
+	for indexed := 0; tail > from && indexed < logIndexBackfillBatch; indexed++ {
+		number := tail - 1
+		hash := rawdb.ReadCanonicalHash(li.bc.db, number)
+		receipts := rawdb.ReadRawReceipts(li.bc.db, hash, number)
+		if receipts == nil {
+			return false, fmt.Errorf("receipts of block %d unavailable", number)
+		}
+		entries := rawdb.WriteLogIndexEntries(batch, number, receipts)
+		status.Entries += uint64(entries)
+		status.Blocks++
+		logIndexEntriesMeter.Mark(int64(entries))
+		logIndexBackfilledMeter.Mark(1)
+		tail = number
+	}
+	rawdb.WriteLogIndexTail(batch, tail)
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	status.Tail = tail
+	return tail <= from, nil
+}
+
+// Prune deletes the index entries of all the blocks below the given one,
+// including the leftovers of blocks reorged out.
+func (li *LogIndex) Prune(before uint64) (*LogIndexStatus, error) {
+	li.lock.Lock()
+	defer li.lock.Unlock()
+
+	tail, head, ok := rawdb.ReadLogIndexRange(li.bc.db)
+	if !ok {
+		return nil, errLogIndexEmpty
+	}
+	status := &LogIndexStatus{Tail: tail, Head: head}
+
+	// Move the tail first, so that the entries being deleted are not relied on
+	if before > tail {
+		if !li.bc.chainmu.TryLock() {
+			return nil, errChainStopped
+		}
+		if tail, _, ok = rawdb.ReadLogIndexRange(li.bc.db); ok && before > tail {
+			rawdb.WriteLogIndexTail(li.bc.db, before)
+			status.Blocks, status.Tail = before-tail, before
+		}
+		li.bc.chainmu.Unlock()
+	}
+	deleted, err := rawdb.DeleteLogIndexEntries(li.bc.db, before)
+	status.Entries = uint64(deleted)
+	logIndexPrunedMeter.Mark(int64(deleted))
+	if err != nil {
+		return status, err
+	}
+	log.Info("Pruned log index", "before", before, "entries", deleted)
+	return status, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the logs of the imported blocks are indexed, and that the index
+// can be backfilled and pruned.
+func TestLogIndex(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		emitter = common.HexToAddress("0xee")
+		topic   = common.HexToHash("0x2a")
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// PUSH32 topic, PUSH1 0, PUSH1 0, LOG1, STOP
+				emitter: {Code: append(append([]byte{byte(vm.PUSH32)}, topic.Bytes()...), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG1), byte(vm.STOP))},
+			},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 6, func(i int, b *BlockGen) {
+		if i == 1 || i == 4 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), emitter, nil, 100000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	config.LogIndex = true
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	index := chain.LogIndex()
+	status, err := index.Status()
+	if err != nil {
+		t.Fatalf("failed to get log index status: %v", err)
+	}
+	if status.Tail != 1 || status.Head != 6 {
+		t.Fatalf("unexpected log index range: have %d-%d, want 1-6", status.Tail, status.Head)
+	}
+	check := func(want []uint64) {
+		t.Helper()
+		byAddress, err := rawdb.ReadLogIndexAddress(chain.db, emitter, 0, 6)
+		if err != nil || !reflect.DeepEqual(byAddress, want) {
+			t.Fatalf("address entries mismatch: have %v (err %v), want %v", byAddress, err, want)
+		}
+		byTopic, err := rawdb.ReadLogIndexTopic(chain.db, topic, 0, 6)
+		if err != nil || !reflect.DeepEqual(byTopic, want) {
+			t.Fatalf("topic entries mismatch: have %v (err %v), want %v", byTopic, err, want)
+		}
+	}
+	check([]uint64{2, 5})
+
+	// Backfilling indexes the genesis block, without any logs
+	if status, err = index.Backfill(context.Background(), 0); err != nil {
+		t.Fatalf("failed to backfill log index: %v", err)
+	}
+	if status.Tail != 0 || status.Head != 6 || status.Blocks != 1 {
+		t.Fatalf("unexpected backfill result: %+v", status)
+	}
+	// Pruning deletes the entries below the new tail
+	if status, err = index.Prune(3); err != nil {
+		t.Fatalf("failed to prune log index: %v", err)
+	}
+	if status.Tail != 3 || status.Blocks != 3 || status.Entries != 2 {
+		t.Fatalf("unexpected prune result: %+v", status)
+	}
+	check([]uint64{5})
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	parallelSpeculatedMeter  = metrics.NewRegisteredMeter("chain/parallel/speculated", nil)
+	parallelReexecutedMeter  = metrics.NewRegisteredMeter("chain/parallel/reexecuted", nil)
+	parallelConflictingMeter = metrics.NewRegisteredMeter("chain/parallel/conflicting", nil)
+)
+
+// speculation is the outcome of executing a transaction on a private copy of
+// the pre-block state.
+type speculation struct {
+	access *state.TxAccessSet
+	result *ExecutionResult
+	evm    *vm.EVM
+	err    error
+}
+
+// ProcessParallel is the optimistic, Block-STM style counterpart of Process.
+// All transactions of the block are first executed concurrently by the given
+// number of workers, each on its own copy of the pre-block state, recording
+// the accounts and slots they read and write. The runs are then validated and
+// committed in block order: a transaction whose reads don't overlap with the
+// writes of the transactions committed before it has its writes replayed onto
+// the state, while any other is re-executed on the state itself.
+//
+// The outcome is identical to Process. Transactions running Stylus programs
+// are always re-executed, as the pages and programs tracked over the block
+// aren't part of their footprint. Tracing and pre-Byzantium blocks, which need
+// intermediate roots, fall back to sequential execution.
+//
+// ProcessParallel is experimental: block import doesn't use it, and it's only
+// exercised by its tests until it has been validated against Process on live
+// chains.
+func (p *StateProcessor) ProcessParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config, workers int) (types.Receipts, []*types.Log, uint64, error) {
+	if workers <= 1 || cfg.Tracer != nil || !p.config.IsByzantium(block.Number()) {
+		return p.Process(block, statedb, cfg)
+	}
+	var (
+		receipts    types.Receipts
+		usedGas     = new(uint64)
+		header      = block.Header()
+		blockHash   = block.Hash()
+		blockNumber = block.Number()
+		allLogs     []*types.Log
+		gp          = new(GasPool).AddGas(block.GasLimit())
+		txs         = block.Transactions()
+	)
+
+	// Mutate the block and state according to any hard-fork specs
+	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	var (
+		context = NewEVMBlockContext(header, p.bc, nil)
+		vmenv   = vm.NewEVM(context, vm.TxContext{}, statedb, p.config, cfg)
+		signer  = types.MakeSigner(p.config, header.Number, header.Time)
+	)
+	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
+		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
+	}
+	msgs := make([]*Message, len(txs))
+	for i, tx := range txs {
+		msg, err := TransactionToMessage(tx, signer, header.BaseFee, MessageReplayMode)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		msgs[i] = msg
+	}
+	specs := p.speculate(block, statedb, msgs, cfg, workers)
+
+	// Validate and commit the speculative runs in order
+	written := state.NewWriteSet()
+	for i, tx := range txs {
+		statedb.SetTxContext(tx.Hash(), i)
+
+		spec := specs[i]
+		if spec.err == nil && !spec.access.Opaque() && !spec.access.Conflicts(written) && gp.Gas() >= msgs[i].GasLimit {
+			statedb.ApplyTxAccess(spec.access)
+			statedb.Finalise(true)
+
+			gp.SubGas(spec.result.UsedGas)
+			*usedGas += spec.result.UsedGas
+			written.Add(spec.access)
+
+			receipt := MakeReceipt(spec.evm, spec.result, statedb, blockNumber, blockHash, tx, *usedGas, nil)
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
+			continue
+		}
+		if spec.err == nil && !spec.access.Opaque() {
+			parallelConflictingMeter.Mark(1)
+		}
+		parallelReexecutedMeter.Mark(1)
+
+		statedb.BeginTxAccess()
+		receipt, _, err := ApplyTransactionWithEVM(msgs[i], p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, func(*ExecutionResult) error {
+			written.Add(statedb.EndTxAccess())
+			return nil
+		})
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	// Fail if Shanghai not enabled and len(withdrawals) is non-zero.
+	withdrawals := block.Withdrawals()
+	if len(withdrawals) > 0 && !p.config.IsShanghai(block.Number(), block.Time(), types.DeserializeHeaderExtraInformation(header).ArbOSFormatVersion) {
+		return nil, nil, 0, errors.New("withdrawals before shanghai")
+	}
+	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
+	p.engine.Finalize(p.bc, header, statedb, block.Body())
+
+	return receipts, allLogs, *usedGas, nil
+}
+
+// speculate executes every message of the block concurrently, each on its own
+// copy of the given state.
+func (p *StateProcessor) speculate(block *types.Block, statedb *state.StateDB, msgs []*Message, cfg vm.Config, workers int) []*speculation {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		specs  = make([]*speculation, len(txs))
+		tasks  = make(chan int)
+		copies = make([]*state.StateDB, len(txs))
+		wg     sync.WaitGroup
+	)
+	// The state can't be copied concurrently with itself, copy upfront
+	for i := range txs {
+		copies[i] = statedb.Copy()
+	}
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				var (
+					tx    = txs[i]
+					spec  = new(speculation)
+					sdb   = copies[i]
+					gp    = new(GasPool).AddGas(block.GasLimit())
+					used  = new(uint64)
+					vmenv = vm.NewEVM(NewEVMBlockContext(header, p.bc, nil), vm.TxContext{}, sdb, p.config, cfg)
+				)
+				sdb.SetTxContext(tx.Hash(), i)
+				sdb.BeginTxAccess()
+
+				_, spec.result, spec.err = ApplyTransactionWithEVM(msgs[i], p.config, gp, sdb, block.Number(), block.Hash(), tx, used, vmenv, func(*ExecutionResult) error {
+					spec.access = sdb.EndTxAccess()
+					return nil
+				})
+				spec.evm = vmenv
+				specs[i] = spec
+			}
+		}()
+	}
+	for i := range txs {
+		tasks <- i
+	}
+	close(tasks)
+	wg.Wait()
+
+	parallelSpeculatedMeter.Mark(int64(len(txs)))
+	return specs
+}
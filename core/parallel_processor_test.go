@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the parallel processor yields the same state, receipts and logs
+// as the sequential one, for a block mixing independent and conflicting
+// transactions.
+func TestProcessParallel(t *testing.T) {
+	var (
+		config = params.MergedTestChainConfig
+		signer = types.LatestSigner(config)
+		keys   = make([]*ecdsa.PrivateKey, 4)
+		alloc  = make(types.GenesisAlloc)
+
+		// Stores the first word of the calldata in slot zero and logs it
+		store = common.HexToAddress("0x5700")
+		code  = common.FromHex("0x6000358060005560206000a0")
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	alloc[store] = types.Account{Code: code, Balance: common.Big0}
+
+	var (
+		db            = rawdb.NewMemoryDatabase()
+		gspec         = &Genesis{Config: config, Alloc: alloc}
+		engine        = beacon.New(ethash.NewFaker())
+		blockchain, _ = NewBlockChain(db, nil, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	)
+	defer blockchain.Stop()
+
+	makeTx := func(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value int64, data []byte) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(value), 100000, big.NewInt(2*params.GWei), data), signer, key)
+		return tx
+	}
+	txs := types.Transactions{
+		makeTx(keys[0], 0, common.HexToAddress("0xaa"), 1, nil),          // independent, creates an account
+		makeTx(keys[1], 0, common.HexToAddress("0xbb"), 1, nil),          // independent, creates an account
+		makeTx(keys[0], 1, common.HexToAddress("0xbb"), 1, nil),          // conflicts with both above
+		makeTx(keys[2], 0, store, 0, common.LeftPadBytes([]byte{1}, 32)), // independent storage write
+		makeTx(keys[3], 0, store, 0, common.LeftPadBytes([]byte{2}, 32)), // conflicts on the slot
+	}
+	creation, _ := types.SignTx(types.NewContractCreation(1, common.Big0, 100000, big.NewInt(2*params.GWei), []byte{0x00}), signer, keys[3])
+	txs = append(txs, creation) // replayed sequentially
+	block := GenerateBadBlock(blockchain.Genesis(), engine, txs, config)
+
+	sequential, _ := blockchain.StateAt(blockchain.Genesis().Root())
+	wantReceipts, wantLogs, wantGas, err := blockchain.Processor().Process(block, sequential, vm.Config{})
+	if err != nil {
+		t.Fatalf("sequential processing failed: %v", err)
+	}
+	parallel, _ := blockchain.StateAt(blockchain.Genesis().Root())
+	haveReceipts, haveLogs, haveGas, err := blockchain.Processor().(*StateProcessor).ProcessParallel(block, parallel, vm.Config{}, 4)
+	if err != nil {
+		t.Fatalf("parallel processing failed: %v", err)
+	}
+	if have, want := parallel.IntermediateRoot(true), sequential.IntermediateRoot(true); have != want {
+		t.Errorf("state root mismatch: have %x, want %x", have, want)
+	}
+	if haveGas != wantGas {
+		t.Errorf("gas used mismatch: have %d, want %d", haveGas, wantGas)
+	}
+	if len(haveLogs) != len(wantLogs) || len(haveLogs) != 2 {
+		t.Errorf("log count mismatch: have %d, want %d", len(haveLogs), len(wantLogs))
+	}
+	have, _ := json.Marshal(haveReceipts)
+	want, _ := json.Marshal(wantReceipts)
+	if string(have) != string(want) {
+		t.Errorf("receipts mismatch:\nhave %s\nwant %s", have, want)
+	}
+	if have, want := parallel.GetState(store, common.Hash{}), common.BytesToHash([]byte{2}); have != want {
+		t.Errorf("slot value mismatch: have %x, want %x", have, want)
+	}
+}
@@ -278,6 +278,93 @@ func WriteTxIndexTail(db ethdb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadReceiptsTail retrieves the number of the oldest block whose receipts are
+// retained, nil if the receipts were never pruned.
+func ReadReceiptsTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(receiptsTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteReceiptsTail stores the number of the oldest block whose receipts are
+// retained, marking the receipts of the blocks below as pruned.
+func WriteReceiptsTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(receiptsTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the receipts tail", "err", err)
+	}
+}
+
+// PruneReceipts deletes the receipts of all the blocks below the given number,
+// canonical or not, retaining their headers and bodies. The receipts tail is
+// moved first, so the receipts are considered pruned even if the deletion is
+// interrupted. The receipts frozen below the tail since the last pruning are
+// deleted too.
+func PruneReceipts(db ethdb.Database, tail uint64) error {
+	start := uint64(0)
+	if old := ReadReceiptsTail(db); old != nil {
+		if *old >= tail {
+			return PruneAncientReceipts(db)
+		}
+		start = *old
+	}
+	WriteReceiptsTail(db, tail)
+
+	if err := PruneAncientReceipts(db); err != nil {
+		return err
+	}
+	var (
+		batch = db.NewBatch()
+		limit = blockReceiptsKey(tail, common.Hash{})
+	)
+	it := db.NewIterator(blockReceiptsPrefix, encodeBlockNumber(start))
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if bytes.Compare(key, limit) >= 0 {
+			break
+		}
+		if len(key) != len(blockReceiptsPrefix)+8+common.HashLength {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// PruneAncientReceipts deletes the receipts of the frozen blocks below the
+// receipts tail, keeping the rest of the ancient chain.
+func PruneAncientReceipts(db ethdb.Database) error {
+	tail := ReadReceiptsTail(db)
+	if tail == nil {
+		return nil
+	}
+	frozen, err := db.Ancients()
+	if err != nil || frozen == 0 {
+		return nil // No ancient store, or nothing frozen yet
+	}
+	pruner, ok := db.(ancientTablePruner)
+	if !ok {
+		return errNotSupported
+	}
+	_, err = pruner.TruncateTableTail(ChainFreezerReceiptTable, min(*tail, frozen))
+	return err
+}
+
 // ReadHeaderRange returns the rlp-encoded headers, starting at 'number', and going
 // backwards towards genesis. This method assumes that the caller already has
 // placed a cap on count, to prevent DoS issues.
@@ -576,7 +663,11 @@ func ReadReceiptsRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawVa
 		// Check if the data is in ancients
 		if isCanon(reader, number, hash) {
 			data, _ = reader.Ancient(ChainFreezerReceiptTable, number)
-			return nil
+			if len(data) > 0 {
+				return nil
+			}
+			// Arbitrum: the receipts pruned from the ancients may have been
+			// backfilled into the key-value store
 		}
 		// If not, try reading from leveldb
 		data, _ = db.Get(blockReceiptsKey(number, hash))
@@ -669,6 +760,41 @@ func DeleteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadBlockStylusStats retrieves the summary of the Stylus executions of the
+// given block, or nil if the block ran no Stylus program or wasn't tracked.
+func ReadBlockStylusStats(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.StylusStats {
+	data, _ := db.Get(blockStylusStatsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(types.StylusStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid block stylus stats RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteBlockStylusStats stores the summary of the Stylus executions of the
+// given block.
+func WriteBlockStylusStats(db ethdb.KeyValueWriter, hash common.Hash, number uint64, stats *types.StylusStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to encode block stylus stats", "err", err)
+	}
+	if err := db.Put(blockStylusStatsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block stylus stats", "err", err)
+	}
+}
+
+// DeleteBlockStylusStats removes the summary of the Stylus executions of the
+// given block.
+func DeleteBlockStylusStats(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockStylusStatsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block stylus stats", "err", err)
+	}
+}
+
 // storedReceiptRLP is the storage encoding of a receipt.
 // Re-definition in core/types/receipt.go.
 // TODO: Re-use the existing definition.
@@ -846,6 +972,7 @@ func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
+	DeleteBlockStylusStats(db, hash, number)
 }
 
 // DeleteBlockWithoutNumber removes all block data associated with a hash, except
@@ -496,6 +496,50 @@ func TestAncientStorage(t *testing.T) {
 	}
 }
 
+// Tests that pruning the receipts deletes them from both the ancient and the
+// key-value stores, keeping the rest of the blocks.
+func TestPruneReceipts(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	blocks := makeTestBlocks(6, 1)
+	receipts := make([]types.Receipts, len(blocks))
+	for i := range receipts {
+		receipts[i] = types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i + 1)}}
+	}
+	if _, err := WriteAncientBlocks(db, blocks[:4], receipts[:4], big.NewInt(100)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	for i := 4; i < 6; i++ {
+		WriteBlock(db, blocks[i])
+		WriteCanonicalHash(db, blocks[i].Hash(), uint64(i))
+		WriteReceipts(db, blocks[i].Hash(), uint64(i), receipts[i])
+	}
+	if err := PruneReceipts(db, 5); err != nil {
+		t.Fatalf("failed to prune receipts: %v", err)
+	}
+	if tail := ReadReceiptsTail(db); tail == nil || *tail != 5 {
+		t.Fatalf("unexpected receipts tail: %v", tail)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if blob := ReadReceiptsRLP(db, hash, number); (len(blob) == 0) != (i < 5) {
+			t.Fatalf("block %d: receipts present %v, want pruned %v", i, len(blob) > 0, i < 5)
+		}
+		if ReadHeader(db, hash, number) == nil || ReadBody(db, hash, number) == nil {
+			t.Fatalf("block %d: header or body pruned", i)
+		}
+	}
+	// Receipts written back below the tail are read from the key-value store
+	WriteReceipts(db, blocks[2].Hash(), 2, receipts[2])
+	if blob := ReadReceiptsRLP(db, blocks[2].Hash(), 2); len(blob) == 0 {
+		t.Fatalf("backfilled receipts not returned")
+	}
+}
+
 func TestCanonicalHashIteration(t *testing.T) {
 	var cases = []struct {
 		from, to uint64
@@ -931,3 +975,21 @@ func TestHeadersRLPStorage(t *testing.T) {
 	checkSequence(1, 1)    // Only block 1
 	checkSequence(1, 2)    // Genesis + block 1
 }
+
+func TestBlockStylusStatsStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	hash := common.Hash{1}
+
+	if stats := ReadBlockStylusStats(db, hash, 1); stats != nil {
+		t.Fatalf("non existent stats returned: %+v", stats)
+	}
+	want := &types.StylusStats{Calls: 3, Modules: 2, Ink: 1750, PagesOpened: 9, MaxPagesEver: 6}
+	WriteBlockStylusStats(db, hash, 1, want)
+	if stats := ReadBlockStylusStats(db, hash, 1); stats == nil || *stats != *want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", stats, want)
+	}
+	DeleteBlock(db, hash, 1)
+	if stats := ReadBlockStylusStats(db, hash, 1); stats != nil {
+		t.Fatalf("deleted stats returned: %+v", stats)
+	}
+}
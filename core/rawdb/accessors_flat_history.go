@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// flatHistoryChangeset lists the entries of the flat history written for a
+// block, so that they can be deleted when the block is unwound or pruned.
+type flatHistoryChangeset struct {
+	Hash     common.Hash
+	Accounts []common.Address
+	Storages []flatHistorySlots
+}
+
+// flatHistorySlots lists the slots of an account modified by a block.
+type flatHistorySlots struct {
+	Address common.Address
+	Slots   []common.Hash
+}
+
+// ReadFlatHistoryRange retrieves the range of blocks whose state changes are
+// indexed by the flat history, along with the hash of the newest one.
+func ReadFlatHistoryRange(db ethdb.KeyValueReader) (tail uint64, head uint64, hash common.Hash, ok bool) {
+	tailData, _ := db.Get(flatHistoryTailKey)
+	headData, _ := db.Get(flatHistoryHeadKey)
+	if len(tailData) != 8 || len(headData) != 8+common.HashLength {
+		return 0, 0, common.Hash{}, false
+	}
+	return binary.BigEndian.Uint64(tailData), binary.BigEndian.Uint64(headData), common.BytesToHash(headData[8:]), true
+}
+
+// WriteFlatHistoryTail stores the number of the oldest block indexed by the
+// flat history.
+func WriteFlatHistoryTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(flatHistoryTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the flat history tail", "err", err)
+	}
+}
+
+// WriteFlatHistoryHead stores the number and hash of the newest block indexed
+// by the flat history.
+func WriteFlatHistoryHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(flatHistoryHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store the flat history head", "err", err)
+	}
+}
+
+// WriteFlatHistory stores the original values of the accounts and slots
+// modified by the given block, as committed by the state, returning the number
+// of entries written. Storage slots are keyed by the hash of the slot.
+func WriteFlatHistory(db ethdb.KeyValueWriter, number uint64, hash common.Hash, accounts map[common.Address][]byte, storages map[common.Address]map[common.Hash][]byte) int {
+	changeset := flatHistoryChangeset{Hash: hash}
+	for addr, origin := range accounts {
+		if err := db.Put(flatHistoryAccountKey(addr, number), origin); err != nil {
+			log.Crit("Failed to store flat account history", "err", err)
+		}
+		changeset.Accounts = append(changeset.Accounts, addr)
+	}
+	entries := len(accounts)
+	for addr, slots := range storages {
+		set := flatHistorySlots{Address: addr}
+		for slot, origin := range slots {
+			if err := db.Put(flatHistoryStorageKey(addr, slot, number), origin); err != nil {
+				log.Crit("Failed to store flat storage history", "err", err)
+			}
+			set.Slots = append(set.Slots, slot)
+		}
+		entries += len(slots)
+		changeset.Storages = append(changeset.Storages, set)
+	}
+	blob, err := rlp.EncodeToBytes(&changeset)
+	if err != nil {
+		log.Crit("Failed to encode flat history changeset", "err", err)
+	}
+	if err := db.Put(flatHistoryChangesetKey(number), blob); err != nil {
+		log.Crit("Failed to store flat history changeset", "err", err)
+	}
+	return entries
+}
+
+// ReadFlatHistoryHash retrieves the hash of the block whose state changes are
+// indexed at the given number, if any.
+func ReadFlatHistoryHash(db ethdb.KeyValueReader, number uint64) (common.Hash, bool) {
+	changeset := readFlatHistoryChangeset(db, number)
+	if changeset == nil {
+		return common.Hash{}, false
+	}
+	return changeset.Hash, true
+}
+
+func readFlatHistoryChangeset(db ethdb.KeyValueReader, number uint64) *flatHistoryChangeset {
+	blob, err := db.Get(flatHistoryChangesetKey(number))
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	changeset := new(flatHistoryChangeset)
+	if err := rlp.DecodeBytes(blob, changeset); err != nil {
+		log.Error("Invalid flat history changeset", "number", number, "err", err)
+		return nil
+	}
+	return changeset
+}
+
+// DeleteFlatHistory deletes the entries of the flat history written for the
+// given block, returning the number of entries deleted.
+func DeleteFlatHistory(db ethdb.KeyValueReader, batch ethdb.KeyValueWriter, number uint64) int {
+	changeset := readFlatHistoryChangeset(db, number)
+	if changeset == nil {
+		return 0
+	}
+	for _, addr := range changeset.Accounts {
+		if err := batch.Delete(flatHistoryAccountKey(addr, number)); err != nil {
+			log.Crit("Failed to delete flat account history", "err", err)
+		}
+	}
+	entries := len(changeset.Accounts)
+	for _, set := range changeset.Storages {
+		for _, slot := range set.Slots {
+			if err := batch.Delete(flatHistoryStorageKey(set.Address, slot, number)); err != nil {
+				log.Crit("Failed to delete flat storage history", "err", err)
+			}
+		}
+		entries += len(set.Slots)
+	}
+	if err := batch.Delete(flatHistoryChangesetKey(number)); err != nil {
+		log.Crit("Failed to delete flat history changeset", "err", err)
+	}
+	return entries
+}
+
+// ReadFlatHistoryChangesets retrieves the numbers of the blocks below the given
+// one with entries in the flat history, in ascending order.
+func ReadFlatHistoryChangesets(db ethdb.Iteratee, before uint64) ([]uint64, error) {
+	it := NewKeyLengthIterator(db.NewIterator(FlatHistoryChangesetPrefix, nil), len(FlatHistoryChangesetPrefix)+8)
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		number := binary.BigEndian.Uint64(it.Key()[len(FlatHistoryChangesetPrefix):])
+		if number >= before {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, it.Error()
+}
+
+// ReadFlatAccountHistory retrieves the blocks after the given one modifying the
+// given account, along with the original values of the account before each of
+// them, in slim RLP encoding and empty if the account was not present. The
+// entries stop at the first block after the to block, included so that the
+// value of the account after every block of the range can be derived.
+func ReadFlatAccountHistory(db ethdb.Iteratee, addr common.Address, after uint64, to uint64) ([]uint64, [][]byte, error) {
+	return readFlatHistoryEntries(db, append(common.CopyBytes(FlatHistoryAccountPrefix), addr.Bytes()...), after, to)
+}
+
+// ReadFlatStorageHistory retrieves the blocks after the given one modifying the
+// given slot, identified by its hash, along with the original values of the
+// slot before each of them, in prefix-zero trimmed RLP encoding and empty if
+// the slot was not present. The entries stop at the first block after the to
+// block, like ReadFlatAccountHistory.
+func ReadFlatStorageHistory(db ethdb.Iteratee, addr common.Address, slot common.Hash, after uint64, to uint64) ([]uint64, [][]byte, error) {
+	prefix := append(append(common.CopyBytes(FlatHistoryStoragePrefix), addr.Bytes()...), slot.Bytes()...)
+	return readFlatHistoryEntries(db, prefix, after, to)
+}
+
+// readFlatHistoryEntries retrieves the entries of the flat history with the
+// given prefix, starting after the given block and ending with the first one
+// after the to block.
+func readFlatHistoryEntries(db ethdb.Iteratee, prefix []byte, after uint64, to uint64) ([]uint64, [][]byte, error) {
+	it := db.NewIterator(prefix, encodeBlockNumber(after+1))
+	defer it.Release()
+
+	var (
+		numbers []uint64
+		origins [][]byte
+	)
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		numbers = append(numbers, number)
+		origins = append(origins, common.CopyBytes(it.Value()))
+		if number > to {
+			break
+		}
+	}
+	return numbers, origins, it.Error()
+}
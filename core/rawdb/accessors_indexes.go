@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +180,131 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// ReadLogIndexRange retrieves the range of blocks whose logs are indexed by the
+// log index, if any.
+func ReadLogIndexRange(db ethdb.KeyValueReader) (tail uint64, head uint64, ok bool) {
+	tailData, _ := db.Get(logIndexTailKey)
+	headData, _ := db.Get(logIndexHeadKey)
+	if len(tailData) != 8 || len(headData) != 8 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(tailData), binary.BigEndian.Uint64(headData), true
+}
+
+// WriteLogIndexTail stores the number of the oldest block indexed by the log
+// index.
+func WriteLogIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(logIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the log index tail", "err", err)
+	}
+}
+
+// WriteLogIndexHead stores the number of the newest block indexed by the log
+// index.
+func WriteLogIndexHead(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(logIndexHeadKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the log index head", "err", err)
+	}
+}
+
+// WriteLogIndexEntries indexes the addresses and topics of the logs emitted in
+// the block with the given number, returning the number of entries written.
+func WriteLogIndexEntries(db ethdb.KeyValueWriter, number uint64, receipts types.Receipts) int {
+	var (
+		addresses = make(map[common.Address]struct{})
+		topics    = make(map[common.Hash]struct{})
+	)
+	for _, receipt := range receipts {
+		for _, entry := range receipt.Logs {
+			addresses[entry.Address] = struct{}{}
+			for _, topic := range entry.Topics {
+				topics[topic] = struct{}{}
+			}
+		}
+	}
+	for addr := range addresses {
+		if err := db.Put(logIndexAddressKey(addr, number), nil); err != nil {
+			log.Crit("Failed to store log index entry", "err", err)
+		}
+	}
+	for topic := range topics {
+		if err := db.Put(logIndexTopicKey(topic, number), nil); err != nil {
+			log.Crit("Failed to store log index entry", "err", err)
+		}
+	}
+	return len(addresses) + len(topics)
+}
+
+// ReadLogIndexAddress retrieves the numbers of the blocks in the given range,
+// inclusive, with logs emitted by the given address.
+func ReadLogIndexAddress(db ethdb.Iteratee, addr common.Address, from uint64, to uint64) ([]uint64, error) {
+	return readLogIndexEntries(db, append(LogIndexAddressPrefix, addr.Bytes()...), from, to)
+}
+
+// ReadLogIndexTopic retrieves the numbers of the blocks in the given range,
+// inclusive, with logs having the given topic at any position.
+func ReadLogIndexTopic(db ethdb.Iteratee, topic common.Hash, from uint64, to uint64) ([]uint64, error) {
+	return readLogIndexEntries(db, append(LogIndexTopicPrefix, topic.Bytes()...), from, to)
+}
+
+// readLogIndexEntries retrieves the block numbers in the given range, inclusive,
+// of the log index entries with the given prefix, in ascending order.
+func readLogIndexEntries(db ethdb.Iteratee, prefix []byte, from uint64, to uint64) ([]uint64, error) {
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		if number > to {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, it.Error()
+}
+
+// DeleteLogIndexEntries deletes the log index entries of all the blocks below
+// the given number, returning the number of entries deleted.
+func DeleteLogIndexEntries(db ethdb.KeyValueStore, before uint64) (int, error) {
+	var (
+		batch   = db.NewBatch()
+		deleted int
+	)
+	for _, index := range []struct {
+		prefix []byte
+		keyLen int
+	}{
+		{LogIndexAddressPrefix, len(LogIndexAddressPrefix) + common.AddressLength + 8},
+		{LogIndexTopicPrefix, len(LogIndexTopicPrefix) + common.HashLength + 8},
+	} {
+		keyLen := index.keyLen
+		it := NewKeyLengthIterator(db.NewIterator(index.prefix, nil), keyLen)
+		for it.Next() {
+			if binary.BigEndian.Uint64(it.Key()[keyLen-8:]) >= before {
+				continue
+			}
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return deleted, err
+			}
+			deleted++
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return deleted, err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, batch.Write()
+}
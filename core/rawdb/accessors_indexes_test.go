@@ -154,3 +154,59 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.SepoliaGenesisHash, true)
 }
+
+// Tests that the log index entries can be stored, queried by range and pruned.
+func TestLogIndex(t *testing.T) {
+	var (
+		db     = NewMemoryDatabase()
+		addrA  = common.HexToAddress("0xa")
+		addrB  = common.HexToAddress("0xb")
+		topicA = common.HexToHash("0x01")
+		topicB = common.HexToHash("0x02")
+	)
+	if _, _, ok := ReadLogIndexRange(db); ok {
+		t.Fatal("log index range found in empty database")
+	}
+	logs := map[uint64][]*types.Log{
+		1: {{Address: addrA, Topics: []common.Hash{topicA}}, {Address: addrA, Topics: []common.Hash{topicA, topicB}}},
+		3: {{Address: addrB, Topics: []common.Hash{topicB}}},
+		5: {{Address: addrA}},
+	}
+	for number := uint64(0); number <= 5; number++ {
+		receipts := types.Receipts{{Logs: logs[number]}}
+		WriteLogIndexEntries(db, number, receipts)
+	}
+	WriteLogIndexTail(db, 0)
+	WriteLogIndexHead(db, 5)
+	if tail, head, ok := ReadLogIndexRange(db); !ok || tail != 0 || head != 5 {
+		t.Fatalf("log index range mismatch: have %d-%d %v, want 0-5", tail, head, ok)
+	}
+	check := func(have []uint64, err error, want []uint64) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("failed to read log index: %v", err)
+		}
+		if len(have) != len(want) {
+			t.Fatalf("log index entries mismatch: have %v, want %v", have, want)
+		}
+		for i := range have {
+			if have[i] != want[i] {
+				t.Fatalf("log index entries mismatch: have %v, want %v", have, want)
+			}
+		}
+	}
+	numbers, err := ReadLogIndexAddress(db, addrA, 0, 5)
+	check(numbers, err, []uint64{1, 5})
+	numbers, err = ReadLogIndexAddress(db, addrA, 2, 4)
+	check(numbers, err, nil)
+	numbers, err = ReadLogIndexTopic(db, topicB, 0, 3)
+	check(numbers, err, []uint64{1, 3})
+
+	if deleted, err := DeleteLogIndexEntries(db, 3); err != nil || deleted != 3 {
+		t.Fatalf("failed to prune log index: deleted %d, err %v", deleted, err)
+	}
+	numbers, err = ReadLogIndexAddress(db, addrA, 0, 5)
+	check(numbers, err, []uint64{5})
+	numbers, err = ReadLogIndexTopic(db, topicB, 0, 5)
+	check(numbers, err, []uint64{3})
+}
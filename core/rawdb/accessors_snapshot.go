@@ -206,3 +206,32 @@ func WriteSnapshotSyncStatus(db ethdb.KeyValueWriter, status []byte) {
 		log.Crit("Failed to store snapshot sync status", "err", err)
 	}
 }
+
+// ReadSnapshotDiffJournal retrieves the incremental journal entry of the diff
+// layer with the given root.
+func ReadSnapshotDiffJournal(db ethdb.KeyValueReader, root common.Hash) []byte {
+	data, _ := db.Get(snapshotDiffJournalKey(root))
+	return data
+}
+
+// WriteSnapshotDiffJournal stores the incremental journal entry of the diff
+// layer with the given root.
+func WriteSnapshotDiffJournal(db ethdb.KeyValueWriter, root common.Hash, entry []byte) {
+	if err := db.Put(snapshotDiffJournalKey(root), entry); err != nil {
+		log.Crit("Failed to store snapshot diff journal", "err", err)
+	}
+}
+
+// DeleteSnapshotDiffJournal deletes the incremental journal entry of the diff
+// layer with the given root.
+func DeleteSnapshotDiffJournal(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Delete(snapshotDiffJournalKey(root)); err != nil {
+		log.Crit("Failed to remove snapshot diff journal", "err", err)
+	}
+}
+
+// IterateSnapshotDiffJournals returns an iterator for walking the incremental
+// journal entries of the snapshot diff layers.
+func IterateSnapshotDiffJournals(db ethdb.Iteratee) ethdb.Iterator {
+	return NewKeyLengthIterator(db.NewIterator(SnapshotDiffJournalPrefix, nil), len(SnapshotDiffJournalPrefix)+common.HashLength)
+}
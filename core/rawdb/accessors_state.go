@@ -86,6 +86,7 @@ func WriteCode(db ethdb.KeyValueWriter, hash common.Hash, code []byte) {
 	if err := db.Put(codeKey(hash), code); err != nil {
 		log.Crit("Failed to store contract code", "err", err)
 	}
+	WriteCodeSize(db, hash, code)
 }
 
 // DeleteCode deletes the specified contract code from the database.
@@ -93,6 +94,55 @@ func DeleteCode(db ethdb.KeyValueWriter, hash common.Hash) {
 	if err := db.Delete(codeKey(hash)); err != nil {
 		log.Crit("Failed to delete contract code", "err", err)
 	}
+	if err := db.Delete(codeSizeKey(hash)); err != nil {
+		log.Crit("Failed to delete contract code size", "err", err)
+	}
+}
+
+// CodeSizeIndexPrefixLen is the number of leading code bytes stored alongside
+// the code size, enough for EOF magic and Stylus program prefix checks.
+const CodeSizeIndexPrefixLen = 32
+
+// ReadCodeSize retrieves the size of the contract code of the provided code
+// hash from the code size index, without loading the code itself.
+func ReadCodeSize(db ethdb.KeyValueReader, hash common.Hash) (int, bool) {
+	data, _ := db.Get(codeSizeKey(hash))
+	if len(data) < 4 {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(data)), true
+}
+
+// ReadCodePrefix retrieves up to n leading bytes of the contract code of the
+// provided code hash from the code size index. False is returned if the index
+// is missing or holds fewer bytes than requested of a longer code.
+func ReadCodePrefix(db ethdb.KeyValueReader, hash common.Hash, n int) ([]byte, bool) {
+	data, _ := db.Get(codeSizeKey(hash))
+	if len(data) < 4 {
+		return nil, false
+	}
+	size, prefix := int(binary.BigEndian.Uint32(data)), data[4:]
+	if n > size {
+		n = size
+	}
+	if n > len(prefix) {
+		return nil, false
+	}
+	return common.CopyBytes(prefix[:n]), true
+}
+
+// WriteCodeSize writes the code size index entry of the provided contract code.
+func WriteCodeSize(db ethdb.KeyValueWriter, hash common.Hash, code []byte) {
+	prefix := code
+	if len(prefix) > CodeSizeIndexPrefixLen {
+		prefix = prefix[:CodeSizeIndexPrefixLen]
+	}
+	data := make([]byte, 4+len(prefix))
+	binary.BigEndian.PutUint32(data, uint32(len(code)))
+	copy(data[4:], prefix)
+	if err := db.Put(codeSizeKey(hash), data); err != nil {
+		log.Crit("Failed to store contract code size", "err", err)
+	}
 }
 
 // ReadStateID retrieves the state id with the provided state root.
@@ -264,3 +314,77 @@ func WriteStateHistory(db ethdb.AncientWriter, id uint64, meta []byte, accountIn
 		return nil
 	})
 }
+
+// ReadStateHistorySize retrieves the total size of the state histories stored,
+// across all the state history tables.
+func ReadStateHistorySize(db ethdb.AncientReader) (uint64, error) {
+	var total uint64
+	for table := range stateFreezerNoSnappy {
+		size, err := db.AncientSize(table)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// ReadAccountAccessEpoch retrieves the epoch the given account was last accessed
+// at, if its accesses are tracked.
+func ReadAccountAccessEpoch(db ethdb.KeyValueReader, addr common.Address) (uint64, bool) {
+	data, _ := db.Get(accountAccessKey(addr))
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteAccountAccessEpoch stores the epoch the given account was last accessed at.
+func WriteAccountAccessEpoch(db ethdb.KeyValueWriter, addr common.Address, epoch uint64) {
+	if err := db.Put(accountAccessKey(addr), encodeBlockNumber(epoch)); err != nil {
+		log.Crit("Failed to store account access epoch", "err", err)
+	}
+}
+
+// DeleteAccountAccessEpoch deletes the last access epoch of the given account.
+func DeleteAccountAccessEpoch(db ethdb.KeyValueWriter, addr common.Address) {
+	if err := db.Delete(accountAccessKey(addr)); err != nil {
+		log.Crit("Failed to delete account access epoch", "err", err)
+	}
+}
+
+// IterateAccountAccessEpochs returns an iterator for walking the last access
+// epochs of all the tracked accounts.
+func IterateAccountAccessEpochs(db ethdb.Iteratee) ethdb.Iterator {
+	return NewKeyLengthIterator(db.NewIterator(AccountAccessPrefix, nil), len(AccountAccessPrefix)+common.AddressLength)
+}
+
+// ReadInactiveAccount retrieves the epoch the given account was marked inactive
+// at, if it's marked.
+func ReadInactiveAccount(db ethdb.KeyValueReader, addr common.Address) (uint64, bool) {
+	data, _ := db.Get(inactiveAccountKey(addr))
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteInactiveAccount marks the given account as inactive since the given epoch.
+func WriteInactiveAccount(db ethdb.KeyValueWriter, addr common.Address, epoch uint64) {
+	if err := db.Put(inactiveAccountKey(addr), encodeBlockNumber(epoch)); err != nil {
+		log.Crit("Failed to store inactive account marker", "err", err)
+	}
+}
+
+// DeleteInactiveAccount deletes the inactivity marker of the given account.
+func DeleteInactiveAccount(db ethdb.KeyValueWriter, addr common.Address) {
+	if err := db.Delete(inactiveAccountKey(addr)); err != nil {
+		log.Crit("Failed to delete inactive account marker", "err", err)
+	}
+}
+
+// IterateInactiveAccounts returns an iterator for walking the markers of all
+// the accounts marked inactive.
+func IterateInactiveAccounts(db ethdb.Iteratee) ethdb.Iterator {
+	return NewKeyLengthIterator(db.NewIterator(InactiveAccountPrefix, nil), len(InactiveAccountPrefix)+common.AddressLength)
+}
@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"fmt"
 	"runtime"
 
@@ -61,6 +62,11 @@ func activatedAsmKeyPrefix(target ethdb.WasmTarget) (WasmPrefix, error) {
 	return prefix, nil
 }
 
+// AllWasmTargets returns all the targets activated asm can be stored for.
+func AllWasmTargets() []ethdb.WasmTarget {
+	return []ethdb.WasmTarget{TargetWavm, TargetArm64, TargetAmd64, TargetHost}
+}
+
 func IsSupportedWasmTarget(target ethdb.WasmTarget) bool {
 	_, err := activatedAsmKeyPrefix(target)
 	return err == nil
@@ -98,6 +104,154 @@ func ReadActivatedAsm(db ethdb.KeyValueReader, target ethdb.WasmTarget, moduleHa
 	return asm
 }
 
+// Retrieves up to limit module hashes, in ascending order and starting at (and
+// including) start, for which activated asm is stored for the given target
+func ReadActivatedModuleHashes(db ethdb.Iteratee, target ethdb.WasmTarget, start common.Hash, limit int) ([]common.Hash, error) {
+	prefix, err := activatedAsmKeyPrefix(target)
+	if err != nil {
+		return nil, err
+	}
+	it := db.NewIterator(prefix[:], start[:])
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() && len(hashes) < limit {
+		if key := it.Key(); len(key) == WasmKeyLen {
+			hashes = append(hashes, common.BytesToHash(key[WasmPrefixLen:]))
+		}
+	}
+	return hashes, it.Error()
+}
+
+// Deletes the activated asm of all targets for a given moduleHash
+func DeleteActivation(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	for _, target := range AllWasmTargets() {
+		prefix, _ := activatedAsmKeyPrefix(target)
+		key := activatedKey(prefix, moduleHash)
+		if err := db.Delete(key[:]); err != nil {
+			log.Crit("Failed to delete activated wasm asm", "err", err)
+		}
+	}
+}
+
+// Stores the block number at which the module with the given moduleHash was first
+// found not to be referenced by any program
+func WriteUnreferencedModule(db ethdb.KeyValueWriter, moduleHash common.Hash, number uint64) {
+	key := activatedKey(unreferencedModulePrefix, moduleHash)
+	if err := db.Put(key[:], encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store unreferenced wasm module", "err", err)
+	}
+}
+
+// Retrieves the block number at which the module with the given moduleHash was
+// first found not to be referenced by any program, if it still isn't
+func ReadUnreferencedModule(db ethdb.KeyValueReader, moduleHash common.Hash) (uint64, bool) {
+	key := activatedKey(unreferencedModulePrefix, moduleHash)
+	data, err := db.Get(key[:])
+	if err != nil || len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// Deletes the unreferenced marker of the module with the given moduleHash
+func DeleteUnreferencedModule(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	key := activatedKey(unreferencedModulePrefix, moduleHash)
+	if err := db.Delete(key[:]); err != nil {
+		log.Crit("Failed to delete unreferenced wasm module", "err", err)
+	}
+}
+
+// Retrieves the module hashes of all the modules marked as unreferenced
+func ReadUnreferencedModuleHashes(db ethdb.Iteratee) ([]common.Hash, error) {
+	it := db.NewIterator(unreferencedModulePrefix[:], nil)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		if key := it.Key(); len(key) == WasmKeyLen {
+			hashes = append(hashes, common.BytesToHash(key[WasmPrefixLen:]))
+		}
+	}
+	return hashes, it.Error()
+}
+
+// Retrieves the number and total size of the activated asm stored for the given
+// target
+func ReadActivatedAsmStats(db ethdb.Iteratee, target ethdb.WasmTarget) (count uint64, size uint64, err error) {
+	prefix, err := activatedAsmKeyPrefix(target)
+	if err != nil {
+		return 0, 0, err
+	}
+	it := db.NewIterator(prefix[:], nil)
+	defer it.Release()
+
+	for it.Next() {
+		if key := it.Key(); len(key) == WasmKeyLen {
+			count++
+			size += uint64(len(it.Value()))
+		}
+	}
+	return count, size, it.Error()
+}
+
+// RecompileRequest is a pending compilation of an activated module for a target
+type RecompileRequest struct {
+	ModuleHash common.Hash
+	Target     ethdb.WasmTarget
+}
+
+// key = prefix + moduleHash + target
+func recompileRequestKey(moduleHash common.Hash, target ethdb.WasmTarget) []byte {
+	key := activatedKey(recompileRequestPrefix, moduleHash)
+	return append(key[:], target...)
+}
+
+// Enqueues the compilation of the module with the given moduleHash for the target
+func WriteRecompileRequest(db ethdb.KeyValueWriter, moduleHash common.Hash, target ethdb.WasmTarget) {
+	if err := db.Put(recompileRequestKey(moduleHash, target), []byte{}); err != nil {
+		log.Crit("Failed to store wasm recompile request", "err", err)
+	}
+}
+
+// Dequeues the compilation of the module with the given moduleHash for the target
+func DeleteRecompileRequest(db ethdb.KeyValueWriter, moduleHash common.Hash, target ethdb.WasmTarget) {
+	if err := db.Delete(recompileRequestKey(moduleHash, target)); err != nil {
+		log.Crit("Failed to delete wasm recompile request", "err", err)
+	}
+}
+
+// Retrieves up to limit pending compilations, in ascending module hash order
+func ReadRecompileRequests(db ethdb.Iteratee, limit int) ([]RecompileRequest, error) {
+	it := db.NewIterator(recompileRequestPrefix[:], nil)
+	defer it.Release()
+
+	var requests []RecompileRequest
+	for it.Next() && len(requests) < limit {
+		if key := it.Key(); len(key) > WasmKeyLen {
+			requests = append(requests, RecompileRequest{
+				ModuleHash: common.BytesToHash(key[WasmPrefixLen:WasmKeyLen]),
+				Target:     ethdb.WasmTarget(key[WasmKeyLen:]),
+			})
+		}
+	}
+	return requests, it.Error()
+}
+
+// Retrieves the number of pending compilations
+func CountRecompileRequests(db ethdb.Iteratee) (uint64, error) {
+	it := db.NewIterator(recompileRequestPrefix[:], nil)
+	defer it.Release()
+
+	var count uint64
+	for it.Next() {
+		if len(it.Key()) > WasmKeyLen {
+			count++
+		}
+	}
+	return count, it.Error()
+}
+
 // Stores wasm schema version
 func WriteWasmSchemaVersion(db ethdb.KeyValueWriter) {
 	if err := db.Put(wasmSchemaVersionKey, []byte{WasmSchemaVersion}); err != nil {
@@ -0,0 +1,249 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestReadActivatedModuleHashes(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var hashes []common.Hash
+	for i := byte(1); i <= 5; i++ {
+		hash := common.Hash{i}
+		hashes = append(hashes, hash)
+		WriteActivation(db, hash, map[ethdb.WasmTarget][]byte{
+			TargetWavm:  {i},
+			TargetAmd64: {i, i},
+		})
+	}
+	// Unrelated targets must not leak into the results
+	WriteActivatedAsm(db, TargetArm64, common.Hash{0xff}, []byte{0xff})
+
+	all, err := ReadActivatedModuleHashes(db, TargetWavm, common.Hash{}, 10)
+	if err != nil {
+		t.Fatalf("failed to read module hashes: %v", err)
+	}
+	if !slices.Equal(all, hashes) {
+		t.Fatalf("module hashes mismatch: have %v, want %v", all, hashes)
+	}
+	page, err := ReadActivatedModuleHashes(db, TargetAmd64, common.Hash{2}, 2)
+	if err != nil {
+		t.Fatalf("failed to read module hashes: %v", err)
+	}
+	if !slices.Equal(page, hashes[1:3]) {
+		t.Fatalf("module hashes mismatch: have %v, want %v", page, hashes[1:3])
+	}
+}
+
+func TestUnreferencedModules(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	hash := common.Hash{1}
+	WriteActivation(db, hash, map[ethdb.WasmTarget][]byte{
+		TargetWavm:  {1},
+		TargetAmd64: {1, 1},
+	})
+	if count, size, err := ReadActivatedAsmStats(db, TargetAmd64); err != nil || count != 1 || size != 2 {
+		t.Fatalf("unexpected asm stats: count %d, size %d, err %v", count, size, err)
+	}
+	WriteUnreferencedModule(db, hash, 100)
+	if number, ok := ReadUnreferencedModule(db, hash); !ok || number != 100 {
+		t.Fatalf("unexpected unreferenced marker: %d, %v", number, ok)
+	}
+	if hashes, err := ReadUnreferencedModuleHashes(db); err != nil || !slices.Equal(hashes, []common.Hash{hash}) {
+		t.Fatalf("unexpected unreferenced modules: %v, %v", hashes, err)
+	}
+	// Unreferenced markers must not be mistaken for activated asm
+	if hashes, err := ReadActivatedModuleHashes(db, TargetWavm, common.Hash{}, 10); err != nil || len(hashes) != 1 {
+		t.Fatalf("unexpected module hashes: %v, %v", hashes, err)
+	}
+	DeleteActivation(db, hash)
+	DeleteUnreferencedModule(db, hash)
+	for _, target := range AllWasmTargets() {
+		if asm := ReadActivatedAsm(db, target, hash); asm != nil {
+			t.Fatalf("asm of target %v not deleted", target)
+		}
+	}
+	if _, ok := ReadUnreferencedModule(db, hash); ok {
+		t.Fatal("unreferenced marker not deleted")
+	}
+}
+
+func TestRecompileRequests(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	WriteRecompileRequest(db, common.Hash{2}, TargetArm64)
+	WriteRecompileRequest(db, common.Hash{1}, TargetArm64)
+	WriteRecompileRequest(db, common.Hash{1}, TargetAmd64)
+
+	if count, err := CountRecompileRequests(db); err != nil || count != 3 {
+		t.Fatalf("unexpected request count: %d, %v", count, err)
+	}
+	requests, err := ReadRecompileRequests(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RecompileRequest{
+		{ModuleHash: common.Hash{1}, Target: TargetAmd64},
+		{ModuleHash: common.Hash{1}, Target: TargetArm64},
+	}
+	if !slices.Equal(requests, want) {
+		t.Fatalf("unexpected requests: have %v, want %v", requests, want)
+	}
+	DeleteRecompileRequest(db, common.Hash{1}, TargetAmd64)
+	DeleteRecompileRequest(db, common.Hash{1}, TargetArm64)
+	requests, err = ReadRecompileRequests(db, 10)
+	if err != nil || !slices.Equal(requests, []RecompileRequest{{ModuleHash: common.Hash{2}, Target: TargetArm64}}) {
+		t.Fatalf("unexpected requests: %v, %v", requests, err)
+	}
+}
+
+func TestCodeSizeIndex(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	short, long := []byte{0xef, 0xf0, 0x00, 0x00}, make([]byte, 100)
+	for i := range long {
+		long[i] = byte(i)
+	}
+	shortHash, longHash := common.Hash{1}, common.Hash{2}
+	WriteCode(db, shortHash, short)
+	WriteCode(db, longHash, long)
+
+	if size, ok := ReadCodeSize(db, longHash); !ok || size != len(long) {
+		t.Fatalf("unexpected code size: have %d %v, want %d", size, ok, len(long))
+	}
+	if prefix, ok := ReadCodePrefix(db, shortHash, 10); !ok || !bytes.Equal(prefix, short) {
+		t.Fatalf("unexpected short code prefix: have %x %v, want %x", prefix, ok, short)
+	}
+	if prefix, ok := ReadCodePrefix(db, longHash, 4); !ok || !bytes.Equal(prefix, long[:4]) {
+		t.Fatalf("unexpected long code prefix: have %x %v, want %x", prefix, ok, long[:4])
+	}
+	// Prefixes beyond the indexed bytes must be read from the code itself
+	if _, ok := ReadCodePrefix(db, longHash, CodeSizeIndexPrefixLen+1); ok {
+		t.Fatal("prefix beyond the index unexpectedly served")
+	}
+	DeleteCode(db, longHash)
+	if _, ok := ReadCodeSize(db, longHash); ok {
+		t.Fatal("code size index retained after code deletion")
+	}
+	// The index keys must be recognisable for pruning, distinct from code keys
+	if ok, hash := IsCodeSizeKey(codeSizeKey(shortHash)); !ok || common.BytesToHash(hash) != shortHash {
+		t.Fatalf("code size key not recognised: %v %x", ok, hash)
+	}
+	if ok, _ := IsCodeKey(codeSizeKey(shortHash)); ok {
+		t.Fatal("code size key mistaken for a code key")
+	}
+	if ok, _ := IsCodeSizeKey(codeKey(shortHash)); ok {
+		t.Fatal("code key mistaken for a code size key")
+	}
+}
+
+func TestAccountAccessEpochs(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	first, second := common.Address{1}, common.Address{2}
+	WriteAccountAccessEpoch(db, first, 3)
+	WriteAccountAccessEpoch(db, second, 5)
+	WriteInactiveAccount(db, first, 7)
+
+	if epoch, ok := ReadAccountAccessEpoch(db, second); !ok || epoch != 5 {
+		t.Fatalf("unexpected access epoch: have %d %v, want 5", epoch, ok)
+	}
+	if epoch, ok := ReadInactiveAccount(db, first); !ok || epoch != 7 {
+		t.Fatalf("unexpected inactivity epoch: have %d %v, want 7", epoch, ok)
+	}
+	if _, ok := ReadInactiveAccount(db, second); ok {
+		t.Fatal("active account marked inactive")
+	}
+	var tracked []common.Address
+	it := IterateAccountAccessEpochs(db)
+	for it.Next() {
+		tracked = append(tracked, common.BytesToAddress(it.Key()[len(AccountAccessPrefix):]))
+	}
+	it.Release()
+	if !slices.Equal(tracked, []common.Address{first, second}) {
+		t.Fatalf("unexpected tracked accounts: %v", tracked)
+	}
+	DeleteInactiveAccount(db, first)
+	DeleteAccountAccessEpoch(db, second)
+	if _, ok := ReadInactiveAccount(db, first); ok {
+		t.Fatal("inactivity marker retained after deletion")
+	}
+	if _, ok := ReadAccountAccessEpoch(db, second); ok {
+		t.Fatal("access epoch retained after deletion")
+	}
+}
+
+func TestFreezeActivations(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewWasmStoreWithFreezer(NewMemoryDatabase(), dir, false)
+	if err != nil {
+		t.Fatalf("failed to open wasm freezer: %v", err)
+	}
+	defer store.Close()
+
+	for i := byte(1); i <= 3; i++ {
+		hash := common.Hash{i}
+		WriteActivation(store, hash, map[ethdb.WasmTarget][]byte{TargetWavm: {i}, TargetAmd64: {i, i}})
+		WriteActivationBlock(store, hash, uint64(i))
+	}
+	frozen, size, err := FreezeActivations(store, 3)
+	if err != nil {
+		t.Fatalf("failed to freeze activations: %v", err)
+	}
+	if frozen != 2 || size != 6 {
+		t.Fatalf("unexpected freeze result: have %d modules of %d bytes, want 2 of 6", frozen, size)
+	}
+	hashes, err := ReadFrozenModuleHashes(store)
+	if err != nil || !slices.Equal(hashes, []common.Hash{{1}, {2}}) {
+		t.Fatalf("frozen modules mismatch: have %v (err %v)", hashes, err)
+	}
+	// The frozen asm is gone from the key-value store, but still readable
+	inner := store.(*wasmStoreWithFreezer).KeyValueStore
+	if ReadActivatedAsm(inner, TargetWavm, common.Hash{1}) != nil {
+		t.Fatal("frozen asm left in the key-value store")
+	}
+	if asm := ReadActivatedAsm(store, TargetAmd64, common.Hash{2}); !bytes.Equal(asm, []byte{2, 2}) {
+		t.Fatalf("frozen asm mismatch: have %x", asm)
+	}
+	if asm := ReadActivatedAsm(store, TargetArm64, common.Hash{2}); asm != nil {
+		t.Fatalf("asm of a missing target returned: %x", asm)
+	}
+	if asm := ReadActivatedAsm(inner, TargetWavm, common.Hash{3}); !bytes.Equal(asm, []byte{3}) {
+		t.Fatalf("recent asm frozen: have %x", asm)
+	}
+	// Deleting a frozen module drops it from the freezer index
+	batch := store.NewBatch()
+	DeleteActivation(batch, common.Hash{1})
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to delete activation: %v", err)
+	}
+	if ReadActivatedAsm(store, TargetWavm, common.Hash{1}) != nil {
+		t.Fatal("deleted frozen module still readable")
+	}
+	// Plain wasm stores can't freeze
+	if _, _, err := FreezeActivations(NewMemoryDatabase(), 3); err != ErrNoWasmFreezer {
+		t.Fatalf("unexpected error freezing without freezer: %v", err)
+	}
+}
@@ -124,6 +124,12 @@ func DeleteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, pat
 	}
 }
 
+// IterateStorageTrieNodes returns an iterator for walking the path-based storage
+// trie nodes of a specific account.
+func IterateStorageTrieNodes(db ethdb.Iteratee, accountHash common.Hash) ethdb.Iterator {
+	return db.NewIterator(storageTrieNodeKey(accountHash, nil), nil)
+}
+
 // ReadLegacyTrieNode retrieves the legacy trie node with the given
 // associated node hash.
 func ReadLegacyTrieNode(db ethdb.KeyValueReader, hash common.Hash) []byte {
@@ -300,3 +306,18 @@ func ParseStateScheme(provided string, disk ethdb.Database) (string, error) {
 	}
 	return "", fmt.Errorf("incompatible state scheme, stored: %s, provided: %s", stored, provided)
 }
+
+// ReadStateMigrationProgress retrieves the serialized progress of the migration
+// of the state to the path scheme.
+func ReadStateMigrationProgress(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(stateMigrationKey)
+	return data
+}
+
+// WriteStateMigrationProgress stores the serialized progress of the migration
+// of the state to the path scheme.
+func WriteStateMigrationProgress(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(stateMigrationKey, progress); err != nil {
+		log.Crit("Failed to store state migration progress", "err", err)
+	}
+}
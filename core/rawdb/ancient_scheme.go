@@ -50,6 +50,12 @@ var chainFreezerNoSnappy = map[string]bool{
 	ChainFreezerDifficultyTable: true,
 }
 
+// Arbitrum: chainFreezerPrunable lists the ancient-tables which may be pruned
+// below the tail of the others, to only retain the recent receipts.
+var chainFreezerPrunable = map[string]bool{
+	ChainFreezerReceiptTable: true,
+}
+
 const (
 	// stateHistoryTableSize defines the maximum size of freezer data files.
 	stateHistoryTableSize = 2 * 1000 * 1000 * 1000
@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const (
@@ -64,7 +65,7 @@ func newChainFreezer(datadir string, namespace string, readonly bool) (*chainFre
 	if datadir == "" {
 		freezer = NewMemoryFreezer(readonly, chainFreezerNoSnappy)
 	} else {
-		freezer, err = NewFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy)
+		freezer, err = newFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy, chainFreezerPrunable)
 	}
 	if err != nil {
 		return nil, err
@@ -313,7 +314,12 @@ func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hash
 			}
 			receipts := ReadReceiptsRLP(nfdb, hash, number)
 			if len(receipts) == 0 {
-				return fmt.Errorf("block receipts missing, can't freeze block %d", number)
+				// Arbitrum: the receipts below the tail were pruned, freeze a
+				// placeholder to be pruned from the ancients next
+				if tail := ReadReceiptsTail(nfdb); tail == nil || number >= *tail {
+					return fmt.Errorf("block receipts missing, can't freeze block %d", number)
+				}
+				receipts = rlp.EmptyList
 			}
 			td := ReadTdRLP(nfdb, hash, number)
 			if len(td) == 0 {
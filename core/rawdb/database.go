@@ -519,6 +519,13 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		accountTries    stat
 		storageTries    stat
 		codes           stat
+		codeSizes       stat
+		accountAccesses stat
+		inactiveAccts   stat
+		stylusStats     stat
+		snapDiffs       stat
+		logIndex        stat
+		flatHistory     stat
 		txLookups       stat
 		accountSnaps    stat
 		storageSnaps    stat
@@ -527,6 +534,11 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		beaconHeaders   stat
 		cliqueSnaps     stat
 
+		// Arbitrum: ArbOS state and wasm store statistics
+		arbosSnaps stat
+		arbosTries stat
+		wasm       = newWasmStoreStat()
+
 		// Les statistic
 		chtTrieNodes   stat
 		bloomTrieNodes stat
@@ -564,14 +576,38 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			stateLookups.Add(size)
 		case IsAccountTrieNode(key):
 			accountTries.Add(size)
+		case IsStorageTrieNode(key) && isArbosStorageKey(key, TrieNodeStoragePrefix):
+			arbosTries.Add(size)
 		case IsStorageTrieNode(key):
 			storageTries.Add(size)
 		case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
 			codes.Add(size)
+		case bytes.HasPrefix(key, CodeSizePrefix) && len(key) == len(CodeSizePrefix)+common.HashLength:
+			codeSizes.Add(size)
+		case bytes.HasPrefix(key, AccountAccessPrefix) && len(key) == len(AccountAccessPrefix)+common.AddressLength:
+			accountAccesses.Add(size)
+		case bytes.HasPrefix(key, InactiveAccountPrefix) && len(key) == len(InactiveAccountPrefix)+common.AddressLength:
+			inactiveAccts.Add(size)
+		case bytes.HasPrefix(key, BlockStylusStatsPrefix) && len(key) == len(BlockStylusStatsPrefix)+8+common.HashLength:
+			stylusStats.Add(size)
+		case bytes.HasPrefix(key, SnapshotDiffJournalPrefix) && len(key) == len(SnapshotDiffJournalPrefix)+common.HashLength:
+			snapDiffs.Add(size)
+		case bytes.HasPrefix(key, LogIndexAddressPrefix) && len(key) == len(LogIndexAddressPrefix)+common.AddressLength+8:
+			logIndex.Add(size)
+		case bytes.HasPrefix(key, LogIndexTopicPrefix) && len(key) == len(LogIndexTopicPrefix)+common.HashLength+8:
+			logIndex.Add(size)
+		case bytes.HasPrefix(key, FlatHistoryAccountPrefix) && len(key) == len(FlatHistoryAccountPrefix)+common.AddressLength+8:
+			flatHistory.Add(size)
+		case bytes.HasPrefix(key, FlatHistoryStoragePrefix) && len(key) == len(FlatHistoryStoragePrefix)+common.AddressLength+common.HashLength+8:
+			flatHistory.Add(size)
+		case bytes.HasPrefix(key, FlatHistoryChangesetPrefix) && len(key) == len(FlatHistoryChangesetPrefix)+8:
+			flatHistory.Add(size)
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txLookups.Add(size)
 		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
 			accountSnaps.Add(size)
+		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength) && isArbosStorageKey(key, SnapshotStoragePrefix):
+			arbosSnaps.Add(size)
 		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
 			storageSnaps.Add(size)
 		case bytes.HasPrefix(key, PreimagePrefix) && len(key) == (len(PreimagePrefix)+common.HashLength):
@@ -596,6 +632,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bytes.HasPrefix(key, BloomTrieIndexPrefix) ||
 			bytes.HasPrefix(key, BloomTriePrefix): // Bloomtrie sub
 			bloomTrieNodes.Add(size)
+		case wasm.add(key, size):
+			// Arbitrum: wasm entries stored along the chain data
 		default:
 			var accounted bool
 			for _, meta := range [][]byte{
@@ -604,6 +642,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
 				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
 				persistentStateIDKey, trieJournalKey, snapshotSyncStatusKey, snapSyncStatusFlagKey,
+				logIndexTailKey, logIndexHeadKey, receiptsTailKey, stateMigrationKey,
+				flatHistoryTailKey, flatHistoryHeadKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -632,6 +672,13 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
+		{"Key-Value store", "Contract code sizes", codeSizes.Size(), codeSizes.Count()},
+		{"Key-Value store", "Account access epochs", accountAccesses.Size(), accountAccesses.Count()},
+		{"Key-Value store", "Inactive account markers", inactiveAccts.Size(), inactiveAccts.Count()},
+		{"Key-Value store", "Block stylus stats", stylusStats.Size(), stylusStats.Count()},
+		{"Key-Value store", "Snapshot diff journal", snapDiffs.Size(), snapDiffs.Count()},
+		{"Key-Value store", "Log index entries", logIndex.Size(), logIndex.Count()},
+		{"Key-Value store", "Flat state history", flatHistory.Size(), flatHistory.Count()},
 		{"Key-Value store", "Hash trie nodes", legacyTries.Size(), legacyTries.Count()},
 		{"Key-Value store", "Path trie state lookups", stateLookups.Size(), stateLookups.Count()},
 		{"Key-Value store", "Path trie account nodes", accountTries.Size(), accountTries.Count()},
@@ -639,12 +686,38 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
+		{"Key-Value store", "ArbOS storage snapshot", arbosSnaps.Size(), arbosSnaps.Count()},
+		{"Key-Value store", "ArbOS storage trie nodes", arbosTries.Size(), arbosTries.Count()},
 		{"Key-Value store", "Beacon sync headers", beaconHeaders.Size(), beaconHeaders.Count()},
 		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
 		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
 	}
+	// Arbitrum: inspect the wasm store, unless its entries were met above
+	if store := separateWasmStore(db); store != nil {
+		wasmStats, wasmUnaccounted, err := inspectWasmStore(store, keyPrefix, keyStart)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, wasmStats.stats("Wasm store")...)
+		total += wasmStats.size()
+		unaccounted.size += wasmUnaccounted.size
+		unaccounted.count += wasmUnaccounted.count
+
+		frozen, err := inspectWasmFreezer(store)
+		if err != nil {
+			return err
+		}
+		if frozen != nil {
+			for _, table := range frozen.sizes {
+				stats = append(stats, []string{"Ancient store (Wasm)", strings.Title(table.name), table.size.String(), fmt.Sprintf("%d", frozen.count())})
+			}
+			total += frozen.size()
+		}
+	} else {
+		stats = append(stats, wasm.stats("Key-Value store")...)
+	}
 	// Inspect all registered append-only file store then.
 	ancients, err := inspectFreezers(db)
 	if err != nil {
@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// arbosStateHash is the hash of the ArbOS state account, keying its storage
+// snapshot and path trie nodes.
+var arbosStateHash = crypto.Keccak256(types.ArbosStateAddress.Bytes())
+
+// isArbosStorageKey reports whether the given storage snapshot or storage trie
+// node key belongs to the ArbOS state account.
+func isArbosStorageKey(key []byte, prefix []byte) bool {
+	return len(key) >= len(prefix)+common.HashLength && bytes.Equal(key[len(prefix):len(prefix)+common.HashLength], arbosStateHash)
+}
+
+// wasmStoreStat holds the statistics of the wasm store entries, which are kept
+// in the chain database unless the wasm store is a separate one.
+type wasmStoreStat struct {
+	asms         map[ethdb.WasmTarget]*stat
+	unreferenced stat
+	recompiles   stat
+	activations  stat
+	frozen       stat
+	deprecated   stat
+	metadata     stat
+}
+
+func newWasmStoreStat() *wasmStoreStat {
+	s := &wasmStoreStat{asms: make(map[ethdb.WasmTarget]*stat)}
+	for _, target := range AllWasmTargets() {
+		s.asms[target] = new(stat)
+	}
+	return s
+}
+
+// add accounts the given entry if it belongs to the wasm store, reporting
+// whether it did.
+func (s *wasmStoreStat) add(key []byte, size common.StorageSize) bool {
+	if target, _, ok := parseActivatedAsmKey(key); ok {
+		s.asms[target].Add(size)
+		return true
+	}
+	switch {
+	case bytes.HasPrefix(key, unreferencedModulePrefix[:]) && len(key) == WasmKeyLen:
+		s.unreferenced.Add(size)
+	case bytes.HasPrefix(key, recompileRequestPrefix[:]) && len(key) > WasmKeyLen:
+		s.recompiles.Add(size)
+	case bytes.HasPrefix(key, activationBlockPrefix[:]) && len(key) == WasmPrefixLen+8+common.HashLength:
+		s.activations.Add(size)
+	case bytes.HasPrefix(key, frozenModulePrefix[:]) && len(key) == WasmKeyLen:
+		s.frozen.Add(size)
+	case bytes.Equal(key, wasmSchemaVersionKey):
+		s.metadata.Add(size)
+	default:
+		prefixes, length := DeprecatedPrefixesV0()
+		if len(key) != length {
+			return false
+		}
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(key, prefix) {
+				s.deprecated.Add(size)
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// size returns the storage size of all the wasm store entries.
+func (s *wasmStoreStat) size() common.StorageSize {
+	total := s.unreferenced.size + s.recompiles.size + s.activations.size + s.frozen.size + s.deprecated.size + s.metadata.size
+	for _, asms := range s.asms {
+		total += asms.size
+	}
+	return total
+}
+
+// stats returns the table rows of the wasm store statistics, attributed to the
+// given database.
+func (s *wasmStoreStat) stats(database string) [][]string {
+	var rows [][]string
+	for _, target := range AllWasmTargets() {
+		asms := s.asms[target]
+		rows = append(rows, []string{database, fmt.Sprintf("Activated asm (%s)", target), asms.Size(), asms.Count()})
+	}
+	return append(rows,
+		[]string{database, "Wasm activation records", s.activations.Size(), s.activations.Count()},
+		[]string{database, "Wasm frozen module index", s.frozen.Size(), s.frozen.Count()},
+		[]string{database, "Wasm unreferenced modules", s.unreferenced.Size(), s.unreferenced.Count()},
+		[]string{database, "Wasm recompile requests", s.recompiles.Size(), s.recompiles.Count()},
+		[]string{database, "Wasm deprecated entries", s.deprecated.Size(), s.deprecated.Count()},
+		[]string{database, "Wasm metadata", s.metadata.Size(), s.metadata.Count()},
+	)
+}
+
+// separateWasmStore returns the wasm store of the given database, or nil if the
+// wasm entries are stored in the database itself.
+func separateWasmStore(db ethdb.Database) ethdb.KeyValueStore {
+	store, _ := db.WasmDataBase()
+	if store == nil || store == ethdb.KeyValueStore(db) {
+		return nil
+	}
+	return store
+}
+
+// inspectWasmStore traverses the separate wasm store and checks the size of the
+// wasm entries, returning the size of the unaccounted ones too.
+func inspectWasmStore(store ethdb.KeyValueStore, keyPrefix, keyStart []byte) (*wasmStoreStat, stat, error) {
+	it := store.NewIterator(keyPrefix, keyStart)
+	defer it.Release()
+
+	var (
+		wasm        = newWasmStoreStat()
+		unaccounted stat
+	)
+	for it.Next() {
+		key := it.Key()
+		size := common.StorageSize(len(key) + len(it.Value()))
+		if !wasm.add(key, size) {
+			unaccounted.Add(size)
+		}
+	}
+	return wasm, unaccounted, it.Error()
+}
+
+// inspectWasmFreezer inspects the freezer holding the asm of the cold modules,
+// if the wasm store has one.
+func inspectWasmFreezer(store ethdb.KeyValueStore) (*freezerInfo, error) {
+	s, ok := store.(*wasmStoreWithFreezer)
+	if !ok {
+		return nil, nil
+	}
+	info, err := inspect("wasm", wasmFreezerNoSnappy(), s.freezer)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
@@ -15,3 +15,41 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// Tests that the entries of a separate wasm store are classified by category.
+func TestInspectWasmStore(t *testing.T) {
+	var (
+		store  = memorydb.New()
+		module = common.HexToHash("0x01")
+	)
+	WriteActivatedAsm(store, TargetWavm, module, []byte{0x01})
+	WriteActivatedAsm(store, TargetAmd64, module, []byte{0x02})
+	WriteActivationBlock(store, module, 7)
+	WriteUnreferencedModule(store, module, 9)
+	WriteRecompileRequest(store, module, TargetArm64)
+	store.Put([]byte("unknown"), []byte{0x03})
+
+	wasm, unaccounted, err := inspectWasmStore(store, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect wasm store: %v", err)
+	}
+	for target, want := range map[ethdb.WasmTarget]counter{TargetWavm: 1, TargetArm64: 0, TargetAmd64: 1, TargetHost: 0} {
+		if have := wasm.asms[target].count; have != want {
+			t.Errorf("asm count mismatch for %s: have %d, want %d", target, have, want)
+		}
+	}
+	if wasm.activations.count != 1 || wasm.unreferenced.count != 1 || wasm.recompiles.count != 1 || wasm.frozen.count != 0 {
+		t.Errorf("unexpected wasm entry counts: %+v", wasm)
+	}
+	if unaccounted.count != 1 {
+		t.Errorf("unaccounted count mismatch: have %d, want 1", unaccounted.count)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+)
+
+// errNotPrunable is returned if the user attempts to prune a table which is not
+// allowed to be pruned independently of the others.
+var errNotPrunable = errors.New("table not prunable")
+
+// ancientTablePruner is implemented by the ancient stores able to prune a single
+// table, leaving the others untouched.
+type ancientTablePruner interface {
+	// TruncateTableTail discards the items of the given table below the provided
+	// threshold number, returning the previous tail of the table.
+	TruncateTableTail(kind string, tail uint64) (uint64, error)
+}
+
+// TruncateTableTail discards the items of a prunable table below the provided
+// threshold number, returning the previous tail of the table. The other tables
+// are left untouched.
+func (f *Freezer) TruncateTableTail(kind string, tail uint64) (uint64, error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	table, ok := f.tables[kind]
+	if !ok {
+		return 0, errUnknownTable
+	}
+	if !f.prunable[kind] {
+		return 0, errNotPrunable
+	}
+	old := table.itemHidden.Load()
+	if old >= tail {
+		return old, nil
+	}
+	if err := table.truncateTail(tail); err != nil {
+		return 0, err
+	}
+	return old, nil
+}
+
+// TruncateTableTail discards the items of the given table below the provided
+// threshold number, returning the previous tail of the table. The other tables
+// are left untouched.
+func (f *MemoryFreezer) TruncateTableTail(kind string, tail uint64) (uint64, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	table, ok := f.tables[kind]
+	if !ok {
+		return 0, errUnknownTable
+	}
+	old := table.offset
+	if old >= tail {
+		return old, nil
+	}
+	if err := table.truncateTail(tail); err != nil {
+		return 0, err
+	}
+	return old, nil
+}
+
+// TruncateTableTail discards the items of the given table below the provided
+// threshold number, if supported by the backing ancient store.
+func (f *chainFreezer) TruncateTableTail(kind string, tail uint64) (uint64, error) {
+	pruner, ok := f.AncientStore.(ancientTablePruner)
+	if !ok {
+		return 0, errNotSupported
+	}
+	return pruner.TruncateTableTail(kind, tail)
+}
+
+// TruncateTableTail discards the items of the given table below the provided
+// threshold number, if supported by the wrapped database.
+func (db *dbWithWasmEntry) TruncateTableTail(kind string, tail uint64) (uint64, error) {
+	pruner, ok := db.Database.(ancientTablePruner)
+	if !ok {
+		return 0, errNotSupported
+	}
+	return pruner.TruncateTableTail(kind, tail)
+}
@@ -500,3 +500,60 @@ func TestFreezerSuite(t *testing.T) {
 		return f
 	})
 }
+
+// Tests that a prunable table can be pruned independently of the others, and
+// that the freezer still opens afterwards.
+func TestFreezerTruncateTableTail(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir      = t.TempDir()
+		tables   = map[string]bool{"a": true, "b": true}
+		prunable = map[string]bool{"b": true}
+	)
+	f, err := newFreezer(dir, "", false, 2049, tables, prunable)
+	if err != nil {
+		t.Fatal("can't open freezer", err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			require.NoError(t, op.AppendRaw("a", i, make([]byte, 1024)))
+			require.NoError(t, op.AppendRaw("b", i, make([]byte, 1024)))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	if _, err := f.TruncateTableTail("a", 5); err != errNotPrunable {
+		t.Fatalf("pruning a non-prunable table: have %v, want %v", err, errNotPrunable)
+	}
+	old, err := f.TruncateTableTail("b", 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), old)
+
+	if _, err := f.Ancient("b", 4); err == nil {
+		t.Fatal("pruned item still retrievable")
+	}
+	if _, err := f.Ancient("a", 4); err != nil {
+		t.Fatalf("item of the unpruned table lost: %v", err)
+	}
+	f.Close()
+
+	// Reopen and check that the repair didn't move the tail of the other table
+	f, err = newFreezer(dir, "", false, 2049, tables, prunable)
+	if err != nil {
+		t.Fatalf("can't reopen freezer after pruning a table: %v", err)
+	}
+	defer f.Close()
+
+	if tail, _ := f.Tail(); tail != 0 {
+		t.Fatalf("freezer tail moved: have %d, want 0", tail)
+	}
+	checkAncientCount(t, f, "a", 10)
+	if _, err := f.Ancient("a", 0); err != nil {
+		t.Fatalf("item of the unpruned table lost after reopen: %v", err)
+	}
+	if _, err := f.Ancient("b", 4); err == nil {
+		t.Fatal("pruned item retrievable after reopen")
+	}
+}
@@ -79,6 +79,25 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// Arbitrum: receiptsTailKey tracks the oldest block whose receipts are
+	// retained, the receipts of the blocks below were pruned.
+	receiptsTailKey = []byte("ReceiptsTail")
+
+	// Arbitrum: logIndexTailKey and logIndexHeadKey track the range of blocks
+	// whose logs have been indexed by the log index.
+	logIndexTailKey = []byte("LogIndexTail")
+	logIndexHeadKey = []byte("LogIndexHead")
+
+	// Arbitrum: stateMigrationKey tracks the progress of the online migration of
+	// the state from the hash scheme to the path scheme.
+	stateMigrationKey = []byte("StateMigration")
+
+	// Arbitrum: flatHistoryTailKey and flatHistoryHeadKey track the range of
+	// blocks whose state changes are indexed by the flat history, the head
+	// also holding the hash of the newest indexed block.
+	flatHistoryTailKey = []byte("FlatHistoryTail")
+	flatHistoryHeadKey = []byte("FlatHistoryHead")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	// This flag is deprecated, it's kept to avoid reporting errors when inspect
 	// database.
@@ -112,11 +131,33 @@ var (
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
 
+	// Arbitrum: code size index, so size and prefix queries don't load the full code
+	CodeSizePrefix = []byte("cs") // CodeSizePrefix + code hash -> code size (uint32 big endian) + leading code bytes
+
 	// Path-based storage scheme of merkle patricia trie.
 	TrieNodeAccountPrefix = []byte("A") // TrieNodeAccountPrefix + hexPath -> trie node
 	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// Arbitrum: incremental snapshot journal, persisting the diff layers as they're created
+	SnapshotDiffJournalPrefix = []byte("snapshot-diff-") // SnapshotDiffJournalPrefix + state root -> diff layer journal entry
+
+	// Arbitrum: exact log index, supplementing the bloombits
+	LogIndexAddressPrefix = []byte("log-index-a-") // LogIndexAddressPrefix + address + num (uint64 big endian) -> empty, block has logs emitted by the address
+	LogIndexTopicPrefix   = []byte("log-index-t-") // LogIndexTopicPrefix + topic + num (uint64 big endian) -> empty, block has logs with the topic
+
+	// Arbitrum: flat state history, the original values of the accounts and slots modified by each block
+	FlatHistoryAccountPrefix   = []byte("flat-history-a-") // FlatHistoryAccountPrefix + address + num (uint64 big endian) -> account before the block in slim RLP, empty if absent
+	FlatHistoryStoragePrefix   = []byte("flat-history-s-") // FlatHistoryStoragePrefix + address + slot hash + num (uint64 big endian) -> slot before the block in trimmed RLP, empty if absent
+	FlatHistoryChangesetPrefix = []byte("flat-history-c-") // FlatHistoryChangesetPrefix + num (uint64 big endian) -> block hash and keys of the entries of the block
+
+	// Arbitrum: account inactivity tracking, for state expiry simulations
+	AccountAccessPrefix   = []byte("account-access-")   // AccountAccessPrefix + address -> last access epoch (uint64 big endian)
+	InactiveAccountPrefix = []byte("account-inactive-") // InactiveAccountPrefix + address -> epoch the account was marked inactive at (uint64 big endian)
+
+	// Arbitrum: summary of the Stylus executions of the blocks running Stylus programs
+	BlockStylusStatsPrefix = []byte("block-stylus-") // BlockStylusStatsPrefix + num (uint64 big endian) + hash -> block stylus stats
+
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
 	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
@@ -241,6 +282,11 @@ func codeKey(hash common.Hash) []byte {
 	return append(CodePrefix, hash.Bytes()...)
 }
 
+// codeSizeKey = CodeSizePrefix + hash
+func codeSizeKey(hash common.Hash) []byte {
+	return append(CodeSizePrefix, hash.Bytes()...)
+}
+
 // IsCodeKey reports whether the given byte slice is the key of contract code,
 // if so return the raw code hash as well.
 func IsCodeKey(key []byte) (bool, []byte) {
@@ -250,6 +296,15 @@ func IsCodeKey(key []byte) (bool, []byte) {
 	return false, nil
 }
 
+// IsCodeSizeKey reports whether the given byte slice is the key of a contract
+// code size index entry, if so return the raw code hash as well.
+func IsCodeSizeKey(key []byte) (bool, []byte) {
+	if bytes.HasPrefix(key, CodeSizePrefix) && len(key) == common.HashLength+len(CodeSizePrefix) {
+		return true, key[len(CodeSizePrefix):]
+	}
+	return false, nil
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
@@ -270,6 +325,51 @@ func accountTrieNodeKey(path []byte) []byte {
 	return append(TrieNodeAccountPrefix, path...)
 }
 
+// snapshotDiffJournalKey = SnapshotDiffJournalPrefix + state root
+func snapshotDiffJournalKey(root common.Hash) []byte {
+	return append(SnapshotDiffJournalPrefix, root.Bytes()...)
+}
+
+// logIndexAddressKey = LogIndexAddressPrefix + address + num (uint64 big endian)
+func logIndexAddressKey(addr common.Address, number uint64) []byte {
+	return append(append(LogIndexAddressPrefix, addr.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// logIndexTopicKey = LogIndexTopicPrefix + topic + num (uint64 big endian)
+func logIndexTopicKey(topic common.Hash, number uint64) []byte {
+	return append(append(LogIndexTopicPrefix, topic.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// flatHistoryAccountKey = FlatHistoryAccountPrefix + address + num (uint64 big endian)
+func flatHistoryAccountKey(addr common.Address, number uint64) []byte {
+	return append(append(FlatHistoryAccountPrefix, addr.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// flatHistoryStorageKey = FlatHistoryStoragePrefix + address + slot hash + num (uint64 big endian)
+func flatHistoryStorageKey(addr common.Address, slot common.Hash, number uint64) []byte {
+	return append(append(append(FlatHistoryStoragePrefix, addr.Bytes()...), slot.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// flatHistoryChangesetKey = FlatHistoryChangesetPrefix + num (uint64 big endian)
+func flatHistoryChangesetKey(number uint64) []byte {
+	return append(FlatHistoryChangesetPrefix, encodeBlockNumber(number)...)
+}
+
+// accountAccessKey = AccountAccessPrefix + address
+func accountAccessKey(addr common.Address) []byte {
+	return append(AccountAccessPrefix, addr.Bytes()...)
+}
+
+// inactiveAccountKey = InactiveAccountPrefix + address
+func inactiveAccountKey(addr common.Address) []byte {
+	return append(InactiveAccountPrefix, addr.Bytes()...)
+}
+
+// blockStylusStatsKey = BlockStylusStatsPrefix + num (uint64 big endian) + hash
+func blockStylusStatsKey(number uint64, hash common.Hash) []byte {
+	return append(append(BlockStylusStatsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // storageTrieNodeKey = TrieNodeStoragePrefix + accountHash + nodePath.
 func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
 	buf := make([]byte, len(TrieNodeStoragePrefix)+common.HashLength+len(path))
@@ -40,6 +40,11 @@ var (
 	activatedAsmArmPrefix  = WasmPrefix{0x00, 'w', 'r'} // (prefix, moduleHash) -> stylus asm for ARM system
 	activatedAsmX86Prefix  = WasmPrefix{0x00, 'w', 'x'} // (prefix, moduleHash) -> stylus asm for x86 system
 	activatedAsmHostPrefix = WasmPrefix{0x00, 'w', 'h'} // (prefix, moduleHash) -> stylus asm for system other then ARM and x86
+
+	unreferencedModulePrefix = WasmPrefix{0x00, 'w', 'u'} // (prefix, moduleHash) -> block number the module was first found unreferenced at
+	recompileRequestPrefix   = WasmPrefix{0x00, 'w', 'q'} // (prefix, moduleHash, target) -> empty, asm to compile for the target
+	activationBlockPrefix    = WasmPrefix{0x00, 'w', 'b'} // (prefix, block number, moduleHash) -> empty, module activated at the block and not frozen yet
+	frozenModulePrefix       = WasmPrefix{0x00, 'w', 'f'} // (prefix, moduleHash) -> index of the module's asm in the wasm freezer
 )
 
 func DeprecatedPrefixesV0() (keyPrefixes [][]byte, keyLength int) {
@@ -50,6 +55,14 @@ func DeprecatedPrefixesV0() (keyPrefixes [][]byte, keyLength int) {
 	}, 3 + 32
 }
 
+// key = activationBlockPrefix + block number (uint64 big endian) + moduleHash
+func activationBlockKey(number uint64, moduleHash common.Hash) []byte {
+	key := make([]byte, 0, WasmPrefixLen+8+common.HashLength)
+	key = append(key, activationBlockPrefix[:]...)
+	key = append(key, encodeBlockNumber(number)...)
+	return append(key, moduleHash[:]...)
+}
+
 // key = prefix + moduleHash
 func activatedKey(prefix WasmPrefix, moduleHash common.Hash) WasmKey {
 	var key WasmKey
@@ -0,0 +1,283 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrNoWasmFreezer is returned if the wasm store was not opened with a freezer.
+var ErrNoWasmFreezer = errors.New("wasm store has no freezer")
+
+// WasmFreezerHashTable indicates the name of the wasm freezer table holding the
+// module hashes, the other tables being named after the targets.
+const WasmFreezerHashTable = "hashes"
+
+// wasmFreezerNoSnappy configures whether compression is disabled for the wasm
+// freezer tables.
+func wasmFreezerNoSnappy() map[string]bool {
+	tables := map[string]bool{WasmFreezerHashTable: true}
+	for _, target := range AllWasmTargets() {
+		tables[string(target)] = false
+	}
+	return tables
+}
+
+// WriteActivationBlock records the block the module with the given moduleHash
+// was activated at, making it a candidate for freezing once old enough.
+func WriteActivationBlock(db ethdb.KeyValueWriter, moduleHash common.Hash, number uint64) {
+	if err := db.Put(activationBlockKey(number, moduleHash), nil); err != nil {
+		log.Crit("Failed to store wasm module activation block", "err", err)
+	}
+}
+
+// ReadFrozenModuleHashes retrieves the module hashes of all the modules whose
+// asm was moved to the wasm freezer.
+func ReadFrozenModuleHashes(db ethdb.Iteratee) ([]common.Hash, error) {
+	it := db.NewIterator(frozenModulePrefix[:], nil)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		if key := it.Key(); len(key) == WasmKeyLen {
+			hashes = append(hashes, common.BytesToHash(key[WasmPrefixLen:]))
+		}
+	}
+	return hashes, it.Error()
+}
+
+// wasmStoreWithFreezer is a wasm store moving the asm of cold modules into a
+// freezer. Reads of activated asm missing from the key-value store go through
+// to the freezer, and deletions drop the frozen asm too.
+type wasmStoreWithFreezer struct {
+	ethdb.KeyValueStore
+	freezer *Freezer
+}
+
+// NewWasmStoreWithFreezer opens the wasm freezer in the given directory, and
+// wraps the wasm store with it. The frozen asm is read transparently through
+// the returned store, and moved out of the key-value store by FreezeActivations.
+func NewWasmStoreWithFreezer(store ethdb.KeyValueStore, datadir string, readonly bool) (ethdb.KeyValueStore, error) {
+	freezer, err := NewFreezer(datadir, "wasm/", readonly, freezerTableSize, wasmFreezerNoSnappy())
+	if err != nil {
+		return nil, err
+	}
+	return &wasmStoreWithFreezer{KeyValueStore: store, freezer: freezer}, nil
+}
+
+// parseActivatedAsmKey returns the target and module hash of an activated asm
+// key, if the key is one.
+func parseActivatedAsmKey(key []byte) (ethdb.WasmTarget, common.Hash, bool) {
+	if len(key) != WasmKeyLen {
+		return "", common.Hash{}, false
+	}
+	for _, target := range AllWasmTargets() {
+		prefix, _ := activatedAsmKeyPrefix(target)
+		if bytes.HasPrefix(key, prefix[:]) {
+			return target, common.BytesToHash(key[WasmPrefixLen:]), true
+		}
+	}
+	return "", common.Hash{}, false
+}
+
+// readFrozenAsm retrieves the asm of the given module and target from the
+// freezer, nil if the module isn't frozen or has no asm for the target.
+func (s *wasmStoreWithFreezer) readFrozenAsm(target ethdb.WasmTarget, moduleHash common.Hash) []byte {
+	key := activatedKey(frozenModulePrefix, moduleHash)
+	data, err := s.KeyValueStore.Get(key[:])
+	if err != nil || len(data) != 8 {
+		return nil
+	}
+	asm, err := s.freezer.Ancient(string(target), binary.BigEndian.Uint64(data))
+	if err != nil {
+		return nil
+	}
+	return asm
+}
+
+func (s *wasmStoreWithFreezer) Has(key []byte) (bool, error) {
+	if has, err := s.KeyValueStore.Has(key); err != nil || has {
+		return has, err
+	}
+	if target, moduleHash, ok := parseActivatedAsmKey(key); ok {
+		return len(s.readFrozenAsm(target, moduleHash)) > 0, nil
+	}
+	return false, nil
+}
+
+func (s *wasmStoreWithFreezer) Get(key []byte) ([]byte, error) {
+	value, err := s.KeyValueStore.Get(key)
+	if err == nil {
+		return value, nil
+	}
+	if target, moduleHash, ok := parseActivatedAsmKey(key); ok {
+		if asm := s.readFrozenAsm(target, moduleHash); len(asm) > 0 {
+			return asm, nil
+		}
+	}
+	return nil, err
+}
+
+func (s *wasmStoreWithFreezer) Delete(key []byte) error {
+	if err := s.KeyValueStore.Delete(key); err != nil {
+		return err
+	}
+	if _, moduleHash, ok := parseActivatedAsmKey(key); ok {
+		frozen := activatedKey(frozenModulePrefix, moduleHash)
+		return s.KeyValueStore.Delete(frozen[:])
+	}
+	return nil
+}
+
+func (s *wasmStoreWithFreezer) NewBatch() ethdb.Batch {
+	return &wasmFreezerBatch{s.KeyValueStore.NewBatch()}
+}
+
+func (s *wasmStoreWithFreezer) NewBatchWithSize(size int) ethdb.Batch {
+	return &wasmFreezerBatch{s.KeyValueStore.NewBatchWithSize(size)}
+}
+
+func (s *wasmStoreWithFreezer) Close() error {
+	errs := []error{s.freezer.Close(), s.KeyValueStore.Close()}
+	return errors.Join(errs...)
+}
+
+// wasmFreezerBatch is a batch of the wasm store dropping the frozen asm of the
+// modules whose activated asm is deleted.
+type wasmFreezerBatch struct {
+	ethdb.Batch
+}
+
+func (b *wasmFreezerBatch) Delete(key []byte) error {
+	if err := b.Batch.Delete(key); err != nil {
+		return err
+	}
+	if _, moduleHash, ok := parseActivatedAsmKey(key); ok {
+		frozen := activatedKey(frozenModulePrefix, moduleHash)
+		return b.Batch.Delete(frozen[:])
+	}
+	return nil
+}
+
+// FreezeActivations moves the asm of the modules activated before the given
+// block from the key-value store into the wasm freezer, returning the number
+// of modules frozen and the total size of the asm moved. The asm is appended
+// to the freezer and synced before being deleted, so an interruption at most
+// leaves unreachable copies in the freezer.
+func FreezeActivations(store ethdb.KeyValueStore, before uint64) (int, uint64, error) {
+	s, ok := store.(*wasmStoreWithFreezer)
+	if !ok {
+		return 0, 0, ErrNoWasmFreezer
+	}
+	var (
+		hashes  []common.Hash
+		seen    = make(map[common.Hash]struct{})
+		records [][]byte
+	)
+	it := s.KeyValueStore.NewIterator(activationBlockPrefix[:], nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != WasmPrefixLen+8+common.HashLength {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[WasmPrefixLen:]) >= before {
+			break
+		}
+		records = append(records, common.CopyBytes(key))
+
+		// Modules activated again are recorded at every activation block
+		moduleHash := common.BytesToHash(key[WasmPrefixLen+8:])
+		if _, ok := seen[moduleHash]; !ok {
+			seen[moduleHash] = struct{}{}
+			hashes = append(hashes, moduleHash)
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return 0, 0, err
+	}
+	if len(hashes) == 0 {
+		return 0, 0, nil
+	}
+	// Append the asm still stored of the candidates, the ones deleted meanwhile
+	// only have their activation record dropped
+	var (
+		frozen []common.Hash
+		size   uint64
+	)
+	next, err := s.freezer.Ancients()
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = s.freezer.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for _, moduleHash := range hashes {
+			var (
+				asms  = make([][]byte, 0, len(AllWasmTargets()))
+				found bool
+			)
+			for _, target := range AllWasmTargets() {
+				asm := ReadActivatedAsm(s.KeyValueStore, target, moduleHash)
+				asms = append(asms, asm)
+				found = found || len(asm) > 0
+			}
+			if !found {
+				continue
+			}
+			number := next + uint64(len(frozen))
+			if err := op.AppendRaw(WasmFreezerHashTable, number, moduleHash[:]); err != nil {
+				return err
+			}
+			for i, target := range AllWasmTargets() {
+				if err := op.AppendRaw(string(target), number, asms[i]); err != nil {
+					return err
+				}
+				size += uint64(len(asms[i]))
+			}
+			frozen = append(frozen, moduleHash)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := s.freezer.Sync(); err != nil {
+		return 0, 0, err
+	}
+	batch := s.KeyValueStore.NewBatch()
+	for i, moduleHash := range frozen {
+		DeleteActivation(batch, moduleHash)
+		key := activatedKey(frozenModulePrefix, moduleHash)
+		if err := batch.Put(key[:], encodeBlockNumber(next+uint64(i))); err != nil {
+			return 0, 0, err
+		}
+	}
+	for _, key := range records {
+		if err := batch.Delete(key); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, 0, err
+	}
+	return len(frozen), size, nil
+}
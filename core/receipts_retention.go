@@ -0,0 +1,238 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	receiptsTailGauge       = metrics.NewRegisteredGauge("chain/receipts/tail", nil)
+	receiptsBackfilledMeter = metrics.NewRegisteredMeter("chain/receipts/backfilled", nil)
+)
+
+// receiptsRetentionInterval is the interval between two prunings of the
+// receipts falling out of the retention window.
+var receiptsRetentionInterval = time.Hour
+
+// PrunedReceiptsError is returned when the receipts or logs of a block pruned by
+// the receipts retention are requested.
+type PrunedReceiptsError struct {
+	Number uint64 // Number of the block requested
+	Tail   uint64 // Number of the oldest block with retained receipts
+}
+
+func (e *PrunedReceiptsError) Error() string {
+	return fmt.Sprintf("receipts of block %d pruned, oldest available block is %d", e.Number, e.Tail)
+}
+
+// ErrorCode returns the JSON error code of the pruned history, as used by the
+// EIP-4444 clients.
+func (e *PrunedReceiptsError) ErrorCode() int {
+	return 4444
+}
+
+// ReceiptsSource retrieves the receipts of old blocks from an archive, to
+// backfill the receipts pruned locally.
+type ReceiptsSource interface {
+	// BlockReceipts retrieves the receipts of the block with the given hash.
+	BlockReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+}
+
+// ReceiptsRetentionStatus describes the range of blocks with retained receipts.
+type ReceiptsRetentionStatus struct {
+	Tail   uint64 // Number of the oldest block with retained receipts
+	Head   uint64 // Number of the chain head
+	Blocks uint64 // Number of blocks pruned or backfilled by the operation
+}
+
+// ReceiptsRetention prunes the receipts, and thus the logs, of the blocks older
+// than the retention window, while keeping their headers and bodies. The window
+// is set in blocks, in time, or both, in which case the wider one applies.
+//
+// The number of the oldest block with retained receipts is persisted as the
+// receipts tail: the receipts below are reported as pruned, even if they were
+// not deleted yet. The pruned receipts can be restored from an archive with
+// Backfill, though they're pruned again if still out of the window.
+type ReceiptsRetention struct {
+	bc     *BlockChain
+	blocks uint64        // Number of recent blocks to retain the receipts of
+	period time.Duration // Age of the oldest block to retain the receipts of
+
+	lock sync.Mutex // Serializes prunings and backfills
+}
+
+func newReceiptsRetention(bc *BlockChain, blocks uint64, period time.Duration) *ReceiptsRetention {
+	if blocks == 0 && period == 0 {
+		return nil
+	}
+	return &ReceiptsRetention{bc: bc, blocks: blocks, period: period}
+}
+
+// ReceiptsRetention returns the receipts retention, or nil if the receipts are
+// retained forever.
+func (bc *BlockChain) ReceiptsRetention() *ReceiptsRetention {
+	return bc.receiptsRetention
+}
+
+// ReceiptsTail returns the number of the oldest block with retained receipts.
+func (bc *BlockChain) ReceiptsTail() uint64 {
+	return bc.receiptsTail.Load()
+}
+
+// CheckReceiptsRetained returns a PrunedReceiptsError if the receipts of the
+// block with the given number were pruned.
+func (bc *BlockChain) CheckReceiptsRetained(number uint64) error {
+	if tail := bc.receiptsTail.Load(); number < tail {
+		return &PrunedReceiptsError{Number: number, Tail: tail}
+	}
+	return nil
+}
+
+// receiptsRetentionLoop periodically prunes the receipts falling out of the
+// retention window.
+func (bc *BlockChain) receiptsRetentionLoop() {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(receiptsRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := bc.receiptsRetention.Prune(bc.receiptsRetention.Cutoff()); err != nil {
+				log.Warn("Failed to prune receipts", "err", err)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// Cutoff returns the number of the oldest block within the retention window.
+func (r *ReceiptsRetention) Cutoff() uint64 {
+	head := r.bc.CurrentBlock().Number.Uint64()
+	cutoff := head + 1
+	if r.blocks > 0 {
+		cutoff = 0
+		if head >= r.blocks {
+			cutoff = head - r.blocks + 1
+		}
+	}
+	if r.period > 0 {
+		since := uint64(time.Now().Add(-r.period).Unix())
+		oldest := uint64(sort.Search(int(head+1), func(i int) bool {
+			header := r.bc.GetHeaderByNumber(uint64(i))
+			return header != nil && header.Time >= since
+		}))
+		cutoff = min(cutoff, oldest)
+	}
+	return min(cutoff, head)
+}
+
+// Status returns the range of blocks with retained receipts.
+func (r *ReceiptsRetention) Status() *ReceiptsRetentionStatus {
+	return &ReceiptsRetentionStatus{
+		Tail: r.bc.receiptsTail.Load(),
+		Head: r.bc.CurrentBlock().Number.Uint64(),
+	}
+}
+
+// Prune deletes the receipts of all the blocks below the given one, moving the
+// receipts tail up. The frozen receipts left behind by a previous pruning are
+// deleted too.
+func (r *ReceiptsRetention) Prune(tail uint64) (*ReceiptsRetentionStatus, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	status := r.Status()
+	if tail > status.Head {
+		return nil, fmt.Errorf("receipts tail %d above the chain head %d", tail, status.Head)
+	}
+	// Report the receipts as pruned first, so they're not relied on meanwhile
+	if tail > status.Tail {
+		r.bc.receiptsTail.Store(tail)
+		receiptsTailGauge.Update(int64(tail))
+		status.Blocks, status.Tail = tail-status.Tail, tail
+	}
+	if err := rawdb.PruneReceipts(r.bc.db, tail); err != nil {
+		return status, err
+	}
+	if status.Blocks > 0 {
+		r.bc.receiptsCache.Purge()
+		log.Info("Pruned receipts", "tail", tail, "blocks", status.Blocks)
+	}
+	return status, nil
+}
+
+// Backfill restores the receipts of the canonical blocks from the receipts tail
+// down to the given block, fetching them from the given source. The receipts
+// are checked against the receipt roots of the headers.
+func (r *ReceiptsRetention) Backfill(ctx context.Context, source ReceiptsSource, from uint64) (*ReceiptsRetentionStatus, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Drop the placeholders frozen in place of the pruned receipts, so that the
+	// backfilled ones are read from the key-value store instead
+	if err := rawdb.PruneAncientReceipts(r.bc.db); err != nil {
+		return nil, err
+	}
+	status := r.Status()
+	for status.Tail > from {
+		if err := ctx.Err(); err != nil {
+			return status, err
+		}
+		number := status.Tail - 1
+		header := r.bc.GetHeaderByNumber(number)
+		if header == nil {
+			return status, fmt.Errorf("header of block %d unavailable", number)
+		}
+		receipts, err := source.BlockReceipts(ctx, header.Hash())
+		if err != nil {
+			return status, fmt.Errorf("failed to fetch the receipts of block %d: %w", number, err)
+		}
+		if root := trie.DeriveReceiptRoot(receipts); root != header.ReceiptHash {
+			return status, fmt.Errorf("receipts of block %d mismatch: have root %x, want %x", number, root, header.ReceiptHash)
+		}
+		batch := r.bc.db.NewBatch()
+		rawdb.WriteReceipts(batch, header.Hash(), number, receipts)
+		rawdb.WriteReceiptsTail(batch, number)
+		if err := batch.Write(); err != nil {
+			return status, err
+		}
+		r.bc.receiptsTail.Store(number)
+		receiptsTailGauge.Update(int64(number))
+		receiptsBackfilledMeter.Mark(1)
+		status.Tail = number
+		status.Blocks++
+	}
+	if status.Blocks > 0 {
+		log.Info("Backfilled receipts", "tail", status.Tail, "blocks", status.Blocks)
+	}
+	return status, nil
+}
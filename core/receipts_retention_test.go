@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testReceiptsSource serves the receipts of the generated blocks.
+type testReceiptsSource map[common.Hash]types.Receipts
+
+func (s testReceiptsSource) BlockReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	receipts, ok := s[hash]
+	if !ok {
+		return nil, fmt.Errorf("block %v not found", hash)
+	}
+	return receipts, nil
+}
+
+// Tests that the receipts below the retention window are pruned, reported as
+// such, and can be backfilled.
+func TestReceiptsRetention(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	config.ReceiptsRetentionBlocks = 4
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	retention := chain.ReceiptsRetention()
+	if cutoff := retention.Cutoff(); cutoff != 5 {
+		t.Fatalf("unexpected retention cutoff: have %d, want 5", cutoff)
+	}
+	status, err := retention.Prune(retention.Cutoff())
+	if err != nil {
+		t.Fatalf("failed to prune receipts: %v", err)
+	}
+	if status.Tail != 5 || status.Blocks != 5 {
+		t.Fatalf("unexpected prune result: %+v", status)
+	}
+	// The receipts below the tail are gone and reported as pruned
+	for i, block := range blocks {
+		have := chain.GetReceiptsByHash(block.Hash())
+		if pruned := block.NumberU64() < 5; pruned != (have == nil) {
+			t.Fatalf("block %d: have receipts %v, want pruned %v", block.NumberU64(), have != nil, pruned)
+		}
+		if block.NumberU64() < 5 && rawdb.ReadRawReceipts(chain.db, block.Hash(), block.NumberU64()) != nil {
+			t.Fatalf("block %d: receipts not deleted", i+1)
+		}
+	}
+	var perr *PrunedReceiptsError
+	if err := chain.CheckReceiptsRetained(2); !errors.As(err, &perr) || perr.Tail != 5 || perr.ErrorCode() != 4444 {
+		t.Fatalf("unexpected pruned receipts error: %v", err)
+	}
+	if err := chain.CheckReceiptsRetained(5); err != nil {
+		t.Fatalf("retained receipts reported as pruned: %v", err)
+	}
+	// Backfilling restores the receipts down to the requested block
+	source := make(testReceiptsSource)
+	for i, block := range blocks {
+		source[block.Hash()] = receipts[i]
+	}
+	if status, err = retention.Backfill(context.Background(), source, 3); err != nil {
+		t.Fatalf("failed to backfill receipts: %v", err)
+	}
+	if status.Tail != 3 || status.Blocks != 2 {
+		t.Fatalf("unexpected backfill result: %+v", status)
+	}
+	if have := chain.GetReceiptsByHash(blocks[2].Hash()); len(have) != 1 || have[0].TxHash != blocks[2].Transactions()[0].Hash() {
+		t.Fatalf("backfilled receipts mismatch: %v", have)
+	}
+	// Receipts not matching the header are rejected
+	source[blocks[1].Hash()] = types.Receipts{}
+	if _, err := retention.Backfill(context.Background(), source, 0); err == nil {
+		t.Fatal("mismatching receipts backfilled")
+	}
+	if tail := chain.ReceiptsTail(); tail != 3 {
+		t.Fatalf("receipts tail moved by failed backfill: have %d, want 3", tail)
+	}
+}
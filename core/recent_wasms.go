@@ -0,0 +1,50 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// RecentWasms is the content of the recent Stylus programs cache at the end
+// of a block, which discounts the repeated calls of programs within the block.
+type RecentWasms struct {
+	Number  uint64                    // Number of the block
+	Hash    common.Hash               // Hash of the block
+	Config  *params.RecentWasmsConfig // Policy of the cache, nil for the legacy behavior
+	Entries []state.RecentWasmEntry   // Programs retained, most recently used first
+}
+
+// recordRecentWasms retains the recent programs cache of the given block.
+func (bc *BlockChain) recordRecentWasms(block *types.Block, statedb *state.StateDB) {
+	recent := statedb.GetRecentWasms()
+	bc.recentWasms.Store(&RecentWasms{
+		Number:  block.NumberU64(),
+		Hash:    block.Hash(),
+		Config:  recent.Config(),
+		Entries: recent.Entries(),
+	})
+}
+
+// RecentWasms returns the recent programs cache at the end of the last written
+// block, nil if no block was written since startup.
+func (bc *BlockChain) RecentWasms() *RecentWasms {
+	return bc.recentWasms.Load()
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// reorgRetainedBlocks is the number of recent blocks whose changes are retained
+// to notify their removal, or their addition, when the canonical chain is
+// reorganised.
+const reorgRetainedBlocks = 128
+
+var reorgUnretainedMeter = metrics.NewRegisteredMeter("chain/reorg/unretained", nil)
+
+// blockChanges are the changes committed by a block, along with the changes of
+// the watched slots notified while the block was canonical.
+type blockChanges struct {
+	header  *types.Header
+	changes *state.StateChanges
+	events  []state.AccountEvent
+	slots   []*SlotChange
+}
+
+// reorgChanges retains the changes committed by the recent blocks written,
+// canonical or not, so that the changes notified for the blocks dropped from
+// the canonical chain can be notified as removed, and the changes of the blocks
+// joining it notified, without re-executing them.
+type reorgChanges struct {
+	lock   sync.Mutex
+	blocks map[common.Hash]*blockChanges
+	order  []common.Hash // Hashes of the retained blocks, oldest first
+}
+
+func newReorgChanges() *reorgChanges {
+	return &reorgChanges{
+		blocks: make(map[common.Hash]*blockChanges),
+	}
+}
+
+// retain records the changes committed by the given block, dropping the ones of
+// the oldest block retained if needed.
+func (r *reorgChanges) retain(header *types.Header, changes *state.StateChanges, events []state.AccountEvent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	hash := header.Hash()
+	if _, ok := r.blocks[hash]; ok {
+		return
+	}
+	if len(r.order) >= reorgRetainedBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[hash] = &blockChanges{header: header, changes: changes, events: events}
+	r.order = append(r.order, hash)
+}
+
+// notified records the changes of the watched slots notified for the given
+// canonical block.
+func (r *reorgChanges) notified(hash common.Hash, slots []*SlotChange) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if block := r.blocks[hash]; block != nil {
+		block.slots = slots
+	}
+}
+
+// get returns the changes retained for the given block, or nil if they are not.
+func (r *reorgChanges) get(hash common.Hash) *blockChanges {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.blocks[hash]
+}
+
+// notifyChanges posts the changes committed by the given block joining the
+// canonical chain.
+func (bc *BlockChain) notifyChanges(header *types.Header, changes *state.StateChanges, events []state.AccountEvent) {
+	if changes != nil {
+		bc.stateChangesFeed.Send(StateChangesEvent{Header: header, Changes: changes})
+		bc.reorgChanges.notified(header.Hash(), bc.notifySlotChanges(header, changes))
+	}
+	if len(events) > 0 {
+		bc.lifecycleFeed.Send(AccountLifecycleEvent{Header: header, Events: events})
+	}
+}
+
+// notifyAddedChanges posts the retained changes of the given block joining the
+// canonical chain through a reorg.
+func (bc *BlockChain) notifyAddedChanges(block *types.Block) {
+	retained := bc.reorgChanges.get(block.Hash())
+	if retained == nil {
+		reorgUnretainedMeter.Mark(1)
+		log.Debug("Changes of reorged block not retained", "number", block.Number(), "hash", block.Hash())
+		return
+	}
+	bc.notifyChanges(retained.header, retained.changes, retained.events)
+}
+
+// notifyRemovedChanges posts the removal of the changes notified for the given
+// block dropped from the canonical chain by a reorg.
+func (bc *BlockChain) notifyRemovedChanges(block *types.Block) {
+	retained := bc.reorgChanges.get(block.Hash())
+	if retained == nil {
+		reorgUnretainedMeter.Mark(1)
+		log.Debug("Changes of reorged block not retained", "number", block.Number(), "hash", block.Hash())
+		return
+	}
+	if retained.changes != nil {
+		bc.stateChangesFeed.Send(StateChangesEvent{Header: retained.header, Changes: retained.changes, Removed: true})
+	}
+	if len(retained.slots) > 0 {
+		bc.slotWatches.feed.Send(SlotChangesEvent{Header: retained.header, Changes: retained.slots, Removed: true})
+	}
+	if len(retained.events) > 0 {
+		bc.lifecycleFeed.Send(AccountLifecycleEvent{Header: retained.header, Events: retained.events, Removed: true})
+	}
+}
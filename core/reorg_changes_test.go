@@ -0,0 +1,152 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the state changes and the watched slot changes of the blocks
+// dropped by a reorg are notified as removed, from the old head down, and the
+// ones of the blocks joining the canonical chain notified.
+func TestReorgChanges(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xee")
+		engine   = ethash.NewFaker()
+		signer   = types.LatestSigner(params.TestChainConfig)
+		// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+		storer = []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender:   {Balance: big.NewInt(params.Ether)},
+			contract: {Code: storer},
+		},
+	}
+	store := func(b *BlockGen, value byte) {
+		data := append(common.Hash{value}.Bytes(), common.Hash{1}.Bytes()...)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), contract, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(tx)
+	}
+	_, original, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		store(b, byte(i+1))
+	})
+	_, fork, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		if i == 0 {
+			store(b, 1)
+		} else {
+			store(b, byte(i+10))
+			b.OffsetTime(-1) // Different blocks than the original chain
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.SlotWatches().Watch([]WatchedSlot{{contract, common.Hash{1}}}); err != nil {
+		t.Fatalf("failed to watch slot: %v", err)
+	}
+	if _, err := chain.InsertChain(original); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var (
+		changes = make(chan StateChangesEvent, 16)
+		slots   = make(chan SlotChangesEvent, 16)
+	)
+	changesSub := chain.SubscribeStateChangesEvent(changes)
+	defer changesSub.Unsubscribe()
+	slotsSub := chain.SlotWatches().SubscribeSlotChangesEvent(slots)
+	defer slotsSub.Unsubscribe()
+
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != fork[3].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, fork[3].Hash())
+	}
+	type notification struct {
+		hash    common.Hash
+		removed bool
+	}
+	want := []notification{
+		{original[2].Hash(), true},
+		{original[1].Hash(), true},
+		{fork[1].Hash(), false},
+		{fork[2].Hash(), false},
+		{fork[3].Hash(), false},
+	}
+	for i, n := range want {
+		select {
+		case ev := <-changes:
+			if ev.Header.Hash() != n.hash || ev.Removed != n.removed {
+				t.Fatalf("state changes %d mismatch: have %x (removed %v), want %x (removed %v)", i, ev.Header.Hash(), ev.Removed, n.hash, n.removed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("state changes %d not notified", i)
+		}
+		select {
+		case ev := <-slots:
+			if ev.Header.Hash() != n.hash || ev.Removed != n.removed {
+				t.Fatalf("slot changes %d mismatch: have %x (removed %v), want %x (removed %v)", i, ev.Header.Hash(), ev.Removed, n.hash, n.removed)
+			}
+			if len(ev.Changes) != 1 {
+				t.Fatalf("slot changes %d mismatch: have %d changes, want 1", i, len(ev.Changes))
+			}
+			if n.removed && ev.Changes[0].New != (common.Hash{byte(ev.Header.Number.Uint64())}) {
+				t.Fatalf("removed slot change %d mismatch: have %x", i, ev.Changes[0].New)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("slot changes %d not notified", i)
+		}
+	}
+	// Rewinding to an older block only removes the changes of the blocks above,
+	// its own changes having been notified already
+	if _, err := chain.SetCanonical(fork[1]); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	for _, block := range []*types.Block{fork[3], fork[2]} {
+		select {
+		case ev := <-changes:
+			if ev.Header.Hash() != block.Hash() || !ev.Removed {
+				t.Fatalf("state changes mismatch: have %x (removed %v), want %x removed", ev.Header.Hash(), ev.Removed, block.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("removal of block %x not notified", block.Hash())
+		}
+	}
+	select {
+	case ev := <-changes:
+		t.Fatalf("unexpected state changes of block %x (removed %v)", ev.Header.Hash(), ev.Removed)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
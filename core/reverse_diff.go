@@ -0,0 +1,45 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// recordReverseDiff retains the state diff committed by the given block, if
+// the retention is enabled.
+func (bc *BlockChain) recordReverseDiff(block *types.Block, statedb *state.StateDB) {
+	if bc.reverseDiffs == nil {
+		return
+	}
+	if changes := statedb.CommittedChanges(); changes != nil {
+		bc.reverseDiffs.Add(block.Hash(), changes.ReverseDiff(block.NumberU64(), block.Hash()))
+	}
+}
+
+// ReverseDiff returns the state diff committed by the block with the given
+// hash, or nil if it's not retained. Live diffs of canonical blocks are also
+// delivered by SubscribeStateChangesEvent.
+func (bc *BlockChain) ReverseDiff(hash common.Hash) *state.ReverseDiff {
+	if bc.reverseDiffs == nil {
+		return nil
+	}
+	diff, _ := bc.reverseDiffs.Get(hash)
+	return diff
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	slotWatchesGauge       = metrics.NewRegisteredGauge("chain/slotwatch/slots", nil)
+	slotWatchChangesMeter  = metrics.NewRegisteredMeter("chain/slotwatch/changes", nil)
+	slotWatchEvaluateTimer = metrics.NewRegisteredResettingTimer("chain/slotwatch/evaluate", nil)
+)
+
+// maxWatchedSlots is the number of distinct storage slots that can be watched
+// at once, across all the watches.
+const maxWatchedSlots = 65536
+
+var (
+	ErrNoWatchedSlots      = errors.New("no slots to watch")
+	ErrTooManyWatchedSlots = errors.New("too many watched slots")
+)
+
+// WatchedSlot is a storage slot of a contract.
+type WatchedSlot struct {
+	Address common.Address
+	Slot    common.Hash
+}
+
+// SlotChange is a change of a watched storage slot by a canonical block.
+type SlotChange struct {
+	Address common.Address
+	Slot    common.Hash
+	Old     common.Hash
+	New     common.Hash
+	Watches []uint64 // Identifiers of the watches including the slot
+}
+
+// SlotChangesEvent is posted when a canonical block has been imported changing
+// some of the watched storage slots. It's posted again with the same changes and
+// Removed set if the block is dropped from the canonical chain by a reorg.
+type SlotChangesEvent struct {
+	Header  *types.Header
+	Changes []*SlotChange
+	Removed bool
+}
+
+// watchedSlot is a storage slot watched by some of the watches.
+type watchedSlot struct {
+	slot    common.Hash
+	watches []uint64
+}
+
+// watchedAccount is the set of the watched slots of a contract, by slot hash.
+type watchedAccount struct {
+	addr  common.Address
+	slots map[common.Hash]*watchedSlot
+}
+
+// SlotWatches is the registry of the storage slots watched across blocks. The
+// watches are evaluated against the storage mutations committed by each
+// canonical block, with the account and slot hashes computed on registration,
+// so the cost of a block is bounded by the number of watched contracts rather
+// than by its storage writes or logs.
+type SlotWatches struct {
+	lock     sync.RWMutex
+	nextID   uint64
+	watches  map[uint64][]WatchedSlot        // Slots of the watches, by identifier
+	accounts map[common.Hash]*watchedAccount // Watched slots, by account hash
+	slots    int                             // Number of distinct watched slots
+	feed     event.Feed
+	scope    event.SubscriptionScope
+}
+
+func newSlotWatches() *SlotWatches {
+	return &SlotWatches{
+		watches:  make(map[uint64][]WatchedSlot),
+		accounts: make(map[common.Hash]*watchedAccount),
+	}
+}
+
+// SlotWatches returns the registry of the watched storage slots.
+func (bc *BlockChain) SlotWatches() *SlotWatches {
+	return bc.slotWatches
+}
+
+// Watch registers a watch of the given storage slots, returning its identifier.
+func (w *SlotWatches) Watch(slots []WatchedSlot) (uint64, error) {
+	if len(slots) == 0 {
+		return 0, ErrNoWatchedSlots
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	slots = slices.Clone(slots)
+	slices.SortFunc(slots, func(a, b WatchedSlot) int {
+		if c := a.Address.Cmp(b.Address); c != 0 {
+			return c
+		}
+		return a.Slot.Cmp(b.Slot)
+	})
+	slots = slices.Compact(slots)
+
+	var added int
+	for _, slot := range slots {
+		if account := w.accounts[crypto.Keccak256Hash(slot.Address[:])]; account == nil || account.slots[crypto.Keccak256Hash(slot.Slot[:])] == nil {
+			added++
+		}
+	}
+	if w.slots+added > maxWatchedSlots {
+		return 0, ErrTooManyWatchedSlots
+	}
+	w.nextID++
+	id := w.nextID
+	w.watches[id] = slots
+
+	for _, slot := range slots {
+		addrHash := crypto.Keccak256Hash(slot.Address[:])
+		account := w.accounts[addrHash]
+		if account == nil {
+			account = &watchedAccount{addr: slot.Address, slots: make(map[common.Hash]*watchedSlot)}
+			w.accounts[addrHash] = account
+		}
+		slotHash := crypto.Keccak256Hash(slot.Slot[:])
+		watched := account.slots[slotHash]
+		if watched == nil {
+			watched = &watchedSlot{slot: slot.Slot}
+			account.slots[slotHash] = watched
+		}
+		watched.watches = append(watched.watches, id)
+	}
+	w.slots += added
+	slotWatchesGauge.Update(int64(w.slots))
+	return id, nil
+}
+
+// Unwatch removes the given watch, returning whether it existed.
+func (w *SlotWatches) Unwatch(id uint64) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	slots, ok := w.watches[id]
+	if !ok {
+		return false
+	}
+	delete(w.watches, id)
+
+	for _, slot := range slots {
+		addrHash := crypto.Keccak256Hash(slot.Address[:])
+		account := w.accounts[addrHash]
+		slotHash := crypto.Keccak256Hash(slot.Slot[:])
+		watched := account.slots[slotHash]
+
+		watched.watches = slices.DeleteFunc(watched.watches, func(other uint64) bool { return other == id })
+		if len(watched.watches) > 0 {
+			continue
+		}
+		delete(account.slots, slotHash)
+		w.slots--
+		if len(account.slots) == 0 {
+			delete(w.accounts, addrHash)
+		}
+	}
+	slotWatchesGauge.Update(int64(w.slots))
+	return true
+}
+
+// Slots returns the storage slots of the given watch, or nil if it doesn't exist.
+func (w *SlotWatches) Slots(id uint64) []WatchedSlot {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return slices.Clone(w.watches[id])
+}
+
+// SubscribeSlotChangesEvent registers a subscription of SlotChangesEvent.
+func (w *SlotWatches) SubscribeSlotChangesEvent(ch chan<- SlotChangesEvent) event.Subscription {
+	return w.scope.Track(w.feed.Subscribe(ch))
+}
+
+// evaluate returns the changes of the watched slots among the given storage
+// mutations, sorted by contract and slot.
+func (w *SlotWatches) evaluate(changes *state.StateChanges) []*SlotChange {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	var result []*SlotChange
+	for addrHash, account := range w.accounts {
+		mutated := changes.Storages[addrHash]
+		if len(mutated) == 0 {
+			continue
+		}
+		for slotHash, watched := range account.slots {
+			if _, ok := mutated[slotHash]; !ok {
+				continue
+			}
+			value, _, err := changes.Storage(account.addr, watched.slot)
+			if err != nil {
+				log.Warn("Failed to decode watched slot", "address", account.addr, "slot", watched.slot, "err", err)
+				continue
+			}
+			origin, _, err := changes.StorageOrigin(account.addr, watched.slot)
+			if err != nil {
+				log.Warn("Failed to decode watched slot origin", "address", account.addr, "slot", watched.slot, "err", err)
+				continue
+			}
+			if origin == value {
+				continue
+			}
+			result = append(result, &SlotChange{
+				Address: account.addr,
+				Slot:    watched.slot,
+				Old:     origin,
+				New:     value,
+				Watches: slices.Clone(watched.watches),
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b *SlotChange) int {
+		if c := a.Address.Cmp(b.Address); c != 0 {
+			return c
+		}
+		return a.Slot.Cmp(b.Slot)
+	})
+	return result
+}
+
+// notifySlotChanges posts the changes of the watched slots by the given
+// canonical block, if any, returning them.
+func (bc *BlockChain) notifySlotChanges(header *types.Header, changes *state.StateChanges) []*SlotChange {
+	w := bc.slotWatches
+	w.lock.RLock()
+	empty := len(w.accounts) == 0
+	w.lock.RUnlock()
+	if empty {
+		return nil
+	}
+	start := time.Now()
+	result := w.evaluate(changes)
+	slotWatchEvaluateTimer.UpdateSince(start)
+
+	if len(result) > 0 {
+		slotWatchChangesMeter.Mark(int64(len(result)))
+		w.feed.Send(SlotChangesEvent{Header: header, Changes: result})
+	}
+	return result
+}
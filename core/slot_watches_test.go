@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the watched storage slots changed by the canonical blocks are
+// notified with their old and new values.
+func TestSlotWatches(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xee")
+		engine   = ethash.NewFaker()
+		signer   = types.LatestSigner(params.TestChainConfig)
+		// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+		storer = []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender:   {Balance: big.NewInt(params.Ether)},
+			contract: {Code: storer, Storage: map[common.Hash]common.Hash{{1}: {1}}},
+		},
+	}
+	store := func(b *BlockGen, slot common.Hash, value byte) {
+		data := append(common.Hash{value}.Bytes(), slot.Bytes()...)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), contract, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(tx)
+	}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *BlockGen) {
+		switch i {
+		case 0:
+			store(b, common.Hash{1}, 2)
+			store(b, common.Hash{3}, 3) // Not watched
+		case 1:
+			store(b, common.Hash{1}, 2) // Unchanged
+			store(b, common.Hash{2}, 4)
+		case 2:
+			store(b, common.Hash{2}, 0)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	watches := chain.SlotWatches()
+	first, err := watches.Watch([]WatchedSlot{{contract, common.Hash{1}}})
+	if err != nil {
+		t.Fatalf("failed to watch slots: %v", err)
+	}
+	second, err := watches.Watch([]WatchedSlot{{contract, common.Hash{2}}, {contract, common.Hash{1}}, {contract, common.Hash{2}}})
+	if err != nil {
+		t.Fatalf("failed to watch slots: %v", err)
+	}
+	if have := watches.Slots(second); len(have) != 2 {
+		t.Fatalf("watched slots mismatch: have %v, want 2 slots", have)
+	}
+	if _, err := watches.Watch(nil); err != ErrNoWatchedSlots {
+		t.Fatalf("empty watch error mismatch: have %v, want %v", err, ErrNoWatchedSlots)
+	}
+	events := make(chan SlotChangesEvent, 10)
+	sub := watches.SubscribeSlotChangesEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	check := func(number uint64, want []*SlotChange) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Header.Number.Uint64() != number {
+				t.Fatalf("block number mismatch: have %d, want %d", ev.Header.Number, number)
+			}
+			if !slices.EqualFunc(ev.Changes, want, func(a, b *SlotChange) bool {
+				return a.Address == b.Address && a.Slot == b.Slot && a.Old == b.Old && a.New == b.New && slices.Equal(a.Watches, b.Watches)
+			}) {
+				t.Fatalf("block %d changes mismatch: have %v, want %v", number, ev.Changes, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no changes notified for block %d", number)
+		}
+	}
+	check(1, []*SlotChange{{Address: contract, Slot: common.Hash{1}, Old: common.Hash{1}, New: common.Hash{2}, Watches: []uint64{first, second}}})
+	check(2, []*SlotChange{{Address: contract, Slot: common.Hash{2}, New: common.Hash{4}, Watches: []uint64{second}}})
+
+	// Once unwatched, the changes of the slot are no longer notified
+	if !watches.Unwatch(second) {
+		t.Fatal("failed to unwatch slots")
+	}
+	if watches.Unwatch(second) {
+		t.Fatal("unwatched slots twice")
+	}
+	if _, err := chain.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected changes notified for block %d: %v", ev.Header.Number, ev.Changes)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import "github.com/ethereum/go-ethereum/core/state/snapshot"
+
+// accessSource is where a state read was served from.
+type accessSource int
+
+const (
+	accessLive          accessSource = iota // Live state object or cached slot of the state
+	accessCache                             // Process-wide account and slot cache
+	accessSnapshotDiff                      // In-memory snapshot diff layer
+	accessSnapshotClean                     // Clean cache of the snapshot disk layer
+	accessSnapshotDisk                      // Persistent snapshot disk layer
+	accessTrie                              // State trie
+)
+
+// snapshotAccessSource converts a snapshot source into an access source.
+func snapshotAccessSource(source snapshot.Source) accessSource {
+	switch source {
+	case snapshot.SourceDiff:
+		return accessSnapshotDiff
+	case snapshot.SourceClean:
+		return accessSnapshotClean
+	default:
+		return accessSnapshotDisk
+	}
+}
+
+// AccessCounters counts state reads by the source serving them.
+type AccessCounters struct {
+	Live          uint64 `json:"live"`
+	Cache         uint64 `json:"cache"`
+	SnapshotDiff  uint64 `json:"snapshotDiff"`
+	SnapshotClean uint64 `json:"snapshotClean"`
+	SnapshotDisk  uint64 `json:"snapshotDisk"`
+	Trie          uint64 `json:"trie"`
+}
+
+func (c *AccessCounters) add(source accessSource) {
+	switch source {
+	case accessLive:
+		c.Live++
+	case accessCache:
+		c.Cache++
+	case accessSnapshotDiff:
+		c.SnapshotDiff++
+	case accessSnapshotClean:
+		c.SnapshotClean++
+	case accessSnapshotDisk:
+		c.SnapshotDisk++
+	case accessTrie:
+		c.Trie++
+	}
+}
+
+// AccessStats classifies the account and storage reads of a transaction by
+// the source serving them, to relate state access gas costs to actual I/O.
+type AccessStats struct {
+	Accounts AccessCounters `json:"accounts"`
+	Storage  AccessCounters `json:"storage"`
+}
+
+// EnableAccessStats starts classifying the state reads. The counters are reset
+// at every transaction boundary set by SetTxContext.
+func (s *StateDB) EnableAccessStats() {
+	s.accessStats = new(AccessStats)
+}
+
+// AccessStats returns the read counters of the current transaction, or nil if
+// the classification is not enabled.
+func (s *StateDB) AccessStats() *AccessStats {
+	s.readLocks.lockMisc()
+	defer s.readLocks.unlockMisc()
+
+	if s.accessStats == nil {
+		return nil
+	}
+	stats := *s.accessStats
+	return &stats
+}
+
+// trackAccountRead records the source of an account read, if enabled.
+func (s *StateDB) trackAccountRead(source accessSource) {
+	s.readLocks.lockMisc()
+	defer s.readLocks.unlockMisc()
+
+	if s.accessStats != nil {
+		s.accessStats.Accounts.add(source)
+	}
+}
+
+// trackStorageRead records the source of a storage read, if enabled.
+func (s *StateDB) trackStorageRead(source accessSource) {
+	s.readLocks.lockMisc()
+	defer s.readLocks.unlockMisc()
+
+	if s.accessStats != nil {
+		s.accessStats.Storage.add(source)
+	}
+}
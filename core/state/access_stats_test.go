@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+func TestAccessStats(t *testing.T) {
+	PurgeStorageCache()
+	defer PurgeStorageCache()
+
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		tdb      = triedb.NewDatabase(disk, nil)
+		db       = NewDatabaseWithNodeDB(disk, tdb)
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, types.EmptyRootHash)
+		addr     = common.HexToAddress("0x1")
+		slot     = common.HexToHash("0x1")
+	)
+	state, _ := New(types.EmptyRootHash, db, snaps)
+	state.SetBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetState(addr, slot, common.HexToHash("0x2"))
+	root, _ := state.Commit(0, true)
+
+	// Disabled classification doesn't report anything
+	state, _ = New(root, db, snaps)
+	if stats := state.AccessStats(); stats != nil {
+		t.Fatalf("unexpected stats without classification: %+v", stats)
+	}
+	// The first reads are served by the snapshot diff layer, repeated ones by
+	// the live objects. Storage reads resolve the account as well.
+	state.EnableAccessStats()
+	state.SetTxContext(common.Hash{1}, 0)
+	state.GetBalance(addr)
+	state.GetState(addr, slot)
+	state.GetBalance(addr)
+	state.GetState(addr, slot)
+
+	want := AccessStats{
+		Accounts: AccessCounters{Live: 3, SnapshotDiff: 1},
+		Storage:  AccessCounters{Live: 1, SnapshotDiff: 1},
+	}
+	if stats := state.AccessStats(); *stats != want {
+		t.Fatalf("unexpected stats: have %+v, want %+v", *stats, want)
+	}
+	// The counters are reset at the transaction boundary
+	state.SetTxContext(common.Hash{2}, 1)
+	state.GetBalance(addr)
+	if stats := state.AccessStats(); *stats != (AccessStats{Accounts: AccessCounters{Live: 1}}) {
+		t.Fatalf("unexpected stats of the second transaction: %+v", *stats)
+	}
+	// Other states of the same root are served by the process-wide cache
+	state, _ = New(root, db, nil)
+	state.EnableAccessStats()
+	state.GetBalance(addr)
+	state.GetState(addr, slot)
+	want = AccessStats{
+		Accounts: AccessCounters{Live: 1, Cache: 1},
+		Storage:  AccessCounters{Cache: 1},
+	}
+	if stats := state.AccessStats(); *stats != want {
+		t.Fatalf("unexpected stats of a cached state: have %+v, want %+v", *stats, want)
+	}
+}
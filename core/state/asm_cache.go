@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// activatedAsmCacheKey identifies the asm of an activated module for a target.
+type activatedAsmCacheKey struct {
+	moduleHash common.Hash
+	target     ethdb.WasmTarget
+}
+
+// asmCache is a size-bounded cache of the asm of activated Stylus modules, so
+// that hot programs aren't read back from the wasm store on every call.
+type asmCache struct {
+	cache   *lru.SizeConstrainedCache[activatedAsmCacheKey, []byte]
+	account *memoryAccount // Memory budget accounting, nil if none
+}
+
+func newAsmCache(size int) *asmCache {
+	return &asmCache{
+		cache: lru.NewSizeConstrainedCache[activatedAsmCacheKey, []byte](uint64(size)),
+	}
+}
+
+// get returns the cached asm of the module for the target.
+func (c *asmCache) get(target ethdb.WasmTarget, moduleHash common.Hash) ([]byte, bool) {
+	asm, _ := c.cache.Get(activatedAsmCacheKey{moduleHash, target})
+	if len(asm) == 0 {
+		asmCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	asmCacheHitMeter.Mark(1)
+	return asm, true
+}
+
+// contains reports whether the asm of the module for the target is cached,
+// without affecting the metrics.
+func (c *asmCache) contains(target ethdb.WasmTarget, moduleHash common.Hash) bool {
+	asm, _ := c.cache.Get(activatedAsmCacheKey{moduleHash, target})
+	return len(asm) > 0
+}
+
+// add caches the asm of the module for the target, evicting the least recently
+// used entries if the budget is exceeded.
+func (c *asmCache) add(target ethdb.WasmTarget, moduleHash common.Hash, asm []byte) {
+	if c.cache.Add(activatedAsmCacheKey{moduleHash, target}, asm) {
+		asmCacheEvictMeter.Mark(1)
+	}
+	asmCacheSizeGauge.Update(int64(c.cache.Size()))
+	if c.account != nil {
+		c.account.track(c.cache.Size())
+	}
+}
+
+// attach accounts the cache against the given memory budget.
+func (c *asmCache) attach(budget *MemoryBudget) {
+	c.account = budget.register("asm", asmCachePriority, func(bytes uint64) uint64 {
+		c.cache.Shrink(bytes)
+		asmCacheSizeGauge.Update(int64(c.cache.Size()))
+		return c.cache.Size()
+	})
+	c.account.track(c.cache.Size())
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+func TestActivatedAsmCacheEviction(t *testing.T) {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = NewDatabaseWithNodeDBAndAsmCache(diskdb, triedb.NewDatabase(diskdb, nil), 10).(*cachingDB)
+	)
+	rawdb.WriteActivatedAsm(diskdb, rawdb.TargetWavm, common.Hash{1}, make([]byte, 6))
+	rawdb.WriteActivatedAsm(diskdb, rawdb.TargetWavm, common.Hash{2}, make([]byte, 6))
+
+	for _, hash := range []common.Hash{{1}, {2}} {
+		if _, err := db.ActivatedAsm(rawdb.TargetWavm, hash); err != nil {
+			t.Fatalf("failed to read asm of %x: %v", hash, err)
+		}
+	}
+	// The second module doesn't fit along with the first one
+	if db.activatedAsmCache.contains(rawdb.TargetWavm, common.Hash{1}) {
+		t.Fatal("least recently used asm not evicted")
+	}
+	if !db.activatedAsmCache.contains(rawdb.TargetWavm, common.Hash{2}) {
+		t.Fatal("most recently used asm not cached")
+	}
+	if size := db.activatedAsmCache.cache.Size(); size != 6 {
+		t.Fatalf("unexpected cache size: have %d, want 6", size)
+	}
+	if _, err := db.ActivatedAsm(rawdb.TargetWavm, common.Hash{3}); err == nil {
+		t.Fatal("missing asm found")
+	}
+}
+
+func TestActivatedAsmPrefetch(t *testing.T) {
+	var (
+		wasmdb   = rawdb.NewMemoryDatabase()
+		diskdb   = rawdb.WrapDatabaseWithWasm(rawdb.NewMemoryDatabase(), wasmdb, 0, []ethdb.WasmTarget{rawdb.TargetWavm})
+		db       = NewDatabase(diskdb)
+		program  = common.HexToAddress("0x1")
+		listed   = common.HexToAddress("0x2")
+		contract = common.HexToAddress("0x3")
+		asm      = map[common.Hash][]byte{{1}: {1}, {2}: {2}}
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetCode(program, append(NewStylusPrefix(0), 1))
+	state.SetCode(listed, append(NewStylusPrefix(0), 2))
+	state.SetCode(contract, []byte{0x60, 0x00})
+	for hash, blob := range asm {
+		rawdb.WriteActivatedAsm(wasmdb, rawdb.TargetWavm, hash, blob)
+	}
+	defer func() { GetStylusModuleHash = nil }()
+	GetStylusModuleHash = func(statedb *StateDB, codeHash common.Hash) (common.Hash, bool) {
+		for _, addr := range []common.Address{program, listed} {
+			if statedb.GetCodeHash(addr) == codeHash {
+				code := statedb.GetCode(addr)
+				return common.Hash{code[len(code)-1]}, true
+			}
+		}
+		return common.Hash{}, false
+	}
+	state.Prepare(params.Rules{IsBerlin: true}, common.Address{}, common.Address{}, &program, nil, types.AccessList{
+		{Address: listed},
+		{Address: contract},
+	})
+	cache := db.(*cachingDB).activatedAsmCache
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if cache.contains(rawdb.TargetWavm, common.Hash{1}) && cache.contains(rawdb.TargetWavm, common.Hash{2}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("asm not prefetched")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for hash, blob := range asm {
+		if have, ok := cache.get(rawdb.TargetWavm, hash); !ok || !bytes.Equal(have, blob) {
+			t.Fatalf("unexpected prefetched asm of %x: %x", hash, have)
+		}
+	}
+}
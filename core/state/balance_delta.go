@@ -0,0 +1,129 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/uint256"
+)
+
+// BalanceDeltaCheckMode selects what happens when the unexpected balance delta
+// diverges from the balance changes of the journal.
+type BalanceDeltaCheckMode uint32
+
+const (
+	BalanceDeltaCheckOff   BalanceDeltaCheckMode = iota // No checking
+	BalanceDeltaCheckLog                                // Log the divergences at error level
+	BalanceDeltaCheckPanic                              // Panic on the first divergence
+)
+
+// balanceDeltaCheck is the process-wide mode of the balance delta check.
+var balanceDeltaCheck atomic.Uint32
+
+// SetBalanceDeltaCheck sets the mode of the balance delta check, which is meant
+// to catch fee-accounting bugs in the ArbOS integrations: at the end of every
+// transaction, the unexpected balance delta is checked against the one derived
+// from the balance changes in the journal, and the burns expected.
+func SetBalanceDeltaCheck(mode BalanceDeltaCheckMode) {
+	balanceDeltaCheck.Store(uint32(mode))
+}
+
+// ParseBalanceDeltaCheckMode parses a mode of the balance delta check: "off" (or
+// empty), "log" or "panic".
+func ParseBalanceDeltaCheckMode(mode string) (BalanceDeltaCheckMode, error) {
+	switch mode {
+	case "", "off":
+		return BalanceDeltaCheckOff, nil
+	case "log":
+		return BalanceDeltaCheckLog, nil
+	case "panic":
+		return BalanceDeltaCheckPanic, nil
+	}
+	return BalanceDeltaCheckOff, fmt.Errorf("invalid balance delta check mode %q", mode)
+}
+
+// balanceDeltaChange is a change of the unexpected balance delta that isn't
+// backed by a balance change, like an expected burn. The delta itself is
+// restored by the revisions, the entry only feeds the balance delta check.
+type balanceDeltaChange struct {
+	amount *big.Int
+}
+
+func (ch balanceDeltaChange) revert(s *StateDB) {}
+
+func (ch balanceDeltaChange) dirtied() *common.Address {
+	return nil
+}
+
+func (ch balanceDeltaChange) copy() journalEntry {
+	return balanceDeltaChange{
+		amount: new(big.Int).Set(ch.amount),
+	}
+}
+
+// markBalanceDelta records the unexpected balance delta the journal starts from.
+func (s *StateDB) markBalanceDelta() {
+	s.arbExtraData.balanceDeltaMark = new(big.Int).Set(s.arbExtraData.unexpectedBalanceDelta)
+}
+
+// checkBalanceDelta checks, if enabled, that the unexpected balance delta moved
+// by the balance changes and the expected burns of the journal since it was
+// marked. It must be called before the destructed accounts are deleted.
+func (s *StateDB) checkBalanceDelta() {
+	mode := BalanceDeltaCheckMode(balanceDeltaCheck.Load())
+	if mode == BalanceDeltaCheckOff {
+		return
+	}
+	var (
+		expected = new(big.Int).Set(s.arbExtraData.balanceDeltaMark)
+		origins  = make(map[common.Address]*uint256.Int)
+	)
+	origin := func(addr common.Address, balance *uint256.Int) {
+		if _, ok := origins[addr]; !ok {
+			origins[addr] = balance
+		}
+	}
+	for _, entry := range s.journal.entries {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			origin(*ch.account, new(uint256.Int))
+		case balanceChange:
+			origin(*ch.account, ch.prev)
+		case selfDestructChange:
+			origin(*ch.account, ch.prevbalance)
+		case balanceDeltaChange:
+			expected.Add(expected, ch.amount)
+		}
+	}
+	for addr, prev := range origins {
+		if obj := s.stateObjects[addr]; obj != nil {
+			expected.Add(expected, obj.Balance().ToBig())
+		}
+		expected.Sub(expected, prev.ToBig())
+	}
+	if delta := s.arbExtraData.unexpectedBalanceDelta; expected.Cmp(delta) != 0 {
+		if mode == BalanceDeltaCheckPanic {
+			panic(fmt.Sprintf("unexpected balance delta %v diverges from the journal's %v (tx %v)", delta, expected, s.thash))
+		}
+		log.Error("Unexpected balance delta diverges from the journal", "delta", delta, "journal", expected, "tx", s.thash)
+	}
+}
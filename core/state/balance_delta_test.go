@@ -0,0 +1,68 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestBalanceDeltaCheck(t *testing.T) {
+	SetBalanceDeltaCheck(BalanceDeltaCheckPanic)
+	defer SetBalanceDeltaCheck(BalanceDeltaCheckOff)
+
+	var (
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+	)
+	statedb, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.AddBalance(alice, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	statedb.SetBalance(bob, uint256.NewInt(50), tracing.BalanceChangeUnspecified)
+	statedb.Finalise(true)
+
+	// Balance changes, expected burns and self-destructs, partly reverted
+	statedb.SubBalance(alice, uint256.NewInt(30), tracing.BalanceChangeUnspecified)
+	statedb.ExpectBalanceBurn(big.NewInt(30))
+	snap := statedb.Snapshot()
+	statedb.AddBalance(bob, uint256.NewInt(20), tracing.BalanceChangeUnspecified)
+	statedb.ExpectBalanceBurn(big.NewInt(5))
+	statedb.RevertToSnapshot(snap)
+	statedb.SelfDestruct(bob)
+	statedb.Finalise(true)
+
+	if _, err := statedb.Commit(0, true); err != nil {
+		t.Fatal(err)
+	}
+	if delta := statedb.GetUnexpectedBalanceDelta(); delta.Sign() != 0 {
+		t.Fatalf("delta not reset by the commit: %v", delta)
+	}
+	// A balance change bypassing the accounting is caught
+	statedb.AddBalance(alice, uint256.NewInt(10), tracing.BalanceChangeUnspecified)
+	statedb.getStateObject(alice).SetBalance(uint256.NewInt(1000), tracing.BalanceChangeUnspecified)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("divergence not caught")
+		}
+	}()
+	statedb.Finalise(true)
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	commitFlushTimer   = metrics.NewRegisteredResettingTimer("state/commit/flush", nil)
+	commitStallTimer   = metrics.NewRegisteredResettingTimer("state/commit/stall", nil)
+	commitPendingGauge = metrics.NewRegisteredGauge("state/commit/pending", nil)
+)
+
+var (
+	errSchedulerClosed  = errors.New("commit scheduler closed")
+	errNoCommitPipeline = errors.New("commit pipeline depth must be positive")
+)
+
+// commitFlush is a disk flush scheduled on a CommitScheduler.
+type commitFlush struct {
+	root  common.Hash // Root of the state flushed, for logging
+	flush func() error
+}
+
+// CommitScheduler runs the disk flush stage of the state commits in the
+// background, so that the execution of the next block can begin while the
+// previous one is being flushed.
+//
+// Flushes run one at a time, in the order they were scheduled. At most depth
+// flushes may be pending: scheduling more blocks until the oldest completes,
+// applying backpressure on the block production. Once a flush fails, the
+// following ones are dropped and the failure is reported to all subsequent
+// calls.
+type CommitScheduler struct {
+	flushes chan *commitFlush
+	done    chan struct{} // Closed when the flush loop terminates
+
+	lock    sync.Mutex
+	cond    *sync.Cond // Signalled when a flush completes
+	pending int        // Flushes scheduled but not completed
+	err     error      // First flush failure
+	closed  bool
+}
+
+// NewCommitScheduler creates a commit scheduler allowing the given number of
+// pending flushes, and starts its flush loop.
+func NewCommitScheduler(depth int) (*CommitScheduler, error) {
+	if depth <= 0 {
+		return nil, errNoCommitPipeline
+	}
+	s := &CommitScheduler{
+		flushes: make(chan *commitFlush, depth-1),
+		done:    make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.lock)
+	go s.loop()
+	return s, nil
+}
+
+// Schedule queues the disk flush of the state with the given root, blocking
+// while the pipeline is full. The error of any earlier failed flush is
+// returned, in which case the flush is not scheduled.
+func (s *CommitScheduler) Schedule(root common.Hash, flush func() error) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return errSchedulerClosed
+	}
+	if s.err != nil {
+		s.lock.Unlock()
+		return s.err
+	}
+	s.pending++
+	s.lock.Unlock()
+
+	commitPendingGauge.Inc(1)
+	start := time.Now()
+	s.flushes <- &commitFlush{root: root, flush: flush}
+	commitStallTimer.UpdateSince(start)
+	return nil
+}
+
+// Wait blocks until all the scheduled flushes completed, returning the error
+// of the first failed one, if any.
+func (s *CommitScheduler) Wait() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for s.pending > 0 {
+		s.cond.Wait()
+	}
+	return s.err
+}
+
+// Close waits for the scheduled flushes to complete and terminates the flush
+// loop. Flushes can't be scheduled afterwards.
+func (s *CommitScheduler) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return s.Wait()
+	}
+	s.closed = true
+	s.lock.Unlock()
+
+	err := s.Wait()
+	close(s.flushes)
+	<-s.done
+	return err
+}
+
+// loop runs the scheduled flushes in order.
+func (s *CommitScheduler) loop() {
+	defer close(s.done)
+
+	for task := range s.flushes {
+		s.lock.Lock()
+		failed := s.err != nil
+		s.lock.Unlock()
+
+		var err error
+		if !failed {
+			start := time.Now()
+			if err = task.flush(); err != nil {
+				log.Error("Failed to flush state", "root", task.root, "err", err)
+			}
+			commitFlushTimer.UpdateSince(start)
+		}
+		commitPendingGauge.Dec(1)
+
+		s.lock.Lock()
+		if err != nil {
+			s.err = fmt.Errorf("state %x flush failed: %w", task.root, err)
+		}
+		s.pending--
+		s.cond.Broadcast()
+		s.lock.Unlock()
+	}
+}
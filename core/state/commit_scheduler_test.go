@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCommitScheduler(t *testing.T) {
+	if _, err := NewCommitScheduler(0); err == nil {
+		t.Fatal("zero depth accepted")
+	}
+	s, err := NewCommitScheduler(2)
+	if err != nil {
+		t.Fatalf("failed to create scheduler: %v", err)
+	}
+	var (
+		release = make(chan struct{})
+		order   []int
+	)
+	// Two flushes fit in the pipeline while the first one is blocked
+	for i := 0; i < 2; i++ {
+		i := i
+		if err := s.Schedule(common.Hash{byte(i)}, func() error {
+			<-release
+			order = append(order, i)
+			return nil
+		}); err != nil {
+			t.Fatalf("failed to schedule flush %d: %v", i, err)
+		}
+	}
+	// The third one must be held back until the first completes
+	scheduled := make(chan error)
+	go func() {
+		scheduled <- s.Schedule(common.Hash{2}, func() error {
+			order = append(order, 2)
+			return nil
+		})
+	}()
+	select {
+	case <-scheduled:
+		t.Fatal("flush scheduled over a full pipeline")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-scheduled; err != nil {
+		t.Fatalf("failed to schedule flush 2: %v", err)
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatalf("flushes failed: %v", err)
+	}
+	if len(order) != 3 || order[0] != 0 || order[1] != 1 || order[2] != 2 {
+		t.Fatalf("flushes run out of order: %v", order)
+	}
+	// A failure drops the following flushes and is reported from then on
+	failure := errors.New("disk full")
+	s.Schedule(common.Hash{3}, func() error { return failure })
+	s.Schedule(common.Hash{4}, func() error {
+		t.Error("flush run after a failure")
+		return nil
+	})
+	if err := s.Wait(); !errors.Is(err, failure) {
+		t.Fatalf("failure not reported: %v", err)
+	}
+	if err := s.Schedule(common.Hash{5}, func() error { return nil }); !errors.Is(err, failure) {
+		t.Fatalf("flush scheduled after a failure: %v", err)
+	}
+	if err := s.Close(); !errors.Is(err, failure) {
+		t.Fatalf("failure not reported on close: %v", err)
+	}
+	if err := s.Schedule(common.Hash{6}, func() error { return nil }); !errors.Is(err, errSchedulerClosed) {
+		t.Fatalf("flush scheduled after close: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// readLockShards is the number of address locks guarding the lazily loaded
+// accounts, storage slots and code of a state in concurrent read mode.
+const readLockShards = 64
+
+// readLocks is the set of locks making the read paths of a StateDB safe to
+// use from multiple goroutines. Reads lazily populate the live object set and
+// the per-object caches, so even a read-only state mutates itself; the locks
+// are sharded by address so that readers of different accounts rarely contend.
+//
+// All methods are no-ops on a nil receiver, which is the default for states
+// that are used from a single goroutine.
+type readLocks struct {
+	shards  [readLockShards]sync.Mutex // Guard the loading of accounts and their storage and code
+	objects sync.RWMutex               // Guards the live object set
+	trie    sync.Mutex                 // Guards the account trie, which resolves nodes in place
+	misc    sync.Mutex                 // Guards the read tracking, metering and error bookkeeping
+}
+
+func (l *readLocks) shard(addr common.Address) *sync.Mutex {
+	return &l.shards[int(addr[common.AddressLength-1])%readLockShards]
+}
+
+func (l *readLocks) lockAddress(addr common.Address) {
+	if l != nil {
+		l.shard(addr).Lock()
+	}
+}
+
+func (l *readLocks) unlockAddress(addr common.Address) {
+	if l != nil {
+		l.shard(addr).Unlock()
+	}
+}
+
+func (l *readLocks) lockTrie() {
+	if l != nil {
+		l.trie.Lock()
+	}
+}
+
+func (l *readLocks) unlockTrie() {
+	if l != nil {
+		l.trie.Unlock()
+	}
+}
+
+func (l *readLocks) lockMisc() {
+	if l != nil {
+		l.misc.Lock()
+	}
+}
+
+func (l *readLocks) unlockMisc() {
+	if l != nil {
+		l.misc.Unlock()
+	}
+}
+
+// EnableConcurrentReads makes the read-only accessors of the state (balance,
+// nonce, code and storage getters) safe to call from multiple goroutines at
+// once. Mutations must still not run concurrently with reads or with each
+// other; the mode is meant for states that are only read from, such as the
+// pending state served over RPC or the pre-state of parallel execution
+// experiments. Copies of the state do not inherit the mode.
+func (s *StateDB) EnableConcurrentReads() {
+	if s.readLocks == nil {
+		s.readLocks = new(readLocks)
+	}
+}
+
+// liveObject returns the live state object of the given address, if any.
+func (s *StateDB) liveObject(addr common.Address) *stateObject {
+	if s.readLocks == nil {
+		return s.stateObjects[addr]
+	}
+	s.readLocks.objects.RLock()
+	defer s.readLocks.objects.RUnlock()
+	return s.stateObjects[addr]
+}
+
+// readHasher returns the hasher to use on the read paths. The shared hasher
+// of the state can't be used by concurrent readers, which get a fresh one.
+func (s *StateDB) readHasher() crypto.KeccakState {
+	if s.readLocks != nil {
+		return crypto.NewKeccakState()
+	}
+	return s.hasher
+}
+
+// meterRead adds the time elapsed since start to the given read meter.
+func (s *StateDB) meterRead(meter *time.Duration, start time.Time) {
+	s.readLocks.lockMisc()
+	*meter += time.Since(start)
+	s.readLocks.unlockMisc()
+}
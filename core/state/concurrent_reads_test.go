@@ -0,0 +1,110 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+func TestConcurrentReads(t *testing.T) {
+	t.Run("trie", func(t *testing.T) { testConcurrentReads(t, false) })
+	t.Run("snapshot", func(t *testing.T) { testConcurrentReads(t, true) })
+}
+
+func testConcurrentReads(t *testing.T, withSnapshot bool) {
+	PurgeStorageCache()
+	defer PurgeStorageCache()
+
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		tdb   = triedb.NewDatabase(disk, nil)
+		db    = NewDatabaseWithNodeDB(disk, tdb)
+		snaps *snapshot.Tree
+		addrs []common.Address
+	)
+	if withSnapshot {
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, types.EmptyRootHash)
+	}
+	state, _ := New(types.EmptyRootHash, db, snaps)
+	for i := 0; i < 32; i++ {
+		addr := common.BytesToAddress([]byte{byte(i + 1)})
+		state.SetBalance(addr, uint256.NewInt(uint64(i+1)), tracing.BalanceChangeUnspecified)
+		state.SetCode(addr, []byte{byte(i), 0xaa, 0xbb})
+		for j := 0; j < 8; j++ {
+			state.SetState(addr, common.BytesToHash([]byte{byte(j)}), common.BytesToHash([]byte{byte(i), byte(j)}))
+		}
+		addrs = append(addrs, addr)
+	}
+	root, _ := state.Commit(0, true)
+	if !withSnapshot {
+		tdb.Commit(root, false)
+	}
+	state, _ = New(root, db, snaps)
+	state.EnableConcurrentReads()
+	state.EnableAccessStats()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, addr := range addrs {
+				if balance := state.GetBalance(addr); balance.Uint64() != uint64(i+1) {
+					t.Errorf("account %d: balance mismatch: have %d, want %d", i, balance, i+1)
+				}
+				if code := state.GetCode(addr); !bytes.Equal(code, []byte{byte(i), 0xaa, 0xbb}) {
+					t.Errorf("account %d: code mismatch: have %x", i, code)
+				}
+				for j := 0; j < 8; j++ {
+					want := common.BytesToHash([]byte{byte(i), byte(j)})
+					if have := state.GetState(addr, common.BytesToHash([]byte{byte(j)})); have != want {
+						t.Errorf("account %d slot %d: value mismatch: have %x, want %x", i, j, have, want)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := state.Error(); err != nil {
+		t.Fatalf("unexpected state error: %v", err)
+	}
+	// Every account and slot is loaded exactly once, all other reads are served
+	// by the live objects.
+	stats := state.AccessStats()
+	if loaded := loadedReads(stats.Accounts); loaded != uint64(len(addrs)) {
+		t.Errorf("account loads mismatch: have %d, want %d", loaded, len(addrs))
+	}
+	if loaded := loadedReads(stats.Storage); loaded != uint64(len(addrs)*8) {
+		t.Errorf("slot loads mismatch: have %d, want %d", loaded, len(addrs)*8)
+	}
+}
+
+// loadedReads returns the number of reads not served by the live objects.
+func loadedReads(c AccessCounters) uint64 {
+	return c.Cache + c.SnapshotDiff + c.SnapshotClean + c.SnapshotDisk + c.Trie
+}
@@ -34,8 +34,8 @@ import (
 )
 
 const (
-	// Arbitrum: Cache size granted for caching clean compiled wasm code.
-	activatedWasmCacheSize = 64 * 1024 * 1024
+	// Arbitrum: Default cache size granted for caching clean compiled wasm code.
+	DefaultActivatedAsmCacheSize = 64 * 1024 * 1024
 
 	// Number of codehash->size associations to keep.
 	codeSizeCacheSize = 100000
@@ -54,6 +54,7 @@ const (
 type Database interface {
 	// Arbitrum: Read activated Stylus contracts
 	ActivatedAsm(target ethdb.WasmTarget, moduleHash common.Hash) (asm []byte, err error)
+	PrefetchActivatedAsm(targets []ethdb.WasmTarget, moduleHashes []common.Hash)
 	WasmStore() ethdb.KeyValueStore
 	WasmCacheTag() uint32
 	WasmTargets() []ethdb.WasmTarget
@@ -73,6 +74,10 @@ type Database interface {
 	// ContractCodeSize retrieves a particular contracts code's size.
 	ContractCodeSize(addr common.Address, codeHash common.Hash) (int, error)
 
+	// ContractCodePrefix retrieves up to n leading bytes of a particular
+	// contract's code.
+	ContractCodePrefix(addr common.Address, codeHash common.Hash, n int) ([]byte, error)
+
 	// DiskDB returns the underlying key-value disk database.
 	DiskDB() ethdb.KeyValueStore
 
@@ -164,7 +169,7 @@ func NewDatabaseWithConfig(db ethdb.Database, config *triedb.Config) Database {
 	wasmdb, wasmTag := db.WasmDataBase()
 	cdb := &cachingDB{
 		// Arbitrum only
-		activatedAsmCache:     lru.NewSizeConstrainedCache[activatedAsmCacheKey, []byte](activatedWasmCacheSize),
+		activatedAsmCache:     newAsmCache(DefaultActivatedAsmCacheSize),
 		wasmTag:               wasmTag,
 		wasmDatabaseRetriever: db,
 
@@ -179,10 +184,24 @@ func NewDatabaseWithConfig(db ethdb.Database, config *triedb.Config) Database {
 
 // NewDatabaseWithNodeDB creates a state database with an already initialized node database.
 func NewDatabaseWithNodeDB(db ethdb.Database, triedb *triedb.Database) Database {
+	return NewDatabaseWithNodeDBAndAsmCache(db, triedb, DefaultActivatedAsmCacheSize)
+}
+
+// NewDatabaseWithNodeDBAndAsmCache creates a state database with an already
+// initialized node database, caching up to the given size of activated asm.
+func NewDatabaseWithNodeDBAndAsmCache(db ethdb.Database, triedb *triedb.Database, asmCacheSize int) Database {
+	return NewDatabaseWithNodeDBAndBudget(db, triedb, asmCacheSize, nil)
+}
+
+// NewDatabaseWithNodeDBAndBudget creates a state database with an already
+// initialized node database, caching up to the given size of activated asm.
+// If a memory budget is given, the code and asm caches of the database, along
+// with the process-wide account and storage cache, are accounted against it.
+func NewDatabaseWithNodeDBAndBudget(db ethdb.Database, triedb *triedb.Database, asmCacheSize int, budget *MemoryBudget) Database {
 	wasmdb, wasmTag := db.WasmDataBase()
 	cdb := &cachingDB{
 		// Arbitrum only
-		activatedAsmCache:     lru.NewSizeConstrainedCache[activatedAsmCacheKey, []byte](activatedWasmCacheSize),
+		activatedAsmCache:     newAsmCache(asmCacheSize),
 		wasmTag:               wasmTag,
 		wasmDatabaseRetriever: db,
 
@@ -192,17 +211,20 @@ func NewDatabaseWithNodeDB(db ethdb.Database, triedb *triedb.Database) Database
 		codeCache:     lru.NewSizeConstrainedCache[common.Hash, []byte](codeCacheSize),
 		triedb:        triedb,
 	}
+	if budget != nil {
+		cdb.codeCacheAccount = budget.register("code", codeCachePriority, func(bytes uint64) uint64 {
+			cdb.codeCache.Shrink(bytes)
+			return cdb.codeCache.Size()
+		})
+		cdb.activatedAsmCache.attach(budget)
+		globalStorageCache.attach(budget)
+	}
 	return cdb
 }
 
-type activatedAsmCacheKey struct {
-	moduleHash common.Hash
-	target     ethdb.WasmTarget
-}
-
 type cachingDB struct {
 	// Arbitrum
-	activatedAsmCache     *lru.SizeConstrainedCache[activatedAsmCacheKey, []byte]
+	activatedAsmCache     *asmCache
 	wasmTag               uint32
 	wasmDatabaseRetriever ethdb.WasmDataBaseRetriever
 
@@ -211,6 +233,8 @@ type cachingDB struct {
 	codeSizeCache *lru.Cache[common.Hash, int]
 	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
 	triedb        *triedb.Database
+
+	codeCacheAccount *memoryAccount // Arbitrum: memory budget accounting of the code cache, nil if none
 }
 
 func (db *cachingDB) WasmStore() ethdb.KeyValueStore {
@@ -272,13 +296,21 @@ func (db *cachingDB) ContractCode(address common.Address, codeHash common.Hash)
 	}
 	code = rawdb.ReadCode(db.disk, codeHash)
 	if len(code) > 0 {
-		db.codeCache.Add(codeHash, code)
-		db.codeSizeCache.Add(codeHash, len(code))
+		db.cacheCode(codeHash, code)
 		return code, nil
 	}
 	return nil, errors.New("not found")
 }
 
+// cacheCode adds the given code to the code and code size caches.
+func (db *cachingDB) cacheCode(codeHash common.Hash, code []byte) {
+	db.codeCache.Add(codeHash, code)
+	db.codeSizeCache.Add(codeHash, len(code))
+	if db.codeCacheAccount != nil {
+		db.codeCacheAccount.track(db.codeCache.Size())
+	}
+}
+
 // ContractCodeWithPrefix retrieves a particular contract's code. If the
 // code can't be found in the cache, then check the existence with **new**
 // db scheme.
@@ -289,8 +321,7 @@ func (db *cachingDB) ContractCodeWithPrefix(address common.Address, codeHash com
 	}
 	code = rawdb.ReadCodeWithPrefix(db.disk, codeHash)
 	if len(code) > 0 {
-		db.codeCache.Add(codeHash, code)
-		db.codeSizeCache.Add(codeHash, len(code))
+		db.cacheCode(codeHash, code)
 		return code, nil
 	}
 	return nil, errors.New("not found")
@@ -301,10 +332,34 @@ func (db *cachingDB) ContractCodeSize(addr common.Address, codeHash common.Hash)
 	if cached, ok := db.codeSizeCache.Get(codeHash); ok {
 		return cached, nil
 	}
+	if code, _ := db.codeCache.Get(codeHash); len(code) > 0 {
+		return len(code), nil
+	}
+	if size, ok := rawdb.ReadCodeSize(db.disk, codeHash); ok {
+		db.codeSizeCache.Add(codeHash, size)
+		return size, nil
+	}
 	code, err := db.ContractCode(addr, codeHash)
 	return len(code), err
 }
 
+// ContractCodePrefix retrieves up to n leading bytes of a particular contract's
+// code. Prefixes within the code size index are served without loading the
+// full code.
+func (db *cachingDB) ContractCodePrefix(addr common.Address, codeHash common.Hash, n int) ([]byte, error) {
+	if code, _ := db.codeCache.Get(codeHash); len(code) > 0 {
+		return common.CopyBytes(code[:min(n, len(code))]), nil
+	}
+	if prefix, ok := rawdb.ReadCodePrefix(db.disk, codeHash, n); ok {
+		return prefix, nil
+	}
+	code, err := db.ContractCode(addr, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	return common.CopyBytes(code[:min(n, len(code))]), nil
+}
+
 // DiskDB returns the underlying key-value disk database.
 func (db *cachingDB) DiskDB() ethdb.KeyValueStore {
 	return db.disk
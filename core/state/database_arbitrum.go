@@ -9,13 +9,40 @@ import (
 )
 
 func (db *cachingDB) ActivatedAsm(target ethdb.WasmTarget, moduleHash common.Hash) ([]byte, error) {
-	cacheKey := activatedAsmCacheKey{moduleHash, target}
-	if asm, _ := db.activatedAsmCache.Get(cacheKey); len(asm) > 0 {
+	if asm, ok := db.activatedAsmCache.get(target, moduleHash); ok {
 		return asm, nil
 	}
 	if asm := rawdb.ReadActivatedAsm(db.wasmdb, target, moduleHash); len(asm) > 0 {
-		db.activatedAsmCache.Add(cacheKey, asm)
+		db.activatedAsmCache.add(target, moduleHash, asm)
 		return asm, nil
 	}
 	return nil, errors.New("not found")
 }
+
+// PrefetchActivatedAsm loads in the background the asm of the given modules
+// missing from the cache, so that their first call doesn't hit the disk.
+func (db *cachingDB) PrefetchActivatedAsm(targets []ethdb.WasmTarget, moduleHashes []common.Hash) {
+	type missing struct {
+		target     ethdb.WasmTarget
+		moduleHash common.Hash
+	}
+	var misses []missing
+	for _, moduleHash := range moduleHashes {
+		for _, target := range targets {
+			if !db.activatedAsmCache.contains(target, moduleHash) {
+				misses = append(misses, missing{target, moduleHash})
+			}
+		}
+	}
+	if len(misses) == 0 {
+		return
+	}
+	go func() {
+		for _, miss := range misses {
+			if asm := rawdb.ReadActivatedAsm(db.wasmdb, miss.target, miss.moduleHash); len(asm) > 0 {
+				db.activatedAsmCache.add(miss.target, miss.moduleHash, asm)
+				asmCachePrefetchMeter.Mark(1)
+			}
+		}
+	}()
+}
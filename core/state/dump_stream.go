@@ -0,0 +1,566 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// The streamed state dump is a sequence of length-prefixed records preceded by
+// a magic string. Every record is a kind byte, followed by the uvarint length of
+// its RLP payload and the payload itself. A dump starts with a header record and
+// ends with a trailer record; in between, every account record is followed by
+// its storage slots and, the first time a code hash is seen, its code. Activated
+// Stylus modules are appended once the last account has been dumped.
+const (
+	dumpStreamVersion = 2
+	maxDumpRecordSize = 256 * 1024 * 1024 // Upper bound on a record, to reject corrupted streams
+
+	dumpRecordHeader  byte = 0
+	dumpRecordAccount byte = 1
+	dumpRecordStorage byte = 2
+	dumpRecordCode    byte = 3
+	dumpRecordWasm    byte = 4
+	dumpRecordTrailer byte = 5
+
+	// Number of module hashes retrieved from the wasm store at a time
+	dumpWasmBatchSize = 1024
+)
+
+var dumpStreamMagic = []byte("STATEDUMP")
+
+type dumpHeaderRecord struct {
+	Version uint64
+	Root    common.Hash
+	Start   common.Hash
+}
+
+type dumpAccountRecord struct {
+	Hash     common.Hash
+	Address  []byte // Empty if the preimage is unknown
+	Nonce    uint64
+	Balance  *uint256.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+type dumpStorageRecord struct {
+	Hash  common.Hash
+	Key   []byte // Empty if the preimage is unknown
+	Value []byte
+}
+
+type dumpCodeRecord struct {
+	Hash common.Hash
+	Code []byte
+}
+
+type dumpWasmRecord struct {
+	Target     string
+	ModuleHash common.Hash
+	Asm        []byte
+}
+
+type dumpTrailerRecord struct {
+	Accounts uint64
+	Next     []byte // Empty if the dump is complete
+}
+
+// StreamDumpConfig is a set of options to control a streamed state dump.
+type StreamDumpConfig struct {
+	Start    []byte             // Account hash to start (or resume) the dump from
+	Max      uint64             // Maximum number of accounts to dump, 0 for no limit
+	Progress func(DumpProgress) // Invoked after every dumped account, if set
+}
+
+// StreamImportConfig is a set of options to control a streamed state import.
+type StreamImportConfig struct {
+	Progress func(DumpProgress) // Invoked after every imported account, if set
+}
+
+// DumpProgress reports how far a streamed dump or import has advanced.
+type DumpProgress struct {
+	Accounts uint64      // Number of accounts processed
+	Slots    uint64      // Number of storage slots processed
+	Codes    uint64      // Number of contract codes processed
+	Modules  uint64      // Number of activated Stylus modules processed
+	Last     common.Hash // Hash of the last account processed
+}
+
+// dumpWriter encodes records into the dump stream.
+type dumpWriter struct {
+	w   io.Writer
+	buf [1 + binary.MaxVarintLen64]byte
+}
+
+func (d *dumpWriter) write(kind byte, record interface{}) error {
+	payload, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	d.buf[0] = kind
+	n := binary.PutUvarint(d.buf[1:], uint64(len(payload)))
+	if _, err := d.w.Write(d.buf[:1+n]); err != nil {
+		return err
+	}
+	_, err = d.w.Write(payload)
+	return err
+}
+
+// dumpReader decodes records from the dump stream.
+type dumpReader struct {
+	r *bufio.Reader
+}
+
+func (d *dumpReader) read() (byte, []byte, error) {
+	kind, err := d.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if size > maxDumpRecordSize {
+		return 0, nil, fmt.Errorf("dump record too large: %d bytes", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return kind, payload, nil
+}
+
+// DumpToWriter streams the flat state (accounts, storage, code and activated
+// Stylus modules) in the binary dump format into the given writer. If the dump
+// is stopped early due to the configured Max, the hash of the next account is
+// returned and can be used as Start of a subsequent dump to resume from.
+func (s *StateDB) DumpToWriter(w io.Writer, conf *StreamDumpConfig) (next []byte, err error) {
+	// Sanitize the input to allow nil configs
+	if conf == nil {
+		conf = new(StreamDumpConfig)
+	}
+	var (
+		out      = &dumpWriter{w: w}
+		progress DumpProgress
+		codes    = make(map[common.Hash]struct{})
+		start    = time.Now()
+		logged   = time.Now()
+		triedb   = s.db.TrieDB()
+	)
+	log.Info("State streaming started", "root", s.originalRoot, "start", common.BytesToHash(conf.Start))
+
+	if _, err := w.Write(dumpStreamMagic); err != nil {
+		return nil, err
+	}
+	header := &dumpHeaderRecord{Version: dumpStreamVersion, Root: s.originalRoot, Start: common.BytesToHash(conf.Start)}
+	if err := out.write(dumpRecordHeader, header); err != nil {
+		return nil, err
+	}
+	tr, err := trie.New(trie.StateTrieID(s.originalRoot), triedb)
+	if err != nil {
+		return nil, err
+	}
+	trieIt, err := tr.NodeIterator(conf.Start)
+	if err != nil {
+		return nil, err
+	}
+	it := trie.NewIterator(trieIt)
+	for it.Next() {
+		if conf.Max > 0 && progress.Accounts >= conf.Max {
+			next = common.CopyBytes(it.Key)
+			break
+		}
+		var data types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		hash := common.BytesToHash(it.Key)
+		account := &dumpAccountRecord{
+			Hash:     hash,
+			Address:  s.trie.GetKey(it.Key),
+			Nonce:    data.Nonce,
+			Balance:  data.Balance,
+			Root:     data.Root,
+			CodeHash: data.CodeHash,
+		}
+		if err := out.write(dumpRecordAccount, account); err != nil {
+			return nil, err
+		}
+		if data.Root != types.EmptyRootHash {
+			st, err := trie.New(trie.StorageTrieID(s.originalRoot, hash, data.Root), triedb)
+			if err != nil {
+				return nil, err
+			}
+			storageNodeIt, err := st.NodeIterator(nil)
+			if err != nil {
+				return nil, err
+			}
+			storageIt := trie.NewIterator(storageNodeIt)
+			for storageIt.Next() {
+				_, content, _, err := rlp.Split(storageIt.Value)
+				if err != nil {
+					return nil, err
+				}
+				slot := &dumpStorageRecord{
+					Hash:  common.BytesToHash(storageIt.Key),
+					Key:   s.trie.GetKey(storageIt.Key),
+					Value: content,
+				}
+				if err := out.write(dumpRecordStorage, slot); err != nil {
+					return nil, err
+				}
+				progress.Slots++
+			}
+			if storageIt.Err != nil {
+				return nil, storageIt.Err
+			}
+		}
+		codeHash := common.BytesToHash(data.CodeHash)
+		if codeHash != types.EmptyCodeHash {
+			if _, ok := codes[codeHash]; !ok {
+				code, err := s.db.ContractCode(common.BytesToAddress(account.Address), codeHash)
+				if err != nil {
+					return nil, err
+				}
+				if err := out.write(dumpRecordCode, &dumpCodeRecord{Hash: codeHash, Code: code}); err != nil {
+					return nil, err
+				}
+				codes[codeHash] = struct{}{}
+				progress.Codes++
+			}
+		}
+		progress.Accounts++
+		progress.Last = hash
+		if conf.Progress != nil {
+			conf.Progress(progress)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("State streaming in progress", "at", hash, "accounts", progress.Accounts,
+				"slots", progress.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	// Activated modules aren't tied to accounts, ship them with the last part
+	if next == nil {
+		if err := s.dumpActivatedModules(out, &progress); err != nil {
+			return nil, err
+		}
+		if conf.Progress != nil {
+			conf.Progress(progress)
+		}
+	}
+	if err := out.write(dumpRecordTrailer, &dumpTrailerRecord{Accounts: progress.Accounts, Next: next}); err != nil {
+		return nil, err
+	}
+	log.Info("State streaming complete", "accounts", progress.Accounts, "slots", progress.Slots,
+		"codes", progress.Codes, "modules", progress.Modules, "next", common.BytesToHash(next),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return next, nil
+}
+
+// dumpActivatedModules streams the activated asm of every Stylus module, for
+// all targets present in the wasm store.
+func (s *StateDB) dumpActivatedModules(out *dumpWriter, progress *DumpProgress) error {
+	wasmdb := s.db.WasmStore()
+	if wasmdb == nil {
+		return nil
+	}
+	for _, target := range rawdb.AllWasmTargets() {
+		var start common.Hash
+		for {
+			hashes, err := rawdb.ReadActivatedModuleHashes(wasmdb, target, start, dumpWasmBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, moduleHash := range hashes {
+				record := &dumpWasmRecord{
+					Target:     string(target),
+					ModuleHash: moduleHash,
+					Asm:        rawdb.ReadActivatedAsm(wasmdb, target, moduleHash),
+				}
+				if err := out.write(dumpRecordWasm, record); err != nil {
+					return err
+				}
+				progress.Modules++
+			}
+			if len(hashes) < dumpWasmBatchSize {
+				break
+			}
+			last := hashes[len(hashes)-1]
+			if last == common.MaxHash {
+				break
+			}
+			start = common.BigToHash(new(big.Int).Add(last.Big(), common.Big1))
+		}
+	}
+	return nil
+}
+
+// ImportFromReader imports a state dump produced by DumpToWriter into the given
+// database, writing the trie nodes in the given state scheme. The import of a
+// dump split into several parts can be resumed by importing the remaining parts
+// in order; the hash of the next account is returned for every part but the
+// last. Once the last part is imported, the state trie is completed from all
+// imported accounts and its root is verified against the one in the dump.
+//
+// The database is expected to contain no other flat state than the imported one.
+func ImportFromReader(db ethdb.Database, scheme string, r io.Reader, conf *StreamImportConfig) (root common.Hash, next []byte, err error) {
+	// Sanitize the input to allow nil configs
+	if conf == nil {
+		conf = new(StreamImportConfig)
+	}
+	in := &dumpReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(dumpStreamMagic))
+	if _, err := io.ReadFull(in.r, magic); err != nil {
+		return common.Hash{}, nil, err
+	}
+	if !bytes.Equal(magic, dumpStreamMagic) {
+		return common.Hash{}, nil, errors.New("not a state dump")
+	}
+	kind, payload, err := in.read()
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	if kind != dumpRecordHeader {
+		return common.Hash{}, nil, fmt.Errorf("unexpected record %d, want header", kind)
+	}
+	var header dumpHeaderRecord
+	if err := rlp.DecodeBytes(payload, &header); err != nil {
+		return common.Hash{}, nil, err
+	}
+	if header.Version != dumpStreamVersion {
+		return common.Hash{}, nil, fmt.Errorf("unsupported state dump version %d", header.Version)
+	}
+	log.Info("State import started", "root", header.Root, "start", header.Start)
+
+	var (
+		batch     = db.NewBatch()
+		wasmdb, _ = db.WasmDataBase()
+		progress  DumpProgress
+		start     = time.Now()
+		logged    = time.Now()
+
+		account *dumpAccountRecord // Account whose storage is being imported
+		storage *trie.StackTrie    // Storage trie of the account being imported
+	)
+	flush := func(force bool) error {
+		if !force && batch.ValueSize() < ethdb.IdealBatchSize {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	// finishAccount verifies the storage root of the account being imported
+	// and persists the account itself.
+	finishAccount := func() error {
+		if account == nil {
+			return nil
+		}
+		if hash := storage.Hash(); hash != account.Root {
+			return fmt.Errorf("storage root mismatch for account %x: have %x, want %x", account.Hash, hash, account.Root)
+		}
+		rawdb.WriteAccountSnapshot(batch, account.Hash, types.SlimAccountRLP(types.StateAccount{
+			Nonce:    account.Nonce,
+			Balance:  account.Balance,
+			Root:     account.Root,
+			CodeHash: account.CodeHash,
+		}))
+		progress.Accounts++
+		progress.Last = account.Hash
+		account, storage = nil, nil
+
+		if conf.Progress != nil {
+			conf.Progress(progress)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("State import in progress", "at", progress.Last, "accounts", progress.Accounts,
+				"slots", progress.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		return flush(false)
+	}
+	for {
+		kind, payload, err := in.read()
+		if err != nil {
+			return common.Hash{}, nil, err
+		}
+		switch kind {
+		case dumpRecordAccount:
+			if err := finishAccount(); err != nil {
+				return common.Hash{}, nil, err
+			}
+			var record dumpAccountRecord
+			if err := rlp.DecodeBytes(payload, &record); err != nil {
+				return common.Hash{}, nil, err
+			}
+			if record.Hash.Cmp(header.Start) < 0 || (progress.Accounts > 0 && record.Hash.Cmp(progress.Last) <= 0) {
+				return common.Hash{}, nil, fmt.Errorf("account %x out of order", record.Hash)
+			}
+			if len(record.Address) > 0 {
+				rawdb.WritePreimages(batch, map[common.Hash][]byte{record.Hash: record.Address})
+			}
+			owner := record.Hash
+			account = &record
+			storage = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+				rawdb.WriteTrieNode(batch, owner, path, hash, blob, scheme)
+			})
+
+		case dumpRecordStorage:
+			if account == nil {
+				return common.Hash{}, nil, errors.New("storage slot without account")
+			}
+			var record dumpStorageRecord
+			if err := rlp.DecodeBytes(payload, &record); err != nil {
+				return common.Hash{}, nil, err
+			}
+			value, err := rlp.EncodeToBytes(record.Value)
+			if err != nil {
+				return common.Hash{}, nil, err
+			}
+			if err := storage.Update(record.Hash[:], value); err != nil {
+				return common.Hash{}, nil, fmt.Errorf("storage slot %x of account %x: %w", record.Hash, account.Hash, err)
+			}
+			rawdb.WriteStorageSnapshot(batch, account.Hash, record.Hash, record.Value)
+			if len(record.Key) > 0 {
+				rawdb.WritePreimages(batch, map[common.Hash][]byte{record.Hash: record.Key})
+			}
+			progress.Slots++
+
+		case dumpRecordCode:
+			var record dumpCodeRecord
+			if err := rlp.DecodeBytes(payload, &record); err != nil {
+				return common.Hash{}, nil, err
+			}
+			if hash := crypto.Keccak256Hash(record.Code); hash != record.Hash {
+				return common.Hash{}, nil, fmt.Errorf("code hash mismatch: have %x, want %x", hash, record.Hash)
+			}
+			rawdb.WriteCode(batch, record.Hash, record.Code)
+			progress.Codes++
+
+		case dumpRecordWasm:
+			var record dumpWasmRecord
+			if err := rlp.DecodeBytes(payload, &record); err != nil {
+				return common.Hash{}, nil, err
+			}
+			target := ethdb.WasmTarget(record.Target)
+			if !rawdb.IsSupportedWasmTarget(target) {
+				return common.Hash{}, nil, fmt.Errorf("unsupported wasm target %q", record.Target)
+			}
+			if wasmdb == nil {
+				return common.Hash{}, nil, errors.New("database has no wasm store")
+			}
+			rawdb.WriteActivatedAsm(wasmdb, target, record.ModuleHash, record.Asm)
+			progress.Modules++
+
+		case dumpRecordTrailer:
+			if err := finishAccount(); err != nil {
+				return common.Hash{}, nil, err
+			}
+			var record dumpTrailerRecord
+			if err := rlp.DecodeBytes(payload, &record); err != nil {
+				return common.Hash{}, nil, err
+			}
+			if record.Accounts != progress.Accounts {
+				return common.Hash{}, nil, fmt.Errorf("account count mismatch: have %d, want %d", progress.Accounts, record.Accounts)
+			}
+			if err := flush(true); err != nil {
+				return common.Hash{}, nil, err
+			}
+			if len(record.Next) > 0 {
+				log.Info("State import part complete", "accounts", progress.Accounts, "next", common.BytesToHash(record.Next),
+					"elapsed", common.PrettyDuration(time.Since(start)))
+				return common.Hash{}, record.Next, nil
+			}
+			root, err := generateAccountTrie(db, scheme)
+			if err != nil {
+				return common.Hash{}, nil, err
+			}
+			if root != header.Root {
+				return common.Hash{}, nil, fmt.Errorf("state root mismatch: have %x, want %x", root, header.Root)
+			}
+			log.Info("State import complete", "root", root, "accounts", progress.Accounts, "slots", progress.Slots,
+				"codes", progress.Codes, "modules", progress.Modules, "elapsed", common.PrettyDuration(time.Since(start)))
+			return root, nil, nil
+
+		default:
+			return common.Hash{}, nil, fmt.Errorf("unknown dump record %d", kind)
+		}
+	}
+}
+
+// generateAccountTrie builds the account trie from the flat accounts in the
+// database, persisting its nodes in the given state scheme.
+func generateAccountTrie(db ethdb.Database, scheme string) (common.Hash, error) {
+	batch := db.NewBatch()
+	tr := trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteTrieNode(batch, common.Hash{}, path, hash, blob, scheme)
+	})
+	it := rawdb.NewKeyLengthIterator(db.NewIterator(rawdb.SnapshotAccountPrefix, nil), len(rawdb.SnapshotAccountPrefix)+common.HashLength)
+	defer it.Release()
+
+	for it.Next() {
+		full, err := types.FullAccountRLP(it.Value())
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := tr.Update(it.Key()[len(rawdb.SnapshotAccountPrefix):], full); err != nil {
+			return common.Hash{}, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return common.Hash{}, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return common.Hash{}, err
+	}
+	root := tr.Hash()
+	if err := batch.Write(); err != nil {
+		return common.Hash{}, err
+	}
+	return root, nil
+}
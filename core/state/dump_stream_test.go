@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// makeDumpState creates a state with a handful of accounts, storage, code and
+// an activated Stylus module.
+func makeDumpState(t *testing.T) (*StateDB, common.Hash) {
+	db := rawdb.NewMemoryDatabase()
+	tdb := NewDatabaseWithConfig(db, &triedb.Config{Preimages: true})
+	sdb, _ := New(types.EmptyRootHash, tdb, nil)
+
+	for i := byte(1); i <= 10; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		sdb.SetBalance(addr, uint256.NewInt(uint64(i)*100), tracing.BalanceChangeUnspecified)
+		sdb.SetNonce(addr, uint64(i))
+		if i%2 == 0 {
+			sdb.SetCode(addr, []byte{i, i, i})
+			for j := byte(1); j <= i; j++ {
+				sdb.SetState(addr, common.Hash{j}, common.Hash{i, j})
+			}
+		}
+	}
+	root, err := sdb.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := tdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	wasmdb, _ := db.WasmDataBase()
+	rawdb.WriteActivatedAsm(wasmdb, rawdb.TargetWavm, common.Hash{0xaa}, []byte{0x01, 0x02})
+
+	sdb, err = New(root, tdb, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen state: %v", err)
+	}
+	return sdb, root
+}
+
+// verifyImportedState checks that the imported state matches the dumped one.
+func verifyImportedState(t *testing.T, db ethdb.Database, scheme string, root common.Hash) {
+	config := &triedb.Config{HashDB: hashdb.Defaults}
+	if scheme == rawdb.PathScheme {
+		config = &triedb.Config{PathDB: pathdb.Defaults}
+	}
+	sdb, err := New(root, NewDatabaseWithConfig(db, config), nil)
+	if err != nil {
+		t.Fatalf("failed to open imported state: %v", err)
+	}
+	for i := byte(1); i <= 10; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		if have, want := sdb.GetBalance(addr), uint256.NewInt(uint64(i)*100); !have.Eq(want) {
+			t.Errorf("account %x: balance mismatch: have %v, want %v", addr, have, want)
+		}
+		if have := sdb.GetNonce(addr); have != uint64(i) {
+			t.Errorf("account %x: nonce mismatch: have %d, want %d", addr, have, i)
+		}
+		if i%2 == 0 {
+			if have := sdb.GetCode(addr); !bytes.Equal(have, []byte{i, i, i}) {
+				t.Errorf("account %x: code mismatch: have %x", addr, have)
+			}
+			for j := byte(1); j <= i; j++ {
+				if have, want := sdb.GetState(addr, common.Hash{j}), (common.Hash{i, j}); have != want {
+					t.Errorf("account %x slot %d: have %x, want %x", addr, j, have, want)
+				}
+			}
+		}
+	}
+	wasmdb, _ := db.WasmDataBase()
+	if asm := rawdb.ReadActivatedAsm(wasmdb, rawdb.TargetWavm, common.Hash{0xaa}); !bytes.Equal(asm, []byte{0x01, 0x02}) {
+		t.Errorf("activated asm mismatch: have %x", asm)
+	}
+}
+
+func TestDumpToWriterImport(t *testing.T) {
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		sdb, root := makeDumpState(t)
+
+		var (
+			buf      bytes.Buffer
+			progress DumpProgress
+		)
+		next, err := sdb.DumpToWriter(&buf, &StreamDumpConfig{Progress: func(p DumpProgress) { progress = p }})
+		if err != nil {
+			t.Fatalf("%s: dump failed: %v", scheme, err)
+		}
+		if next != nil {
+			t.Fatalf("%s: unexpected next key %x", scheme, next)
+		}
+		if progress.Accounts != 10 || progress.Slots != 30 || progress.Codes != 5 || progress.Modules != 1 {
+			t.Fatalf("%s: unexpected dump progress %+v", scheme, progress)
+		}
+		db := rawdb.NewMemoryDatabase()
+		imported, next, err := ImportFromReader(db, scheme, &buf, nil)
+		if err != nil {
+			t.Fatalf("%s: import failed: %v", scheme, err)
+		}
+		if imported != root || next != nil {
+			t.Fatalf("%s: unexpected import result: root %x, next %x", scheme, imported, next)
+		}
+		verifyImportedState(t, db, scheme, root)
+	}
+}
+
+func TestDumpToWriterResume(t *testing.T) {
+	sdb, root := makeDumpState(t)
+	db := rawdb.NewMemoryDatabase()
+
+	var (
+		start []byte
+		parts int
+	)
+	for {
+		var buf bytes.Buffer
+		next, err := sdb.DumpToWriter(&buf, &StreamDumpConfig{Start: start, Max: 3})
+		if err != nil {
+			t.Fatalf("part %d: dump failed: %v", parts, err)
+		}
+		imported, resume, err := ImportFromReader(db, rawdb.HashScheme, &buf, nil)
+		if err != nil {
+			t.Fatalf("part %d: import failed: %v", parts, err)
+		}
+		if !bytes.Equal(next, resume) {
+			t.Fatalf("part %d: resume key mismatch: dump %x, import %x", parts, next, resume)
+		}
+		parts++
+		if next == nil {
+			if imported != root {
+				t.Fatalf("root mismatch: have %x, want %x", imported, root)
+			}
+			break
+		}
+		start = next
+	}
+	if parts != 4 {
+		t.Fatalf("unexpected number of parts: have %d, want 4", parts)
+	}
+	verifyImportedState(t, db, rawdb.HashScheme, root)
+}
+
+func TestImportFromReaderCorrupted(t *testing.T) {
+	sdb, _ := makeDumpState(t)
+
+	var buf bytes.Buffer
+	if _, err := sdb.DumpToWriter(&buf, nil); err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	// Truncated streams must be rejected
+	data := buf.Bytes()
+	if _, _, err := ImportFromReader(rawdb.NewMemoryDatabase(), rawdb.HashScheme, bytes.NewReader(data[:len(data)/2]), nil); err == nil {
+		t.Fatal("expected error on truncated dump")
+	}
+	// Streams with a different magic must be rejected
+	if _, _, err := ImportFromReader(rawdb.NewMemoryDatabase(), rawdb.HashScheme, bytes.NewReader([]byte("NOTADUMP!")), nil); err == nil {
+		t.Fatal("expected error on invalid magic")
+	}
+}
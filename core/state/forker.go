@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+)
+
+// forkerPrefetchNamespace is the namespace of the prefetcher shared by the
+// states of a Forker.
+const forkerPrefetchNamespace = "forker"
+
+// errForkerClosed is returned if a state is requested from a closed forker.
+var errForkerClosed = errors.New("forker closed")
+
+// Forker hands out independent states on top of a common parent, e.g. to
+// evaluate alternative block candidates in parallel.
+//
+// Rather than copying the parent state, the children are created from a single
+// opened account trie and share a trie prefetcher, so that the nodes loaded for
+// one candidate are readily available to the others. Clean accounts and storage
+// slots are further shared through the process-wide storage cache, which is
+// keyed by the parent root.
+//
+// The children may be used concurrently, but each one of them individually is
+// no more thread safe than any other state.
+type Forker struct {
+	root  common.Hash    // Root hash of the parent state
+	db    Database       // Database to open the states through
+	snaps *snapshot.Tree // Snapshot tree to read the states through, if any
+	trie  Trie           // Account trie of the parent, copied into each child
+
+	prefetcher *triePrefetcher // Trie prefetcher shared by the children
+	closed     bool
+	lock       sync.Mutex
+}
+
+// NewForker creates a forker of the state with the given root.
+func NewForker(root common.Hash, db Database, snaps *snapshot.Tree) (*Forker, error) {
+	tr, err := db.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	prefetcher := newTriePrefetcher(db, root, forkerPrefetchNamespace)
+	prefetcher.shared = true
+
+	return &Forker{
+		root:       root,
+		db:         db,
+		snaps:      snaps,
+		trie:       tr,
+		prefetcher: prefetcher,
+	}, nil
+}
+
+// Root returns the root hash of the parent state.
+func (f *Forker) Root() common.Hash {
+	return f.root
+}
+
+// Fork returns a new child state of the parent. Changes made to the child
+// aren't visible to the parent or to the other children.
+func (f *Forker) Fork() (*StateDB, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return nil, errForkerClosed
+	}
+	state := newWithTrie(f.root, f.db.CopyTrie(f.trie), f.db, f.snaps)
+	state.prefetcher = f.prefetcher
+	return state, nil
+}
+
+// Close terminates the shared prefetcher. The children handed out remain usable,
+// retaining the trie nodes prefetched so far, but no new child can be forked.
+func (f *Forker) Close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	f.prefetcher.closeShared()
+}
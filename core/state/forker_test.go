@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestForker(t *testing.T) {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	parent, _ := New(types.EmptyRootHash, db, nil)
+	for i := byte(0); i < 16; i++ {
+		addr := common.Address{i}
+		parent.SetBalance(addr, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+		parent.SetState(addr, common.Hash{i}, common.Hash{i})
+	}
+	root, err := parent.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit parent: %v", err)
+	}
+	// mutate applies the candidate specific changes to a state
+	mutate := func(state *StateDB, candidate byte) {
+		for i := byte(0); i < 16; i++ {
+			addr := common.Address{i}
+			state.AddBalance(addr, uint256.NewInt(uint64(candidate)), tracing.BalanceChangeUnspecified)
+			state.SetState(addr, common.Hash{i}, common.Hash{i, candidate})
+		}
+		state.SetState(common.Address{candidate}, common.Hash{0xff}, common.Hash{candidate})
+	}
+	forker, err := NewForker(root, db, nil)
+	if err != nil {
+		t.Fatalf("failed to create forker: %v", err)
+	}
+	var (
+		candidates = 8
+		roots      = make([]common.Hash, candidates)
+		wg         sync.WaitGroup
+	)
+	for i := 0; i < candidates; i++ {
+		state, err := forker.Fork()
+		if err != nil {
+			t.Fatalf("failed to fork state: %v", err)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mutate(state, byte(i+1))
+			roots[i] = state.IntermediateRoot(false)
+		}(i)
+	}
+	wg.Wait()
+	forker.Close()
+
+	if _, err := forker.Fork(); err == nil {
+		t.Fatal("forked a closed forker")
+	}
+	for i := 0; i < candidates; i++ {
+		state, _ := New(root, db, nil)
+		mutate(state, byte(i+1))
+		if want := state.IntermediateRoot(false); roots[i] != want {
+			t.Fatalf("candidate %d: root mismatch: have %x, want %x", i, roots[i], want)
+		}
+	}
+	// The parent remains untouched by the children
+	state, _ := New(root, db, nil)
+	if balance := state.GetBalance(common.Address{1}); balance.Uint64() != 1 {
+		t.Fatalf("parent balance modified: %v", balance)
+	}
+}
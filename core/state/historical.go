@@ -0,0 +1,236 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+var (
+	historicalAccountReadMeter = metrics.NewRegisteredMeter("state/historical/account", nil)
+	historicalStorageReadMeter = metrics.NewRegisteredMeter("state/historical/storage", nil)
+	historicalCodeReadMeter    = metrics.NewRegisteredMeter("state/historical/code", nil)
+)
+
+// errHistoricalReadOnly is returned when modifying a state served by a
+// historical state provider.
+var errHistoricalReadOnly = errors.New("historical state is read-only")
+
+// HistoricalStateProvider serves the accounts, storage slots and contract code
+// of historical states that are not available locally, typically by querying
+// a remote archive service. States are identified by their root hash.
+//
+// Implementations must be safe for concurrent use.
+type HistoricalStateProvider interface {
+	// Account returns the account at the given address in the given state, or
+	// nil if the account doesn't exist.
+	Account(root common.Hash, addr common.Address) (*types.StateAccount, error)
+
+	// Storage returns the value of the given storage slot of the given account
+	// in the given state.
+	Storage(root common.Hash, addr common.Address, slot common.Hash) (common.Hash, error)
+
+	// Code returns the contract code with the given hash, deployed at the given
+	// address in the given state.
+	Code(root common.Hash, addr common.Address, codeHash common.Hash) ([]byte, error)
+}
+
+// historicalDatabase is a state database whose states missing locally are read
+// through a historical state provider instead.
+type historicalDatabase struct {
+	Database
+	provider  HistoricalStateProvider
+	codeRoots *lru.Cache[common.Hash, common.Hash] // States the code hashes were served from, to fetch the code from
+}
+
+// NewHistoricalDatabase wraps the given state database so that opening a state
+// whose account trie is not available locally serves the state from the given
+// provider instead. Such states are read-only: reading them works as usual,
+// while committing changes to them fails. Contract code missing locally is
+// fetched from the provider too.
+func NewHistoricalDatabase(db Database, provider HistoricalStateProvider) Database {
+	return &historicalDatabase{
+		Database:  db,
+		provider:  provider,
+		codeRoots: lru.NewCache[common.Hash, common.Hash](codeSizeCacheSize),
+	}
+}
+
+// OpenTrie opens the main account trie, falling back to the provider if the
+// trie is missing locally.
+func (db *historicalDatabase) OpenTrie(root common.Hash) (Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err == nil {
+		return tr, nil
+	}
+	var missing *trie.MissingNodeError
+	if !errors.As(err, &missing) {
+		return nil, err
+	}
+	return &historicalTrie{db: db, root: root}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, which is served by the
+// provider if the account trie is.
+func (db *historicalDatabase) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self Trie) (Trie, error) {
+	if _, ok := self.(*historicalTrie); ok {
+		return &historicalTrie{db: db, root: stateRoot, owner: &address, storageRoot: root}, nil
+	}
+	return db.Database.OpenStorageTrie(stateRoot, address, root, self)
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *historicalDatabase) CopyTrie(t Trie) Trie {
+	if t, ok := t.(*historicalTrie); ok {
+		cpy := *t
+		return &cpy
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// contractCode fetches the code with the given hash from the provider. The
+// code is checked against the hash, as the provider is not trusted.
+func (db *historicalDatabase) contractCode(addr common.Address, codeHash common.Hash) ([]byte, error) {
+	root, ok := db.codeRoots.Get(codeHash)
+	if !ok {
+		return nil, fmt.Errorf("historical code %x not found", codeHash)
+	}
+	code, err := db.provider.Code(root, addr, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(code); hash != codeHash {
+		return nil, fmt.Errorf("historical code hash mismatch: have %x, want %x", hash, codeHash)
+	}
+	historicalCodeReadMeter.Mark(1)
+	return code, nil
+}
+
+// ContractCode retrieves a particular contract's code, falling back to the
+// provider if it's missing locally.
+func (db *historicalDatabase) ContractCode(addr common.Address, codeHash common.Hash) ([]byte, error) {
+	if code, err := db.Database.ContractCode(addr, codeHash); err == nil {
+		return code, nil
+	}
+	return db.contractCode(addr, codeHash)
+}
+
+// ContractCodeSize retrieves a particular contract's code size, falling back to
+// the provider if the code is missing locally.
+func (db *historicalDatabase) ContractCodeSize(addr common.Address, codeHash common.Hash) (int, error) {
+	if size, err := db.Database.ContractCodeSize(addr, codeHash); err == nil {
+		return size, nil
+	}
+	code, err := db.contractCode(addr, codeHash)
+	return len(code), err
+}
+
+// ContractCodePrefix retrieves up to n leading bytes of a particular contract's
+// code, falling back to the provider if the code is missing locally.
+func (db *historicalDatabase) ContractCodePrefix(addr common.Address, codeHash common.Hash, n int) ([]byte, error) {
+	if prefix, err := db.Database.ContractCodePrefix(addr, codeHash, n); err == nil {
+		return prefix, nil
+	}
+	code, err := db.contractCode(addr, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	return common.CopyBytes(code[:min(n, len(code))]), nil
+}
+
+// historicalTrie is a read-only account or storage trie served by a historical
+// state provider.
+type historicalTrie struct {
+	db          *historicalDatabase
+	root        common.Hash     // Root of the state the trie belongs to
+	owner       *common.Address // Owner of the storage trie, nil for the account trie
+	storageRoot common.Hash     // Root of the storage trie, if any
+}
+
+func (t *historicalTrie) GetKey([]byte) []byte {
+	return nil
+}
+
+func (t *historicalTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	historicalAccountReadMeter.Mark(1)
+	account, err := t.db.provider.Account(t.root, address)
+	if err != nil || account == nil {
+		return nil, err
+	}
+	if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+		t.db.codeRoots.Add(codeHash, t.root)
+	}
+	return account, nil
+}
+
+func (t *historicalTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	historicalStorageReadMeter.Mark(1)
+	value, err := t.db.provider.Storage(t.root, addr, common.BytesToHash(key))
+	if err != nil {
+		return nil, err
+	}
+	return common.TrimLeftZeroes(value[:]), nil
+}
+
+func (t *historicalTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	return errHistoricalReadOnly
+}
+
+func (t *historicalTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return errHistoricalReadOnly
+}
+
+func (t *historicalTrie) DeleteAccount(address common.Address) error {
+	return errHistoricalReadOnly
+}
+
+func (t *historicalTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return errHistoricalReadOnly
+}
+
+func (t *historicalTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return nil
+}
+
+func (t *historicalTrie) Hash() common.Hash {
+	if t.owner != nil {
+		return t.storageRoot
+	}
+	return t.root
+}
+
+func (t *historicalTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet, error) {
+	return common.Hash{}, nil, errHistoricalReadOnly
+}
+
+func (t *historicalTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errors.New("historical state can't be iterated")
+}
+
+func (t *historicalTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errors.New("historical state can't be proven")
+}
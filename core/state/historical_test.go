@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// testHistoricalProvider serves a single historical state from memory.
+type testHistoricalProvider struct {
+	root     common.Hash
+	accounts map[common.Address]*types.StateAccount
+	storage  map[common.Address]map[common.Hash]common.Hash
+	code     map[common.Address][]byte
+}
+
+func (p *testHistoricalProvider) Account(root common.Hash, addr common.Address) (*types.StateAccount, error) {
+	if root != p.root {
+		return nil, errors.New("unknown state")
+	}
+	return p.accounts[addr], nil
+}
+
+func (p *testHistoricalProvider) Storage(root common.Hash, addr common.Address, slot common.Hash) (common.Hash, error) {
+	if root != p.root {
+		return common.Hash{}, errors.New("unknown state")
+	}
+	return p.storage[addr][slot], nil
+}
+
+func (p *testHistoricalProvider) Code(root common.Hash, addr common.Address, codeHash common.Hash) ([]byte, error) {
+	if root != p.root {
+		return nil, errors.New("unknown state")
+	}
+	return p.code[addr], nil
+}
+
+func TestHistoricalDatabase(t *testing.T) {
+	var (
+		root     = common.HexToHash("0xdeadbeef")
+		contract = common.HexToAddress("0xcc")
+		missing  = common.HexToAddress("0xaa")
+		slot     = common.HexToHash("0x01")
+		code     = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	)
+	provider := &testHistoricalProvider{
+		root: root,
+		accounts: map[common.Address]*types.StateAccount{
+			contract: {
+				Nonce:    3,
+				Balance:  uint256.NewInt(42),
+				Root:     common.HexToHash("0x5707"),
+				CodeHash: crypto.Keccak256(code),
+			},
+		},
+		storage: map[common.Address]map[common.Hash]common.Hash{
+			contract: {slot: common.HexToHash("0x0102")},
+		},
+		code: map[common.Address][]byte{contract: code},
+	}
+	db := NewHistoricalDatabase(NewDatabase(rawdb.NewMemoryDatabase()), provider)
+
+	statedb, err := New(root, db, nil)
+	if err != nil {
+		t.Fatalf("failed to open historical state: %v", err)
+	}
+	if have := statedb.GetNonce(contract); have != 3 {
+		t.Errorf("nonce mismatch: have %d, want 3", have)
+	}
+	if have := statedb.GetBalance(contract); !have.Eq(uint256.NewInt(42)) {
+		t.Errorf("balance mismatch: have %v, want 42", have)
+	}
+	if have, want := statedb.GetState(contract, slot), common.HexToHash("0x0102"); have != want {
+		t.Errorf("slot mismatch: have %x, want %x", have, want)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Errorf("code mismatch: have %x, want %x", have, code)
+	}
+	if have := statedb.GetCodeSize(contract); have != len(code) {
+		t.Errorf("code size mismatch: have %d, want %d", have, len(code))
+	}
+	if statedb.Exist(missing) {
+		t.Error("missing account reported as existing")
+	}
+	if err := statedb.Error(); err != nil {
+		t.Fatalf("historical reads failed: %v", err)
+	}
+	// Modifications are allowed in memory, but can't be committed
+	statedb.SetBalance(missing, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	if statedb.GetBalance(missing).Uint64() != 1 {
+		t.Error("in-memory modification lost")
+	}
+	if _, err := statedb.Commit(1, true); err == nil {
+		t.Error("historical state committed")
+	}
+}
+
+func TestHistoricalDatabaseCodeMismatch(t *testing.T) {
+	var (
+		root     = common.HexToHash("0xdeadbeef")
+		contract = common.HexToAddress("0xcc")
+	)
+	provider := &testHistoricalProvider{
+		root: root,
+		accounts: map[common.Address]*types.StateAccount{
+			contract: {
+				Balance:  uint256.NewInt(0),
+				Root:     types.EmptyRootHash,
+				CodeHash: crypto.Keccak256([]byte{0x01}),
+			},
+		},
+		code: map[common.Address][]byte{contract: {0x02}},
+	}
+	statedb, err := New(root, NewHistoricalDatabase(NewDatabase(rawdb.NewMemoryDatabase()), provider), nil)
+	if err != nil {
+		t.Fatalf("failed to open historical state: %v", err)
+	}
+	if code := statedb.GetCode(contract); len(code) != 0 {
+		t.Errorf("unverified code served: %x", code)
+	}
+	if statedb.Error() == nil {
+		t.Error("code hash mismatch not reported")
+	}
+}
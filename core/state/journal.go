@@ -332,6 +332,9 @@ func (ch transientStorageChange) copy() journalEntry {
 }
 
 func (ch refundChange) revert(s *StateDB) {
+	if s.logger != nil && s.logger.OnRefundChange != nil && s.refund != ch.prev {
+		s.logger.OnRefundChange(s.refund, ch.prev, true)
+	}
 	s.refund = ch.prev
 }
 
@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// journalRevertWarnThreshold is the number of journal entries a transaction
+// may revert before the contract whose changes were reverted the most is
+// reported.
+const journalRevertWarnThreshold = 10000
+
+// JournalStats counts the journal activity of a transaction: the state changes
+// journalled, the snapshots taken and the reverts to them.
+type JournalStats struct {
+	Entries         int `json:"entries"`         // Journal entries appended, including the reverted ones
+	Snapshots       int `json:"snapshots"`       // Snapshots taken
+	Reverts         int `json:"reverts"`         // Reverts to a snapshot
+	RevertedEntries int `json:"revertedEntries"` // Journal entries undone by the reverts
+	MaxRevertDepth  int `json:"maxRevertDepth"`  // Journal entries undone by the deepest revert
+
+	// Contract whose changes were reverted the most, if any
+	Hotspot         *common.Address `json:"hotspot,omitempty"`
+	HotspotReverted int             `json:"hotspotReverted"`
+
+	reverted map[common.Address]int // Reverted entries by the address they dirtied
+}
+
+// JournalStats returns the journal activity of the last finalised transaction.
+func (s *StateDB) JournalStats() JournalStats {
+	return s.lastJournalStats
+}
+
+// trackSnapshot records a snapshot taken.
+func (s *StateDB) trackSnapshot() {
+	s.journalStats.Snapshots++
+}
+
+// trackRevert records the revert of the journal to the given length, before
+// the entries are undone.
+func (s *StateDB) trackRevert(snapshot int) {
+	stats := &s.journalStats
+	depth := s.journal.length() - snapshot
+
+	stats.Reverts++
+	stats.RevertedEntries += depth
+	stats.MaxRevertDepth = max(stats.MaxRevertDepth, depth)
+	journalRevertDepthHist.Update(int64(depth))
+
+	for _, entry := range s.journal.entries[snapshot:] {
+		if addr := entry.dirtied(); addr != nil {
+			if stats.reverted == nil {
+				stats.reverted = make(map[common.Address]int)
+			}
+			stats.reverted[*addr]++
+		}
+	}
+}
+
+// finaliseJournalStats records the journal activity of the transaction being
+// finalised in the metrics, before the journal is cleared.
+func (s *StateDB) finaliseJournalStats() {
+	stats := s.journalStats
+	stats.Entries = s.journal.length() + stats.RevertedEntries
+	if stats.Entries == 0 && stats.Snapshots == 0 {
+		return
+	}
+	for addr, reverted := range stats.reverted {
+		if reverted > stats.HotspotReverted {
+			addr := addr
+			stats.Hotspot, stats.HotspotReverted = &addr, reverted
+		}
+	}
+	stats.reverted = nil
+
+	journalEntriesHist.Update(int64(stats.Entries))
+	journalSnapshotsHist.Update(int64(stats.Snapshots))
+	journalRevertsHist.Update(int64(stats.Reverts))
+
+	if stats.RevertedEntries >= journalRevertWarnThreshold {
+		log.Debug("Transaction reverted many state changes", "tx", s.thash, "reverts", stats.Reverts,
+			"reverted", stats.RevertedEntries, "depth", stats.MaxRevertDepth, "hotspot", stats.Hotspot, "hotspotReverted", stats.HotspotReverted)
+	}
+	s.lastJournalStats = stats
+	s.journalStats = JournalStats{}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestJournalStats(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xaa")
+		contract = common.HexToAddress("0xcc")
+	)
+	state, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetTxContext(common.Hash{1}, 0)
+
+	state.SetBalance(sender, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	outer := state.Snapshot()
+	state.SetState(contract, common.Hash{1}, common.Hash{1})
+	inner := state.Snapshot()
+	state.SetState(contract, common.Hash{2}, common.Hash{2})
+	state.SetState(contract, common.Hash{3}, common.Hash{3})
+	state.RevertToSnapshot(inner)
+	state.SetNonce(sender, 1)
+	state.RevertToSnapshot(outer)
+	state.Finalise(true)
+
+	stats := state.JournalStats()
+	if stats.Snapshots != 2 || stats.Reverts != 2 {
+		t.Errorf("snapshot/revert count mismatch: have %d/%d, want 2/2", stats.Snapshots, stats.Reverts)
+	}
+	// The inner revert undid 2 slot writes, the outer one the contract creation,
+	// a slot write and the nonce change, leaving the sender creation and balance
+	if stats.RevertedEntries != 5 || stats.Entries != stats.RevertedEntries+2 {
+		t.Errorf("entry count mismatch: have %d, reverted %d", stats.Entries, stats.RevertedEntries)
+	}
+	if stats.MaxRevertDepth != 3 {
+		t.Errorf("revert depth mismatch: have %d, want 3", stats.MaxRevertDepth)
+	}
+	if stats.Hotspot == nil || *stats.Hotspot != contract {
+		t.Errorf("hotspot mismatch: have %v, want %v", stats.Hotspot, contract)
+	}
+	// The next transaction starts from scratch
+	state.SetTxContext(common.Hash{2}, 1)
+	state.SetBalance(sender, uint256.NewInt(2), tracing.BalanceChangeUnspecified)
+	state.Finalise(true)
+
+	if stats := state.JournalStats(); stats.Entries != 1 || stats.Reverts != 0 || stats.Hotspot != nil {
+		t.Errorf("stats not reset: %+v", stats)
+	}
+}
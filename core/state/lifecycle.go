@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccountEventType is the kind of an account lifecycle event.
+type AccountEventType string
+
+const (
+	AccountCreated     AccountEventType = "created"     // Account didn't exist before the block
+	AccountDestructed  AccountEventType = "destructed"  // Pre-existing account was removed by the block
+	AccountResurrected AccountEventType = "resurrected" // Pre-existing account was removed and recreated by the block
+	AccountCodeChanged AccountEventType = "codeChanged" // Code of a surviving account was replaced
+)
+
+// AccountEvent is a lifecycle change of a single account committed by a block.
+// The code hashes are the ones before and after the block, zero if the account
+// didn't exist at that point.
+type AccountEvent struct {
+	Type         AccountEventType `json:"type"`
+	Address      common.Address   `json:"address"`
+	PrevCodeHash common.Hash      `json:"prevCodeHash"`
+	CodeHash     common.Hash      `json:"codeHash"`
+}
+
+// accountEvents derives the lifecycle events of the pending block from the
+// destruction set and the account mutations. It must be called after the
+// mutations were finalised but before the objects are committed, as the
+// original account values are still needed.
+func (s *StateDB) accountEvents() []AccountEvent {
+	var events []AccountEvent
+	for addr, op := range s.mutations {
+		prev, destructed := s.stateObjectsDestruct[addr]
+		if op.isDelete() {
+			// Accounts created and deleted within the block never existed
+			if prev != nil {
+				events = append(events, AccountEvent{
+					Type:         AccountDestructed,
+					Address:      addr,
+					PrevCodeHash: common.BytesToHash(prev.CodeHash),
+				})
+			}
+			continue
+		}
+		obj := s.stateObjects[addr]
+		if obj == nil {
+			continue
+		}
+		event := AccountEvent{Address: addr, CodeHash: common.BytesToHash(obj.CodeHash())}
+		switch {
+		case destructed && prev != nil:
+			event.Type, event.PrevCodeHash = AccountResurrected, common.BytesToHash(prev.CodeHash)
+		case obj.origin == nil:
+			event.Type = AccountCreated
+		case !bytes.Equal(obj.origin.CodeHash, obj.CodeHash()):
+			event.Type, event.PrevCodeHash = AccountCodeChanged, common.BytesToHash(obj.origin.CodeHash)
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return bytes.Compare(events[i].Address[:], events[j].Address[:]) < 0
+	})
+	return events
+}
+
+// CommittedAccountEvents returns the account lifecycle events of the last
+// Commit, sorted by address.
+func (s *StateDB) CommittedAccountEvents() []AccountEvent {
+	return s.committedEvents
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func TestAccountEvents(t *testing.T) {
+	var (
+		db        = NewDatabase(rawdb.NewMemoryDatabase())
+		destroyed = common.Address{1}
+		reborn    = common.Address{2}
+		upgraded  = common.Address{3}
+		untouched = common.Address{4}
+		created   = common.Address{5}
+		ephemeral = common.Address{6}
+
+		oldCode, newCode = []byte{0x01}, []byte{0x02}
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	for _, addr := range []common.Address{destroyed, reborn, upgraded, untouched} {
+		state.SetCode(addr, oldCode)
+	}
+	root, _ := state.Commit(0, true)
+
+	state, _ = New(root, db, nil)
+	state.SelfDestruct(destroyed)
+	state.SelfDestruct(reborn)
+	state.Finalise(true)
+	state.SetCode(reborn, newCode)
+	state.SetCode(upgraded, newCode)
+	state.SetBalance(untouched, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetBalance(created, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetCode(ephemeral, newCode)
+	state.Finalise(true)
+	state.SelfDestruct(ephemeral)
+	if _, err := state.Commit(1, true); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	var (
+		oldHash = crypto.Keccak256Hash(oldCode)
+		newHash = crypto.Keccak256Hash(newCode)
+	)
+	want := []AccountEvent{
+		{Type: AccountDestructed, Address: destroyed, PrevCodeHash: oldHash},
+		{Type: AccountResurrected, Address: reborn, PrevCodeHash: oldHash, CodeHash: newHash},
+		{Type: AccountCodeChanged, Address: upgraded, PrevCodeHash: oldHash, CodeHash: newHash},
+		{Type: AccountCreated, Address: created, CodeHash: types.EmptyCodeHash},
+	}
+	if have := state.CommittedAccountEvents(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected account events:\nhave %+v\nwant %+v", have, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Eviction priorities of the caches sharing a memory budget. When the budget is
+// exceeded, the caches of lower priority are shrunk first.
+const (
+	storageCachePriority = iota // Accounts and slots, cheap to resolve again from the snapshot
+	codeCachePriority           // Contract code, read back from the database
+	asmCachePriority            // Activated Stylus asm, large reads from the wasm store
+)
+
+var (
+	memoryBudgetUsedGauge  = metrics.NewRegisteredGauge("state/memory/used", nil)
+	memoryBudgetEvictMeter = metrics.NewRegisteredMeter("state/memory/evict", nil)
+)
+
+// memoryAccount tracks the memory used by a single consumer of a memory budget.
+type memoryAccount struct {
+	budget   *MemoryBudget
+	name     string
+	priority int
+	shrink   func(bytes uint64) uint64 // Releases at least the given bytes, returning the new size; nil if not evictable
+	size     atomic.Uint64
+	gauge    metrics.Gauge
+}
+
+// track records the current memory used by the consumer, enforcing the budget
+// if it's exceeded. It must not be called with the consumer's locks held, as
+// the consumer may be shrunk.
+func (a *memoryAccount) track(size uint64) {
+	a.set(size)
+	if uint64(a.budget.used.Load()) > a.budget.limit {
+		a.budget.enforce()
+	}
+}
+
+// set records the current memory used by the consumer, without enforcing the
+// budget.
+func (a *memoryAccount) set(size uint64) {
+	old := a.size.Swap(size)
+	memoryBudgetUsedGauge.Update(a.budget.used.Add(int64(size) - int64(old)))
+	a.gauge.Update(int64(size))
+}
+
+// MemoryBudget is a memory allowance shared by the state caches: the clean
+// account and storage cache, the code cache, the Stylus asm cache, along with
+// the snapshot caches reported by the blockchain. Each cache is still capped by
+// its own limit, while the budget caps their total: once exceeded, the
+// evictable caches are shrunk by priority until the total fits again.
+//
+// The memory used by each consumer is exported in the state/memory/<name>
+// gauges.
+type MemoryBudget struct {
+	limit uint64
+	used  atomic.Int64 // Total memory used by all the consumers
+
+	lock     sync.Mutex       // Protects the accounts and serializes the enforcement
+	accounts []*memoryAccount // Consumers, sorted by eviction priority
+}
+
+// NewMemoryBudget creates a memory budget of the given number of bytes.
+func NewMemoryBudget(limit uint64) *MemoryBudget {
+	return &MemoryBudget{limit: limit}
+}
+
+// register adds a consumer to the budget. Consumers without a shrink callback
+// are accounted but never evicted.
+func (b *MemoryBudget) register(name string, priority int, shrink func(bytes uint64) uint64) *memoryAccount {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, account := range b.accounts {
+		if account.name == name {
+			account.priority, account.shrink = priority, shrink
+			return account
+		}
+	}
+	account := &memoryAccount{
+		budget:   b,
+		name:     name,
+		priority: priority,
+		shrink:   shrink,
+		gauge:    metrics.GetOrRegisterGauge("state/memory/"+name, nil),
+	}
+	b.accounts = append(b.accounts, account)
+	sort.SliceStable(b.accounts, func(i, j int) bool {
+		return b.accounts[i].priority < b.accounts[j].priority
+	})
+	return account
+}
+
+// Set records the memory used by a consumer managing its memory on its own,
+// like the snapshot caches. The memory is accounted against the budget, which
+// may cause the evictable caches to be shrunk, but is never evicted itself.
+func (b *MemoryBudget) Set(name string, size uint64) {
+	b.lock.Lock()
+	var account *memoryAccount
+	for _, acct := range b.accounts {
+		if acct.name == name {
+			account = acct
+			break
+		}
+	}
+	b.lock.Unlock()
+
+	if account == nil {
+		account = b.register(name, -1, nil)
+	}
+	account.track(size)
+}
+
+// Used returns the total memory accounted against the budget.
+func (b *MemoryBudget) Used() uint64 {
+	return uint64(b.used.Load())
+}
+
+// Usage returns the memory used by each consumer of the budget.
+func (b *MemoryBudget) Usage() map[string]uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	usage := make(map[string]uint64, len(b.accounts))
+	for _, account := range b.accounts {
+		usage[account.name] = account.size.Load()
+	}
+	return usage
+}
+
+// enforce shrinks the evictable caches, lowest priority first, until the total
+// memory used fits the budget. If the budget is already being enforced by
+// another goroutine, it returns right away.
+func (b *MemoryBudget) enforce() {
+	if !b.lock.TryLock() {
+		return
+	}
+	defer b.lock.Unlock()
+
+	for _, account := range b.accounts {
+		used := uint64(b.used.Load())
+		if used <= b.limit {
+			break
+		}
+		if account.shrink == nil || account.size.Load() == 0 {
+			continue
+		}
+		before := account.size.Load()
+		after := account.shrink(used - b.limit)
+		account.set(after)
+		if after < before {
+			memoryBudgetEvictMeter.Mark(int64(before - after))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+func TestMemoryBudgetPriorities(t *testing.T) {
+	budget := NewMemoryBudget(100)
+
+	sizes := map[string]uint64{"low": 40, "high": 40}
+	shrink := func(name string) func(uint64) uint64 {
+		return func(bytes uint64) uint64 {
+			sizes[name] -= min(bytes, sizes[name])
+			return sizes[name]
+		}
+	}
+	high := budget.register("high", 1, shrink("high"))
+	low := budget.register("low", 0, shrink("low"))
+	high.track(sizes["high"])
+	low.track(sizes["low"])
+
+	if used := budget.Used(); used != 80 {
+		t.Fatalf("used memory mismatch: have %d, want 80", used)
+	}
+	// Exceeding the budget evicts the lowest priority consumer first
+	budget.Set("fixed", 50)
+	if sizes["low"] != 10 || sizes["high"] != 40 {
+		t.Fatalf("unexpected eviction: low %d, high %d", sizes["low"], sizes["high"])
+	}
+	// Then the higher priority consumers once the lower ones are empty
+	budget.Set("fixed", 80)
+	if sizes["low"] != 0 || sizes["high"] != 20 {
+		t.Fatalf("unexpected eviction: low %d, high %d", sizes["low"], sizes["high"])
+	}
+	usage := budget.Usage()
+	if usage["fixed"] != 80 || usage["low"] != 0 || usage["high"] != 20 {
+		t.Fatalf("usage mismatch: %v", usage)
+	}
+	// Consumers not evictable are never shrunk
+	budget.Set("fixed", 150)
+	if usage := budget.Usage(); usage["fixed"] != 150 || budget.Used() != 150 {
+		t.Fatalf("fixed consumer shrunk: %v", usage)
+	}
+}
+
+func TestMemoryBudgetCaches(t *testing.T) {
+	PurgeStorageCache()
+	defer globalStorageCache.budget.Store(nil)
+
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		budget = NewMemoryBudget(1024)
+		db     = NewDatabaseWithNodeDBAndBudget(diskdb, triedb.NewDatabase(diskdb, nil), 1024, budget).(*cachingDB)
+	)
+	for i := byte(1); i <= 4; i++ {
+		code := make([]byte, 200)
+		code[0] = i
+		hash := common.BytesToHash([]byte{i})
+		rawdb.WriteCode(diskdb, hash, code)
+		if _, err := db.ContractCode(common.Address{}, hash); err != nil {
+			t.Fatalf("failed to read code: %v", err)
+		}
+	}
+	rawdb.WriteActivatedAsm(diskdb, rawdb.TargetWavm, common.Hash{1}, make([]byte, 300))
+	if _, err := db.ActivatedAsm(rawdb.TargetWavm, common.Hash{1}); err != nil {
+		t.Fatalf("failed to read asm: %v", err)
+	}
+	// The code cache is evicted before the asm cache
+	if used := budget.Used(); used > 1024 {
+		t.Fatalf("budget exceeded: %d", used)
+	}
+	if size := db.codeCache.Size(); size != 600 {
+		t.Errorf("code cache size mismatch: have %d, want 600", size)
+	}
+	if !db.activatedAsmCache.contains(rawdb.TargetWavm, common.Hash{1}) {
+		t.Error("asm evicted before code")
+	}
+	if _, ok := db.codeCache.Get(common.BytesToHash([]byte{1})); ok {
+		t.Error("least recently used code not evicted")
+	}
+}
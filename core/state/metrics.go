@@ -33,4 +33,23 @@ var (
 	slotDeletionTimer    = metrics.NewRegisteredResettingTimer("state/delete/storage/timer", nil)
 	slotDeletionCount    = metrics.NewRegisteredMeter("state/delete/storage/slot", nil)
 	slotDeletionSize     = metrics.NewRegisteredMeter("state/delete/storage/size", nil)
+
+	// Arbitrum: process-wide account and storage slot cache
+	storageCacheAccountHitMeter  = metrics.NewRegisteredMeter("state/cache/account/hit", nil)
+	storageCacheAccountMissMeter = metrics.NewRegisteredMeter("state/cache/account/miss", nil)
+	storageCacheSlotHitMeter     = metrics.NewRegisteredMeter("state/cache/storage/hit", nil)
+	storageCacheSlotMissMeter    = metrics.NewRegisteredMeter("state/cache/storage/miss", nil)
+
+	// Arbitrum: activated Stylus asm cache
+	asmCacheHitMeter      = metrics.NewRegisteredMeter("state/cache/asm/hit", nil)
+	asmCacheMissMeter     = metrics.NewRegisteredMeter("state/cache/asm/miss", nil)
+	asmCacheEvictMeter    = metrics.NewRegisteredMeter("state/cache/asm/evict", nil)
+	asmCachePrefetchMeter = metrics.NewRegisteredMeter("state/cache/asm/prefetch", nil)
+	asmCacheSizeGauge     = metrics.NewRegisteredGauge("state/cache/asm/size", nil)
+
+	// Arbitrum: journal activity per transaction
+	journalEntriesHist     = metrics.NewRegisteredHistogram("state/journal/tx/entries", nil, metrics.NewExpDecaySample(1028, 0.015))
+	journalSnapshotsHist   = metrics.NewRegisteredHistogram("state/journal/tx/snapshots", nil, metrics.NewExpDecaySample(1028, 0.015))
+	journalRevertsHist     = metrics.NewRegisteredHistogram("state/journal/tx/reverts", nil, metrics.NewExpDecaySample(1028, 0.015))
+	journalRevertDepthHist = metrics.NewRegisteredHistogram("state/journal/revert/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
 )
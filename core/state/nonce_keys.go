@@ -0,0 +1,73 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// NonceKeysAddress is the system account whose storage holds the nonces of the
+// non-zero nonce keys of all accounts, keeping them out of the storage of the
+// accounts themselves.
+//
+// The address is reserved on the chains enabling the nonce keys. Like the ArbOS
+// precompiles and ArbosAddress (0xA4B05), it sits in the low range of addresses
+// no key is known for, and no precompile is installed at it, so its storage is
+// only ever written through SetKeyedNonce.
+var NonceKeysAddress = common.HexToAddress("0x000000000000000000000000000000000000A4B1")
+
+// nonceKeySlot returns the storage slot of the nonce of the given account and
+// nonce key, within the storage of NonceKeysAddress.
+func nonceKeySlot(addr common.Address, key *uint256.Int) common.Hash {
+	k := key.Bytes32()
+	return crypto.Keccak256Hash(addr.Bytes(), k[:])
+}
+
+// GetKeyedNonce retrieves the nonce of the given account in the lane of the
+// given nonce key, or 0 if the lane was never used. The zero key is the
+// regular account nonce.
+func (s *StateDB) GetKeyedNonce(addr common.Address, key *uint256.Int) uint64 {
+	if key.IsZero() {
+		return s.GetNonce(addr)
+	}
+	value := s.GetState(NonceKeysAddress, nonceKeySlot(addr, key))
+	return binary.BigEndian.Uint64(value[common.HashLength-8:])
+}
+
+// SetKeyedNonce sets the nonce of the given account in the lane of the given
+// nonce key. The zero key is the regular account nonce.
+//
+// The first write to a non-zero lane also sets the nonce of NonceKeysAddress
+// to 1. Without code nor balance the account would otherwise be empty, and
+// deleted along with all the lanes when touched after EIP-158. The nonce stays
+// at 1 from then on, and is part of the state root like any other.
+func (s *StateDB) SetKeyedNonce(addr common.Address, key *uint256.Int, nonce uint64) {
+	if key.IsZero() {
+		s.SetNonce(addr, nonce)
+		return
+	}
+	if s.GetNonce(NonceKeysAddress) == 0 {
+		s.SetNonce(NonceKeysAddress, 1)
+	}
+	var value common.Hash
+	binary.BigEndian.PutUint64(value[common.HashLength-8:], nonce)
+	s.SetState(NonceKeysAddress, nonceKeySlot(addr, key), value)
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestKeyedNonces(t *testing.T) {
+	var (
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		state, _ = New(types.EmptyRootHash, db, nil)
+		first    = common.HexToAddress("0xaa")
+		second   = common.HexToAddress("0xbb")
+		key      = uint256.NewInt(7)
+	)
+	state.SetKeyedNonce(first, new(uint256.Int), 3)
+	state.SetKeyedNonce(first, key, 5)
+	state.SetKeyedNonce(second, key, 9)
+
+	if have := state.GetNonce(first); have != 3 {
+		t.Fatalf("zero key not aliasing the account nonce: have %d, want 3", have)
+	}
+	if have := state.GetKeyedNonce(first, uint256.NewInt(8)); have != 0 {
+		t.Fatalf("unused lane nonce: have %d, want 0", have)
+	}
+	root, err := state.Commit(0, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db, nil)
+	if have := state.GetKeyedNonce(first, key); have != 5 {
+		t.Fatalf("first account lane nonce: have %d, want 5", have)
+	}
+	if have := state.GetKeyedNonce(second, key); have != 9 {
+		t.Fatalf("second account lane nonce: have %d, want 9", have)
+	}
+	// The lanes must be kept out of the account storage
+	if state.GetStorageRoot(first) != types.EmptyRootHash {
+		t.Fatal("lane nonce written to the account storage")
+	}
+	// The system account is kept alive by its nonce
+	if have := state.GetNonce(NonceKeysAddress); have != 1 {
+		t.Fatalf("system account nonce: have %d, want 1", have)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// TxAccessSet is the footprint of a single transaction executed on a
+// speculative copy of the state: the accounts and slots it read, and the
+// values it left in the accounts and slots it wrote. It is used by optimistic
+// parallel execution to validate a speculative run against the transactions
+// committed before it, and to replay the run onto the canonical state.
+type TxAccessSet struct {
+	reads    *accessList
+	accounts map[common.Address]*accountWrite
+	slots    map[common.Address]map[common.Hash]common.Hash
+	logs     []*types.Log
+	delta    *big.Int // Change of the unexpected balance delta over the transaction
+
+	// opaque is set if the transaction made changes that can't be replayed by
+	// value, such as contract deployments, self-destructs or wasm activations,
+	// or if it ran any Stylus program: the pages, the recent programs and the
+	// recorded modules are tracked per block, outside of the journal. Accounts
+	// created by value transfers are replayed by value, as setting their
+	// balance and nonce recreates them.
+	opaque bool
+}
+
+// stylusMark is the Stylus bookkeeping of the state at some point, compared
+// across a transaction to tell whether it ran any program.
+type stylusMark struct {
+	stats       types.StylusStats
+	open, ever  uint16
+	wasms       int // Number of recorded modules
+	recentWasms uint64
+}
+
+// stylusMark returns the current Stylus bookkeeping of the state.
+func (s *StateDB) stylusMark() stylusMark {
+	mark := stylusMark{
+		stats: s.arbExtraData.stylusStats,
+		open:  s.arbExtraData.openWasmPages,
+		ever:  s.arbExtraData.everWasmPages,
+		wasms: len(s.arbExtraData.userWasms),
+	}
+	if recent := s.arbExtraData.recentWasms; recent.state != nil {
+		mark.recentWasms = recent.state.clock
+	}
+	return mark
+}
+
+// accountWrite is the post-transaction value of a mutated account, or the
+// amount it was credited with if the transaction never read it.
+type accountWrite struct {
+	balance *uint256.Int
+	nonce   uint64
+	code    []byte       // Nil unless the code was changed
+	credit  *uint256.Int // Non-nil if the account was only credited
+}
+
+// Opaque reports whether the transaction made changes that can only be
+// reproduced by executing it again on the canonical state.
+func (a *TxAccessSet) Opaque() bool {
+	return a.opaque
+}
+
+// Conflicts reports whether the transaction read any account or slot that was
+// written by the transactions accumulated in the given write set.
+func (a *TxAccessSet) Conflicts(w *WriteSet) bool {
+	for addr, idx := range a.reads.addresses {
+		if _, ok := w.accounts[addr]; ok {
+			return true
+		}
+		written := w.slots[addr]
+		if idx < 0 || len(written) == 0 {
+			continue
+		}
+		for slot := range a.reads.slots[idx] {
+			if _, ok := written[slot]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteSet accumulates the accounts and slots written by a sequence of
+// transactions.
+type WriteSet struct {
+	accounts map[common.Address]struct{}
+	slots    map[common.Address]map[common.Hash]struct{}
+}
+
+// NewWriteSet creates an empty write set.
+func NewWriteSet() *WriteSet {
+	return &WriteSet{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// Add merges the writes of the given transaction into the set.
+func (w *WriteSet) Add(a *TxAccessSet) {
+	for addr := range a.accounts {
+		w.accounts[addr] = struct{}{}
+	}
+	for addr, slots := range a.slots {
+		if w.slots[addr] == nil {
+			w.slots[addr] = make(map[common.Hash]struct{}, len(slots))
+		}
+		for slot := range slots {
+			w.slots[addr][slot] = struct{}{}
+		}
+	}
+}
+
+// BeginTxAccess starts recording the footprint of the next transaction. Reads
+// are collected from this point on, writes are collected from the journal.
+// Accounts that are only credited, like the recipient of the fees, are not
+// counted as read, as the outcome of a credit doesn't depend on the balance.
+func (s *StateDB) BeginTxAccess() {
+	s.txAccessOuter = s.blockAccessList
+	s.blockAccessList = newAccessList()
+	s.txBlindCredits = make(map[common.Address]struct{})
+	s.txAccessMark = s.journal.length()
+	s.txAccessDelta = new(big.Int).Set(s.arbExtraData.unexpectedBalanceDelta)
+	s.txAccessStylus = s.stylusMark()
+}
+
+// EndTxAccess stops the recording and returns the footprint of the transaction
+// executed since the last call to BeginTxAccess. It must be called before the
+// state is finalised, as the writes are collected from the journal.
+func (s *StateDB) EndTxAccess() *TxAccessSet {
+	// Collecting the written values reads the state, don't track it
+	reads := s.blockAccessList
+	s.blockAccessList = newAccessList()
+
+	a := &TxAccessSet{
+		reads:    reads,
+		accounts: make(map[common.Address]*accountWrite),
+		slots:    make(map[common.Address]map[common.Hash]common.Hash),
+		logs:     s.logs[s.thash],
+		delta:    new(big.Int).Sub(s.arbExtraData.unexpectedBalanceDelta, s.txAccessDelta),
+		opaque:   s.arbExtraData.arbTxFilter || s.stylusMark() != s.txAccessStylus,
+	}
+	origins := make(map[common.Address]*uint256.Int)
+	for _, entry := range s.journal.entries[s.txAccessMark:] {
+		switch ch := entry.(type) {
+		case balanceChange:
+			if _, ok := origins[*ch.account]; !ok {
+				origins[*ch.account] = ch.prev
+			}
+			a.writeAccount(s, *ch.account)
+		case createObjectChange, nonceChange, touchChange:
+			// Fresh accounts are fully described by their balance, nonce and
+			// code, deployments are opaque through createContractChange
+			a.writeAccount(s, *ch.dirtied())
+		case codeChange:
+			a.writeAccount(s, *ch.account).code = s.GetCode(*ch.account)
+		case storageChange:
+			if a.slots[*ch.account] == nil {
+				a.slots[*ch.account] = make(map[common.Hash]common.Hash)
+			}
+			a.slots[*ch.account][ch.key] = s.GetState(*ch.account, ch.key)
+		case refundChange, addLogChange, addPreimageChange, accessListAddAccountChange, accessListAddSlotChange, transientStorageChange, balanceDeltaChange, selfDestructRecordChange:
+			// Transaction scoped, nothing to replay
+		default:
+			a.opaque = true
+		}
+	}
+	// Accounts that were credited but never read are replayed as credits, so
+	// that fee payments to the same recipient don't conflict with each other
+	for addr := range s.txBlindCredits {
+		w, ok := a.accounts[addr]
+		if !ok || reads.ContainsAddress(addr) {
+			continue
+		}
+		w.credit = new(uint256.Int)
+		if origin, ok := origins[addr]; ok {
+			w.credit.Sub(w.balance, origin)
+		}
+	}
+	s.blockAccessList = s.txAccessOuter
+	for addr := range s.txBlindCredits {
+		s.blockAccessList.AddAddress(addr)
+	}
+	s.txAccessOuter, s.txBlindCredits = nil, nil
+	s.mergeReads(a)
+	return a
+}
+
+// creditsBlindly reports whether crediting the given account doesn't need to
+// be recorded as a read of the current transaction.
+func (s *StateDB) creditsBlindly(addr common.Address) bool {
+	return s.txBlindCredits != nil && !s.blockAccessList.ContainsAddress(addr)
+}
+
+// forgetCreditRead drops the read of an account done only to credit it.
+func (s *StateDB) forgetCreditRead(addr common.Address) {
+	delete(s.blockAccessList.addresses, addr)
+	s.txBlindCredits[addr] = struct{}{}
+}
+
+// writeAccount records the current value of the given account as written.
+func (a *TxAccessSet) writeAccount(s *StateDB, addr common.Address) *accountWrite {
+	if w, ok := a.accounts[addr]; ok {
+		return w
+	}
+	w := &accountWrite{
+		balance: s.GetBalance(addr).Clone(),
+		nonce:   s.GetNonce(addr),
+	}
+	a.accounts[addr] = w
+	return w
+}
+
+// ApplyTxAccess replays the writes and logs of a speculatively executed
+// transaction onto the state, as if it had been executed on it. The caller
+// is responsible for validating the footprint against the transactions
+// applied since the speculation, and for setting the transaction context.
+func (s *StateDB) ApplyTxAccess(a *TxAccessSet) {
+	delta := new(big.Int).Add(s.arbExtraData.unexpectedBalanceDelta, a.delta)
+	for addr, w := range a.accounts {
+		if w.credit != nil {
+			s.AddBalance(addr, w.credit, tracing.BalanceChangeUnspecified)
+			continue
+		}
+		s.SetBalance(addr, w.balance, tracing.BalanceChangeUnspecified)
+		s.SetNonce(addr, w.nonce)
+		if w.code != nil {
+			s.SetCode(addr, w.code)
+		}
+	}
+	for addr, slots := range a.slots {
+		for slot, value := range slots {
+			s.SetState(addr, slot, value)
+		}
+	}
+	// Replaying by value skews the balance delta, which must match the run
+	if skew := new(big.Int).Sub(delta, s.arbExtraData.unexpectedBalanceDelta); skew.Sign() != 0 {
+		s.journal.append(balanceDeltaChange{amount: skew})
+	}
+	s.arbExtraData.unexpectedBalanceDelta = delta
+
+	for _, l := range a.logs {
+		s.AddLog(&types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data, BlockNumber: l.BlockNumber})
+	}
+	s.mergeReads(a)
+}
+
+// mergeReads adds the reads of the given transaction to the block access list.
+func (s *StateDB) mergeReads(a *TxAccessSet) {
+	for addr, idx := range a.reads.addresses {
+		s.blockAccessList.AddAddress(addr)
+		if idx >= 0 {
+			for slot := range a.reads.slots[idx] {
+				s.blockAccessList.AddSlot(addr, slot)
+			}
+		}
+	}
+}
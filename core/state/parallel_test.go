@@ -0,0 +1,126 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestTxAccessSet(t *testing.T) {
+	var (
+		coinbase = common.HexToAddress("0xc0")
+		contract = common.HexToAddress("0xcc")
+		other    = common.HexToAddress("0xaa")
+		slot     = common.HexToHash("0x01")
+	)
+	base, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	base.SetBalance(coinbase, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	base.SetBalance(other, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	base.SetState(contract, slot, common.HexToHash("0x01"))
+	base.SetNonce(contract, 1)
+	base.Finalise(true)
+
+	run := func(fn func(s *StateDB)) *TxAccessSet {
+		s := base.Copy()
+		s.BeginTxAccess()
+		fn(s)
+		return s.EndTxAccess()
+	}
+	var (
+		writer = run(func(s *StateDB) {
+			s.SetState(contract, slot, common.HexToHash("0x02"))
+			s.AddBalance(coinbase, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+		})
+		slotReader = run(func(s *StateDB) {
+			s.GetState(contract, slot)
+			s.AddBalance(coinbase, uint256.NewInt(2), tracing.BalanceChangeUnspecified)
+		})
+		crediter = run(func(s *StateDB) {
+			s.SubBalance(other, uint256.NewInt(10), tracing.BalanceChangeUnspecified)
+			s.AddBalance(coinbase, uint256.NewInt(3), tracing.BalanceChangeUnspecified)
+		})
+		balanceReader = run(func(s *StateDB) {
+			s.GetBalance(coinbase)
+		})
+	)
+	written := NewWriteSet()
+	written.Add(writer)
+
+	if !slotReader.Conflicts(written) {
+		t.Error("slot read not conflicting with slot write")
+	}
+	if crediter.Conflicts(written) {
+		t.Error("fee credits conflicting with each other")
+	}
+	if !balanceReader.Conflicts(written) {
+		t.Error("balance read not conflicting with credit")
+	}
+	// Replaying the credits must accumulate them over the current balance
+	state := base.Copy()
+	state.ApplyTxAccess(writer)
+	state.ApplyTxAccess(crediter)
+	state.Finalise(true)
+
+	if have, want := state.GetBalance(coinbase), uint256.NewInt(104); !have.Eq(want) {
+		t.Errorf("coinbase balance mismatch: have %v, want %v", have, want)
+	}
+	if have, want := state.GetBalance(other), uint256.NewInt(90); !have.Eq(want) {
+		t.Errorf("sender balance mismatch: have %v, want %v", have, want)
+	}
+	if have, want := state.GetState(contract, slot), common.HexToHash("0x02"); have != want {
+		t.Errorf("slot mismatch: have %x, want %x", have, want)
+	}
+	if !state.blockAccessList.ContainsAddress(coinbase) {
+		t.Error("credited account missing from the block access list")
+	}
+	// Accounts created by transfers are replayed by value, program calls
+	// through the recent programs cache can't be
+	fresh := common.HexToAddress("0xff")
+	transfer := run(func(s *StateDB) {
+		s.SubBalance(other, uint256.NewInt(5), tracing.BalanceChangeUnspecified)
+		s.AddBalance(fresh, uint256.NewInt(5), tracing.BalanceChangeUnspecified)
+	})
+	if transfer.Opaque() {
+		t.Error("transfer to a new account is opaque")
+	}
+	state.ApplyTxAccess(transfer)
+	state.Finalise(true)
+	if have, want := state.GetBalance(fresh), uint256.NewInt(5); !have.Eq(want) {
+		t.Errorf("created account balance mismatch: have %v, want %v", have, want)
+	}
+	// Program calls are tracked per block outside of the journal, and can't be
+	// replayed by value
+	for name, call := range map[string]func(s *StateDB){
+		"pages":  func(s *StateDB) { s.AddStylusPages(2) },
+		"call":   func(s *StateDB) { s.RecordStylusCall(common.HexToHash("0x01"), 100) },
+		"recent": func(s *StateDB) { s.GetRecentWasms().Insert(common.HexToHash("0x01"), 4) },
+	} {
+		if name == "recent" {
+			base.SetRecentWasmsConfig(&params.RecentWasmsConfig{Capacity: 4})
+		}
+		if !run(call).Opaque() {
+			t.Errorf("program %s not opaque", name)
+		}
+	}
+}
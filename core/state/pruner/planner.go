@@ -0,0 +1,358 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// planBloomFileName is the filename of the bloom filter holding the
+	// entries of the retained states of a pruning plan.
+	planBloomFileName = "pruneplan.bf"
+
+	// planCheckpointFileName is the filename of the checkpoint of a pruning
+	// plan, used to resume an interrupted run.
+	planCheckpointFileName = "pruneplan.json"
+
+	// planCheckpointInterval is the minimal time between two checkpoints
+	// while scanning the database.
+	planCheckpointInterval = 30 * time.Second
+
+	// defaultPlanRetain is the number of recent state roots retained if
+	// none is configured.
+	defaultPlanRetain = 128
+)
+
+// PlanConfig includes the configurations for planning a pruning run.
+type PlanConfig struct {
+	Config
+	Retain uint64 // The number of most recent canonical state roots to retain
+	DryRun bool   // If set, reclaimable space is only reported and nothing is deleted
+}
+
+// PlanReport summarizes the state entries found by a pruning plan.
+type PlanReport struct {
+	Roots       []common.Hash      `json:"roots"`       // State roots retained, including genesis
+	StaleNodes  uint64             `json:"staleNodes"`  // Number of trie nodes not reachable from the retained roots
+	StaleCodes  uint64             `json:"staleCodes"`  // Number of contract codes not referenced by the retained roots
+	Retained    uint64             `json:"retained"`    // Number of state entries retained
+	Reclaimable common.StorageSize `json:"reclaimable"` // Size of the stale entries
+	Deleted     bool               `json:"deleted"`     // Whether the stale entries were deleted
+}
+
+// planCheckpoint is the persisted progress of a pruning plan.
+type planCheckpoint struct {
+	Head     common.Hash   `json:"head"`     // Head block the retained roots were selected from
+	Done     []common.Hash `json:"done"`     // Roots already committed to the bloom filter
+	Scanning bool          `json:"scanning"` // Whether the bloom filter is complete and the database is being scanned
+	DryRun   bool          `json:"dryRun"`   // Whether the scan is a dry run
+	Next     hexutil.Bytes `json:"next"`     // Database key to resume the scan from
+	Report   PlanReport    `json:"report"`   // Statistics of the scan so far
+}
+
+// PlanPruning determines the trie nodes and contract codes of the hash-based
+// state database which are not reachable from the last config.Retain canonical
+// state roots nor from the genesis state, and reports the space they occupy. If
+// config.DryRun isn't set, the stale entries are deleted as well.
+//
+// The progress is checkpointed into the datadir, so that an interrupted run
+// picks up where it left off. Once deletion has started, the run must be
+// resumed and completed before the node can be started again.
+func PlanPruning(db ethdb.Database, config PlanConfig) (*PlanReport, error) {
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		return nil, fmt.Errorf("pruning plan is not supported for %q state scheme", scheme)
+	}
+	headBlock := rawdb.ReadHeadBlock(db)
+	if headBlock == nil {
+		return nil, errors.New("failed to load head block")
+	}
+	// Sanitize the configs, same as for the regular pruner.
+	if config.Retain == 0 {
+		config.Retain = defaultPlanRetain
+	}
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	if config.Threads <= 0 {
+		config.Threads = 1
+	}
+	var (
+		bloomPath      = filepath.Join(config.Datadir, planBloomFileName)
+		checkpointPath = filepath.Join(config.Datadir, planCheckpointFileName)
+	)
+	checkpoint, err := readPlanCheckpoint(checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint != nil {
+		switch {
+		case checkpoint.Scanning && !checkpoint.DryRun:
+			// Deletion has started, it has to be completed with the same bloom
+			log.Info("Resuming interrupted state pruning", "next", checkpoint.Next)
+			if config.DryRun {
+				log.Warn("Ignoring dry run, interrupted pruning has to be completed")
+				config.DryRun = false
+			}
+		case checkpoint.Head != headBlock.Hash():
+			// The chain progressed since, the bloom filter is outdated
+			log.Info("Discarding outdated pruning plan", "head", checkpoint.Head)
+			checkpoint = nil
+		case checkpoint.DryRun != config.DryRun:
+			// The bloom filter can be reused, but the scan has to be redone
+			checkpoint.DryRun = config.DryRun
+			checkpoint.Next = nil
+			checkpoint.Report = PlanReport{Roots: checkpoint.Report.Roots}
+		}
+	}
+	if checkpoint == nil {
+		roots, err := retainedRoots(db, headBlock.NumberU64(), config.Retain)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint = &planCheckpoint{
+			Head:   headBlock.Hash(),
+			DryRun: config.DryRun,
+			Report: PlanReport{Roots: roots},
+		}
+		os.RemoveAll(bloomPath)
+	}
+	// Build the bloom filter of the retained states, or resume building it.
+	var bloom *stateBloom
+	if len(checkpoint.Done) > 0 {
+		bloom, checkpoint.Done, err = NewStateBloomFromDisk(bloomPath)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Loaded pruning plan bloom filter", "path", bloomPath, "roots", checkpoint.Done)
+	} else {
+		if bloom, err = newStateBloomWithSize(config.BloomSize); err != nil {
+			return nil, err
+		}
+	}
+	if !checkpoint.Scanning {
+		done := make(map[common.Hash]struct{})
+		for _, root := range checkpoint.Done {
+			done[root] = struct{}{}
+		}
+		for _, root := range checkpoint.Report.Roots {
+			if _, ok := done[root]; ok {
+				continue
+			}
+			log.Info("Building bloom filter for pruning plan", "root", root)
+			if err := dumpRawTrieDescendants(db, root, bloom, &config.Config); err != nil {
+				return nil, err
+			}
+			checkpoint.Done = append(checkpoint.Done, root)
+			if err := bloom.Commit(bloomPath, bloomPath+stateBloomFileTempSuffix, checkpoint.Done); err != nil {
+				return nil, err
+			}
+			if err := writePlanCheckpoint(checkpointPath, checkpoint); err != nil {
+				return nil, err
+			}
+		}
+		checkpoint.Scanning = true
+		if err := writePlanCheckpoint(checkpointPath, checkpoint); err != nil {
+			return nil, err
+		}
+	}
+	report, err := scanStaleEntries(db, bloom, checkpoint, checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if config.DryRun {
+		// Keep the bloom filter around, a subsequent run may reuse it
+		log.Info("State pruning plan complete", "roots", len(report.Roots), "staleNodes", report.StaleNodes,
+			"staleCodes", report.StaleCodes, "retained", report.Retained, "reclaimable", report.Reclaimable)
+		return report, nil
+	}
+	// Clean up any false positives that are top-level state roots.
+	if err := removeOtherRoots(db, report.Roots, bloom, config.Threads); err != nil {
+		return nil, err
+	}
+	// The deletion is done, drop the plan so the next one starts afresh.
+	os.RemoveAll(checkpointPath)
+	os.RemoveAll(bloomPath)
+
+	// Start compactions, will remove the deleted data from the disk immediately.
+	// Note for small pruning, the compaction is skipped.
+	if report.StaleNodes+report.StaleCodes >= rangeCompactionThreshold {
+		if err := compactDatabase(db); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("State pruning successful", "roots", len(report.Roots), "pruned", report.Reclaimable)
+	return report, nil
+}
+
+// retainedRoots returns the last retain distinct state roots present in the
+// database along the canonical chain, followed by the genesis state root.
+func retainedRoots(db ethdb.Database, head uint64, retain uint64) ([]common.Hash, error) {
+	genesisHash := rawdb.ReadCanonicalHash(db, 0)
+	if genesisHash == (common.Hash{}) {
+		return nil, errors.New("missing genesis hash")
+	}
+	genesis := rawdb.ReadHeader(db, genesisHash, 0)
+	if genesis == nil {
+		return nil, errors.New("missing genesis header")
+	}
+	var (
+		roots []common.Hash
+		seen  = map[common.Hash]struct{}{genesis.Root: {}}
+	)
+	for number := head; number > 0 && uint64(len(roots)) < retain; number-- {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, number), number)
+		if header == nil {
+			return nil, fmt.Errorf("missing canonical header %d", number)
+		}
+		if _, ok := seen[header.Root]; ok {
+			continue
+		}
+		seen[header.Root] = struct{}{}
+		if rawdb.HasLegacyTrieNode(db, header.Root) {
+			roots = append(roots, header.Root)
+		}
+	}
+	return append(roots, genesis.Root), nil
+}
+
+// scanStaleEntries iterates the database from the checkpointed position and
+// accounts for all the trie nodes and contract codes not contained in the
+// bloom filter, deleting them unless the plan is a dry run.
+func scanStaleEntries(db ethdb.Database, bloom *stateBloom, checkpoint *planCheckpoint, checkpointPath string) (*PlanReport, error) {
+	// A scan from the start discards the statistics of any previous one
+	if len(checkpoint.Next) == 0 {
+		checkpoint.Report = PlanReport{Roots: checkpoint.Report.Roots}
+	}
+	var (
+		report = &checkpoint.Report
+		start  = time.Now()
+		logged = time.Now()
+		saved  = time.Now()
+		batch  = db.NewBatch()
+		iter   = db.NewIterator(nil, checkpoint.Next)
+	)
+	defer func() { iter.Release() }()
+
+	for iter.Next() {
+		key := iter.Key()
+
+		// Code size index entries share the fate of the code they describe.
+		isCode, codeKey := rawdb.IsCodeKey(key)
+		if !isCode {
+			isCode, codeKey = rawdb.IsCodeSizeKey(key)
+		}
+		if len(key) != common.HashLength && !isCode {
+			continue
+		}
+		checkKey := key
+		if isCode {
+			checkKey = codeKey
+		}
+		if bloom.Contain(checkKey) {
+			report.Retained++
+			continue
+		}
+		if isCode {
+			report.StaleCodes++
+		} else {
+			report.StaleNodes++
+		}
+		report.Reclaimable += common.StorageSize(len(key) + len(iter.Value()))
+		if !checkpoint.DryRun {
+			batch.Delete(key)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Scanning stale state data", "nodes", report.StaleNodes, "codes", report.StaleCodes,
+				"retained", report.Retained, "size", report.Reclaimable, "dryrun", checkpoint.DryRun,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		// Flush the deletions and checkpoint the progress. The iterator is
+		// recreated after every batch commit in order to allow the underlying
+		// compactor to delete the entries.
+		if batch.ValueSize() >= ethdb.IdealBatchSize || (checkpoint.DryRun && time.Since(saved) > planCheckpointInterval) {
+			if err := batch.Write(); err != nil {
+				return nil, err
+			}
+			batch.Reset()
+
+			checkpoint.Next = common.CopyBytes(key)
+			if err := writePlanCheckpoint(checkpointPath, checkpoint); err != nil {
+				return nil, err
+			}
+			saved = time.Now()
+
+			iter.Release()
+			iter = db.NewIterator(nil, key)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if batch.ValueSize() > 0 {
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+	}
+	report.Deleted = !checkpoint.DryRun
+	checkpoint.Next = nil
+	if err := writePlanCheckpoint(checkpointPath, checkpoint); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// readPlanCheckpoint loads the checkpoint of a pruning plan, returning nil if
+// no plan is in progress.
+func readPlanCheckpoint(path string) (*planCheckpoint, error) {
+	blob, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var checkpoint planCheckpoint
+	if err := json.Unmarshal(blob, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid pruning plan checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// writePlanCheckpoint atomically persists the checkpoint of a pruning plan.
+func writePlanCheckpoint(path string, checkpoint *planCheckpoint) error {
+	blob, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+stateBloomFileTempSuffix, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+stateBloomFileTempSuffix, path)
+}
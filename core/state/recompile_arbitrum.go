@@ -0,0 +1,232 @@
+package state
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Compiles the asm of an activated Stylus module for the given target, from the asm already stored
+// for the other targets
+var RecompileWasmRust func(target ethdb.WasmTarget, moduleHash common.Hash, activated map[ethdb.WasmTarget][]byte) ([]byte, error)
+
+// recompileBatchSize is the number of queued compilations loaded at once
+const recompileBatchSize = 128
+
+// RecompileStatus describes the backlog of the asm recompiler
+type RecompileStatus struct {
+	Running   bool   // Whether the recompiler is processing the backlog
+	Pending   uint64 // Number of compilations waiting in the queue
+	Compiled  uint64 // Number of compilations completed since startup
+	Failed    uint64 // Number of compilations failed since startup
+	LastError string // Error of the last failed compilation
+}
+
+// AsmRecompiler backfills the wasm store with the asm of activated Stylus modules
+// for additional targets, e.g. after migrating a node to another architecture.
+// The compilations are queued in the wasm store, so that they survive restarts,
+// and processed in the background.
+type AsmRecompiler struct {
+	db      ethdb.KeyValueStore // Wasm store to backfill
+	trigger chan struct{}       // Channel to wake up the processing
+	quit    chan struct{}
+	done    chan struct{}
+
+	status RecompileStatus
+	lock   sync.Mutex
+}
+
+// NewAsmRecompiler creates a recompiler of the given wasm store and starts
+// processing the queued compilations.
+func NewAsmRecompiler(db ethdb.KeyValueStore) *AsmRecompiler {
+	r := &AsmRecompiler{
+		db:      db,
+		trigger: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// Close stops the recompiler. The remaining compilations are resumed when the
+// recompiler is restarted.
+func (r *AsmRecompiler) Close() {
+	close(r.quit)
+	<-r.done
+}
+
+// Enqueue queues the compilation of the given modules for every target missing
+// from the wasm store, or of all the activated modules if none is given.
+// It returns the number of compilations queued.
+func (r *AsmRecompiler) Enqueue(targets []ethdb.WasmTarget, moduleHashes []common.Hash) (uint64, error) {
+	for _, target := range targets {
+		if !rawdb.IsSupportedWasmTarget(target) {
+			return 0, errors.New("unsupported target " + string(target))
+		}
+	}
+	if len(moduleHashes) == 0 {
+		found := make(map[common.Hash]struct{})
+		for _, target := range rawdb.AllWasmTargets() {
+			hashes, err := rawdb.ReadActivatedModuleHashes(r.db, target, common.Hash{}, math.MaxInt)
+			if err != nil {
+				return 0, err
+			}
+			for _, hash := range hashes {
+				if _, ok := found[hash]; !ok {
+					found[hash] = struct{}{}
+					moduleHashes = append(moduleHashes, hash)
+				}
+			}
+		}
+	}
+	var (
+		batch  = r.db.NewBatch()
+		queued uint64
+	)
+	for _, moduleHash := range moduleHashes {
+		var activated bool
+		for _, target := range rawdb.AllWasmTargets() {
+			if len(rawdb.ReadActivatedAsm(r.db, target, moduleHash)) > 0 {
+				activated = true
+				break
+			}
+		}
+		if !activated {
+			continue
+		}
+		for _, target := range targets {
+			if len(rawdb.ReadActivatedAsm(r.db, target, moduleHash)) > 0 {
+				continue
+			}
+			rawdb.WriteRecompileRequest(batch, moduleHash, target)
+			queued++
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	if queued > 0 {
+		log.Info("Queued Stylus asm recompilation", "targets", targets, "compilations", queued)
+		select {
+		case r.trigger <- struct{}{}:
+		default:
+		}
+	}
+	return queued, nil
+}
+
+// Status returns the state of the recompilation backlog.
+func (r *AsmRecompiler) Status() (*RecompileStatus, error) {
+	pending, err := rawdb.CountRecompileRequests(r.db)
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	status := r.status
+	status.Pending = pending
+	return &status, nil
+}
+
+func (r *AsmRecompiler) loop() {
+	defer close(r.done)
+
+	for {
+		if err := r.process(); err != nil {
+			log.Error("Failed to process Stylus asm recompilation", "err", err)
+		}
+		select {
+		case <-r.trigger:
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// process compiles the queued modules until the queue is drained or the
+// recompiler is closed.
+func (r *AsmRecompiler) process() error {
+	if RecompileWasmRust == nil {
+		return nil
+	}
+	r.setRunning(true)
+	defer r.setRunning(false)
+
+	for {
+		requests, err := rawdb.ReadRecompileRequests(r.db, recompileBatchSize)
+		if err != nil || len(requests) == 0 {
+			return err
+		}
+		for _, request := range requests {
+			select {
+			case <-r.quit:
+				return nil
+			default:
+			}
+			r.compile(request)
+		}
+	}
+}
+
+// compile compiles a queued module and stores the resulting asm, dropping the
+// request regardless of the outcome.
+func (r *AsmRecompiler) compile(request rawdb.RecompileRequest) {
+	var (
+		start     = time.Now()
+		activated = make(map[ethdb.WasmTarget][]byte)
+		err       error
+	)
+	for _, target := range rawdb.AllWasmTargets() {
+		if asm := rawdb.ReadActivatedAsm(r.db, target, request.ModuleHash); len(asm) > 0 {
+			activated[target] = asm
+		}
+	}
+	batch := r.db.NewBatch()
+	switch {
+	case len(activated[request.Target]) > 0:
+		// Compiled meanwhile, e.g. by a lazy activation
+	case len(activated) == 0:
+		err = errors.New("module not activated")
+	default:
+		var asm []byte
+		if asm, err = RecompileWasmRust(request.Target, request.ModuleHash, activated); err == nil {
+			rawdb.WriteActivatedAsm(batch, request.Target, request.ModuleHash, asm)
+		}
+	}
+	rawdb.DeleteRecompileRequest(batch, request.ModuleHash, request.Target)
+	if werr := batch.Write(); werr != nil && err == nil {
+		err = werr
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err != nil {
+		r.status.Failed++
+		r.status.LastError = err.Error()
+		log.Warn("Failed to recompile Stylus asm", "module", request.ModuleHash, "target", request.Target, "err", err)
+		return
+	}
+	r.status.Compiled++
+	log.Debug("Recompiled Stylus asm", "module", request.ModuleHash, "target", request.Target, "sources", len(activated), "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+func (r *AsmRecompiler) setRunning(running bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.status.Running = running
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestAsmRecompiler(t *testing.T) {
+	RecompileWasmRust = func(target ethdb.WasmTarget, moduleHash common.Hash, activated map[ethdb.WasmTarget][]byte) ([]byte, error) {
+		if moduleHash == (common.Hash{3}) {
+			return nil, errors.New("compilation failed")
+		}
+		return append([]byte(target), activated[rawdb.TargetWavm]...), nil
+	}
+	defer func() { RecompileWasmRust = nil }()
+
+	db := rawdb.NewMemoryDatabase()
+	for _, hash := range []common.Hash{{1}, {3}} {
+		rawdb.WriteActivation(db, hash, map[ethdb.WasmTarget][]byte{rawdb.TargetWavm: hash[:1]})
+	}
+	rawdb.WriteActivation(db, common.Hash{2}, map[ethdb.WasmTarget][]byte{
+		rawdb.TargetWavm:  {2},
+		rawdb.TargetArm64: {2},
+	})
+	recompiler := NewAsmRecompiler(db)
+	defer recompiler.Close()
+
+	if _, err := recompiler.Enqueue([]ethdb.WasmTarget{"riscv"}, nil); err == nil {
+		t.Fatal("unsupported target accepted")
+	}
+	queued, err := recompiler.Enqueue([]ethdb.WasmTarget{rawdb.TargetArm64}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queued != 2 {
+		t.Fatalf("unexpected compilations queued: have %d, want 2", queued)
+	}
+	var status *RecompileStatus
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if status, err = recompiler.Status(); err != nil {
+			t.Fatal(err)
+		}
+		if status.Pending == 0 && status.Compiled+status.Failed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recompilation not completed: %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status.Compiled != 1 || status.Failed != 1 || status.LastError == "" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if asm := rawdb.ReadActivatedAsm(db, rawdb.TargetArm64, common.Hash{1}); !bytes.Equal(asm, []byte("arm64\x01")) {
+		t.Fatalf("unexpected recompiled asm: %x", asm)
+	}
+	if asm := rawdb.ReadActivatedAsm(db, rawdb.TargetArm64, common.Hash{2}); !bytes.Equal(asm, []byte{2}) {
+		t.Fatalf("existing asm overwritten: %x", asm)
+	}
+	if asm := rawdb.ReadActivatedAsm(db, rawdb.TargetArm64, common.Hash{3}); len(asm) != 0 {
+		t.Fatalf("unexpected asm of failed compilation: %x", asm)
+	}
+}
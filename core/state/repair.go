@@ -0,0 +1,184 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+var errRepairUnsupported = errors.New("state repair is only supported by the hash scheme")
+
+// TrieNodeSource retrieves the trie nodes missing from the local database,
+// typically from a remote node holding the same state.
+type TrieNodeSource interface {
+	// TrieNode returns the trie node with the given hash.
+	TrieNode(hash common.Hash) ([]byte, error)
+}
+
+// RepairStats is the outcome of a state repair.
+type RepairStats struct {
+	Accounts    uint64 // Number of accounts walked
+	Fetched     uint64 // Number of trie nodes restored from the node source
+	Regenerated bool   // Whether the state was regenerated from the snapshot
+}
+
+// RepairState walks the whole state with the given root, account trie and
+// storage tries, restoring the trie nodes missing from the database. Missing
+// nodes are fetched from the given source if any, and verified against their
+// hash. If a node can't be restored that way, the state is regenerated from the
+// snapshot instead, if it covers the root.
+//
+// Only the hash scheme is supported, whose nodes can be written independently
+// of the rest of the trie.
+func RepairState(ctx context.Context, db ethdb.Database, tdb *triedb.Database, snaps *snapshot.Tree, root common.Hash, source TrieNodeSource) (*RepairStats, error) {
+	if tdb.Scheme() != rawdb.HashScheme {
+		return nil, errRepairUnsupported
+	}
+	r := &stateRepairer{ctx: ctx, db: db, triedb: tdb, source: source}
+	err := r.walk(root)
+
+	var missing *trie.MissingNodeError
+	if err == nil || !errors.As(err, &missing) || snaps == nil || snaps.Snapshot(root) == nil {
+		return &r.stats, err
+	}
+	log.Warn("Regenerating state from snapshot", "root", root, "err", err)
+	if err := snapshot.GenerateTrie(snaps, root, db, db); err != nil {
+		return &r.stats, fmt.Errorf("failed to regenerate state: %w", err)
+	}
+	// Walk the regenerated state again, to make sure it's complete now
+	r.stats.Regenerated = true
+	r.stats.Accounts = 0
+	if err := r.walk(root); err != nil {
+		return &r.stats, err
+	}
+	return &r.stats, nil
+}
+
+// stateRepairer walks a state, restoring the trie nodes found missing.
+type stateRepairer struct {
+	ctx    context.Context
+	db     ethdb.Database
+	triedb *triedb.Database
+	source TrieNodeSource
+	stats  RepairStats
+}
+
+// restore fetches the missing node reported by the given error from the source
+// and writes it to the database.
+func (r *stateRepairer) restore(missing *trie.MissingNodeError) error {
+	if r.source == nil {
+		return missing
+	}
+	blob, err := r.source.TrieNode(missing.NodeHash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch node: %v: %w", err, missing)
+	}
+	if hash := crypto.Keccak256Hash(blob); hash != missing.NodeHash {
+		return fmt.Errorf("fetched node hash mismatch: have %x: %w", hash, missing)
+	}
+	rawdb.WriteLegacyTrieNode(r.db, missing.NodeHash, blob)
+	r.stats.Fetched++
+	return nil
+}
+
+// openTrie opens the trie with the given id, restoring its root node if it's
+// missing.
+func (r *stateRepairer) openTrie(id *trie.ID) (*trie.StateTrie, error) {
+	for restored := false; ; restored = true {
+		tr, err := trie.NewStateTrie(id, r.triedb)
+		var missing *trie.MissingNodeError
+		if err == nil || restored || !errors.As(err, &missing) {
+			return tr, err
+		}
+		if err := r.restore(missing); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// iterate walks all the nodes of the given trie, restoring the missing ones,
+// and calls the given function for each leaf.
+func (r *stateRepairer) iterate(tr *trie.StateTrie, onLeaf func(key []byte, blob []byte) error) error {
+	it, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	var last common.Hash
+	for {
+		// The node iterator retries the failed node on the next call, so it's
+		// resumed once the missing node is restored
+		for it.Next(true) {
+			if err := r.ctx.Err(); err != nil {
+				return err
+			}
+			if it.Leaf() {
+				if err := onLeaf(it.LeafKey(), it.LeafBlob()); err != nil {
+					return err
+				}
+			}
+		}
+		err := it.Error()
+		if err == nil {
+			return nil
+		}
+		var missing *trie.MissingNodeError
+		if !errors.As(err, &missing) || missing.NodeHash == last {
+			return err
+		}
+		if err := r.restore(missing); err != nil {
+			return err
+		}
+		last = missing.NodeHash
+	}
+}
+
+// walk walks the account trie of the given state and all the storage tries.
+func (r *stateRepairer) walk(root common.Hash) error {
+	accTrie, err := r.openTrie(trie.StateTrieID(root))
+	if err != nil {
+		return err
+	}
+	return r.iterate(accTrie, func(key []byte, blob []byte) error {
+		r.stats.Accounts++
+
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return err
+		}
+		if account.Root == types.EmptyRootHash {
+			return nil
+		}
+		storageTrie, err := r.openTrie(trie.StorageTrieID(root, common.BytesToHash(key), account.Root))
+		if err != nil {
+			return err
+		}
+		return r.iterate(storageTrie, func([]byte, []byte) error { return nil })
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/holiman/uint256"
+)
+
+// testNodeSource serves trie nodes from memory.
+type testNodeSource map[common.Hash][]byte
+
+func (s testNodeSource) TrieNode(hash common.Hash) ([]byte, error) {
+	if blob, ok := s[hash]; ok {
+		return blob, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// makeRepairState commits a state with a few contracts to a fresh database,
+// returning its root along with all its trie nodes.
+func makeRepairState(t *testing.T) (ethdb.Database, common.Hash, testNodeSource) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := NewDatabase(db)
+	statedb, _ := New(types.EmptyRootHash, sdb, nil)
+	for i := byte(1); i <= 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		statedb.SetBalance(addr, uint256.NewInt(uint64(i)), tracing.BalanceChangeUnspecified)
+		if i%4 == 0 {
+			for j := byte(1); j <= 16; j++ {
+				statedb.SetState(addr, common.BytesToHash([]byte{j}), common.BytesToHash([]byte{i, j}))
+			}
+		}
+	}
+	root, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to flush state: %v", err)
+	}
+	nodes := make(testNodeSource)
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) == common.HashLength && crypto.Keccak256Hash(it.Value()) == common.BytesToHash(it.Key()) {
+			nodes[common.BytesToHash(it.Key())] = common.CopyBytes(it.Value())
+		}
+	}
+	return db, root, nodes
+}
+
+func TestRepairState(t *testing.T) {
+	db, root, nodes := makeRepairState(t)
+
+	// Corrupt the database, dropping the root and every third node
+	var deleted uint64
+	for hash := range nodes {
+		if hash == root || hash[0]%3 == 0 {
+			rawdb.DeleteLegacyTrieNode(db, hash)
+			deleted++
+		}
+	}
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
+
+	// Without any node source, the missing nodes are reported
+	var missing *trie.MissingNodeError
+	if _, err := RepairState(context.Background(), db, tdb, nil, root, nil); !errors.As(err, &missing) {
+		t.Fatalf("missing node not reported: %v", err)
+	}
+	// With a node source, the state is restored
+	stats, err := RepairState(context.Background(), db, tdb, nil, root, nodes)
+	if err != nil {
+		t.Fatalf("failed to repair state: %v", err)
+	}
+	if stats.Fetched != deleted {
+		t.Errorf("fetched nodes mismatch: have %d, want %d", stats.Fetched, deleted)
+	}
+	if stats.Accounts != 64 {
+		t.Errorf("walked accounts mismatch: have %d, want 64", stats.Accounts)
+	}
+	stats, err = RepairState(context.Background(), db, tdb, nil, root, nil)
+	if err != nil {
+		t.Fatalf("repaired state incomplete: %v", err)
+	}
+	if stats.Fetched != 0 {
+		t.Errorf("complete state fetched %d nodes", stats.Fetched)
+	}
+}
+
+func TestRepairStateInvalidNode(t *testing.T) {
+	db, root, nodes := makeRepairState(t)
+
+	rawdb.DeleteLegacyTrieNode(db, root)
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
+
+	// Nodes not matching their hash are rejected
+	source := testNodeSource{root: []byte{0xc0}}
+	if _, err := RepairState(context.Background(), db, tdb, nil, root, source); err == nil {
+		t.Fatal("invalid node accepted")
+	}
+	if rawdb.HasLegacyTrieNode(db, root) {
+		t.Error("invalid node written")
+	}
+	if _, err := RepairState(context.Background(), db, tdb, nil, root, nodes); err != nil {
+		t.Fatalf("failed to repair state: %v", err)
+	}
+}
+
+func TestRepairStateFromSnapshot(t *testing.T) {
+	db, root, nodes := makeRepairState(t)
+
+	snaps, err := snapshot.New(snapshot.Config{CacheSize: 16}, db, triedb.NewDatabase(db, nil), root)
+	if err != nil {
+		t.Fatalf("failed to generate snapshot: %v", err)
+	}
+	defer snaps.Release()
+
+	for hash := range nodes {
+		if hash[0]%2 == 0 {
+			rawdb.DeleteLegacyTrieNode(db, hash)
+		}
+	}
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: &hashdb.Config{}})
+
+	stats, err := RepairState(context.Background(), db, tdb, snaps, root, nil)
+	if err != nil {
+		t.Fatalf("failed to repair state: %v", err)
+	}
+	if !stats.Regenerated {
+		t.Error("state not regenerated from the snapshot")
+	}
+	if stats.Accounts != 64 {
+		t.Errorf("walked accounts mismatch: have %d, want 64", stats.Accounts)
+	}
+	for hash := range nodes {
+		if !rawdb.HasLegacyTrieNode(db, hash) {
+			t.Fatalf("node %x not regenerated", hash)
+		}
+	}
+}
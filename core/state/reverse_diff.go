@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie/triestate"
+)
+
+// ReverseDiff is the stable serialization of the trie state set of a block: the
+// original values of all the accounts and slots it mutated, alongside the values
+// it left behind. Replicas revert the block by writing back the origins, and
+// apply it by writing the values. Entries are sorted by address and slot hash,
+// so both the JSON and the RLP encodings are deterministic.
+type ReverseDiff struct {
+	Number     hexutil.Uint64       `json:"number"`
+	Hash       common.Hash          `json:"hash"`
+	Root       common.Hash          `json:"root"`       // State root after the block
+	ParentRoot common.Hash          `json:"parentRoot"` // State root the diff reverts to
+	Accounts   []ReverseDiffAccount `json:"accounts"`
+}
+
+// ReverseDiffAccount is the change of a single account. The values are in slim
+// RLP encoding, empty if the account was not present.
+type ReverseDiffAccount struct {
+	Address common.Address    `json:"address"`
+	Origin  hexutil.Bytes     `json:"origin"`
+	Value   hexutil.Bytes     `json:"value"`
+	Storage []ReverseDiffSlot `json:"storage"`
+}
+
+// ReverseDiffSlot is the change of a single storage slot, identified by the
+// hash of its key. The values are prefix-zero trimmed RLP encoded, empty if the
+// slot was not present.
+type ReverseDiffSlot struct {
+	Hash   common.Hash   `json:"hash"`
+	Origin hexutil.Bytes `json:"origin"`
+	Value  hexutil.Bytes `json:"value"`
+}
+
+// ReverseDiff serializes the committed changes as the diff of the given block.
+func (c *StateChanges) ReverseDiff(number uint64, hash common.Hash) *ReverseDiff {
+	diff := &ReverseDiff{
+		Number:     hexutil.Uint64(number),
+		Hash:       hash,
+		Root:       c.Root,
+		ParentRoot: c.OriginRoot,
+		Accounts:   make([]ReverseDiffAccount, 0, len(c.AccountsOrigin)),
+	}
+	addrs := make([]common.Address, 0, len(c.AccountsOrigin))
+	for addr := range c.AccountsOrigin {
+		addrs = append(addrs, addr) // Storage changes always mutate the account too
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	for _, addr := range addrs {
+		addrHash := crypto.Keccak256Hash(addr[:])
+		account := ReverseDiffAccount{
+			Address: addr,
+			Origin:  c.AccountsOrigin[addr],
+			Value:   c.Accounts[addrHash],
+			Storage: make([]ReverseDiffSlot, 0, len(c.StoragesOrigin[addr])),
+		}
+		for slot, origin := range c.StoragesOrigin[addr] {
+			account.Storage = append(account.Storage, ReverseDiffSlot{
+				Hash:   slot,
+				Origin: origin,
+				Value:  c.Storages[addrHash][slot],
+			})
+		}
+		sort.Slice(account.Storage, func(i, j int) bool {
+			return bytes.Compare(account.Storage[i].Hash[:], account.Storage[j].Hash[:]) < 0
+		})
+		diff.Accounts = append(diff.Accounts, account)
+	}
+	return diff
+}
+
+// Set reconstructs the trie state set of the diff, which can be applied on top
+// of the post-block state to revert it (see triestate.Apply).
+func (d *ReverseDiff) Set() *triestate.Set {
+	var (
+		accounts = make(map[common.Address][]byte)
+		storages = make(map[common.Address]map[common.Hash][]byte)
+	)
+	for _, account := range d.Accounts {
+		accounts[account.Address] = emptyToNil(account.Origin)
+		if len(account.Storage) == 0 {
+			continue
+		}
+		slots := make(map[common.Hash][]byte, len(account.Storage))
+		for _, slot := range account.Storage {
+			slots[slot.Hash] = emptyToNil(slot.Origin)
+		}
+		storages[account.Address] = slots
+	}
+	return triestate.New(accounts, storages)
+}
+
+func emptyToNil(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
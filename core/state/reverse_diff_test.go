@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triestate"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+func TestReverseDiff(t *testing.T) {
+	var (
+		disk      = rawdb.NewMemoryDatabase()
+		tdb       = triedb.NewDatabase(disk, &triedb.Config{PathDB: pathdb.Defaults})
+		db        = NewDatabaseWithNodeDB(disk, tdb)
+		updated   = common.HexToAddress("0x1")
+		destroyed = common.HexToAddress("0x2")
+		created   = common.HexToAddress("0x3")
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(updated, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetState(updated, common.HexToHash("0x1"), common.HexToHash("0x1"))
+	state.SetState(updated, common.HexToHash("0x2"), common.HexToHash("0x2"))
+	state.SetBalance(destroyed, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetState(destroyed, common.HexToHash("0x1"), common.HexToHash("0x1"))
+	parent, _ := state.Commit(0, true)
+
+	state, _ = New(parent, db, nil)
+	state.SetBalance(updated, uint256.NewInt(2), tracing.BalanceChangeUnspecified)
+	state.SetState(updated, common.HexToHash("0x1"), common.Hash{})
+	state.SetState(updated, common.HexToHash("0x3"), common.HexToHash("0x3"))
+	state.SelfDestruct(destroyed)
+	state.SetBalance(created, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	root, err := state.Commit(1, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	diff := state.CommittedChanges().ReverseDiff(1, common.Hash{1})
+	if diff.Root != root || diff.ParentRoot != parent {
+		t.Fatalf("unexpected diff roots: have %x -> %x, want %x -> %x", diff.ParentRoot, diff.Root, parent, root)
+	}
+	if len(diff.Accounts) != 3 {
+		t.Fatalf("unexpected number of changed accounts: have %d, want 3", len(diff.Accounts))
+	}
+	// The encoding must survive a roundtrip
+	blob, err := rlp.EncodeToBytes(diff)
+	if err != nil {
+		t.Fatalf("failed to encode diff: %v", err)
+	}
+	var decoded ReverseDiff
+	if err := rlp.DecodeBytes(blob, &decoded); err != nil {
+		t.Fatalf("failed to decode diff: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Set(), diff.Set()) {
+		t.Fatalf("decoded diff mismatch")
+	}
+	// Applying the origins on top of the post state must restore the parent
+	set := decoded.Set()
+	if _, err := triestate.Apply(parent, root, set.Accounts, set.Storages, trie.NewMerkleLoader(tdb)); err != nil {
+		t.Fatalf("failed to revert block: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// SelfDestructRecord is a selfdestruct executed by the current transaction,
+// along with where the balance of the contract went.
+type SelfDestructRecord struct {
+	Contract    common.Address
+	Beneficiary common.Address
+	Transferred *uint256.Int // Balance credited to the beneficiary
+	Burnt       *uint256.Int // Balance destroyed, the contract being its own beneficiary
+	Destructed  bool         // Whether the contract is destructed, rather than only drained as of EIP-6780
+}
+
+// selfDestructRecordChange is the recording of a selfdestruct, dropped along
+// with the call that executed it.
+type selfDestructRecordChange struct{}
+
+func (ch selfDestructRecordChange) revert(s *StateDB) {
+	records := s.arbExtraData.selfDestructs
+	s.arbExtraData.selfDestructs = records[:len(records)-1]
+}
+
+func (ch selfDestructRecordChange) dirtied() *common.Address {
+	return nil
+}
+
+func (ch selfDestructRecordChange) copy() journalEntry {
+	return selfDestructRecordChange{}
+}
+
+// RecordSelfDestruct records a selfdestruct executed by the current transaction.
+func (s *StateDB) RecordSelfDestruct(record SelfDestructRecord) {
+	s.journal.append(selfDestructRecordChange{})
+	s.arbExtraData.selfDestructs = append(s.arbExtraData.selfDestructs, record)
+}
+
+// SelfDestructs returns the selfdestructs executed by the current transaction,
+// excluding the ones of the reverted calls, in execution order.
+func (s *StateDB) SelfDestructs() []SelfDestructRecord {
+	records := make([]SelfDestructRecord, len(s.arbExtraData.selfDestructs))
+	copy(records, s.arbExtraData.selfDestructs)
+	return records
+}
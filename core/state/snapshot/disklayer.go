@@ -41,6 +41,8 @@ type diskLayer struct {
 	genMarker  []byte                    // Marker for the state that's indexed during initial layer generation
 	genPending chan struct{}             // Notification channel when generation is done (test synchronicity)
 	genAbort   chan chan *generatorStats // Notification channel to abort generating the snapshot in this layer
+	genStats   generatorStats            // Generator statistics as of the last progress checkpoint
+	genLimiter *generatorLimiter         // Rate limiter of the generator, shared by successive disk layers
 
 	lock sync.RWMutex
 }
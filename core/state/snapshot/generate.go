@@ -76,6 +76,7 @@ func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, cache
 		genMarker:  genMarker,
 		genPending: make(chan struct{}),
 		genAbort:   make(chan chan *generatorStats),
+		genStats:   *stats,
 	}
 	go base.generate(stats)
 	log.Debug("Start snapshot generation", "root", root)
@@ -498,6 +499,7 @@ func (dl *diskLayer) checkAndFlush(ctx *generatorContext, current []byte) error
 
 		dl.lock.Lock()
 		dl.genMarker = current
+		dl.genStats = *ctx.stats
 		dl.lock.Unlock()
 
 		if abort != nil {
@@ -511,7 +513,16 @@ func (dl *diskLayer) checkAndFlush(ctx *generatorContext, current []byte) error
 	if time.Since(ctx.logged) > 8*time.Second {
 		ctx.stats.Log("Generating state snapshot", dl.root, current)
 		ctx.logged = time.Now()
+
+		dl.lock.Lock()
+		dl.genStats = *ctx.stats
+		dl.lock.Unlock()
 	}
+	// Slow down if the generation is rate limited
+	dl.lock.RLock()
+	limiter := dl.genLimiter
+	dl.lock.RUnlock()
+	limiter.wait()
 	return nil
 }
 
@@ -715,6 +726,7 @@ func (dl *diskLayer) generate(stats *generatorStats) {
 
 	dl.lock.Lock()
 	dl.genMarker = nil
+	dl.genStats = *stats
 	close(dl.genPending)
 	dl.lock.Unlock()
 
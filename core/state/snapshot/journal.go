@@ -143,6 +143,16 @@ func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, root comm
 		log.Warn("Failed to load journal", "error", err)
 		return nil, false, err
 	}
+	// Arbitrum: if the journal is outdated, e.g. after an unclean shutdown,
+	// recover the diff layers from the incremental journal if available
+	if snapshot.Root() != root {
+		if recovered, err := loadDiffJournal(diskdb, triedb, base, root); err != nil {
+			log.Debug("Failed to recover snapshot from the diff journal", "err", err)
+		} else {
+			log.Info("Recovered snapshot from the diff journal", "root", root, "diskroot", base.root)
+			snapshot = recovered
+		}
+	}
 	// Entire snapshot journal loaded, sanity check the head. If the loaded
 	// snapshot is not matched with current state root, print a warning log
 	// or discard the entire snapshot it's legacy snapshot.
@@ -181,13 +191,15 @@ func loadSnapshot(diskdb ethdb.KeyValueStore, triedb *triedb.Database, root comm
 		if len(generator.Marker) >= 8 {
 			origin = binary.BigEndian.Uint64(generator.Marker)
 		}
-		go base.generate(&generatorStats{
+		stats := &generatorStats{
 			origin:   origin,
 			start:    time.Now(),
 			accounts: generator.Accounts,
 			slots:    generator.Slots,
 			storage:  common.StorageSize(generator.Storage),
-		})
+		}
+		base.genStats = *stats
+		go base.generate(stats)
 	}
 	return snapshot, false, nil
 }
@@ -238,30 +250,13 @@ func (dl *diffLayer) Journal(buffer *bytes.Buffer) (common.Hash, error) {
 	if err := rlp.Encode(buffer, dl.root); err != nil {
 		return common.Hash{}, err
 	}
-	destructs := make([]journalDestruct, 0, len(dl.destructSet))
-	for hash := range dl.destructSet {
-		destructs = append(destructs, journalDestruct{Hash: hash})
-	}
+	destructs, accounts, storage := encodeDiff(dl.destructSet, dl.accountData, dl.storageData)
 	if err := rlp.Encode(buffer, destructs); err != nil {
 		return common.Hash{}, err
 	}
-	accounts := make([]journalAccount, 0, len(dl.accountData))
-	for hash, blob := range dl.accountData {
-		accounts = append(accounts, journalAccount{Hash: hash, Blob: blob})
-	}
 	if err := rlp.Encode(buffer, accounts); err != nil {
 		return common.Hash{}, err
 	}
-	storage := make([]journalStorage, 0, len(dl.storageData))
-	for hash, slots := range dl.storageData {
-		keys := make([]common.Hash, 0, len(slots))
-		vals := make([][]byte, 0, len(slots))
-		for key, val := range slots {
-			keys = append(keys, key)
-			vals = append(vals, val)
-		}
-		storage = append(storage, journalStorage{Hash: hash, Keys: keys, Vals: vals})
-	}
 	if err := rlp.Encode(buffer, storage); err != nil {
 		return common.Hash{}, err
 	}
@@ -309,13 +304,10 @@ func iterateJournal(db ethdb.KeyValueReader, callback journalCallback) error {
 	}
 	for {
 		var (
-			root        common.Hash
-			destructs   []journalDestruct
-			accounts    []journalAccount
-			storage     []journalStorage
-			destructSet = make(map[common.Hash]struct{})
-			accountData = make(map[common.Hash][]byte)
-			storageData = make(map[common.Hash]map[common.Hash][]byte)
+			root      common.Hash
+			destructs []journalDestruct
+			accounts  []journalAccount
+			storage   []journalStorage
 		)
 		// Read the next diff journal entry
 		if err := r.Decode(&root); err != nil {
@@ -334,30 +326,65 @@ func iterateJournal(db ethdb.KeyValueReader, callback journalCallback) error {
 		if err := r.Decode(&storage); err != nil {
 			return fmt.Errorf("load diff storage: %v", err)
 		}
-		for _, entry := range destructs {
-			destructSet[entry.Hash] = struct{}{}
-		}
-		for _, entry := range accounts {
-			if len(entry.Blob) > 0 { // RLP loses nil-ness, but `[]byte{}` is not a valid item, so reinterpret that
-				accountData[entry.Hash] = entry.Blob
-			} else {
-				accountData[entry.Hash] = nil
-			}
-		}
-		for _, entry := range storage {
-			slots := make(map[common.Hash][]byte)
-			for i, key := range entry.Keys {
-				if len(entry.Vals[i]) > 0 { // RLP loses nil-ness, but `[]byte{}` is not a valid item, so reinterpret that
-					slots[key] = entry.Vals[i]
-				} else {
-					slots[key] = nil
-				}
-			}
-			storageData[entry.Hash] = slots
-		}
+		destructSet, accountData, storageData := decodeDiff(destructs, accounts, storage)
 		if err := callback(parent, root, destructSet, accountData, storageData); err != nil {
 			return err
 		}
 		parent = root
 	}
 }
+
+// encodeDiff converts the contents of a diff layer into their journal format.
+func encodeDiff(destructSet map[common.Hash]struct{}, accountData map[common.Hash][]byte, storageData map[common.Hash]map[common.Hash][]byte) ([]journalDestruct, []journalAccount, []journalStorage) {
+	destructs := make([]journalDestruct, 0, len(destructSet))
+	for hash := range destructSet {
+		destructs = append(destructs, journalDestruct{Hash: hash})
+	}
+	accounts := make([]journalAccount, 0, len(accountData))
+	for hash, blob := range accountData {
+		accounts = append(accounts, journalAccount{Hash: hash, Blob: blob})
+	}
+	storage := make([]journalStorage, 0, len(storageData))
+	for hash, slots := range storageData {
+		keys := make([]common.Hash, 0, len(slots))
+		vals := make([][]byte, 0, len(slots))
+		for key, val := range slots {
+			keys = append(keys, key)
+			vals = append(vals, val)
+		}
+		storage = append(storage, journalStorage{Hash: hash, Keys: keys, Vals: vals})
+	}
+	return destructs, accounts, storage
+}
+
+// decodeDiff converts the journalled contents of a diff layer back into their
+// in-memory format.
+func decodeDiff(destructs []journalDestruct, accounts []journalAccount, storage []journalStorage) (map[common.Hash]struct{}, map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte) {
+	var (
+		destructSet = make(map[common.Hash]struct{})
+		accountData = make(map[common.Hash][]byte)
+		storageData = make(map[common.Hash]map[common.Hash][]byte)
+	)
+	for _, entry := range destructs {
+		destructSet[entry.Hash] = struct{}{}
+	}
+	for _, entry := range accounts {
+		if len(entry.Blob) > 0 { // RLP loses nil-ness, but `[]byte{}` is not a valid item, so reinterpret that
+			accountData[entry.Hash] = entry.Blob
+		} else {
+			accountData[entry.Hash] = nil
+		}
+	}
+	for _, entry := range storage {
+		slots := make(map[common.Hash][]byte)
+		for i, key := range entry.Keys {
+			if len(entry.Vals[i]) > 0 { // RLP loses nil-ness, but `[]byte{}` is not a valid item, so reinterpret that
+				slots[key] = entry.Vals[i]
+			} else {
+				slots[key] = nil
+			}
+		}
+		storageData[entry.Hash] = slots
+	}
+	return destructSet, accountData, storageData
+}
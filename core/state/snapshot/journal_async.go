@@ -0,0 +1,262 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+var (
+	snapJournalWriteMeter   = metrics.NewRegisteredMeter("state/snapshot/journal/async/write", nil)
+	snapJournalPruneMeter   = metrics.NewRegisteredMeter("state/snapshot/journal/async/prune", nil)
+	snapJournalRecoverMeter = metrics.NewRegisteredMeter("state/snapshot/journal/async/recover", nil)
+)
+
+const (
+	// asyncJournalQueue is the number of journal operations that may be queued
+	// before the snapshot updates are blocked.
+	asyncJournalQueue = 128
+
+	// asyncJournalValidation is the maximum number of accounts of the head diff
+	// layer checked against the state trie when recovering from the journal.
+	asyncJournalValidation = 1024
+)
+
+// journalDiff is an entry of the incremental journal, persisting a single diff
+// layer along with the root of its parent.
+type journalDiff struct {
+	Parent    common.Hash
+	Destructs []journalDestruct
+	Accounts  []journalAccount
+	Storage   []journalStorage
+}
+
+// journalOp is an operation queued on the incremental journal: either the write
+// of a diff layer entry, the pruning of the entries not live anymore, or a sync
+// marker.
+type journalOp struct {
+	root  common.Hash
+	entry []byte                   // Encoded diff layer to write, if any
+	live  map[common.Hash]struct{} // Diff layers to retain when pruning, if any
+	done  chan struct{}            // Closed when the operation completed, if any
+}
+
+// asyncJournal persists the diff layers as they are created, in the background,
+// so that they survive an unclean shutdown. Unlike the journal written on a
+// clean shutdown, every layer is stored in its own database entry, written
+// atomically, keyed by its root and linked to its parent.
+//
+// Entries are written in the order the layers were created, so a crash may only
+// ever lose the most recent layers. Entries are pruned once their layers are
+// flattened into the disk layer or become stale.
+type asyncJournal struct {
+	db   ethdb.KeyValueStore
+	ops  chan *journalOp
+	done chan struct{} // Closed when the journal loop terminates
+}
+
+// newAsyncJournal creates an incremental journal and starts its write loop.
+func newAsyncJournal(db ethdb.KeyValueStore) *asyncJournal {
+	j := &asyncJournal{
+		db:   db,
+		ops:  make(chan *journalOp, asyncJournalQueue),
+		done: make(chan struct{}),
+	}
+	go j.loop()
+	return j
+}
+
+// add queues the write of a new diff layer. The layer is encoded right away as
+// its contents may be modified when the layers below are flattened.
+func (j *asyncJournal) add(root common.Hash, parent common.Hash, destructSet map[common.Hash]struct{}, accountData map[common.Hash][]byte, storageData map[common.Hash]map[common.Hash][]byte) {
+	destructs, accounts, storage := encodeDiff(destructSet, accountData, storageData)
+	entry, err := rlp.EncodeToBytes(&journalDiff{Parent: parent, Destructs: destructs, Accounts: accounts, Storage: storage})
+	if err != nil {
+		log.Error("Failed to encode snapshot diff journal", "root", root, "err", err)
+		return
+	}
+	j.ops <- &journalOp{root: root, entry: entry}
+}
+
+// prune queues the deletion of all the entries not belonging to the given
+// live diff layers.
+func (j *asyncJournal) prune(live map[common.Hash]struct{}) {
+	j.ops <- &journalOp{live: live}
+}
+
+// sync blocks until all the queued operations completed.
+func (j *asyncJournal) sync() {
+	done := make(chan struct{})
+	j.ops <- &journalOp{done: done}
+	<-done
+}
+
+// close waits for the queued operations to complete and terminates the loop.
+func (j *asyncJournal) close() {
+	close(j.ops)
+	<-j.done
+}
+
+// loop runs the queued journal operations in order.
+func (j *asyncJournal) loop() {
+	defer close(j.done)
+
+	for op := range j.ops {
+		switch {
+		case op.entry != nil:
+			rawdb.WriteSnapshotDiffJournal(j.db, op.root, op.entry)
+			snapJournalWriteMeter.Mark(1)
+
+		case op.live != nil:
+			j.deleteExcept(op.live)
+
+		case op.done != nil:
+			close(op.done)
+		}
+	}
+}
+
+// deleteExcept deletes all the journal entries not belonging to the given live
+// diff layers.
+func (j *asyncJournal) deleteExcept(live map[common.Hash]struct{}) {
+	var (
+		batch   = j.db.NewBatch()
+		deleted int
+	)
+	it := rawdb.IterateSnapshotDiffJournals(j.db)
+	for it.Next() {
+		root := common.BytesToHash(it.Key()[len(rawdb.SnapshotDiffJournalPrefix):])
+		if _, ok := live[root]; ok {
+			continue
+		}
+		rawdb.DeleteSnapshotDiffJournal(batch, root)
+		deleted++
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		log.Error("Failed to iterate snapshot diff journal", "err", err)
+		return
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to prune snapshot diff journal", "err", err)
+		return
+	}
+	snapJournalPruneMeter.Mark(int64(deleted))
+}
+
+// liveLayers returns the roots of the diff layers in the tree. The caller must
+// hold the tree lock.
+func (t *Tree) liveLayers() map[common.Hash]struct{} {
+	live := make(map[common.Hash]struct{}, len(t.layers))
+	for root, layer := range t.layers {
+		if _, ok := layer.(*diffLayer); ok {
+			live[root] = struct{}{}
+		}
+	}
+	return live
+}
+
+// loadDiffJournal reconstructs the diff layers from the given disk layer up to
+// the given root from the incremental journal. The recovered layers are checked
+// to be continuous, and the accounts of the head layer against the state trie.
+func loadDiffJournal(db ethdb.KeyValueStore, triedb *triedb.Database, base *diskLayer, root common.Hash) (snapshot, error) {
+	// Walk the journal back from the requested root to the disk layer
+	var (
+		diffs = make(map[common.Hash]*journalDiff)
+		chain []common.Hash
+	)
+	it := rawdb.IterateSnapshotDiffJournals(db)
+	for it.Next() {
+		diff := new(journalDiff)
+		if err := rlp.DecodeBytes(it.Value(), diff); err != nil {
+			log.Warn("Skipping corrupted snapshot diff journal", "key", fmt.Sprintf("%x", it.Key()), "err", err)
+			continue
+		}
+		diffs[common.BytesToHash(it.Key()[len(rawdb.SnapshotDiffJournalPrefix):])] = diff
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if len(diffs) == 0 {
+		return nil, errors.New("diff journal missing")
+	}
+	for current := root; current != base.root; {
+		diff, ok := diffs[current]
+		if !ok || len(chain) == len(diffs) {
+			return nil, fmt.Errorf("diff journal not continuous with disk layer at %#x", current)
+		}
+		chain = append(chain, current)
+		current = diff.Parent
+	}
+	if len(chain) == 0 {
+		return base, nil
+	}
+	// Rebuild the layers bottom up
+	var head snapshot = base
+	for i := len(chain) - 1; i >= 0; i-- {
+		diff := diffs[chain[i]]
+		destructSet, accountData, storageData := decodeDiff(diff.Destructs, diff.Accounts, diff.Storage)
+		head = newDiffLayer(head, chain[i], destructSet, accountData, storageData)
+	}
+	if err := validateDiffJournal(triedb, head.(*diffLayer)); err != nil {
+		return nil, err
+	}
+	snapJournalRecoverMeter.Mark(int64(len(chain)))
+	return head, nil
+}
+
+// validateDiffJournal checks the accounts modified by the given recovered head
+// layer against the state trie of its root.
+func validateDiffJournal(triedb *triedb.Database, head *diffLayer) error {
+	tr, err := trie.NewStateTrie(trie.StateTrieID(head.root), triedb)
+	if err != nil {
+		return fmt.Errorf("state of recovered head unavailable: %w", err)
+	}
+	checked := 0
+	for hash, blob := range head.accountData {
+		if checked == asyncJournalValidation {
+			break
+		}
+		checked++
+
+		account, err := tr.GetAccountByHash(hash)
+		if err != nil {
+			return err
+		}
+		var want []byte
+		if account != nil {
+			want = types.SlimAccountRLP(*account)
+		}
+		if !bytes.Equal(blob, want) {
+			return fmt.Errorf("recovered account %#x diverges from the trie", hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/holiman/uint256"
+)
+
+// makeJournalState commits a state trie holding the given accounts, returning
+// its root along with the snapshot account data of each account.
+func makeJournalState(t *testing.T, db *triedb.Database, balances map[common.Address]uint64) (common.Hash, map[common.Hash][]byte) {
+	tr, _ := trie.NewStateTrie(trie.StateTrieID(types.EmptyRootHash), db)
+	accounts := make(map[common.Hash][]byte)
+	for addr, balance := range balances {
+		acc := &types.StateAccount{Balance: uint256.NewInt(balance), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+		if err := tr.UpdateAccount(addr, acc); err != nil {
+			t.Fatalf("failed to update account: %v", err)
+		}
+		accounts[crypto.Keccak256Hash(addr.Bytes())] = types.SlimAccountRLP(*acc)
+	}
+	root, nodes, _ := tr.Commit(false)
+	if err := db.Update(root, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil); err != nil {
+		t.Fatalf("failed to update trie database: %v", err)
+	}
+	if err := db.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie database: %v", err)
+	}
+	return root, accounts
+}
+
+// Tests that the diff layers persisted by the incremental journal can be
+// recovered without a shutdown journal, and are pruned once flattened.
+func TestAsyncJournalRecovery(t *testing.T) {
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		tdb    = triedb.NewDatabase(diskdb, &triedb.Config{HashDB: &hashdb.Config{}})
+		addrA  = common.HexToAddress("0xa")
+		addrB  = common.HexToAddress("0xb")
+	)
+	root1, _ := makeJournalState(t, tdb, map[common.Address]uint64{addrA: 1})
+	root2, accounts2 := makeJournalState(t, tdb, map[common.Address]uint64{addrA: 2, addrB: 1})
+	root3, accounts3 := makeJournalState(t, tdb, map[common.Address]uint64{addrA: 2, addrB: 2})
+
+	base := generateSnapshot(diskdb, tdb, 16, root1)
+	select {
+	case <-base.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatal("snapshot generation timed out")
+	}
+	snaps := &Tree{
+		diskdb:  diskdb,
+		triedb:  tdb,
+		layers:  map[common.Hash]snapshot{root1: base},
+		journal: newAsyncJournal(diskdb),
+	}
+	defer snaps.journal.close()
+
+	if err := snaps.Update(root2, root1, nil, accounts2, nil); err != nil {
+		t.Fatalf("failed to create diff layer: %v", err)
+	}
+	delete(accounts3, crypto.Keccak256Hash(addrA.Bytes())) // unchanged
+	if err := snaps.Update(root3, root2, nil, accounts3, nil); err != nil {
+		t.Fatalf("failed to create diff layer: %v", err)
+	}
+	snaps.journal.sync()
+
+	// Recover the layers as if the node crashed without journalling
+	head, err := loadDiffJournal(diskdb, tdb, base, root3)
+	if err != nil {
+		t.Fatalf("failed to recover diff layers: %v", err)
+	}
+	if head.Root() != root3 || head.Parent().Root() != root2 || head.Parent().Parent() != base {
+		t.Fatal("recovered diff layers not linked to the disk layer")
+	}
+	hashB := crypto.Keccak256Hash(addrB.Bytes())
+	if blob, err := head.AccountRLP(hashB); err != nil || !bytes.Equal(blob, accounts3[hashB]) {
+		t.Errorf("recovered account mismatch: have %x (err %v), want %x", blob, err, accounts3[hashB])
+	}
+	// Layers not continuous with the disk layer can't be recovered
+	if _, err := loadDiffJournal(diskdb, tdb, base, common.HexToHash("0xdead")); err == nil {
+		t.Error("recovered unknown diff layer")
+	}
+	// Entries diverging from the state trie are rejected
+	blob, _ := rlp.EncodeToBytes(&journalDiff{
+		Parent:   root2,
+		Accounts: []journalAccount{{Hash: hashB, Blob: accounts2[hashB]}},
+	})
+	rawdb.WriteSnapshotDiffJournal(diskdb, root3, blob)
+	if _, err := loadDiffJournal(diskdb, tdb, base, root3); err == nil {
+		t.Error("recovered diverging diff layer")
+	}
+	// Flattening the layers prunes their entries
+	if err := snaps.Cap(root3, 0); err != nil {
+		t.Fatalf("failed to flatten diff layers: %v", err)
+	}
+	snaps.journal.sync()
+
+	it := rawdb.IterateSnapshotDiffJournals(diskdb)
+	defer it.Release()
+	for it.Next() {
+		t.Errorf("flattened diff layer retained: %x", it.Key())
+	}
+}
@@ -50,4 +50,15 @@ var (
 	snapStorageWriteCounter = metrics.NewRegisteredCounter("state/snapshot/generation/duration/storage/write", nil)
 	// snapStorageCleanCounter measures time spent on deleting storages
 	snapStorageCleanCounter = metrics.NewRegisteredCounter("state/snapshot/generation/duration/storage/clean", nil)
+
+	// snapVerifyAccountMeter counts the accounts cross-checked by the verifier
+	snapVerifyAccountMeter = metrics.NewRegisteredMeter("state/snapshot/verify/account/checked", nil)
+	// snapVerifyAccountDivergedMeter counts the accounts found diverged from the trie
+	snapVerifyAccountDivergedMeter = metrics.NewRegisteredMeter("state/snapshot/verify/account/diverged", nil)
+	// snapVerifyStorageMeter counts the storage slots cross-checked by the verifier
+	snapVerifyStorageMeter = metrics.NewRegisteredMeter("state/snapshot/verify/storage/checked", nil)
+	// snapVerifyStorageDivergedMeter counts the storage slots found diverged from the trie
+	snapVerifyStorageDivergedMeter = metrics.NewRegisteredMeter("state/snapshot/verify/storage/diverged", nil)
+	// snapVerifyRepairedMeter counts the diverged entries repaired from the trie
+	snapVerifyRepairedMeter = metrics.NewRegisteredMeter("state/snapshot/verify/repaired", nil)
 )
@@ -0,0 +1,146 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GeneratorProgress is the progress of the snapshot generation, as of the last
+// checkpoint of the generator.
+type GeneratorProgress struct {
+	Root       common.Hash        // Root of the disk layer being generated
+	Generating bool               // Whether the generation is still in progress
+	Marker     []byte             // Position of the generator, account hash optionally followed by slot hash
+	Accounts   uint64             // Number of accounts indexed(generated or recovered)
+	Slots      uint64             // Number of storage slots indexed(generated or recovered)
+	Dangling   uint64             // Number of dangling storage slots
+	Storage    common.StorageSize // Total account and storage slot size(generation or recovery)
+	Elapsed    time.Duration      // Time elapsed since the generation (re)started
+	ETA        time.Duration      // Estimated remaining time, zero if unknown
+	RateLimit  uint64             // Maximum number of items generated per second, zero if unlimited
+}
+
+// GeneratorProgress returns the progress of the snapshot generation.
+func (t *Tree) GeneratorProgress() (*GeneratorProgress, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	layer := t.disklayer()
+	if layer == nil {
+		return nil, errors.New("disk layer is missing")
+	}
+	layer.lock.RLock()
+	defer layer.lock.RUnlock()
+
+	stats := layer.genStats
+	progress := &GeneratorProgress{
+		Root:       layer.root,
+		Generating: layer.genMarker != nil,
+		Marker:     common.CopyBytes(layer.genMarker),
+		Accounts:   stats.accounts,
+		Slots:      stats.slots,
+		Dangling:   stats.dangling,
+		Storage:    stats.storage,
+		RateLimit:  t.limiter.limit(),
+	}
+	if progress.Generating && !stats.start.IsZero() {
+		progress.Elapsed = time.Since(stats.start)
+		progress.ETA, _ = stats.eta(layer.genMarker)
+	}
+	return progress, nil
+}
+
+// SetGeneratorRateLimit limits the number of accounts and storage slots the
+// snapshot generator processes per second, so that the generation can be slowed
+// down at runtime. A zero rate removes the limit.
+func (t *Tree) SetGeneratorRateLimit(rate uint64) {
+	t.limiter.setLimit(rate)
+}
+
+// generatorLimiter rate limits the snapshot generator. A nil limiter doesn't
+// limit anything.
+type generatorLimiter struct {
+	rate  uint64    // Maximum number of items per second, zero if unlimited
+	start time.Time // Start of the current rate measurement window
+	count uint64    // Number of items processed in the current window
+	lock  sync.Mutex
+}
+
+// limit returns the configured maximum number of items per second.
+func (l *generatorLimiter) limit() uint64 {
+	if l == nil {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.rate
+}
+
+// setLimit configures the maximum number of items per second and restarts the
+// rate measurement.
+func (l *generatorLimiter) setLimit(rate uint64) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.rate, l.start, l.count = rate, time.Time{}, 0
+}
+
+// wait accounts for a processed item, blocking as long as needed for the rate
+// limit to be respected.
+func (l *generatorLimiter) wait() {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	if l.rate == 0 {
+		l.lock.Unlock()
+		return
+	}
+	now := time.Now()
+	if l.start.IsZero() {
+		l.start = now
+	}
+	l.count++
+	delay := time.Duration(l.count)*time.Second/time.Duration(l.rate) - now.Sub(l.start)
+
+	// Start a new window every second to not make up for idle periods
+	if l.count >= l.rate {
+		l.start, l.count = now.Add(delay), 0
+	}
+	l.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// setLimiter sets the rate limiter of the disk layer's generator.
+func (dl *diskLayer) setLimiter(limiter *generatorLimiter) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.genLimiter = limiter
+}
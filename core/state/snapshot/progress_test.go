@@ -0,0 +1,88 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the generator progress is reported once generation completes.
+func TestGeneratorProgress(t *testing.T) {
+	var helper = newHelper(rawdb.HashScheme)
+	stRoot := helper.makeStorageTrie(common.Hash{}, []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, false)
+
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: uint256.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: uint256.NewInt(2), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.makeStorageTrie(hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Snapshot generation failed")
+	}
+	tree := &Tree{layers: map[common.Hash]snapshot{root: snap}, limiter: new(generatorLimiter)}
+	tree.SetGeneratorRateLimit(1000)
+
+	progress, err := tree.GeneratorProgress()
+	if err != nil {
+		t.Fatalf("failed to retrieve progress: %v", err)
+	}
+	if progress.Root != root || progress.Generating {
+		t.Fatalf("unexpected progress root %x, generating %v", progress.Root, progress.Generating)
+	}
+	if progress.Accounts != 2 || progress.Slots != 3 {
+		t.Fatalf("unexpected progress: accounts %d, slots %d", progress.Accounts, progress.Slots)
+	}
+	if progress.RateLimit != 1000 {
+		t.Fatalf("unexpected rate limit: have %d, want 1000", progress.RateLimit)
+	}
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+}
+
+// Tests that the generator limiter enforces the configured rate.
+func TestGeneratorLimiter(t *testing.T) {
+	// A nil or unconfigured limiter doesn't limit anything
+	var limiter *generatorLimiter
+	limiter.wait()
+
+	limiter = new(generatorLimiter)
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		limiter.wait()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("unlimited generation throttled: %v", elapsed)
+	}
+	// Limit to 50 items per second, 25 items should take about half a second
+	limiter.setLimit(50)
+	start = time.Now()
+	for i := 0; i < 25; i++ {
+		limiter.wait()
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("rate limit not enforced: %v", elapsed)
+	}
+}
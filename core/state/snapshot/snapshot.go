@@ -154,8 +154,21 @@ type Config struct {
 	Recovery   bool // Indicator that the snapshots is in the recovery mode
 	NoBuild    bool // Indicator that the snapshots generation is disallowed
 	AsyncBuild bool // The snapshot generation is allowed to be constructed asynchronously
+
+	// Arbitrum: diff layer retention, independent of the trie retention
+	DiffLayers      int    // Number of diff layers retained by the state on commit (0 = DefaultDiffLayers)
+	AggregatorLimit uint64 // Bytes the bottom-most diff layer accumulates before flushing to disk (0 = default)
+
+	// Arbitrum: persist the diff layers as they're created, to recover them
+	// after an unclean shutdown
+	AsyncJournal bool
 }
 
+// DefaultDiffLayers is the number of diff layers retained if not configured.
+// It matches the default trie retention, pairing every in-memory state root
+// with a diff layer.
+const DefaultDiffLayers = 128
+
 // Tree is an Ethereum state snapshot tree. It consists of one persistent base
 // layer backed by a key-value store, on top of which arbitrarily many in-memory
 // diff layers are topped. The memory diffs can form a tree with branching, but
@@ -172,6 +185,13 @@ type Tree struct {
 	layers map[common.Hash]snapshot // Collection of all known layers
 	lock   sync.RWMutex
 
+	limiter *generatorLimiter // Rate limiter of the snapshot generation
+
+	verifier     *verifier  // Background verifier of the flat snapshot, if started
+	verifierLock sync.Mutex // Lock protecting the verifier
+
+	journal *asyncJournal // Arbitrum: incremental journal of the diff layers, nil if disabled
+
 	// Test hooks
 	onFlatten func() // Hook invoked when the bottom most diff layers are flattened
 }
@@ -199,6 +219,11 @@ func New(config Config, diskdb ethdb.KeyValueStore, triedb *triedb.Database, roo
 		diskdb: diskdb,
 		triedb: triedb,
 		layers: make(map[common.Hash]snapshot),
+
+		limiter: new(generatorLimiter),
+	}
+	if config.AsyncJournal {
+		snap.journal = newAsyncJournal(diskdb)
 	}
 	// Attempt to load a previously persisted snapshot and rebuild one if failed
 	head, disabled, err := loadSnapshot(diskdb, triedb, root, config.CacheSize, config.Recovery, config.NoBuild)
@@ -220,12 +245,38 @@ func New(config Config, diskdb ethdb.KeyValueStore, triedb *triedb.Database, roo
 	}
 	// Existing snapshot loaded, seed all the layers
 	for head != nil {
+		if layer, ok := head.(*diskLayer); ok {
+			layer.setLimiter(snap.limiter)
+		}
 		snap.layers[head.Root()] = head
 		head = head.Parent()
 	}
+	// Drop the journal entries of the layers not loaded
+	if snap.journal != nil {
+		snap.journal.prune(snap.liveLayers())
+	}
 	return snap, nil
 }
 
+// DiffLayers returns the number of diff layers the tree should be capped to.
+func (t *Tree) DiffLayers() int {
+	if t.config.DiffLayers > 0 {
+		return t.config.DiffLayers
+	}
+	return DefaultDiffLayers
+}
+
+// aggregatorLimit returns the size the bottom-most diff layer may grow to
+// before it's flushed into the disk layer. Raising it above the default keeps
+// the bloom filters at their default size, trading more false positives for
+// fewer disk writes.
+func (t *Tree) aggregatorLimit() uint64 {
+	if t.config.AggregatorLimit > 0 {
+		return t.config.AggregatorLimit
+	}
+	return aggregatorMemoryLimit
+}
+
 // waitBuild blocks until the snapshot finishes rebuilding. This method is meant
 // to be used by tests to ensure we're testing what we believe we are.
 func (t *Tree) waitBuild() {
@@ -288,7 +339,9 @@ func (t *Tree) Disable() {
 		}
 	}
 	t.layers = map[common.Hash]snapshot{}
-
+	if t.journal != nil {
+		t.journal.prune(t.liveLayers())
+	}
 	// Delete all snapshot liveness information from the database
 	batch := t.diskdb.NewBatch()
 
@@ -365,6 +418,10 @@ func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs m
 	}
 	snap := parent.(snapshot).Update(blockRoot, destructs, accounts, storage)
 
+	// Arbitrum: persist the new layer in the background
+	if t.journal != nil {
+		t.journal.add(blockRoot, parentRoot, destructs, accounts, storage)
+	}
 	// Save the new snapshot for later
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -420,6 +477,9 @@ func (t *Tree) Cap(root common.Hash, layers int) error {
 
 		// Replace the entire snapshot tree with the flat base
 		t.layers = map[common.Hash]snapshot{base.root: base}
+		if t.journal != nil {
+			t.journal.prune(t.liveLayers())
+		}
 		return nil
 	}
 	persisted := t.cap(diff, layers)
@@ -457,6 +517,12 @@ func (t *Tree) Cap(root common.Hash, layers int) error {
 			}
 		}
 		rebloom(persisted.root)
+
+		// Arbitrum: the layers flattened into the disk layer can't be recovered
+		// from the incremental journal anymore
+		if t.journal != nil {
+			t.journal.prune(t.liveLayers())
+		}
 	}
 	return nil
 }
@@ -506,7 +572,7 @@ func (t *Tree) cap(diff *diffLayer, layers int) *diskLayer {
 			t.onFlatten()
 		}
 		diff.parent = flattened
-		if flattened.memory < aggregatorMemoryLimit {
+		if flattened.memory < t.aggregatorLimit() {
 			// Accumulator layer is smaller than the limit, so we can abort, unless
 			// there's a snapshot being generated currently. In that case, the trie
 			// will move from underneath the generator so we **must** merge all the
@@ -669,6 +735,8 @@ func diffToDisk(bottom *diffLayer) *diskLayer {
 		triedb:     base.triedb,
 		genMarker:  base.genMarker,
 		genPending: base.genPending,
+		genStats:   base.genStats,
+		genLimiter: base.genLimiter,
 	}
 	// If snapshot generation hasn't finished yet, port over all the starts and
 	// continue where the previous round left off.
@@ -685,6 +753,10 @@ func diffToDisk(bottom *diffLayer) *diskLayer {
 
 // Release releases resources
 func (t *Tree) Release() {
+	t.StopVerifier()
+	if t.journal != nil {
+		t.journal.close()
+	}
 	if dl := t.disklayer(); dl != nil {
 		dl.Release()
 	}
@@ -770,8 +842,14 @@ func (t *Tree) Rebuild(root common.Hash) {
 	// Start generating a new snapshot from scratch on a background thread. The
 	// generator will run a wiper first if there's not one running right now.
 	log.Info("Rebuilding state snapshot")
+	base := generateSnapshot(t.diskdb, t.triedb, t.config.CacheSize, root)
+	base.setLimiter(t.limiter)
+
 	t.layers = map[common.Hash]snapshot{
-		root: generateSnapshot(t.diskdb, t.triedb, t.config.CacheSize, root),
+		root: base,
+	}
+	if t.journal != nil {
+		t.journal.prune(t.liveLayers())
 	}
 }
 
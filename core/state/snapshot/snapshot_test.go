@@ -490,3 +490,43 @@ func TestReadStateDuringFlattening(t *testing.T) {
 		t.Fatal("Unexpected blocker")
 	}
 }
+
+// Tests that the configured aggregator limit decides whether the bottom-most
+// diff layer is flushed into the disk layer on capping.
+func TestConfiguredAggregatorLimit(t *testing.T) {
+	for _, limit := range []uint64{1, 1024 * 1024} {
+		base := &diskLayer{
+			diskdb: rawdb.NewMemoryDatabase(),
+			root:   common.HexToHash("0x01"),
+			cache:  fastcache.New(1024 * 500),
+		}
+		snaps := &Tree{
+			config: Config{DiffLayers: 1, AggregatorLimit: limit},
+			layers: map[common.Hash]snapshot{
+				base.root: base,
+			},
+		}
+		accounts := map[common.Hash][]byte{
+			common.HexToHash("0xa1"): randomAccount(),
+		}
+		for i := byte(2); i <= 4; i++ {
+			if err := snaps.Update(common.Hash{31: i}, common.Hash{31: i - 1}, nil, accounts, nil); err != nil {
+				t.Fatalf("failed to create a diff layer: %v", err)
+			}
+		}
+		if err := snaps.Cap(common.Hash{31: 4}, snaps.DiffLayers()); err != nil {
+			t.Fatalf("failed to cap snapshot tree: %v", err)
+		}
+		// A tiny limit flushes the accumulator, a large one retains it
+		want := 3
+		if limit == 1 {
+			want = 2
+		}
+		if n := len(snaps.layers); n != want {
+			t.Errorf("limit %d: post-cap layer count mismatch: have %d, want %d", limit, n, want)
+		}
+	}
+	if layers := new(Tree).DiffLayers(); layers != DefaultDiffLayers {
+		t.Errorf("default diff layers mismatch: have %d, want %d", layers, DefaultDiffLayers)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Source is the part of the snapshot tree a data item is served from.
+type Source int
+
+const (
+	SourceDiff  Source = iota // In-memory diff layer
+	SourceClean               // Clean cache of the disk layer
+	SourceDisk                // Persistent disk layer
+)
+
+// AccountSource reports which part of the snapshot tree would serve the given
+// account, without retrieving it. It must be called before the account is read,
+// since a disk read populates the clean cache.
+func AccountSource(snap Snapshot, hash common.Hash) Source {
+	for {
+		switch layer := snap.(type) {
+		case *diffLayer:
+			layer.lock.RLock()
+			_, updated := layer.accountData[hash]
+			_, destructed := layer.destructSet[hash]
+			parent := layer.parent
+			layer.lock.RUnlock()
+
+			if updated || destructed {
+				return SourceDiff
+			}
+			snap = parent
+		case *diskLayer:
+			if layer.cache.Has(hash[:]) {
+				return SourceClean
+			}
+			return SourceDisk
+		default:
+			return SourceDisk
+		}
+	}
+}
+
+// StorageSource reports which part of the snapshot tree would serve the given
+// storage slot, without retrieving it. It must be called before the slot is
+// read, since a disk read populates the clean cache.
+func StorageSource(snap Snapshot, accountHash, storageHash common.Hash) Source {
+	for {
+		switch layer := snap.(type) {
+		case *diffLayer:
+			layer.lock.RLock()
+			_, updated := layer.storageData[accountHash][storageHash]
+			_, destructed := layer.destructSet[accountHash]
+			parent := layer.parent
+			layer.lock.RUnlock()
+
+			if updated || destructed {
+				return SourceDiff
+			}
+			snap = parent
+		case *diskLayer:
+			if layer.cache.Has(append(accountHash[:], storageHash[:]...)) {
+				return SourceClean
+			}
+			return SourceDisk
+		default:
+			return SourceDisk
+		}
+	}
+}
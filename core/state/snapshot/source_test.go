@@ -0,0 +1,75 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestReadSource(t *testing.T) {
+	var (
+		diskdb  = rawdb.NewMemoryDatabase()
+		onDisk  = common.HexToHash("0xa1")
+		inDiff  = common.HexToHash("0xa2")
+		storage = common.HexToHash("0xb1")
+	)
+	rawdb.WriteAccountSnapshot(diskdb, onDisk, randomAccount())
+	rawdb.WriteStorageSnapshot(diskdb, onDisk, storage, []byte{0x01})
+
+	base := &diskLayer{
+		diskdb: diskdb,
+		root:   common.HexToHash("0x01"),
+		cache:  fastcache.New(1024 * 500),
+	}
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	accounts := map[common.Hash][]byte{inDiff: randomAccount()}
+	storages := map[common.Hash]map[common.Hash][]byte{inDiff: {storage: {0x02}}}
+	if err := snaps.Update(common.HexToHash("0x02"), base.root, nil, accounts, storages); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	head := snaps.Snapshot(common.HexToHash("0x02"))
+
+	if source := AccountSource(head, inDiff); source != SourceDiff {
+		t.Errorf("diff account source mismatch: have %d, want %d", source, SourceDiff)
+	}
+	if source := StorageSource(head, inDiff, storage); source != SourceDiff {
+		t.Errorf("diff slot source mismatch: have %d, want %d", source, SourceDiff)
+	}
+	// Disk reads populate the clean cache
+	if source := AccountSource(head, onDisk); source != SourceDisk {
+		t.Errorf("disk account source mismatch: have %d, want %d", source, SourceDisk)
+	}
+	if source := StorageSource(head, onDisk, storage); source != SourceDisk {
+		t.Errorf("disk slot source mismatch: have %d, want %d", source, SourceDisk)
+	}
+	head.Account(onDisk)
+	head.Storage(onDisk, storage)
+	if source := AccountSource(head, onDisk); source != SourceClean {
+		t.Errorf("cached account source mismatch: have %d, want %d", source, SourceClean)
+	}
+	if source := StorageSource(head, onDisk, storage); source != SourceClean {
+		t.Errorf("cached slot source mismatch: have %d, want %d", source, SourceClean)
+	}
+}
@@ -0,0 +1,565 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxRangeDivergences is the maximum number of divergences reported in
+	// detail for a single verified range, the rest are only counted.
+	maxRangeDivergences = 1024
+
+	// maxRecentDivergences is the number of most recent divergences retained
+	// by the background verifier.
+	maxRecentDivergences = 64
+)
+
+// verifyChunkSize is the number of entries verified while holding the lock of
+// the snapshot tree, which is released in between to not block the flattening
+// of the diff layers for long.
+var verifyChunkSize = 1024
+
+// Kinds of divergences between the flat snapshot and the trie.
+const (
+	DivergenceMissing  = "missing"  // Entry in the trie but not in the snapshot
+	DivergenceExtra    = "extra"    // Entry in the snapshot but not in the trie
+	DivergenceMismatch = "mismatch" // Entry in both, but with different values
+)
+
+// Divergence is an entry of the flat snapshot which doesn't match the trie.
+type Divergence struct {
+	Account  common.Hash  // Hash of the diverged account, or of the account owning the slot
+	Slot     *common.Hash // Hash of the diverged storage slot, nil for accounts
+	Kind     string       // Kind of the divergence
+	Repaired bool         // Whether the snapshot entry was repaired from the trie
+}
+
+// VerifyResult is the outcome of verifying a range of the flat snapshot.
+type VerifyResult struct {
+	Root        common.Hash  // Root of the verified disk layer
+	Origin      common.Hash  // First account hash of the verified range
+	Next        common.Hash  // First account hash after the verified range
+	NextSlot    *common.Hash // First storage slot of Next after the range, nil if Next itself is pending
+	Done        bool         // Whether the range reached the end of the account space
+	Accounts    uint64       // Number of accounts verified
+	Slots       uint64       // Number of storage slots verified
+	Diverged    uint64       // Number of diverged entries found
+	Repaired    uint64       // Number of diverged entries repaired
+	Divergences []Divergence // Diverged entries, capped to maxRangeDivergences
+}
+
+// rangeVerifier accumulates the divergences of a range and repairs them.
+type rangeVerifier struct {
+	dl     *diskLayer
+	batch  ethdb.Batch
+	repair bool
+	result *VerifyResult
+
+	account common.Hash  // Account the verification continues at
+	slot    *common.Hash // Storage slot of the account it continues at, nil if the account itself is pending
+	budget  int          // Number of entries left to verify in the range
+	left    int          // Number of entries left to verify in the current chunk
+}
+
+// report records a divergence, repairing it if requested. A nil value deletes
+// the snapshot entry.
+func (v *rangeVerifier) report(account common.Hash, slot *common.Hash, kind string, value []byte) {
+	v.result.Diverged++
+	if slot == nil {
+		snapVerifyAccountDivergedMeter.Mark(1)
+	} else {
+		snapVerifyStorageDivergedMeter.Mark(1)
+	}
+	if v.repair {
+		switch {
+		case slot == nil && value == nil:
+			rawdb.DeleteAccountSnapshot(v.batch, account)
+			v.dl.cache.Set(account[:], nil)
+		case slot == nil:
+			rawdb.WriteAccountSnapshot(v.batch, account, value)
+			v.dl.cache.Set(account[:], value)
+		case value == nil:
+			rawdb.DeleteStorageSnapshot(v.batch, account, *slot)
+			v.dl.cache.Set(append(account[:], slot[:]...), nil)
+		default:
+			rawdb.WriteStorageSnapshot(v.batch, account, *slot, value)
+			v.dl.cache.Set(append(account[:], slot[:]...), value)
+		}
+		v.result.Repaired++
+		snapVerifyRepairedMeter.Mark(1)
+	}
+	if len(v.result.Divergences) < maxRangeDivergences {
+		v.result.Divergences = append(v.result.Divergences, Divergence{
+			Account:  account,
+			Slot:     slot,
+			Kind:     kind,
+			Repaired: v.repair,
+		})
+	}
+	log.Warn("Snapshot diverged from trie", "account", account, "slot", slot, "kind", kind, "repaired", v.repair)
+}
+
+// consume accounts for a verified entry.
+func (v *rangeVerifier) consume() {
+	v.budget--
+	v.left--
+}
+
+// flush writes out the repairs if the batch grew large enough.
+func (v *rangeVerifier) flush(force bool) error {
+	if !force && v.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := v.batch.Write(); err != nil {
+		return err
+	}
+	v.batch.Reset()
+	return nil
+}
+
+// VerifyRange cross-checks up to limit entries of the flat snapshot, accounts
+// and storage slots alike, starting at origin, against the trie of the disk
+// layer. If originSlot is set, the account at origin was verified by a previous
+// range and only its storage is resumed, from that slot on. Diverged entries are
+// reported and, if repair is set, overwritten with the content of the trie.
+//
+// The entries are verified in chunks, the lock of the tree being released in
+// between for the diff layers to be flattened. The range ends early if the disk
+// layer changes meanwhile, the next range resuming on the new one.
+func (t *Tree) VerifyRange(origin common.Hash, originSlot *common.Hash, limit int, repair bool) (*VerifyResult, error) {
+	if limit <= 0 {
+		return nil, errors.New("non-positive range limit")
+	}
+	v := &rangeVerifier{
+		repair:  repair,
+		result:  &VerifyResult{Origin: origin},
+		account: origin,
+		slot:    originSlot,
+		budget:  limit,
+	}
+	for v.budget > 0 && !v.result.Done {
+		ok, err := t.verifyChunk(v, min(v.budget, verifyChunkSize))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+	}
+	if !v.result.Done {
+		v.result.Next, v.result.NextSlot = v.account, v.slot
+	}
+	return v.result, nil
+}
+
+// verifyChunk verifies up to size entries from the cursor of the range while
+// holding the lock of the tree, advancing the cursor. False is returned if the
+// disk layer changed since the previous chunk of the range.
+func (t *Tree) verifyChunk(v *rangeVerifier, size int) (bool, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	dl := t.disklayer()
+	if dl == nil {
+		return false, errors.New("disk layer is missing")
+	}
+	dl.lock.RLock()
+	stale, generating := dl.stale, dl.genMarker != nil
+	dl.lock.RUnlock()
+	if stale {
+		return false, ErrSnapshotStale
+	}
+	if generating {
+		return false, ErrNotConstructed
+	}
+	if v.dl != nil && v.dl != dl {
+		return false, nil
+	}
+	if v.dl == nil {
+		v.dl, v.batch, v.result.Root = dl, dl.diskdb.NewBatch(), dl.root
+	}
+	v.left = size
+
+	tr, err := trie.New(trie.StateTrieID(dl.root), dl.triedb)
+	if err != nil {
+		return false, err
+	}
+	nodeIt, err := tr.NodeIterator(v.account[:])
+	if err != nil {
+		return false, err
+	}
+	var (
+		trieIt = trie.NewIterator(nodeIt)
+		snapIt = rawdb.NewKeyLengthIterator(dl.diskdb.NewIterator(rawdb.SnapshotAccountPrefix, v.account[:]), len(rawdb.SnapshotAccountPrefix)+common.HashLength)
+		trieOk = trieIt.Next()
+		snapOk = snapIt.Next()
+	)
+	defer snapIt.Release()
+
+	for (trieOk || snapOk) && v.left > 0 {
+		var (
+			trieKey, snapKey []byte
+			cmp              int
+		)
+		if trieOk {
+			trieKey = trieIt.Key
+		}
+		if snapOk {
+			snapKey = snapIt.Key()[len(rawdb.SnapshotAccountPrefix):]
+		}
+		switch {
+		case !snapOk:
+			cmp = -1
+		case !trieOk:
+			cmp = 1
+		default:
+			cmp = bytes.Compare(trieKey, snapKey)
+		}
+		var (
+			hash common.Hash
+			root = types.EmptyRootHash
+		)
+		if cmp <= 0 {
+			hash = common.BytesToHash(trieKey)
+		} else {
+			hash = common.BytesToHash(snapKey)
+		}
+		// The account at the cursor was already verified if its storage is resumed
+		resumed := v.slot != nil && hash == v.account
+		switch {
+		case cmp < 0:
+			// Account only in the trie
+			slim, storageRoot, err := slimAccount(trieIt.Value)
+			if err != nil {
+				return false, err
+			}
+			if !resumed {
+				v.report(hash, nil, DivergenceMissing, slim)
+			}
+			root = storageRoot
+
+		case cmp > 0:
+			// Account only in the snapshot, along with any storage of it
+			if !resumed {
+				v.report(hash, nil, DivergenceExtra, nil)
+			}
+
+		default:
+			slim, storageRoot, err := slimAccount(trieIt.Value)
+			if err != nil {
+				return false, err
+			}
+			if !resumed && !bytes.Equal(slim, snapIt.Value()) {
+				v.report(hash, nil, DivergenceMismatch, slim)
+			}
+			root = storageRoot
+		}
+		start := v.slot
+		if !resumed {
+			start = nil
+			v.result.Accounts++
+			snapVerifyAccountMeter.Mark(1)
+			v.consume()
+		}
+		v.account, v.slot = hash, nil
+
+		next, err := v.verifyStorage(hash, root, start)
+		if err != nil {
+			return false, err
+		}
+		if err := v.flush(false); err != nil {
+			return false, err
+		}
+		if next != nil {
+			// The chunk ended within the storage of the account
+			v.slot = next
+			return true, v.flush(true)
+		}
+		if cmp <= 0 {
+			trieOk = trieIt.Next()
+		}
+		if cmp >= 0 {
+			snapOk = snapIt.Next()
+		}
+	}
+	if trieIt.Err != nil {
+		return false, trieIt.Err
+	}
+	if err := snapIt.Error(); err != nil {
+		return false, err
+	}
+	if err := v.flush(true); err != nil {
+		return false, err
+	}
+	// Position the cursor at the lowest pending account
+	switch {
+	case trieOk && snapOk:
+		next := trieIt.Key
+		if key := snapIt.Key()[len(rawdb.SnapshotAccountPrefix):]; bytes.Compare(key, next) < 0 {
+			next = key
+		}
+		v.account = common.BytesToHash(next)
+	case trieOk:
+		v.account = common.BytesToHash(trieIt.Key)
+	case snapOk:
+		v.account = common.BytesToHash(snapIt.Key()[len(rawdb.SnapshotAccountPrefix):])
+	default:
+		v.result.Done = true
+	}
+	return true, nil
+}
+
+// verifyStorage cross-checks the storage snapshot of an account against its
+// storage trie with the given root, from the given slot on. If the chunk ends
+// before the storage does, the first pending slot is returned.
+func (v *rangeVerifier) verifyStorage(account common.Hash, root common.Hash, start *common.Hash) (*common.Hash, error) {
+	var origin []byte
+	if start != nil {
+		origin = start[:]
+	}
+	var trieIt *trie.Iterator
+	if root != types.EmptyRootHash {
+		tr, err := trie.New(trie.StorageTrieID(v.dl.root, account, root), v.dl.triedb)
+		if err != nil {
+			return nil, err
+		}
+		nodeIt, err := tr.NodeIterator(origin)
+		if err != nil {
+			return nil, err
+		}
+		trieIt = trie.NewIterator(nodeIt)
+	}
+	var (
+		prefix = len(rawdb.SnapshotStoragePrefix) + common.HashLength
+		snapIt = rawdb.NewKeyLengthIterator(v.dl.diskdb.NewIterator(append(common.CopyBytes(rawdb.SnapshotStoragePrefix), account[:]...), origin), prefix+common.HashLength)
+		trieOk = trieIt != nil && trieIt.Next()
+		snapOk = snapIt.Next()
+	)
+	defer snapIt.Release()
+
+	for trieOk || snapOk {
+		var cmp int
+		switch {
+		case !snapOk:
+			cmp = -1
+		case !trieOk:
+			cmp = 1
+		default:
+			cmp = bytes.Compare(trieIt.Key, snapIt.Key()[prefix:])
+		}
+		if v.left <= 0 {
+			var next common.Hash
+			if cmp <= 0 {
+				next = common.BytesToHash(trieIt.Key)
+			} else {
+				next = common.BytesToHash(snapIt.Key()[prefix:])
+			}
+			return &next, nil
+		}
+		switch {
+		case cmp < 0:
+			slot := common.BytesToHash(trieIt.Key)
+			v.report(account, &slot, DivergenceMissing, common.CopyBytes(trieIt.Value))
+			trieOk = trieIt.Next()
+
+		case cmp > 0:
+			slot := common.BytesToHash(snapIt.Key()[prefix:])
+			v.report(account, &slot, DivergenceExtra, nil)
+			snapOk = snapIt.Next()
+
+		default:
+			if !bytes.Equal(trieIt.Value, snapIt.Value()) {
+				slot := common.BytesToHash(trieIt.Key)
+				v.report(account, &slot, DivergenceMismatch, common.CopyBytes(trieIt.Value))
+			}
+			trieOk, snapOk = trieIt.Next(), snapIt.Next()
+		}
+		v.result.Slots++
+		snapVerifyStorageMeter.Mark(1)
+		v.consume()
+
+		if err := v.flush(false); err != nil {
+			return nil, err
+		}
+	}
+	if trieIt != nil && trieIt.Err != nil {
+		return nil, trieIt.Err
+	}
+	return nil, snapIt.Error()
+}
+
+// slimAccount converts a consensus account into its snapshot representation,
+// also returning its storage root.
+func slimAccount(blob []byte) ([]byte, common.Hash, error) {
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return nil, common.Hash{}, err
+	}
+	return types.SlimAccountRLP(account), account.Root, nil
+}
+
+// VerifierConfig is the configuration of the background snapshot verifier.
+type VerifierConfig struct {
+	Limit    int           // Number of entries, accounts and storage slots, verified per range
+	Interval time.Duration // Pause between two ranges
+	Repair   bool          // Whether diverged entries are repaired from the trie
+}
+
+// VerifierStatus is the state of the background snapshot verifier.
+type VerifierStatus struct {
+	Running    bool           // Whether the verifier is running
+	Config     VerifierConfig // Configuration of the (last) verifier
+	Cursor     common.Hash    // Account hash the next range starts at
+	CursorSlot *common.Hash   // Storage slot of the cursor account the next range resumes at, if any
+	Rounds     uint64         // Number of completed passes over the whole snapshot
+	Accounts   uint64         // Number of accounts verified
+	Slots      uint64         // Number of storage slots verified
+	Diverged   uint64         // Number of diverged entries found
+	Repaired   uint64         // Number of diverged entries repaired
+	Recent     []Divergence   // Most recent divergences
+	LastError  string         // Last error preventing a range from being verified
+}
+
+// verifier continuously verifies the flat snapshot range by range.
+type verifier struct {
+	status VerifierStatus
+	quit   chan struct{}
+	done   chan struct{}
+	lock   sync.Mutex
+}
+
+// StartVerifier starts verifying the flat snapshot against the trie in the
+// background, range by range, wrapping around once the whole snapshot is done.
+func (t *Tree) StartVerifier(config VerifierConfig) error {
+	if config.Limit <= 0 {
+		return errors.New("non-positive range limit")
+	}
+	t.verifierLock.Lock()
+	defer t.verifierLock.Unlock()
+
+	if t.verifier != nil {
+		t.verifier.lock.Lock()
+		running := t.verifier.status.Running
+		t.verifier.lock.Unlock()
+		if running {
+			return errors.New("verifier already running")
+		}
+	}
+	v := &verifier{
+		status: VerifierStatus{Running: true, Config: config},
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	t.verifier = v
+	go v.loop(t)
+	log.Info("Started snapshot verifier", "limit", config.Limit, "interval", config.Interval, "repair", config.Repair)
+	return nil
+}
+
+// StopVerifier stops the background snapshot verifier, if running.
+func (t *Tree) StopVerifier() {
+	t.verifierLock.Lock()
+	defer t.verifierLock.Unlock()
+
+	if t.verifier == nil {
+		return
+	}
+	select {
+	case <-t.verifier.quit:
+	default:
+		close(t.verifier.quit)
+	}
+	<-t.verifier.done
+}
+
+// VerifierStatus returns the state of the background snapshot verifier.
+func (t *Tree) VerifierStatus() VerifierStatus {
+	t.verifierLock.Lock()
+	defer t.verifierLock.Unlock()
+
+	if t.verifier == nil {
+		return VerifierStatus{}
+	}
+	t.verifier.lock.Lock()
+	defer t.verifier.lock.Unlock()
+
+	status := t.verifier.status
+	status.Recent = append([]Divergence(nil), status.Recent...)
+	return status
+}
+
+func (v *verifier) loop(t *Tree) {
+	defer close(v.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-v.quit:
+			v.lock.Lock()
+			v.status.Running = false
+			v.lock.Unlock()
+			log.Info("Stopped snapshot verifier")
+			return
+		case <-timer.C:
+		}
+		v.lock.Lock()
+		cursor, slot, config := v.status.Cursor, v.status.CursorSlot, v.status.Config
+		v.lock.Unlock()
+
+		result, err := t.VerifyRange(cursor, slot, config.Limit, config.Repair)
+
+		v.lock.Lock()
+		if err != nil {
+			// The snapshot might be under construction, keep retrying
+			v.status.LastError = err.Error()
+		} else {
+			v.status.LastError = ""
+			v.status.Accounts += result.Accounts
+			v.status.Slots += result.Slots
+			v.status.Diverged += result.Diverged
+			v.status.Repaired += result.Repaired
+			v.status.Recent = append(v.status.Recent, result.Divergences...)
+			if n := len(v.status.Recent); n > maxRecentDivergences {
+				v.status.Recent = v.status.Recent[n-maxRecentDivergences:]
+			}
+			if result.Done {
+				v.status.Cursor, v.status.CursorSlot = common.Hash{}, nil
+				v.status.Rounds++
+			} else {
+				v.status.Cursor, v.status.CursorSlot = result.Next, result.NextSlot
+			}
+		}
+		v.lock.Unlock()
+
+		timer.Reset(config.Interval)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// newVerifierTree generates a snapshot of a small state and wraps it into a tree.
+func newVerifierTree(t *testing.T, scheme string) (*testHelper, *Tree) {
+	helper := newHelper(scheme)
+	stRoot := helper.makeStorageTrie(common.Hash{}, []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, false)
+
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: uint256.NewInt(1), Root: stRoot, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: uint256.NewInt(2), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addTrieAccount("acc-3", &types.StateAccount{Balance: uint256.NewInt(3), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.makeStorageTrie(hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Snapshot generation failed")
+	}
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+
+	return helper, &Tree{layers: map[common.Hash]snapshot{root: snap}, limiter: new(generatorLimiter)}
+}
+
+// corruptSnapshot introduces one divergence of each kind into the flat state.
+func corruptSnapshot(helper *testHelper) {
+	helper.addSnapAccount("acc-2", &types.StateAccount{Balance: uint256.NewInt(20), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	helper.addSnapAccount("acc-9", &types.StateAccount{Balance: uint256.NewInt(9), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()})
+	rawdb.DeleteStorageSnapshot(helper.diskdb, hashData([]byte("acc-1")), hashData([]byte("key-2")))
+}
+
+// Tests that diverged snapshot entries are detected and repaired.
+func TestVerifyRange(t *testing.T) {
+	testVerifyRange(t, rawdb.HashScheme)
+	testVerifyRange(t, rawdb.PathScheme)
+}
+
+func testVerifyRange(t *testing.T, scheme string) {
+	helper, tree := newVerifierTree(t, scheme)
+
+	result, err := tree.VerifyRange(common.Hash{}, nil, 100, false)
+	if err != nil {
+		t.Fatalf("failed to verify range: %v", err)
+	}
+	if !result.Done || result.Accounts != 3 || result.Slots != 3 || result.Diverged != 0 {
+		t.Fatalf("unexpected result of clean snapshot: %+v", result)
+	}
+	corruptSnapshot(helper)
+
+	// Report the divergences without repairing them, twice to ensure nothing changed
+	for i := 0; i < 2; i++ {
+		result, err = tree.VerifyRange(common.Hash{}, nil, 100, false)
+		if err != nil {
+			t.Fatalf("failed to verify range: %v", err)
+		}
+		if result.Accounts != 4 || result.Diverged != 3 || result.Repaired != 0 {
+			t.Fatalf("unexpected result of corrupt snapshot: %+v", result)
+		}
+	}
+	kinds := make(map[string]int)
+	for _, d := range result.Divergences {
+		kinds[d.Kind]++
+		if d.Kind == DivergenceMissing && (d.Slot == nil || *d.Slot != hashData([]byte("key-2"))) {
+			t.Fatalf("unexpected missing entry: %+v", d)
+		}
+	}
+	if kinds[DivergenceMissing] != 1 || kinds[DivergenceExtra] != 1 || kinds[DivergenceMismatch] != 1 {
+		t.Fatalf("unexpected divergences: %v", kinds)
+	}
+	// Repair the divergences and ensure the snapshot is clean afterwards
+	if result, err = tree.VerifyRange(common.Hash{}, nil, 100, true); err != nil {
+		t.Fatalf("failed to repair range: %v", err)
+	}
+	if result.Diverged != 3 || result.Repaired != 3 {
+		t.Fatalf("unexpected result of repair: %+v", result)
+	}
+	if result, err = tree.VerifyRange(common.Hash{}, nil, 100, false); err != nil {
+		t.Fatalf("failed to verify range: %v", err)
+	}
+	if result.Accounts != 3 || result.Diverged != 0 {
+		t.Fatalf("unexpected result of repaired snapshot: %+v", result)
+	}
+	dl := tree.disklayer()
+	if acc, _ := dl.Account(hashData([]byte("acc-2"))); acc == nil || acc.Balance.Uint64() != 2 {
+		t.Fatalf("account not repaired: %v", acc)
+	}
+	if acc, _ := dl.Account(hashData([]byte("acc-9"))); acc != nil {
+		t.Fatalf("extra account not removed: %v", acc)
+	}
+}
+
+// Tests that the snapshot can be verified in multiple ranges, resuming within
+// the storage of the accounts.
+func TestVerifyRangeResume(t *testing.T) {
+	helper, tree := newVerifierTree(t, rawdb.HashScheme)
+	corruptSnapshot(helper)
+
+	var (
+		origin   common.Hash
+		slot     *common.Hash
+		accounts uint64
+		slots    uint64
+		diverged uint64
+		ranges   int
+	)
+	for {
+		result, err := tree.VerifyRange(origin, slot, 1, false)
+		if err != nil {
+			t.Fatalf("failed to verify range: %v", err)
+		}
+		if result.Accounts+result.Slots != 1 {
+			t.Fatalf("range exceeded its limit: %+v", result)
+		}
+		accounts += result.Accounts
+		slots += result.Slots
+		diverged += result.Diverged
+		ranges++
+		if result.Done {
+			break
+		}
+		origin, slot = result.Next, result.NextSlot
+	}
+	if ranges != 7 || accounts != 4 || slots != 3 || diverged != 3 {
+		t.Fatalf("unexpected verification: ranges %d, accounts %d, slots %d, diverged %d", ranges, accounts, slots, diverged)
+	}
+}
+
+// Tests that a range verified in multiple chunks, releasing the tree lock in
+// between, matches one verified at once.
+func TestVerifyRangeChunks(t *testing.T) {
+	helper, tree := newVerifierTree(t, rawdb.HashScheme)
+	corruptSnapshot(helper)
+
+	defer func(size int) { verifyChunkSize = size }(verifyChunkSize)
+	verifyChunkSize = 2
+
+	result, err := tree.VerifyRange(common.Hash{}, nil, 100, false)
+	if err != nil {
+		t.Fatalf("failed to verify range: %v", err)
+	}
+	if !result.Done || result.Accounts != 4 || result.Slots != 3 || result.Diverged != 3 {
+		t.Fatalf("unexpected result of chunked verification: %+v", result)
+	}
+}
+
+// Tests that the background verifier repairs the snapshot and wraps around.
+func TestVerifier(t *testing.T) {
+	helper, tree := newVerifierTree(t, rawdb.HashScheme)
+	corruptSnapshot(helper)
+
+	if err := tree.StartVerifier(VerifierConfig{Limit: 2, Repair: true}); err != nil {
+		t.Fatalf("failed to start verifier: %v", err)
+	}
+	if err := tree.StartVerifier(VerifierConfig{Limit: 2}); err == nil {
+		t.Fatal("started verifier twice")
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for tree.VerifierStatus().Rounds < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("verifier didn't complete: %+v", tree.VerifierStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tree.StopVerifier()
+
+	status := tree.VerifierStatus()
+	if status.Running {
+		t.Fatal("verifier still running")
+	}
+	if status.Diverged != 3 || status.Repaired != 3 || len(status.Recent) != 3 {
+		t.Fatalf("unexpected verifier status: %+v", status)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -152,6 +153,7 @@ func (s *stateObject) getState(key common.Hash) (common.Hash, bool) {
 	// If we have a dirty value for this state entry, return it
 	value, dirty := s.dirtyStorage[key]
 	if dirty {
+		s.db.trackStorageRead(accessLive)
 		return value, true
 	}
 	// Otherwise return the entry's original value
@@ -160,11 +162,17 @@ func (s *stateObject) getState(key common.Hash) (common.Hash, bool) {
 
 // GetCommittedState retrieves a value from the committed account storage trie.
 func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
+	// Arbitrum: serialize concurrent readers of the account's storage
+	s.db.readLocks.lockAddress(s.address)
+	defer s.db.readLocks.unlockAddress(s.address)
+
 	// If we have a pending write or clean cached, return that
 	if value, pending := s.pendingStorage[key]; pending {
+		s.db.trackStorageRead(accessLive)
 		return value
 	}
 	if value, cached := s.originStorage[key]; cached {
+		s.db.trackStorageRead(accessLive)
 		return value
 	}
 	// If the object was destructed in *this* block (and potentially resurrected),
@@ -174,18 +182,40 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 	//      have been handles via pendingStorage above.
 	//   2) we don't have new values, and can deliver empty response back
 	if _, destructed := s.db.stateObjectsDestruct[s.address]; destructed {
+		s.db.trackStorageRead(accessLive)
 		return common.Hash{}
 	}
-	// If no live objects are available, attempt to use snapshots
+	// Arbitrum: consult the process-wide slot cache before hitting the
+	// snapshot or the trie, unless the state is deterministic (recording).
+	// Verkle accounts don't carry a storage root to key the slots with.
 	var (
 		enc   []byte
 		err   error
 		value common.Hash
+		khash = crypto.Keccak256Hash(key.Bytes())
 	)
+	cacheable := !s.db.deterministic && !s.db.db.TrieDB().IsVerkle()
+	if cacheable {
+		if value, ok := globalStorageCache.slot(s.data.Root, s.address, khash); ok {
+			s.db.trackStorageRead(accessCache)
+			s.originStorage[key] = value
+			return value
+		}
+	}
+	// If no live objects are available, attempt to use snapshots
 	if s.db.snap != nil {
-		start := time.Now()
-		enc, err = s.db.snap.Storage(s.addrHash, crypto.Keccak256Hash(key.Bytes()))
-		s.db.SnapshotStorageReads += time.Since(start)
+		var (
+			start  = time.Now()
+			source accessSource
+		)
+		if s.db.accessStats != nil {
+			source = snapshotAccessSource(snapshot.StorageSource(s.db.snap, s.addrHash, khash))
+		}
+		enc, err = s.db.snap.Storage(s.addrHash, khash)
+		s.db.meterRead(&s.db.SnapshotStorageReads, start)
+		if err == nil {
+			s.db.trackStorageRead(source)
+		}
 
 		if len(enc) > 0 {
 			_, content, _, err := rlp.Split(enc)
@@ -204,7 +234,8 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 			return common.Hash{}
 		}
 		val, err := tr.GetStorage(s.address, key.Bytes())
-		s.db.StorageReads += time.Since(start)
+		s.db.meterRead(&s.db.StorageReads, start)
+		s.db.trackStorageRead(accessTrie)
 
 		if err != nil {
 			s.db.setError(err)
@@ -212,6 +243,9 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 		}
 		value.SetBytes(val)
 	}
+	if cacheable && s.db.Error() == nil {
+		globalStorageCache.setSlot(s.data.Root, s.address, khash, value)
+	}
 	s.originStorage[key] = value
 	return value
 }
@@ -500,6 +534,9 @@ func (s *stateObject) Address() common.Address {
 
 // Code returns the contract code associated with this object, if any.
 func (s *stateObject) Code() []byte {
+	s.db.readLocks.lockAddress(s.address)
+	defer s.db.readLocks.unlockAddress(s.address)
+
 	if len(s.code) != 0 {
 		return s.code
 	}
@@ -518,6 +555,9 @@ func (s *stateObject) Code() []byte {
 // or zero if none. This method is an almost mirror of Code, but uses a cache
 // inside the database to avoid loading codes seen recently.
 func (s *stateObject) CodeSize() int {
+	s.db.readLocks.lockAddress(s.address)
+	defer s.db.readLocks.unlockAddress(s.address)
+
 	if len(s.code) != 0 {
 		return len(s.code)
 	}
@@ -531,6 +571,29 @@ func (s *stateObject) CodeSize() int {
 	return size
 }
 
+// CodePrefix returns up to n leading bytes of the contract code associated
+// with this object. Like CodeSize, it avoids loading the full code if the
+// database can serve the prefix on its own.
+func (s *stateObject) CodePrefix(n int) []byte {
+	if n <= 0 {
+		return nil
+	}
+	s.db.readLocks.lockAddress(s.address)
+	defer s.db.readLocks.unlockAddress(s.address)
+
+	if len(s.code) != 0 {
+		return common.CopyBytes(s.code[:min(n, len(s.code))])
+	}
+	if bytes.Equal(s.CodeHash(), types.EmptyCodeHash.Bytes()) {
+		return nil
+	}
+	prefix, err := s.db.db.ContractCodePrefix(s.address, common.BytesToHash(s.CodeHash()), n)
+	if err != nil {
+		s.db.setError(fmt.Errorf("can't load code prefix %x: %v", s.CodeHash(), err))
+	}
+	return prefix
+}
+
 func (s *stateObject) SetCode(codeHash common.Hash, code []byte) {
 	prevcode := s.Code()
 	s.db.journal.append(codeChange{
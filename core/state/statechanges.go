@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// StateChanges is the set of account and storage mutations persisted by a
+// single state commit. The maps are the ones accumulated by the StateDB for
+// the snapshot layers, so they are keyed by the hashes of the account
+// addresses and storage slots and hold their slim RLP encoded values.
+type StateChanges struct {
+	Root           common.Hash                               // State root after the commit
+	OriginRoot     common.Hash                               // State root before the commit
+	Destructs      map[common.Hash]struct{}                  // Accounts destructed in the block
+	Accounts       map[common.Hash][]byte                    // Mutated accounts in slim RLP encoding
+	Storages       map[common.Hash]map[common.Hash][]byte    // Mutated slots in prefix-zero trimmed RLP encoding
+	AccountsOrigin map[common.Address][]byte                 // Original values of the mutated accounts
+	StoragesOrigin map[common.Address]map[common.Hash][]byte // Original values of the mutated slots
+}
+
+// Account returns the post-commit value of the given account and whether it
+// was actually modified. A nil account with modified set means the account
+// was deleted.
+func (c *StateChanges) Account(addr common.Address) (*types.StateAccount, bool, error) {
+	addrHash := crypto.Keccak256Hash(addr[:])
+	data, updated := c.Accounts[addrHash]
+	if !updated {
+		_, destructed := c.Destructs[addrHash]
+		return nil, destructed, nil
+	}
+	if origin, ok := c.AccountsOrigin[addr]; ok && bytes.Equal(origin, data) {
+		return nil, false, nil
+	}
+	account, err := types.FullAccount(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return account, true, nil
+}
+
+// Storage returns the post-commit value of the given storage slot and whether
+// it was modified.
+func (c *StateChanges) Storage(addr common.Address, slot common.Hash) (common.Hash, bool, error) {
+	slots := c.Storages[crypto.Keccak256Hash(addr[:])]
+	if slots == nil {
+		return common.Hash{}, false, nil
+	}
+	data, ok := slots[crypto.Keccak256Hash(slot[:])]
+	if !ok {
+		return common.Hash{}, false, nil
+	}
+	if len(data) == 0 {
+		return common.Hash{}, true, nil
+	}
+	_, content, _, err := rlp.Split(data)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return common.BytesToHash(content), true, nil
+}
+
+// StorageOrigin returns the pre-commit value of the given storage slot and
+// whether it was modified.
+func (c *StateChanges) StorageOrigin(addr common.Address, slot common.Hash) (common.Hash, bool, error) {
+	data, ok := c.StoragesOrigin[addr][crypto.Keccak256Hash(slot[:])]
+	if !ok {
+		return common.Hash{}, false, nil
+	}
+	if len(data) == 0 {
+		return common.Hash{}, true, nil
+	}
+	_, content, _, err := rlp.Split(data)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return common.BytesToHash(content), true, nil
+}
+
+// StorageWrites returns the number of storage slots modified by the commit,
+// by account.
+func (c *StateChanges) StorageWrites() map[common.Address]int {
+	writes := make(map[common.Address]int, len(c.StoragesOrigin))
+	for addr, slots := range c.StoragesOrigin {
+		if len(slots) > 0 {
+			writes[addr] = len(slots)
+		}
+	}
+	return writes
+}
+
+// CommittedChanges returns the state changes persisted by the last Commit,
+// or nil if the state has not been committed yet.
+func (s *StateDB) CommittedChanges() *StateChanges {
+	return s.committedChanges
+}
@@ -19,9 +19,11 @@ package state
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"maps"
 	"math/big"
+	"runtime"
 	"slices"
 	"sort"
 	"sync"
@@ -35,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
 	"github.com/ethereum/go-ethereum/trie/triestate"
@@ -177,6 +180,32 @@ type StateDB struct {
 	// Testing hooks
 	onCommit func(states *triestate.Set) // Hook invoked when commit is performed
 
+	// Arbitrum: the account and storage changes persisted by the last commit
+	committedChanges *StateChanges
+
+	// Arbitrum: the account lifecycle events of the last commit
+	committedEvents []AccountEvent
+
+	// Arbitrum: the accounts and storage slots accessed over the whole block
+	blockAccessList *accessList
+
+	// Arbitrum: read classification of the current transaction, nil if disabled
+	accessStats *AccessStats
+
+	// Arbitrum: journal activity of the current and the last finalised transaction
+	journalStats     JournalStats
+	lastJournalStats JournalStats
+
+	// Arbitrum: locks guarding the read paths, nil unless concurrent reads are enabled
+	readLocks *readLocks
+
+	// Arbitrum: footprint recording state of the current transaction, see BeginTxAccess
+	txAccessOuter  *accessList
+	txAccessMark   int
+	txAccessDelta  *big.Int
+	txAccessStylus stylusMark
+	txBlindCredits map[common.Address]struct{}
+
 	deterministic bool
 }
 
@@ -186,13 +215,20 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 	if err != nil {
 		return nil, err
 	}
+	return newWithTrie(root, tr, db, snaps), nil
+}
+
+// newWithTrie creates a new state on top of an already opened account trie.
+func newWithTrie(root common.Hash, tr Trie, db Database, snaps *snapshot.Tree) *StateDB {
 	sdb := &StateDB{
 		arbExtraData: &ArbitrumExtraData{
 			unexpectedBalanceDelta: new(big.Int),
+			balanceDeltaMark:       new(big.Int),
 			openWasmPages:          0,
 			everWasmPages:          0,
 			activatedWasms:         make(map[common.Hash]ActivatedWasm),
 			recentWasms:            NewRecentWasms(),
+			stylusModules:          make(map[common.Hash]struct{}),
 		},
 
 		db:                   db,
@@ -210,21 +246,34 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 		preimages:            make(map[common.Hash][]byte),
 		journal:              newJournal(),
 		accessList:           newAccessList(),
+		blockAccessList:      newAccessList(),
 		transientStorage:     newTransientStorage(),
 		hasher:               crypto.NewKeccakState(),
 	}
 	if sdb.snaps != nil {
 		sdb.snap = sdb.snaps.Snapshot(root)
 	}
-	return sdb, nil
+	return sdb
 }
 
 func (s *StateDB) FilterTx() {
 	s.arbExtraData.arbTxFilter = true
 }
 
+// FilterTxWithReason filters the current transaction, recording why it was filtered
+func (s *StateDB) FilterTxWithReason(reason string) {
+	s.arbExtraData.arbTxFilter = true
+	s.arbExtraData.arbTxFilterReason = reason
+}
+
 func (s *StateDB) ClearTxFilter() {
 	s.arbExtraData.arbTxFilter = false
+	s.arbExtraData.arbTxFilterReason = ""
+}
+
+// TxFilterReason returns why the current transaction was filtered, if a reason was given
+func (s *StateDB) TxFilterReason() string {
+	return s.arbExtraData.arbTxFilterReason
 }
 
 func (s *StateDB) IsTxFiltered() bool {
@@ -260,6 +309,9 @@ func (s *StateDB) StopPrefetcher() {
 
 // setError remembers the first non-nil error it is called with.
 func (s *StateDB) setError(err error) {
+	s.readLocks.lockMisc()
+	defer s.readLocks.unlockMisc()
+
 	if s.dbErr == nil {
 		s.dbErr = err
 	}
@@ -267,6 +319,9 @@ func (s *StateDB) setError(err error) {
 
 // Error returns the memorized database failure occurred earlier.
 func (s *StateDB) Error() error {
+	s.readLocks.lockMisc()
+	defer s.readLocks.unlockMisc()
+
 	return s.dbErr
 }
 
@@ -318,6 +373,9 @@ func (s *StateDB) Preimages() map[common.Hash][]byte {
 // AddRefund adds gas to the refund counter
 func (s *StateDB) AddRefund(gas uint64) {
 	s.journal.append(refundChange{prev: s.refund})
+	if s.logger != nil && s.logger.OnRefundChange != nil {
+		s.logger.OnRefundChange(s.refund, s.refund+gas, false)
+	}
 	s.refund += gas
 }
 
@@ -328,6 +386,9 @@ func (s *StateDB) SubRefund(gas uint64) {
 	if gas > s.refund {
 		panic(fmt.Sprintf("Refund counter below zero (gas: %d > refund: %d)", gas, s.refund))
 	}
+	if s.logger != nil && s.logger.OnRefundChange != nil {
+		s.logger.OnRefundChange(s.refund, s.refund-gas, false)
+	}
 	s.refund -= gas
 }
 
@@ -373,6 +434,150 @@ func (s *StateDB) GetStorageRoot(addr common.Address) common.Hash {
 	return common.Hash{}
 }
 
+// StorageTrie returns an independent copy of the storage trie of an account
+// with all the uncommitted storage changes applied, or nil if the account does
+// not exist. Untouched nodes are resolved against the state the StateDB was
+// opened at, so the trie stays readable on path-scheme databases even if the
+// StateDB holds an intermediate state.
+func (s *StateDB) StorageTrie(addr common.Address) (Trie, error) {
+	obj := s.getStateObject(addr)
+	if obj == nil {
+		return nil, nil
+	}
+	var tr Trie
+	if obj.trie != nil {
+		tr = s.db.CopyTrie(obj.trie)
+	} else {
+		var err error
+		tr, err = s.db.OpenStorageTrie(s.originalRoot, addr, obj.data.Root, s.trie)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, storage := range []Storage{obj.pendingStorage, obj.dirtyStorage} {
+		for key, value := range storage {
+			var err error
+			if value == (common.Hash{}) {
+				err = tr.DeleteStorage(addr, key[:])
+			} else {
+				err = tr.UpdateStorage(addr, key[:], common.TrimLeftZeroes(value[:]))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tr, nil
+}
+
+// ErrNoStorageSnapshot is returned when iterating the storage of an account
+// without a state snapshot covering the state the StateDB was opened at.
+var ErrNoStorageSnapshot = errors.New("storage iteration requires the state snapshot")
+
+// IterateStorage calls fn for the storage slots of an account in hashed key
+// order, starting at the given hash, with all the uncommitted storage changes
+// applied. Slots are passed RLP encoded, as stored in the snapshot, along with
+// the preimage of the changed slots (nil for the others, whose preimages are in
+// the trie database if recorded). The iteration stops as soon as fn returns
+// false.
+//
+// The slots are read from the snapshot of the state the StateDB was opened at,
+// so the storage trie is never resolved. ErrNoStorageSnapshot is returned if
+// the snapshot is not available.
+func (s *StateDB) IterateStorage(addr common.Address, start common.Hash, fn func(hash common.Hash, key *common.Hash, slot []byte) bool) error {
+	obj := s.getStateObject(addr)
+	if obj == nil {
+		return nil
+	}
+	if s.snap == nil {
+		return ErrNoStorageSnapshot
+	}
+	// Collect the uncommitted changes by slot hash, in the order they apply:
+	// the ones already flushed into the trie, then the pending and the dirty
+	// ones. Deletions are tracked as empty slots.
+	var (
+		overlay   = make(map[common.Hash][]byte)
+		preimages = make(map[common.Hash]common.Hash)
+	)
+	if flushed := s.storages[obj.addrHash]; len(flushed) > 0 {
+		maps.Copy(overlay, flushed)
+		// The flushed slots are tracked by hash only, look their keys up
+		// among the ones loaded into the object.
+		for key := range obj.originStorage {
+			hash := crypto.HashData(s.hasher, key[:])
+			if _, ok := flushed[hash]; ok {
+				preimages[hash] = key
+			}
+		}
+	}
+	for _, storage := range []Storage{obj.pendingStorage, obj.dirtyStorage} {
+		for key, value := range storage {
+			var slot []byte
+			if value != (common.Hash{}) {
+				// Encoding []byte cannot fail, ok to ignore the error.
+				slot, _ = rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+			}
+			hash := crypto.HashData(s.hasher, key[:])
+			overlay[hash], preimages[hash] = slot, key
+		}
+	}
+	changes := make([]common.Hash, 0, len(overlay))
+	for hash := range overlay {
+		if hash.Cmp(start) >= 0 {
+			changes = append(changes, hash)
+		}
+	}
+	slices.SortFunc(changes, common.Hash.Cmp)
+
+	// Destructed accounts start over from an empty storage, so only the
+	// changes apply to them.
+	var (
+		iter snapshot.StorageIterator
+		more bool
+	)
+	if _, destructed := s.stateObjectsDestruct[addr]; !destructed && obj.data.Root != types.EmptyRootHash {
+		var err error
+		if iter, err = s.snaps.StorageIterator(s.originalRoot, obj.addrHash, start); err != nil {
+			return err
+		}
+		defer iter.Release()
+		more = iter.Next()
+	}
+	for more || len(changes) > 0 {
+		var (
+			hash common.Hash
+			slot []byte
+		)
+		if more && (len(changes) == 0 || iter.Hash().Cmp(changes[0]) < 0) {
+			hash, slot = iter.Hash(), common.CopyBytes(iter.Slot())
+			if err := iter.Error(); err != nil { // error might occur after Slot function
+				return err
+			}
+			more = iter.Next()
+		} else {
+			hash, slot = changes[0], overlay[changes[0]]
+			if more && iter.Hash() == hash {
+				more = iter.Next() // overridden by the change
+			}
+			changes = changes[1:]
+		}
+		if len(slot) == 0 {
+			continue // deleted slot
+		}
+		var key *common.Hash
+		if preimage, ok := preimages[hash]; ok {
+			key = &preimage
+		}
+		if !fn(hash, key, slot) {
+			return nil
+		}
+	}
+	if iter != nil {
+		return iter.Error()
+	}
+	return nil
+}
+
 // TxIndex returns the current transaction index set by Prepare.
 func (s *StateDB) TxIndex() int {
 	return s.txIndex
@@ -394,6 +599,16 @@ func (s *StateDB) GetCodeSize(addr common.Address) int {
 	return 0
 }
 
+// GetCodePrefix returns up to n leading bytes of the code of the given
+// account, without loading the full code where possible.
+func (s *StateDB) GetCodePrefix(addr common.Address, n int) []byte {
+	stateObject := s.getStateObject(addr)
+	if stateObject != nil {
+		return stateObject.CodePrefix(n)
+	}
+	return nil
+}
+
 func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
@@ -404,6 +619,11 @@ func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 // GetState retrieves a value from the given account's storage trie.
 func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
+	// Arbitrum: track the storage slots accessed over the block
+	s.readLocks.lockMisc()
+	s.blockAccessList.AddSlot(addr, hash)
+	s.readLocks.unlockMisc()
+
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(hash)
@@ -413,6 +633,11 @@ func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
 
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	// Arbitrum: track the storage slots accessed over the block
+	s.readLocks.lockMisc()
+	s.blockAccessList.AddSlot(addr, hash)
+	s.readLocks.unlockMisc()
+
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetCommittedState(hash)
@@ -439,7 +664,13 @@ func (s *StateDB) HasSelfDestructed(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (s *StateDB) AddBalance(addr common.Address, amount *uint256.Int, reason tracing.BalanceChangeReason) {
+	// Arbitrum: a credit doesn't depend on the balance, see BeginTxAccess
+	blind := s.creditsBlindly(addr)
+
 	stateObject := s.getOrNewStateObject(addr)
+	if blind {
+		s.forgetCreditRead(addr)
+	}
 	if stateObject != nil {
 		s.arbExtraData.unexpectedBalanceDelta.Add(s.arbExtraData.unexpectedBalanceDelta, amount.ToBig())
 		stateObject.AddBalance(amount, reason)
@@ -473,6 +704,7 @@ func (s *StateDB) ExpectBalanceBurn(amount *big.Int) {
 		panic(fmt.Sprintf("ExpectBalanceBurn called with negative amount %v", amount))
 	}
 	s.arbExtraData.unexpectedBalanceDelta.Add(s.arbExtraData.unexpectedBalanceDelta, amount)
+	s.journal.append(balanceDeltaChange{amount: new(big.Int).Set(amount)})
 }
 
 func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
@@ -490,6 +722,9 @@ func (s *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	// Arbitrum: track the storage slots accessed over the block
+	s.blockAccessList.AddSlot(addr, key)
+
 	stateObject := s.getOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(key, value)
@@ -631,22 +866,58 @@ func (s *StateDB) deleteStateObject(addr common.Address) {
 // getStateObject retrieves a state object given by the address, returning nil if
 // the object is not found or was deleted in this execution context.
 func (s *StateDB) getStateObject(addr common.Address) *stateObject {
+	// Arbitrum: track the accounts accessed over the block
+	s.readLocks.lockMisc()
+	s.blockAccessList.AddAddress(addr)
+	s.readLocks.unlockMisc()
+
 	// Prefer live objects if any is available
-	if obj := s.stateObjects[addr]; obj != nil {
+	if obj := s.liveObject(addr); obj != nil {
+		s.trackAccountRead(accessLive)
 		return obj
 	}
 	// Short circuit if the account is already destructed in this block.
 	if _, ok := s.stateObjectsDestruct[addr]; ok {
+		s.trackAccountRead(accessLive)
 		return nil
 	}
-	// If no live objects are available, attempt to use snapshots
+	// Arbitrum: concurrent readers load each account once, under its lock
+	if s.readLocks != nil {
+		s.readLocks.lockAddress(addr)
+		defer s.readLocks.unlockAddress(addr)
+
+		if obj := s.liveObject(addr); obj != nil {
+			s.trackAccountRead(accessLive)
+			return obj
+		}
+	}
+	// Arbitrum: consult the process-wide account cache before hitting the
+	// snapshot or the trie. Deterministic (recording) states bypass it, as
+	// they need every trie node on the access path to be resolved.
 	var data *types.StateAccount
+	if !s.deterministic {
+		if acc, ok := globalStorageCache.account(s.originalRoot, addr); ok {
+			s.trackAccountRead(accessCache)
+			obj := newObject(s, addr, acc)
+			s.setStateObject(obj)
+			return obj
+		}
+	}
+	// If no live objects are available, attempt to use snapshots
 	if s.snap != nil {
-		start := time.Now()
-		acc, err := s.snap.Account(crypto.HashData(s.hasher, addr.Bytes()))
-		s.SnapshotAccountReads += time.Since(start)
+		var (
+			start    = time.Now()
+			addrHash = crypto.HashData(s.readHasher(), addr.Bytes())
+			source   accessSource
+		)
+		if s.accessStats != nil {
+			source = snapshotAccessSource(snapshot.AccountSource(s.snap, addrHash))
+		}
+		acc, err := s.snap.Account(addrHash)
+		s.meterRead(&s.SnapshotAccountReads, start)
 
 		if err == nil {
+			s.trackAccountRead(source)
 			if acc == nil {
 				return nil
 			}
@@ -668,8 +939,11 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 	if data == nil {
 		start := time.Now()
 		var err error
+		s.readLocks.lockTrie()
 		data, err = s.trie.GetAccount(addr)
-		s.AccountReads += time.Since(start)
+		s.readLocks.unlockTrie()
+		s.meterRead(&s.AccountReads, start)
+		s.trackAccountRead(accessTrie)
 
 		if err != nil {
 			s.setError(fmt.Errorf("getDeleteStateObject (%x) error: %w", addr.Bytes(), err))
@@ -679,6 +953,9 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 			return nil
 		}
 	}
+	if !s.deterministic {
+		globalStorageCache.setAccount(s.originalRoot, addr, data)
+	}
 	// Insert into the live set
 	obj := newObject(s, addr, data)
 	s.setStateObject(obj)
@@ -686,6 +963,10 @@ func (s *StateDB) getStateObject(addr common.Address) *stateObject {
 }
 
 func (s *StateDB) setStateObject(object *stateObject) {
+	if s.readLocks != nil {
+		s.readLocks.objects.Lock()
+		defer s.readLocks.objects.Unlock()
+	}
 	s.stateObjects[object.Address()] = object
 }
 
@@ -744,11 +1025,17 @@ func (s *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		arbExtraData: &ArbitrumExtraData{
 			unexpectedBalanceDelta: new(big.Int).Set(s.arbExtraData.unexpectedBalanceDelta),
+			balanceDeltaMark:       new(big.Int).Set(s.arbExtraData.balanceDeltaMark),
 			activatedWasms:         make(map[common.Hash]ActivatedWasm, len(s.arbExtraData.activatedWasms)),
 			recentWasms:            s.arbExtraData.recentWasms.Copy(),
 			openWasmPages:          s.arbExtraData.openWasmPages,
 			everWasmPages:          s.arbExtraData.everWasmPages,
+			wasmPages:              s.arbExtraData.wasmPages,
+			wasmPagesErr:           s.arbExtraData.wasmPagesErr,
+			stylusStats:            s.arbExtraData.stylusStats,
+			stylusModules:          maps.Clone(s.arbExtraData.stylusModules),
 			arbTxFilter:            s.arbExtraData.arbTxFilter,
+			arbTxFilterReason:      s.arbExtraData.arbTxFilterReason,
 		},
 
 		db:                   s.db,
@@ -805,6 +1092,10 @@ func (s *StateDB) Copy() *StateDB {
 	// empty lists, so we do it anyway to not blow up if we ever decide copy them
 	// in the middle of a transaction.
 	state.accessList = s.accessList.Copy()
+	state.blockAccessList = s.blockAccessList.Copy()
+	if s.accessStats != nil {
+		state.accessStats = s.AccessStats()
+	}
 	state.transientStorage = s.transientStorage.Copy()
 
 	// Arbitrum: copy wasm calls and activated WASMs
@@ -818,6 +1109,10 @@ func (s *StateDB) Copy() *StateDB {
 		// It's fine to skip a deep copy since activations are immutable.
 		state.arbExtraData.activatedWasms[moduleHash] = asmMap
 	}
+	if s.arbExtraData.l1DataUsage != nil {
+		state.arbExtraData.l1DataUsage = s.arbExtraData.l1DataUsage.Copy()
+	}
+	state.arbExtraData.selfDestructs = slices.Clone(s.arbExtraData.selfDestructs)
 
 	// If there's a prefetcher running, make an inactive copy of it that can
 	// only access data but does not actively preload (since the user will not
@@ -833,6 +1128,7 @@ func (s *StateDB) Snapshot() int {
 	id := s.nextRevisionId
 	s.nextRevisionId++
 	s.validRevisions = append(s.validRevisions, revision{id, s.journal.length(), new(big.Int).Set(s.arbExtraData.unexpectedBalanceDelta)})
+	s.trackSnapshot()
 	return id
 }
 
@@ -850,6 +1146,7 @@ func (s *StateDB) RevertToSnapshot(revid int) {
 	s.arbExtraData.unexpectedBalanceDelta = new(big.Int).Set(revision.unexpectedBalanceDelta)
 
 	// Replay the journal to undo changes and remove invalidated snapshots
+	s.trackRevert(snapshot)
 	s.journal.revert(s, snapshot)
 	s.validRevisions = s.validRevisions[:idx]
 }
@@ -863,6 +1160,9 @@ func (s *StateDB) GetRefund() uint64 {
 // the journal as well as the refunds. Finalise, however, will not push any updates
 // into the tries just yet. Only IntermediateRoot or Commit will do that.
 func (s *StateDB) Finalise(deleteEmptyObjects bool) {
+	// Arbitrum: check the balance delta against the journal, if enabled
+	s.checkBalanceDelta()
+
 	addressesToPrefetch := make([][]byte, 0, len(s.journal.dirties))
 	for addr, dirtyCount := range s.journal.dirties {
 		isZombie := s.journal.zombieEntries[addr] == dirtyCount
@@ -909,8 +1209,12 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	if s.prefetcher != nil && len(addressesToPrefetch) > 0 {
 		s.prefetcher.prefetch(common.Hash{}, s.originalRoot, common.Address{}, addressesToPrefetch)
 	}
+	// Arbitrum: record the journal activity of the transaction
+	s.finaliseJournalStats()
+
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
+	s.markBalanceDelta()
 }
 
 // IntermediateRoot computes the current root hash of the state trie.
@@ -1020,9 +1324,17 @@ func (s *StateDB) SetTxContext(thash common.Hash, ti int) {
 	s.thash = thash
 	s.txIndex = ti
 
+	// Arbitrum: classify the reads of each transaction separately
+	if s.accessStats != nil {
+		s.accessStats = new(AccessStats)
+	}
+
 	// Arbitrum: clear memory charging state for new tx
 	s.arbExtraData.openWasmPages = 0
 	s.arbExtraData.everWasmPages = 0
+	s.arbExtraData.wasmPagesErr = nil
+	s.arbExtraData.l1DataUsage = nil
+	s.arbExtraData.selfDestructs = nil
 }
 
 func (s *StateDB) clearJournalAndRefund() {
@@ -1178,6 +1490,14 @@ func (s *StateDB) handleDestruction(nodes *trienode.MergedNodeSet) error {
 	if s.db.TrieDB().Scheme() == rawdb.HashScheme {
 		return nil
 	}
+	// Storage deletion can take considerable time for big contracts, run it
+	// concurrently across the destructed accounts with a bounded worker pool.
+	var (
+		lock    sync.Mutex
+		workers errgroup.Group
+	)
+	workers.SetLimit(runtime.NumCPU())
+
 	for addr, prev := range s.stateObjectsDestruct {
 		// The original account was non-existing, and it's marked as destructed
 		// in the scope of block. It can be case (a) or (b).
@@ -1194,29 +1514,41 @@ func (s *StateDB) handleDestruction(nodes *trienode.MergedNodeSet) error {
 		// It can overwrite the data in s.accountsOrigin set by 'updateStateObject'.
 		s.accountsOrigin[addr] = types.SlimAccountRLP(*prev) // case (c) or (d)
 
-		// Short circuit if the storage was empty.
-		if prev.Root == types.EmptyRootHash {
+		// Short circuit if the storage was empty. Verkle accounts don't track
+		// a storage root and their slots, interleaved with the other accounts
+		// in the unified tree, can't be enumerated for deletion; EIP-6780 makes
+		// this a non-issue for accounts destructed by the EVM.
+		if prev.Root == types.EmptyRootHash || s.db.TrieDB().IsVerkle() {
 			continue
 		}
-		// Remove storage slots belong to the account.
-		slots, set, err := s.deleteStorage(addr, addrHash, prev.Root)
-		if err != nil {
-			return fmt.Errorf("failed to delete storage, err: %w", err)
-		}
-		if s.storagesOrigin[addr] == nil {
-			s.storagesOrigin[addr] = slots
-		} else {
-			// It can overwrite the data in s.storagesOrigin[addrHash] set by
-			// 'object.updateTrie'.
-			for key, val := range slots {
-				s.storagesOrigin[addr][key] = val
+		// The storage is deleted within the commit regardless of its size, so
+		// the memory and the latency of the commit grow with the storage of
+		// the destructed accounts. It can't be deferred: the original slots
+		// are needed by the state history of this block, and the node deletions
+		// must go through the trie database to keep its layers consistent.
+		addr, root := addr, prev.Root
+		workers.Go(func() error {
+			// Remove storage slots belong to the account.
+			slots, set, err := s.deleteStorage(addr, addrHash, root)
+			if err != nil {
+				return fmt.Errorf("failed to delete storage, err: %w", err)
 			}
-		}
-		if err := nodes.Merge(set); err != nil {
-			return err
-		}
+			lock.Lock()
+			defer lock.Unlock()
+
+			if s.storagesOrigin[addr] == nil {
+				s.storagesOrigin[addr] = slots
+			} else {
+				// It can overwrite the data in s.storagesOrigin[addrHash] set by
+				// 'object.updateTrie'.
+				for key, val := range slots {
+					s.storagesOrigin[addr][key] = val
+				}
+			}
+			return nodes.Merge(set)
+		})
 	}
-	return nil
+	return workers.Wait()
 }
 
 // GetTrie returns the account trie.
@@ -1230,6 +1562,11 @@ func (s *StateDB) GetTrie() Trie {
 // must be created with new root and updated database for accessing post-
 // commit states.
 //
+// The commit runs in stages: the tries are hashed, their dirty nodes are
+// collected and the nodes are then handed to the trie database. Flushing
+// the trie database to disk is left to the caller, which may overlap it with
+// the execution of the next block through a CommitScheduler.
+//
 // The associated block number of the state transition is also provided
 // for more chain context.
 func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, error) {
@@ -1243,6 +1580,51 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 	// Finalize any pending changes and merge everything into the tries
 	s.IntermediateRoot(deleteEmptyObjects)
 
+	// Arbitrum: derive the lifecycle events before the original values are lost
+	events := s.accountEvents()
+
+	root, nodes, err := s.commitNodes(block)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	s.commitSnapshot(root)
+	s.arbExtraData.unexpectedBalanceDelta.Set(new(big.Int))
+	s.markBalanceDelta()
+
+	if root == (common.Hash{}) {
+		root = types.EmptyRootHash
+	}
+	origin := s.originalRoot
+	if origin == (common.Hash{}) {
+		origin = types.EmptyRootHash
+	}
+	if err := s.commitTrieDB(block, root, origin, nodes); err != nil {
+		return common.Hash{}, err
+	}
+	s.committedEvents = events
+	s.committedChanges = &StateChanges{
+		Root:           root,
+		OriginRoot:     origin,
+		Destructs:      s.convertAccountSet(s.stateObjectsDestruct),
+		Accounts:       s.accounts,
+		Storages:       s.storages,
+		AccountsOrigin: s.accountsOrigin,
+		StoragesOrigin: s.storagesOrigin,
+	}
+	// Clear all internal flags at the end of commit operation.
+	s.accounts = make(map[common.Hash][]byte)
+	s.storages = make(map[common.Hash]map[common.Hash][]byte)
+	s.accountsOrigin = make(map[common.Address][]byte)
+	s.storagesOrigin = make(map[common.Address]map[common.Hash][]byte)
+	s.mutations = make(map[common.Address]*mutation)
+	s.stateObjectsDestruct = make(map[common.Address]*types.StateAccount)
+	return root, nil
+}
+
+// commitNodes is the node generation stage of the commit: it commits the
+// hashed account and storage tries, collecting their dirty nodes, and writes
+// the dirty contract code and Stylus programs.
+func (s *StateDB) commitNodes(block uint64) (common.Hash, *trienode.MergedNodeSet, error) {
 	// Commit objects to the trie, measuring the elapsed time
 	var (
 		accountTrieNodesUpdated int
@@ -1252,9 +1634,12 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 		nodes                   = trienode.NewMergedNodeSet()
 		wasmCodeWriter          = s.db.WasmStore().NewBatch()
 	)
+	// Arbitrum: drop the cached entries superseded by this commit
+	s.invalidateStorageCache()
+
 	// Handle all state deletions first
 	if err := s.handleDestruction(nodes); err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, nil, err
 	}
 	// Handle all state updates afterwards, concurrently to one another to shave
 	// off some milliseconds from the commit operation. Also accumulate the code
@@ -1353,6 +1738,7 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 	// Arbitrum: write Stylus programs to disk
 	for moduleHash, asmMap := range s.arbExtraData.activatedWasms {
 		rawdb.WriteActivation(wasmCodeWriter, moduleHash, asmMap)
+		rawdb.WriteActivationBlock(wasmCodeWriter, moduleHash, block)
 	}
 	if len(s.arbExtraData.activatedWasms) > 0 {
 		s.arbExtraData.activatedWasms = make(map[common.Hash]ActivatedWasm)
@@ -1368,7 +1754,7 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 	})
 	// Wait for everything to finish and update the metrics
 	if err := workers.Wait(); err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, nil, err
 	}
 	accountUpdatedMeter.Mark(int64(s.AccountUpdated))
 	storageUpdatedMeter.Mark(int64(s.StorageUpdated))
@@ -1380,41 +1766,44 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 	storageTriesDeletedMeter.Mark(int64(storageTrieNodesDeleted))
 	s.AccountUpdated, s.AccountDeleted = 0, 0
 	s.StorageUpdated, s.StorageDeleted = 0, 0
+	return root, nodes, nil
+}
 
+// commitSnapshot updates the snapshot tree, if enabled, with the changes of
+// the committed state.
+func (s *StateDB) commitSnapshot(root common.Hash) {
 	// If snapshotting is enabled, update the snapshot tree with this new version
 	if s.snap != nil {
-		start = time.Now()
+		start := time.Now()
 		// Only update if there's a state transition (skip empty Clique blocks)
 		if parent := s.snap.Root(); parent != root {
 			if err := s.snaps.Update(root, parent, s.convertAccountSet(s.stateObjectsDestruct), s.accounts, s.storages); err != nil {
 				log.Warn("Failed to update snapshot tree", "from", parent, "to", root, "err", err)
 			}
-			// Keep TriesInMemory diff layers in the memory, persistent layer is 129th.
+			// Keep the configured number of diff layers (TriesInMemory by default)
+			// in the memory, the persistent layer is the one below.
 			// - head layer is paired with HEAD state
 			// - head-1 layer is paired with HEAD-1 state
 			// - head-127 layer(bottom-most diff layer) is paired with HEAD-127 state
-			if err := s.snaps.Cap(root, DefaultTriesInMemory); err != nil {
-				log.Warn("Failed to cap snapshot tree", "root", root, "layers", DefaultTriesInMemory, "err", err)
+			layers := s.snaps.DiffLayers()
+			if err := s.snaps.Cap(root, layers); err != nil {
+				log.Warn("Failed to cap snapshot tree", "root", root, "layers", layers, "err", err)
 			}
 		}
 		s.SnapshotCommits += time.Since(start)
 		s.snap = nil
 	}
+}
 
-	s.arbExtraData.unexpectedBalanceDelta.Set(new(big.Int))
-
-	if root == (common.Hash{}) {
-		root = types.EmptyRootHash
-	}
-	origin := s.originalRoot
-	if origin == (common.Hash{}) {
-		origin = types.EmptyRootHash
-	}
+// commitTrieDB is the trie database update stage of the commit: it hands the
+// collected dirty nodes to the trie database, which keeps them in memory until
+// flushed to disk.
+func (s *StateDB) commitTrieDB(block uint64, root, origin common.Hash, nodes *trienode.MergedNodeSet) error {
 	if root != origin {
-		start = time.Now()
+		start := time.Now()
 		set := triestate.New(s.accountsOrigin, s.storagesOrigin)
 		if err := s.db.TrieDB().Update(root, origin, block, nodes, set); err != nil {
-			return common.Hash{}, err
+			return err
 		}
 		s.originalRoot = root
 		s.TrieDBCommits += time.Since(start)
@@ -1423,14 +1812,7 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 			s.onCommit(set)
 		}
 	}
-	// Clear all internal flags at the end of commit operation.
-	s.accounts = make(map[common.Hash][]byte)
-	s.storages = make(map[common.Hash]map[common.Hash][]byte)
-	s.accountsOrigin = make(map[common.Address][]byte)
-	s.storagesOrigin = make(map[common.Address]map[common.Hash][]byte)
-	s.mutations = make(map[common.Address]*mutation)
-	s.stateObjectsDestruct = make(map[common.Address]*types.StateAccount)
-	return root, nil
+	return nil
 }
 
 // Prepare handles the preparatory steps for executing a state transition with.
@@ -1472,6 +1854,57 @@ func (s *StateDB) Prepare(rules params.Rules, sender, coinbase common.Address, d
 	}
 	// Reset transient storage at the beginning of transaction execution
 	s.transientStorage = newTransientStorage()
+
+	// Resolve the trie paths of the access list concurrently with execution
+	s.prefetchAccessList(list)
+
+	// Arbitrum: warm the asm of the Stylus programs hinted by the transaction
+	s.prefetchActivatedAsm(dst, list)
+}
+
+// prefetchAccessList schedules the accounts and storage slots of the given
+// transaction access list for prefetching. Slots are only scheduled for accounts
+// whose storage root is known without touching the trie, either from a live
+// object or from the snapshot.
+func (s *StateDB) prefetchAccessList(list types.AccessList) {
+	if s.prefetcher == nil || len(list) == 0 {
+		return
+	}
+	addresses := make([][]byte, 0, len(list))
+	for _, el := range list {
+		addresses = append(addresses, common.CopyBytes(el.Address[:]))
+		if len(el.StorageKeys) == 0 {
+			continue
+		}
+		var (
+			addrHash common.Hash
+			root     common.Hash
+		)
+		if obj := s.stateObjects[el.Address]; obj != nil {
+			addrHash, root = obj.addrHash, obj.data.Root
+		} else if s.snap != nil {
+			addrHash = crypto.HashData(s.hasher, el.Address.Bytes())
+			acc, err := s.snap.Account(addrHash)
+			if err != nil || acc == nil {
+				continue
+			}
+			root = types.EmptyRootHash
+			if len(acc.Root) > 0 {
+				root = common.BytesToHash(acc.Root)
+			}
+		} else {
+			continue
+		}
+		if root == types.EmptyRootHash {
+			continue
+		}
+		slots := make([][]byte, 0, len(el.StorageKeys))
+		for _, key := range el.StorageKeys {
+			slots = append(slots, common.CopyBytes(key[:]))
+		}
+		s.prefetcher.prefetch(addrHash, root, el.Address, slots)
+	}
+	s.prefetcher.prefetch(common.Hash{}, s.originalRoot, common.Address{}, addresses)
 }
 
 // AddAddressToAccessList adds the given address to the access list
@@ -24,12 +24,14 @@ import (
 
 	"errors"
 	"runtime"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -83,9 +85,85 @@ func (s *StateDB) ActivateWasm(moduleHash common.Hash, asmMap map[ethdb.WasmTarg
 	s.journal.append(wasmActivation{
 		moduleHash: moduleHash,
 	})
+	if s.logger != nil && s.logger.CaptureStylusModule != nil {
+		s.logger.CaptureStylusModule(moduleHash, true)
+	}
+}
+
+// Resolves the module hash of the Stylus program with the given code hash, installed by Nitro
+var GetStylusModuleHash func(statedb *StateDB, codeHash common.Hash) (moduleHash common.Hash, ok bool)
+
+// prefetchActivatedAsm loads in the background the asm of the Stylus programs at
+// the destination and in the access list of a transaction.
+func (s *StateDB) prefetchActivatedAsm(dst *common.Address, list types.AccessList) {
+	if GetStylusModuleHash == nil {
+		return
+	}
+	// The hints aren't accesses of the block, don't track them
+	tracked := s.blockAccessList
+	s.blockAccessList = newAccessList()
+	defer func() { s.blockAccessList = tracked }()
+
+	addrs := make([]common.Address, 0, len(list)+1)
+	if dst != nil {
+		addrs = append(addrs, *dst)
+	}
+	for _, el := range list {
+		addrs = append(addrs, el.Address)
+	}
+	var (
+		seen         = make(map[common.Hash]struct{})
+		moduleHashes []common.Hash
+	)
+	for _, addr := range addrs {
+		codeHash := s.GetCodeHash(addr)
+		if codeHash == (common.Hash{}) || codeHash == types.EmptyCodeHash {
+			continue
+		}
+		if _, ok := seen[codeHash]; ok {
+			continue
+		}
+		seen[codeHash] = struct{}{}
+		if moduleHash, ok := GetStylusModuleHash(s, codeHash); ok {
+			moduleHashes = append(moduleHashes, moduleHash)
+		}
+	}
+	if len(moduleHashes) > 0 {
+		s.db.PrefetchActivatedAsm(s.db.WasmTargets(), moduleHashes)
+	}
+}
+
+// BlockAccessList returns the accounts and storage slots accessed by the state
+// since its creation, across all the transactions of the block, sorted by address
+// and slot. Accesses within reverted scopes are included too.
+func (s *StateDB) BlockAccessList() types.AccessList {
+	al := s.blockAccessList
+	list := make(types.AccessList, 0, len(al.addresses))
+	for addr, idx := range al.addresses {
+		tuple := types.AccessTuple{Address: addr, StorageKeys: []common.Hash{}}
+		if idx >= 0 {
+			for slot := range al.slots[idx] {
+				tuple.StorageKeys = append(tuple.StorageKeys, slot)
+			}
+			slices.SortFunc(tuple.StorageKeys, func(a, b common.Hash) int { return a.Cmp(b) })
+		}
+		list = append(list, tuple)
+	}
+	slices.SortFunc(list, func(a, b types.AccessTuple) int { return a.Address.Cmp(b.Address) })
+	return list
 }
 
 func (s *StateDB) TryGetActivatedAsm(target ethdb.WasmTarget, moduleHash common.Hash) ([]byte, error) {
+	asm, err := s.tryGetActivatedAsm(target, moduleHash)
+	if err == nil && s.logger != nil && s.logger.CaptureStylusModule != nil {
+		s.logger.CaptureStylusModule(moduleHash, false)
+	}
+	return asm, err
+}
+
+// tryGetActivatedAsm is TryGetActivatedAsm without reporting the load to the
+// tracer, for internal uses that are not loads of the executing program.
+func (s *StateDB) tryGetActivatedAsm(target ethdb.WasmTarget, moduleHash common.Hash) ([]byte, error) {
 	asmMap, exists := s.arbExtraData.activatedWasms[moduleHash]
 	if exists {
 		if asm, exists := asmMap[target]; exists {
@@ -96,6 +174,14 @@ func (s *StateDB) TryGetActivatedAsm(target ethdb.WasmTarget, moduleHash common.
 }
 
 func (s *StateDB) TryGetActivatedAsmMap(targets []ethdb.WasmTarget, moduleHash common.Hash) (map[ethdb.WasmTarget][]byte, error) {
+	asmMap, err := s.tryGetActivatedAsmMap(targets, moduleHash)
+	if err == nil && s.logger != nil && s.logger.CaptureStylusModule != nil {
+		s.logger.CaptureStylusModule(moduleHash, false)
+	}
+	return asmMap, err
+}
+
+func (s *StateDB) tryGetActivatedAsmMap(targets []ethdb.WasmTarget, moduleHash common.Hash) (map[ethdb.WasmTarget][]byte, error) {
 	asmMap := s.arbExtraData.activatedWasms[moduleHash]
 	if asmMap != nil {
 		for _, target := range targets {
@@ -130,16 +216,97 @@ func (s *StateDB) SetStylusPagesOpen(open uint16) {
 	s.arbExtraData.openWasmPages = open
 }
 
-// Tracks that `new` additional pages have been opened, returning the previous counts
+// Tracks that `new` additional pages have been opened, returning the previous counts.
+// Pages beyond the ceilings of the chain are not counted, the violation is left
+// for the interpreter to fail the program with, see TakeWasmPagesError.
 func (s *StateDB) AddStylusPages(new uint16) (uint16, uint16) {
 	open, ever := s.GetStylusPages()
+	if err := s.checkStylusPages(new); err != nil {
+		if s.arbExtraData.wasmPagesErr == nil {
+			s.arbExtraData.wasmPagesErr = err
+		}
+		return open, ever
+	}
 	s.arbExtraData.openWasmPages = common.SaturatingUAdd(open, new)
 	s.arbExtraData.everWasmPages = common.MaxInt(ever, s.arbExtraData.openWasmPages)
+	s.arbExtraData.stylusStats.PagesOpened += uint64(new)
+	s.recordStylusPagesEver()
 	return open, ever
 }
 
 func (s *StateDB) AddStylusPagesEver(new uint16) {
 	s.arbExtraData.everWasmPages = common.SaturatingUAdd(s.arbExtraData.everWasmPages, new)
+	s.recordStylusPagesEver()
+}
+
+// GrowStylusPages tracks that the program at the given address opened `new`
+// additional pages for the given gas cost, like AddStylusPages, for the hosts
+// knowing the cost of the growth. If that exceeds the ceilings of the chain, the
+// counts are left unchanged and ErrWasmPagesExceeded is returned.
+func (s *StateDB) GrowStylusPages(addr common.Address, new uint16, cost uint64) error {
+	if err := s.checkStylusPages(new); err != nil {
+		return err
+	}
+	s.AddStylusPages(new)
+	if s.logger != nil && s.logger.OnWasmMemoryGrow != nil {
+		s.logger.OnWasmMemoryGrow(addr, new, cost)
+	}
+	return nil
+}
+
+// TakeWasmPagesError returns the violation of the wasm pages ceilings since the
+// last call, if any, and clears it.
+func (s *StateDB) TakeWasmPagesError() error {
+	err := s.arbExtraData.wasmPagesErr
+	s.arbExtraData.wasmPagesErr = nil
+	return err
+}
+
+// checkStylusPages returns ErrWasmPagesExceeded if opening `new` additional pages
+// would exceed the per-transaction or per-block ceilings of the chain.
+func (s *StateDB) checkStylusPages(new uint16) error {
+	config := s.arbExtraData.wasmPages
+	if config == nil {
+		return nil
+	}
+	open := uint64(s.arbExtraData.openWasmPages) + uint64(new)
+	if config.MaxTxPages != 0 && open > uint64(config.MaxTxPages) {
+		return fmt.Errorf("%w: %d pages open in transaction, limit %d", ErrWasmPagesExceeded, open, config.MaxTxPages)
+	}
+	block := s.arbExtraData.stylusStats.PagesOpened + uint64(new)
+	if config.MaxBlockPages != 0 && block > config.MaxBlockPages {
+		return fmt.Errorf("%w: %d pages opened in block, limit %d", ErrWasmPagesExceeded, block, config.MaxBlockPages)
+	}
+	return nil
+}
+
+// SetWasmPagesConfig applies the ceilings of the wasm pages opened by the Stylus
+// programs. A nil config implies no ceiling.
+func (s *StateDB) SetWasmPagesConfig(config *params.WasmPagesConfig) {
+	s.arbExtraData.wasmPages = config
+}
+
+func (s *StateDB) recordStylusPagesEver() {
+	stats := &s.arbExtraData.stylusStats
+	stats.MaxPagesEver = max(stats.MaxPagesEver, uint64(s.arbExtraData.everWasmPages))
+}
+
+// RecordStylusCall tracks the execution of a Stylus program for the summary of
+// the block, with the ink it used.
+func (s *StateDB) RecordStylusCall(moduleHash common.Hash, ink uint64) {
+	stats := &s.arbExtraData.stylusStats
+	stats.Calls++
+	stats.Ink = common.SaturatingUAdd(stats.Ink, ink)
+	if _, ok := s.arbExtraData.stylusModules[moduleHash]; !ok {
+		s.arbExtraData.stylusModules[moduleHash] = struct{}{}
+		stats.Modules++
+	}
+}
+
+// StylusStats returns the summary of the Stylus executions since the creation
+// of the state, across all the transactions of the block.
+func (s *StateDB) StylusStats() types.StylusStats {
+	return s.arbExtraData.stylusStats
 }
 
 // Arbitrum: preserve empty account behavior from old geth and ArbOS versions.
@@ -166,14 +333,26 @@ func (s *StateDB) Deterministic() bool {
 
 var ErrArbTxFilter error = errors.New("internal error")
 
+// ErrWasmPagesExceeded is returned when a Stylus program opens more wasm pages
+// than allowed by the chain.
+var ErrWasmPagesExceeded = errors.New("wasm pages ceiling exceeded")
+
 type ArbitrumExtraData struct {
 	unexpectedBalanceDelta *big.Int                      // total balance change across all accounts
+	balanceDeltaMark       *big.Int                      // unexpected balance delta at the start of the journal
 	userWasms              UserWasms                     // user wasms encountered during execution
 	openWasmPages          uint16                        // number of pages currently open
 	everWasmPages          uint16                        // largest number of pages ever allocated during this tx's execution
+	wasmPages              *params.WasmPagesConfig       // ceilings of the pages opened, nil if unbounded
+	wasmPagesErr           error                         // violation of the pages ceilings not yet failed
 	activatedWasms         map[common.Hash]ActivatedWasm // newly activated WASMs
 	recentWasms            RecentWasms
+	stylusStats            types.StylusStats        // summary of the stylus executions of the block
+	stylusModules          map[common.Hash]struct{} // distinct modules executed in the block
 	arbTxFilter            bool
+	arbTxFilterReason      string
+	l1DataUsage            *types.L1DataUsage   // metering of the L1 data of the current tx, nil if unmetered
+	selfDestructs          []SelfDestructRecord // selfdestructs executed by the current tx
 }
 
 func (s *StateDB) SetArbFinalizer(f func(*ArbitrumExtraData)) {
@@ -184,6 +363,26 @@ func (s *StateDB) GetCurrentTxLogs() []*types.Log {
 	return s.logs[s.thash]
 }
 
+// RecordL1DataUsage records components of the metering of the L1 data of the
+// current transaction, overriding those recorded so far. ArbOS records the
+// pricing of the data as it charges the poster cost, the state transition the
+// sizes measured.
+func (s *StateDB) RecordL1DataUsage(usage *types.L1DataUsage) {
+	if s.arbExtraData.l1DataUsage == nil {
+		s.arbExtraData.l1DataUsage = new(types.L1DataUsage)
+	}
+	s.arbExtraData.l1DataUsage.Merge(usage)
+}
+
+// L1DataUsage returns the metering of the L1 data of the current transaction,
+// or nil if it isn't metered.
+func (s *StateDB) L1DataUsage() *types.L1DataUsage {
+	if s.arbExtraData.l1DataUsage == nil {
+		return nil
+	}
+	return s.arbExtraData.l1DataUsage.Copy()
+}
+
 // GetUnexpectedBalanceDelta returns the total unexpected change in balances since the last commit to the database.
 func (s *StateDB) GetUnexpectedBalanceDelta() *big.Int {
 	return new(big.Int).Set(s.arbExtraData.unexpectedBalanceDelta)
@@ -258,7 +457,7 @@ func (s *StateDB) RecordProgram(targets []ethdb.WasmTarget, moduleHash common.Ha
 		// nothing to record
 		return
 	}
-	asmMap, err := s.TryGetActivatedAsmMap(targets, moduleHash)
+	asmMap, err := s.tryGetActivatedAsmMap(targets, moduleHash)
 	if err != nil {
 		log.Crit("can't find activated wasm while recording", "modulehash", moduleHash, "err", err)
 	}
@@ -267,16 +466,25 @@ func (s *StateDB) RecordProgram(targets []ethdb.WasmTarget, moduleHash common.Ha
 	}
 }
 
+// UserWasms returns the asm of the Stylus modules executed since the recording
+// started, by module hash, which the validators need to prove the execution. It
+// returns nil if the programs are not being recorded.
 func (s *StateDB) UserWasms() UserWasms {
 	return s.arbExtraData.userWasms
 }
 
 func (s *StateDB) RecordCacheWasm(wasm CacheWasm) {
 	s.journal.entries = append(s.journal.entries, wasm)
+	if s.logger != nil && s.logger.CaptureStylusCache != nil {
+		s.logger.CaptureStylusCache(wasm.ModuleHash, wasm.Version, wasm.Tag, wasm.Debug, true)
+	}
 }
 
 func (s *StateDB) RecordEvictWasm(wasm EvictWasm) {
 	s.journal.entries = append(s.journal.entries, wasm)
+	if s.logger != nil && s.logger.CaptureStylusCache != nil {
+		s.logger.CaptureStylusCache(wasm.ModuleHash, wasm.Version, wasm.Tag, wasm.Debug, false)
+	}
 }
 
 func (s *StateDB) GetRecentWasms() RecentWasms {
@@ -285,8 +493,24 @@ func (s *StateDB) GetRecentWasms() RecentWasms {
 
 // Type for managing recent program access.
 // The cache contained is discarded at the end of each block.
+//
+// Without a policy, the cache is recreated on every insertion, preserving the
+// historical behavior of never reporting a hit.
 type RecentWasms struct {
-	cache *lru.BasicLRU[common.Hash, struct{}]
+	cache *lru.BasicLRU[common.Hash, uint64] // Recent programs, along with the tick of their last access
+	state *recentWasmsState                  // Policy and clock of the cache, nil if none is configured
+}
+
+type recentWasmsState struct {
+	config *params.RecentWasmsConfig
+	cache  *lru.BasicLRU[common.Hash, uint64] // Created on the first insertion if the capacity defaults to ArbOS's
+	clock  uint64                             // Number of insertions so far
+}
+
+// RecentWasmEntry describes a program retained by the recent programs cache
+type RecentWasmEntry struct {
+	Hash common.Hash
+	Age  uint64 // Number of program calls since the program was last used
 }
 
 // Creates an un uninitialized cache
@@ -296,25 +520,106 @@ func NewRecentWasms() RecentWasms {
 
 // Inserts a new item, returning true if already present.
 func (p RecentWasms) Insert(item common.Hash, retain uint16) bool {
+	if p.state != nil {
+		return p.state.insert(item, retain)
+	}
 	if p.cache == nil {
-		cache := lru.NewBasicLRU[common.Hash, struct{}](int(retain))
+		cache := lru.NewBasicLRU[common.Hash, uint64](int(retain))
 		p.cache = &cache
 	}
 	if _, hit := p.cache.Get(item); hit {
 		return hit
 	}
-	p.cache.Add(item, struct{}{})
+	p.cache.Add(item, 0)
 	return false
 }
 
+func (s *recentWasmsState) insert(item common.Hash, retain uint16) bool {
+	if s.cache == nil {
+		capacity := retain
+		if s.config.Capacity != 0 {
+			capacity = s.config.Capacity
+		}
+		cache := lru.NewBasicLRU[common.Hash, uint64](int(capacity))
+		s.cache = &cache
+	}
+	s.clock++
+	last, hit := s.cache.Get(item)
+	s.cache.Add(item, s.clock)
+	if hit && s.config.MaxAge != 0 && s.clock-last > s.config.MaxAge {
+		return false
+	}
+	return hit
+}
+
+// Entries returns the programs retained by the cache, most recently used first.
+func (p RecentWasms) Entries() []RecentWasmEntry {
+	if p.state == nil || p.state.cache == nil {
+		return nil
+	}
+	keys := p.state.cache.Keys()
+	entries := make([]RecentWasmEntry, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		last, _ := p.state.cache.Peek(keys[i])
+		entries = append(entries, RecentWasmEntry{Hash: keys[i], Age: p.state.clock - last})
+	}
+	return entries
+}
+
+// Config returns the policy of the cache, nil if none is configured.
+func (p RecentWasms) Config() *params.RecentWasmsConfig {
+	if p.state == nil {
+		return nil
+	}
+	return p.state.config
+}
+
 // Copies all entries into a new LRU.
 func (p RecentWasms) Copy() RecentWasms {
+	if p.state != nil {
+		state := &recentWasmsState{config: p.state.config, clock: p.state.clock}
+		if p.state.cache != nil {
+			cache := lru.NewBasicLRU[common.Hash, uint64](p.state.cache.Capacity())
+			for _, item := range p.state.cache.Keys() {
+				last, _ := p.state.cache.Peek(item)
+				cache.Add(item, last)
+			}
+			state.cache = &cache
+		}
+		return RecentWasms{state: state}
+	}
 	if p.cache == nil {
 		return NewRecentWasms()
 	}
-	cache := lru.NewBasicLRU[common.Hash, struct{}](p.cache.Capacity())
+	cache := lru.NewBasicLRU[common.Hash, uint64](p.cache.Capacity())
 	for _, item := range p.cache.Keys() {
-		cache.Add(item, struct{}{})
+		cache.Add(item, 0)
 	}
 	return RecentWasms{cache: &cache}
 }
+
+// SetRecentWasmsConfig applies the policy of the recent programs cache, unless
+// it's already in effect. A nil policy retains the legacy behavior.
+func (s *StateDB) SetRecentWasmsConfig(config *params.RecentWasmsConfig) {
+	if config == nil || s.arbExtraData.recentWasms.state != nil {
+		return
+	}
+	s.arbExtraData.recentWasms = RecentWasms{state: &recentWasmsState{config: config}}
+}
+
+// invalidateStorageCache drops the entries of the process-wide storage cache
+// which are superseded by the mutations about to be committed.
+func (s *StateDB) invalidateStorageCache() {
+	if len(s.mutations) == 0 {
+		return
+	}
+	roots := make(map[common.Address]common.Hash, len(s.mutations))
+	for addr := range s.mutations {
+		var root common.Hash
+		if obj := s.stateObjects[addr]; obj != nil && obj.origin != nil {
+			root = obj.origin.Root
+		}
+		roots[addr] = root
+	}
+	globalStorageCache.invalidate(s.originalRoot, roots, s.storagesOrigin)
+}
@@ -0,0 +1,199 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestRecentWasmsLegacy(t *testing.T) {
+	recent := NewRecentWasms()
+	for i := 0; i < 2; i++ {
+		if recent.Insert(common.Hash{1}, 16) {
+			t.Fatal("legacy cache reported a hit")
+		}
+	}
+	if entries := recent.Entries(); len(entries) != 0 {
+		t.Fatalf("unexpected legacy entries: %v", entries)
+	}
+}
+
+func TestRecentWasmsPolicy(t *testing.T) {
+	statedb, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetRecentWasmsConfig(&params.RecentWasmsConfig{Capacity: 2, MaxAge: 2})
+
+	recent := statedb.GetRecentWasms()
+	if recent.Insert(common.Hash{1}, 16) {
+		t.Fatal("hit on first insertion")
+	}
+	if !recent.Insert(common.Hash{1}, 16) {
+		t.Fatal("miss on repeated insertion")
+	}
+	recent.Insert(common.Hash{2}, 16)
+	recent.Insert(common.Hash{2}, 16)
+	recent.Insert(common.Hash{2}, 16)
+
+	// The first program decayed after two calls of the second one
+	copied := statedb.Copy().GetRecentWasms()
+	if recent.Insert(common.Hash{1}, 16) {
+		t.Fatal("hit on decayed entry")
+	}
+	recent.Insert(common.Hash{3}, 16)
+
+	// The capacity overrides ArbOS's, evicting the least recently used program
+	want := []RecentWasmEntry{{Hash: common.Hash{3}, Age: 0}, {Hash: common.Hash{1}, Age: 1}}
+	entries := statedb.GetRecentWasms().Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("unexpected entries: have %v, want %v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatalf("unexpected entry %d: have %v, want %v", i, entries[i], want[i])
+		}
+	}
+	// Copies are independent of the original
+	if entries := copied.Entries(); len(entries) != 2 || entries[0].Hash != (common.Hash{2}) || entries[1].Age != 3 {
+		t.Fatalf("unexpected copied entries: %v", entries)
+	}
+}
+
+func TestBlockAccessList(t *testing.T) {
+	statedb, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	var (
+		reader  = common.Address{1}
+		writer  = common.Address{2}
+		missing = common.Address{3}
+	)
+	statedb.GetBalance(reader)
+	statedb.SetState(writer, common.Hash{2}, common.Hash{1})
+	statedb.Finalise(true)
+
+	// Accesses of reverted scopes are retained
+	snapshot := statedb.Snapshot()
+	statedb.GetState(writer, common.Hash{1})
+	statedb.GetNonce(missing)
+	statedb.RevertToSnapshot(snapshot)
+
+	want := types.AccessList{
+		{Address: reader, StorageKeys: []common.Hash{}},
+		{Address: writer, StorageKeys: []common.Hash{{1}, {2}}},
+		{Address: missing, StorageKeys: []common.Hash{}},
+	}
+	if have := statedb.BlockAccessList(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected block access list: have %v, want %v", have, want)
+	}
+	if have := statedb.Copy().BlockAccessList(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("unexpected copied block access list: have %v, want %v", have, want)
+	}
+}
+
+func TestStylusStats(t *testing.T) {
+	statedb, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	statedb.SetTxContext(common.Hash{1}, 0)
+	statedb.RecordStylusCall(common.Hash{1}, 1000)
+	statedb.AddStylusPages(4)
+	statedb.RecordStylusCall(common.Hash{2}, 500)
+	statedb.AddStylusPages(2)
+	statedb.SetStylusPagesOpen(0)
+
+	// The pages open are per transaction, the largest count being retained
+	statedb.SetTxContext(common.Hash{2}, 1)
+	statedb.RecordStylusCall(common.Hash{1}, 250)
+	statedb.AddStylusPages(3)
+	statedb.AddStylusPagesEver(1)
+
+	want := types.StylusStats{Calls: 3, Modules: 2, Ink: 1750, PagesOpened: 9, MaxPagesEver: 6}
+	if have := statedb.StylusStats(); have != want {
+		t.Fatalf("unexpected stylus stats: have %+v, want %+v", have, want)
+	}
+	// Copies keep tracking the distinct modules independently
+	copied := statedb.Copy()
+	copied.RecordStylusCall(common.Hash{2}, 0)
+	copied.RecordStylusCall(common.Hash{3}, 0)
+	if have := copied.StylusStats(); have.Calls != 5 || have.Modules != 3 {
+		t.Fatalf("unexpected copied stylus stats: have %+v", have)
+	}
+	if have := statedb.StylusStats(); have != want {
+		t.Fatalf("original stylus stats modified: have %+v, want %+v", have, want)
+	}
+}
+
+func TestGrowStylusPages(t *testing.T) {
+	statedb, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetWasmPagesConfig(&params.WasmPagesConfig{MaxTxPages: 8, MaxBlockPages: 12})
+
+	var grows []uint16
+	statedb.SetLogger(&tracing.Hooks{
+		OnWasmMemoryGrow: func(addr common.Address, pages uint16, cost uint64) {
+			grows = append(grows, pages)
+		},
+	})
+	program := common.Address{1}
+
+	statedb.SetTxContext(common.Hash{1}, 0)
+	if err := statedb.GrowStylusPages(program, 6, 100); err != nil {
+		t.Fatalf("failed to grow pages: %v", err)
+	}
+	if err := statedb.GrowStylusPages(program, 3, 100); !errors.Is(err, ErrWasmPagesExceeded) {
+		t.Fatalf("transaction ceiling not enforced: %v", err)
+	}
+	if open, ever := statedb.GetStylusPages(); open != 6 || ever != 6 {
+		t.Fatalf("pages counted past the ceiling: open %d, ever %d", open, ever)
+	}
+	// The block ceiling spans the transactions
+	statedb.SetTxContext(common.Hash{2}, 1)
+	if err := statedb.GrowStylusPages(program, 6, 100); err != nil {
+		t.Fatalf("failed to grow pages: %v", err)
+	}
+	statedb.SetTxContext(common.Hash{3}, 2)
+	if err := statedb.GrowStylusPages(program, 1, 100); !errors.Is(err, ErrWasmPagesExceeded) {
+		t.Fatalf("block ceiling not enforced: %v", err)
+	}
+	if !reflect.DeepEqual(grows, []uint16{6, 6}) {
+		t.Fatalf("unexpected memory grow hooks: %v", grows)
+	}
+}
+
+func TestCodePrefixFromIndex(t *testing.T) {
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		addr = common.Address{1}
+		code = append([]byte{0xef, 0xf0, 0x00, 0x00}, make([]byte, 1000)...)
+	)
+	sdb := NewDatabase(db)
+	statedb, _ := New(types.EmptyRootHash, sdb, nil)
+	statedb.SetCode(addr, code)
+	root, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	// Drop the code blob, size and prefix queries must be served by the index
+	rawdb.DeleteCode(db, crypto.Keccak256Hash(code))
+	rawdb.WriteCodeSize(db, crypto.Keccak256Hash(code), code)
+
+	statedb, _ = New(root, NewDatabase(db), nil)
+	if have := statedb.GetCodeSize(addr); have != len(code) {
+		t.Fatalf("unexpected code size: have %d, want %d", have, len(code))
+	}
+	if have := statedb.GetCodePrefix(addr, 4); !bytes.Equal(have, code[:4]) {
+		t.Fatalf("unexpected code prefix: have %x, want %x", have, code[:4])
+	}
+	if !IsStylusProgram(statedb.GetCodePrefix(addr, len(StylusDiscriminant)+1)) {
+		t.Fatal("stylus program not detected from code prefix")
+	}
+	if err := statedb.Error(); err != nil {
+		t.Fatalf("unexpected state error: %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// The differential fuzzer applies sequences of operations decoded from its input
+// to the StateDB, over both the hash and the path schemes, and to a map-based
+// reference model of the state, checking after every operation that the state
+// observed through the StateDB matches the model, and that the roots committed
+// by both schemes match the root of the model state written from scratch.
+//
+// The accounts and slots are drawn from small sets, so that the sequences keep
+// hitting the same accounts as they are created, destructed and resurrected.
+var (
+	diffFuzzAddrs = []common.Address{{0x01}, {0x02}, {0x03}, {0x04}}
+	diffFuzzSlots = []common.Hash{{0x01}, {0x02}, {0x03}}
+)
+
+const (
+	diffOpSetBalance = iota
+	diffOpAddBalance
+	diffOpSetNonce
+	diffOpSetState
+	diffOpSetCode
+	diffOpCreateAccount
+	diffOpSelfDestruct
+	diffOpSnapshot
+	diffOpRevert
+	diffOpFinalise
+	diffOpCommit
+	diffOpCount
+)
+
+// refAccount is an account of the reference model.
+type refAccount struct {
+	balance    uint64
+	nonce      uint64
+	code       []byte
+	storage    map[common.Hash]common.Hash
+	destructed bool
+}
+
+func (a *refAccount) empty() bool {
+	return a.balance == 0 && a.nonce == 0 && len(a.code) == 0
+}
+
+// refState is the reference model of the state: the accounts by address, with
+// their storage, the accounts destructed in the transaction being marked until
+// its end.
+type refState map[common.Address]*refAccount
+
+func (s refState) copy() refState {
+	cpy := make(refState, len(s))
+	for addr, account := range s {
+		acc := *account
+		acc.storage = make(map[common.Hash]common.Hash, len(account.storage))
+		for slot, value := range account.storage {
+			acc.storage[slot] = value
+		}
+		cpy[addr] = &acc
+	}
+	return cpy
+}
+
+// account returns the given account, creating it if missing.
+func (s refState) account(addr common.Address) *refAccount {
+	if account, ok := s[addr]; ok {
+		return account
+	}
+	account := &refAccount{storage: make(map[common.Hash]common.Hash)}
+	s[addr] = account
+	return account
+}
+
+// finalise deletes the accounts destructed in the transaction, and the empty
+// ones along with their storage (EIP-158).
+func (s refState) finalise() {
+	for addr, account := range s {
+		if account.destructed || account.empty() {
+			delete(s, addr)
+		}
+	}
+}
+
+// root writes the model state into an empty state and returns its root.
+func (s refState) root() (common.Hash, error) {
+	statedb, err := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for addr, account := range s {
+		statedb.SetBalance(addr, uint256.NewInt(account.balance), tracing.BalanceChangeUnspecified)
+		statedb.SetNonce(addr, account.nonce)
+		statedb.SetCode(addr, account.code)
+		for slot, value := range account.storage {
+			statedb.SetState(addr, slot, value)
+		}
+	}
+	return statedb.Commit(0, true)
+}
+
+// diffFuzzState is a StateDB under test, reopened at every commit.
+type diffFuzzState struct {
+	scheme string
+	disk   ethdb.Database
+	tdb    *triedb.Database
+	db     Database
+	state  *StateDB
+	snaps  []int
+	block  uint64
+}
+
+func newDiffFuzzState(scheme string) (*diffFuzzState, error) {
+	config := triedb.HashDefaults
+	if scheme == rawdb.PathScheme {
+		config = &triedb.Config{PathDB: pathdb.Defaults}
+	}
+	disk := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(disk, config)
+	db := NewDatabaseWithNodeDB(disk, tdb)
+	state, err := New(types.EmptyRootHash, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &diffFuzzState{scheme: scheme, disk: disk, tdb: tdb, db: db, state: state}, nil
+}
+
+func (s *diffFuzzState) close() {
+	s.tdb.Close()
+	s.disk.Close()
+}
+
+// commit commits the state and reopens it at the new root.
+func (s *diffFuzzState) commit() (common.Hash, error) {
+	s.block++
+	root, err := s.state.Commit(s.block, true)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if s.state, err = New(root, s.db, nil); err != nil {
+		return common.Hash{}, err
+	}
+	s.snaps = s.snaps[:0]
+	return root, nil
+}
+
+// verify checks that the state observed through the StateDB matches the model.
+func (s *diffFuzzState) verify(ref refState) error {
+	for _, addr := range diffFuzzAddrs {
+		account, exist := ref[addr]
+		if have := s.state.Exist(addr); have != exist {
+			return fmt.Errorf("%s: account %x existence mismatch: have %v, want %v", s.scheme, addr, have, exist)
+		}
+		if !exist {
+			account = &refAccount{}
+		}
+		if have := s.state.GetBalance(addr); !have.Eq(uint256.NewInt(account.balance)) {
+			return fmt.Errorf("%s: account %x balance mismatch: have %v, want %d", s.scheme, addr, have, account.balance)
+		}
+		if have := s.state.GetNonce(addr); have != account.nonce {
+			return fmt.Errorf("%s: account %x nonce mismatch: have %d, want %d", s.scheme, addr, have, account.nonce)
+		}
+		if have := s.state.GetCode(addr); !bytes.Equal(have, account.code) {
+			return fmt.Errorf("%s: account %x code mismatch: have %x, want %x", s.scheme, addr, have, account.code)
+		}
+		for _, slot := range diffFuzzSlots {
+			if have, want := s.state.GetState(addr, slot), account.storage[slot]; have != want {
+				return fmt.Errorf("%s: account %x slot %x mismatch: have %x, want %x", s.scheme, addr, slot, have, want)
+			}
+		}
+	}
+	return nil
+}
+
+// diffFuzzInput decodes the operations and their arguments from the fuzzer
+// input, reading zeroes once it's exhausted.
+type diffFuzzInput []byte
+
+func (in *diffFuzzInput) next() byte {
+	if len(*in) == 0 {
+		return 0
+	}
+	b := (*in)[0]
+	*in = (*in)[1:]
+	return b
+}
+
+// runDiffFuzz applies the operations encoded in the given input to the StateDB
+// over both schemes and to the reference model, and reports the first mismatch.
+func runDiffFuzz(data []byte) error {
+	var states []*diffFuzzState
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		state, err := newDiffFuzzState(scheme)
+		if err != nil {
+			return err
+		}
+		defer state.close()
+		states = append(states, state)
+	}
+	var (
+		ref     = make(refState)
+		refSnap []refState
+		input   = diffFuzzInput(data)
+	)
+	for step := 0; len(input) > 0; step++ {
+		var (
+			op   = input.next() % diffOpCount
+			addr = diffFuzzAddrs[int(input.next())%len(diffFuzzAddrs)]
+			arg  = input.next()
+			desc = fmt.Sprintf("step %d: op %d account %x arg %d", step, op, addr, arg)
+		)
+		switch op {
+		case diffOpSetBalance:
+			ref.account(addr).balance = uint64(arg)
+			for _, s := range states {
+				s.state.SetBalance(addr, uint256.NewInt(uint64(arg)), tracing.BalanceChangeUnspecified)
+			}
+		case diffOpAddBalance:
+			ref.account(addr).balance += uint64(arg)
+			for _, s := range states {
+				s.state.AddBalance(addr, uint256.NewInt(uint64(arg)), tracing.BalanceChangeUnspecified)
+			}
+		case diffOpSetNonce:
+			ref.account(addr).nonce = uint64(arg)
+			for _, s := range states {
+				s.state.SetNonce(addr, uint64(arg))
+			}
+		case diffOpSetState:
+			var (
+				slot  = diffFuzzSlots[int(arg)%len(diffFuzzSlots)]
+				value = common.Hash{}
+			)
+			if arg >= 128 {
+				value[31] = arg
+			}
+			if account := ref.account(addr); value == (common.Hash{}) {
+				delete(account.storage, slot)
+			} else {
+				account.storage[slot] = value
+			}
+			for _, s := range states {
+				s.state.SetState(addr, slot, value)
+			}
+		case diffOpSetCode:
+			code := []byte{arg, byte(step)}
+			ref.account(addr).code = code
+			for _, s := range states {
+				s.state.SetCode(addr, code)
+			}
+		case diffOpCreateAccount:
+			// Accounts are only created by the EVM if missing
+			if _, ok := ref[addr]; ok {
+				continue
+			}
+			ref.account(addr)
+			for _, s := range states {
+				s.state.CreateAccount(addr)
+			}
+		case diffOpSelfDestruct:
+			if account, ok := ref[addr]; ok {
+				account.destructed, account.balance = true, 0
+			}
+			for _, s := range states {
+				s.state.SelfDestruct(addr)
+			}
+		case diffOpSnapshot:
+			refSnap = append(refSnap, ref.copy())
+			for _, s := range states {
+				s.snaps = append(s.snaps, s.state.Snapshot())
+			}
+		case diffOpRevert:
+			if len(refSnap) == 0 {
+				continue
+			}
+			index := int(arg) % len(refSnap)
+			ref, refSnap = refSnap[index], refSnap[:index]
+			for _, s := range states {
+				s.state.RevertToSnapshot(s.snaps[index])
+				s.snaps = s.snaps[:index]
+			}
+		case diffOpFinalise:
+			ref.finalise()
+			refSnap = refSnap[:0]
+
+			var roots []common.Hash
+			for _, s := range states {
+				if arg%2 == 0 {
+					s.state.Finalise(true)
+				} else {
+					roots = append(roots, s.state.IntermediateRoot(true))
+				}
+				s.snaps = s.snaps[:0]
+			}
+			if len(roots) > 0 && roots[0] != roots[1] {
+				return fmt.Errorf("%s: intermediate root mismatch: hash %x, path %x", desc, roots[0], roots[1])
+			}
+		case diffOpCommit:
+			ref.finalise()
+			refSnap = refSnap[:0]
+
+			want, err := ref.root()
+			if err != nil {
+				return err
+			}
+			for _, s := range states {
+				root, err := s.commit()
+				if err != nil {
+					return fmt.Errorf("%s: %s: commit failed: %v", desc, s.scheme, err)
+				}
+				if root != want {
+					return fmt.Errorf("%s: %s: root mismatch: have %x, want %x", desc, s.scheme, root, want)
+				}
+			}
+		}
+		for _, s := range states {
+			if err := s.verify(ref); err != nil {
+				return fmt.Errorf("%s: %v", desc, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FuzzStateDBDifferential checks the StateDB against the reference model, over
+// the sequences of operations decoded from the fuzzer input.
+func FuzzStateDBDifferential(f *testing.F) {
+	// Destruct an account with storage, then resurrect it in the next
+	// transaction, within the same block and across blocks
+	f.Add([]byte{
+		diffOpSetBalance, 0, 1, diffOpSetState, 0, 129, diffOpSetState, 0, 200, diffOpCommit, 0, 0,
+		diffOpSelfDestruct, 0, 0, diffOpFinalise, 0, 0,
+		diffOpCreateAccount, 0, 0, diffOpSetNonce, 0, 1, diffOpSetState, 0, 130, diffOpCommit, 0, 0,
+		diffOpSelfDestruct, 0, 0, diffOpCommit, 0, 0,
+		diffOpAddBalance, 0, 5, diffOpCommit, 0, 0,
+	})
+	// Destruct an account, then revert the destruction
+	f.Add([]byte{
+		diffOpSetCode, 1, 7, diffOpSetState, 1, 131, diffOpCommit, 0, 0,
+		diffOpSnapshot, 0, 0, diffOpSelfDestruct, 1, 0, diffOpSetState, 1, 0, diffOpRevert, 0, 0,
+		diffOpSnapshot, 0, 0, diffOpSelfDestruct, 1, 0, diffOpFinalise, 0, 1,
+		diffOpCreateAccount, 1, 0, diffOpAddBalance, 1, 3, diffOpCommit, 0, 0,
+	})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := runDiffFuzz(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestStateDBDifferential runs the differential fuzzer over random sequences.
+func TestStateDBDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		data := make([]byte, 3*(1+r.Intn(100)))
+		r.Read(data)
+		if err := runDiffFuzz(data); err != nil {
+			t.Fatalf("sequence %d (%x): %v", i, data, err)
+		}
+	}
+}
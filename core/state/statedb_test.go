@@ -37,8 +37,10 @@ import (
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/trie/triestate"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/ethereum/go-ethereum/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/triedb/pathdb"
@@ -1287,3 +1289,116 @@ func TestDeleteStorage(t *testing.T) {
 		t.Fatalf("difference found:\nfast: %v\nslow: %v\n", fastRes, slowRes)
 	}
 }
+
+// Tests that the storage of multiple destructed accounts is deleted and tracked
+// as original value when committing.
+func TestHandleDestructionMultipleAccounts(t *testing.T) {
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		tdb   = triedb.NewDatabase(disk, &triedb.Config{PathDB: pathdb.Defaults})
+		db    = NewDatabaseWithNodeDB(disk, tdb)
+		addrs []common.Address
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	for i := 0; i < 16; i++ {
+		addr := common.BytesToAddress([]byte{byte(i + 1)})
+		state.SetBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+		for j := 0; j < 100; j++ {
+			state.SetState(addr, common.Hash(uint256.NewInt(uint64(j)).Bytes32()), common.Hash(uint256.NewInt(uint64(j+1)).Bytes32()))
+		}
+		addrs = append(addrs, addr)
+	}
+	root, err := state.Commit(0, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	tdb.Commit(root, false)
+
+	state, _ = New(root, db, nil)
+	for _, addr := range addrs {
+		state.SelfDestruct(addr)
+	}
+	var set *triestate.Set
+	state.onCommit = func(states *triestate.Set) { set = states }
+	if root, err = state.Commit(1, true); err != nil {
+		t.Fatalf("failed to commit destruction: %v", err)
+	}
+	if root != types.EmptyRootHash {
+		t.Fatalf("unexpected root after destruction: %x", root)
+	}
+	for _, addr := range addrs {
+		if len(set.Storages[addr]) != 100 {
+			t.Fatalf("unexpected original storage of %x: have %d slots, want 100", addr, len(set.Storages[addr]))
+		}
+	}
+}
+
+func TestIterateStorage(t *testing.T) {
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		tdb      = triedb.NewDatabase(disk, nil)
+		db       = NewDatabaseWithNodeDB(disk, tdb)
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, tdb, types.EmptyRootHash)
+		state, _ = New(types.EmptyRootHash, db, snaps)
+		addr     = common.HexToAddress("0x1")
+	)
+	state.SetBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	for i := byte(1); i <= 4; i++ {
+		state.SetState(addr, common.Hash{i}, common.Hash{i})
+	}
+	root, _ := state.Commit(0, true)
+
+	collect := func(state *StateDB, start common.Hash, limit int) map[common.Hash]common.Hash {
+		slots := make(map[common.Hash]common.Hash)
+		err := state.IterateStorage(addr, start, func(hash common.Hash, key *common.Hash, slot []byte) bool {
+			if len(slots) == limit {
+				return false
+			}
+			_, content, _, err := rlp.Split(slot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slots[hash] = common.BytesToHash(content)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slots
+	}
+	// Changes override the snapshot and deletions hide its slots
+	state, _ = New(root, db, snaps)
+	state.SetState(addr, common.Hash{0x01}, common.Hash{0x11})
+	state.SetState(addr, common.Hash{0x02}, common.Hash{})
+	state.SetState(addr, common.Hash{0x05}, common.Hash{0x05})
+
+	want := map[common.Hash]common.Hash{
+		crypto.Keccak256Hash(common.Hash{0x01}.Bytes()): {0x11},
+		crypto.Keccak256Hash(common.Hash{0x03}.Bytes()): {0x03},
+		crypto.Keccak256Hash(common.Hash{0x04}.Bytes()): {0x04},
+		crypto.Keccak256Hash(common.Hash{0x05}.Bytes()): {0x05},
+	}
+	if have := collect(state, common.Hash{}, 10); !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong storage: have %v, want %v", have, want)
+	}
+	if have := collect(state, common.Hash{}, 2); len(have) != 2 {
+		t.Fatalf("iteration not stopped: have %d slots", len(have))
+	}
+	// Destructed accounts don't see the storage in the snapshot anymore
+	state, _ = New(root, db, snaps)
+	state.SelfDestruct(addr)
+	state.Finalise(true)
+	state.SetState(addr, common.Hash{0x05}, common.Hash{0x05})
+
+	want = map[common.Hash]common.Hash{
+		crypto.Keccak256Hash(common.Hash{0x05}.Bytes()): {0x05},
+	}
+	if have := collect(state, common.Hash{}, 10); !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong storage after destruction: have %v, want %v", have, want)
+	}
+	// States without a snapshot can't be iterated
+	state, _ = New(root, db, nil)
+	if err := state.IterateStorage(addr, common.Hash{}, func(common.Hash, *common.Hash, []byte) bool { return true }); !errors.Is(err, ErrNoStorageSnapshot) {
+		t.Fatalf("unexpected error without snapshot: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// stateBackend is a trie backend the state tests are exercised against.
+type stateBackend struct {
+	name string
+	db   Database
+}
+
+// newStateBackends creates an empty state database for each supported trie
+// backend: the merkle patricia trie and the experimental verkle tree.
+func newStateBackends() []stateBackend {
+	verkledb := rawdb.NewMemoryDatabase()
+	return []stateBackend{
+		{name: "merkle", db: NewDatabase(rawdb.NewMemoryDatabase())},
+		{name: "verkle", db: NewDatabaseWithConfig(verkledb, &triedb.Config{IsVerkle: true, PathDB: pathdb.Defaults})},
+	}
+}
+
+// commitState commits the state, returning a fresh state opened at the new root.
+func commitState(t *testing.T, state *StateDB, db Database, block uint64) *StateDB {
+	t.Helper()
+
+	root, err := state.Commit(block, true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, err = New(root, db, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen state: %v", err)
+	}
+	return state
+}
+
+func TestStateBackendsRoundtrip(t *testing.T) {
+	for _, backend := range newStateBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			var (
+				addr  = common.HexToAddress("0xaaaa")
+				other = common.HexToAddress("0xbbbb")
+				code  = bytes.Repeat([]byte{0x5b}, 1000) // Spans multiple verkle code chunks
+			)
+			state, _ := New(types.EmptyRootHash, backend.db, nil)
+			state.SetBalance(addr, uint256.NewInt(42), tracing.BalanceChangeUnspecified)
+			state.SetNonce(addr, 7)
+			state.SetCode(addr, code)
+			state.SetState(addr, common.Hash{1}, common.Hash{2})
+			state.SetBalance(other, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+			state = commitState(t, state, backend.db, 1)
+
+			if balance := state.GetBalance(addr); balance.Uint64() != 42 {
+				t.Fatalf("balance mismatch: have %v, want 42", balance)
+			}
+			if nonce := state.GetNonce(addr); nonce != 7 {
+				t.Fatalf("nonce mismatch: have %d, want 7", nonce)
+			}
+			if have := state.GetCode(addr); !bytes.Equal(have, code) {
+				t.Fatalf("code mismatch: have %x, want %x", have, code)
+			}
+			if size := state.GetCodeSize(addr); size != len(code) {
+				t.Fatalf("code size mismatch: have %d, want %d", size, len(code))
+			}
+			if value := state.GetState(addr, common.Hash{1}); value != (common.Hash{2}) {
+				t.Fatalf("slot mismatch: have %x, want %x", value, common.Hash{2})
+			}
+			// Storage updates across blocks must not be served stale
+			parent := state.IntermediateRoot(true)
+			state.SetState(addr, common.Hash{1}, common.Hash{3})
+			state.SetState(addr, common.Hash{4}, common.Hash{5})
+			state = commitState(t, state, backend.db, 2)
+
+			if value := state.GetState(addr, common.Hash{1}); value != (common.Hash{3}) {
+				t.Fatalf("updated slot mismatch: have %x, want %x", value, common.Hash{3})
+			}
+			if value := state.GetState(addr, common.Hash{4}); value != (common.Hash{5}) {
+				t.Fatalf("new slot mismatch: have %x, want %x", value, common.Hash{5})
+			}
+			if balance := state.GetBalance(other); balance.Uint64() != 1 {
+				t.Fatalf("untouched balance mismatch: have %v, want 1", balance)
+			}
+			// Nor the parent state be served the updates
+			state, err := New(parent, backend.db, nil)
+			if err != nil {
+				t.Fatalf("failed to open parent state: %v", err)
+			}
+			if value := state.GetState(addr, common.Hash{1}); value != (common.Hash{2}) {
+				t.Fatalf("parent slot mismatch: have %x, want %x", value, common.Hash{2})
+			}
+		})
+	}
+}
+
+func TestStateBackendsTrieType(t *testing.T) {
+	for _, backend := range newStateBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			tr, err := backend.db.OpenTrie(types.EmptyRootHash)
+			if err != nil {
+				t.Fatalf("failed to open trie: %v", err)
+			}
+			_, verkle := tr.(*trie.VerkleTrie)
+			if verkle != backend.db.TrieDB().IsVerkle() {
+				t.Fatalf("unexpected trie type %T", tr)
+			}
+			// Storage shares the account trie in verkle
+			st, err := backend.db.OpenStorageTrie(types.EmptyRootHash, common.Address{}, types.EmptyRootHash, tr)
+			if err != nil {
+				t.Fatalf("failed to open storage trie: %v", err)
+			}
+			if verkle && st != tr {
+				t.Fatal("verkle storage trie differs from the account trie")
+			}
+		})
+	}
+}
+
+func TestStateBackendsSelfDestruct(t *testing.T) {
+	for _, backend := range newStateBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			addr := common.HexToAddress("0xaaaa")
+
+			state, _ := New(types.EmptyRootHash, backend.db, nil)
+			state.SetBalance(addr, uint256.NewInt(42), tracing.BalanceChangeUnspecified)
+			state.SetCode(addr, []byte{0x60, 0x00})
+			state.SetState(addr, common.Hash{1}, common.Hash{2})
+			state = commitState(t, state, backend.db, 1)
+
+			state.SelfDestruct(addr)
+			state = commitState(t, state, backend.db, 2)
+
+			if state.Exist(addr) {
+				t.Fatal("destructed account still exists")
+			}
+			if code := state.GetCode(addr); len(code) != 0 {
+				t.Fatalf("destructed code retained: %x", code)
+			}
+		})
+	}
+}
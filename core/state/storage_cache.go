@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// Arbitrum: Number of clean accounts kept in the process-wide account cache.
+	accountCacheItems = 64 * 1024
+
+	// Arbitrum: Number of clean storage slots kept in the process-wide slot cache.
+	slotCacheItems = 1024 * 1024
+
+	// Approximate memory used by an account and a slot cache entry, including
+	// the LRU bookkeeping, for the memory budget accounting.
+	accountCacheEntrySize = 256
+	slotCacheEntrySize    = 192
+)
+
+// accountCacheKey identifies an account at a specific state root.
+type accountCacheKey struct {
+	root common.Hash
+	addr common.Address
+}
+
+// slotCacheKey identifies a storage slot (by the hash of its key) of an
+// account whose storage trie has the given root.
+type slotCacheKey struct {
+	root common.Hash
+	addr common.Address
+	slot common.Hash
+}
+
+// storageCache is a process-wide, size-bounded cache of clean account and
+// storage data resolved from the snapshot or the tries. Entries are keyed by
+// the root they were resolved against, so they can never be served for a
+// different state; commits merely drop the entries that have been superseded
+// to make room for fresh ones.
+//
+// The cache is shared by all state databases, since the roots uniquely
+// identify the content regardless of where it was loaded from.
+type storageCache struct {
+	accounts *lru.Cache[accountCacheKey, *types.StateAccount]
+	slots    *lru.Cache[slotCacheKey, common.Hash]
+
+	budget atomic.Pointer[memoryAccount] // Memory budget accounting, nil if none
+}
+
+var globalStorageCache = newStorageCache(accountCacheItems, slotCacheItems)
+
+func newStorageCache(accounts, slots int) *storageCache {
+	return &storageCache{
+		accounts: lru.NewCache[accountCacheKey, *types.StateAccount](accounts),
+		slots:    lru.NewCache[slotCacheKey, common.Hash](slots),
+	}
+}
+
+// account returns a copy of the cached account at the given state root.
+func (c *storageCache) account(root common.Hash, addr common.Address) (*types.StateAccount, bool) {
+	acct, ok := c.accounts.Get(accountCacheKey{root, addr})
+	if !ok {
+		storageCacheAccountMissMeter.Mark(1)
+		return nil, false
+	}
+	storageCacheAccountHitMeter.Mark(1)
+	return acct.Copy(), true
+}
+
+// setAccount caches a copy of the given account at the given state root.
+func (c *storageCache) setAccount(root common.Hash, addr common.Address, acct *types.StateAccount) {
+	c.accounts.Add(accountCacheKey{root, addr}, acct.Copy())
+	c.track()
+}
+
+// slot returns the cached value of the storage slot of the account whose
+// storage trie has the given root.
+func (c *storageCache) slot(root common.Hash, addr common.Address, slot common.Hash) (common.Hash, bool) {
+	value, ok := c.slots.Get(slotCacheKey{root, addr, slot})
+	if !ok {
+		storageCacheSlotMissMeter.Mark(1)
+		return common.Hash{}, false
+	}
+	storageCacheSlotHitMeter.Mark(1)
+	return value, true
+}
+
+// setSlot caches the value of the storage slot of the account whose storage
+// trie has the given root.
+func (c *storageCache) setSlot(root common.Hash, addr common.Address, slot common.Hash, value common.Hash) {
+	c.slots.Add(slotCacheKey{root, addr, slot}, value)
+	c.track()
+}
+
+// invalidate drops the entries superseded by a commit: the mutated accounts
+// at the pre-commit state root and their mutated slots at the pre-commit
+// storage roots.
+func (c *storageCache) invalidate(root common.Hash, accounts map[common.Address]common.Hash, slots map[common.Address]map[common.Hash][]byte) {
+	for addr, storageRoot := range accounts {
+		c.accounts.Remove(accountCacheKey{root, addr})
+		for slot := range slots[addr] {
+			c.slots.Remove(slotCacheKey{storageRoot, addr, slot})
+		}
+	}
+	c.track()
+}
+
+// size returns the approximate memory used by the cache.
+func (c *storageCache) size() uint64 {
+	return uint64(c.accounts.Len())*accountCacheEntrySize + uint64(c.slots.Len())*slotCacheEntrySize
+}
+
+// track reports the memory used by the cache to the memory budget, if any.
+func (c *storageCache) track() {
+	if account := c.budget.Load(); account != nil {
+		account.track(c.size())
+	}
+}
+
+// shrink evicts the least recently used slots, then accounts, until at least
+// the given number of bytes is released.
+func (c *storageCache) shrink(bytes uint64) uint64 {
+	var released uint64
+	for released < bytes {
+		if _, _, ok := c.slots.RemoveOldest(); !ok {
+			break
+		}
+		released += slotCacheEntrySize
+	}
+	for released < bytes {
+		if _, _, ok := c.accounts.RemoveOldest(); !ok {
+			break
+		}
+		released += accountCacheEntrySize
+	}
+	return c.size()
+}
+
+// attach accounts the cache against the given memory budget. As the cache is
+// process-wide, it's accounted against the last budget attached.
+func (c *storageCache) attach(budget *MemoryBudget) {
+	account := budget.register("storage", storageCachePriority, c.shrink)
+	c.budget.Store(account)
+	account.track(c.size())
+}
+
+// PurgeStorageCache drops all the entries of the process-wide account and
+// storage slot cache.
+func PurgeStorageCache() {
+	globalStorageCache.accounts.Purge()
+	globalStorageCache.slots.Purge()
+	globalStorageCache.track()
+}
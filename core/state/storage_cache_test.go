@@ -0,0 +1,97 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func TestStorageCache(t *testing.T) {
+	PurgeStorageCache()
+	defer PurgeStorageCache()
+
+	var (
+		db    = NewDatabase(rawdb.NewMemoryDatabase())
+		addr  = common.HexToAddress("0x1")
+		slot  = common.HexToHash("0x2")
+		khash = crypto.Keccak256Hash(slot.Bytes())
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(addr, uint256.NewInt(42), tracing.BalanceChangeUnspecified)
+	state.SetState(addr, slot, common.HexToHash("0x3"))
+	root, err := state.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Resolve the account and the slot from a fresh state, populating the cache
+	state, _ = New(root, db, nil)
+	if got := state.GetState(addr, slot); got != common.HexToHash("0x3") {
+		t.Fatalf("slot mismatch: have %x, want %x", got, common.HexToHash("0x3"))
+	}
+	storageRoot := state.GetStorageRoot(addr)
+	if _, ok := globalStorageCache.account(root, addr); !ok {
+		t.Fatal("account not cached")
+	}
+	if value, ok := globalStorageCache.slot(storageRoot, addr, khash); !ok || value != common.HexToHash("0x3") {
+		t.Fatalf("slot not cached: have %x, %v", value, ok)
+	}
+	// A fresh state must be served from the cache
+	cached, _ := New(root, db, nil)
+	if got := cached.GetBalance(addr); got.Uint64() != 42 {
+		t.Fatalf("balance mismatch: have %v, want 42", got)
+	}
+	if got := cached.GetState(addr, slot); got != common.HexToHash("0x3") {
+		t.Fatalf("slot mismatch: have %x, want %x", got, common.HexToHash("0x3"))
+	}
+	// Mutating the slot must drop the superseded entries on commit
+	state.SetState(addr, slot, common.HexToHash("0x4"))
+	if _, err := state.Commit(1, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if _, ok := globalStorageCache.account(root, addr); ok {
+		t.Fatal("superseded account still cached")
+	}
+	if _, ok := globalStorageCache.slot(storageRoot, addr, khash); ok {
+		t.Fatal("superseded slot still cached")
+	}
+}
+
+func TestStorageCacheDeterministic(t *testing.T) {
+	PurgeStorageCache()
+	defer PurgeStorageCache()
+
+	var (
+		db   = NewDatabase(rawdb.NewMemoryDatabase())
+		addr = common.HexToAddress("0x1")
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(addr, uint256.NewInt(42), tracing.BalanceChangeUnspecified)
+	root, _ := state.Commit(0, false)
+
+	state, _ = NewDeterministic(root, db)
+	state.GetBalance(addr)
+	if _, ok := globalStorageCache.account(root, addr); ok {
+		t.Fatal("deterministic state populated the cache")
+	}
+}
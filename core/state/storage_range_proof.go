@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// errInvalidRangeLimit is returned if a storage range of no slots is requested.
+var errInvalidRangeLimit = errors.New("storage range limit must be positive")
+
+// StorageRange is a contiguous range of the storage slots of an account, by
+// increasing slot hash.
+type StorageRange struct {
+	Root   common.Hash    // Storage root the range is proven against
+	Hashes []common.Hash  // Hashes of the slots
+	Values [][]byte       // RLP-encoded values of the slots, as stored in the trie
+	Keys   []*common.Hash // Keys of the slots, nil if the preimage is unknown
+	Next   *common.Hash   // Hash of the slot following the range, nil if none
+}
+
+// ProveStorageRange collects the storage slots of the given account from the
+// start slot hash onwards, up to the given number of slots, and writes the
+// proof of the range to proofDb: the union of the edge proofs of the start hash
+// and the last slot, as accepted by trie.VerifyRangeProof. Passing a ProofSet
+// deduplicates the nodes shared by the two edges.
+//
+// The range is read from the storage trie with the uncommitted changes applied,
+// so the state must have been hashed by IntermediateRoot for the root to match
+// the one of the account. An account with no storage yields an empty range.
+func (s *StateDB) ProveStorageRange(addr common.Address, start common.Hash, limit int, proofDb ethdb.KeyValueWriter) (*StorageRange, error) {
+	if limit <= 0 {
+		return nil, errInvalidRangeLimit
+	}
+	tr, err := s.StorageTrie(addr)
+	if err != nil {
+		return nil, err
+	}
+	result := new(StorageRange)
+	if tr == nil {
+		return result, nil
+	}
+	result.Root = tr.Hash()
+
+	nodeIt, err := tr.NodeIterator(start[:])
+	if err != nil {
+		return nil, err
+	}
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if len(result.Hashes) == limit {
+			result.Next = &hash
+			break
+		}
+		result.Hashes = append(result.Hashes, hash)
+		result.Values = append(result.Values, common.CopyBytes(it.Value))
+
+		var key *common.Hash
+		if preimage := tr.GetKey(it.Key); preimage != nil {
+			key = new(common.Hash)
+			*key = common.BytesToHash(preimage)
+		}
+		result.Keys = append(result.Keys, key)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	// Prove the edges of the range, the start hash proving the absence of any
+	// slot before the first one, and the last slot the absence of any other
+	// slot in the range.
+	if err := tr.Prove(start[:], proofDb); err != nil {
+		return nil, err
+	}
+	if n := len(result.Hashes); n > 0 {
+		if err := tr.Prove(result.Hashes[n-1][:], proofDb); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the storage of an account is proven in consecutive ranges, each
+// verifiable against the storage root of the account.
+func TestProveStorageRange(t *testing.T) {
+	var (
+		db    = NewDatabase(rawdb.NewMemoryDatabase())
+		addr  = common.HexToAddress("0xaaaa")
+		empty = common.HexToAddress("0xbbbb")
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(addr, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	state.SetBalance(empty, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	for i := 0; i < 100; i++ {
+		state.SetState(addr, common.BigToHash(new(big.Int).Lsh(common.Big1, uint(i))), common.BigToHash(big.NewInt(int64(i+1))))
+	}
+	state.IntermediateRoot(true)
+	root := state.GetStorageRoot(addr)
+
+	var (
+		start common.Hash
+		slots int
+	)
+	for {
+		proof := trienode.NewProofSet()
+		result, err := state.ProveStorageRange(addr, start, 30, proof)
+		if err != nil {
+			t.Fatalf("failed to prove range from %x: %v", start, err)
+		}
+		if result.Root != root {
+			t.Fatalf("range root mismatch: have %x, want %x", result.Root, root)
+		}
+		keys := make([][]byte, len(result.Hashes))
+		for i, hash := range result.Hashes {
+			keys[i] = common.CopyBytes(hash[:])
+		}
+		more, err := trie.VerifyRangeProof(root, start[:], keys, result.Values, proof)
+		if err != nil {
+			t.Fatalf("failed to verify range from %x: %v", start, err)
+		}
+		if more != (result.Next != nil) {
+			t.Fatalf("continuation mismatch: more %v, next %v", more, result.Next)
+		}
+		slots += len(result.Hashes)
+		if result.Next == nil {
+			break
+		}
+		start = *result.Next
+	}
+	if slots != 100 {
+		t.Fatalf("proven slot count mismatch: have %d, want 100", slots)
+	}
+	// Tampering with a value must break the proof
+	proof := trienode.NewProofSet()
+	result, err := state.ProveStorageRange(addr, common.Hash{}, 10, proof)
+	if err != nil {
+		t.Fatalf("failed to prove range: %v", err)
+	}
+	keys := make([][]byte, len(result.Hashes))
+	for i, hash := range result.Hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	result.Values[5] = []byte{0x42}
+	if _, err := trie.VerifyRangeProof(root, nil, keys, result.Values, proof); err == nil {
+		t.Fatal("tampered range verified")
+	}
+	// An account without storage yields an empty range
+	result, err = state.ProveStorageRange(empty, common.Hash{}, 10, trienode.NewProofSet())
+	if err != nil {
+		t.Fatalf("failed to prove empty range: %v", err)
+	}
+	if len(result.Hashes) != 0 || result.Next != nil {
+		t.Fatalf("unexpected range of empty storage: %d slots, next %v", len(result.Hashes), result.Next)
+	}
+	if _, err := state.ProveStorageRange(addr, common.Hash{}, 0, trienode.NewProofSet()); err != errInvalidRangeLimit {
+		t.Fatalf("unexpected error for zero limit: %v", err)
+	}
+}
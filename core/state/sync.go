@@ -26,8 +26,29 @@ import (
 
 // NewStateSync creates a new state trie download scheduler.
 func NewStateSync(root common.Hash, database ethdb.KeyValueReader, onLeaf func(keys [][]byte, leaf []byte) error, scheme string) *trie.Sync {
+	var syncer *trie.Sync
+	onAccount, _ := stateSyncCallbacks(&syncer, onLeaf)
+	syncer = trie.NewSync(root, database, onAccount, scheme)
+	return syncer
+}
+
+// RestoreStateSync recreates a state trie download scheduler from the queue
+// exported by an interrupted one, to resume its sync.
+func RestoreStateSync(queue *trie.SyncQueue, database ethdb.KeyValueReader, onLeaf func(keys [][]byte, leaf []byte) error, scheme string) (*trie.Sync, error) {
+	var (
+		syncer *trie.Sync
+		err    error
+	)
+	onAccount, onSlot := stateSyncCallbacks(&syncer, onLeaf)
+	syncer, err = trie.RestoreSync(queue, database, onAccount, onSlot, scheme)
+	return syncer, err
+}
+
+// stateSyncCallbacks returns the callbacks of the leaves of the account trie
+// and the storage tries of the given state trie download scheduler.
+func stateSyncCallbacks(syncer **trie.Sync, onLeaf func(keys [][]byte, leaf []byte) error) (trie.LeafCallback, trie.LeafCallback) {
 	// Register the storage slot callback if the external callback is specified.
-	var onSlot func(keys [][]byte, path []byte, leaf []byte, parent common.Hash, parentPath []byte) error
+	var onSlot trie.LeafCallback
 	if onLeaf != nil {
 		onSlot = func(keys [][]byte, path []byte, leaf []byte, parent common.Hash, parentPath []byte) error {
 			return onLeaf(keys, leaf)
@@ -35,7 +56,6 @@ func NewStateSync(root common.Hash, database ethdb.KeyValueReader, onLeaf func(k
 	}
 	// Register the account callback to connect the state trie and the storage
 	// trie belongs to the contract.
-	var syncer *trie.Sync
 	onAccount := func(keys [][]byte, path []byte, leaf []byte, parent common.Hash, parentPath []byte) error {
 		if onLeaf != nil {
 			if err := onLeaf(keys, leaf); err != nil {
@@ -46,10 +66,9 @@ func NewStateSync(root common.Hash, database ethdb.KeyValueReader, onLeaf func(k
 		if err := rlp.DecodeBytes(leaf, &obj); err != nil {
 			return err
 		}
-		syncer.AddSubTrie(obj.Root, path, parent, parentPath, onSlot)
-		syncer.AddCodeEntry(common.BytesToHash(obj.CodeHash), path, parent, parentPath)
+		(*syncer).AddSubTrie(obj.Root, path, parent, parentPath, onSlot)
+		(*syncer).AddCodeEntry(common.BytesToHash(obj.CodeHash), path, parent, parentPath)
 		return nil
 	}
-	syncer = trie.NewSync(root, database, onAccount, scheme)
-	return syncer
+	return onAccount, onSlot
 }
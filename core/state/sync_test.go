@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -746,3 +747,86 @@ func copyPreimages(srcDb, dstDb ethdb.Database) {
 	}
 	rawdb.WritePreimages(dstDb, preimages)
 }
+
+// Tests that a sync interrupted at any point resumes from its exported queue,
+// without retrieving any node or code twice.
+func TestResumedStateSync(t *testing.T) {
+	testResumedStateSync(t, rawdb.HashScheme)
+	testResumedStateSync(t, rawdb.PathScheme)
+}
+
+func testResumedStateSync(t *testing.T, scheme string) {
+	// Create a random state to copy
+	srcDisk, srcDb, ndb, srcRoot, srcAccounts := makeTestState(scheme)
+
+	// Create a destination state and sync with the scheduler
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := NewStateSync(srcRoot, dstDb, nil, ndb.Scheme())
+
+	reader, err := ndb.Reader(srcRoot)
+	if err != nil {
+		t.Fatalf("state is not available %x", srcRoot)
+	}
+	var (
+		fetchedNodes = make(map[string]struct{})
+		fetchedCodes = make(map[common.Hash]struct{})
+	)
+	for sched.Pending() > 0 {
+		// Process only part of the retrieved items, leaving the others in flight
+		paths, nodes, codes := sched.Missing(8)
+		for i := 0; i < len(paths); i += 2 {
+			if _, ok := fetchedNodes[paths[i]]; ok {
+				t.Fatalf("node %x retrieved twice", paths[i])
+			}
+			fetchedNodes[paths[i]] = struct{}{}
+
+			owner, inner := trie.ResolvePath([]byte(paths[i]))
+			data, err := reader.Node(owner, inner, nodes[i])
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for path %x: %v", paths[i], err)
+			}
+			if err := sched.ProcessNode(trie.NodeSyncResult{Path: paths[i], Data: data}); err != nil {
+				t.Fatalf("failed to process node %x: %v", paths[i], err)
+			}
+		}
+		for _, hash := range codes {
+			if _, ok := fetchedCodes[hash]; ok {
+				t.Fatalf("code %x retrieved twice", hash)
+			}
+			fetchedCodes[hash] = struct{}{}
+
+			data, err := srcDb.ContractCode(common.Address{}, hash)
+			if err != nil {
+				t.Fatalf("failed to retrieve contract bytecode for hash %x", hash)
+			}
+			if err := sched.ProcessCode(trie.CodeSyncResult{Hash: hash, Data: data}); err != nil {
+				t.Fatalf("failed to process code %x: %v", hash, err)
+			}
+		}
+		batch := dstDb.NewBatch()
+		if err := sched.Commit(batch); err != nil {
+			t.Fatalf("failed to commit data: %v", err)
+		}
+		batch.Write()
+
+		// Interrupt the sync, resuming it from the exported queue. The items left
+		// in flight are scheduled again.
+		blob, err := json.Marshal(sched.Export())
+		if err != nil {
+			t.Fatalf("failed to encode sync queue: %v", err)
+		}
+		var queue trie.SyncQueue
+		if err := json.Unmarshal(blob, &queue); err != nil {
+			t.Fatalf("failed to decode sync queue: %v", err)
+		}
+		if sched, err = RestoreStateSync(&queue, dstDb, nil, ndb.Scheme()); err != nil {
+			t.Fatalf("failed to restore sync: %v", err)
+		}
+	}
+	// Copy the preimages from source db in order to traverse the state.
+	srcDb.TrieDB().WritePreimages()
+	copyPreimages(srcDisk, dstDb)
+
+	// Cross check that the two states are in sync
+	checkStateAccounts(t, dstDb, ndb.Scheme(), srcRoot, srcAccounts)
+}
@@ -33,13 +33,17 @@ var (
 // items and does trie-loading of them. The goal is to get as much useful content
 // into the caches as possible.
 //
-// Note, the prefetcher's API is not thread safe.
+// Note, the prefetcher's API is not thread safe, unless it's shared by the
+// states of a Forker.
 type triePrefetcher struct {
 	db       Database               // Database to fetch trie nodes through
 	root     common.Hash            // Root hash of the account trie for metrics
 	fetches  map[string]Trie        // Partially or fully fetched tries. Only populated for inactive copies.
 	fetchers map[string]*subfetcher // Subfetchers for each trie
 
+	shared bool       // Whether the prefetcher is shared by the states of a Forker, which don't own it
+	lock   sync.Mutex // Lock protecting the fetchers of a shared prefetcher
+
 	deliveryMissMeter metrics.Meter
 	accountLoadMeter  metrics.Meter
 	accountDupMeter   metrics.Meter
@@ -72,8 +76,35 @@ func newTriePrefetcher(db Database, root common.Hash, namespace string) *triePre
 }
 
 // close iterates over all the subfetchers, aborts any that were left spinning
-// and reports the stats to the metrics subsystem.
+// and reports the stats to the metrics subsystem. Shared prefetchers are only
+// closed by their Forker.
 func (p *triePrefetcher) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.shared {
+		return
+	}
+	p.terminate()
+}
+
+// closeShared terminates a shared prefetcher, retaining the tries loaded so far
+// for the states still using it.
+func (p *triePrefetcher) closeShared() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	fetches := make(map[string]Trie, len(p.fetchers))
+	for id, fetcher := range p.fetchers {
+		fetcher.abort()
+		fetches[id] = fetcher.peek()
+	}
+	p.terminate()
+	p.fetches = fetches
+}
+
+// terminate aborts all the subfetchers and reports their stats.
+func (p *triePrefetcher) terminate() {
 	for _, fetcher := range p.fetchers {
 		fetcher.abort() // safe to do multiple times
 
@@ -108,6 +139,9 @@ func (p *triePrefetcher) close() {
 // is mostly used in the miner which creates a copy of it's actively mutated
 // state to be sealed while it may further mutate the state.
 func (p *triePrefetcher) copy() *triePrefetcher {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	copy := &triePrefetcher{
 		db:      p.db,
 		root:    p.root,
@@ -142,6 +176,9 @@ func (p *triePrefetcher) copy() *triePrefetcher {
 
 // prefetch schedules a batch of trie items to prefetch.
 func (p *triePrefetcher) prefetch(owner common.Hash, root common.Hash, addr common.Address, keys [][]byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	// If the prefetcher is an inactive one, bail out
 	if p.fetches != nil {
 		return
@@ -159,6 +196,9 @@ func (p *triePrefetcher) prefetch(owner common.Hash, root common.Hash, addr comm
 // trie returns the trie matching the root hash, or nil if the prefetcher doesn't
 // have it.
 func (p *triePrefetcher) trie(owner common.Hash, root common.Hash) Trie {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	// If the prefetcher is inactive, return from existing deep copies
 	id := p.trieID(owner, root)
 	if p.fetches != nil {
@@ -176,8 +216,11 @@ func (p *triePrefetcher) trie(owner common.Hash, root common.Hash) Trie {
 		return nil
 	}
 	// Interrupt the prefetcher if it's by any chance still running and return
-	// a copy of any pre-loaded trie.
-	fetcher.abort() // safe to do multiple times
+	// a copy of any pre-loaded trie. Shared prefetchers keep running for the
+	// other states.
+	if !p.shared {
+		fetcher.abort() // safe to do multiple times
+	}
 
 	trie := fetcher.peek()
 	if trie == nil {
@@ -190,6 +233,13 @@ func (p *triePrefetcher) trie(owner common.Hash, root common.Hash) Trie {
 // used marks a batch of state items used to allow creating statistics as to
 // how useful or wasteful the prefetcher is.
 func (p *triePrefetcher) used(owner common.Hash, root common.Hash, used [][]byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// The usage of a shared prefetcher is spread over multiple states
+	if p.shared {
+		return
+	}
 	if fetcher := p.fetchers[p.trieID(owner, root)]; fetcher != nil {
 		fetcher.used = used
 	}
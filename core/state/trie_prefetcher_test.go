@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 )
 
@@ -111,3 +112,32 @@ func TestCopyClose(t *testing.T) {
 		t.Fatal("Copy trie should not return nil")
 	}
 }
+
+func TestPrefetchAccessList(t *testing.T) {
+	var (
+		db   = NewDatabase(rawdb.NewMemoryDatabase())
+		addr = common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
+		skey = common.HexToHash("aaa")
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetState(addr, skey, common.HexToHash("bbb"))
+	root, _ := state.Commit(0, false)
+
+	state, _ = New(root, db, nil)
+	state.GetBalance(addr) // Resolve the storage root of the account
+	state.prefetcher = newTriePrefetcher(db, root, "")
+	defer state.StopPrefetcher()
+
+	list := types.AccessList{{Address: addr, StorageKeys: []common.Hash{skey}}}
+	state.Prepare(params.Rules{IsBerlin: true}, common.Address{}, common.Address{}, nil, nil, list)
+
+	obj := state.stateObjects[addr]
+	for _, id := range []string{
+		state.prefetcher.trieID(common.Hash{}, root),
+		state.prefetcher.trieID(obj.addrHash, obj.data.Root),
+	} {
+		if _, ok := state.prefetcher.fetchers[id]; !ok {
+			t.Fatalf("trie %x not scheduled for prefetching", id)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errStateHistoryUnsupported = errors.New("state history requires the path scheme")
+
+// StorageHistoryEntry is the value of a storage slot from a block on.
+type StorageHistoryEntry struct {
+	Block uint64      // Number of the block the slot has the value after
+	Value common.Hash // Value of the slot
+}
+
+// StorageHistory returns the value of the given storage slot after the first
+// block of the range, followed by its new values after each of the blocks of
+// the range modifying it.
+//
+// The values are derived without re-execution: from the flat history if it
+// covers the range, otherwise the blocks above the disk layer are read from the
+// state layers kept in memory, and the older ones from the original values
+// recorded by the state histories of the path scheme, as far as the state
+// history retention goes.
+func (bc *BlockChain) StorageHistory(address common.Address, slot common.Hash, from, to uint64) ([]StorageHistoryEntry, error) {
+	if head := bc.CurrentBlock().Number.Uint64(); to > head {
+		to = head
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if bc.flatHistory != nil {
+		entries, err := bc.flatHistory.StorageHistory(address, slot, from, to)
+		if !errors.Is(err, errFlatHistoryUncovered) {
+			return entries, err
+		}
+	}
+	if bc.triedb.Scheme() != rawdb.PathScheme {
+		return nil, errStateHistoryUnsupported
+	}
+	value := func(number uint64) (common.Hash, error) {
+		header := bc.GetHeaderByNumber(number)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("header of block %d unavailable", number)
+		}
+		statedb, err := bc.StateAt(header.Root)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("state of block %d unavailable: %w", number, err)
+		}
+		return statedb.GetState(address, slot), nil
+	}
+	// The state of the disk layer is the one of the last state history, if any
+	disk := from
+	if _, last, err := bc.triedb.HistoryRange(); err == nil && last > from {
+		disk = last
+	}
+	// Read the values of the blocks above the disk layer from their states,
+	// walking down from the last block of the range
+	var (
+		top      = max(to, disk)
+		changes  []StorageHistoryEntry
+		current  common.Hash
+		err      error
+		previous common.Hash
+	)
+	if current, err = value(top); err != nil {
+		return nil, err
+	}
+	for number := top; number > disk; number-- {
+		if previous, err = value(number - 1); err != nil {
+			return nil, err
+		}
+		if previous != current {
+			changes = append(changes, StorageHistoryEntry{Block: number, Value: current})
+		}
+		current = previous
+	}
+	// Walk down the state histories from the disk layer, the original values
+	// they record being the values after the blocks preceding
+	if disk > from {
+		start, err := bc.stateHistoryID(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bc.stateHistoryID(disk)
+		if err != nil {
+			return nil, err
+		}
+		stats, err := bc.triedb.StorageHistory(address, crypto.Keccak256Hash(slot.Bytes()), start+1, end)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(stats.Blocks) - 1; i >= 0; i-- {
+			if previous, err = decodeStorageOrigin(stats.Origins[i]); err != nil {
+				return nil, err
+			}
+			if previous != current {
+				changes = append(changes, StorageHistoryEntry{Block: stats.Blocks[i], Value: current})
+			}
+			current = previous
+		}
+	}
+	// Report the value after the first block followed by the changes in range
+	entries := []StorageHistoryEntry{{Block: from, Value: current}}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].Block <= to {
+			entries = append(entries, changes[i])
+		}
+	}
+	return entries, nil
+}
+
+// stateHistoryID returns the id of the state of the given block in the state
+// history of the path scheme.
+func (bc *BlockChain) stateHistoryID(number uint64) (uint64, error) {
+	header := bc.GetHeaderByNumber(number)
+	if header == nil {
+		return 0, fmt.Errorf("header of block %d unavailable", number)
+	}
+	id := rawdb.ReadStateID(bc.db, header.Root)
+	if id == nil {
+		return 0, fmt.Errorf("state history of block %d pruned", number)
+	}
+	return *id, nil
+}
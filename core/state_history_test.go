@@ -0,0 +1,115 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the storage history is reconstructed from both the state layers in
+// memory and the state histories below the disk layer.
+func TestStorageHistory(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		storer = common.HexToAddress("0xee")
+		slot   = common.BigToHash(big.NewInt(1))
+		engine = ethash.NewFaker()
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.PathScheme)
+		blocks = state.DefaultTriesInMemory + 32
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+			storer: {Code: []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}},
+		},
+	}
+	// Every fifth block sets the slot, and every seventh one clears it
+	want := []StorageHistoryEntry{{Block: 0}}
+	_, chainBlocks, _ := GenerateChainWithGenesis(genesis, engine, blocks, func(i int, b *BlockGen) {
+		number := uint64(i + 1)
+		value := common.Hash{}
+		switch {
+		case number%7 == 0:
+		case number%5 == 0:
+			value = common.BigToHash(new(big.Int).SetUint64(number))
+		default:
+			return
+		}
+		data := append(value.Bytes(), slot.Bytes()...)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), storer, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(tx)
+
+		if last := want[len(want)-1]; last.Value != value {
+			want = append(want, StorageHistoryEntry{Block: number, Value: value})
+		}
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	chain, err := NewBlockChain(db, config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(chainBlocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, last, err := chain.triedb.HistoryRange(); err != nil || last == 0 {
+		t.Fatalf("state history missing: last %d, err %v", last, err)
+	}
+	// Query ranges both below and above the disk layer
+	for _, r := range [][2]uint64{{0, uint64(blocks)}, {12, 80}, {35, 35}, {40, uint64(blocks)}, {uint64(blocks) - 10, uint64(blocks)}} {
+		from, to := r[0], r[1]
+		var expect []StorageHistoryEntry
+		for _, entry := range want {
+			switch {
+			case entry.Block <= from:
+				expect = []StorageHistoryEntry{{Block: from, Value: entry.Value}}
+			case entry.Block <= to:
+				expect = append(expect, entry)
+			}
+		}
+		have, err := chain.StorageHistory(storer, slot, from, to)
+		if err != nil {
+			t.Fatalf("failed to retrieve history %d-%d: %v", from, to, err)
+		}
+		if !reflect.DeepEqual(have, expect) {
+			t.Fatalf("history %d-%d mismatch:\nhave %v\nwant %v", from, to, have, expect)
+		}
+	}
+	if _, err := chain.StorageHistory(storer, slot, 10, 5); err == nil {
+		t.Fatal("invalid range accepted")
+	}
+}
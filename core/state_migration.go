@@ -0,0 +1,593 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	stateMigrationNodesMeter    = metrics.NewRegisteredMeter("chain/statemigration/nodes", nil)
+	stateMigrationAccountsMeter = metrics.NewRegisteredMeter("chain/statemigration/accounts", nil)
+	stateMigrationBlockGauge    = metrics.NewRegisteredGauge("chain/statemigration/block", nil)
+)
+
+var (
+	errStateMigrationNotBackfilled = errors.New("state migration backfill not complete")
+	errStateMigrationCutOver       = errors.New("state migration already cut over")
+)
+
+// stateMigrationInterval is the interval between two catch-ups of the migrated
+// state with the latest state persisted by the hash scheme.
+var stateMigrationInterval = time.Minute
+
+// stateMigrationBatch is the number of accounts backfilled at once, between two
+// saves of the migration progress.
+const stateMigrationBatch = 10_000
+
+// stateMigrationProgress is the persisted progress of the state migration.
+type stateMigrationProgress struct {
+	Root         common.Hash // Root of the state migrated, or being backfilled
+	Number       uint64      // Number of the block of Root
+	Target       common.Hash // Root of the state being caught up with, if any
+	TargetNumber uint64      // Number of the block of Target
+	Cursor       []byte      // Hashed key of the last account backfilled
+	Backfilled   bool        // Whether the state at Root was fully backfilled
+	CutOver      bool        // Whether the migrated state was made the persistent one
+	Accounts     uint64      // Number of accounts backfilled
+	Nodes        uint64      // Number of path scheme trie nodes written
+}
+
+func readStateMigrationProgress(db ethdb.KeyValueReader) (*stateMigrationProgress, error) {
+	blob := rawdb.ReadStateMigrationProgress(db)
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	progress := new(stateMigrationProgress)
+	if err := rlp.DecodeBytes(blob, progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func writeStateMigrationProgress(db ethdb.KeyValueWriter, progress *stateMigrationProgress) {
+	blob, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode state migration progress", "err", err)
+	}
+	rawdb.WriteStateMigrationProgress(db, blob)
+}
+
+// StateMigrationStatus describes the progress of the state migration.
+type StateMigrationStatus struct {
+	Number     uint64      // Number of the block of the state migrated, or being backfilled
+	Root       common.Hash // Root of the state migrated, or being backfilled
+	Head       uint64      // Number of the chain head
+	Progress   float64     // Estimated fraction of the state backfilled
+	Backfilled bool        // Whether the backfill is complete
+	CutOver    bool        // Whether the migrated state was made the persistent one
+	Accounts   uint64      // Number of accounts backfilled
+	Nodes      uint64      // Number of path scheme trie nodes written
+}
+
+// StateMigration converts the state of a hash scheme node to the path scheme in
+// the background, while the node keeps running on the hash scheme.
+//
+// The state persisted when the migration starts is backfilled first, writing
+// the trie nodes under their path scheme keys next to the hash scheme ones. The
+// migrated state then follows the states persisted by the hash scheme, by
+// writing the nodes changed between the two and deleting the ones gone.
+//
+// The root node of the account trie, whose presence makes the database a path
+// scheme one, is only written by the cutover. From then on the migrated state
+// tracks the persisted one atomically, and is brought up to the chain head at
+// shutdown, so that the node can be restarted with the path scheme. The hash
+// scheme nodes are left in place.
+type StateMigration struct {
+	bc *BlockChain
+
+	lock sync.Mutex // Serializes the migration steps and the cutover
+}
+
+func newStateMigration(bc *BlockChain, enabled bool) *StateMigration {
+	if !enabled {
+		return nil
+	}
+	if bc.triedb.Scheme() != rawdb.HashScheme {
+		log.Warn("State migration requires the hash scheme, disabling")
+		return nil
+	}
+	return &StateMigration{bc: bc}
+}
+
+// StateMigration returns the state migration, or nil if not enabled.
+func (bc *BlockChain) StateMigration() *StateMigration {
+	return bc.stateMigration
+}
+
+// stateMigrationLoop backfills the migrated state, and periodically catches it
+// up with the state persisted by the hash scheme.
+func (bc *BlockChain) stateMigrationLoop() {
+	defer bc.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-bc.quit
+		cancel()
+	}()
+	ticker := time.NewTicker(stateMigrationInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := bc.stateMigration.step(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("Failed to migrate state", "err", err)
+			}
+			done = true
+		}
+		if !done {
+			select {
+			case <-bc.quit:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// Status returns the progress of the migration.
+func (m *StateMigration) Status() (*StateMigrationStatus, error) {
+	progress, err := readStateMigrationProgress(m.bc.db)
+	if err != nil {
+		return nil, err
+	}
+	status := &StateMigrationStatus{Head: m.bc.CurrentBlock().Number.Uint64()}
+	if progress == nil {
+		return status, nil
+	}
+	status.Number, status.Root = progress.Number, progress.Root
+	status.Backfilled, status.CutOver = progress.Backfilled, progress.CutOver
+	status.Accounts, status.Nodes = progress.Accounts, progress.Nodes
+
+	// The accounts are backfilled in the order of their hashes, spread uniformly
+	switch {
+	case progress.Backfilled:
+		status.Progress = 1
+	case len(progress.Cursor) >= 8:
+		status.Progress = float64(binary.BigEndian.Uint64(progress.Cursor)) / math.MaxUint64
+	}
+	return status, nil
+}
+
+// persistedHead returns the header of the latest block whose state was written
+// to disk by the hash scheme, nil if none is known.
+func (m *StateMigration) persistedHead() (*types.Header, error) {
+	head := m.bc.CurrentBlock()
+	if rawdb.HasLegacyTrieNode(m.bc.db, head.Root) {
+		return head, nil
+	}
+	if !m.bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	number := m.bc.lastWrite
+	m.bc.chainmu.Unlock()
+
+	header := m.bc.GetHeaderByNumber(number)
+	if header == nil || !rawdb.HasLegacyTrieNode(m.bc.db, header.Root) {
+		return nil, nil
+	}
+	return header, nil
+}
+
+// step runs a step of the migration: starts it, backfills a batch of accounts,
+// or catches the migrated state up with the persisted one. It returns whether
+// the migration is idle until more state is persisted.
+func (m *StateMigration) step(ctx context.Context) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	progress, err := readStateMigrationProgress(m.bc.db)
+	if err != nil {
+		return false, err
+	}
+	head, err := m.persistedHead()
+	if err != nil {
+		return false, err
+	}
+	if progress == nil {
+		if head == nil {
+			return true, nil
+		}
+		progress = &stateMigrationProgress{Root: head.Root, Number: head.Number.Uint64()}
+		writeStateMigrationProgress(m.bc.db, progress)
+		log.Info("Started state migration to the path scheme", "number", progress.Number, "root", progress.Root)
+	}
+	if !progress.Backfilled {
+		return false, m.backfill(ctx, progress)
+	}
+	return true, m.catchUp(ctx, progress, head)
+}
+
+// backfill writes the path scheme nodes of a batch of accounts of the state
+// being backfilled, along with their storage tries.
+func (m *StateMigration) backfill(ctx context.Context, progress *stateMigrationProgress) error {
+	tr, err := trie.New(trie.StateTrieID(progress.Root), m.bc.triedb)
+	if err != nil {
+		return err
+	}
+	it, err := tr.NodeIterator(progress.Cursor)
+	if err != nil {
+		return err
+	}
+	var (
+		w        = newStateMigrationWriter(m.bc.db, false)
+		accounts uint64
+		complete = true
+	)
+	for it.Next(true) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !it.Leaf() {
+			if it.Hash() != (common.Hash{}) {
+				w.write(common.Hash{}, it.Path(), it.NodeBlob())
+			}
+			if err := w.maybeFlush(); err != nil {
+				return err
+			}
+			continue
+		}
+		key := it.LeafKey()
+		if len(progress.Cursor) > 0 && string(key) == string(progress.Cursor) {
+			continue // backfilled by the previous batch
+		}
+		if accounts == stateMigrationBatch {
+			complete = false
+			break
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		owner := common.BytesToHash(key)
+		oldID := trie.StorageTrieID(progress.Root, owner, types.EmptyRootHash)
+		newID := trie.StorageTrieID(progress.Root, owner, account.Root)
+		if err := m.migrateTrie(ctx, w, oldID, newID, nil, nil); err != nil {
+			return err
+		}
+		progress.Cursor = common.CopyBytes(key)
+		accounts++
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if complete {
+		progress.Backfilled, progress.Cursor = true, nil
+	}
+	progress.Accounts += accounts
+	progress.Nodes += w.nodes
+	writeStateMigrationProgress(w.batch, progress)
+	if err := w.flush(); err != nil {
+		return err
+	}
+	stateMigrationAccountsMeter.Mark(int64(accounts))
+	if complete {
+		stateMigrationBlockGauge.Update(int64(progress.Number))
+		log.Info("Backfilled migrated state", "number", progress.Number, "root", progress.Root, "accounts", progress.Accounts, "nodes", progress.Nodes)
+	}
+	return nil
+}
+
+// catchUp brings the migrated state up to the given persisted one, resuming the
+// catch-up interrupted first if any. Once cut over, the migrated state is moved
+// atomically, along with the root node of the account trie.
+func (m *StateMigration) catchUp(ctx context.Context, progress *stateMigrationProgress, head *types.Header) error {
+	if progress.Target == (common.Hash{}) {
+		if head == nil || head.Number.Uint64() <= progress.Number || head.Root == progress.Root {
+			return nil
+		}
+		progress.Target, progress.TargetNumber = head.Root, head.Number.Uint64()
+		writeStateMigrationProgress(m.bc.db, progress)
+	}
+	oldTr, err := trie.New(trie.StateTrieID(progress.Root), m.bc.triedb)
+	if err != nil {
+		return err
+	}
+	newTr, err := trie.New(trie.StateTrieID(progress.Target), m.bc.triedb)
+	if err != nil {
+		return err
+	}
+	w := newStateMigrationWriter(m.bc.db, progress.CutOver)
+
+	// Migrate the storage tries of the accounts changed, and drop the ones of
+	// the accounts deleted
+	onLeaf := func(key []byte, blob []byte) error {
+		var account, prev types.StateAccount
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return err
+		}
+		prev.Root = types.EmptyRootHash
+		if blob, err := oldTr.Get(key); err != nil {
+			return err
+		} else if len(blob) > 0 {
+			if err := rlp.DecodeBytes(blob, &prev); err != nil {
+				return err
+			}
+		}
+		if prev.Root == account.Root {
+			return nil
+		}
+		owner := common.BytesToHash(key)
+		oldID := trie.StorageTrieID(progress.Root, owner, prev.Root)
+		newID := trie.StorageTrieID(progress.Target, owner, account.Root)
+		return m.migrateTrie(ctx, w, oldID, newID, nil, nil)
+	}
+	onDelete := func(key []byte) error {
+		if blob, err := newTr.Get(key); err != nil || len(blob) > 0 {
+			return err
+		}
+		return w.deleteStorage(common.BytesToHash(key))
+	}
+	if err := m.migrateTrie(ctx, w, trie.StateTrieID(progress.Root), trie.StateTrieID(progress.Target), onLeaf, onDelete); err != nil {
+		return err
+	}
+	if progress.CutOver {
+		rawdb.WriteAccountTrieNode(w.batch, nil, rawdb.ReadLegacyTrieNode(m.bc.db, progress.Target))
+	}
+	progress.Root, progress.Number = progress.Target, progress.TargetNumber
+	progress.Target, progress.TargetNumber = common.Hash{}, 0
+	progress.Nodes += w.nodes
+	writeStateMigrationProgress(w.batch, progress)
+	if err := w.flush(); err != nil {
+		return err
+	}
+	stateMigrationBlockGauge.Update(int64(progress.Number))
+	log.Info("Caught up migrated state", "number", progress.Number, "root", progress.Root, "nodes", w.nodes)
+	return nil
+}
+
+// migrateTrie writes the path scheme nodes of the trie in the new version not
+// present in the old one, and deletes the nodes of the old version gone. The
+// leaves changed or added are reported to onLeaf, and the leaves changed or
+// deleted to onDelete.
+func (m *StateMigration) migrateTrie(ctx context.Context, w *stateMigrationWriter, oldID, newID *trie.ID, onLeaf func(key, blob []byte) error, onDelete func(key []byte) error) error {
+	oldTr, err := trie.New(oldID, m.bc.triedb)
+	if err != nil {
+		return err
+	}
+	newTr, err := trie.New(newID, m.bc.triedb)
+	if err != nil {
+		return err
+	}
+	diff := func(a, b *trie.Trie, fn func(it trie.NodeIterator) error) error {
+		itA, err := a.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		itB, err := b.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		it, _ := trie.NewDifferenceIterator(itA, itB)
+		for it.Next(true) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(it); err != nil {
+				return err
+			}
+			if err := w.maybeFlush(); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	}
+	// Write the nodes added, tracking their paths to spare them from deletion
+	written := make(map[string]struct{})
+	err = diff(oldTr, newTr, func(it trie.NodeIterator) error {
+		if it.Leaf() {
+			if onLeaf != nil {
+				return onLeaf(it.LeafKey(), it.LeafBlob())
+			}
+			return nil
+		}
+		if it.Hash() != (common.Hash{}) {
+			written[string(it.Path())] = struct{}{}
+			w.write(newID.Owner, it.Path(), it.NodeBlob())
+		}
+		return nil
+	})
+	if err != nil || oldID.Root == types.EmptyRootHash {
+		return err
+	}
+	// Delete the nodes whose path is gone, or holds an embedded node now
+	return diff(newTr, oldTr, func(it trie.NodeIterator) error {
+		if it.Leaf() {
+			if onDelete != nil {
+				return onDelete(it.LeafKey())
+			}
+			return nil
+		}
+		if it.Hash() != (common.Hash{}) {
+			if _, ok := written[string(it.Path())]; !ok {
+				w.delete(oldID.Owner, it.Path())
+			}
+		}
+		return nil
+	})
+}
+
+// Cutover catches the migrated state up with the chain head, and makes it the
+// persistent state of the database by writing the root node of the account
+// trie. The node is to be restarted with the path scheme afterwards, meanwhile
+// the migrated state keeps following the hash scheme one.
+func (m *StateMigration) Cutover(ctx context.Context) (*StateMigrationStatus, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	progress, err := readStateMigrationProgress(m.bc.db)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil || !progress.Backfilled {
+		return nil, errStateMigrationNotBackfilled
+	}
+	if progress.CutOver {
+		return nil, errStateMigrationCutOver
+	}
+	// Persist the head state, holding the chain so that it stays the head
+	if !m.bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	defer m.bc.chainmu.Unlock()
+
+	if m.bc.commits != nil {
+		if err := m.bc.commits.Wait(); err != nil {
+			return nil, err
+		}
+	}
+	head := m.bc.CurrentBlock()
+	if err := m.bc.triedb.Commit(head.Root, false); err != nil {
+		return nil, err
+	}
+	if err := m.catchUp(ctx, progress, head); err != nil {
+		return nil, err
+	}
+	if progress.Root != head.Root {
+		return nil, fmt.Errorf("migrated state at block %d, head at block %d", progress.Number, head.Number)
+	}
+	progress.CutOver = true
+	batch := m.bc.db.NewBatch()
+	rawdb.WriteAccountTrieNode(batch, nil, rawdb.ReadLegacyTrieNode(m.bc.db, progress.Root))
+	writeStateMigrationProgress(batch, progress)
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Cut over state migration, restart with the path scheme", "number", progress.Number, "root", progress.Root)
+	return m.Status()
+}
+
+// flush brings the migrated state up to the given head at shutdown once cut
+// over, the head state having been persisted by the hash scheme.
+func (m *StateMigration) flush(head *types.Header) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	progress, err := readStateMigrationProgress(m.bc.db)
+	if err != nil || progress == nil || !progress.CutOver {
+		return
+	}
+	if !rawdb.HasLegacyTrieNode(m.bc.db, head.Root) {
+		log.Warn("Head state not persisted, migrated state left behind", "number", head.Number, "migrated", progress.Number)
+		return
+	}
+	if err := m.catchUp(context.Background(), progress, head); err != nil {
+		log.Error("Failed to catch up migrated state", "err", err)
+	}
+}
+
+// stateMigrationWriter writes the path scheme trie nodes of the migrated state,
+// either at once or in batches as they grow.
+type stateMigrationWriter struct {
+	db     ethdb.KeyValueStore
+	batch  ethdb.Batch
+	atomic bool   // Whether to write all the changes at once
+	nodes  uint64 // Number of nodes written
+}
+
+func newStateMigrationWriter(db ethdb.KeyValueStore, atomic bool) *stateMigrationWriter {
+	return &stateMigrationWriter{db: db, batch: db.NewBatch(), atomic: atomic}
+}
+
+// write stores a trie node, except the root node of the account trie which is
+// only written by the cutover.
+func (w *stateMigrationWriter) write(owner common.Hash, path []byte, blob []byte) {
+	if owner == (common.Hash{}) {
+		if len(path) == 0 {
+			return
+		}
+		rawdb.WriteAccountTrieNode(w.batch, path, blob)
+	} else {
+		rawdb.WriteStorageTrieNode(w.batch, owner, path, blob)
+	}
+	w.nodes++
+	stateMigrationNodesMeter.Mark(1)
+}
+
+func (w *stateMigrationWriter) delete(owner common.Hash, path []byte) {
+	if owner == (common.Hash{}) {
+		if len(path) > 0 {
+			rawdb.DeleteAccountTrieNode(w.batch, path)
+		}
+	} else {
+		rawdb.DeleteStorageTrieNode(w.batch, owner, path)
+	}
+}
+
+// deleteStorage deletes all the storage trie nodes of the given account.
+func (w *stateMigrationWriter) deleteStorage(owner common.Hash) error {
+	it := rawdb.IterateStorageTrieNodes(w.db, owner)
+	defer it.Release()
+
+	for it.Next() {
+		if err := w.batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		if err := w.maybeFlush(); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (w *stateMigrationWriter) maybeFlush() error {
+	if w.atomic || w.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	return w.flush()
+}
+
+func (w *stateMigrationWriter) flush() error {
+	if err := w.batch.Write(); err != nil {
+		return err
+	}
+	w.batch.Reset()
+	return nil
+}
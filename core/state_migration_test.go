@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the state migrated to the path scheme matches the hash scheme one
+// after the backfill, the catch-ups with the persisted states and the cutover.
+func TestStateMigration(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		storer  = common.HexToAddress("0xee")
+		storage = make(map[common.Hash]common.Hash)
+		engine  = ethash.NewFaker()
+		signer  = types.LatestSigner(params.TestChainConfig)
+		config  = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	for i := 0; i < 32; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+			storer: {Code: []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}, Storage: storage},
+		},
+	}
+	// Every block sets a slot, clears another and funds a new account
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		sstore := func(slot, value int64) {
+			data := append(common.BigToHash(big.NewInt(value)).Bytes(), common.BigToHash(big.NewInt(slot)).Bytes()...)
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), storer, nil, 100000, b.BaseFee(), data), signer, key)
+			b.AddTx(tx)
+		}
+		sstore(int64(100+i), int64(i+1))
+		sstore(int64(i), 0)
+
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.BigToAddress(big.NewInt(int64(0x1000+i))), big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	config.TrieDirtyDisabled = true
+	config.StateMigration = true
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	migration := chain.StateMigration()
+	migrate := func() {
+		t.Helper()
+		for {
+			done, err := migration.step(context.Background())
+			if err != nil {
+				t.Fatalf("failed to migrate state: %v", err)
+			}
+			if done {
+				return
+			}
+		}
+	}
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	migrate()
+	if _, err := migration.Cutover(context.Background()); err != nil {
+		t.Fatalf("failed to cut over: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	migrate()
+
+	status, err := migration.Status()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	head := chain.CurrentBlock()
+	if !status.Backfilled || !status.CutOver || status.Number != head.Number.Uint64() || status.Root != head.Root {
+		t.Fatalf("unexpected migration status: %+v", status)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("state scheme mismatch: have %q, want %q", scheme, rawdb.PathScheme)
+	}
+	// The path scheme nodes are exactly the nodes of the head state
+	want := make(map[string][]byte)
+	collect := func(prefix []byte, id *trie.ID) {
+		tr, err := trie.New(id, chain.triedb)
+		if err != nil {
+			t.Fatalf("failed to open trie: %v", err)
+		}
+		it := tr.MustNodeIterator(nil)
+		for it.Next(true) {
+			if !it.Leaf() && it.Hash() != (common.Hash{}) {
+				want[string(append(common.CopyBytes(prefix), it.Path()...))] = common.CopyBytes(it.NodeBlob())
+			}
+		}
+		if it.Error() != nil {
+			t.Fatalf("failed to iterate trie: %v", it.Error())
+		}
+	}
+	collect(rawdb.TrieNodeAccountPrefix, trie.StateTrieID(head.Root))
+	tr, _ := trie.New(trie.StateTrieID(head.Root), chain.triedb)
+	it := tr.MustNodeIterator(nil)
+	for it.Next(true) {
+		if it.Leaf() {
+			var account types.StateAccount
+			if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+				t.Fatalf("failed to decode account: %v", err)
+			}
+			owner := common.BytesToHash(it.LeafKey())
+			collect(append(common.CopyBytes(rawdb.TrieNodeStoragePrefix), owner.Bytes()...), trie.StorageTrieID(head.Root, owner, account.Root))
+		}
+	}
+	have := make(map[string][]byte)
+	for _, prefix := range [][]byte{rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			if !rawdb.IsLegacyTrieNode(it.Key(), it.Value()) {
+				have[string(it.Key())] = common.CopyBytes(it.Value())
+			}
+		}
+		it.Release()
+	}
+	if len(have) != len(want) {
+		t.Fatalf("path scheme node count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for key, blob := range want {
+		if !bytes.Equal(have[key], blob) {
+			t.Fatalf("path scheme node %x mismatch: have %x, want %x", key, have[key], blob)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	prewarmTxsMeter     = metrics.NewRegisteredMeter("chain/prewarm/txs", nil)
+	prewarmDroppedMeter = metrics.NewRegisteredMeter("chain/prewarm/dropped", nil)
+	prewarmSlotsMeter   = metrics.NewRegisteredMeter("chain/prewarm/slots", nil)
+)
+
+const (
+	// prewarmQueueSize is the number of transactions waiting to be prewarmed,
+	// beyond which the new ones are not prewarmed.
+	prewarmQueueSize = 1024
+
+	// prewarmTrackedContracts is the number of contracts whose slot accesses are
+	// tracked, the least recently accessed ones being dropped.
+	prewarmTrackedContracts = 4096
+
+	// prewarmTrackedSlots is the number of slots tracked per contract, beyond
+	// which the access counts are halved and the slots no longer accessed dropped.
+	prewarmTrackedSlots = 256
+)
+
+// StatePrewarmer speculatively resolves the state a transaction is likely to
+// access as it enters the pool, ahead of its execution by the sequencer: the
+// sender and recipient accounts, the code of the recipient, the entries of the
+// access list, and the storage slots of the recipient contract most accessed by
+// the recent blocks. The reads go through the head state, loading the clean
+// caches of the snapshot and the tries and the process-wide state cache that
+// the execution is served from.
+//
+// The slot access statistics are gathered from the accesses of the blocks
+// written, with the counts decaying as the tracked slots overflow.
+type StatePrewarmer struct {
+	bc    *BlockChain
+	slots int // Number of the most accessed slots prewarmed per contract
+
+	queue chan *types.Transaction
+
+	lock     sync.Mutex
+	accesses lru.BasicLRU[common.Address, map[common.Hash]uint64] // Access counts of the slots of the contracts
+
+	root    common.Hash    // Root of the head state the reads go through
+	statedb *state.StateDB // Head state the reads go through, reopened on new heads
+}
+
+func newStatePrewarmer(bc *BlockChain, slots int) *StatePrewarmer {
+	if slots <= 0 {
+		return nil
+	}
+	return &StatePrewarmer{
+		bc:       bc,
+		slots:    slots,
+		queue:    make(chan *types.Transaction, prewarmQueueSize),
+		accesses: lru.NewBasicLRU[common.Address, map[common.Hash]uint64](prewarmTrackedContracts),
+	}
+}
+
+// StatePrewarmer returns the prewarmer of the state accessed by the pending
+// transactions, or nil if the prewarming is disabled.
+func (bc *BlockChain) StatePrewarmer() *StatePrewarmer {
+	return bc.statePrewarmer
+}
+
+// recordSlotAccesses updates the slot access statistics with the accesses of
+// the given block, if the prewarming is enabled.
+func (bc *BlockChain) recordSlotAccesses(statedb *state.StateDB) {
+	if bc.statePrewarmer == nil {
+		return
+	}
+	bc.statePrewarmer.record(statedb.BlockAccessList())
+}
+
+func (p *StatePrewarmer) record(list types.AccessList) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, tuple := range list {
+		if len(tuple.StorageKeys) == 0 {
+			continue
+		}
+		counts, ok := p.accesses.Get(tuple.Address)
+		if !ok {
+			counts = make(map[common.Hash]uint64)
+			p.accesses.Add(tuple.Address, counts)
+		}
+		for _, slot := range tuple.StorageKeys {
+			counts[slot]++
+		}
+		for len(counts) > prewarmTrackedSlots {
+			for slot, count := range counts {
+				if count /= 2; count == 0 {
+					delete(counts, slot)
+				} else {
+					counts[slot] = count
+				}
+			}
+		}
+	}
+}
+
+// HotSlots returns the slots of the given contract most accessed by the recent
+// blocks, by decreasing number of accesses.
+func (p *StatePrewarmer) HotSlots(addr common.Address) []common.Hash {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	counts, ok := p.accesses.Peek(addr)
+	if !ok {
+		return nil
+	}
+	slots := make([]common.Hash, 0, len(counts))
+	for slot := range counts {
+		slots = append(slots, slot)
+	}
+	slices.SortFunc(slots, func(a, b common.Hash) int {
+		if counts[a] != counts[b] {
+			if counts[a] > counts[b] {
+				return -1
+			}
+			return 1
+		}
+		return a.Cmp(b)
+	})
+	return slots[:min(len(slots), p.slots)]
+}
+
+// Prewarm queues the given transaction for prewarming, unless the queue is
+// full. It never blocks.
+func (p *StatePrewarmer) Prewarm(tx *types.Transaction) {
+	select {
+	case p.queue <- tx:
+	default:
+		prewarmDroppedMeter.Mark(1)
+	}
+}
+
+// statePrewarmLoop prewarms the queued transactions until the chain is stopped.
+func (bc *BlockChain) statePrewarmLoop() {
+	defer bc.wg.Done()
+
+	for {
+		select {
+		case tx := <-bc.statePrewarmer.queue:
+			bc.statePrewarmer.prewarm(tx)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// prewarm resolves the state the given transaction is likely to access.
+func (p *StatePrewarmer) prewarm(tx *types.Transaction) {
+	head := p.bc.CurrentBlock()
+	if p.statedb == nil || p.root != head.Root {
+		statedb, err := p.bc.StateAt(head.Root)
+		if err != nil {
+			log.Debug("Head state unavailable for prewarming", "root", head.Root, "err", err)
+			return
+		}
+		p.root, p.statedb = head.Root, statedb
+	}
+	prewarmTxsMeter.Mark(1)
+
+	// Recovering the sender caches it in the transaction for the execution
+	if from, err := types.Sender(types.LatestSigner(p.bc.chainConfig), tx); err == nil {
+		p.statedb.GetBalance(from)
+	}
+	var slots int
+	for _, tuple := range tx.AccessList() {
+		p.statedb.GetBalance(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			p.statedb.GetState(tuple.Address, slot)
+		}
+		slots += len(tuple.StorageKeys)
+	}
+	if to := tx.To(); to != nil && len(p.statedb.GetCode(*to)) > 0 {
+		for _, slot := range p.HotSlots(*to) {
+			p.statedb.GetState(*to, slot)
+			slots++
+		}
+	}
+	prewarmSlotsMeter.Mark(int64(slots))
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the prewarmer ranks the slots of the contracts by the accesses of
+// the blocks written, and prewarms the transactions against the head state.
+func TestStatePrewarmer(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		storer = common.HexToAddress("0xee")
+		engine = ethash.NewFaker()
+		signer = types.LatestSigner(params.TestChainConfig)
+		config = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+			storer: {Code: []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}},
+		},
+	}
+	// Slot 1 is written by every block, slot 2 by every other, slot 3 once
+	store := func(b *BlockGen, slot uint64) {
+		data := append(common.Hash{0x01}.Bytes(), common.BigToHash(new(big.Int).SetUint64(slot)).Bytes()...)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), storer, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(tx)
+	}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *BlockGen) {
+		store(b, 1)
+		if i%2 == 0 {
+			store(b, 2)
+		}
+		if i == 0 {
+			store(b, 3)
+		}
+	})
+	config.PrewarmSlots = 2
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	prewarmer := chain.StatePrewarmer()
+	want := []common.Hash{common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2))}
+	if have := prewarmer.HotSlots(storer); !reflect.DeepEqual(have, want) {
+		t.Fatalf("hot slots mismatch: have %x, want %x", have, want)
+	}
+	if have := prewarmer.HotSlots(sender); have != nil {
+		t.Fatalf("unexpected hot slots of an account: %x", have)
+	}
+	// Prewarm a transaction synchronously, opening the head state
+	tx, _ := types.SignTx(types.NewTransaction(uint64(len(blocks)+5), storer, nil, 100000, big.NewInt(params.InitialBaseFee), nil), signer, key)
+	prewarmer.prewarm(tx)
+	if prewarmer.statedb == nil || prewarmer.root != chain.CurrentBlock().Root {
+		t.Fatalf("head state not opened for prewarming")
+	}
+	if prewarmer.statedb.GetBalance(sender).IsZero() {
+		t.Fatalf("sender balance not resolved")
+	}
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -29,6 +30,8 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -529,3 +532,89 @@ func TestProcessVerkle(t *testing.T) {
 		}
 	}
 }
+
+// TestSimulateBlobs tests that the messages carrying blobs are rejected by the
+// Arbitrum blocks, lacking a blob fee market, unless simulated as on L1.
+func TestSimulateBlobs(t *testing.T) {
+	var (
+		config   = *params.TestChainConfig
+		contract = common.Address{0xf1}
+		sender   = common.Address{0xf2}
+		blobHash = common.Hash{0x01, 0xaa}
+	)
+	config.ArbitrumChainParams = params.ArbitrumChainParams{EnableArbOS: true}
+
+	// Returns the first blob hash and the blob base fee
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.BLOBHASH), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.BLOBBASEFEE), byte(vm.PUSH1), 0x20, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x40, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+	apply := func(simulate bool, blobBaseFee *big.Int, blobFeeCap int64) (*ExecutionResult, *state.StateDB, error) {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, code)
+		statedb.SetBalance(sender, uint256.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+
+		blockCtx := vm.BlockContext{
+			CanTransfer:  CanTransfer,
+			Transfer:     Transfer,
+			GetHash:      func(uint64) common.Hash { return common.Hash{} },
+			BlockNumber:  big.NewInt(1),
+			Time:         1,
+			Difficulty:   common.Big0,
+			Random:       &common.Hash{},
+			BaseFee:      big.NewInt(params.InitialBaseFee),
+			BlobBaseFee:  blobBaseFee,
+			GasLimit:     params.GenesisGasLimit,
+			ArbOSVersion: params.ArbosVersion_20,
+		}
+		msg := &Message{
+			TxRunMode:         MessageEthcallMode,
+			To:                &contract,
+			From:              sender,
+			Value:             common.Big0,
+			GasLimit:          100000,
+			GasPrice:          common.Big0,
+			GasFeeCap:         common.Big0,
+			GasTipCap:         common.Big0,
+			BlobGasFeeCap:     big.NewInt(blobFeeCap),
+			BlobHashes:        []common.Hash{blobHash},
+			SkipAccountChecks: true,
+		}
+		evm := vm.NewEVM(blockCtx, NewEVMTxContext(msg), statedb, &config, vm.Config{NoBaseFee: true, SimulateBlobs: simulate})
+		res, err := ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64))
+		return res, statedb, err
+	}
+	// Rejected unless simulated
+	if _, _, err := apply(false, nil, 10); !errors.Is(err, ErrBlobsUnsupported) {
+		t.Fatalf("unsimulated blobs: have error %v, want %v", err, ErrBlobsUnsupported)
+	}
+	// Simulated against an idle blob fee market
+	res, statedb, err := apply(true, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Err != nil {
+		t.Fatalf("simulated call failed: %v", res.Err)
+	}
+	if have := common.BytesToHash(res.ReturnData[:32]); have != blobHash {
+		t.Errorf("blob hash mismatch: have %x, want %x", have, blobHash)
+	}
+	if have := new(big.Int).SetBytes(res.ReturnData[32:]); have.Cmp(big.NewInt(params.BlobTxMinBlobGasprice)) != 0 {
+		t.Errorf("blob base fee mismatch: have %v, want %v", have, params.BlobTxMinBlobGasprice)
+	}
+	want := uint256.NewInt(params.Ether - params.BlobTxBlobGasPerBlob*params.BlobTxMinBlobGasprice)
+	if have := statedb.GetBalance(sender); have.Cmp(want) != 0 {
+		t.Errorf("sender balance mismatch: have %v, want %v", have, want)
+	}
+	// Simulated against an overridden blob base fee
+	if res, _, err = apply(true, big.NewInt(7), 10); err != nil {
+		t.Fatal(err)
+	}
+	if have := new(big.Int).SetBytes(res.ReturnData[32:]); have.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("overridden blob base fee mismatch: have %v, want 7", have)
+	}
+	if _, _, err := apply(true, big.NewInt(7), 5); !errors.Is(err, ErrBlobFeeCapTooLow) {
+		t.Fatalf("low blob fee cap: have error %v, want %v", err, ErrBlobFeeCapTooLow)
+	}
+}
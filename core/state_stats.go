@@ -0,0 +1,234 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/bits"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// stateStatsTopContracts is the number of contracts with the most storage slots
+// reported by the state statistics.
+const stateStatsTopContracts = 32
+
+var (
+	errStateStatsRunning = errors.New("state statistics walk already running")
+	errStateStatsNoSnap  = errors.New("state statistics require the snapshot")
+)
+
+// ContractSlots is the number of storage slots of a contract.
+type ContractSlots struct {
+	Hash    common.Hash     // Hash of the address of the contract
+	Address *common.Address // Address of the contract, nil if the preimage is unknown
+	Slots   uint64          // Number of storage slots
+}
+
+// StateStatsResult describes the whole state, as of the snapshot it was walked.
+type StateStatsResult struct {
+	Root            common.Hash       // Root of the persisted snapshot when the walk started
+	Accounts        uint64            // Number of accounts
+	Contracts       uint64            // Number of accounts with code
+	StylusContracts uint64            // Number of accounts with a Stylus program
+	Slots           uint64            // Number of storage slots
+	Codes           uint64            // Number of distinct codes
+	CodeBytes       uint64            // Total size of the distinct codes
+	StylusCodes     uint64            // Number of distinct Stylus programs
+	StylusCodeBytes uint64            // Total size of the distinct Stylus programs
+	CodeSizes       map[uint64]uint64 // Number of distinct codes by power of two exclusive upper bound of their size
+	TopContracts    []ContractSlots   // Contracts with the most storage slots, by decreasing count
+	Time            time.Time         // Time the walk completed at
+	Elapsed         time.Duration     // Duration of the walk
+}
+
+// StateStats periodically walks the flat state persisted by the snapshot and
+// maintains state-wide statistics, for capacity planning. The walk goes through
+// the database rather than a snapshot layer, so it never goes stale but may
+// observe the changes flattened into the disk layer while it's running.
+type StateStats struct {
+	bc *BlockChain
+
+	walk sync.Mutex // Serializes the walks
+
+	lock sync.RWMutex
+	last *StateStatsResult // Statistics of the last complete walk, nil if none
+}
+
+func newStateStats(bc *BlockChain, interval time.Duration) *StateStats {
+	if interval <= 0 {
+		return nil
+	}
+	return &StateStats{bc: bc}
+}
+
+// StateStats returns the state statistics service, or nil if it's disabled.
+func (bc *BlockChain) StateStats() *StateStats {
+	return bc.stateStats
+}
+
+// stateStatsLoop walks the state at the given interval until the chain is stopped.
+func (bc *BlockChain) stateStatsLoop(interval time.Duration) {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := bc.stateStats.Walk(); err != nil && !errors.Is(err, errChainStopped) {
+				log.Warn("Failed to gather state statistics", "err", err)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// Last returns the statistics of the last complete walk, or nil if none has
+// completed yet.
+func (s *StateStats) Last() *StateStatsResult {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.last
+}
+
+// Walk walks the whole flat state and updates the statistics.
+func (s *StateStats) Walk() (*StateStatsResult, error) {
+	if !s.walk.TryLock() {
+		return nil, errStateStatsRunning
+	}
+	defer s.walk.Unlock()
+
+	db := s.bc.db
+	root := rawdb.ReadSnapshotRoot(db)
+	if root == (common.Hash{}) {
+		return nil, errStateStatsNoSnap
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		codes  = make(map[common.Hash]struct{})
+		result = &StateStatsResult{Root: root, CodeSizes: make(map[uint64]uint64)}
+	)
+	log.Info("State statistics walk started", "root", root)
+
+	it := rawdb.NewKeyLengthIterator(db.NewIterator(rawdb.SnapshotAccountPrefix, nil), len(rawdb.SnapshotAccountPrefix)+common.HashLength)
+	defer it.Release()
+
+	for it.Next() {
+		select {
+		case <-s.bc.quit:
+			return nil, errChainStopped
+		default:
+		}
+		account, err := types.FullAccount(it.Value())
+		if err != nil {
+			return nil, err
+		}
+		hash := common.BytesToHash(it.Key()[len(rawdb.SnapshotAccountPrefix):])
+		result.Accounts++
+
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+			result.Contracts++
+			code := rawdb.ReadCode(db, codeHash)
+			stylus := state.IsStylusProgram(code)
+			if stylus {
+				result.StylusContracts++
+			}
+			if _, ok := codes[codeHash]; !ok {
+				codes[codeHash] = struct{}{}
+				result.Codes++
+				result.CodeBytes += uint64(len(code))
+				result.CodeSizes[uint64(1)<<bits.Len64(uint64(len(code)))]++
+				if stylus {
+					result.StylusCodes++
+					result.StylusCodeBytes += uint64(len(code))
+				}
+			}
+		}
+		if account.Root != types.EmptyRootHash {
+			slots, err := s.countSlots(hash)
+			if err != nil {
+				return nil, err
+			}
+			result.Slots += slots
+			result.addContract(db, hash, slots)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("State statistics walk in progress", "at", hash, "accounts", result.Accounts,
+				"slots", result.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	result.Time = time.Now()
+	result.Elapsed = time.Since(start)
+	log.Info("State statistics walk complete", "accounts", result.Accounts, "contracts", result.Contracts,
+		"slots", result.Slots, "codes", result.Codes, "elapsed", common.PrettyDuration(result.Elapsed))
+
+	s.lock.Lock()
+	s.last = result
+	s.lock.Unlock()
+	return result, nil
+}
+
+// countSlots counts the storage slots of the given account in the snapshot.
+func (s *StateStats) countSlots(hash common.Hash) (uint64, error) {
+	it := rawdb.IterateStorageSnapshots(s.bc.db, hash)
+	defer it.Release()
+
+	var slots uint64
+	for it.Next() {
+		slots++
+	}
+	return slots, it.Error()
+}
+
+// addContract retains the given contract if it's among the ones with the most
+// storage slots so far.
+func (r *StateStatsResult) addContract(db ethdb.KeyValueReader, hash common.Hash, slots uint64) {
+	if len(r.TopContracts) == stateStatsTopContracts && r.TopContracts[len(r.TopContracts)-1].Slots >= slots {
+		return
+	}
+	contract := ContractSlots{Hash: hash, Slots: slots}
+	if preimage := rawdb.ReadPreimage(db, hash); len(preimage) == common.AddressLength {
+		addr := common.BytesToAddress(preimage)
+		contract.Address = &addr
+	}
+	pos, _ := slices.BinarySearchFunc(r.TopContracts, slots, func(c ContractSlots, slots uint64) int {
+		if c.Slots > slots {
+			return -1
+		}
+		return 1
+	})
+	r.TopContracts = slices.Insert(r.TopContracts, pos, contract)
+	if len(r.TopContracts) > stateStatsTopContracts {
+		r.TopContracts = r.TopContracts[:stateStatsTopContracts]
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the state statistics account for the accounts, contracts, codes and
+// slots of the flat state.
+func TestStateStats(t *testing.T) {
+	var (
+		evmCode    = []byte{byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.STOP)}
+		stylusCode = append(append([]byte{}, state.StylusDiscriminant...), make([]byte, 61)...)
+		large      = common.HexToAddress("0xb1")
+		small      = common.HexToAddress("0xb2")
+		shared     = common.HexToAddress("0xb3")
+		program    = common.HexToAddress("0xb4")
+		config     = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	storage := func(n int) map[common.Hash]common.Hash {
+		slots := make(map[common.Hash]common.Hash)
+		for i := 1; i <= n; i++ {
+			slots[common.BigToHash(big.NewInt(int64(i)))] = common.Hash{0x01}
+		}
+		return slots
+	}
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+		Alloc: types.GenesisAlloc{
+			common.HexToAddress("0xa1"): {Balance: big.NewInt(1)},
+			large:                       {Code: evmCode, Storage: storage(5)},
+			small:                       {Code: evmCode, Storage: storage(2)},
+			shared:                      {Code: evmCode},
+			program:                     {Code: stylusCode, Storage: storage(1)},
+		},
+	}
+	config.SnapshotWait = true
+	config.StateStatsInterval = time.Hour
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	stats := chain.StateStats()
+	if stats.Last() != nil {
+		t.Fatalf("statistics available before any walk")
+	}
+	result, err := stats.Walk()
+	if err != nil {
+		t.Fatalf("failed to walk the state: %v", err)
+	}
+	if result.Root != chain.CurrentBlock().Root {
+		t.Errorf("root mismatch: have %x, want %x", result.Root, chain.CurrentBlock().Root)
+	}
+	if result.Accounts != 5 || result.Contracts != 4 || result.StylusContracts != 1 || result.Slots != 8 {
+		t.Errorf("unexpected counts: %d accounts, %d contracts, %d stylus contracts, %d slots",
+			result.Accounts, result.Contracts, result.StylusContracts, result.Slots)
+	}
+	if result.Codes != 2 || result.CodeBytes != uint64(len(evmCode)+len(stylusCode)) {
+		t.Errorf("unexpected codes: %d codes of %d bytes", result.Codes, result.CodeBytes)
+	}
+	if result.StylusCodes != 1 || result.StylusCodeBytes != uint64(len(stylusCode)) {
+		t.Errorf("unexpected stylus codes: %d codes of %d bytes", result.StylusCodes, result.StylusCodeBytes)
+	}
+	if result.CodeSizes[8] != 1 || result.CodeSizes[128] != 1 {
+		t.Errorf("unexpected code size histogram: %v", result.CodeSizes)
+	}
+	want := []common.Hash{crypto.Keccak256Hash(large.Bytes()), crypto.Keccak256Hash(small.Bytes()), crypto.Keccak256Hash(program.Bytes())}
+	if len(result.TopContracts) != len(want) {
+		t.Fatalf("unexpected top contracts: %v", result.TopContracts)
+	}
+	for i, hash := range want {
+		if result.TopContracts[i].Hash != hash {
+			t.Errorf("top contract %d mismatch: have %x, want %x", i, result.TopContracts[i].Hash, hash)
+		}
+	}
+	if stats.Last() != result {
+		t.Errorf("last statistics not retained")
+	}
+}
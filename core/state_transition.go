@@ -284,7 +284,7 @@ func (st *StateTransition) buyGas() error {
 			balanceCheck.Add(balanceCheck, blobBalanceCheck)
 			// Pay for blobGasUsed * actual blob fee
 			blobFee := new(big.Int).SetUint64(blobGas)
-			blobFee.Mul(blobFee, st.evm.Context.BlobBaseFee)
+			blobFee.Mul(blobFee, st.evm.BlobBaseFee())
 			mgval.Add(mgval, blobFee)
 		}
 	}
@@ -385,14 +385,17 @@ func (st *StateTransition) preCheck() error {
 	// Check that the user is paying at least the current blob fee
 	if st.evm.ChainConfig().IsCancun(st.evm.Context.BlockNumber, st.evm.Context.Time, st.evm.Context.ArbOSVersion) {
 		if st.blobGasUsed() > 0 {
+			// Arbitrum: blocks without a blob fee market only process blobs in simulations
+			blobBaseFee := st.evm.BlobBaseFee()
+			if blobBaseFee == nil {
+				return fmt.Errorf("%w: address %v", ErrBlobsUnsupported, msg.From.Hex())
+			}
 			// Skip the checks if gas fields are zero and blobBaseFee was explicitly disabled (eth_call)
 			skipCheck := st.evm.Config.NoBaseFee && msg.BlobGasFeeCap.BitLen() == 0
 			if !skipCheck {
-				// This will panic if blobBaseFee is nil, but blobBaseFee presence
-				// is verified as part of header validation.
-				if msg.BlobGasFeeCap.Cmp(st.evm.Context.BlobBaseFee) < 0 {
+				if msg.BlobGasFeeCap.Cmp(blobBaseFee) < 0 {
 					return fmt.Errorf("%w: address %v blobGasFeeCap: %v, blobBaseFee: %v", ErrBlobFeeCapTooLow,
-						msg.From.Hex(), msg.BlobGasFeeCap, st.evm.Context.BlobBaseFee)
+						msg.From.Hex(), msg.BlobGasFeeCap, blobBaseFee)
 				}
 			}
 		}
@@ -463,10 +466,13 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	st.gasRemaining -= gas
 
 	tipAmount := big.NewInt(0)
+	gasBeforeCharging := st.gasRemaining
 	tipReceipient, err := st.evm.ProcessingHook.GasChargingHook(&st.gasRemaining)
 	if err != nil {
 		return nil, err
 	}
+	// Arbitrum: meter the L1 data, along with the pricing recorded while charging it
+	st.meterL1Data(gasBeforeCharging - st.gasRemaining)
 
 	// Check clause 6
 	value, overflow := uint256.FromBig(msg.Value)
@@ -483,9 +489,9 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}
 
 	// Execute the preparatory steps for state transition which includes:
-	// - prepare accessList(post-berlin)
+	// - prepare accessList(post-berlin), along with the accounts and slots warm by the chain config
 	// - reset transient storage(eip 1153)
-	st.state.Prepare(rules, msg.From, st.evm.Context.Coinbase, msg.To, vm.ActivePrecompiles(rules), msg.AccessList)
+	st.state.Prepare(rules, msg.From, st.evm.Context.Coinbase, msg.To, vm.ActivePrecompiles(rules), vm.WarmAccessList(st.evm.ChainConfig(), rules, msg.AccessList))
 
 	var deployedContract *common.Address
 
@@ -556,14 +562,16 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 func (st *StateTransition) refundGas(refundQuotient uint64) uint64 {
 	st.gasRemaining += st.evm.ProcessingHook.ForceRefundGas()
 	nonrefundable := st.evm.ProcessingHook.NonrefundableGas()
-	var refund uint64
+	var limit uint64
 	if nonrefundable < st.gasUsed() {
-		// Apply refund counter, capped to a refund quotient
-		refund := (st.gasUsed() - nonrefundable) / refundQuotient
-		if refund > st.state.GetRefund() {
-			refund = st.state.GetRefund()
-		}
-		st.gasRemaining += refund
+		limit = (st.gasUsed() - nonrefundable) / refundQuotient
+	}
+	// Apply refund counter, capped to a refund quotient
+	refund := min(limit, st.state.GetRefund())
+
+	// Arbitrum: record how the refund counter was applied
+	if tracer := st.evm.Config.Tracer; tracer != nil && tracer.OnRefund != nil {
+		tracer.OnRefund(st.state.GetRefund(), refundQuotient, nonrefundable, limit, refund)
 	}
 
 	if st.evm.Config.Tracer != nil && st.evm.Config.Tracer.OnGasChange != nil && refund > 0 {
@@ -17,6 +17,7 @@
 package tracing
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,6 +180,38 @@ type (
 	CaptureArbitrumStorageSetHook = func(key, value common.Hash, depth int, before bool)
 
 	CaptureStylusHostioHook = func(name string, args, outs []byte, startInk, endInk uint64)
+
+	// CaptureStylusModuleHook is called when the asm of a Stylus module is loaded,
+	// or when a module is activated.
+	CaptureStylusModuleHook = func(moduleHash common.Hash, activated bool)
+
+	// CaptureStylusCacheHook is called when a Stylus module is added to or evicted
+	// from the cache of the Stylus runtime.
+	CaptureStylusCacheHook = func(moduleHash common.Hash, version uint16, tag uint32, debug bool, cached bool)
+
+	// WasmMemoryGrowHook is called when a Stylus program grows its memory by the
+	// given number of pages, with the gas cost charged for them.
+	WasmMemoryGrowHook = func(addr common.Address, pages uint16, cost uint64)
+
+	// RefundChangeHook is called when the refund counter changes, including when
+	// the changes made by a reverted call are rolled back.
+	RefundChangeHook = func(prev, new uint64, reverted bool)
+
+	// RefundHook is called once the refund counter is applied at the end of a
+	// transaction, with the counter, the quotient of the gas used it is capped
+	// to, the gas used excluded from the cap, the resulting cap, and the gas
+	// refunded.
+	RefundHook = func(counter, quotient, nonrefundable, limit, refund uint64)
+
+	// L1DataUsageHook is called once the L1 data of a transaction is metered,
+	// after its poster cost is charged.
+	L1DataUsageHook = func(usage *types.L1DataUsage)
+
+	// SelfDestructHook is called once a contract selfdestructs, with the balance
+	// credited to the beneficiary and the balance burnt, the contract being its
+	// own beneficiary. Unless destructed, the contract was only drained, as of
+	// EIP-6780 for the contracts not created by the transaction.
+	SelfDestructHook = func(contract, beneficiary common.Address, transferred, burnt *big.Int, destructed bool)
 )
 
 type Hooks struct {
@@ -213,6 +246,18 @@ type Hooks struct {
 	CaptureArbitrumStorageSet CaptureArbitrumStorageSetHook
 	// Stylus: capture hostio invocation
 	CaptureStylusHostio CaptureStylusHostioHook
+	// Stylus: capture module loads and activations, and runtime cache changes
+	CaptureStylusModule CaptureStylusModuleHook
+	CaptureStylusCache  CaptureStylusCacheHook
+	// Stylus: capture the memory growths of programs
+	OnWasmMemoryGrow WasmMemoryGrowHook
+	// Arbitrum: capture the refund counter changes and the refund applied
+	OnRefundChange RefundChangeHook
+	OnRefund       RefundHook
+	// Arbitrum: capture the metering of the L1 data
+	OnL1DataUsage L1DataUsageHook
+	// Arbitrum: capture where the balance of the selfdestructed contracts went
+	OnSelfDestruct SelfDestructHook
 }
 
 // BalanceChangeReason is used to indicate the reason for a balance change, useful
@@ -264,6 +309,44 @@ const (
 	BalanceDecreaseSelfdestructBurn BalanceChangeReason = 14
 )
 
+// String returns the name of the balance change reason.
+func (r BalanceChangeReason) String() string {
+	switch r {
+	case BalanceChangeUnspecified:
+		return "Unspecified"
+	case BalanceIncreaseRewardMineUncle:
+		return "RewardMineUncle"
+	case BalanceIncreaseRewardMineBlock:
+		return "RewardMineBlock"
+	case BalanceIncreaseWithdrawal:
+		return "Withdrawal"
+	case BalanceIncreaseGenesisBalance:
+		return "GenesisBalance"
+	case BalanceIncreaseRewardTransactionFee:
+		return "RewardTransactionFee"
+	case BalanceDecreaseGasBuy:
+		return "GasBuy"
+	case BalanceIncreaseGasReturn:
+		return "GasReturn"
+	case BalanceIncreaseDaoContract:
+		return "DaoContract"
+	case BalanceDecreaseDaoAccount:
+		return "DaoAccount"
+	case BalanceChangeTransfer:
+		return "Transfer"
+	case BalanceChangeTouchAccount:
+		return "TouchAccount"
+	case BalanceIncreaseSelfdestruct:
+		return "IncreaseSelfdestruct"
+	case BalanceDecreaseSelfdestruct:
+		return "DecreaseSelfdestruct"
+	case BalanceDecreaseSelfdestructBurn:
+		return "SelfdestructBurn"
+	default:
+		return fmt.Sprintf("BalanceChangeReason(%d)", r)
+	}
+}
+
 // GasChangeReason is used to indicate the reason for a gas change, useful
 // for tracing and reporting.
 //
@@ -17,6 +17,7 @@ var _ = (*receiptMarshaling)(nil)
 func (r Receipt) MarshalJSON() ([]byte, error) {
 	type Receipt struct {
 		GasUsedForL1      hexutil.Uint64 `json:"gasUsedForL1"`
+		L1Data            *L1DataUsage   `json:"l1Data,omitempty"`
 		Type              hexutil.Uint64 `json:"type,omitempty"`
 		PostState         hexutil.Bytes  `json:"root"`
 		Status            hexutil.Uint64 `json:"status"`
@@ -35,6 +36,7 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 	}
 	var enc Receipt
 	enc.GasUsedForL1 = hexutil.Uint64(r.GasUsedForL1)
+	enc.L1Data = r.L1Data
 	enc.Type = hexutil.Uint64(r.Type)
 	enc.PostState = r.PostState
 	enc.Status = hexutil.Uint64(r.Status)
@@ -57,6 +59,7 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 func (r *Receipt) UnmarshalJSON(input []byte) error {
 	type Receipt struct {
 		GasUsedForL1      *hexutil.Uint64 `json:"gasUsedForL1"`
+		L1Data            *L1DataUsage    `json:"l1Data,omitempty"`
 		Type              *hexutil.Uint64 `json:"type,omitempty"`
 		PostState         *hexutil.Bytes  `json:"root"`
 		Status            *hexutil.Uint64 `json:"status"`
@@ -80,6 +83,9 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 	if dec.GasUsedForL1 != nil {
 		r.GasUsedForL1 = uint64(*dec.GasUsedForL1)
 	}
+	if dec.L1Data != nil {
+		r.L1Data = dec.L1Data
+	}
 	if dec.Type != nil {
 		r.Type = uint8(*dec.Type)
 	}
@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+//go:generate go run github.com/fjl/gencodec -type L1DataUsage -field-override l1DataUsageMarshaling -out gen_l1data_json.go
+
+// L1DataUsage is the metering of the data a transaction is posted to L1 with,
+// and of the components of its L1 fee, for alternative data availability
+// pricing models to be evaluated against. The sizes are measured by the node,
+// the pricing is recorded by ArbOS as it charges the poster cost.
+type L1DataUsage struct {
+	CalldataSize     uint64   `json:"calldataSize"`     // Size of the serialized transaction
+	CompressedSize   uint64   `json:"compressedSize"`   // Estimated size once compressed, 0 if not estimated
+	CompressionLevel uint64   `json:"compressionLevel"` // Brotli level of the estimate
+	Units            uint64   `json:"units"`            // L1 gas units the data is priced at
+	PricePerUnit     *big.Int `json:"pricePerUnit"`     // L1 price per unit, in wei
+	PosterCost       *big.Int `json:"posterCost"`       // Cost of posting the data, in wei
+	GasForL1         uint64   `json:"gasForL1"`         // L2 gas charged to cover the poster cost
+}
+
+type l1DataUsageMarshaling struct {
+	CalldataSize     hexutil.Uint64
+	CompressedSize   hexutil.Uint64
+	CompressionLevel hexutil.Uint64
+	Units            hexutil.Uint64
+	PricePerUnit     *hexutil.Big
+	PosterCost       *hexutil.Big
+	GasForL1         hexutil.Uint64
+}
+
+// Merge overrides the components of the usage with those set in the given one.
+func (u *L1DataUsage) Merge(other *L1DataUsage) {
+	if other.CalldataSize != 0 {
+		u.CalldataSize = other.CalldataSize
+	}
+	if other.CompressedSize != 0 {
+		u.CompressedSize, u.CompressionLevel = other.CompressedSize, other.CompressionLevel
+	}
+	if other.Units != 0 {
+		u.Units = other.Units
+	}
+	if other.PricePerUnit != nil {
+		u.PricePerUnit = new(big.Int).Set(other.PricePerUnit)
+	}
+	if other.PosterCost != nil {
+		u.PosterCost = new(big.Int).Set(other.PosterCost)
+	}
+	if other.GasForL1 != 0 {
+		u.GasForL1 = other.GasForL1
+	}
+}
+
+// Copy returns a deep copy of the usage.
+func (u *L1DataUsage) Copy() *L1DataUsage {
+	cpy := new(L1DataUsage)
+	cpy.Merge(u)
+	return cpy
+}
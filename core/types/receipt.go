@@ -52,7 +52,8 @@ const (
 // Receipt represents the results of a transaction.
 type Receipt struct {
 	// Arbitrum Implementation fields
-	GasUsedForL1 uint64 `json:"gasUsedForL1"`
+	GasUsedForL1 uint64       `json:"gasUsedForL1"`
+	L1Data       *L1DataUsage `json:"l1Data,omitempty"` // Metering of the L1 data, only set on execution
 
 	// Consensus fields: These fields are defined by the Yellow Paper
 	Type              uint8  `json:"type,omitempty"`
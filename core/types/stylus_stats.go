@@ -0,0 +1,32 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+// StylusStats is the summary of the Stylus executions of a block, for the
+// capacity monitoring of the Stylus engine.
+type StylusStats struct {
+	Calls        uint64 // Number of Stylus program calls
+	Modules      uint64 // Number of distinct modules executed
+	Ink          uint64 // Total ink used by the calls
+	PagesOpened  uint64 // Total number of wasm pages opened by the calls
+	MaxPagesEver uint64 // Largest number of wasm pages open at once in a transaction
+}
+
+// Empty returns whether the block ran no Stylus program.
+func (s *StylusStats) Empty() bool {
+	return s.Calls == 0 && s.Modules == 0 && s.Ink == 0 && s.PagesOpened == 0 && s.MaxPagesEver == 0
+}
@@ -176,6 +176,13 @@ func init() {
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	// Arbitrum: include the precompiles registered by the embedder
+	return appendPluginPrecompiles(activeForkPrecompiles(rules), rules)
+}
+
+// activeForkPrecompiles returns the precompile addresses of the fork enabled
+// with the current configuration.
+func activeForkPrecompiles(rules params.Rules) []common.Address {
 	switch {
 	case rules.IsStylus:
 		return PrecompiledAddressesArbOS30
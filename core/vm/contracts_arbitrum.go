@@ -16,7 +16,11 @@
 
 package vm
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
 
 var (
 	PrecompiledContractsArbitrum = make(map[common.Address]PrecompiledContract)
@@ -24,3 +28,20 @@ var (
 	PrecompiledContractsArbOS30  = make(map[common.Address]PrecompiledContract)
 	PrecompiledAddressesArbOS30  []common.Address
 )
+
+// WarmAccessList returns the access list of a transaction extended with the
+// accounts and storage slots the chain config declares warm under the given
+// rules, for them to be added to the access list of the transaction along with
+// the precompiles.
+func WarmAccessList(config *params.ChainConfig, rules params.Rules, list types.AccessList) types.AccessList {
+	warm := config.WarmAccessTuples(rules.ArbOSVersion)
+	if len(warm) == 0 {
+		return list
+	}
+	extended := make(types.AccessList, len(list), len(list)+len(warm))
+	copy(extended, list)
+	for _, tuple := range warm {
+		extended = append(extended, types.AccessTuple{Address: tuple.Address, StorageKeys: tuple.StorageKeys})
+	}
+	return extended
+}
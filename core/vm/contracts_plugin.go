@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errPluginCallContext is returned if a plugin precompile is run without the
+// context of a call, as only RunPrecompiledContract provides one.
+var errPluginCallContext = errors.New("plugin precompile run without call context")
+
+// PluginPrecompile is a precompiled contract registered by an embedder of the
+// EVM, rather than compiled into the precompile sets of the forks.
+type PluginPrecompile struct {
+	Address common.Address
+	Name    string
+
+	// IsActive reports whether the precompile is active under the given rules,
+	// the precompile being active from genesis if nil.
+	IsActive func(rules params.Rules) bool
+
+	// RequiredGas returns the gas charged for running the precompile with the
+	// given input, before it runs.
+	RequiredGas func(input []byte) uint64
+
+	// Run runs the precompile with the given input. The state accessed through
+	// the call is journaled, so that it's reverted along with the call.
+	Run func(call *PluginPrecompileCall, input []byte) ([]byte, error)
+}
+
+// pluginPrecompiles are the precompiles registered by the embedder, by address.
+var pluginPrecompiles = make(map[common.Address]*pluginPrecompile)
+
+// RegisterPrecompile registers the given precompile, to be run by the EVM at its
+// address under the rules it's active for. The precompiles of the forks take
+// precedence, so it can't replace any of them. It's not safe to register the
+// precompiles concurrently with the execution, which they are meant to be
+// registered ahead of, typically from an init function.
+func RegisterPrecompile(plugin PluginPrecompile) error {
+	if plugin.RequiredGas == nil || plugin.Run == nil {
+		return fmt.Errorf("precompile %v (%s) lacks a gas or run function", plugin.Address, plugin.Name)
+	}
+	if _, ok := pluginPrecompiles[plugin.Address]; ok {
+		return fmt.Errorf("precompile %v (%s) already registered", plugin.Address, plugin.Name)
+	}
+	for _, builtin := range []map[common.Address]PrecompiledContract{
+		PrecompiledContractsPrague, PrecompiledContractsArbitrum, PrecompiledContractsArbOS30,
+	} {
+		if _, ok := builtin[plugin.Address]; ok {
+			return fmt.Errorf("precompile %v (%s) collides with a builtin precompile", plugin.Address, plugin.Name)
+		}
+	}
+	pluginPrecompiles[plugin.Address] = &pluginPrecompile{plugin}
+	return nil
+}
+
+// UnregisterPrecompile unregisters the precompile at the given address, if any.
+func UnregisterPrecompile(addr common.Address) {
+	delete(pluginPrecompiles, addr)
+}
+
+// pluginPrecompileAt returns the registered precompile at the given address, if
+// it's active under the given rules.
+func pluginPrecompileAt(addr common.Address, rules params.Rules) (PrecompiledContract, bool) {
+	p, ok := pluginPrecompiles[addr]
+	if !ok || (p.IsActive != nil && !p.IsActive(rules)) {
+		return nil, false
+	}
+	return p, true
+}
+
+// appendPluginPrecompiles returns the given precompile addresses along with the
+// addresses of the registered precompiles active under the given rules, leaving
+// the given slice untouched.
+func appendPluginPrecompiles(addrs []common.Address, rules params.Rules) []common.Address {
+	var active []common.Address
+	for addr, p := range pluginPrecompiles {
+		if p.IsActive == nil || p.IsActive(rules) {
+			active = append(active, addr)
+		}
+	}
+	if len(active) == 0 {
+		return addrs
+	}
+	slices.SortFunc(active, func(a, b common.Address) int { return a.Cmp(b) })
+	return append(slices.Clip(addrs), active...)
+}
+
+// pluginPrecompile adapts a registered precompile to the precompiled contracts,
+// running it with the context of the call.
+type pluginPrecompile struct {
+	PluginPrecompile
+}
+
+func (p *pluginPrecompile) RequiredGas(input []byte) uint64 {
+	return p.PluginPrecompile.RequiredGas(input)
+}
+
+func (p *pluginPrecompile) Run(input []byte) ([]byte, error) {
+	return nil, errPluginCallContext
+}
+
+func (p *pluginPrecompile) RunAdvanced(input []byte, suppliedGas uint64, info *AdvancedPrecompileCall) ([]byte, uint64, error) {
+	gasCost := p.PluginPrecompile.RequiredGas(input)
+	if suppliedGas < gasCost {
+		return nil, 0, ErrOutOfGas
+	}
+	if tracer := info.Evm.Config.Tracer; tracer != nil && tracer.OnGasChange != nil {
+		tracer.OnGasChange(suppliedGas, suppliedGas-gasCost, tracing.GasChangeCallPrecompiledContract)
+	}
+	ret, err := p.PluginPrecompile.Run(&PluginPrecompileCall{info}, input)
+	return ret, suppliedGas - gasCost, err
+}
+
+// PluginPrecompileCall is the context of a call to a plugin precompile, giving
+// it access to its own storage and logs. The accesses go through the journal of
+// the state, and the hooks of its tracer, as the ones of the contracts do.
+type PluginPrecompileCall struct {
+	*AdvancedPrecompileCall
+}
+
+// GetState returns the value of the given slot of the storage of the precompile.
+func (c *PluginPrecompileCall) GetState(key common.Hash) common.Hash {
+	return c.Evm.StateDB.GetState(c.PrecompileAddress, key)
+}
+
+// SetState sets the value of the given slot of the storage of the precompile,
+// failing in a static call. The account of the precompile is given a nonce on
+// its first write, so that its storage isn't dropped as the one of an empty
+// account.
+func (c *PluginPrecompileCall) SetState(key common.Hash, value common.Hash) error {
+	if c.ReadOnly {
+		return ErrWriteProtection
+	}
+	if c.Evm.StateDB.GetNonce(c.PrecompileAddress) == 0 {
+		c.Evm.StateDB.SetNonce(c.PrecompileAddress, 1)
+	}
+	c.Evm.StateDB.SetState(c.PrecompileAddress, key, value)
+	return nil
+}
+
+// AddLog emits a log of the precompile, failing in a static call.
+func (c *PluginPrecompileCall) AddLog(topics []common.Hash, data []byte) error {
+	if c.ReadOnly {
+		return ErrWriteProtection
+	}
+	c.Evm.StateDB.AddLog(&types.Log{
+		Address:     c.PrecompileAddress,
+		Topics:      topics,
+		Data:        data,
+		BlockNumber: c.Evm.Context.BlockNumber.Uint64(),
+	})
+	return nil
+}
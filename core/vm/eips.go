@@ -285,10 +285,16 @@ func opBlobHash(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([
 
 // opBlobBaseFee implements BLOBBASEFEE opcode
 func opBlobBaseFee(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
-	if interpreter.evm.chainConfig.IsArbitrum() {
-		return nil, errors.New("BLOBBASEFEE is not supported on Arbitrum")
+	if interpreter.evm.chainConfig.IsArbitrum() && !interpreter.evm.Config.SimulateBlobs {
+		// Arbitrum: supported only if the chain schedules it explicitly
+		if _, scheduled := interpreter.evm.chainConfig.ScheduledEIP(7516, interpreter.evm.Context.ArbOSVersion); !scheduled {
+			return nil, errors.New("BLOBBASEFEE is not supported on Arbitrum")
+		}
+	}
+	blobBaseFee := new(uint256.Int)
+	if fee := interpreter.evm.BlobBaseFee(); fee != nil {
+		blobBaseFee.SetFromBig(fee)
 	}
-	blobBaseFee, _ := uint256.FromBig(interpreter.evm.Context.BlobBaseFee)
 	scope.Stack.push(blobBaseFee)
 	return nil, nil
 }
@@ -62,6 +62,10 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 		precompiles = PrecompiledContractsHomestead
 	}
 	p, ok := precompiles[addr]
+	// Arbitrum: fall back to the precompiles registered by the embedder
+	if !ok {
+		p, ok = pluginPrecompileAt(addr, evm.chainRules)
+	}
 	return p, ok
 }
 
@@ -164,6 +168,15 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 	}
 	evm.ProcessingHook = DefaultTxProcessor{evm: evm}
 	evm.interpreter = NewEVMInterpreter(evm)
+
+	// Arbitrum: apply the chain's policy of the recent programs cache
+	if policy := chainConfig.RecentWasmsPolicy(blockCtx.ArbOSVersion); statedb != nil && policy != nil {
+		statedb.SetRecentWasmsConfig(policy)
+	}
+	// Arbitrum: apply the chain's ceilings of the wasm pages
+	if ceilings := chainConfig.WasmPagesCeilings(blockCtx.ArbOSVersion); statedb != nil && ceilings != nil {
+		statedb.SetWasmPagesConfig(ceilings)
+	}
 	return evm
 }
 
@@ -172,6 +185,15 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+
+	// Arbitrum: apply the chain's policy of the recent programs cache
+	if policy := evm.chainConfig.RecentWasmsPolicy(evm.Context.ArbOSVersion); statedb != nil && policy != nil {
+		statedb.SetRecentWasmsConfig(policy)
+	}
+	// Arbitrum: apply the chain's ceilings of the wasm pages
+	if ceilings := evm.chainConfig.WasmPagesCeilings(evm.Context.ArbOSVersion); statedb != nil && ceilings != nil {
+		statedb.SetWasmPagesConfig(ceilings)
+	}
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -20,8 +20,11 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 )
 
 // Depth returns the current depth
@@ -37,6 +40,39 @@ func (evm *EVM) DecrementDepth() {
 	evm.depth -= 1
 }
 
+// BlobBaseFee returns the blob base fee of the block, or nil if the block has no
+// blob fee market. Arbitrum blocks have none, so the simulations of messages
+// carrying blobs run against an idle one, at the minimum blob base fee, unless
+// the block context is overridden.
+func (evm *EVM) BlobBaseFee() *big.Int {
+	if evm.Context.BlobBaseFee == nil && evm.Config.SimulateBlobs {
+		return big.NewInt(params.BlobTxMinBlobGasprice)
+	}
+	return evm.Context.BlobBaseFee
+}
+
+// recordSelfDestruct records where the balance of the given selfdestructed
+// contract went, for the state and the tracer.
+func (evm *EVM) recordSelfDestruct(contract, beneficiary common.Address, balance *uint256.Int) {
+	record := state.SelfDestructRecord{
+		Contract:    contract,
+		Beneficiary: beneficiary,
+		Transferred: new(uint256.Int),
+		Burnt:       new(uint256.Int),
+		Destructed:  evm.StateDB.HasSelfDestructed(contract),
+	}
+	if beneficiary != contract {
+		record.Transferred.Set(balance)
+	} else if record.Destructed {
+		record.Burnt.Set(balance)
+	}
+	evm.StateDB.RecordSelfDestruct(record)
+
+	if tracer := evm.Config.Tracer; tracer != nil && tracer.OnSelfDestruct != nil {
+		tracer.OnSelfDestruct(contract, beneficiary, record.Transferred.ToBig(), record.Burnt.ToBig(), record.Destructed)
+	}
+}
+
 type TxProcessingHook interface {
 	StartTxHook() (bool, uint64, error, []byte) // return 4-tuple rather than *struct to avoid an import cycle
 	GasChargingHook(gasRemaining *uint64) (common.Address, error)
@@ -834,6 +834,8 @@ func opSelfdestruct(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 			tracer.OnExit(interpreter.evm.depth, []byte{}, 0, nil, false)
 		}
 	}
+	// Arbitrum: record where the balance went
+	interpreter.evm.recordSelfDestruct(scope.Contract.Address(), beneficiary.Bytes20(), balance)
 	return nil, errStopToken
 }
 
@@ -844,7 +846,7 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 
 	// Arbitrum: revert if acting account is a Stylus program
 	actingAddress := scope.Contract.Address()
-	if code := interpreter.evm.StateDB.GetCode(actingAddress); state.IsStylusProgram(code) {
+	if prefix := interpreter.evm.StateDB.GetCodePrefix(actingAddress, len(state.StylusDiscriminant)+1); state.IsStylusProgram(prefix) {
 		return nil, ErrExecutionReverted
 	}
 
@@ -861,6 +863,8 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 			tracer.OnExit(interpreter.evm.depth, []byte{}, 0, nil, false)
 		}
 	}
+	// Arbitrum: record where the balance went
+	interpreter.evm.recordSelfDestruct(scope.Contract.Address(), beneficiary.Bytes20(), balance)
 	return nil, errStopToken
 }
 
@@ -37,6 +37,7 @@ type StateDB interface {
 	RecordCacheWasm(wasm state.CacheWasm)
 	RecordEvictWasm(wasm state.EvictWasm)
 	GetRecentWasms() state.RecentWasms
+	SetRecentWasmsConfig(config *params.RecentWasmsConfig)
 
 	// Arbitrum: track stylus's memory footprint
 	GetStylusPages() (uint16, uint16)
@@ -44,14 +45,27 @@ type StateDB interface {
 	SetStylusPagesOpen(open uint16)
 	AddStylusPages(new uint16) (uint16, uint16)
 	AddStylusPagesEver(new uint16)
+	GrowStylusPages(addr common.Address, new uint16, cost uint64) error
+	TakeWasmPagesError() error
+	SetWasmPagesConfig(config *params.WasmPagesConfig)
+	RecordStylusCall(moduleHash common.Hash, ink uint64)
 
 	// Arbitrum: preserve old empty account behavior
 	CreateZombieIfDeleted(common.Address)
 
 	// Arbitrum
 	FilterTx()
+	FilterTxWithReason(reason string)
 	ClearTxFilter()
 	IsTxFiltered() bool
+	TxFilterReason() string
+
+	// Arbitrum: meter the L1 data of the transaction
+	RecordL1DataUsage(usage *types.L1DataUsage)
+	L1DataUsage() *types.L1DataUsage
+
+	// Arbitrum: record where the balance of the selfdestructed contracts went
+	RecordSelfDestruct(record state.SelfDestructRecord)
 
 	Deterministic() bool
 	Database() state.Database
@@ -66,11 +80,14 @@ type StateDB interface {
 
 	GetNonce(common.Address) uint64
 	SetNonce(common.Address, uint64)
+	GetKeyedNonce(common.Address, *uint256.Int) uint64
+	SetKeyedNonce(common.Address, *uint256.Int, uint64)
 
 	GetCodeHash(common.Address) common.Hash
 	GetCode(common.Address) []byte
 	SetCode(common.Address, []byte)
 	GetCodeSize(common.Address) int
+	GetCodePrefix(common.Address, int) []byte
 
 	AddRefund(uint64)
 	SubRefund(uint64)
@@ -34,6 +34,15 @@ type Config struct {
 	NoBaseFee               bool  // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool  // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int // Additional EIPS that are to be enabled
+
+	// Arbitrum: meter the L1 data of the transactions, estimating their size
+	// once compressed at the given brotli level
+	MeterL1Data            bool
+	L1DataCompressionLevel uint64
+
+	// Arbitrum: process the blobs of the messages as L1 would rather than
+	// rejecting them, for the RPC simulations of the transactions bound for L1
+	SimulateBlobs bool
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -139,6 +148,17 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
+	copied := len(extraEips) > 0
+	// Arbitrum: apply the chain's schedule of the EIPs activated independently of their fork
+	if len(evm.chainConfig.ArbitrumChainParams.EIPActivations) > 0 {
+		table, copied = scheduleEIPs(table, copied, evm.chainConfig, evm.chainRules)
+	}
+	// Arbitrum: apply the chain's overrides of the opcode gas costs, once scheduled
+	if evm.chainRules.IsArbitrum {
+		if overrides := evm.chainConfig.OpcodeGasOverrides(evm.chainRules.ArbOSVersion); len(overrides) > 0 {
+			table = overrideOpcodeGas(table, copied, overrides)
+		}
+	}
 	return &EVMInterpreter{evm: evm, table: table}
 }
 
@@ -217,6 +237,10 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	// Arbitrum: handle Stylus programs
 	if in.evm.chainRules.IsStylus && state.IsStylusProgram(contract.Code) {
 		ret, err = in.evm.ProcessingHook.ExecuteWASM(callContext, input, in)
+		// Fail the program if it opened more pages than the chain allows
+		if pagesErr := in.evm.StateDB.TakeWasmPagesError(); pagesErr != nil && err == nil {
+			ret, err = nil, pagesErr
+		}
 		return
 	}
 
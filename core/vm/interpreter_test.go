@@ -17,6 +17,9 @@
 package vm
 
 import (
+	"errors"
+	"maps"
+	"reflect"
 	"testing"
 	"time"
 
@@ -75,3 +78,184 @@ func TestLoopInterrupt(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduledEIPs(t *testing.T) {
+	active := func(jt *JumpTable) map[int]bool {
+		return map[int]bool{
+			1153: jt[TLOAD].HasCost() && jt[TSTORE].HasCost(),
+			5656: jt[MCOPY].HasCost(),
+			6780: reflect.ValueOf(jt[SELFDESTRUCT].execute).Pointer() == reflect.ValueOf(opSelfdestruct6780).Pointer(),
+			7516: jt[BLOBBASEFEE].HasCost(),
+		}
+	}
+	// The combinations of the ArbOS versions before Cancun, at Cancun and at Stylus
+	for i, tt := range []struct {
+		schedule     map[int]uint64
+		arbosVersion uint64
+		want         map[int]bool
+	}{
+		{nil, params.ArbosVersion_11, map[int]bool{1153: false, 5656: false, 6780: false, 7516: false}},
+		{nil, params.ArbosVersion_20, map[int]bool{1153: true, 5656: true, 6780: true, 7516: true}},
+		{map[int]uint64{1153: params.ArbosVersion_11, 5656: params.ArbosVersion_11}, params.ArbosVersion_11, map[int]bool{1153: true, 5656: true, 6780: false, 7516: false}},
+		{map[int]uint64{6780: params.ArbosVersion_30, 7516: params.ArbosVersion_30}, params.ArbosVersion_20, map[int]bool{1153: true, 5656: true, 6780: false, 7516: false}},
+		{map[int]uint64{6780: params.ArbosVersion_30, 7516: params.ArbosVersion_30}, params.ArbosVersion_30, map[int]bool{1153: true, 5656: true, 6780: true, 7516: true}},
+	} {
+		config := *params.TestChainConfig
+		config.ArbitrumChainParams = params.ArbitrumChainParams{EnableArbOS: true, EIPActivations: tt.schedule}
+		if err := ValidateEIPActivations(&config); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		vmctx := BlockContext{BlockNumber: common.Big0, Random: &common.Hash{}, ArbOSVersion: tt.arbosVersion}
+		evm := NewEVM(vmctx, TxContext{}, nil, &config, Config{})
+		if have := active(evm.interpreter.table); !maps.Equal(have, tt.want) {
+			t.Errorf("test %d: active EIPs mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	// The bundled tables are left untouched
+	if have, want := active(&cancunInstructionSet), (map[int]bool{1153: true, 5656: true, 6780: true, 7516: true}); !maps.Equal(have, want) {
+		t.Errorf("cancun instruction set modified: %v", have)
+	}
+	config := *params.TestChainConfig
+	config.ArbitrumChainParams.EIPActivations = map[int]uint64{4844: params.ArbosVersion_20}
+	if err := ValidateEIPActivations(&config); err == nil {
+		t.Error("unschedulable EIP accepted")
+	}
+}
+
+func TestScheduledOpcodeGas(t *testing.T) {
+	overrides := &params.OpcodeGasConfig{ArbosVersion: params.ArbosVersion_32, Gas: map[string]uint64{"PUSH1": 5, "SLOAD": 400}}
+	for i, tt := range []struct {
+		overrides    *params.OpcodeGasConfig
+		arbosVersion uint64
+		push, sload  uint64
+	}{
+		{nil, params.ArbosVersion_32, 3, 0},
+		{overrides, params.ArbosVersion_31, 3, 0},
+		{overrides, params.ArbosVersion_32, 5, 400},
+	} {
+		config := *params.TestChainConfig
+		config.ArbitrumChainParams = params.ArbitrumChainParams{EnableArbOS: true, OpcodeGas: tt.overrides}
+		if err := ValidateOpcodeGas(&config); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		vmctx := BlockContext{BlockNumber: common.Big0, Random: &common.Hash{}, ArbOSVersion: tt.arbosVersion}
+		table := NewEVM(vmctx, TxContext{}, nil, &config, Config{}).interpreter.table
+		if have := table[PUSH1].constantGas; have != tt.push {
+			t.Errorf("test %d: PUSH1 gas mismatch: have %d, want %d", i, have, tt.push)
+		}
+		if have := table[SLOAD].constantGas; have != tt.sload {
+			t.Errorf("test %d: SLOAD gas mismatch: have %d, want %d", i, have, tt.sload)
+		}
+	}
+	// The bundled tables are left untouched
+	if have := cancunInstructionSet[PUSH1].constantGas; have != GasFastestStep {
+		t.Errorf("cancun instruction set modified: PUSH1 gas %d", have)
+	}
+}
+
+func TestScheduledBlobBaseFee(t *testing.T) {
+	address := common.BytesToAddress([]byte("contract"))
+	code := []byte{byte(BLOBBASEFEE), byte(PUSH1), 0, byte(MSTORE), byte(PUSH1), 0x20, byte(PUSH1), 0, byte(RETURN)}
+
+	for i, tt := range []struct {
+		schedule map[int]uint64
+		ok       bool
+	}{
+		{nil, false},
+		{map[int]uint64{7516: params.ArbosVersion_20}, true},
+	} {
+		config := *params.TestChainConfig
+		config.ArbitrumChainParams = params.ArbitrumChainParams{EnableArbOS: true, EIPActivations: tt.schedule}
+
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(address, code)
+		vmctx := BlockContext{
+			Transfer:     func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber:  common.Big0,
+			Random:       &common.Hash{},
+			ArbOSVersion: params.ArbosVersion_20,
+		}
+		evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+		ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(uint256.Int))
+		if (err == nil) != tt.ok {
+			t.Fatalf("test %d: have error %v, want success %v", i, err, tt.ok)
+		}
+		if tt.ok && common.BytesToHash(ret) != (common.Hash{}) {
+			t.Errorf("test %d: blob base fee without blob fee market: have %x, want 0", i, ret)
+		}
+	}
+}
+
+func TestScheduledRecentWasms(t *testing.T) {
+	policy := &params.RecentWasmsConfig{ArbosVersion: params.ArbosVersion_32, Capacity: 4}
+	for i, tt := range []struct {
+		arbosVersion uint64
+		want         *params.RecentWasmsConfig
+	}{
+		{params.ArbosVersion_31, nil},
+		{params.ArbosVersion_32, policy},
+	} {
+		config := *params.TestChainConfig
+		config.ArbitrumChainParams = params.ArbitrumChainParams{EnableArbOS: true, RecentWasms: policy}
+
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		vmctx := BlockContext{BlockNumber: common.Big0, Random: &common.Hash{}, ArbOSVersion: tt.arbosVersion}
+		NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+		if have := statedb.GetRecentWasms().Config(); have != tt.want {
+			t.Errorf("test %d: recent programs policy mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// pagesProcessor mimics the Stylus runtime opening the given number of pages
+// per program call.
+type pagesProcessor struct {
+	DefaultTxProcessor
+	pages uint16
+}
+
+func (p pagesProcessor) ExecuteWASM(scope *ScopeContext, input []byte, interpreter *EVMInterpreter) ([]byte, error) {
+	open, _ := interpreter.evm.StateDB.AddStylusPages(p.pages)
+	defer interpreter.evm.StateDB.SetStylusPagesOpen(open)
+	return []byte{1}, nil
+}
+
+func TestWasmPagesCeilings(t *testing.T) {
+	address := common.BytesToAddress([]byte("program"))
+	code := append(state.NewStylusPrefix(0), 0x00)
+
+	for i, tt := range []struct {
+		arbosVersion uint64
+		pages        uint16
+		ok           bool
+	}{
+		{params.ArbosVersion_32, 4, true},
+		{params.ArbosVersion_32, 9, false},
+		{params.ArbosVersion_31, 9, true}, // Ceilings not active yet
+	} {
+		config := *params.TestChainConfig
+		config.ArbitrumChainParams = params.ArbitrumChainParams{
+			EnableArbOS: true,
+			WasmPages:   &params.WasmPagesConfig{ArbosVersion: params.ArbosVersion_32, MaxTxPages: 8},
+		}
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(address, code)
+		vmctx := BlockContext{
+			CanTransfer:  func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:     func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber:  common.Big0,
+			Random:       &common.Hash{},
+			ArbOSVersion: tt.arbosVersion,
+		}
+		evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+		evm.ProcessingHook = pagesProcessor{DefaultTxProcessor{evm: evm}, tt.pages}
+
+		_, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(uint256.Int))
+		if (err == nil) != tt.ok {
+			t.Fatalf("test %d: have error %v, want success %v", i, err, tt.ok)
+		}
+		if !tt.ok && !errors.Is(err, state.ErrWasmPagesExceeded) {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+	}
+}
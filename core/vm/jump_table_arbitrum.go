@@ -0,0 +1,113 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// schedulableEIPs are the EIPs the Arbitrum chains may activate independently
+// of the fork bundling them, with the changes they make to the jump tables.
+var schedulableEIPs = map[int]struct {
+	fork    func(rules params.Rules) bool
+	enable  func(jt *JumpTable)
+	disable func(jt *JumpTable)
+}{
+	1153: {isCancun, enable1153, func(jt *JumpTable) { undefineOps(jt, TLOAD, TSTORE) }},
+	5656: {isCancun, enable5656, func(jt *JumpTable) { undefineOps(jt, MCOPY) }},
+	6780: {isCancun, enable6780, func(jt *JumpTable) {
+		selfdestruct := *shanghaiInstructionSet[SELFDESTRUCT]
+		jt[SELFDESTRUCT] = &selfdestruct
+	}},
+	7516: {isCancun, enable7516, func(jt *JumpTable) { undefineOps(jt, BLOBBASEFEE) }},
+}
+
+func isCancun(rules params.Rules) bool { return rules.IsCancun }
+
+// undefineOps makes the given opcodes invalid in the given jump table.
+func undefineOps(jt *JumpTable, ops ...OpCode) {
+	for _, op := range ops {
+		jt[op] = &operation{execute: opUndefined, maxStack: maxStack(0, 0)}
+	}
+}
+
+// ValidateEIPActivations checks that the EIPs scheduled by the given chain
+// config can be activated independently of their fork.
+func ValidateEIPActivations(config *params.ChainConfig) error {
+	for eip := range config.ArbitrumChainParams.EIPActivations {
+		if _, ok := schedulableEIPs[eip]; !ok {
+			return fmt.Errorf("EIP-%d can't be scheduled independently of its fork", eip)
+		}
+	}
+	return nil
+}
+
+// scheduleEIPs returns the given jump table with the EIPs scheduled by the
+// chain config enabled or disabled as of the ArbOS version of the rules, where
+// the schedule departs from the fork of the table. It's copied first unless
+// it's already a copy or left as is, which is reported.
+func scheduleEIPs(table *JumpTable, copied bool, config *params.ChainConfig, rules params.Rules) (*JumpTable, bool) {
+	for eip, s := range schedulableEIPs {
+		active, scheduled := config.ScheduledEIP(eip, rules.ArbOSVersion)
+		if !scheduled || active == s.fork(rules) {
+			continue
+		}
+		if !copied {
+			// Deep-copy jumptable to prevent modification of opcodes in other tables
+			table, copied = copyJumpTable(table), true
+		}
+		if active {
+			s.enable(table)
+		} else {
+			s.disable(table)
+		}
+	}
+	return table, copied
+}
+
+// ValidateOpcodeGas checks that the opcode gas overrides of the given chain
+// config name known opcodes.
+func ValidateOpcodeGas(config *params.ChainConfig) error {
+	if config.ArbitrumChainParams.OpcodeGas == nil {
+		return nil
+	}
+	for name := range config.ArbitrumChainParams.OpcodeGas.Gas {
+		if _, ok := stringToOp[name]; !ok {
+			return fmt.Errorf("gas override of unknown opcode %q", name)
+		}
+	}
+	return nil
+}
+
+// overrideOpcodeGas returns the given jump table with the constant gas of the
+// overridden opcodes replaced, copying it first unless it's already a copy. The
+// dynamic gas of the operations is left as is, so the override of an operation
+// priced by its accesses, as SLOAD after Berlin, adds to the access costs.
+func overrideOpcodeGas(table *JumpTable, copied bool, overrides map[string]uint64) *JumpTable {
+	if !copied {
+		// Deep-copy jumptable to prevent modification of opcodes in other tables
+		table = copyJumpTable(table)
+	}
+	for name, gas := range overrides {
+		if op, ok := stringToOp[name]; ok {
+			table[op].constantGas = gas
+		}
+	}
+	return table
+}
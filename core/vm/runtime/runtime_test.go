@@ -17,9 +17,12 @@
 package runtime
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -30,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/asm"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers"
@@ -677,6 +681,86 @@ func TestColdAccountAccessCost(t *testing.T) {
 	}
 }
 
+// TestWarmAccessCost tests that the accounts and slots declared warm by the chain
+// config are charged the warm access cost from their first access.
+func TestWarmAccessCost(t *testing.T) {
+	// BALANCE(0xff), SLOAD(1), SLOAD(2)
+	code := []byte{
+		byte(vm.PUSH1), 0xff, byte(vm.BALANCE), byte(vm.POP),
+		byte(vm.PUSH1), 0x1, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x2, byte(vm.SLOAD), byte(vm.POP),
+	}
+	for i, tc := range []struct {
+		warm *params.WarmAccessConfig
+		want []uint64 // Costs of the BALANCE and SLOADs
+	}{
+		{
+			want: []uint64{2600, 2100, 2100},
+		},
+		{ // Account warm
+			warm: &params.WarmAccessConfig{Accounts: []params.WarmAccessTuple{{Address: common.BytesToAddress([]byte{0xff})}}},
+			want: []uint64{100, 2100, 2100},
+		},
+		{ // Account and slot 1 of the contract warm
+			warm: &params.WarmAccessConfig{Accounts: []params.WarmAccessTuple{
+				{Address: common.BytesToAddress([]byte{0xff})},
+				{Address: common.BytesToAddress([]byte("contract")), StorageKeys: []common.Hash{common.BytesToHash([]byte{0x1})}},
+			}},
+			want: []uint64{100, 100, 2100},
+		},
+		{ // Account warm from a later ArbOS version
+			warm: &params.WarmAccessConfig{ArbosVersion: params.ArbosVersion_32, Accounts: []params.WarmAccessTuple{{Address: common.BytesToAddress([]byte{0xff})}}},
+			want: []uint64{2600, 2100, 2100},
+		},
+	} {
+		cfg := &Config{EVMConfig: vm.Config{}}
+		setDefaults(cfg)
+		cfg.ChainConfig.ArbitrumChainParams.WarmAccess = tc.warm
+
+		tracer := logger.NewStructLogger(nil)
+		cfg.EVMConfig.Tracer = tracer.Hooks()
+		Execute(code, nil, cfg)
+
+		logs := tracer.StructLogs()
+		for j, step := range []int{1, 4, 7} {
+			if have, want := logs[step].GasCost, tc.want[j]; have != want {
+				t.Errorf("testcase %d, gas report wrong, step %d, have %d want %d", i, step, have, want)
+			}
+		}
+	}
+}
+
+func TestOpcodeGasOverride(t *testing.T) {
+	// SLOAD(1), SLOAD(1)
+	code := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.SLOAD), byte(vm.POP),
+		byte(vm.PUSH1), 0x1, byte(vm.SLOAD), byte(vm.POP),
+	}
+	// The overrides only apply to Arbitrum chains
+	cfg := &Config{EVMConfig: vm.Config{}}
+	setDefaults(cfg)
+	cfg.ChainConfig.ArbitrumChainParams.OpcodeGas = &params.OpcodeGasConfig{Gas: map[string]uint64{"PUSH1": 5, "SLOAD": 400}}
+	if err := vm.ValidateOpcodeGas(cfg.ChainConfig); err != nil {
+		t.Fatal(err)
+	}
+	tracer := logger.NewStructLogger(nil)
+	cfg.EVMConfig.Tracer = tracer.Hooks()
+	Execute(code, nil, cfg)
+
+	logs := tracer.StructLogs()
+	for j, step := range []int{0, 1, 4} {
+		if have, want := logs[step].GasCost, []uint64{3, 2100, 100}[j]; have != want {
+			t.Errorf("gas report wrong, step %d, have %d want %d", step, have, want)
+		}
+	}
+	// Unknown opcodes are rejected
+	config := *params.TestChainConfig
+	config.ArbitrumChainParams.OpcodeGas = &params.OpcodeGasConfig{Gas: map[string]uint64{"SLOAD2": 1}}
+	if err := vm.ValidateOpcodeGas(&config); err == nil {
+		t.Error("gas override of unknown opcode accepted")
+	}
+}
+
 func TestRuntimeJSTracer(t *testing.T) {
 	jsTracers := []string{
 		`{enters: 0, exits: 0, enterGas: 0, gasUsed: 0, steps:0,
@@ -913,3 +997,169 @@ func BenchmarkTracerStepVsCallFrame(b *testing.B) {
 	benchmarkNonModifyingCode(10000000, code, "tracer-step-10M", stepTracer, b)
 	benchmarkNonModifyingCode(10000000, code, "tracer-call-frame-10M", callFrameTracer, b)
 }
+
+func TestPluginPrecompile(t *testing.T) {
+	counter := common.BytesToAddress([]byte{0xff, 0x01})
+	if err := vm.RegisterPrecompile(vm.PluginPrecompile{
+		Address:     counter,
+		Name:        "counter",
+		IsActive:    func(rules params.Rules) bool { return rules.IsLondon },
+		RequiredGas: func(input []byte) uint64 { return 1000 },
+		Run: func(call *vm.PluginPrecompileCall, input []byte) ([]byte, error) {
+			count := new(big.Int).SetBytes(call.GetState(common.Hash{}).Bytes())
+			next := common.BigToHash(count.Add(count, common.Big1))
+			if err := call.SetState(common.Hash{}, next); err != nil {
+				return nil, err
+			}
+			return next.Bytes(), call.AddLog(nil, next.Bytes())
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer vm.UnregisterPrecompile(counter)
+
+	if err := vm.RegisterPrecompile(vm.PluginPrecompile{Address: counter, RequiredGas: func([]byte) uint64 { return 0 }, Run: func(*vm.PluginPrecompileCall, []byte) ([]byte, error) { return nil, nil }}); err == nil {
+		t.Fatal("registered the precompile twice")
+	}
+	if err := vm.RegisterPrecompile(vm.PluginPrecompile{Address: common.BytesToAddress([]byte{0x1}), RequiredGas: func([]byte) uint64 { return 0 }, Run: func(*vm.PluginPrecompileCall, []byte) ([]byte, error) { return nil, nil }}); err == nil {
+		t.Fatal("registered a precompile over ecrecover")
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	count := func() uint64 { return statedb.GetState(counter, common.Hash{}).Big().Uint64() }
+
+	// Calls the counter, then returns or reverts with its output
+	caller := func(op vm.OpCode, end vm.OpCode) []byte {
+		code := []byte{
+			byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		}
+		if op == vm.CALL {
+			code = append(code, byte(vm.PUSH1), 0)
+		}
+		code = append(code, byte(vm.PUSH2), 0xff, 0x01, byte(vm.GAS), byte(op), byte(vm.POP))
+		return append(code, byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0, byte(end))
+	}
+	run := func(code []byte, london bool) ([]byte, error) {
+		cfg := &Config{State: statedb}
+		setDefaults(cfg)
+		if !london {
+			config := *cfg.ChainConfig
+			config.LondonBlock = big.NewInt(1)
+			cfg.ChainConfig = &config
+		}
+		contract := common.BytesToAddress([]byte("contract"))
+		statedb.SetCode(contract, code)
+		ret, _, err := Call(contract, nil, cfg)
+		return ret, err
+	}
+	// Active precompile, called directly and by a contract
+	if ret, err := run(caller(vm.CALL, vm.RETURN), true); err != nil || new(big.Int).SetBytes(ret).Uint64() != 1 {
+		t.Fatalf("call failed: have %x, %v", ret, err)
+	}
+	if ret, _, err := Call(counter, nil, &Config{State: statedb}); err != nil || new(big.Int).SetBytes(ret).Uint64() != 2 {
+		t.Fatalf("direct call failed: have %x, %v", ret, err)
+	}
+	if have := len(statedb.Logs()); have != 2 {
+		t.Errorf("log count mismatch: have %d, want 2", have)
+	}
+	// The writes are reverted along with the call, and forbidden in static calls
+	if _, err := run(caller(vm.CALL, vm.REVERT), true); err != vm.ErrExecutionReverted {
+		t.Fatalf("reverting call: have error %v, want %v", err, vm.ErrExecutionReverted)
+	}
+	if ret, err := run(caller(vm.STATICCALL, vm.RETURN), true); err != nil || len(bytes.TrimLeft(ret, "\x00")) != 0 {
+		t.Fatalf("static call: have %x, %v", ret, err)
+	}
+	if have := count(); have != 2 {
+		t.Errorf("counter mismatch: have %d, want 2", have)
+	}
+	// Inactive precompile, the call landing on an empty account
+	if ret, err := run(caller(vm.CALL, vm.RETURN), false); err != nil || len(bytes.TrimLeft(ret, "\x00")) != 0 {
+		t.Fatalf("inactive call: have %x, %v", ret, err)
+	}
+	if have := count(); have != 2 {
+		t.Errorf("counter mismatch after inactive call: have %d, want 2", have)
+	}
+	if addrs := vm.ActivePrecompiles(params.Rules{IsLondon: true}); !slices.Contains(addrs, counter) {
+		t.Errorf("active precompiles lack the plugin: %v", addrs)
+	}
+	if addrs := vm.ActivePrecompiles(params.Rules{}); slices.Contains(addrs, counter) {
+		t.Errorf("inactive plugin in the active precompiles: %v", addrs)
+	}
+}
+
+func TestSelfDestructRecords(t *testing.T) {
+	var (
+		contract = common.BytesToAddress([]byte("contract"))
+		child    = common.BytesToAddress([]byte("child"))
+		dead     = common.HexToAddress("0xdead")
+	)
+	selfdestructTo := func(beneficiary common.Address) []byte {
+		return append(append([]byte{byte(vm.PUSH20)}, beneficiary.Bytes()...), byte(vm.SELFDESTRUCT))
+	}
+	// Calls the child, then reverts
+	reverting := append(append([]byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH20)}, child.Bytes()...),
+		byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT),
+	)
+	for i, tc := range []struct {
+		cancun bool
+		code   []byte
+		want   []state.SelfDestructRecord
+	}{
+		{ // Burnt
+			code: selfdestructTo(contract),
+			want: []state.SelfDestructRecord{{Contract: contract, Beneficiary: contract, Transferred: uint256.NewInt(0), Burnt: uint256.NewInt(100), Destructed: true}},
+		},
+		{ // Transferred
+			code: selfdestructTo(dead),
+			want: []state.SelfDestructRecord{{Contract: contract, Beneficiary: dead, Transferred: uint256.NewInt(100), Burnt: uint256.NewInt(0), Destructed: true}},
+		},
+		{ // Kept, as of EIP-6780
+			cancun: true,
+			code:   selfdestructTo(contract),
+			want:   []state.SelfDestructRecord{{Contract: contract, Beneficiary: contract, Transferred: uint256.NewInt(0), Burnt: uint256.NewInt(0), Destructed: false}},
+		},
+		{ // Drained, as of EIP-6780
+			cancun: true,
+			code:   selfdestructTo(dead),
+			want:   []state.SelfDestructRecord{{Contract: contract, Beneficiary: dead, Transferred: uint256.NewInt(100), Burnt: uint256.NewInt(0), Destructed: false}},
+		},
+		{ // Reverted
+			code: reverting,
+		},
+	} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, tc.code)
+		statedb.SetCode(child, selfdestructTo(dead))
+		statedb.SetBalance(contract, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+		statedb.SetBalance(child, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+		statedb.Finalise(true)
+
+		var traced []state.SelfDestructRecord
+		cfg := &Config{State: statedb, EVMConfig: vm.Config{Tracer: &tracing.Hooks{
+			OnSelfDestruct: func(contract, beneficiary common.Address, transferred, burnt *big.Int, destructed bool) {
+				traced = append(traced, state.SelfDestructRecord{
+					Contract:    contract,
+					Beneficiary: beneficiary,
+					Transferred: uint256.MustFromBig(transferred),
+					Burnt:       uint256.MustFromBig(burnt),
+					Destructed:  destructed,
+				})
+			},
+		}}}
+		setDefaults(cfg)
+		if tc.cancun {
+			config := *cfg.ChainConfig
+			config.ShanghaiTime, config.CancunTime = new(uint64), new(uint64)
+			cfg.ChainConfig, cfg.Random = &config, &common.Hash{}
+		}
+		Call(contract, nil, cfg)
+
+		if have := statedb.SelfDestructs(); !reflect.DeepEqual(have, tc.want) && (len(have) > 0 || len(tc.want) > 0) {
+			t.Errorf("testcase %d: recorded selfdestructs mismatch: have %+v, want %+v", i, have, tc.want)
+		}
+		if tc.want != nil && !reflect.DeepEqual(traced, tc.want) {
+			t.Errorf("testcase %d: traced selfdestructs mismatch: have %+v, want %+v", i, traced, tc.want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	wasmGCCollectedMeter = metrics.NewRegisteredMeter("chain/wasm/gc/collected", nil)
+	wasmGCFreedMeter     = metrics.NewRegisteredMeter("chain/wasm/gc/freed", nil)
+	wasmGCTimer          = metrics.NewRegisteredResettingTimer("chain/wasm/gc/time", nil)
+
+	wasmFrozenMeter     = metrics.NewRegisteredMeter("chain/wasm/freezer/frozen", nil)
+	wasmFrozenSizeMeter = metrics.NewRegisteredMeter("chain/wasm/freezer/size", nil)
+)
+
+var errWasmGCUnsupported = errors.New("ArbOS doesn't report Stylus module references")
+
+// WasmGCResult is the outcome of a garbage collection of the wasm store.
+type WasmGCResult struct {
+	Number     uint64        // Number of the head block the references were taken from
+	Modules    uint64        // Number of modules found in the wasm store
+	Referenced uint64        // Number of modules referenced by at least one program
+	Retained   uint64        // Number of unreferenced modules retained by the policy
+	Collected  uint64        // Number of unreferenced modules deleted
+	Freed      uint64        // Total size of the asm deleted
+	Time       time.Time     // Time the collection completed at
+	Elapsed    time.Duration // Duration of the collection
+}
+
+// WasmFreezeResult is the outcome of a freezing of the cold modules.
+type WasmFreezeResult struct {
+	Number  uint64        // Number of the head block the activation age was measured from
+	Before  uint64        // Modules activated before this block were frozen
+	Frozen  uint64        // Number of modules moved to the wasm freezer
+	Size    uint64        // Total size of the asm moved
+	Elapsed time.Duration // Duration of the freezing
+}
+
+// WasmStoreStats describes the content of the wasm store.
+type WasmStoreStats struct {
+	Targets      map[ethdb.WasmTarget]WasmTargetStats // Asm stored for each target, excluding the frozen modules
+	Frozen       uint64                               // Number of modules moved to the wasm freezer
+	Unreferenced uint64                               // Number of modules awaiting collection
+	Retention    uint64                               // Number of blocks unreferenced modules are retained for
+	LastGC       *WasmGCResult                        // Outcome of the last collection, nil if none ran yet
+}
+
+// WasmTargetStats describes the asm stored for a single target.
+type WasmTargetStats struct {
+	Modules uint64 // Number of modules stored
+	Size    uint64 // Total size of the asm stored
+}
+
+// WasmStoreGC deletes the activated Stylus modules no longer referenced by any
+// program, as reported by ArbOS for the chain head state. As modules might be
+// referenced again after a reorg or a reactivation, a module is only deleted
+// once it stayed unreferenced for the configured number of blocks.
+//
+// If the wasm store has a freezer, the modules activated long ago are moved out
+// of the key-value store into the freezer, and collected from there alike.
+type WasmStoreGC struct {
+	bc        *BlockChain
+	retention uint64 // Number of blocks unreferenced modules are retained for
+	freezeAge uint64 // Number of blocks after activation modules are frozen at (0 = never)
+
+	last atomic.Pointer[WasmGCResult] // Outcome of the last collection
+	lock sync.Mutex                   // Serializes collections
+}
+
+func newWasmStoreGC(bc *BlockChain, retention uint64, freezeAge uint64) *WasmStoreGC {
+	return &WasmStoreGC{bc: bc, retention: retention, freezeAge: freezeAge}
+}
+
+// WasmStoreGC returns the garbage collector of the wasm store.
+func (bc *BlockChain) WasmStoreGC() *WasmStoreGC {
+	return bc.wasmGC
+}
+
+// wasmGCLoop periodically collects the unreferenced modules of the wasm store.
+func (bc *BlockChain) wasmGCLoop(interval time.Duration) {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := bc.wasmGC.Collect(); err != nil && !errors.Is(err, errWasmGCUnsupported) {
+				log.Warn("Failed to collect wasm store garbage", "err", err)
+			}
+			if bc.wasmGC.freezeAge > 0 {
+				if _, err := bc.wasmGC.Freeze(bc.wasmGC.freezeAge); err != nil && !errors.Is(err, rawdb.ErrNoWasmFreezer) {
+					log.Warn("Failed to freeze wasm modules", "err", err)
+				}
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// moduleRefs retrieves the modules referenced at the state of the given header.
+func (gc *WasmStoreGC) moduleRefs(header *types.Header) (map[common.Hash]uint64, error) {
+	statedb, err := gc.bc.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return GetStylusModuleRefs(statedb, header)
+}
+
+// Collect deletes the modules of the wasm store which weren't referenced by any
+// program for longer than the retention period, and marks the newly unreferenced
+// ones.
+func (gc *WasmStoreGC) Collect() (*WasmGCResult, error) {
+	if GetStylusModuleRefs == nil {
+		return nil, errWasmGCUnsupported
+	}
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+
+	var (
+		start     = time.Now()
+		wasmStore = gc.bc.StateCache().WasmStore()
+		head      = gc.bc.CurrentBlock()
+	)
+	// Gather the references without blocking the chain, the candidates are
+	// checked again below if the chain progressed meanwhile.
+	refs, err := gc.moduleRefs(head)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[common.Hash]struct{})
+	for _, target := range rawdb.AllWasmTargets() {
+		hashes, err := rawdb.ReadActivatedModuleHashes(wasmStore, target, common.Hash{}, math.MaxInt)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range hashes {
+			modules[hash] = struct{}{}
+		}
+	}
+	frozen, err := rawdb.ReadFrozenModuleHashes(wasmStore)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range frozen {
+		modules[hash] = struct{}{}
+	}
+	// Blocks activating modules are written holding the chain mutex, so holding
+	// it ensures no module is activated again while being deleted.
+	if !gc.bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	defer gc.bc.chainmu.Unlock()
+
+	if current := gc.bc.CurrentBlock(); current.Hash() != head.Hash() {
+		head = current
+		if refs, err = gc.moduleRefs(head); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		result = &WasmGCResult{Number: head.Number.Uint64(), Modules: uint64(len(modules))}
+		batch  = wasmStore.NewBatch()
+		number = head.Number.Uint64()
+	)
+	for hash := range modules {
+		if refs[hash] > 0 {
+			result.Referenced++
+			rawdb.DeleteUnreferencedModule(batch, hash)
+			continue
+		}
+		since, ok := rawdb.ReadUnreferencedModule(wasmStore, hash)
+		if !ok || since > number {
+			// Newly unreferenced, or marked on a reorged chain
+			rawdb.WriteUnreferencedModule(batch, hash, number)
+			result.Retained++
+			continue
+		}
+		if number-since < gc.retention {
+			result.Retained++
+			continue
+		}
+		for _, target := range rawdb.AllWasmTargets() {
+			result.Freed += uint64(len(rawdb.ReadActivatedAsm(wasmStore, target, hash)))
+		}
+		rawdb.DeleteActivation(batch, hash)
+		rawdb.DeleteUnreferencedModule(batch, hash)
+		result.Collected++
+	}
+	// Drop the markers of modules deleted by other means
+	unreferenced, err := rawdb.ReadUnreferencedModuleHashes(wasmStore)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range unreferenced {
+		if _, ok := modules[hash]; !ok {
+			rawdb.DeleteUnreferencedModule(batch, hash)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	result.Time = time.Now()
+	result.Elapsed = time.Since(start)
+	gc.last.Store(result)
+
+	wasmGCCollectedMeter.Mark(int64(result.Collected))
+	wasmGCFreedMeter.Mark(int64(result.Freed))
+	wasmGCTimer.UpdateSince(start)
+
+	log.Info("Collected wasm store garbage", "number", number, "modules", result.Modules, "referenced", result.Referenced,
+		"retained", result.Retained, "collected", result.Collected, "freed", common.StorageSize(result.Freed), "elapsed", common.PrettyDuration(result.Elapsed))
+	return result, nil
+}
+
+// Compact collects the garbage of the wasm store, then compacts the key range of
+// the activated modules to reclaim the space of the deleted ones.
+func (gc *WasmStoreGC) Compact() (*WasmGCResult, error) {
+	result, err := gc.Collect()
+	if err != nil && !errors.Is(err, errWasmGCUnsupported) {
+		return nil, err
+	}
+	start := time.Now()
+	if err := gc.bc.StateCache().WasmStore().Compact([]byte{0x00, 'w'}, []byte{0x00, 'x'}); err != nil {
+		return nil, err
+	}
+	log.Info("Compacted wasm store", "elapsed", common.PrettyDuration(time.Since(start)))
+	return result, nil
+}
+
+// Stats returns the content of the wasm store.
+func (gc *WasmStoreGC) Stats() (*WasmStoreStats, error) {
+	wasmStore := gc.bc.StateCache().WasmStore()
+
+	stats := &WasmStoreStats{
+		Targets:   make(map[ethdb.WasmTarget]WasmTargetStats),
+		Retention: gc.retention,
+	}
+	for _, target := range rawdb.AllWasmTargets() {
+		count, size, err := rawdb.ReadActivatedAsmStats(wasmStore, target)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			stats.Targets[target] = WasmTargetStats{Modules: count, Size: size}
+		}
+	}
+	unreferenced, err := rawdb.ReadUnreferencedModuleHashes(wasmStore)
+	if err != nil {
+		return nil, err
+	}
+	stats.Unreferenced = uint64(len(unreferenced))
+
+	frozen, err := rawdb.ReadFrozenModuleHashes(wasmStore)
+	if err != nil {
+		return nil, err
+	}
+	stats.Frozen = uint64(len(frozen))
+
+	if last := gc.last.Load(); last != nil {
+		result := *last
+		stats.LastGC = &result
+	}
+	return stats, nil
+}
+
+// Freeze moves the modules activated at least the given number of blocks ago
+// out of the key-value store into the wasm freezer, keeping the hot key-value
+// store small. The frozen modules remain readable through the wasm store.
+func (gc *WasmStoreGC) Freeze(age uint64) (*WasmFreezeResult, error) {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+
+	// Blocks activating modules are written holding the chain mutex, so holding
+	// it ensures no module is activated again while being frozen.
+	if !gc.bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	defer gc.bc.chainmu.Unlock()
+
+	var (
+		start  = time.Now()
+		number = gc.bc.CurrentBlock().Number.Uint64()
+		result = &WasmFreezeResult{Number: number}
+	)
+	if number < age {
+		return result, nil
+	}
+	result.Before = number - age + 1
+
+	frozen, size, err := rawdb.FreezeActivations(gc.bc.StateCache().WasmStore(), result.Before)
+	if err != nil {
+		return nil, err
+	}
+	result.Frozen, result.Size = uint64(frozen), size
+	result.Elapsed = time.Since(start)
+
+	wasmFrozenMeter.Mark(int64(frozen))
+	wasmFrozenSizeMeter.Mark(int64(size))
+	if frozen > 0 {
+		log.Info("Froze cold wasm modules", "before", result.Before, "modules", frozen, "size", common.StorageSize(size), "elapsed", common.PrettyDuration(result.Elapsed))
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that unreferenced modules are only collected once they stayed
+// unreferenced for the retention period.
+func TestWasmStoreGC(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		genesis = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		config  = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, nil)
+
+	config.WasmGCRetention = 2
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		kept      = common.Hash{1}
+		revived   = common.Hash{2}
+		collected = common.Hash{3}
+		refs      = map[common.Hash]uint64{kept: 1}
+		wasmStore = chain.StateCache().WasmStore()
+	)
+	defer func(hook func(*state.StateDB, *types.Header) (map[common.Hash]uint64, error)) {
+		GetStylusModuleRefs = hook
+	}(GetStylusModuleRefs)
+	GetStylusModuleRefs = func(*state.StateDB, *types.Header) (map[common.Hash]uint64, error) { return refs, nil }
+
+	for _, hash := range []common.Hash{kept, revived, collected} {
+		rawdb.WriteActivation(wasmStore, hash, map[ethdb.WasmTarget][]byte{rawdb.TargetWavm: {1}, rawdb.TargetAmd64: {1, 2}})
+	}
+	// The unreferenced modules are marked, but retained
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	result, err := chain.WasmStoreGC().Collect()
+	if err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if result.Modules != 3 || result.Referenced != 1 || result.Retained != 2 || result.Collected != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	// The revived module must lose its marker, the other must be collected
+	// after the retention period
+	refs[revived] = 1
+	if _, err := chain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if result, err = chain.WasmStoreGC().Collect(); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if result.Referenced != 2 || result.Collected != 1 || result.Freed != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if rawdb.ReadActivatedAsm(wasmStore, rawdb.TargetWavm, collected) != nil {
+		t.Fatal("unreferenced module not collected")
+	}
+	if rawdb.ReadActivatedAsm(wasmStore, rawdb.TargetWavm, revived) == nil {
+		t.Fatal("revived module collected")
+	}
+	stats, err := chain.WasmStoreGC().Stats()
+	if err != nil {
+		t.Fatalf("failed to retrieve stats: %v", err)
+	}
+	if stats.Unreferenced != 0 || stats.Targets[rawdb.TargetAmd64].Modules != 2 || stats.LastGC == nil || stats.LastGC.Collected != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if _, err := chain.WasmStoreGC().Compact(); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+}
@@ -143,3 +143,16 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	}
 	return true, nil
 }
+
+// SetSnapServing enables or disables the serving of the snap requests of the
+// peers, returning whether they were served before. The unserved requests are
+// answered with empty responses. The setting lasts until the node is restarted.
+func (api *AdminAPI) SetSnapServing(enabled bool) (bool, error) {
+	if api.eth.config.SnapshotCache == 0 {
+		return false, errors.New("snap protocol is not running")
+	}
+	server := api.eth.handler.snapServer
+	served := server.Enabled()
+	server.SetEnabled(enabled)
+	return served, nil
+}
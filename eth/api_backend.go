@@ -239,10 +239,18 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	if number := rawdb.ReadHeaderNumber(b.eth.chainDb, hash); number != nil {
+		if err := b.eth.blockchain.CheckReceiptsRetained(*number); err != nil {
+			return nil, err
+		}
+	}
 	return b.eth.blockchain.GetReceiptsByHash(hash), nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
+	if err := b.eth.blockchain.CheckReceiptsRetained(number); err != nil {
+		return nil, err
+	}
 	return rawdb.ReadLogs(b.eth.chainDb, hash, number), nil
 }
 
@@ -267,6 +275,14 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg *core.Message, state *st
 	return vm.NewEVM(context, txContext, state, b.ChainConfig(), *vmConfig)
 }
 
+func (b *EthAPIBackend) SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeStateChangesEvent(ch)
+}
+
+func (b *EthAPIBackend) SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeAccountLifecycleEvent(ch)
+}
+
 func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
@@ -441,6 +457,31 @@ func (b *EthAPIBackend) StateAtTransaction(ctx context.Context, block *types.Blo
 	return b.eth.stateAtTransaction(ctx, block, txIndex, reexec)
 }
 
+// intraBlockStateReexec is the number of blocks re-executed to regenerate the
+// parent state of a block replayed up to a transaction.
+const intraBlockStateReexec = 128
+
+// StateAtTransactionIndex returns the state of the given block right before the
+// execution of the transaction at the given index, from the cache of the
+// intra-block states if enabled.
+func (b *EthAPIBackend) StateAtTransactionIndex(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error) {
+	states := b.eth.blockchain.IntraBlockStates()
+	if states != nil {
+		if statedb := states.Get(block.Hash(), txIndex); statedb != nil {
+			return statedb, nil
+		}
+	}
+	_, _, statedb, release, err := b.eth.stateAtTransaction(ctx, block, txIndex, intraBlockStateReexec)
+	if err != nil {
+		return nil, err
+	}
+	if states == nil {
+		statedb.SetArbFinalizer(func(*state.ArbitrumExtraData) { release() })
+		return statedb, nil
+	}
+	return states.Add(block.Hash(), txIndex, statedb, release), nil
+}
+
 func (b *EthAPIBackend) FallbackClient() types.FallbackClient {
 	return nil
 }
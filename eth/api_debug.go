@@ -137,7 +137,9 @@ func (api *DebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error)
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
-// AccountRange enumerates all accounts in the given block and start point in paging request
+// AccountRange enumerates all accounts in the given block and start point in paging request.
+// The start point is either the key of an account, such as the next key of a previous page,
+// or an address, in which case the enumeration starts at that account.
 func (api *DebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, start hexutil.Bytes, maxResults int, nocode, nostorage, incompletes bool) (state.Dump, error) {
 	var stateDb *state.StateDB
 	var err error
@@ -193,6 +195,10 @@ func (api *DebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, start hex
 		return state.Dump{}, errors.New("either block number or block hash must be specified")
 	}
 
+	// Arbitrum: accounts are keyed by the hash of their address
+	if len(start) == common.AddressLength {
+		start = crypto.Keccak256(start)
+	}
 	opts := &state.DumpConfig{
 		SkipCode:          nocode,
 		SkipStorage:       nostorage,
@@ -236,19 +242,64 @@ func (api *DebugAPI) StorageRangeAt(ctx context.Context, blockNrOrHash rpc.Block
 	}
 	defer release()
 
-	return storageRangeAt(statedb, block.Root(), contractAddress, keyStart, maxResult)
+	return storageRangeAt(statedb, contractAddress, keyStart, maxResult)
 }
 
-func storageRangeAt(statedb *state.StateDB, root common.Hash, address common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
-	storageRoot := statedb.GetStorageRoot(address)
-	if storageRoot == types.EmptyRootHash || storageRoot == (common.Hash{}) {
-		return StorageRangeResult{}, nil // empty storage
+func storageRangeAt(statedb *state.StateDB, address common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
+	// Iterate the storage from the state snapshot, with the changes made on top
+	// of the traced state applied. The start key is a prefix of the hashed slot
+	// key, hence right padded.
+	var (
+		seek    common.Hash
+		slotErr error
+		triedb  = statedb.Database().TrieDB()
+		result  = StorageRangeResult{Storage: storageMap{}}
+	)
+	copy(seek[:], start)
+	err := statedb.IterateStorage(address, seek, func(hash common.Hash, key *common.Hash, slot []byte) bool {
+		if len(result.Storage) >= maxResult {
+			// Add the 'next key' so clients can continue downloading.
+			result.NextKey = &hash
+			return false
+		}
+		_, content, _, err := rlp.Split(slot)
+		if err != nil {
+			slotErr = err
+			return false
+		}
+		e := storageEntry{Key: key, Value: common.BytesToHash(content)}
+		if e.Key == nil {
+			if preimage := triedb.Preimage(hash); preimage != nil {
+				preimage := common.BytesToHash(preimage)
+				e.Key = &preimage
+			}
+		}
+		result.Storage[hash] = e
+		return true
+	})
+	if errors.Is(err, state.ErrNoStorageSnapshot) && triedb.Scheme() == rawdb.HashScheme {
+		// Hash-scheme databases without a snapshot can still afford to
+		// resolve the storage trie.
+		return storageTrieRangeAt(statedb, address, start, maxResult)
+	}
+	if err == nil {
+		err = slotErr
 	}
-	id := trie.StorageTrieID(root, crypto.Keccak256Hash(address.Bytes()), storageRoot)
-	tr, err := trie.NewStateTrie(id, statedb.Database().TrieDB())
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
+	return result, nil
+}
+
+// storageTrieRangeAt is the trie based fallback of storageRangeAt.
+func storageTrieRangeAt(statedb *state.StateDB, address common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
+	tr, err := statedb.StorageTrie(address)
+	if err != nil {
+		return StorageRangeResult{}, err
+	}
+	if tr == nil || tr.Hash() == types.EmptyRootHash {
+		return StorageRangeResult{}, nil // empty storage
+	}
 	trieIt, err := tr.NodeIterator(start)
 	if err != nil {
 		return StorageRangeResult{}, err
@@ -18,6 +18,7 @@ package eth
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"slices"
@@ -28,10 +29,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 	"github.com/holiman/uint256"
 )
 
@@ -161,10 +164,12 @@ func TestStorageRangeAt(t *testing.T) {
 
 	// Create a state where account 0x010000... has a few storage entries.
 	var (
-		db     = state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &triedb.Config{Preimages: true})
-		sdb, _ = state.New(types.EmptyRootHash, db, nil)
-		addr   = common.Address{0x01}
-		keys   = []common.Hash{ // hashes of Keys of storage
+		disk     = rawdb.NewMemoryDatabase()
+		db       = state.NewDatabaseWithConfig(disk, &triedb.Config{Preimages: true})
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, db.TrieDB(), types.EmptyRootHash)
+		sdb, _   = state.New(types.EmptyRootHash, db, snaps)
+		addr     = common.Address{0x01}
+		keys     = []common.Hash{ // hashes of Keys of storage
 			common.HexToHash("340dd630ad21bf010b4e676dbfa9ba9a02175262d1fa356232cfde6cb5b47ef2"),
 			common.HexToHash("426fcb404ab2d5d8e61a3d918108006bbb0a9be65e92235bb10eefbdb6dcd053"),
 			common.HexToHash("48078cfed56339ea54962e72c37c7f588fc4f8e5bc173827ba75cb10a63a96a5"),
@@ -181,9 +186,7 @@ func TestStorageRangeAt(t *testing.T) {
 		sdb.SetState(addr, *entry.Key, entry.Value)
 	}
 	root, _ := sdb.Commit(0, false)
-	sdb, _ = state.New(root, db, nil)
 
-	// Check a few combinations of limit and start/end.
 	tests := []struct {
 		start []byte
 		limit int
@@ -210,14 +213,83 @@ func TestStorageRangeAt(t *testing.T) {
 			want: StorageRangeResult{storageMap{keys[1]: storage[keys[1]], keys[2]: storage[keys[2]]}, &keys[3]},
 		},
 	}
-	for _, test := range tests {
-		result, err := storageRangeAt(sdb, root, addr, test.start, test.limit)
-		if err != nil {
-			t.Error(err)
-		}
-		if !reflect.DeepEqual(result, test.want) {
-			t.Fatalf("wrong result for range %#x.., limit %d:\ngot %s\nwant %s",
-				test.start, test.limit, dumper.Sdump(result), dumper.Sdump(&test.want))
+	// Check a few combinations of limit and start/end, both on the snapshot
+	// and on the trie the hash scheme falls back to.
+	for _, snaps := range []*snapshot.Tree{snaps, nil} {
+		sdb, _ = state.New(root, db, snaps)
+		for _, test := range tests {
+			result, err := storageRangeAt(sdb, addr, test.start, test.limit)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(result, test.want) {
+				t.Fatalf("wrong result for range %#x.., limit %d, snapshot %t:\ngot %s\nwant %s",
+					test.start, test.limit, snaps != nil, dumper.Sdump(result), dumper.Sdump(&test.want))
+			}
 		}
 	}
 }
+
+// TestStorageRangeAtPathScheme checks that storage ranges of intermediate
+// states on path-scheme databases are served from the snapshot, with the
+// uncommitted changes of the state applied.
+func TestStorageRangeAtPathScheme(t *testing.T) {
+	t.Parallel()
+
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		db       = state.NewDatabaseWithConfig(disk, &triedb.Config{Preimages: true, PathDB: pathdb.Defaults})
+		snaps, _ = snapshot.New(snapshot.Config{CacheSize: 10}, disk, db.TrieDB(), types.EmptyRootHash)
+		sdb, _   = state.New(types.EmptyRootHash, db, snaps)
+		addr     = common.Address{0x01}
+	)
+	for i := byte(1); i <= 4; i++ {
+		sdb.SetState(addr, common.Hash{i}, common.Hash{i})
+	}
+	root, _ := sdb.Commit(1, false)
+
+	// Build a newer state on top, so the storage root of the account in the
+	// latest layer differs from the one being traced.
+	sdb, _ = state.New(root, db, snaps)
+	sdb.SetState(addr, common.Hash{0x01}, common.Hash{0x11})
+	if _, err := sdb.Commit(2, false); err != nil {
+		t.Fatal(err)
+	}
+	// Storage ranges are served by the snapshot, path-scheme databases don't
+	// fall back to the storage trie.
+	sdb, _ = state.New(root, db, nil)
+	if _, err := storageRangeAt(sdb, addr, nil, 100); !errors.Is(err, state.ErrNoStorageSnapshot) {
+		t.Fatalf("unexpected error without snapshot: %v", err)
+	}
+	// Modify the original state without committing it, leaving changes
+	// flushed into the trie, in the pending and in the dirty storage.
+	sdb, _ = state.New(root, db, snaps)
+	sdb.SetState(addr, common.Hash{0x02}, common.Hash{0x22})
+	sdb.SetState(addr, common.Hash{0x03}, common.Hash{})
+	sdb.IntermediateRoot(false)
+	sdb.SetState(addr, common.Hash{0x04}, common.Hash{0x44})
+	sdb.Finalise(false)
+	sdb.SetState(addr, common.Hash{0x05}, common.Hash{0x05})
+
+	want := storageMap{}
+	for key, value := range map[common.Hash]common.Hash{
+		{0x01}: {0x01},
+		{0x02}: {0x22},
+		{0x04}: {0x44},
+		{0x05}: {0x05},
+	} {
+		key := key
+		want[crypto.Keccak256Hash(key[:])] = storageEntry{Key: &key, Value: value}
+	}
+	result, err := storageRangeAt(sdb, addr, nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, StorageRangeResult{want, nil}) {
+		t.Fatalf("wrong result:\ngot %s\nwant %s", dumper.Sdump(result), dumper.Sdump(want))
+	}
+	// The state itself must not be affected by the iteration
+	if have := sdb.GetState(addr, common.Hash{0x05}); have != (common.Hash{0x05}) {
+		t.Fatalf("state modified by storage range: have %x", have)
+	}
+}
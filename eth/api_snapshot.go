@@ -0,0 +1,247 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+)
+
+var errSnapshotDisabled = errors.New("state snapshot is disabled")
+
+// SnapshotAdminAPI exposes the state snapshot generation status and controls
+// in the admin namespace.
+type SnapshotAdminAPI struct {
+	chain *core.BlockChain
+}
+
+// NewSnapshotAdminAPI creates a new instance of SnapshotAdminAPI.
+func NewSnapshotAdminAPI(chain *core.BlockChain) *SnapshotAdminAPI {
+	return &SnapshotAdminAPI{chain: chain}
+}
+
+// SnapshotProgress is the RPC representation of the snapshot generation progress.
+type SnapshotProgress struct {
+	Root       common.Hash    `json:"root"`
+	Generating bool           `json:"generating"`
+	Marker     hexutil.Bytes  `json:"marker,omitempty"`
+	Accounts   hexutil.Uint64 `json:"accounts"`
+	Slots      hexutil.Uint64 `json:"slots"`
+	Dangling   hexutil.Uint64 `json:"dangling"`
+	Storage    hexutil.Uint64 `json:"storage"`
+	Elapsed    string         `json:"elapsed"`
+	ETA        string         `json:"eta,omitempty"`
+	RateLimit  hexutil.Uint64 `json:"rateLimit"`
+}
+
+// SnapshotProgress returns the progress of the state snapshot generation, as of
+// the last checkpoint of the generator.
+func (api *SnapshotAdminAPI) SnapshotProgress() (*SnapshotProgress, error) {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return nil, errSnapshotDisabled
+	}
+	progress, err := snaps.GeneratorProgress()
+	if err != nil {
+		return nil, err
+	}
+	result := &SnapshotProgress{
+		Root:       progress.Root,
+		Generating: progress.Generating,
+		Marker:     progress.Marker,
+		Accounts:   hexutil.Uint64(progress.Accounts),
+		Slots:      hexutil.Uint64(progress.Slots),
+		Dangling:   hexutil.Uint64(progress.Dangling),
+		Storage:    hexutil.Uint64(progress.Storage),
+		Elapsed:    common.PrettyDuration(progress.Elapsed).String(),
+		RateLimit:  hexutil.Uint64(progress.RateLimit),
+	}
+	if progress.ETA > 0 {
+		result.ETA = common.PrettyDuration(progress.ETA).String()
+	}
+	return result, nil
+}
+
+// SetSnapshotRateLimit limits the number of accounts and storage slots the
+// snapshot generator processes per second, zero removing the limit. The limit
+// applies immediately and lasts until the node is restarted.
+func (api *SnapshotAdminAPI) SetSnapshotRateLimit(rate hexutil.Uint64) error {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return errSnapshotDisabled
+	}
+	snaps.SetGeneratorRateLimit(uint64(rate))
+	return nil
+}
+
+// SnapshotDivergence is the RPC representation of a snapshot entry diverged from
+// the trie.
+type SnapshotDivergence struct {
+	Account  common.Hash  `json:"account"`
+	Slot     *common.Hash `json:"slot,omitempty"`
+	Kind     string       `json:"kind"`
+	Repaired bool         `json:"repaired"`
+}
+
+func newSnapshotDivergences(divergences []snapshot.Divergence) []SnapshotDivergence {
+	result := make([]SnapshotDivergence, 0, len(divergences))
+	for _, d := range divergences {
+		result = append(result, SnapshotDivergence{
+			Account:  d.Account,
+			Slot:     d.Slot,
+			Kind:     d.Kind,
+			Repaired: d.Repaired,
+		})
+	}
+	return result
+}
+
+// SnapshotVerifyResult is the RPC representation of a verified snapshot range.
+type SnapshotVerifyResult struct {
+	Root        common.Hash          `json:"root"`
+	Origin      common.Hash          `json:"origin"`
+	Next        *common.Hash         `json:"next,omitempty"`
+	NextSlot    *common.Hash         `json:"nextSlot,omitempty"`
+	Accounts    hexutil.Uint64       `json:"accounts"`
+	Slots       hexutil.Uint64       `json:"slots"`
+	Diverged    hexutil.Uint64       `json:"diverged"`
+	Repaired    hexutil.Uint64       `json:"repaired"`
+	Divergences []SnapshotDivergence `json:"divergences"`
+}
+
+// VerifySnapshotRange cross-checks up to limit entries of the flat snapshot,
+// accounts and storage slots alike, starting at origin, against the state trie.
+// If originSlot is set, only the storage of the origin account is resumed from
+// that slot. If repair is set, diverged snapshot entries are overwritten with
+// the trie content. The returned next hash is absent once the end of the state
+// is reached, the next slot is present if the range ended within a storage.
+func (api *SnapshotAdminAPI) VerifySnapshotRange(origin common.Hash, limit hexutil.Uint64, repair *bool, originSlot *common.Hash) (*SnapshotVerifyResult, error) {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return nil, errSnapshotDisabled
+	}
+	result, err := snaps.VerifyRange(origin, originSlot, int(limit), repair != nil && *repair)
+	if err != nil {
+		return nil, err
+	}
+	res := &SnapshotVerifyResult{
+		Root:        result.Root,
+		Origin:      result.Origin,
+		Accounts:    hexutil.Uint64(result.Accounts),
+		Slots:       hexutil.Uint64(result.Slots),
+		Diverged:    hexutil.Uint64(result.Diverged),
+		Repaired:    hexutil.Uint64(result.Repaired),
+		Divergences: newSnapshotDivergences(result.Divergences),
+	}
+	if !result.Done {
+		res.Next, res.NextSlot = &result.Next, result.NextSlot
+	}
+	return res, nil
+}
+
+// SnapshotVerifierConfig is the RPC representation of the background snapshot
+// verifier configuration.
+type SnapshotVerifierConfig struct {
+	Limit    *hexutil.Uint64 `json:"limit"`
+	Interval *string         `json:"interval"`
+	Repair   bool            `json:"repair"`
+}
+
+// StartSnapshotVerifier starts continuously verifying the flat snapshot against
+// the state trie in the background. By default 1000 entries are verified every
+// second, without repairing the diverged entries.
+func (api *SnapshotAdminAPI) StartSnapshotVerifier(config *SnapshotVerifierConfig) error {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return errSnapshotDisabled
+	}
+	conf := snapshot.VerifierConfig{
+		Limit:    1000,
+		Interval: time.Second,
+	}
+	if config != nil {
+		if config.Limit != nil {
+			conf.Limit = int(*config.Limit)
+		}
+		if config.Interval != nil {
+			interval, err := time.ParseDuration(*config.Interval)
+			if err != nil {
+				return err
+			}
+			conf.Interval = interval
+		}
+		conf.Repair = config.Repair
+	}
+	return snaps.StartVerifier(conf)
+}
+
+// StopSnapshotVerifier stops the background snapshot verifier.
+func (api *SnapshotAdminAPI) StopSnapshotVerifier() error {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return errSnapshotDisabled
+	}
+	snaps.StopVerifier()
+	return nil
+}
+
+// SnapshotVerifierStatus is the RPC representation of the background snapshot
+// verifier state.
+type SnapshotVerifierStatus struct {
+	Running    bool                 `json:"running"`
+	Limit      hexutil.Uint64       `json:"limit"`
+	Interval   string               `json:"interval"`
+	Repair     bool                 `json:"repair"`
+	Cursor     common.Hash          `json:"cursor"`
+	CursorSlot *common.Hash         `json:"cursorSlot,omitempty"`
+	Rounds     hexutil.Uint64       `json:"rounds"`
+	Accounts   hexutil.Uint64       `json:"accounts"`
+	Slots      hexutil.Uint64       `json:"slots"`
+	Diverged   hexutil.Uint64       `json:"diverged"`
+	Repaired   hexutil.Uint64       `json:"repaired"`
+	Recent     []SnapshotDivergence `json:"recent"`
+	LastError  string               `json:"lastError,omitempty"`
+}
+
+// SnapshotVerifierStatus returns the state of the background snapshot verifier.
+func (api *SnapshotAdminAPI) SnapshotVerifierStatus() (*SnapshotVerifierStatus, error) {
+	snaps := api.chain.Snapshots()
+	if snaps == nil {
+		return nil, errSnapshotDisabled
+	}
+	status := snaps.VerifierStatus()
+	return &SnapshotVerifierStatus{
+		Running:    status.Running,
+		Limit:      hexutil.Uint64(status.Config.Limit),
+		Interval:   status.Config.Interval.String(),
+		Repair:     status.Config.Repair,
+		Cursor:     status.Cursor,
+		CursorSlot: status.CursorSlot,
+		Rounds:     hexutil.Uint64(status.Rounds),
+		Accounts:   hexutil.Uint64(status.Accounts),
+		Slots:      hexutil.Uint64(status.Slots),
+		Diverged:   hexutil.Uint64(status.Diverged),
+		Repaired:   hexutil.Uint64(status.Repaired),
+		Recent:     newSnapshotDivergences(status.Recent),
+		LastError:  status.LastError,
+	}, nil
+}
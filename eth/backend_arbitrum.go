@@ -21,6 +21,14 @@ func NewArbEthereum(
 	}
 }
 
+// WithHistoricalState makes the states that are unavailable locally, and can't
+// be regenerated, be served from the given database instead. The database is
+// expected to be a state.NewHistoricalDatabase.
+func (eth *Ethereum) WithHistoricalState(db state.Database) *Ethereum {
+	eth.historicalState = db
+	return eth
+}
+
 func (eth *Ethereum) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*types.Transaction, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
 	return eth.stateAtTransaction(ctx, block, txIndex, reexec)
 }
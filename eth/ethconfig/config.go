@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
@@ -125,6 +126,9 @@ type Config struct {
 	SnapshotCache  int
 	Preimages      bool
 
+	// Arbitrum: configuration of the serving of the snap requests of the peers
+	SnapServe snap.ServeConfig
+
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
 
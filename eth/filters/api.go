@@ -250,6 +250,74 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
+// StateChanges creates a subscription that fires whenever an imported block
+// modifies one of the watched accounts or storage slots, and again with the
+// changes flagged as removed if the block is reorged out.
+func (api *FilterAPI) StateChanges(ctx context.Context, crit StateChangesCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if err := crit.validate(); err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := make(chan []*StateChange)
+		changesSub := api.events.SubscribeStateChanges(crit, changes)
+		defer changesSub.Unsubscribe()
+
+		for {
+			select {
+			case changes := <-changes:
+				for _, change := range changes {
+					notifier.Notify(rpcSub.ID, change)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// AccountLifecycle creates a subscription that fires whenever an imported block
+// creates, destructs, resurrects or replaces the code of a matching account,
+// and again with the events flagged as removed if the block is reorged out.
+func (api *FilterAPI) AccountLifecycle(ctx context.Context, crit AccountLifecycleCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if err := crit.validate(); err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		accounts := make(chan []*AccountLifecycleChange)
+		accountsSub := api.events.SubscribeAccountLifecycle(crit, accounts)
+		defer accountsSub.Unsubscribe()
+
+		for {
+			select {
+			case changes := <-accounts:
+				for _, change := range changes {
+					notifier.Notify(rpcSub.ID, change)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -365,6 +433,61 @@ func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*type
 	return returnLogs(logs), err
 }
 
+// GetLogsPage returns a page of the logs matching the given criteria. Unlike
+// GetLogs, the query doesn't fail when the result is too large or too long to
+// collect: the page is cut short once the limit of logs or the search time is
+// reached, and a cursor is returned to resume from with the same criteria. The
+// cursor is null once the range was fully searched.
+//
+// The end of the range is resolved by the first page, so that the later ones
+// search the same blocks. A cursor fails if the chain reorged past it.
+func (api *FilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes, limit *hexutil.Uint) (*LogsPage, error) {
+	if len(crit.Topics) > maxTopics {
+		return nil, errExceedMaxTopics
+	}
+	size := api.sys.cfg.LogPageSize
+	if limit != nil && *limit > 0 && int(*limit) < size {
+		size = int(*limit)
+	}
+	// Block filters return all the logs of the block at once
+	if crit.BlockHash != nil {
+		logs, err := api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &LogsPage{Logs: returnLogs(logs)}, nil
+	}
+	var resume *logsCursor
+	if cursor != nil {
+		var err error
+		if resume, err = decodeLogsCursor(*cursor); err != nil {
+			return nil, err
+		}
+	}
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	if begin > 0 && end > 0 && begin > end {
+		return nil, errInvalidBlockRange
+	}
+	filter := api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+	logs, next, err := filter.logsPage(ctx, resume, size, api.sys.cfg.LogPageTimeout)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogsPage{Logs: returnLogs(logs)}
+	if next != nil {
+		encoded := next.encode()
+		page.Cursor = &encoded
+	}
+	return page, nil
+}
+
 // UninstallFilter removes the filter with the given filter id.
 func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Lock()
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -108,9 +109,27 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		return f.blockLogs(ctx, header)
 	}
 
+	if err := f.resolveRange(ctx); err != nil {
+		return nil, err
+	}
+	logChan, errChan := f.rangeLogsAsync(ctx)
+	var logs []*types.Log
+	for {
+		select {
+		case log := <-logChan:
+			logs = append(logs, log)
+		case err := <-errChan:
+			return logs, err
+		}
+	}
+}
+
+// resolveRange resolves the special begin and end block numbers of a range
+// filter into actual block numbers.
+func (f *Filter) resolveRange(ctx context.Context) error {
 	// Disallow pending logs.
 	if f.begin == rpc.PendingBlockNumber.Int64() || f.end == rpc.PendingBlockNumber.Int64() {
-		return nil, errPendingLogsUnsupported
+		return errPendingLogsUnsupported
 	}
 
 	resolveSpecial := func(number int64) (int64, error) {
@@ -142,22 +161,12 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	var err error
 	// range query need to resolve the special begin/end block number
 	if f.begin, err = resolveSpecial(f.begin); err != nil {
-		return nil, err
+		return err
 	}
 	if f.end, err = resolveSpecial(f.end); err != nil {
-		return nil, err
-	}
-
-	logChan, errChan := f.rangeLogsAsync(ctx)
-	var logs []*types.Log
-	for {
-		select {
-		case log := <-logChan:
-			logs = append(logs, log)
-		case err := <-errChan:
-			return logs, err
-		}
+		return err
 	}
+	return nil
 }
 
 // rangeLogsAsync retrieves block-range logs that match the filter criteria asynchronously,
@@ -174,23 +183,27 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 			close(logChan)
 		}()
 
-		// Gather all indexed logs, and finish with non indexed ones
-		var (
-			end            = uint64(f.end)
-			size, sections = f.sys.backend.BloomStatus()
-			err            error
-		)
-		if indexed := sections * size; indexed > uint64(f.begin) {
-			if indexed > end {
-				indexed = end + 1
+		end := uint64(f.end)
+
+		// Arbitrum: use the exact log index over the range it covers, falling
+		// back to the bloombits before and after it
+		if tail, head, ok := f.logIndexRange(); ok && uint64(f.begin) <= end && tail <= end && head >= uint64(f.begin) {
+			if tail > uint64(f.begin) {
+				if err := f.bloomLogs(ctx, tail-1, logChan); err != nil {
+					errChan <- err
+					return
+				}
 			}
-			if err = f.indexedLogs(ctx, indexed-1, logChan); err != nil {
+			if err := f.logIndexLogs(ctx, min(head, end), logChan); err != nil {
 				errChan <- err
 				return
 			}
+			if uint64(f.begin) > end {
+				errChan <- nil
+				return
+			}
 		}
-
-		if err := f.unindexedLogs(ctx, end, logChan); err != nil {
+		if err := f.bloomLogs(ctx, end, logChan); err != nil {
 			errChan <- err
 			return
 		}
@@ -201,6 +214,22 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 	return logChan, errChan
 }
 
+// bloomLogs retrieves the logs matching the filter criteria up to the given
+// block, first from the bloombits indexed sections, then by raw block iteration.
+func (f *Filter) bloomLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	// Gather all indexed logs, and finish with non indexed ones
+	size, sections := f.sys.backend.BloomStatus()
+	if indexed := sections * size; indexed > uint64(f.begin) {
+		if indexed > end {
+			indexed = end + 1
+		}
+		if err := f.indexedLogs(ctx, indexed-1, logChan); err != nil {
+			return err
+		}
+	}
+	return f.unindexedLogs(ctx, end, logChan)
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
@@ -226,8 +255,6 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 				}
 				return err
 			}
-			f.begin = int64(number) + 1
-
 			// Retrieve the suggested block and pull any truly matching logs
 			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
 			if header == nil || err != nil {
@@ -240,6 +267,9 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 			for _, log := range found {
 				logChan <- log
 			}
+			// Arbitrum: only move past the block once its logs are delivered,
+			// so that paginated queries can resume from it
+			f.begin = int64(number) + 1
 
 		case <-ctx.Done():
 			return ctx.Err()
@@ -247,6 +277,95 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 	}
 }
 
+// logIndexRange returns the range of blocks covered by the exact log index, if
+// any and if the filter has address or topic criteria it can serve.
+func (f *Filter) logIndexRange() (uint64, uint64, bool) {
+	if len(f.addresses) == 0 && !slices.ContainsFunc(f.topics, func(topics []common.Hash) bool { return len(topics) > 0 }) {
+		return 0, 0, false
+	}
+	tail, head, ok := rawdb.ReadLogIndexRange(f.sys.backend.ChainDb())
+	return tail, head, ok && tail <= head
+}
+
+// logIndexLogs returns the logs matching the filter criteria up to the given
+// block based on the exact log index, only checking the blocks with entries
+// for all the criteria.
+func (f *Filter) logIndexLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	numbers, err := f.logIndexCandidates(uint64(f.begin), end)
+	if err != nil {
+		return err
+	}
+	for _, number := range numbers {
+		header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if header == nil || err != nil {
+			return err
+		}
+		found, err := f.checkMatches(ctx, header)
+		if err != nil {
+			return err
+		}
+		for _, log := range found {
+			select {
+			case logChan <- log:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		f.begin = int64(number) + 1
+	}
+	f.begin = int64(end) + 1
+	return nil
+}
+
+// logIndexCandidates returns the numbers of the blocks in the given range with
+// log index entries matching every criteria of the filter: any of the addresses
+// and, for each topic position, any of the topics.
+func (f *Filter) logIndexCandidates(begin, end uint64) ([]uint64, error) {
+	var (
+		db         = f.sys.backend.ChainDb()
+		candidates []uint64
+		criteria   int
+	)
+	intersect := func(numbers []uint64) {
+		slices.Sort(numbers)
+		numbers = slices.Compact(numbers)
+		if criteria++; criteria == 1 {
+			candidates = numbers
+			return
+		}
+		candidates = slices.DeleteFunc(candidates, func(number uint64) bool {
+			_, found := slices.BinarySearch(numbers, number)
+			return !found
+		})
+	}
+	if len(f.addresses) > 0 {
+		var numbers []uint64
+		for _, addr := range f.addresses {
+			matches, err := rawdb.ReadLogIndexAddress(db, addr, begin, end)
+			if err != nil {
+				return nil, err
+			}
+			numbers = append(numbers, matches...)
+		}
+		intersect(numbers)
+	}
+	for _, topics := range f.topics {
+		if len(topics) == 0 {
+			continue
+		}
+		var numbers []uint64
+		for _, topic := range topics {
+			matches, err := rawdb.ReadLogIndexTopic(db, topic, begin, end)
+			if err != nil {
+				return nil, err
+			}
+			numbers = append(numbers, matches...)
+		}
+		intersect(numbers)
+	}
+	return candidates, nil
+}
+
 // unindexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
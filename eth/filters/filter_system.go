@@ -42,6 +42,10 @@ import (
 type Config struct {
 	LogCacheSize int           // maximum number of cached blocks (default: 32)
 	Timeout      time.Duration // how long filters stay active (default: 5min)
+
+	// Arbitrum: limits of the pages of eth_getLogsPage
+	LogPageSize    int           // maximum number of logs per page (default: 10000)
+	LogPageTimeout time.Duration // time spent searching logs per page (default: 5s)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -51,6 +55,12 @@ func (cfg Config) withDefaults() Config {
 	if cfg.LogCacheSize == 0 {
 		cfg.LogCacheSize = 32
 	}
+	if cfg.LogPageSize == 0 {
+		cfg.LogPageSize = 10000
+	}
+	if cfg.LogPageTimeout == 0 {
+		cfg.LogPageTimeout = 5 * time.Second
+	}
 	return cfg
 }
 
@@ -68,6 +78,8 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription
+	SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -154,6 +166,12 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// StateChangesSubscription queries for changes of watched accounts and
+	// storage slots in imported blocks
+	StateChangesSubscription
+	// AccountLifecycleSubscription queries for accounts created, destructed,
+	// resurrected or given new code in imported blocks
+	AccountLifecycleSubscription
 	// LastIndexSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -168,6 +186,10 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// stateChangesChanSize is the size of channel listening to StateChangesEvent.
+	stateChangesChanSize = 10
+	// lifecycleChanSize is the size of channel listening to AccountLifecycleEvent.
+	lifecycleChanSize = 10
 )
 
 type subscription struct {
@@ -175,9 +197,13 @@ type subscription struct {
 	typ       Type
 	created   time.Time
 	logsCrit  ethereum.FilterQuery
+	stateCrit StateChangesCriteria
+	lifeCrit  AccountLifecycleCriteria
 	logs      chan []*types.Log
 	txs       chan []*types.Transaction
 	headers   chan *types.Header
+	changes   chan []*StateChange
+	accounts  chan []*AccountLifecycleChange
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -193,14 +219,18 @@ type EventSystem struct {
 	logsSub   event.Subscription // Subscription for new log event
 	rmLogsSub event.Subscription // Subscription for removed log event
 	chainSub  event.Subscription // Subscription for new chain event
+	stateSub  event.Subscription // Subscription for state changes event
+	lifeSub   event.Subscription // Subscription for account lifecycle event
 
 	// Channels
-	install   chan *subscription         // install filter for event notification
-	uninstall chan *subscription         // remove filter for event notification
-	txsCh     chan core.NewTxsEvent      // Channel to receive new transactions event
-	logsCh    chan []*types.Log          // Channel to receive new log event
-	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
-	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	install   chan *subscription              // install filter for event notification
+	uninstall chan *subscription              // remove filter for event notification
+	txsCh     chan core.NewTxsEvent           // Channel to receive new transactions event
+	logsCh    chan []*types.Log               // Channel to receive new log event
+	rmLogsCh  chan core.RemovedLogsEvent      // Channel to receive removed log event
+	chainCh   chan core.ChainEvent            // Channel to receive new chain event
+	stateCh   chan core.StateChangesEvent     // Channel to receive state changes event
+	lifeCh    chan core.AccountLifecycleEvent // Channel to receive account lifecycle event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -219,6 +249,8 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 		logsCh:    make(chan []*types.Log, logsChanSize),
 		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+		stateCh:   make(chan core.StateChangesEvent, stateChangesChanSize),
+		lifeCh:    make(chan core.AccountLifecycleEvent, lifecycleChanSize),
 	}
 
 	// Subscribe events
@@ -226,9 +258,11 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.stateSub = m.backend.SubscribeStateChangesEvent(m.stateCh)
+	m.lifeSub = m.backend.SubscribeAccountLifecycleEvent(m.lifeCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.stateSub == nil || m.lifeSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -264,6 +298,8 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.txs:
 			case <-sub.f.headers:
+			case <-sub.f.changes:
+			case <-sub.f.accounts:
 			}
 		}
 
@@ -331,6 +367,8 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
+		changes:   make(chan []*StateChange),
+		accounts:  make(chan []*AccountLifecycleChange),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -347,6 +385,8 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		txs:       make(chan []*types.Transaction),
 		headers:   headers,
+		changes:   make(chan []*StateChange),
+		accounts:  make(chan []*AccountLifecycleChange),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -363,6 +403,46 @@ func (es *EventSystem) SubscribePendingTxs(txs chan []*types.Transaction) *Subsc
 		logs:      make(chan []*types.Log),
 		txs:       txs,
 		headers:   make(chan *types.Header),
+		changes:   make(chan []*StateChange),
+		accounts:  make(chan []*AccountLifecycleChange),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeStateChanges creates a subscription that writes the changes of the
+// watched accounts and storage slots committed by imported blocks.
+func (es *EventSystem) SubscribeStateChanges(crit StateChangesCriteria, changes chan []*StateChange) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       StateChangesSubscription,
+		stateCrit: crit,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		txs:       make(chan []*types.Transaction),
+		headers:   make(chan *types.Header),
+		changes:   changes,
+		accounts:  make(chan []*AccountLifecycleChange),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeAccountLifecycle creates a subscription that writes the lifecycle
+// changes of the matching accounts committed by imported blocks.
+func (es *EventSystem) SubscribeAccountLifecycle(crit AccountLifecycleCriteria, accounts chan []*AccountLifecycleChange) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       AccountLifecycleSubscription,
+		lifeCrit:  crit,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		txs:       make(chan []*types.Transaction),
+		headers:   make(chan *types.Header),
+		changes:   make(chan []*StateChange),
+		accounts:  accounts,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -395,6 +475,22 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 }
 
+func (es *EventSystem) handleStateChangesEvent(filters filterIndex, ev core.StateChangesEvent) {
+	for _, f := range filters[StateChangesSubscription] {
+		if changes := filterStateChanges(ev.Header, ev.Changes, ev.Removed, f.stateCrit); len(changes) > 0 {
+			f.changes <- changes
+		}
+	}
+}
+
+func (es *EventSystem) handleAccountLifecycleEvent(filters filterIndex, ev core.AccountLifecycleEvent) {
+	for _, f := range filters[AccountLifecycleSubscription] {
+		if changes := filterAccountLifecycle(ev.Header, ev.Events, ev.Removed, f.lifeCrit); len(changes) > 0 {
+			f.accounts <- changes
+		}
+	}
+}
+
 // eventLoop (un)installs filters and processes mux events.
 func (es *EventSystem) eventLoop() {
 	// Ensure all subscriptions get cleaned up
@@ -403,6 +499,8 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.stateSub.Unsubscribe()
+		es.lifeSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -420,6 +518,10 @@ func (es *EventSystem) eventLoop() {
 			es.handleLogs(index, ev.Logs)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.stateCh:
+			es.handleStateChangesEvent(index, ev)
+		case ev := <-es.lifeCh:
+			es.handleAccountLifecycleEvent(index, ev)
 
 		case f := <-es.install:
 			index[f.typ][f.id] = f
@@ -438,6 +540,10 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.stateSub.Err():
+			return
+		case <-es.lifeSub.Err():
+			return
 		}
 	}
 }
@@ -27,16 +27,20 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
 )
 
 type testBackend struct {
@@ -46,6 +50,8 @@ type testBackend struct {
 	logsFeed        event.Feed
 	rmLogsFeed      event.Feed
 	chainFeed       event.Feed
+	stateFeed       event.Feed
+	lifecycleFeed   event.Feed
 	pendingBlock    *types.Block
 	pendingReceipts types.Receipts
 }
@@ -129,6 +135,14 @@ func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent)
 	return b.rmLogsFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription {
+	return b.stateFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription {
+	return b.lifecycleFeed.Subscribe(ch)
+}
+
 func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return b.logsFeed.Subscribe(ch)
 }
@@ -238,6 +252,127 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestStateChangesSubscription tests that a state changes subscription only
+// reports actual modifications of the watched accounts and slots.
+func TestStateChangesSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+
+		watched   = common.HexToAddress("0x1111")
+		unwatched = common.HexToAddress("0x2222")
+		slot      = common.HexToHash("0x01")
+		other     = common.HexToHash("0x02")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	commit := func(number int64) core.StateChangesEvent {
+		if _, err := statedb.Commit(uint64(number), true); err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		return core.StateChangesEvent{Header: &types.Header{Number: big.NewInt(number)}, Changes: statedb.CommittedChanges()}
+	}
+	// Block 1 modifies the watched account and slots
+	statedb.SetBalance(watched, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+	statedb.SetNonce(watched, 1)
+	statedb.SetState(watched, slot, common.HexToHash("0xff"))
+	statedb.SetState(watched, other, common.HexToHash("0xee"))
+	statedb.SetBalance(unwatched, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	block1 := commit(1)
+
+	// Block 2 only touches the watched account without modifying it
+	statedb.AddBalance(watched, new(uint256.Int), tracing.BalanceChangeUnspecified)
+	block2 := commit(2)
+
+	// Block 3 clears the watched slot
+	statedb.SetState(watched, slot, common.Hash{})
+	block3 := commit(3)
+
+	changes := make(chan []*StateChange)
+	sub := api.events.SubscribeStateChanges(StateChangesCriteria{Addresses: []common.Address{watched}, Slots: []common.Hash{slot}}, changes)
+	defer sub.Unsubscribe()
+
+	for _, ev := range []core.StateChangesEvent{block1, block2, block3} {
+		backend.stateFeed.Send(ev)
+	}
+	want := []*StateChange{
+		{
+			BlockNumber: 1,
+			BlockHash:   block1.Header.Hash(),
+			Address:     watched,
+			Balance:     (*hexutil.Big)(big.NewInt(100)),
+			Nonce:       func() *hexutil.Uint64 { n := hexutil.Uint64(1); return &n }(),
+			CodeHash:    &types.EmptyCodeHash,
+			Storage:     map[common.Hash]common.Hash{slot: common.HexToHash("0xff")},
+		},
+		{
+			BlockNumber: 3,
+			BlockHash:   block3.Header.Hash(),
+			Address:     watched,
+			Storage:     map[common.Hash]common.Hash{slot: {}},
+		},
+	}
+	for i, want := range want {
+		select {
+		case have := <-changes:
+			if len(have) != 1 {
+				t.Fatalf("change %d: expected a single change, got %d", i, len(have))
+			}
+			if !reflect.DeepEqual(have[0], want) {
+				t.Fatalf("change %d mismatch: have %+v, want %+v", i, have[0], want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("change %d: timeout", i)
+		}
+	}
+}
+
+// TestAccountLifecycleSubscription tests that an account lifecycle subscription
+// only reports the events matching its criteria.
+func TestAccountLifecycleSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+
+		watched   = common.HexToAddress("0x1111")
+		unwatched = common.HexToAddress("0x2222")
+		header    = &types.Header{Number: big.NewInt(1)}
+	)
+	accounts := make(chan []*AccountLifecycleChange)
+	sub := api.events.SubscribeAccountLifecycle(AccountLifecycleCriteria{
+		Addresses: []common.Address{watched},
+		Types:     []state.AccountEventType{state.AccountDestructed},
+	}, accounts)
+	defer sub.Unsubscribe()
+
+	backend.lifecycleFeed.Send(core.AccountLifecycleEvent{Header: header, Events: []state.AccountEvent{
+		{Type: state.AccountCreated, Address: watched},
+		{Type: state.AccountDestructed, Address: watched, PrevCodeHash: common.Hash{1}},
+		{Type: state.AccountDestructed, Address: unwatched},
+	}})
+	want := []*AccountLifecycleChange{{
+		BlockNumber:  1,
+		BlockHash:    header.Hash(),
+		AccountEvent: state.AccountEvent{Type: state.AccountDestructed, Address: watched, PrevCodeHash: common.Hash{1}},
+	}}
+	select {
+	case have := <-accounts:
+		if !reflect.DeepEqual(have, want) {
+			t.Fatalf("lifecycle change mismatch: have %+v, want %+v", have, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	if err := (AccountLifecycleCriteria{Types: []state.AccountEventType{"unknown"}}).validate(); err == nil {
+		t.Fatal("unknown event type accepted")
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
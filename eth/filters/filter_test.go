@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -387,3 +388,153 @@ func TestFilters(t *testing.T) {
 		}
 	})
 }
+
+// Tests that range filters use the exact log index over the blocks it covers,
+// and the bloom filters before and after them.
+func TestFiltersLogIndex(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{})
+		addr1  = common.HexToAddress("0x1")
+		addr2  = common.HexToAddress("0x2")
+		topicA = common.HexToHash("0xa")
+		topicB = common.HexToHash("0xb")
+		gspec  = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+		emitted = map[int]*types.Log{
+			3:  {Address: addr1, Topics: []common.Hash{topicA}}, // before the index
+			8:  {Address: addr1, Topics: []common.Hash{topicA}},
+			10: {Address: addr1, Topics: []common.Hash{topicB}},
+			12: {Address: addr2, Topics: []common.Hash{topicA}},
+			15: {Address: addr1, Topics: []common.Hash{topicA}}, // after the index
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 20, func(i int, gen *core.BlockGen) {
+		if log, ok := emitted[i+1]; ok {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{log}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+
+		// Index blocks 5 to 12, leaving block 10 out to check the index is used
+		if number := block.NumberU64(); number >= 5 && number <= 12 && number != 10 {
+			rawdb.WriteLogIndexEntries(db, number, receipts[i])
+		}
+	}
+	rawdb.WriteLogIndexTail(db, 5)
+	rawdb.WriteLogIndexHead(db, 12)
+
+	for i, tc := range []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       []uint64
+	}{
+		{0, int64(rpc.LatestBlockNumber), []common.Address{addr1}, [][]common.Hash{{topicA}}, []uint64{3, 8, 15}},
+		{0, int64(rpc.LatestBlockNumber), []common.Address{addr1, addr2}, nil, []uint64{3, 8, 12, 15}},
+		{0, int64(rpc.LatestBlockNumber), nil, [][]common.Hash{{topicA}}, []uint64{3, 8, 12, 15}},
+		{6, 12, []common.Address{addr2}, [][]common.Hash{{topicA, topicB}}, []uint64{12}},
+		{9, 11, []common.Address{addr1}, nil, nil},
+		{13, 20, []common.Address{addr1}, nil, []uint64{15}},
+	} {
+		logs, err := sys.NewRangeFilter(tc.begin, tc.end, tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		var have []uint64
+		for _, log := range logs {
+			have = append(have, log.BlockNumber)
+		}
+		if len(have) != len(tc.want) {
+			t.Fatalf("test %d: logs mismatch: have blocks %v, want %v", i, have, tc.want)
+		}
+		for j := range have {
+			if have[j] != tc.want[j] {
+				t.Fatalf("test %d: logs mismatch: have blocks %v, want %v", i, have, tc.want)
+			}
+		}
+	}
+}
+
+// Tests that paginated log queries return all the logs over the pages, resuming
+// within blocks, and that cursors are invalidated by reorgs.
+func TestGetLogsPage(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{LogPageSize: 4})
+		api    = NewFilterAPI(sys)
+		addr   = common.HexToAddress("0x1")
+		gspec  = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, gen *core.BlockGen) {
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{Address: addr}, {Address: addr}, {Address: addr}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	crit := FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}}
+	want, err := api.GetLogs(context.Background(), crit)
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	var (
+		have   []*types.Log
+		cursor *hexutil.Bytes
+		pages  int
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit, cursor, nil)
+		if err != nil {
+			t.Fatalf("failed to get logs page %d: %v", pages, err)
+		}
+		if len(page.Logs) > 4 {
+			t.Fatalf("page %d exceeds the limit: %d logs", pages, len(page.Logs))
+		}
+		have = append(have, page.Logs...)
+		pages++
+		if page.Cursor == nil {
+			break
+		}
+		cursor = page.Cursor
+	}
+	if len(have) != 30 || pages != 8 {
+		t.Fatalf("unexpected pagination: have %d logs over %d pages, want 30 over 8", len(have), pages)
+	}
+	for i := range have {
+		if have[i].BlockNumber != want[i].BlockNumber || have[i].Index != want[i].Index {
+			t.Fatalf("log %d mismatch: have %d/%d, want %d/%d", i, have[i].BlockNumber, have[i].Index, want[i].BlockNumber, want[i].Index)
+		}
+	}
+	// Cursors pointing to a block reorged out are rejected
+	page, err := api.GetLogsPage(context.Background(), crit, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get logs page: %v", err)
+	}
+	reorged := append(hexutil.Bytes{}, *page.Cursor...)
+	reorged[8] ^= 0xff
+	if _, err := api.GetLogsPage(context.Background(), crit, &reorged, nil); err != errLogsCursorReorged {
+		t.Fatalf("reorged cursor error mismatch: have %v, want %v", err, errLogsCursorReorged)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errTooManyLifecycleAddresses = errors.New("too many addresses to watch")
+
+// AccountLifecycleCriteria selects the account lifecycle events reported by a
+// subscription. Empty fields match everything.
+type AccountLifecycleCriteria struct {
+	Addresses []common.Address         `json:"addresses"`
+	Types     []state.AccountEventType `json:"types"`
+}
+
+func (crit AccountLifecycleCriteria) validate() error {
+	if len(crit.Addresses) > maxWatchedAddresses {
+		return errTooManyLifecycleAddresses
+	}
+	for _, typ := range crit.Types {
+		switch typ {
+		case state.AccountCreated, state.AccountDestructed, state.AccountResurrected, state.AccountCodeChanged:
+		default:
+			return fmt.Errorf("unknown account event type %q", typ)
+		}
+	}
+	return nil
+}
+
+// AccountLifecycleChange is an account lifecycle event along with the block
+// that committed it. The event is notified again with removed set if the block
+// is reorged out.
+type AccountLifecycleChange struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Removed     bool           `json:"removed,omitempty"`
+	state.AccountEvent
+}
+
+// filterAccountLifecycle returns the lifecycle events of the given block that
+// match the criteria, flagged as removed if the block was reorged out.
+func filterAccountLifecycle(header *types.Header, events []state.AccountEvent, removed bool, crit AccountLifecycleCriteria) []*AccountLifecycleChange {
+	var ret []*AccountLifecycleChange
+	for _, event := range events {
+		if len(crit.Addresses) > 0 && !slices.Contains(crit.Addresses, event.Address) {
+			continue
+		}
+		if len(crit.Types) > 0 && !slices.Contains(crit.Types, event.Type) {
+			continue
+		}
+		ret = append(ret, &AccountLifecycleChange{
+			BlockNumber:  hexutil.Uint64(header.Number.Uint64()),
+			BlockHash:    header.Hash(),
+			Removed:      removed,
+			AccountEvent: event,
+		})
+	}
+	return ret
+}
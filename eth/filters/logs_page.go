@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errInvalidLogsCursor = errors.New("invalid logs cursor")
+	errLogsCursorReorged = errors.New("logs cursor invalidated by a chain reorg")
+)
+
+// logsCursorLength is the length of an encoded logs cursor: the number and hash
+// of the block to resume from, the index of the first log to return from it,
+// and the number of the last block of the range.
+const logsCursorLength = 8 + common.HashLength + 4 + 8
+
+// LogsPage is a page of the logs matching a filter. The cursor, if not null, is
+// to be passed back with the same criteria to retrieve the next page.
+type LogsPage struct {
+	Logs   []*types.Log   `json:"logs"`
+	Cursor *hexutil.Bytes `json:"cursor"`
+}
+
+// logsCursor is the position of the next log to return in a paginated query.
+type logsCursor struct {
+	number uint64      // Number of the block to resume from
+	hash   common.Hash // Hash of the block to resume from, to detect reorgs
+	index  uint        // Index of the first log of the block to return
+	end    uint64      // Last block of the range, resolved by the first page
+}
+
+func (c *logsCursor) encode() hexutil.Bytes {
+	buf := make([]byte, 0, logsCursorLength)
+	buf = binary.BigEndian.AppendUint64(buf, c.number)
+	buf = append(buf, c.hash.Bytes()...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(c.index))
+	return binary.BigEndian.AppendUint64(buf, c.end)
+}
+
+func decodeLogsCursor(data []byte) (*logsCursor, error) {
+	if len(data) != logsCursorLength {
+		return nil, errInvalidLogsCursor
+	}
+	c := &logsCursor{
+		number: binary.BigEndian.Uint64(data),
+		hash:   common.BytesToHash(data[8 : 8+common.HashLength]),
+		index:  uint(binary.BigEndian.Uint32(data[8+common.HashLength:])),
+		end:    binary.BigEndian.Uint64(data[8+common.HashLength+4:]),
+	}
+	if c.number > c.end {
+		return nil, errInvalidLogsCursor
+	}
+	return c, nil
+}
+
+// logsPage searches the range for matching log entries like Logs, resuming from
+// the given cursor if any, but stops once more than the given number of logs
+// are found or the time budget expires. It returns the logs found along with
+// the cursor of the next page, nil if the range was exhausted.
+func (f *Filter) logsPage(ctx context.Context, resume *logsCursor, limit int, budget time.Duration) ([]*types.Log, *logsCursor, error) {
+	if resume != nil {
+		header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(resume.number))
+		if err != nil {
+			return nil, nil, err
+		}
+		if header == nil || header.Hash() != resume.hash {
+			return nil, nil, errLogsCursorReorged
+		}
+		f.begin, f.end = int64(resume.number), int64(resume.end)
+	} else if err := f.resolveRange(ctx); err != nil {
+		return nil, nil, err
+	}
+	var (
+		pageCtx context.Context
+		cancel  context.CancelFunc
+	)
+	if budget > 0 {
+		pageCtx, cancel = context.WithTimeout(ctx, budget)
+	} else {
+		pageCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	var (
+		logChan, errChan = f.rangeLogsAsync(pageCtx)
+		logs             []*types.Log
+		next             *types.Log // First log not returned, if the limit was hit
+		err              error
+	)
+	for done := false; !done; {
+		select {
+		case log := <-logChan:
+			if resume != nil && log.BlockNumber == resume.number && log.Index < resume.index {
+				continue
+			}
+			if next != nil {
+				continue // draining after the limit was hit
+			}
+			if len(logs) == limit {
+				next = log
+				cancel()
+				continue
+			}
+			logs = append(logs, log)
+		case err = <-errChan:
+			done = true
+		}
+	}
+	// The block range was exhausted
+	if err == nil && next == nil {
+		return logs, nil, nil
+	}
+	// Fail if the request was aborted, or the search failed on its own
+	if ctx.Err() != nil || (next == nil && pageCtx.Err() == nil) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, nil, err
+	}
+	// The page was cut short by the limit or the time budget, resume right
+	// after the last log returned or the last block fully scanned
+	cursor := &logsCursor{number: uint64(f.begin), end: uint64(f.end)}
+	switch {
+	case next != nil:
+		cursor.number, cursor.index = next.BlockNumber, next.Index
+	case len(logs) > 0 && logs[len(logs)-1].BlockNumber >= cursor.number:
+		last := logs[len(logs)-1]
+		cursor.number, cursor.index = last.BlockNumber, last.Index+1
+	}
+	if cursor.number > cursor.end {
+		return logs, nil, nil
+	}
+	header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(cursor.number))
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errLogsCursorReorged
+	}
+	cursor.hash = header.Hash()
+	return logs, cursor, nil
+}
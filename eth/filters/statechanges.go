@@ -0,0 +1,118 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxWatchedAddresses is the maximum number of accounts a single state changes
+// subscription may watch.
+const maxWatchedAddresses = 1000
+
+var (
+	errNoWatchedAddresses      = errors.New("no addresses to watch")
+	errTooManyWatchedAddresses = errors.New("too many addresses to watch")
+)
+
+// StateChangesCriteria selects the accounts and storage slots watched by a
+// state changes subscription. The slots are watched in every listed account.
+type StateChangesCriteria struct {
+	Addresses []common.Address `json:"addresses"`
+	Slots     []common.Hash    `json:"slots"`
+}
+
+func (crit StateChangesCriteria) validate() error {
+	if len(crit.Addresses) == 0 {
+		return errNoWatchedAddresses
+	}
+	if len(crit.Addresses) > maxWatchedAddresses {
+		return errTooManyWatchedAddresses
+	}
+	return nil
+}
+
+// StateChange describes how a watched account was modified by a block. The
+// account fields hold the post-block values and are only set if the account
+// itself changed; storage only contains the watched slots that changed. The
+// change is notified again with removed set if the block is reorged out.
+type StateChange struct {
+	BlockNumber hexutil.Uint64              `json:"blockNumber"`
+	BlockHash   common.Hash                 `json:"blockHash"`
+	Address     common.Address              `json:"address"`
+	Removed     bool                        `json:"removed,omitempty"`
+	Deleted     bool                        `json:"deleted,omitempty"`
+	Balance     *hexutil.Big                `json:"balance,omitempty"`
+	Nonce       *hexutil.Uint64             `json:"nonce,omitempty"`
+	CodeHash    *common.Hash                `json:"codeHash,omitempty"`
+	Storage     map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// filterStateChanges returns the changes of the watched accounts and slots
+// contained in the state changes committed by the given block, flagged as
+// removed if the block was reorged out.
+func filterStateChanges(header *types.Header, changes *state.StateChanges, removed bool, crit StateChangesCriteria) []*StateChange {
+	var ret []*StateChange
+	for _, addr := range crit.Addresses {
+		account, modified, err := changes.Account(addr)
+		if err != nil {
+			log.Warn("Failed to decode changed account", "block", header.Number, "address", addr, "err", err)
+			continue
+		}
+		change := &StateChange{
+			BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+			BlockHash:   header.Hash(),
+			Address:     addr,
+			Removed:     removed,
+		}
+		if modified {
+			if account == nil {
+				change.Deleted = true
+			} else {
+				nonce := hexutil.Uint64(account.Nonce)
+				codeHash := common.BytesToHash(account.CodeHash)
+				change.Balance = (*hexutil.Big)(account.Balance.ToBig())
+				change.Nonce = &nonce
+				change.CodeHash = &codeHash
+			}
+		}
+		for _, slot := range crit.Slots {
+			value, modified, err := changes.Storage(addr, slot)
+			if err != nil {
+				log.Warn("Failed to decode changed storage slot", "block", header.Number, "address", addr, "slot", slot, "err", err)
+				continue
+			}
+			if !modified {
+				continue
+			}
+			if change.Storage == nil {
+				change.Storage = make(map[common.Hash]common.Hash)
+			}
+			change.Storage[slot] = value
+		}
+		if modified || len(change.Storage) > 0 {
+			ret = append(ret, change)
+		}
+	}
+	return ret
+}
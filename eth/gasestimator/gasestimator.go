@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
@@ -50,6 +51,9 @@ type Options struct {
 	RunScheduledTxes func(context.Context, core.NodeInterfaceBackendAPI, *state.StateDB, *types.Header, vm.BlockContext, core.MessageRunMode, *core.ExecutionResult) (*core.ExecutionResult, error)
 
 	ErrorRatio float64 // Allowed overestimation ratio for faster estimation termination
+
+	// Arbitrum: tracer attached to every execution of the estimation, if any
+	Tracer *tracing.Hooks
 }
 
 // Estimate returns the lowest possible gas limit that allows the transaction to
@@ -239,7 +243,7 @@ func run(ctx context.Context, call *core.Message, opts *Options) (*core.Executio
 		return res, err
 	}
 
-	evm := opts.Backend.GetEVM(ctx, call, dirtyState, opts.Header, &vm.Config{NoBaseFee: true}, &evmContext)
+	evm := opts.Backend.GetEVM(ctx, call, dirtyState, opts.Header, &vm.Config{NoBaseFee: true, SimulateBlobs: true, Tracer: opts.Tracer}, &evmContext)
 
 	go func() {
 		<-ctx.Done()
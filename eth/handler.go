@@ -93,6 +93,7 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+	SnapServe      snap.ServeConfig       // Configuration of the serving of the snap requests
 }
 
 type handler struct {
@@ -111,6 +112,7 @@ type handler struct {
 	downloader *downloader.Downloader
 	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
+	snapServer *snap.Server
 
 	eventMux *event.TypeMux
 	txsCh    chan core.NewTxsEvent
@@ -142,6 +144,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		txpool:         config.TxPool,
 		chain:          config.Chain,
 		peers:          newPeerSet(),
+		snapServer:     snap.NewServer(config.SnapServe),
 		requiredBlocks: config.RequiredBlocks,
 		quitSync:       make(chan struct{}),
 		handlerDoneCh:  make(chan struct{}),
@@ -28,6 +28,9 @@ type snapHandler handler
 
 func (h *snapHandler) Chain() *core.BlockChain { return h.chain }
 
+// Server retrieves the configuration and state of the serving of the snap data.
+func (h *snapHandler) Server() *snap.Server { return h.snapServer }
+
 // RunPeer is invoked when a peer joins on the `snap` protocol.
 func (h *snapHandler) RunPeer(peer *snap.Peer, hand snap.Handler) error {
 	return (*handler)(h).runSnapExtension(peer, hand)
@@ -66,6 +66,9 @@ type Backend interface {
 	// Chain retrieves the blockchain object to serve data.
 	Chain() *core.BlockChain
 
+	// Server retrieves the configuration and state of the serving of the data.
+	Server() *Server
+
 	// RunPeer is invoked when a peer joins on the `eth` protocol. The handler
 	// should do any peer maintenance work, handshakes and validations. If all
 	// is passed, control should be given back to the `handler` to process the
@@ -118,6 +121,7 @@ func MakeProtocols(backend Backend, dnsdisc enode.Iterator) []p2p.Protocol {
 // Handle is the callback invoked to manage the life cycle of a `snap` peer.
 // When this function terminates, the peer is disconnected.
 func Handle(backend Backend, peer *Peer) error {
+	peer.limiter = backend.Server().newLimiter()
 	for {
 		if err := HandleMessage(backend, peer); err != nil {
 			peer.Log().Debug("Message handling failed in `snap`", "err", err)
@@ -159,7 +163,18 @@ func HandleMessage(backend Backend, peer *Peer) error {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		accounts, proofs := ServiceGetAccountRangeQuery(backend.Chain(), &req)
+		// or if the serving is throttled
+		var (
+			accounts []*AccountData
+			proofs   [][]byte
+		)
+		if server := backend.Server(); server.admit(peer) {
+			req.Bytes = server.responseLimit(req.Bytes)
+			accounts, proofs = ServiceGetAccountRangeQuery(backend.Chain(), &req)
+
+			snapServeAccountRangeMeter.Mark(1)
+			snapServeAccountBytesMeter.Mark(int64(accountRangeSize(accounts, proofs)))
+		}
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, AccountRangeMsg, &AccountRangePacket{
@@ -191,7 +206,18 @@ func HandleMessage(backend Backend, peer *Peer) error {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		slots, proofs := ServiceGetStorageRangesQuery(backend.Chain(), &req)
+		// or if the serving is throttled
+		var (
+			slots  [][]*StorageData
+			proofs [][]byte
+		)
+		if server := backend.Server(); server.admit(peer) {
+			req.Bytes = server.responseLimit(req.Bytes)
+			slots, proofs = ServiceGetStorageRangesQuery(backend.Chain(), &req)
+
+			snapServeStorageRangesMeter.Mark(1)
+			snapServeStorageBytesMeter.Mark(int64(storageRangesSize(slots, proofs)))
+		}
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, StorageRangesMsg, &StorageRangesPacket{
@@ -225,7 +251,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		codes := ServiceGetByteCodesQuery(backend.Chain(), &req)
+		// or if the serving is throttled
+		var codes [][]byte
+		if server := backend.Server(); server.admit(peer) {
+			req.Bytes = server.responseLimit(req.Bytes)
+			codes = ServiceGetByteCodesQuery(backend.Chain(), &req)
+		}
 
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, ByteCodesMsg, &ByteCodesPacket{
@@ -250,9 +281,13 @@ func HandleMessage(backend Backend, peer *Peer) error {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		// Service the request, potentially returning nothing in case of errors
-		nodes, err := ServiceGetTrieNodesQuery(backend.Chain(), &req, start)
-		if err != nil {
-			return err
+		// or if the serving is throttled
+		var nodes [][]byte
+		if server := backend.Server(); server.admit(peer) {
+			req.Bytes = server.responseLimit(req.Bytes)
+			if nodes, err = ServiceGetTrieNodesQuery(backend.Chain(), &req, start); err != nil {
+				return err
+			}
 		}
 		// Send back anything accumulated (or empty in case of errors)
 		return p2p.Send(peer.rw, TrieNodesMsg, &TrieNodesPacket{
@@ -138,6 +138,7 @@ type dummyBackend struct {
 }
 
 func (d *dummyBackend) Chain() *core.BlockChain       { return d.chain }
+func (d *dummyBackend) Server() *Server               { return NewServer(ServeConfig{}) }
 func (d *dummyBackend) RunPeer(*Peer, Handler) error  { return nil }
 func (d *dummyBackend) PeerInfo(enode.ID) interface{} { return "Foo" }
 func (d *dummyBackend) Handle(*Peer, Packet) error    { return nil }
@@ -66,4 +66,17 @@ var (
 	// discarded during the snap sync.
 	largeStorageDiscardGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/discard", nil)
 	largeStorageResumedGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/resume", nil)
+
+	// snapServe*Meter track the account and storage range requests served to
+	// the remote peers, along with the size of the responses.
+	snapServeAccountRangeMeter  = metrics.NewRegisteredMeter("eth/protocols/snap/serve/account/requests", nil)
+	snapServeAccountBytesMeter  = metrics.NewRegisteredMeter("eth/protocols/snap/serve/account/bytes", nil)
+	snapServeStorageRangesMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/storage/requests", nil)
+	snapServeStorageBytesMeter  = metrics.NewRegisteredMeter("eth/protocols/snap/serve/storage/bytes", nil)
+
+	// snapServeThrottledMeter tracks the requests left unserved due to the rate
+	// limit of their peer, and snapServeDisabledMeter the ones left unserved as
+	// the serving is disabled.
+	snapServeThrottledMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/throttled", nil)
+	snapServeDisabledMeter  = metrics.NewRegisteredMeter("eth/protocols/snap/serve/disabled", nil)
 )
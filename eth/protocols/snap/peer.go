@@ -20,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
+	"golang.org/x/time/rate"
 )
 
 // Peer is a collection of relevant information we have about a `snap` peer.
@@ -30,7 +31,8 @@ type Peer struct {
 	rw        p2p.MsgReadWriter // Input/output streams for snap
 	version   uint              // Protocol version negotiated
 
-	logger log.Logger // Contextual logger with the peer id injected
+	logger  log.Logger    // Contextual logger with the peer id injected
+	limiter *rate.Limiter // Limiter of the requests served to the peer, nil if unlimited
 }
 
 // NewPeer creates a wrapper for a network connection and negotiated  protocol
@@ -0,0 +1,115 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// ServeConfig is the configuration of the serving of the snap requests of the
+// remote peers, to bound the load they put on the node.
+type ServeConfig struct {
+	ResponseBytes uint64  `toml:",omitempty"` // Size budget of the responses, capped at the protocol soft limit, 0 for the soft limit
+	PeerRate      float64 `toml:",omitempty"` // Requests served per second per peer, 0 for no limit
+	PeerBurst     int     `toml:",omitempty"` // Requests served at once per peer beyond the rate
+	Disabled      bool    `toml:",omitempty"` // Whether to start without serving the requests
+}
+
+// Server tracks the configuration of the serving of the snap requests, and
+// whether they are served at all.
+type Server struct {
+	config  ServeConfig
+	enabled atomic.Bool
+}
+
+// NewServer creates a server of the snap requests with the given configuration.
+func NewServer(config ServeConfig) *Server {
+	s := &Server{config: config}
+	s.enabled.Store(!config.Disabled)
+	return s
+}
+
+// Enabled returns whether the requests are served. Unserved requests are
+// answered with empty responses.
+func (s *Server) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// SetEnabled enables or disables the serving of the requests.
+func (s *Server) SetEnabled(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+// responseLimit returns the size budget of the response to a request asking for
+// the given number of bytes.
+func (s *Server) responseLimit(requested uint64) uint64 {
+	limit := uint64(softResponseLimit)
+	if s.config.ResponseBytes > 0 {
+		limit = min(limit, s.config.ResponseBytes)
+	}
+	return min(requested, limit)
+}
+
+// newLimiter creates the limiter of the requests of a peer, or nil if they are
+// not rate limited.
+func (s *Server) newLimiter() *rate.Limiter {
+	if s.config.PeerRate <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(s.config.PeerRate), max(s.config.PeerBurst, 1))
+}
+
+// admit returns whether the next request of the given peer is to be served.
+func (s *Server) admit(peer *Peer) bool {
+	if !s.Enabled() {
+		snapServeDisabledMeter.Mark(1)
+		return false
+	}
+	if peer.limiter != nil && !peer.limiter.Allow() {
+		snapServeThrottledMeter.Mark(1)
+		return false
+	}
+	return true
+}
+
+// accountRangeSize returns the size of the payload of an account range response.
+func accountRangeSize(accounts []*AccountData, proofs [][]byte) int {
+	size := 0
+	for _, account := range accounts {
+		size += len(account.Hash) + len(account.Body)
+	}
+	for _, node := range proofs {
+		size += len(node)
+	}
+	return size
+}
+
+// storageRangesSize returns the size of the payload of a storage ranges response.
+func storageRangesSize(slots [][]*StorageData, proofs [][]byte) int {
+	size := 0
+	for _, account := range slots {
+		for _, slot := range account {
+			size += len(slot.Hash) + len(slot.Body)
+		}
+	}
+	for _, node := range proofs {
+		size += len(node)
+	}
+	return size
+}
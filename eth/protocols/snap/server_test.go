@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// serverBackend is a backend serving the snap requests with a given server.
+type serverBackend struct {
+	dummyBackend
+	server *Server
+}
+
+func (b *serverBackend) Server() *Server { return b.server }
+
+// replayRW replays a request, and records the response to it.
+type replayRW struct {
+	code     uint64
+	data     []byte
+	response []byte
+}
+
+func (rw *replayRW) ReadMsg() (p2p.Msg, error) {
+	return p2p.Msg{Code: rw.code, Payload: bytes.NewReader(rw.data), Size: uint32(len(rw.data)), ReceivedAt: time.Now()}, nil
+}
+
+func (rw *replayRW) WriteMsg(msg p2p.Msg) error {
+	var err error
+	rw.response, err = io.ReadAll(msg.Payload)
+	return err
+}
+
+// Tests that the account range requests are served within the configured
+// budget and rate, and not at all once the serving is disabled.
+func TestServeAccountRange(t *testing.T) {
+	bc := getChain()
+	defer bc.Stop()
+
+	request, _ := rlp.EncodeToBytes(&GetAccountRangePacket{
+		ID:     1,
+		Root:   trieRoot,
+		Origin: common.Hash{},
+		Limit:  common.MaxHash,
+		Bytes:  softResponseLimit,
+	})
+	serve := func(backend *serverBackend, peer *Peer) int {
+		rw := &replayRW{code: GetAccountRangeMsg, data: request}
+		peer.rw = rw
+		if err := HandleMessage(backend, peer); err != nil {
+			t.Fatalf("failed to handle request: %v", err)
+		}
+		var res AccountRangePacket
+		if err := rlp.DecodeBytes(rw.response, &res); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return len(res.Accounts)
+	}
+	// Without restriction, all the accounts fit in the response
+	backend := &serverBackend{dummyBackend{bc}, NewServer(ServeConfig{})}
+	full := serve(backend, NewFakePeer(SNAP1, "peer0001", nil))
+	if full < 1000 {
+		t.Fatalf("unrestricted account count mismatch: have %d, want at least 1000", full)
+	}
+	// A response budget cuts the range short
+	backend.server = NewServer(ServeConfig{ResponseBytes: 1024})
+	if n := serve(backend, NewFakePeer(SNAP1, "peer0001", nil)); n == 0 || n >= full {
+		t.Fatalf("budgeted account count out of range: %d", n)
+	}
+	// The rate limit leaves the requests beyond the burst unserved
+	backend.server = NewServer(ServeConfig{PeerRate: 0.001, PeerBurst: 2})
+	peer := NewFakePeer(SNAP1, "peer0001", nil)
+	peer.limiter = backend.server.newLimiter()
+	for i, want := range []int{full, full, 0} {
+		if n := serve(backend, peer); n != want {
+			t.Fatalf("request %d: rate limited account count mismatch: have %d, want %d", i, n, want)
+		}
+	}
+	// Other peers are limited independently
+	other := NewFakePeer(SNAP1, "peer0002", nil)
+	other.limiter = backend.server.newLimiter()
+	if n := serve(backend, other); n != full {
+		t.Fatalf("other peer account count mismatch: have %d, want %d", n, full)
+	}
+	// Disabling the serving leaves all the requests unserved
+	backend.server = NewServer(ServeConfig{})
+	backend.server.SetEnabled(false)
+	if n := serve(backend, NewFakePeer(SNAP1, "peer0001", nil)); n != 0 {
+		t.Fatalf("disabled account count mismatch: have %d, want 0", n)
+	}
+	backend.server.SetEnabled(true)
+	if n := serve(backend, NewFakePeer(SNAP1, "peer0001", nil)); n != full {
+		t.Fatalf("reenabled account count mismatch: have %d, want %d", n, full)
+	}
+}
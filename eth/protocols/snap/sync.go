@@ -395,6 +395,11 @@ type SyncProgress struct {
 	TrienodeHealBytes  common.StorageSize // Number of state trie bytes persisted to disk
 	BytecodeHealSynced uint64             // Number of bytecodes downloaded
 	BytecodeHealBytes  common.StorageSize // Number of bytecodes persisted to disk
+
+	// Arbitrum: queue of the healing of the state root being synced, to resume
+	// the healing where it was interrupted rather than from the root
+	HealRoot  common.Hash     // State root the healing queue pertains to
+	HealQueue *trie.SyncQueue // Pending healing requests, nil if not healing
 }
 
 // SyncPending is analogous to SyncProgress, but it's used to report on pending
@@ -812,6 +817,17 @@ func (s *Syncer) loadSyncStatus() {
 
 			s.snapped = len(s.tasks) == 0
 
+			// Resume the healing of the same root where it was interrupted
+			if progress.HealQueue != nil && progress.HealRoot == s.root {
+				scheduler, err := state.RestoreStateSync(progress.HealQueue, s.db, s.onHealState, s.scheme)
+				if err != nil {
+					log.Warn("Failed to restore the healing queue", "root", s.root, "err", err)
+				} else {
+					log.Debug("Restored the healing queue", "root", s.root, "pending", scheduler.Pending())
+					s.healer.scheduler = scheduler
+				}
+			}
+
 			s.accountSynced = progress.AccountSynced
 			s.accountBytes = progress.AccountBytes
 			s.bytecodeSynced = progress.BytecodeSynced
@@ -919,6 +935,12 @@ func (s *Syncer) saveSyncStatus() {
 		BytecodeHealSynced: s.bytecodeHealSynced,
 		BytecodeHealBytes:  s.bytecodeHealBytes,
 	}
+	// Save the healing queue, the nodes it committed were flushed already. The
+	// in-flight requests are part of it, to be retrieved again.
+	if len(s.tasks) == 0 && s.healer != nil {
+		progress.HealRoot = s.root
+		progress.HealQueue = s.healer.scheduler.Export()
+	}
 	status, err := json.Marshal(progress)
 	if err != nil {
 		panic(err) // This can only fail during implementation
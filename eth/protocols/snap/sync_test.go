@@ -30,6 +30,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -677,6 +678,70 @@ func testSync(t *testing.T, scheme string) {
 	verifyTrie(scheme, syncer.db, sourceAccountTrie.Hash(), t)
 }
 
+// TestSyncResumeHealing tests that an interrupted healing is resumed from its
+// saved queue rather than from the state root, if still healing the same root.
+func TestSyncResumeHealing(t *testing.T) {
+	t.Parallel()
+
+	testSyncResumeHealing(t, rawdb.HashScheme)
+	testSyncResumeHealing(t, rawdb.PathScheme)
+}
+
+func testSyncResumeHealing(t *testing.T, scheme string) {
+	nodeScheme, sourceAccountTrie, _ := makeAccountTrieNoStorage(100, scheme)
+	root := sourceAccountTrie.Hash()
+
+	newHealer := func(syncer *Syncer, root common.Hash) {
+		syncer.root = root
+		syncer.healer = &healTask{
+			scheduler: state.NewStateSync(root, syncer.db, syncer.onHealState, nodeScheme),
+			trieTasks: make(map[string]common.Hash),
+			codeTasks: make(map[common.Hash]struct{}),
+		}
+	}
+	// Retrieve the root node, leaving its children pending, and interrupt
+	syncer := NewSyncer(rawdb.NewMemoryDatabase(), nodeScheme)
+	newHealer(syncer, root)
+
+	paths, _, _ := syncer.healer.scheduler.Missing(1)
+	blob, _, err := sourceAccountTrie.GetNode(trie.NewSyncPath([]byte(paths[0]))[0])
+	if err != nil {
+		t.Fatalf("failed to retrieve root node: %v", err)
+	}
+	if err := syncer.healer.scheduler.ProcessNode(trie.NodeSyncResult{Path: paths[0], Data: blob}); err != nil {
+		t.Fatalf("failed to process root node: %v", err)
+	}
+	pending := syncer.healer.scheduler.Pending()
+	if pending <= 1 {
+		t.Fatalf("no children of the root pending: %d", pending)
+	}
+	syncer.saveSyncStatus()
+
+	// The healing of the same root resumes with the children of the root
+	resumed := NewSyncer(syncer.db, nodeScheme)
+	newHealer(resumed, root)
+	resumed.loadSyncStatus()
+	if have := resumed.healer.scheduler.Pending(); have != pending {
+		t.Fatalf("resumed pending count mismatch: have %d, want %d", have, pending)
+	}
+	paths, _, _ = resumed.healer.scheduler.Missing(0)
+	if len(paths) != pending-1 {
+		t.Fatalf("resumed missing count mismatch: have %d, want %d", len(paths), pending-1)
+	}
+	for _, path := range paths {
+		if len(path) == 0 {
+			t.Fatal("root node requested again")
+		}
+	}
+	// The healing of another root restarts from it
+	restarted := NewSyncer(syncer.db, nodeScheme)
+	newHealer(restarted, common.Hash{0x01})
+	restarted.loadSyncStatus()
+	if have := restarted.healer.scheduler.Pending(); have != 1 {
+		t.Fatalf("restarted pending count mismatch: have %d, want 1", have)
+	}
+}
+
 // TestSyncTinyTriePanic tests a basic sync with one peer, and a tiny trie. This caused a
 // panic within the prover
 func TestSyncTinyTriePanic(t *testing.T) {
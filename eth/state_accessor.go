@@ -226,9 +226,20 @@ func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), erro
 //     on disk.
 func (eth *Ethereum) stateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, baseBlock *types.Block, readOnly bool, preferDisk bool) (statedb *state.StateDB, release tracers.StateReleaseFunc, err error) {
 	if eth.blockchain.TrieDB().Scheme() == rawdb.HashScheme {
-		return eth.hashState(ctx, block, reexec, base, baseBlock, readOnly, preferDisk)
+		statedb, release, err = eth.hashState(ctx, block, reexec, base, baseBlock, readOnly, preferDisk)
+	} else {
+		statedb, release, err = eth.pathState(block)
+	}
+	// Arbitrum: serve the state from the historical state provider, if any
+	if err != nil && base == nil && eth.historicalState != nil {
+		historical, herr := state.New(block.Root(), eth.historicalState, nil)
+		if herr != nil {
+			return nil, nil, err
+		}
+		log.Debug("Serving historical state remotely", "number", block.Number(), "root", block.Root(), "err", err)
+		return historical, noopReleaser, nil
 	}
-	return eth.pathState(block)
+	return statedb, release, err
 }
 
 // arbitrum: exposing stateAtBlock function
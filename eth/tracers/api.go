@@ -162,6 +162,22 @@ type TraceConfig struct {
 	TracerConfig json.RawMessage
 }
 
+// TraceCallManyCall is a single call of a traceCallMany batch, along with the
+// tracer to trace it with and the state overrides to apply right before it.
+type TraceCallManyCall struct {
+	TraceConfig
+	Call           ethapi.TransactionArgs
+	StateOverrides *ethapi.StateOverride
+}
+
+// TraceCallManyConfig is the config for traceCallMany API, applying to all the
+// calls of the batch.
+type TraceCallManyConfig struct {
+	Reexec         *uint64
+	BlockOverrides *ethapi.BlockOverrides
+	TxIndex        *hexutil.Uint
+}
+
 // TraceCallConfig is the config for traceCall API. It holds one more
 // field to override the state for tracing.
 type TraceCallConfig struct {
@@ -580,19 +596,7 @@ func (api *API) StandardTraceBadBlockToFile(ctx context.Context, hash common.Has
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requested tracer.
 func (api *API) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
-	if block.NumberU64() == 0 {
-		return nil, errors.New("genesis is not traceable")
-	}
-	// Prepare base state
-	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
-	if err != nil {
-		return nil, err
-	}
-	reexec := defaultTraceReexec
-	if config != nil && config.Reexec != nil {
-		reexec = *config.Reexec
-	}
-	statedb, release, err := api.backend.StateAtBlock(ctx, parent, reexec, nil, true, false)
+	statedb, release, err := api.blockTraceState(ctx, block, config)
 	if err != nil {
 		return nil, err
 	}
@@ -606,12 +610,44 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 		}
 	}
 	// Native tracers have low overhead
+	results := make([]*txTraceResult, 0, len(block.Transactions()))
+	err = api.traceBlockTxs(ctx, block, statedb, config, func(i int, result *txTraceResult) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// blockTraceState retrieves the parent state of the given block to trace the
+// block on top of.
+func (api *API) blockTraceState(ctx context.Context, block *types.Block, config *TraceConfig) (*state.StateDB, StateReleaseFunc, error) {
+	if block.NumberU64() == 0 {
+		return nil, nil, errors.New("genesis is not traceable")
+	}
+	// Prepare base state
+	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
+	if err != nil {
+		return nil, nil, err
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	return api.backend.StateAtBlock(ctx, parent, reexec, nil, true, false)
+}
+
+// traceBlockTxs traces all the transactions of the given block sequentially on
+// top of its parent state, handing the trace of each transaction to the given
+// callback as soon as it's produced.
+func (api *API) traceBlockTxs(ctx context.Context, block *types.Block, statedb *state.StateDB, config *TraceConfig, fn func(i int, result *txTraceResult) error) error {
 	var (
 		txs       = block.Transactions()
 		blockHash = block.Hash()
 		blockCtx  = core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 		signer    = types.MakeSigner(api.backend.ChainConfig(), block.Number(), block.Time())
-		results   = make([]*txTraceResult, len(txs))
 	)
 	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
 		vmenv := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, api.backend.ChainConfig(), vm.Config{})
@@ -628,11 +664,13 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 		}
 		res, err := api.traceTx(ctx, tx, msg, txctx, blockCtx, statedb, config)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(i, &txTraceResult{TxHash: tx.Hash(), Result: res}); err != nil {
+			return err
 		}
-		results[i] = &txTraceResult{TxHash: tx.Hash(), Result: res}
 	}
-	return results, nil
+	return nil
 }
 
 // traceBlockParallel is for tracers that have a high overhead (read JS tracers). One thread
@@ -894,6 +932,107 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // the trace will be conducted on the state after executing the specified transaction
 // within the specified block.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	var (
+		reexec  *uint64
+		txIndex *hexutil.Uint
+	)
+	if config != nil {
+		reexec, txIndex = config.Reexec, config.TxIndex
+	}
+	block, statedb, release, err := api.stateForCall(ctx, blockNrOrHash, reexec, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+	}
+	// Execute the trace
+	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+		return nil, err
+	}
+	var (
+		msg         = args.ToMessage(vmctx.BaseFee, api.backend.RPCGasCap(), block.Header(), statedb, core.MessageEthcallMode)
+		tx          = args.ToTransaction()
+		traceConfig *TraceConfig
+	)
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig)
+}
+
+// TraceCallMany lets you trace a batch of calls, executed sequentially on top
+// of the provided block, each call seeing the state changes of the ones before
+// it. Every call is traced with its own tracer. A call failing to execute does
+// not abort the batch, its error is reported in place of its trace.
+func (api *API) TraceCallMany(ctx context.Context, calls []TraceCallManyCall, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallManyConfig) ([]*txTraceResult, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("empty batch")
+	}
+	if config == nil {
+		config = new(TraceCallManyConfig)
+	}
+	block, statedb, release, err := api.stateForCall(ctx, blockNrOrHash, config.Reexec, config.TxIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	config.BlockOverrides.Apply(&vmctx)
+
+	results := make([]*txTraceResult, len(calls))
+	for i, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := call.StateOverrides.Apply(statedb); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		args := call.Call
+		if args.Nonce == nil {
+			// Default to the sender's nonce so that every call has a distinct hash
+			var from common.Address
+			if args.From != nil {
+				from = *args.From
+			}
+			nonce := hexutil.Uint64(statedb.GetNonce(from))
+			args.Nonce = &nonce
+		}
+		if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		var (
+			msg   = args.ToMessage(vmctx.BaseFee, api.backend.RPCGasCap(), block.Header(), statedb, core.MessageEthcallMode)
+			tx    = args.ToTransaction()
+			txctx = &Context{
+				BlockNumber: vmctx.BlockNumber,
+				TxIndex:     i,
+				TxHash:      tx.Hash(),
+			}
+		)
+		results[i] = &txTraceResult{TxHash: tx.Hash()}
+		res, err := api.traceTx(ctx, tx, msg, txctx, vmctx, statedb, &call.TraceConfig)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Result = res
+	}
+	return results, nil
+}
+
+// stateForCall retrieves the block a call is to be traced on top of, along with
+// the state after executing the block or, if a transaction index is specified,
+// after executing the given transaction within the block.
+func (api *API) stateForCall(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, reexecOverride *uint64, txIndex *hexutil.Uint) (*types.Block, *state.StateDB, StateReleaseFunc, error) {
 	// Try to retrieve the specified block
 	var (
 		err     error
@@ -910,52 +1049,29 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// more flexibility and stability than trying to trace on 'pending', since
 			// the contents of 'pending' is unstable and probably not a true representation
 			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
+			return nil, nil, nil, errors.New("tracing on top of pending is not supported")
 		}
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
-	if config != nil && config.Reexec != nil {
-		reexec = *config.Reexec
+	if reexecOverride != nil {
+		reexec = *reexecOverride
 	}
-
-	if config != nil && config.TxIndex != nil {
-		_, _, statedb, release, err = api.backend.StateAtTransaction(ctx, block, int(*config.TxIndex), reexec)
+	if txIndex != nil {
+		_, _, statedb, release, err = api.backend.StateAtTransaction(ctx, block, int(*txIndex), reexec)
 	} else {
 		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	}
 	if err != nil {
-		return nil, err
-	}
-	defer release()
-
-	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-	// Apply the customization rules if required.
-	if config != nil {
-		if err := config.StateOverrides.Apply(statedb); err != nil {
-			return nil, err
-		}
-		config.BlockOverrides.Apply(&vmctx)
-	}
-	// Execute the trace
-	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
-		return nil, err
-	}
-	var (
-		msg         = args.ToMessage(vmctx.BaseFee, api.backend.RPCGasCap(), block.Header(), statedb, core.MessageEthcallMode)
-		tx          = args.ToTransaction()
-		traceConfig *TraceConfig
-	)
-	if config != nil {
-		traceConfig = &config.TraceConfig
+		return nil, nil, nil, err
 	}
-	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig)
+	return block, statedb, release, nil
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
@@ -986,7 +1102,9 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 		}
 	}
 	// The actual TxContext will be created as part of ApplyTransactionWithEVM.
-	vmenv := vm.NewEVM(vmctx, vm.TxContext{GasPrice: message.GasPrice, BlobFeeCap: message.BlobGasFeeCap}, statedb, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
+	// Arbitrum: the blobs of the simulated calls are processed as on L1
+	vmConf := vm.Config{Tracer: tracer.Hooks, NoBaseFee: true, SimulateBlobs: !message.TxRunMode.ExecutedOnChain()}
+	vmenv := vm.NewEVM(vmctx, vm.TxContext{GasPrice: message.GasPrice, BlobFeeCap: message.BlobGasFeeCap}, statedb, api.backend.ChainConfig(), vmConf)
 	statedb.SetLogger(tracer.Hooks)
 
 	// Define a meaningful timeout of a single transaction trace
@@ -409,6 +409,58 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[2].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	invalidTimeout := "invalid"
+	calls := []TraceCallManyCall{
+		// Move all the funds of account 2 to account 1
+		{Call: ethapi.TransactionArgs{From: &accounts[2].addr, To: &accounts[1].addr, Value: (*hexutil.Big)(big.NewInt(params.Ether))}},
+		// Account 2 is drained by the preceding call
+		{Call: ethapi.TransactionArgs{From: &accounts[2].addr, To: &accounts[0].addr, Value: (*hexutil.Big)(big.NewInt(1))}},
+		// Account 1 received the funds of the first call
+		{Call: ethapi.TransactionArgs{From: &accounts[1].addr, To: &accounts[0].addr, Value: (*hexutil.Big)(big.NewInt(2 * params.Ether))}},
+		// Every call is traced with its own tracer config
+		{
+			TraceConfig: TraceConfig{Timeout: &invalidTimeout},
+			Call:        ethapi.TransactionArgs{From: &accounts[0].addr, To: &accounts[1].addr},
+		},
+	}
+	results, err := api.TraceCallMany(context.Background(), calls, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(0)), nil)
+	if err != nil {
+		t.Fatalf("failed to trace calls: %v", err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(calls))
+	}
+	for i, wantErr := range []bool{false, true, false, true} {
+		if have := results[i].Error != ""; have != wantErr {
+			t.Fatalf("call %d: error mismatch: have %q, want error %v", i, results[i].Error, wantErr)
+		}
+	}
+	blob, err := json.Marshal(results[2].Result)
+	if err != nil {
+		t.Fatalf("failed to marshal trace: %v", err)
+	}
+	if want := `{"gas":21000,"failed":false,"returnValue":"","structLogs":[]}`; string(blob) != want {
+		t.Fatalf("trace mismatch: have %s, want %s", blob, want)
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
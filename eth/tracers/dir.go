@@ -18,7 +18,9 @@ package tracers
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/tracing"
@@ -61,14 +63,38 @@ var DefaultDirectory = directory{elems: make(map[string]elem)}
 type directory struct {
 	elems  map[string]elem
 	jsEval jsCtorFn
+	lock   sync.RWMutex // Guards elems against registrations by embedders at runtime
 }
 
 // Register registers a method as a lookup for tracers, meaning that
 // users can invoke a named tracer through that lookup.
 func (d *directory) Register(name string, f ctorFn, isJS bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
 	d.elems[name] = elem{ctor: f, isJS: isJS}
 }
 
+// RegisterNative registers a native tracer constructor under the given name in
+// the default directory, making the tracer available through the standard
+// debug tracing APIs. It's meant for programs embedding the node, which can't
+// add tracers to this package; the registration should be done at startup,
+// before serving any tracing requests. Registering a name that's already taken,
+// including the names of the bundled tracers, is an error.
+func RegisterNative(name string, ctor func(ctx *Context, cfg json.RawMessage) (*Tracer, error)) error {
+	if name == "" || ctor == nil {
+		return fmt.Errorf("invalid native tracer registration %q", name)
+	}
+	DefaultDirectory.lock.Lock()
+	defer DefaultDirectory.lock.Unlock()
+
+	if _, ok := DefaultDirectory.elems[name]; ok {
+		return fmt.Errorf("tracer %q already registered", name)
+	}
+	DefaultDirectory.elems[name] = elem{ctor: ctor}
+	return nil
+}
+
 // RegisterJSEval registers a tracer that is able to parse
 // dynamic user-provided JS code.
 func (d *directory) RegisterJSEval(f jsCtorFn) {
@@ -79,7 +105,11 @@ func (d *directory) RegisterJSEval(f jsCtorFn) {
 // registered lookups. Name is either name of an existing tracer
 // or an arbitrary JS code.
 func (d *directory) New(name string, ctx *Context, cfg json.RawMessage) (*Tracer, error) {
-	if elem, ok := d.elems[name]; ok {
+	d.lock.RLock()
+	elem, ok := d.elems[name]
+	d.lock.RUnlock()
+
+	if ok {
 		return elem.ctor(ctx, cfg)
 	}
 	// Assume JS code
@@ -90,6 +120,9 @@ func (d *directory) New(name string, ctx *Context, cfg json.RawMessage) (*Tracer
 // JS code. Because code evaluation has high overhead, this
 // info will be used in determining fast and slow code paths.
 func (d *directory) IsJS(name string) bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
 	if elem, ok := d.elems[name]; ok {
 		return elem.isJS
 	}
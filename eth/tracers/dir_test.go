@@ -0,0 +1,75 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+)
+
+// Tests that native tracers registered by embedders are served by the tracing
+// APIs like the bundled ones.
+func TestRegisterNative(t *testing.T) {
+	t.Parallel()
+
+	var (
+		name = "testOpcodeCounter"
+		ctor = func(ctx *Context, cfg json.RawMessage) (*Tracer, error) {
+			var ops int
+			return &Tracer{
+				Hooks: &tracing.Hooks{
+					OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+						ops++
+					},
+				},
+				GetResult: func() (json.RawMessage, error) {
+					return json.RawMessage(fmt.Sprintf(`{"tx":%d,"ops":%d}`, ctx.TxIndex, ops)), nil
+				},
+				Stop: func(err error) {},
+			}, nil
+		}
+	)
+	if err := RegisterNative(name, ctor); err != nil {
+		t.Fatalf("failed to register tracer: %v", err)
+	}
+	if err := RegisterNative(name, ctor); err == nil {
+		t.Fatal("duplicate tracer registered")
+	}
+	if err := RegisterNative("", ctor); err == nil {
+		t.Fatal("unnamed tracer registered")
+	}
+	if DefaultDirectory.IsJS(name) {
+		t.Fatal("native tracer reported as JS")
+	}
+	api, backend := newStreamTestAPI(t, 2)
+	defer backend.chain.Stop()
+
+	results, err := api.TraceBlockByNumber(context.Background(), 1, &TraceConfig{Tracer: &name})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	for i, result := range results {
+		have, _ := json.Marshal(result.Result)
+		if want := fmt.Sprintf(`{"tx":%d,"ops":0}`, i); string(have) != want {
+			t.Errorf("result %d mismatch: have %s, want %s", i, have, want)
+		}
+	}
+}
@@ -59,21 +59,29 @@ type callTrace struct {
 	BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
 	AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
 
-	From         common.Address  `json:"from"`
-	Gas          *hexutil.Uint64 `json:"gas"`
-	GasUsed      *hexutil.Uint64 `json:"gasUsed"`
-	To           *common.Address `json:"to,omitempty"`
-	Input        hexutil.Bytes   `json:"input"`
-	Output       hexutil.Bytes   `json:"output,omitempty"`
-	Error        string          `json:"error,omitempty"`
-	RevertReason string          `json:"revertReason,omitempty"`
-	Calls        []callTrace     `json:"calls,omitempty"`
-	Logs         []callLog       `json:"logs,omitempty"`
-	Value        *hexutil.Big    `json:"value,omitempty"`
+	From         common.Address    `json:"from"`
+	Gas          *hexutil.Uint64   `json:"gas"`
+	GasUsed      *hexutil.Uint64   `json:"gasUsed"`
+	To           *common.Address   `json:"to,omitempty"`
+	Input        hexutil.Bytes     `json:"input"`
+	Output       hexutil.Bytes     `json:"output,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	RevertReason string            `json:"revertReason,omitempty"`
+	Calls        []callTrace       `json:"calls,omitempty"`
+	Logs         []callLog         `json:"logs,omitempty"`
+	SelfDestruct *callSelfDestruct `json:"selfDestruct,omitempty"`
+	Value        *hexutil.Big      `json:"value,omitempty"`
 	// Gencodec adds overridden fields at the end
 	Type string `json:"type"`
 }
 
+type callSelfDestruct struct {
+	Beneficiary common.Address `json:"beneficiary"`
+	Transferred *hexutil.Big   `json:"transferred"`
+	Burnt       *hexutil.Big   `json:"burnt"`
+	Destructed  bool           `json:"destructed"`
+}
+
 // callTracerTest defines a single test to check the call tracer against.
 type callTracerTest struct {
 	Genesis      *core.Genesis   `json:"genesis"`
@@ -98,6 +98,9 @@ func (al accessList) accessList() types.AccessList {
 type AccessListTracer struct {
 	excl map[common.Address]struct{} // Set of account to exclude from the list
 	list accessList                  // Set of accounts and storage slots touched
+
+	// Arbitrum: storage context of every call frame, for Stylus programs
+	frames []common.Address
 }
 
 // NewAccessListTracer creates a new tracer that can generate AccessLists.
@@ -128,6 +131,11 @@ func NewAccessListTracer(acl types.AccessList, from, to common.Address, precompi
 func (a *AccessListTracer) Hooks() *tracing.Hooks {
 	return &tracing.Hooks{
 		OnOpcode: a.OnOpcode,
+
+		// Arbitrum: capture the accesses of Stylus programs
+		OnEnter:             a.OnEnter,
+		OnExit:              a.OnExit,
+		CaptureStylusHostio: a.CaptureStylusHostio,
 	}
 }
 
@@ -0,0 +1,61 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package logger
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// OnEnter tracks the storage context of every call frame, as Stylus programs
+// don't report the address whose storage they access.
+func (a *AccessListTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Delegated calls execute in the storage context of the caller
+	storage := to
+	if op := vm.OpCode(typ); op == vm.DELEGATECALL || op == vm.CALLCODE {
+		storage = from
+	}
+	a.frames = append(a.frames, storage)
+}
+
+// OnExit pops the storage context of the exited call frame.
+func (a *AccessListTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(a.frames) > 0 {
+		a.frames = a.frames[:len(a.frames)-1]
+	}
+}
+
+// CaptureStylusHostio captures the hostios of Stylus programs that touch storage
+// or addresses and adds them to the accesslist.
+func (a *AccessListTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	switch name {
+	case "storage_load_bytes32", "storage_cache_bytes32":
+		if len(args) >= common.HashLength && len(a.frames) > 0 {
+			a.list.addSlot(a.frames[len(a.frames)-1], common.BytesToHash(args[:common.HashLength]))
+		}
+	case "account_balance", "account_code", "account_code_size", "account_codehash",
+		"call_contract", "delegate_call_contract", "static_call_contract":
+		if len(args) >= common.AddressLength {
+			addr := common.BytesToAddress(args[:common.AddressLength])
+			if _, ok := a.excl[addr]; !ok {
+				a.list.addAddress(addr)
+			}
+		}
+	}
+}
@@ -116,6 +116,7 @@ type StructLogger struct {
 	output  []byte
 	err     error
 	usedGas uint64
+	refund  *RefundResult // Arbitrum: refund counter trajectory, nil until it changes
 
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
 	reason    error       // Textual reason for the interruption
@@ -138,6 +139,9 @@ func (l *StructLogger) Hooks() *tracing.Hooks {
 		OnTxEnd:   l.OnTxEnd,
 		OnExit:    l.OnExit,
 		OnOpcode:  l.OnOpcode,
+
+		OnRefundChange: l.OnRefundChange,
+		OnRefund:       l.OnRefund,
 	}
 }
 
@@ -147,6 +151,7 @@ func (l *StructLogger) Reset() {
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
 	l.err = nil
+	l.refund = nil
 }
 
 // OnOpcode logs a new structured log message and pushes it out to the environment
@@ -247,6 +252,7 @@ func (l *StructLogger) GetResult() (json.RawMessage, error) {
 		Failed:      failed,
 		ReturnValue: returnVal,
 		StructLogs:  formatLogs(l.StructLogs()),
+		Refund:      l.refund,
 	})
 }
 
@@ -271,6 +277,35 @@ func (l *StructLogger) OnTxEnd(receipt *types.Receipt, err error) {
 	l.usedGas = receipt.GasUsed
 }
 
+// OnRefundChange records a change of the refund counter.
+func (l *StructLogger) OnRefundChange(prev, new uint64, reverted bool) {
+	if l.refund == nil {
+		l.refund = &RefundResult{Changes: []RefundChange{}}
+	}
+	change := RefundChange{Step: len(l.logs), Prev: prev, New: new, Reverted: reverted}
+	switch {
+	case reverted:
+	case new > prev:
+		l.refund.Added += new - prev
+	default:
+		l.refund.Subtracted += prev - new
+	}
+	l.refund.Changes = append(l.refund.Changes, change)
+}
+
+// OnRefund records how the refund counter was applied at the end of the
+// transaction. It is only reported if the counter changed during the execution.
+func (l *StructLogger) OnRefund(counter, quotient, nonrefundable, limit, refund uint64) {
+	if l.refund == nil {
+		return
+	}
+	l.refund.Counter = counter
+	l.refund.Quotient = quotient
+	l.refund.Nonrefundable = nonrefundable
+	l.refund.Limit = limit
+	l.refund.Refunded = refund
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
@@ -418,6 +453,34 @@ type ExecutionResult struct {
 	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
 	StructLogs  []StructLogRes `json:"structLogs"`
+
+	// Arbitrum: how the refund counter evolved and was applied, if it changed
+	Refund *RefundResult `json:"refund,omitempty"`
+}
+
+// RefundResult is the trajectory of the refund counter of a transaction, and
+// the refund it resulted in. The counter is capped to a quotient of the gas used
+// at the end of the transaction, excluding the gas Arbitrum doesn't refund, such
+// as the L1 calldata costs.
+type RefundResult struct {
+	Added         uint64         `json:"added"`         // Gas added to the counter, reverted changes included
+	Subtracted    uint64         `json:"subtracted"`    // Gas subtracted from the counter, reverted changes included
+	Counter       uint64         `json:"counter"`       // Counter at the end of the execution
+	Quotient      uint64         `json:"quotient"`      // Quotient of the gas used the refund is capped to
+	Nonrefundable uint64         `json:"nonrefundable"` // Gas used excluded from the cap
+	Limit         uint64         `json:"limit"`         // Cap of the refund
+	Refunded      uint64         `json:"refunded"`      // Gas refunded, the counter clamped to the cap
+	Changes       []RefundChange `json:"changes"`
+}
+
+// RefundChange is a change of the refund counter. The step is the index of the
+// structured log of the opcode about to execute, the counter being adjusted as
+// its gas is charged, or of the next opcode once a reverted call returns.
+type RefundChange struct {
+	Step     int    `json:"step"`
+	Prev     uint64 `json:"prev"`
+	New      uint64 `json:"new"`
+	Reverted bool   `json:"reverted,omitempty"` // Whether the change rolls back those of a reverted call
 }
 
 // StructLogRes stores a structured log emitted by the EVM while replaying a
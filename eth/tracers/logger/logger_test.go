@@ -23,7 +23,10 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
@@ -106,3 +109,139 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		})
 	}
 }
+
+// Tests that the trajectory of the refund counter and its clamping are reported.
+func TestStructLoggerRefund(t *testing.T) {
+	var (
+		from     = common.HexToAddress("0xf1")
+		contract = common.HexToAddress("0xf2")
+		// Clears slot 0, then sets and resets slot 1
+		code = []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 1, byte(vm.SSTORE),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		}
+		revert = []byte{byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT)}
+	)
+	for _, reverts := range []bool{false, true} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetCode(contract, code)
+		if reverts {
+			statedb.SetCode(contract, append(code, revert...))
+		}
+		statedb.SetState(contract, common.Hash{}, common.BigToHash(big.NewInt(1)))
+		statedb.Finalise(true)
+
+		logger := NewStructLogger(nil)
+		statedb.SetLogger(logger.Hooks())
+		blockCtx := vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: big.NewInt(1),
+			BaseFee:     new(big.Int),
+		}
+		evm := vm.NewEVM(blockCtx, vm.TxContext{GasPrice: new(big.Int)}, statedb, params.TestChainConfig, vm.Config{Tracer: logger.Hooks()})
+		msg := &core.Message{
+			From:      from,
+			To:        &contract,
+			Value:     new(big.Int),
+			GasLimit:  100000,
+			GasPrice:  new(big.Int),
+			GasFeeCap: new(big.Int),
+			GasTipCap: new(big.Int),
+		}
+		logger.OnTxStart(evm.GetVMContext(), nil, from)
+		result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.OnTxEnd(&types.Receipt{GasUsed: result.UsedGas}, nil)
+
+		blob, err := logger.GetResult()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res ExecutionResult
+		if err := json.Unmarshal(blob, &res); err != nil {
+			t.Fatal(err)
+		}
+		refund := res.Refund
+		if refund == nil {
+			t.Fatalf("reverts %v: refund not reported", reverts)
+		}
+		// The clearing of slot 0 and the reset of slot 1 are refunded
+		added := params.SstoreClearsScheduleRefundEIP3529 + params.SstoreSetGasEIP2200 - params.WarmStorageReadCostEIP2929
+		if refund.Added != added || refund.Subtracted != 0 {
+			t.Errorf("reverts %v: added %d subtracted %d, want %d 0", reverts, refund.Added, refund.Subtracted, added)
+		}
+		if refund.Quotient != params.RefundQuotientEIP3529 {
+			t.Errorf("reverts %v: quotient %d, want %d", reverts, refund.Quotient, params.RefundQuotientEIP3529)
+		}
+		if refund.Limit != (result.UsedGas+result.RefundedGas)/params.RefundQuotientEIP3529 {
+			t.Errorf("reverts %v: limit %d, gas used %d", reverts, refund.Limit, result.UsedGas+result.RefundedGas)
+		}
+		if refund.Refunded != result.RefundedGas {
+			t.Errorf("reverts %v: refunded %d, want %d", reverts, refund.Refunded, result.RefundedGas)
+		}
+		if reverts {
+			// Both changes are rolled back, newest first
+			if len(refund.Changes) != 4 || !refund.Changes[2].Reverted || !refund.Changes[3].Reverted || refund.Changes[3].New != 0 {
+				t.Errorf("revert of the refund counter not reported: %+v", refund.Changes)
+			}
+			if refund.Counter != 0 || refund.Refunded != 0 {
+				t.Errorf("reverted counter %d refunded %d, want 0 0", refund.Counter, refund.Refunded)
+			}
+		} else {
+			if len(refund.Changes) != 2 || refund.Changes[1].New != added {
+				t.Errorf("refund counter changes mismatch: %+v", refund.Changes)
+			}
+			// The counter exceeds the cap, the refund is clamped to it
+			if refund.Counter != added || refund.Refunded != refund.Limit || refund.Refunded >= added {
+				t.Errorf("counter %d refunded %d, want %d clamped to %d", refund.Counter, refund.Refunded, added, refund.Limit)
+			}
+		}
+	}
+}
+
+func TestAccessListTracerStylus(t *testing.T) {
+	var (
+		from     = common.HexToAddress("0x01")
+		program  = common.HexToAddress("0x02")
+		library  = common.HexToAddress("0x03")
+		callee   = common.HexToAddress("0x04")
+		slot1    = common.HexToHash("0x11")
+		slot2    = common.HexToHash("0x22")
+		tracer   = NewAccessListTracer(nil, from, program, nil)
+		hooks    = tracer.Hooks()
+		zeroHash = common.Hash{}
+	)
+	hooks.OnEnter(0, byte(vm.CALL), from, program, nil, 0, nil)
+	hooks.CaptureStylusHostio("storage_load_bytes32", slot1.Bytes(), zeroHash.Bytes(), 0, 0)
+
+	// Delegated calls access the storage of the caller
+	hooks.OnEnter(1, byte(vm.DELEGATECALL), program, library, nil, 0, nil)
+	hooks.CaptureStylusHostio("storage_cache_bytes32", append(slot2.Bytes(), zeroHash.Bytes()...), nil, 0, 0)
+	hooks.OnExit(1, nil, 0, nil, false)
+
+	hooks.CaptureStylusHostio("call_contract", append(callee.Bytes(), 0x01), nil, 0, 0)
+	hooks.OnExit(0, nil, 0, nil, false)
+
+	acl := tracer.AccessList()
+	if len(acl) != 2 {
+		t.Fatalf("access list length mismatch: have %d, want 2: %v", len(acl), acl)
+	}
+	for _, tuple := range acl {
+		switch tuple.Address {
+		case program:
+			if len(tuple.StorageKeys) != 2 {
+				t.Fatalf("program slots mismatch: have %v, want [%v %v]", tuple.StorageKeys, slot1, slot2)
+			}
+		case callee:
+			if len(tuple.StorageKeys) != 0 {
+				t.Fatalf("unexpected callee slots: %v", tuple.StorageKeys)
+			}
+		default:
+			t.Fatalf("unexpected address in access list: %v", tuple.Address)
+		}
+	}
+}
@@ -46,6 +46,23 @@ type callLog struct {
 	Position hexutil.Uint `json:"position"`
 }
 
+type callBalanceChange struct {
+	Address common.Address `json:"address"`
+	Prev    *hexutil.Big   `json:"prev"`
+	New     *hexutil.Big   `json:"new"`
+	Reason  string         `json:"reason"`
+	// Position of the balance change relative to subcalls within the same trace
+	Position hexutil.Uint `json:"position"`
+}
+
+// callSelfDestruct is where the balance of a selfdestructed contract went.
+type callSelfDestruct struct {
+	Beneficiary common.Address `json:"beneficiary"`
+	Transferred *hexutil.Big   `json:"transferred"`
+	Burnt       *hexutil.Big   `json:"burnt"`
+	Destructed  bool           `json:"destructed"` // False if only drained, as of EIP-6780
+}
+
 type callFrame struct {
 	// Arbitrum: we add these here due to the tracer returning the top frame
 	BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
@@ -63,6 +80,11 @@ type callFrame struct {
 	Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
 	Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
 
+	BalanceChanges []callBalanceChange `json:"balanceChanges,omitempty" rlp:"optional"`
+
+	// Arbitrum: where the balance went, for the selfdestruct frames
+	SelfDestruct *callSelfDestruct `json:"selfDestruct,omitempty" rlp:"optional"`
+
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
 	Value            *big.Int `json:"value,omitempty" rlp:"optional"`
@@ -114,6 +136,9 @@ type callTracer struct {
 	beforeEVMTransfers []arbitrumTransfer
 	afterEVMTransfers  []arbitrumTransfer
 
+	// Balance changes occurring before the top-level call is entered (e.g. gas buy)
+	pendingBalanceChanges []callBalanceChange
+
 	callstack []callFrame
 	config    callTracerConfig
 	gasLimit  uint64
@@ -125,6 +150,8 @@ type callTracer struct {
 type callTracerConfig struct {
 	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
 	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+
+	WithBalanceChanges bool `json:"withBalanceChanges"` // If true, call tracer will collect balance changes with their reasons
 }
 
 // newCallTracer returns a native go tracer which tracks
@@ -141,6 +168,8 @@ func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer,
 			OnEnter:                 t.OnEnter,
 			OnExit:                  t.OnExit,
 			OnLog:                   t.OnLog,
+			OnBalanceChange:         t.OnBalanceChange,
+			OnSelfDestruct:          t.OnSelfDestruct,
 			CaptureArbitrumTransfer: t.CaptureArbitrumTransfer,
 		},
 		GetResult: t.GetResult,
@@ -187,6 +216,8 @@ func (t *callTracer) OnEnter(depth int, typ byte, from common.Address, to common
 	}
 	if depth == 0 {
 		call.Gas = t.gasLimit
+		call.BalanceChanges = t.pendingBalanceChanges
+		t.pendingBalanceChanges = nil
 	}
 	t.callstack = append(t.callstack, call)
 }
@@ -264,6 +295,58 @@ func (t *callTracer) OnLog(log *types.Log) {
 	t.callstack[len(t.callstack)-1].Logs = append(t.callstack[len(t.callstack)-1].Logs, l)
 }
 
+// OnBalanceChange attributes a balance change, along with its reason, to the
+// currently executing frame. Changes happening before the top-level call is
+// entered are attributed to the top-level frame.
+func (t *callTracer) OnBalanceChange(addr common.Address, prev, current *big.Int, reason tracing.BalanceChangeReason) {
+	if !t.config.WithBalanceChanges {
+		return
+	}
+	// Avoid processing nested calls when only caring about top call
+	if t.config.OnlyTopCall && t.depth > 0 {
+		return
+	}
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	change := callBalanceChange{
+		Address: addr,
+		Prev:    (*hexutil.Big)(new(big.Int).Set(prev)),
+		New:     (*hexutil.Big)(new(big.Int).Set(current)),
+		Reason:  reason.String(),
+	}
+	if len(t.callstack) == 0 {
+		t.pendingBalanceChanges = append(t.pendingBalanceChanges, change)
+		return
+	}
+	frame := &t.callstack[len(t.callstack)-1]
+	change.Position = hexutil.Uint(len(frame.Calls))
+	frame.BalanceChanges = append(frame.BalanceChanges, change)
+}
+
+// OnSelfDestruct attaches where the balance went to the selfdestruct frame
+// just exited.
+func (t *callTracer) OnSelfDestruct(contract, beneficiary common.Address, transferred, burnt *big.Int, destructed bool) {
+	if t.config.OnlyTopCall || t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	parent := &t.callstack[len(t.callstack)-1]
+	if len(parent.Calls) == 0 {
+		return
+	}
+	call := &parent.Calls[len(parent.Calls)-1]
+	if call.Type != vm.SELFDESTRUCT || call.From != contract {
+		return
+	}
+	call.SelfDestruct = &callSelfDestruct{
+		Beneficiary: beneficiary,
+		Transferred: (*hexutil.Big)(new(big.Int).Set(transferred)),
+		Burnt:       (*hexutil.Big)(new(big.Int).Set(burnt)),
+		Destructed:  destructed,
+	}
+}
+
 // GetResult returns the json-encoded nested list of call traces, and any
 // error arising from the encoding or forceful termination (via `Stop`).
 func (t *callTracer) GetResult() (json.RawMessage, error) {
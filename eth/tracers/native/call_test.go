@@ -0,0 +1,101 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallTracerBalanceChanges(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xaa")
+		contract = common.HexToAddress("0xbb")
+		callee   = common.HexToAddress("0xcc")
+		coinbase = common.HexToAddress("0xdd")
+	)
+	run := func(cfg string) json.RawMessage {
+		tracer, err := tracers.DefaultDirectory.New("callTracer", &tracers.Context{}, json.RawMessage(cfg))
+		require.NoError(t, err)
+
+		tx := types.NewTx(&types.LegacyTx{To: &contract, Value: big.NewInt(0), Gas: 50000, GasPrice: big.NewInt(1)})
+		tracer.OnTxStart(&tracing.VMContext{ChainConfig: params.MainnetChainConfig}, tx, sender)
+		tracer.OnBalanceChange(sender, big.NewInt(100000), big.NewInt(50000), tracing.BalanceDecreaseGasBuy)
+		tracer.OnEnter(0, byte(vm.CALL), sender, contract, nil, 50000, big.NewInt(0))
+		tracer.OnEnter(1, byte(vm.CALL), contract, callee, nil, 10000, big.NewInt(5))
+		tracer.OnBalanceChange(contract, big.NewInt(10), big.NewInt(5), tracing.BalanceChangeTransfer)
+		tracer.OnBalanceChange(callee, big.NewInt(0), big.NewInt(5), tracing.BalanceChangeTransfer)
+		tracer.OnExit(1, nil, 100, nil, false)
+		tracer.OnExit(0, nil, 21000, nil, false)
+		tracer.OnBalanceChange(sender, big.NewInt(50000), big.NewInt(79000), tracing.BalanceIncreaseGasReturn)
+		tracer.OnBalanceChange(coinbase, big.NewInt(0), big.NewInt(21000), tracing.BalanceIncreaseRewardTransactionFee)
+		tracer.OnTxEnd(&types.Receipt{GasUsed: 21000}, nil)
+
+		res, err := tracer.GetResult()
+		require.NoError(t, err)
+		return res
+	}
+	type balanceChange struct {
+		Address  common.Address `json:"address"`
+		Prev     *hexutil.Big   `json:"prev"`
+		New      *hexutil.Big   `json:"new"`
+		Reason   string         `json:"reason"`
+		Position hexutil.Uint   `json:"position"`
+	}
+	type frame struct {
+		BalanceChanges []balanceChange `json:"balanceChanges"`
+		Calls          []frame         `json:"calls"`
+	}
+
+	// Balance changes are only collected when requested
+	var top frame
+	require.NoError(t, json.Unmarshal(run(`{}`), &top))
+	require.Empty(t, top.BalanceChanges)
+	require.Len(t, top.Calls, 1)
+	require.Empty(t, top.Calls[0].BalanceChanges)
+
+	top = frame{}
+	require.NoError(t, json.Unmarshal(run(`{"withBalanceChanges": true}`), &top))
+	require.Len(t, top.BalanceChanges, 3)
+	require.Equal(t, sender, top.BalanceChanges[0].Address)
+	require.Equal(t, "GasBuy", top.BalanceChanges[0].Reason)
+	require.Equal(t, hexutil.Uint(0), top.BalanceChanges[0].Position)
+	require.Equal(t, "GasReturn", top.BalanceChanges[1].Reason)
+	require.Equal(t, hexutil.Uint(1), top.BalanceChanges[1].Position)
+	require.Equal(t, coinbase, top.BalanceChanges[2].Address)
+	require.Equal(t, "RewardTransactionFee", top.BalanceChanges[2].Reason)
+	require.Equal(t, int64(21000), top.BalanceChanges[2].New.ToInt().Int64())
+
+	require.Len(t, top.Calls, 1)
+	inner := top.Calls[0].BalanceChanges
+	require.Len(t, inner, 2)
+	require.Equal(t, contract, inner[0].Address)
+	require.Equal(t, "Transfer", inner[0].Reason)
+	require.Equal(t, int64(10), inner[0].Prev.ToInt().Int64())
+	require.Equal(t, callee, inner[1].Address)
+	require.Equal(t, int64(5), inner[1].New.ToInt().Int64())
+}
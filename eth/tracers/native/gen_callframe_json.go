@@ -29,6 +29,8 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 		RevertReason       string              `json:"revertReason,omitempty"`
 		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
 		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
+		BalanceChanges     []callBalanceChange `json:"balanceChanges,omitempty" rlp:"optional"`
+		SelfDestruct       *callSelfDestruct   `json:"selfDestruct,omitempty" rlp:"optional"`
 		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
 		TypeString         string              `json:"type"`
 	}
@@ -46,6 +48,8 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.RevertReason = c.RevertReason
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
+	enc.BalanceChanges = c.BalanceChanges
+	enc.SelfDestruct = c.SelfDestruct
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
 	return json.Marshal(&enc)
@@ -67,6 +71,8 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 		RevertReason       *string             `json:"revertReason,omitempty"`
 		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
 		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
+		BalanceChanges     []callBalanceChange `json:"balanceChanges,omitempty" rlp:"optional"`
+		SelfDestruct       *callSelfDestruct   `json:"selfDestruct,omitempty" rlp:"optional"`
 		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
@@ -112,6 +118,12 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
+	if dec.BalanceChanges != nil {
+		c.BalanceChanges = dec.BalanceChanges
+	}
+	if dec.SelfDestruct != nil {
+		c.SelfDestruct = dec.SelfDestruct
+	}
 	if dec.Value != nil {
 		c.Value = (*big.Int)(dec.Value)
 	}
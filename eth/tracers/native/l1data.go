@@ -0,0 +1,85 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("l1DataTracer", newL1DataTracer, false)
+}
+
+// l1DataTracer reports the metering of the L1 data of a transaction: its size,
+// its estimated size once compressed, and the components of its L1 fee.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "l1DataTracer"})
+//	{
+//	  calldataSize: "0x7a",
+//	  compressedSize: "0x64",
+//	  compressionLevel: "0xb",
+//	  units: "0x640",
+//	  pricePerUnit: "0x3b9aca00",
+//	  posterCost: "0x5d21dba000",
+//	  gasForL1: "0x3a98"
+//	}
+type l1DataTracer struct {
+	usage     *types.L1DataUsage
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+func newL1DataTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := new(l1DataTracer)
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnL1DataUsage: t.OnL1DataUsage,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *l1DataTracer) OnL1DataUsage(usage *types.L1DataUsage) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.usage = usage
+}
+
+// GetResult returns the metering of the L1 data, null if the transaction was
+// not metered.
+func (t *l1DataTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.usage)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *l1DataTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -75,6 +75,13 @@ func newMuxTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, e
 			CaptureArbitrumStorageGet: t.CaptureArbitrumStorageGet,
 			CaptureArbitrumStorageSet: t.CaptureArbitrumStorageSet,
 			CaptureStylusHostio:       t.CaptureStylusHostio,
+			CaptureStylusModule:       t.CaptureStylusModule,
+			CaptureStylusCache:        t.CaptureStylusCache,
+			OnWasmMemoryGrow:          t.OnWasmMemoryGrow,
+			OnRefundChange:            t.OnRefundChange,
+			OnRefund:                  t.OnRefund,
+			OnL1DataUsage:             t.OnL1DataUsage,
+			OnSelfDestruct:            t.OnSelfDestruct,
 		},
 		GetResult: t.GetResult,
 		Stop:      t.Stop,
@@ -209,6 +216,62 @@ func (t *muxTracer) CaptureStylusHostio(name string, args, outs []byte, startInk
 	}
 }
 
+func (t *muxTracer) CaptureStylusModule(moduleHash common.Hash, activated bool) {
+	for _, t := range t.tracers {
+		if t.CaptureStylusModule != nil {
+			t.CaptureStylusModule(moduleHash, activated)
+		}
+	}
+}
+
+func (t *muxTracer) CaptureStylusCache(moduleHash common.Hash, version uint16, tag uint32, debug bool, cached bool) {
+	for _, t := range t.tracers {
+		if t.CaptureStylusCache != nil {
+			t.CaptureStylusCache(moduleHash, version, tag, debug, cached)
+		}
+	}
+}
+
+func (t *muxTracer) OnWasmMemoryGrow(addr common.Address, pages uint16, cost uint64) {
+	for _, t := range t.tracers {
+		if t.OnWasmMemoryGrow != nil {
+			t.OnWasmMemoryGrow(addr, pages, cost)
+		}
+	}
+}
+
+func (t *muxTracer) OnRefundChange(prev, new uint64, reverted bool) {
+	for _, t := range t.tracers {
+		if t.OnRefundChange != nil {
+			t.OnRefundChange(prev, new, reverted)
+		}
+	}
+}
+
+func (t *muxTracer) OnRefund(counter, quotient, nonrefundable, limit, refund uint64) {
+	for _, t := range t.tracers {
+		if t.OnRefund != nil {
+			t.OnRefund(counter, quotient, nonrefundable, limit, refund)
+		}
+	}
+}
+
+func (t *muxTracer) OnL1DataUsage(usage *types.L1DataUsage) {
+	for _, t := range t.tracers {
+		if t.OnL1DataUsage != nil {
+			t.OnL1DataUsage(usage)
+		}
+	}
+}
+
+func (t *muxTracer) OnSelfDestruct(contract, beneficiary common.Address, transferred, burnt *big.Int, destructed bool) {
+	for _, t := range t.tracers {
+		if t.OnSelfDestruct != nil {
+			t.OnSelfDestruct(contract, beneficiary, transferred, burnt, destructed)
+		}
+	}
+}
+
 // GetResult returns an empty json object.
 func (t *muxTracer) GetResult() (json.RawMessage, error) {
 	resObject := make(map[string]json.RawMessage)
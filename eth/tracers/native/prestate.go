@@ -68,6 +68,7 @@ type prestateTracer struct {
 	reason    error       // Textual reason for the interruption
 	created   map[common.Address]bool
 	deleted   map[common.Address]bool
+	stylus    stylusDiff // Arbitrum: Stylus activity, reported in diff mode
 }
 
 type prestateTracerConfig struct {
@@ -93,6 +94,9 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Trac
 			OnTxStart: t.OnTxStart,
 			OnTxEnd:   t.OnTxEnd,
 			OnOpcode:  t.OnOpcode,
+
+			CaptureStylusModule: t.CaptureStylusModule,
+			CaptureStylusCache:  t.CaptureStylusCache,
 		},
 		GetResult: t.GetResult,
 		Stop:      t.Stop,
@@ -182,10 +186,15 @@ func (t *prestateTracer) GetResult() (json.RawMessage, error) {
 	var res []byte
 	var err error
 	if t.config.DiffMode {
+		var stylus *stylusDiff
+		if !t.stylus.empty() {
+			stylus = &t.stylus
+		}
 		res, err = json.Marshal(struct {
-			Post stateMap `json:"post"`
-			Pre  stateMap `json:"pre"`
-		}{t.post, t.pre})
+			Post   stateMap    `json:"post"`
+			Pre    stateMap    `json:"pre"`
+			Stylus *stylusDiff `json:"stylus,omitempty"`
+		}{t.post, t.pre, stylus})
 	} else {
 		res, err = json.Marshal(t.pre)
 	}
@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrestateTracerStylusDiff(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("prestateTracer", &tracers.Context{}, json.RawMessage(`{"diffMode":true}`))
+	require.NoError(t, err)
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	statedb.SetLogger(tracer.Hooks)
+
+	var (
+		activated = common.HexToHash("0x01")
+		cached    = common.HexToHash("0x02")
+		target    = rawdb.LocalTarget()
+	)
+	statedb.ActivateWasm(activated, map[ethdb.WasmTarget][]byte{target: {0x00}})
+	statedb.ActivateWasm(activated, map[ethdb.WasmTarget][]byte{target: {0x00}}) // already active, not reported
+	_, err = statedb.TryGetActivatedAsm(target, activated)
+	require.NoError(t, err)
+	_, err = statedb.TryGetActivatedAsmMap([]ethdb.WasmTarget{target}, activated)
+	require.NoError(t, err)
+	statedb.RecordCacheWasm(state.CacheWasm{ModuleHash: cached, Version: 1, Tag: 1})
+	statedb.RecordEvictWasm(state.EvictWasm{ModuleHash: cached, Version: 1, Tag: 1, Debug: true})
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var diff struct {
+		Stylus struct {
+			Loaded    []common.Hash `json:"loaded"`
+			Activated []common.Hash `json:"activated"`
+			Cache     []struct {
+				ModuleHash common.Hash `json:"moduleHash"`
+				Debug      bool        `json:"debug"`
+				Evicted    bool        `json:"evicted"`
+			} `json:"cache"`
+		} `json:"stylus"`
+	}
+	require.NoError(t, json.Unmarshal(res, &diff))
+	require.Equal(t, []common.Hash{activated}, diff.Stylus.Loaded)
+	require.Equal(t, []common.Hash{activated}, diff.Stylus.Activated)
+	require.Len(t, diff.Stylus.Cache, 2)
+	require.False(t, diff.Stylus.Cache[0].Evicted)
+	require.True(t, diff.Stylus.Cache[1].Evicted)
+	require.True(t, diff.Stylus.Cache[1].Debug)
+
+	// Transactions without Stylus activity keep the legacy output
+	tracer, err = tracers.DefaultDirectory.New("prestateTracer", &tracers.Context{}, json.RawMessage(`{"diffMode":true}`))
+	require.NoError(t, err)
+	res, err = tracer.GetResult()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"post":{},"pre":{}}`, string(res))
+}
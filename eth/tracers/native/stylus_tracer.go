@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stylusTracer", newStylusTracer, false)
+}
+
+// hostioTrace is a single host I/O performed by a Stylus program.
+type hostioTrace struct {
+	Name     string         `json:"name"`
+	Depth    int            `json:"depth"`
+	Address  common.Address `json:"address"`
+	Args     hexutil.Bytes  `json:"args,omitempty"`
+	Outs     hexutil.Bytes  `json:"outs,omitempty"`
+	StartInk uint64         `json:"startInk"`
+	EndInk   uint64         `json:"endInk"`
+	InkUsed  uint64         `json:"inkUsed"`
+}
+
+// stylusTracer records every host I/O made by the Stylus programs executed
+// within a transaction, in execution order. Each entry carries the call depth
+// and the address of the program that issued it, so the trace of nested calls
+// can be reconstructed from the flat list.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "stylusTracer"})
+//	[
+//	  {name: "user_entrypoint", depth: 0, address: "0x...", args: "0x00000004", startInk: 99990000, endInk: 99990000, inkUsed: 0},
+//	  {name: "storage_load_bytes32", depth: 0, address: "0x...", args: "0x00..", outs: "0x00..", startInk: 99989000, endInk: 99969000, inkUsed: 20000},
+//	  ...
+//	]
+type stylusTracer struct {
+	hostios   []hostioTrace
+	frames    []common.Address // Code address of every open call frame
+	interrupt atomic.Bool      // Atomic flag to signal execution interruption
+	reason    error            // Textual reason for the interruption
+}
+
+// newStylusTracer returns a native go tracer which records the host I/Os
+// of Stylus programs.
+func newStylusTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := &stylusTracer{}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnEnter:             t.OnEnter,
+			OnExit:              t.OnExit,
+			CaptureStylusHostio: t.CaptureStylusHostio,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+// OnEnter tracks the code address of every call frame, as Stylus programs
+// don't report which program issued a host I/O.
+func (t *stylusTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, to)
+}
+
+// OnExit pops the code address of the exited call frame.
+func (t *stylusTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.frames) > 0 {
+		t.frames = t.frames[:len(t.frames)-1]
+	}
+}
+
+// CaptureStylusHostio records a host I/O of the currently executing program.
+func (t *stylusTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	trace := hostioTrace{
+		Name:     name,
+		Args:     common.CopyBytes(args),
+		Outs:     common.CopyBytes(outs),
+		StartInk: startInk,
+		EndInk:   endInk,
+	}
+	if startInk > endInk {
+		trace.InkUsed = startInk - endInk
+	}
+	if len(t.frames) > 0 {
+		trace.Depth = len(t.frames) - 1
+		trace.Address = t.frames[len(t.frames)-1]
+	}
+	t.hostios = append(t.hostios, trace)
+}
+
+// GetResult returns the json-encoded list of host I/Os, and any error arising
+// from the encoding or forceful termination (via `Stop`).
+func (t *stylusTracer) GetResult() (json.RawMessage, error) {
+	hostios := t.hostios
+	if hostios == nil {
+		hostios = []hostioTrace{}
+	}
+	res, err := json.Marshal(hostios)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *stylusTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
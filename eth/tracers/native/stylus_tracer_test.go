@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStylusTracer(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("stylusTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+
+	var (
+		outer = common.HexToAddress("0xaa")
+		inner = common.HexToAddress("0xbb")
+		key   = common.HexToHash("0x01")
+	)
+	tracer.OnEnter(0, byte(vm.CALL), common.Address{}, outer, nil, 0, big.NewInt(0))
+	tracer.CaptureStylusHostio("storage_load_bytes32", key[:], common.Hash{}.Bytes(), 1000, 900)
+	tracer.OnEnter(1, byte(vm.CALL), outer, inner, nil, 0, big.NewInt(0))
+	tracer.CaptureStylusHostio("pay_for_memory_grow", []byte{0, 1}, nil, 800, 750)
+	tracer.OnExit(1, nil, 0, nil, false)
+	tracer.CaptureStylusHostio("call_contract", inner[:], nil, 900, 500)
+	tracer.OnExit(0, nil, 0, nil, false)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var hostios []struct {
+		Name    string         `json:"name"`
+		Depth   int            `json:"depth"`
+		Address common.Address `json:"address"`
+		Args    hexutil.Bytes  `json:"args"`
+		InkUsed uint64         `json:"inkUsed"`
+	}
+	require.NoError(t, json.Unmarshal(res, &hostios))
+	require.Len(t, hostios, 3)
+
+	require.Equal(t, "storage_load_bytes32", hostios[0].Name)
+	require.Equal(t, outer, hostios[0].Address)
+	require.Equal(t, 0, hostios[0].Depth)
+	require.Equal(t, key[:], []byte(hostios[0].Args))
+	require.Equal(t, uint64(100), hostios[0].InkUsed)
+
+	require.Equal(t, "pay_for_memory_grow", hostios[1].Name)
+	require.Equal(t, inner, hostios[1].Address)
+	require.Equal(t, 1, hostios[1].Depth)
+
+	require.Equal(t, "call_contract", hostios[2].Name)
+	require.Equal(t, outer, hostios[2].Address)
+	require.Equal(t, uint64(400), hostios[2].InkUsed)
+}
+
+func TestStylusTracerStop(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("stylusTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+
+	stopError := errors.New("stop error")
+	tracer.OnEnter(0, byte(vm.CALL), common.Address{}, common.Address{}, nil, 0, big.NewInt(0))
+	tracer.Stop(stopError)
+	tracer.CaptureStylusHostio("storage_load_bytes32", nil, nil, 10, 5)
+
+	res, tracerError := tracer.GetResult()
+	require.Equal(t, stopError, tracerError)
+	require.JSONEq(t, "[]", string(res))
+}
@@ -18,6 +18,7 @@ package native
 
 import (
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -74,6 +75,54 @@ func (t *flatCallTracer) CaptureArbitrumTransfer(from, to *common.Address, value
 	}
 }
 
+// stylusDiff is the Stylus activity of a transaction, reported by the prestate
+// tracer in diff mode so that Stylus transactions can be re-simulated from the
+// trace alone: the modules whose asm was loaded, the modules activated, and
+// the changes to the cache of the Stylus runtime, in order of occurrence.
+type stylusDiff struct {
+	Loaded    []common.Hash       `json:"loaded,omitempty"`
+	Activated []common.Hash       `json:"activated,omitempty"`
+	Cache     []stylusCacheChange `json:"cache,omitempty"`
+}
+
+type stylusCacheChange struct {
+	ModuleHash common.Hash `json:"moduleHash"`
+	Version    uint16      `json:"version"`
+	Tag        uint32      `json:"tag"`
+	Debug      bool        `json:"debug,omitempty"`
+	Evicted    bool        `json:"evicted,omitempty"`
+}
+
+func (d *stylusDiff) empty() bool {
+	return len(d.Loaded) == 0 && len(d.Activated) == 0 && len(d.Cache) == 0
+}
+
+func (t *prestateTracer) CaptureStylusModule(moduleHash common.Hash, activated bool) {
+	if !t.config.DiffMode || t.interrupt.Load() {
+		return
+	}
+	if activated {
+		if !slices.Contains(t.stylus.Activated, moduleHash) {
+			t.stylus.Activated = append(t.stylus.Activated, moduleHash)
+		}
+	} else if !slices.Contains(t.stylus.Loaded, moduleHash) {
+		t.stylus.Loaded = append(t.stylus.Loaded, moduleHash)
+	}
+}
+
+func (t *prestateTracer) CaptureStylusCache(moduleHash common.Hash, version uint16, tag uint32, debug bool, cached bool) {
+	if !t.config.DiffMode || t.interrupt.Load() {
+		return
+	}
+	t.stylus.Cache = append(t.stylus.Cache, stylusCacheChange{
+		ModuleHash: moduleHash,
+		Version:    version,
+		Tag:        tag,
+		Debug:      debug,
+		Evicted:    !cached,
+	})
+}
+
 func bigToHex(n *big.Int) string {
 	if n == nil {
 		return ""
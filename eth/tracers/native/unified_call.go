@@ -0,0 +1,268 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("unifiedCallTracer", newUnifiedCallTracer, false)
+}
+
+const (
+	runtimeEVM    = "evm"
+	runtimeStylus = "stylus"
+)
+
+// unifiedHostio is a host I/O performed by the Stylus program of a frame.
+type unifiedHostio struct {
+	Name     string         `json:"name"`
+	Args     hexutil.Bytes  `json:"args,omitempty"`
+	Outs     hexutil.Bytes  `json:"outs,omitempty"`
+	StartInk hexutil.Uint64 `json:"startInk"`
+	EndInk   hexutil.Uint64 `json:"endInk"`
+	// Position of the host I/O relative to subcalls within the same frame
+	Position hexutil.Uint `json:"position"`
+}
+
+// unifiedFrame is a call frame of either runtime. The Stylus specific fields
+// are only set for the frames executing a Stylus program.
+type unifiedFrame struct {
+	Type         string          `json:"type"`
+	Runtime      string          `json:"runtime"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	ModuleHash   *common.Hash    `json:"moduleHash,omitempty"`
+	Ink          *hexutil.Uint64 `json:"ink,omitempty"`     // Ink available to the program on entry
+	InkUsed      *hexutil.Uint64 `json:"inkUsed,omitempty"` // Ink used by the program, including by its subcalls
+	Hostios      []unifiedHostio `json:"hostios,omitempty"`
+	Calls        []*unifiedFrame `json:"calls,omitempty"`
+
+	op     vm.OpCode
+	endInk uint64 // Ink left after the last host I/O of the program
+}
+
+func (f *unifiedFrame) processOutput(output []byte, gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	if f.Ink != nil && uint64(*f.Ink) >= f.endInk {
+		used := hexutil.Uint64(uint64(*f.Ink) - f.endInk)
+		f.InkUsed = &used
+	}
+	output = common.CopyBytes(output)
+	if err == nil {
+		f.Output = output
+		return
+	}
+	f.Error = err.Error()
+	if f.op == vm.CREATE || f.op == vm.CREATE2 {
+		f.To = nil
+	}
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = output
+	if unpacked, err := abi.UnpackRevert(output); err == nil {
+		f.RevertReason = unpacked
+	}
+}
+
+type unifiedCallTracerConfig struct {
+	WithHostios bool `json:"withHostios"` // If true, the host I/Os of the Stylus programs are collected
+}
+
+// unifiedCallTracer records the call frames of a transaction as a single tree,
+// the frames executing EVM bytecode and Stylus programs sharing a schema, so
+// that debuggers don't need to stitch the callTracer and stylusTracer outputs
+// together. The Stylus frames additionally carry the hash of the module
+// executed and the ink used, and optionally the host I/Os performed.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "unifiedCallTracer", tracerConfig: {withHostios: true}})
+//	{
+//	  type: "CALL", runtime: "stylus", from: "0x...", to: "0x...", gas: "0x...", gasUsed: "0x...", input: "0x...",
+//	  moduleHash: "0x...", ink: "0x...", inkUsed: "0x...",
+//	  hostios: [{name: "user_entrypoint", startInk: "0x...", endInk: "0x...", position: "0x0"}, ...],
+//	  calls: [{type: "STATICCALL", runtime: "evm", ...}]
+//	}
+type unifiedCallTracer struct {
+	config    unifiedCallTracerConfig
+	statedb   tracing.StateDB
+	gasLimit  uint64
+	callstack []*unifiedFrame
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newUnifiedCallTracer returns a native go tracer which tracks the EVM and
+// Stylus call frames of a transaction in a single tree.
+func newUnifiedCallTracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	var config unifiedCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	t := &unifiedCallTracer{config: config}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:           t.OnTxStart,
+			OnTxEnd:             t.OnTxEnd,
+			OnEnter:             t.OnEnter,
+			OnExit:              t.OnExit,
+			CaptureStylusHostio: t.CaptureStylusHostio,
+			CaptureStylusModule: t.CaptureStylusModule,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *unifiedCallTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.statedb = env.StateDB
+	t.gasLimit = tx.Gas()
+}
+
+func (t *unifiedCallTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	// Error happened during tx validation.
+	if err != nil || len(t.callstack) == 0 {
+		return
+	}
+	t.callstack[0].GasUsed = hexutil.Uint64(receipt.GasUsed)
+}
+
+// OnEnter opens a frame, determining its runtime from the code executed.
+func (t *unifiedCallTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	op := vm.OpCode(typ)
+	frame := &unifiedFrame{
+		Type:    op.String(),
+		Runtime: runtimeEVM,
+		From:    from,
+		To:      &to,
+		Gas:     hexutil.Uint64(gas),
+		Input:   common.CopyBytes(input),
+		op:      op,
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	if depth == 0 && t.gasLimit != 0 {
+		frame.Gas = hexutil.Uint64(t.gasLimit)
+	}
+	// The code of the destination is run, except for the creations
+	if op != vm.CREATE && op != vm.CREATE2 && t.statedb != nil && state.IsStylusProgram(t.statedb.GetCode(to)) {
+		frame.Runtime = runtimeStylus
+	}
+	t.callstack = append(t.callstack, frame)
+}
+
+// OnExit closes the innermost frame, nesting it into its parent.
+func (t *unifiedCallTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	size := len(t.callstack)
+	if size == 0 {
+		return
+	}
+	frame := t.callstack[size-1]
+	frame.processOutput(output, gasUsed, err)
+	if size == 1 {
+		return
+	}
+	t.callstack = t.callstack[:size-1]
+	parent := t.callstack[size-2]
+	parent.Calls = append(parent.Calls, frame)
+}
+
+// CaptureStylusHostio accounts a host I/O to the innermost frame, the first
+// one carrying the ink available to the program.
+func (t *unifiedCallTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	frame := t.callstack[len(t.callstack)-1]
+	if frame.Ink == nil {
+		ink := hexutil.Uint64(startInk)
+		frame.Ink = &ink
+	}
+	frame.endInk = endInk
+
+	if t.config.WithHostios {
+		frame.Hostios = append(frame.Hostios, unifiedHostio{
+			Name:     name,
+			Args:     common.CopyBytes(args),
+			Outs:     common.CopyBytes(outs),
+			StartInk: hexutil.Uint64(startInk),
+			EndInk:   hexutil.Uint64(endInk),
+			Position: hexutil.Uint(len(frame.Calls)),
+		})
+	}
+}
+
+// CaptureStylusModule attributes the module loaded to the innermost Stylus
+// frame, as the program's asm is loaded right before its execution.
+func (t *unifiedCallTracer) CaptureStylusModule(moduleHash common.Hash, activated bool) {
+	if activated || t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	frame := t.callstack[len(t.callstack)-1]
+	if frame.Runtime == runtimeStylus && frame.ModuleHash == nil {
+		frame.ModuleHash = &moduleHash
+	}
+}
+
+// GetResult returns the json-encoded tree of call frames, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *unifiedCallTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *unifiedCallTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedCallTracer(t *testing.T) {
+	tracer, err := tracers.DefaultDirectory.New("unifiedCallTracer", &tracers.Context{}, json.RawMessage(`{"withHostios":true}`))
+	require.NoError(t, err)
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+
+	var (
+		sender  = common.HexToAddress("0x01")
+		program = common.HexToAddress("0xaa")
+		solid   = common.HexToAddress("0xbb")
+		module  = common.HexToHash("0x02")
+	)
+	statedb.SetCode(program, append(append([]byte{}, state.StylusDiscriminant...), 0x00, 0x00))
+	statedb.SetCode(solid, []byte{byte(vm.STOP)})
+
+	tx := types.NewTransaction(0, program, big.NewInt(0), 100000, big.NewInt(1), []byte{0x01})
+	tracer.OnTxStart(&tracing.VMContext{StateDB: statedb}, tx, sender)
+	tracer.OnEnter(0, byte(vm.CALL), sender, program, []byte{0x01}, 90000, big.NewInt(0))
+	tracer.CaptureStylusModule(module, false)
+	tracer.CaptureStylusHostio("user_entrypoint", []byte{0, 0, 0, 1}, nil, 1000, 1000)
+	tracer.OnEnter(1, byte(vm.STATICCALL), program, solid, []byte{0x02}, 500, nil)
+	tracer.OnExit(1, []byte{0x03}, 100, nil, false)
+	tracer.CaptureStylusHostio("static_call_contract", solid[:], nil, 900, 600)
+	tracer.CaptureStylusHostio("user_returned", nil, nil, 600, 600)
+	tracer.OnExit(0, []byte{0x04}, 2000, nil, false)
+	tracer.OnTxEnd(&types.Receipt{GasUsed: 23000}, nil)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	type hostio struct {
+		Name     string       `json:"name"`
+		Position hexutil.Uint `json:"position"`
+	}
+	type frame struct {
+		Type       string          `json:"type"`
+		Runtime    string          `json:"runtime"`
+		To         common.Address  `json:"to"`
+		Gas        hexutil.Uint64  `json:"gas"`
+		GasUsed    hexutil.Uint64  `json:"gasUsed"`
+		Output     hexutil.Bytes   `json:"output"`
+		ModuleHash *common.Hash    `json:"moduleHash"`
+		Ink        *hexutil.Uint64 `json:"ink"`
+		InkUsed    *hexutil.Uint64 `json:"inkUsed"`
+		Hostios    []hostio        `json:"hostios"`
+		Calls      []frame         `json:"calls"`
+	}
+	var top frame
+	require.NoError(t, json.Unmarshal(res, &top))
+
+	require.Equal(t, "CALL", top.Type)
+	require.Equal(t, "stylus", top.Runtime)
+	require.Equal(t, hexutil.Uint64(100000), top.Gas)
+	require.Equal(t, hexutil.Uint64(23000), top.GasUsed)
+	require.Equal(t, []byte{0x04}, []byte(top.Output))
+	require.Equal(t, &module, top.ModuleHash)
+	require.Equal(t, hexutil.Uint64(1000), *top.Ink)
+	require.Equal(t, hexutil.Uint64(400), *top.InkUsed)
+	require.Equal(t, []hostio{{"user_entrypoint", 0}, {"static_call_contract", 1}, {"user_returned", 1}}, top.Hostios)
+
+	require.Len(t, top.Calls, 1)
+	inner := top.Calls[0]
+	require.Equal(t, "STATICCALL", inner.Type)
+	require.Equal(t, "evm", inner.Runtime)
+	require.Equal(t, solid, inner.To)
+	require.Equal(t, hexutil.Uint64(100), inner.GasUsed)
+	require.Nil(t, inner.ModuleHash)
+	require.Nil(t, inner.Ink)
+	require.Empty(t, inner.Hostios)
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// blockTraceFrame is a single frame of a streamed block trace: either the trace
+// of one transaction, or the final frame of the stream. The final frame holds
+// the number of transactions traced, and the error that stopped the tracing
+// if any.
+type blockTraceFrame struct {
+	TxIndex hexutil.Uint `json:"txIndex"`
+	*txTraceResult
+	Done bool `json:"done,omitempty"`
+}
+
+// BlockTraceFile is the handle of a block trace written to a file.
+type BlockTraceFile struct {
+	File         string         `json:"file"`         // Path of the trace file on the server
+	Transactions hexutil.Uint   `json:"transactions"` // Number of transactions traced
+	Size         hexutil.Uint64 `json:"size"`         // Size of the trace file in bytes
+}
+
+// blockByNumberOrHash returns the block with the given number or hash.
+func (api *API) blockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return api.blockByHash(ctx, hash)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
+		return api.blockByNumber(ctx, number)
+	}
+	return nil, errors.New("invalid arguments; neither block number nor hash specified")
+}
+
+// TraceBlockStream is the streaming counterpart of traceBlockByNumber and
+// traceBlockByHash. Instead of returning all the transaction traces of the
+// block at once, which may take hundreds of megabytes for large blocks, the
+// traces are sent one frame at a time as notifications, followed by a final
+// frame marked done. Tracing only advances as fast as the frames are written
+// to the connection, so a slow subscriber slows the tracing down instead of
+// piling up traces on the node.
+func (api *API) TraceBlockStream(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceConfig) (*rpc.Subscription, error) {
+	block, err := api.blockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	go func() {
+		// The request context ends with the subscription call, tie the tracing
+		// to the subscription instead.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-sub.Err():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		traced, err := api.streamBlock(ctx, block, config, func(frame *blockTraceFrame) error {
+			return notifier.Notify(sub.ID, frame)
+		})
+		final := &blockTraceFrame{TxIndex: hexutil.Uint(traced), Done: true}
+		if err != nil {
+			if ctx.Err() != nil {
+				return // subscriber gone
+			}
+			final.txTraceResult = &txTraceResult{Error: err.Error()}
+		}
+		notifier.Notify(sub.ID, final)
+	}()
+	return sub, nil
+}
+
+// TraceBlockToFile traces all the transactions of a block like traceBlockByNumber
+// and traceBlockByHash, but writes the traces to a file on the server instead
+// of returning them, one JSON encoded frame per line. The traces are written
+// as they're produced, so the node never holds more than one of them at once.
+// The returned handle holds the path of the file.
+func (api *API) TraceBlockToFile(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceConfig) (*BlockTraceFile, error) {
+	block, err := api.blockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	dump, err := os.CreateTemp(os.TempDir(), fmt.Sprintf("block_%#x-trace-", block.Hash().Bytes()[:4]))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		writer = bufio.NewWriter(dump)
+		enc    = json.NewEncoder(writer)
+	)
+	traced, err := api.streamBlock(ctx, block, config, func(frame *blockTraceFrame) error {
+		return enc.Encode(frame)
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = dump.Sync()
+	}
+	if closeErr := dump.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dump.Name())
+		return nil, err
+	}
+	info, err := os.Stat(dump.Name())
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Wrote block trace", "number", block.NumberU64(), "hash", block.Hash(), "file", dump.Name(), "size", info.Size())
+	return &BlockTraceFile{
+		File:         dump.Name(),
+		Transactions: hexutil.Uint(traced),
+		Size:         hexutil.Uint64(info.Size()),
+	}, nil
+}
+
+// streamBlock traces all the transactions of the given block sequentially,
+// handing the trace of each transaction to the given callback as soon as it's
+// produced. It returns the number of transactions traced.
+func (api *API) streamBlock(ctx context.Context, block *types.Block, config *TraceConfig, emit func(*blockTraceFrame) error) (int, error) {
+	statedb, release, err := api.blockTraceState(ctx, block, config)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	var traced int
+	err = api.traceBlockTxs(ctx, block, statedb, config, func(i int, result *txTraceResult) error {
+		if err := emit(&blockTraceFrame{TxIndex: hexutil.Uint(i), txTraceResult: result}); err != nil {
+			return err
+		}
+		traced++
+		return nil
+	})
+	return traced, err
+}
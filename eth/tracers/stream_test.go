@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newStreamTestAPI creates a tracing API over a chain with a single block of
+// the given number of transfers.
+func newStreamTestAPI(t *testing.T, txs int) (*API, *testBackend) {
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for nonce := 0; nonce < txs; nonce++ {
+			tx, _ := types.SignTx(types.NewTransaction(uint64(nonce), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), types.HomesteadSigner{}, accounts[0].key)
+			b.AddTx(tx)
+		}
+	})
+	return NewAPI(backend), backend
+}
+
+func TestTraceBlockStream(t *testing.T) {
+	t.Parallel()
+
+	api, backend := newStreamTestAPI(t, 5)
+	defer backend.chain.Stop()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", api); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	want, err := api.TraceBlockByNumber(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	frames := make(chan json.RawMessage)
+	sub, err := client.Subscribe(context.Background(), "debug", frames, "traceBlockStream", rpc.BlockNumberOrHashWithNumber(1), nil)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; ; i++ {
+		var frame json.RawMessage
+		select {
+		case frame = <-frames:
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for trace frame")
+		}
+		if i == len(want) {
+			if have, want := string(frame), `{"txIndex":"0x5","done":true}`; have != want {
+				t.Fatalf("final frame mismatch: have %s, want %s", have, want)
+			}
+			break
+		}
+		expect, _ := json.Marshal(&blockTraceFrame{TxIndex: hexutil.Uint(i), txTraceResult: want[i]})
+		if string(frame) != string(expect) {
+			t.Fatalf("frame %d mismatch: have %s, want %s", i, frame, expect)
+		}
+	}
+}
+
+func TestTraceBlockToFile(t *testing.T) {
+	t.Parallel()
+
+	api, backend := newStreamTestAPI(t, 3)
+	defer backend.chain.Stop()
+
+	want, err := api.TraceBlockByNumber(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	handle, err := api.TraceBlockToFile(context.Background(), rpc.BlockNumberOrHashWithNumber(1), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block to file: %v", err)
+	}
+	defer os.Remove(handle.File)
+
+	if handle.Transactions != 3 {
+		t.Fatalf("traced transaction count mismatch: have %d, want 3", handle.Transactions)
+	}
+	file, err := os.Open(handle.File)
+	if err != nil {
+		t.Fatalf("failed to open trace file: %v", err)
+	}
+	defer file.Close()
+
+	var (
+		scanner = bufio.NewScanner(file)
+		lines   int
+	)
+	for ; scanner.Scan(); lines++ {
+		expect, _ := json.Marshal(&blockTraceFrame{TxIndex: hexutil.Uint(lines), txTraceResult: want[lines]})
+		if have := scanner.Text(); have != string(expect) {
+			t.Fatalf("line %d mismatch: have %s, want %s", lines, have, expect)
+		}
+	}
+	if lines != len(want) {
+		t.Fatalf("trace line count mismatch: have %d, want %d", lines, len(want))
+	}
+	if _, err := api.TraceBlockToFile(context.Background(), rpc.BlockNumberOrHashWithNumber(0), nil); err == nil {
+		t.Fatal("genesis traced to file")
+	}
+}
@@ -18,6 +18,18 @@ type ExtraOptions struct {
 	TargetByteDeletionRate      int
 	Experimental                ExtraOptionsExperimental
 	Levels                      []ExtraLevelOptions
+
+	// BlockCacheSize is the size of the block cache in bytes, overriding the
+	// cache allowance of the database (0 = the cache allowance)
+	BlockCacheSize int64
+
+	// MemTableSize is the size of a memory table in bytes, overriding the half of
+	// the cache allowance split between the memory tables (0 = derived)
+	MemTableSize int
+
+	// DisableWAL disables the write-ahead log, losing the writes not flushed on
+	// a crash, for nodes able to recover them from their parent chain
+	DisableWAL bool
 }
 
 type ExtraOptionsExperimental struct {
@@ -33,4 +45,34 @@ type ExtraLevelOptions struct {
 	BlockSize      int
 	IndexBlockSize int
 	TargetFileSize int64
+
+	// FilterBits is the number of bits per key of the bloom filters of the level
+	// (0 = 10 bits, negative = no filter)
+	FilterBits int
+}
+
+// ArbitrumProfile returns the tuning of the database for high-throughput
+// Arbitrum nodes, writing blocks at a high rate and keeping large states: larger
+// memory tables and tables absorb the write bursts of the sequencer, with more
+// level-zero headroom before the writes stall, and the syncs are spread over the
+// writes. Its fields can be overridden from the configuration of the node.
+func ArbitrumProfile() *ExtraOptions {
+	levels := make([]ExtraLevelOptions, 7)
+	for i := range levels {
+		levels[i] = ExtraLevelOptions{
+			BlockSize:      32 * 1024,
+			IndexBlockSize: 256 * 1024,
+			TargetFileSize: (2 << i) * 1024 * 1024,
+		}
+	}
+	return &ExtraOptions{
+		BytesPerSync:                1024 * 1024,
+		L0CompactionFileThreshold:   2048,
+		L0CompactionThreshold:       4,
+		L0StopWritesThreshold:       24,
+		LBaseMaxBytes:               64 * 1024 * 1024,
+		MemTableStopWritesThreshold: 4,
+		WALBytesPerSync:             1024 * 1024,
+		Levels:                      levels,
+	}
 }
@@ -76,6 +76,16 @@ type Database struct {
 
 	compDebtGauge       metrics.Gauge
 	compInProgressGauge metrics.Gauge
+	readAmpGauge        metrics.Gauge // Gauge for tracking the read amplification of the LSM
+
+	memTableSizeGauge   metrics.Gauge // Gauge for tracking the size of the memory tables
+	memTableCountGauge  metrics.Gauge // Gauge for tracking the number of memory tables
+	blockCacheSizeGauge metrics.Gauge // Gauge for tracking the size of the block cache
+	blockCacheHitMeter  metrics.Meter // Meter for measuring the block cache hits
+	blockCacheMissMeter metrics.Meter // Meter for measuring the block cache misses
+	filterHitMeter      metrics.Meter // Meter for measuring the data block reads avoided by the bloom filters
+	filterMissMeter     metrics.Meter // Meter for measuring the data block reads not avoided by the bloom filters
+	walSizeGauge        metrics.Gauge // Gauge for tracking the size of the live write-ahead logs
 
 	commitCountMeter               metrics.Meter
 	commitTotalDurationMeter       metrics.Meter
@@ -95,6 +105,7 @@ type Database struct {
 
 	level0SublevelsGauge metrics.Gauge
 	levelsGauge          []metrics.Gauge // Gauge for tracking the number of tables in levels
+	levelSizesGauge      []metrics.Gauge // Gauge for tracking the size of the tables in levels
 
 	quitLock sync.RWMutex    // Mutex protecting the quit channel and the closed flag
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
@@ -164,6 +175,19 @@ func (l panicLogger) Fatalf(format string, args ...interface{}) {
 	panic(fmt.Errorf("fatal: "+format, args...))
 }
 
+// filterPolicy returns the bloom filter policy with the given number of bits per
+// key, 10 by default, or nil if disabled.
+func filterPolicy(bits int) pebble.FilterPolicy {
+	switch {
+	case bits < 0:
+		return nil
+	case bits == 0:
+		return bloom.FilterPolicy(10)
+	default:
+		return bloom.FilterPolicy(bits)
+	}
+}
+
 // New returns a wrapped pebble DB object. The namespace is the prefix that the
 // metrics reporting should use for surfacing internal stats.
 func New(file string, cache int, handles int, namespace string, readonly bool, ephemeral bool, extraOptions *ExtraOptions) (*Database, error) {
@@ -193,7 +217,7 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 				BlockSize:      level.BlockSize,
 				IndexBlockSize: level.IndexBlockSize,
 				TargetFileSize: level.TargetFileSize,
-				FilterPolicy:   bloom.FilterPolicy(10),
+				FilterPolicy:   filterPolicy(level.FilterBits),
 			})
 		}
 	}
@@ -224,6 +248,9 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 	// including a frozen memory table and another live one.
 	memTableLimit := extraOptions.MemTableStopWritesThreshold
 	memTableSize := cache * 1024 * 1024 / 2 / memTableLimit
+	if extraOptions.MemTableSize > 0 {
+		memTableSize = extraOptions.MemTableSize
+	}
 
 	// The memory table size is currently capped at maxMemTableSize-1 due to a
 	// known bug in the pebble where maxMemTableSize is not recognized as a
@@ -234,6 +261,10 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 	if memTableSize >= maxMemTableSize {
 		memTableSize = maxMemTableSize - 1
 	}
+	blockCacheSize := int64(cache * 1024 * 1024)
+	if extraOptions.BlockCacheSize > 0 {
+		blockCacheSize = extraOptions.BlockCacheSize
+	}
 	db := &Database{
 		fn:           file,
 		log:          logger,
@@ -244,7 +275,7 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 		// Pebble has a single combined cache area and the write
 		// buffers are taken from this too. Assign all available
 		// memory allowance for cache.
-		Cache:        pebble.NewCache(int64(cache * 1024 * 1024)),
+		Cache:        pebble.NewCache(blockCacheSize),
 		MaxOpenFiles: handles,
 
 		// The size of memory table(as well as the write buffer).
@@ -284,6 +315,7 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 		WALDir:                      extraOptions.WALDir,
 		WALMinSyncInterval:          extraOptions.WALMinSyncInterval,
 		TargetByteDeletionRate:      extraOptions.TargetByteDeletionRate,
+		DisableWAL:                  extraOptions.DisableWAL,
 	}
 	// Disable seek compaction explicitly. Check https://github.com/ethereum/go-ethereum/pull/20130
 	// for more details.
@@ -321,6 +353,16 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 
 	db.compDebtGauge = metrics.GetOrRegisterGauge(namespace+"compact/debt", nil)
 	db.compInProgressGauge = metrics.GetOrRegisterGauge(namespace+"compact/inprogress", nil)
+	db.readAmpGauge = metrics.GetOrRegisterGauge(namespace+"compact/readamp", nil)
+
+	db.memTableSizeGauge = metrics.GetOrRegisterGauge(namespace+"memory/memtable/size", nil)
+	db.memTableCountGauge = metrics.GetOrRegisterGauge(namespace+"memory/memtable/count", nil)
+	db.blockCacheSizeGauge = metrics.GetOrRegisterGauge(namespace+"cache/block/size", nil)
+	db.blockCacheHitMeter = metrics.GetOrRegisterMeter(namespace+"cache/block/hit", nil)
+	db.blockCacheMissMeter = metrics.GetOrRegisterMeter(namespace+"cache/block/miss", nil)
+	db.filterHitMeter = metrics.GetOrRegisterMeter(namespace+"filter/hit", nil)
+	db.filterMissMeter = metrics.GetOrRegisterMeter(namespace+"filter/miss", nil)
+	db.walSizeGauge = metrics.GetOrRegisterGauge(namespace+"wal/size", nil)
 
 	db.commitCountMeter = metrics.GetOrRegisterMeter(namespace+"commit/counter", nil)
 	db.commitTotalDurationMeter = metrics.GetOrRegisterMeter(namespace+"commit/duration/total", nil)
@@ -552,6 +594,10 @@ func (d *Database) meter(refresh time.Duration, namespace string) {
 		commitWaits                [2]int64
 		writeDelayTimes            [2]int64
 		writeDelayCounts           [2]int64
+		blockCacheHits             [2]int64
+		blockCacheMisses           [2]int64
+		filterHits                 [2]int64
+		filterMisses               [2]int64
 		lastWriteStallReport       time.Time
 	)
 
@@ -651,6 +697,21 @@ func (d *Database) meter(refresh time.Duration, namespace string) {
 
 		d.compDebtGauge.Update(int64(stats.Compact.EstimatedDebt))
 		d.compInProgressGauge.Update(stats.Compact.NumInProgress)
+		d.readAmpGauge.Update(int64(stats.ReadAmp()))
+
+		blockCacheHits[i%2] = stats.BlockCache.Hits
+		blockCacheMisses[i%2] = stats.BlockCache.Misses
+		filterHits[i%2] = stats.Filter.Hits
+		filterMisses[i%2] = stats.Filter.Misses
+
+		d.memTableSizeGauge.Update(int64(stats.MemTable.Size))
+		d.memTableCountGauge.Update(stats.MemTable.Count)
+		d.blockCacheSizeGauge.Update(stats.BlockCache.Size)
+		d.blockCacheHitMeter.Mark(blockCacheHits[i%2] - blockCacheHits[(i-1)%2])
+		d.blockCacheMissMeter.Mark(blockCacheMisses[i%2] - blockCacheMisses[(i-1)%2])
+		d.filterHitMeter.Mark(filterHits[i%2] - filterHits[(i-1)%2])
+		d.filterMissMeter.Mark(filterMisses[i%2] - filterMisses[(i-1)%2])
+		d.walSizeGauge.Update(int64(stats.WAL.Size))
 
 		if len(stats.Levels) > 0 {
 			d.level0SublevelsGauge.Update(int64(stats.Levels[0].Sublevels))
@@ -659,8 +720,10 @@ func (d *Database) meter(refresh time.Duration, namespace string) {
 			// Append metrics for additional layers
 			if i >= len(d.levelsGauge) {
 				d.levelsGauge = append(d.levelsGauge, metrics.GetOrRegisterGauge(namespace+fmt.Sprintf("tables/level%v", i), nil))
+				d.levelSizesGauge = append(d.levelSizesGauge, metrics.GetOrRegisterGauge(namespace+fmt.Sprintf("tables/level%v/size", i), nil))
 			}
 			d.levelsGauge[i].Update(level.NumFiles)
+			d.levelSizesGauge[i].Update(level.Size)
 		}
 
 		// Sleep a bit, then repeat the stats collection
@@ -56,3 +56,23 @@ func BenchmarkPebbleDB(b *testing.B) {
 		}
 	})
 }
+
+func TestPebbleTuning(t *testing.T) {
+	options := ArbitrumProfile()
+	options.BlockCacheSize = 32 * 1024 * 1024
+	options.MemTableSize = 4 * 1024 * 1024
+	options.Levels[0].FilterBits = -1
+
+	db, err := New(t.TempDir(), 16, 16, "", false, true, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || string(value) != "value" {
+		t.Fatalf("value mismatch: have %q, %v", value, err)
+	}
+}
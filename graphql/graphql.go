@@ -504,6 +504,36 @@ func (t *Transaction) BlobGasUsed(ctx context.Context) (*hexutil.Uint64, error)
 	return &ret, nil
 }
 
+func (t *Transaction) GasUsedForL1(ctx context.Context) (*hexutil.Uint64, error) {
+	if !t.r.backend.ChainConfig().IsArbitrum() {
+		return nil, nil
+	}
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(receipt.GasUsedForL1)
+	return &ret, nil
+}
+
+func (t *Transaction) L1BlockNumber(ctx context.Context) (*hexutil.Uint64, error) {
+	tx, block := t.resolve(ctx)
+	// Pending tx
+	if tx == nil || block == nil {
+		return nil, nil
+	}
+	if legacyTx, ok := tx.GetInner().(*types.ArbitrumLegacyTxData); ok {
+		ret := hexutil.Uint64(legacyTx.L1BlockNumber)
+		return &ret, nil
+	}
+	info, err := block.arbitrumHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(info.L1BlockNumber)
+	return &ret, nil
+}
+
 func (t *Transaction) BlobGasPrice(ctx context.Context) (*hexutil.Big, error) {
 	tx, _ := t.resolve(ctx)
 	if tx == nil {
@@ -1096,6 +1126,62 @@ func (b *Block) ExcessBlobGas(ctx context.Context) (*hexutil.Uint64, error) {
 	return &ret, nil
 }
 
+// arbitrumHeaderInfo returns the ArbOS information encoded in the header of a
+// Nitro block, or nil for non-Arbitrum and classic blocks.
+func (b *Block) arbitrumHeaderInfo(ctx context.Context) (*types.HeaderInfo, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !b.r.backend.ChainConfig().IsArbitrumNitro(header.Number) {
+		return nil, nil
+	}
+	info := types.DeserializeHeaderExtraInformation(header)
+	return &info, nil
+}
+
+func (b *Block) L1BlockNumber(ctx context.Context) (*hexutil.Uint64, error) {
+	info, err := b.arbitrumHeaderInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		ret := hexutil.Uint64(info.L1BlockNumber)
+		return &ret, nil
+	}
+	if !b.r.backend.ChainConfig().IsArbitrum() {
+		return nil, nil
+	}
+	// Classic blocks carry the L1 block number in their legacy transactions
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil || len(block.Transactions()) == 0 {
+		return nil, err
+	}
+	legacyTx, ok := block.Transactions()[0].GetInner().(*types.ArbitrumLegacyTxData)
+	if !ok {
+		return nil, nil
+	}
+	ret := hexutil.Uint64(legacyTx.L1BlockNumber)
+	return &ret, nil
+}
+
+func (b *Block) SendRoot(ctx context.Context) (*common.Hash, error) {
+	info, err := b.arbitrumHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return &info.SendRoot, nil
+}
+
+func (b *Block) SendCount(ctx context.Context) (*hexutil.Uint64, error) {
+	info, err := b.arbitrumHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(info.SendCount)
+	return &ret, nil
+}
+
 // BlockFilterCriteria encapsulates criteria passed to a `logs` accessor inside
 // a block.
 type BlockFilterCriteria struct {
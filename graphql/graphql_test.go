@@ -149,6 +149,12 @@ func TestGraphQLBlockSerialization(t *testing.T) {
 			want: `{"data":{"block":{"number":"0xa","call":{"data":"0x","status":"0x1"}}}}`,
 			code: 200,
 		},
+		// Arbitrum fields are null on non-Arbitrum chains
+		{
+			body: `{"query": "{block{number,l1BlockNumber,sendRoot,sendCount}}","variables": null}`,
+			want: `{"data":{"block":{"number":"0xa","l1BlockNumber":null,"sendRoot":null,"sendCount":null}}}`,
+			code: 200,
+		},
 		{
 			body: `{"query": "{blocks {number}}"}`,
 			want: `{"errors":[{"message":"from block number must be specified","path":["blocks"]}],"data":null}`,
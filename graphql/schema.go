@@ -147,6 +147,14 @@ const schema string = `
         blobGasUsed: Long
         # blobGasPrice is the actual value per blob gas deducted from the senders account.
         blobGasPrice: BigInt
+        # GasUsedForL1 is the amount of gas spent on L1 calldata by this transaction.
+        # This will be null on non-Arbitrum chains or if the transaction has not yet
+        # been mined.
+        gasUsedForL1: Long
+        # L1BlockNumber is the L1 block number observed by the sequencer when this
+        # transaction was included. This will be null on non-Arbitrum chains or if
+        # the transaction has not yet been mined.
+        l1BlockNumber: Long
         # CreatedContract is the account that was created by a contract creation
         # transaction. If the transaction was not a contract creation transaction,
         # or it has not yet been mined, this field will be null.
@@ -279,6 +287,16 @@ const schema string = `
         blobGasUsed: Long
         # ExcessBlobGas is a running total of blob gas consumed in excess of the target, prior to the block.
         excessBlobGas: Long
+        # L1BlockNumber is the L1 block number observed by the sequencer when this
+        # block was produced. If the chain is not an Arbitrum chain, this field
+        # will be null.
+        l1BlockNumber: Long
+        # SendRoot is the merkle root of the L2 to L1 messages sent up to and
+        # including this block. This field is only available for Arbitrum Nitro blocks.
+        sendRoot: Bytes32
+        # SendCount is the number of L2 to L1 messages sent up to and including
+        # this block. This field is only available for Arbitrum Nitro blocks.
+        sendCount: Long
     }
 
     # CallData represents the data associated with a local contract call.
@@ -78,6 +78,7 @@ type Builder struct {
 	hashes   []common.Hash
 	tds      []*big.Int
 	written  int
+	arbitrum bool // Arbitrum: write the receipts in their storage encoding
 
 	buf    *bytes.Buffer
 	snappy *snappy.Writer
@@ -93,6 +94,15 @@ func NewBuilder(w io.Writer) *Builder {
 	}
 }
 
+// NewArbitrumBuilder returns a new Builder instance writing the receipts in
+// their storage encoding, as CompressedArbitrumReceipts entries. Unlike the
+// consensus encoding, it retains the Arbitrum specific fields of the receipts.
+func NewArbitrumBuilder(w io.Writer) *Builder {
+	b := NewBuilder(w)
+	b.arbitrum = true
+	return b
+}
+
 // Add writes a compressed block entry and compressed receipts entry to the
 // underlying e2store file.
 func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
@@ -104,7 +114,16 @@ func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int)
 	if err != nil {
 		return err
 	}
-	er, err := rlp.EncodeToBytes(receipts)
+	var er []byte
+	if b.arbitrum {
+		stored := make([]*types.ReceiptForStorage, len(receipts))
+		for i, receipt := range receipts {
+			stored[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		er, err = rlp.EncodeToBytes(stored)
+	} else {
+		er, err = rlp.EncodeToBytes(receipts)
+	}
 	if err != nil {
 		return err
 	}
@@ -112,7 +131,8 @@ func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int)
 }
 
 // AddRLP writes a compressed block entry and compressed receipts entry to the
-// underlying e2store file.
+// underlying e2store file. The receipts of the Arbitrum builders must be in
+// their storage encoding.
 func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td, difficulty *big.Int) error {
 	// Write Era1 version entry before first block.
 	if b.startNum == nil {
@@ -140,7 +160,11 @@ func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash comm
 	if err := b.snappyWrite(TypeCompressedBody, body); err != nil {
 		return err
 	}
-	if err := b.snappyWrite(TypeCompressedReceipts, receipts); err != nil {
+	receiptsType := TypeCompressedReceipts
+	if b.arbitrum {
+		receiptsType = TypeCompressedArbitrumReceipts
+	}
+	if err := b.snappyWrite(receiptsType, receipts); err != nil {
 		return err
 	}
 
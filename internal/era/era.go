@@ -44,6 +44,11 @@ var (
 	TypeAccumulator        uint16 = 0x07
 	TypeBlockIndex         uint16 = 0x3266
 
+	// Arbitrum: receipts in their storage encoding, retaining the L1 gas used
+	// and the contract addresses of the Arbitrum transactions, written in place
+	// of the CompressedReceipts entries by the Arbitrum builders
+	TypeCompressedArbitrumReceipts uint16 = 0x4172
+
 	MaxEra1Size = 8192
 )
 
@@ -248,6 +253,21 @@ func newSnappyReader(e *e2store.Reader, expectedType uint16, off int64) (io.Read
 	return snappy.NewReader(r), int64(n), err
 }
 
+// newReceiptsReader returns a snappy.Reader for the receipts entry at the given
+// offset, in either the standard or the Arbitrum storage encoding, reporting
+// which one it is.
+func newReceiptsReader(e *e2store.Reader, off int64) (io.Reader, int64, bool, error) {
+	typ, _, err := e.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if typ != TypeCompressedArbitrumReceipts {
+		typ = TypeCompressedReceipts
+	}
+	r, n, err := newSnappyReader(e, typ, off)
+	return r, n, typ == TypeCompressedArbitrumReceipts, err
+}
+
 // metadata wraps the metadata in the block index.
 type metadata struct {
 	start  uint64
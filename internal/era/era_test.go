@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type testchain struct {
@@ -124,6 +125,59 @@ func TestEra1Builder(t *testing.T) {
 	}
 }
 
+// Tests that the Arbitrum builders retain the receipt fields missing from the
+// consensus encoding.
+func TestEra1ArbitrumReceipts(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "era1-test")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer f.Close()
+
+	var (
+		builder = NewArbitrumBuilder(f)
+		block   = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)})
+		want    = types.Receipts{{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			GasUsedForL1:      42,
+			Logs:              []*types.Log{},
+		}}
+	)
+	if err := builder.Add(block, want, big.NewInt(1)); err != nil {
+		t.Fatalf("error adding entry: %v", err)
+	}
+	if _, err := builder.Finalize(); err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %s", err)
+	}
+	if !it.Next() || it.Error() != nil {
+		t.Fatalf("missing entry: %v", it.Error())
+	}
+	have, receipts, err := it.BlockAndReceipts()
+	if err != nil {
+		t.Fatalf("error reading entry: %v", err)
+	}
+	if have.Hash() != block.Hash() {
+		t.Fatalf("block hash mismatch: have %x, want %x", have.Hash(), block.Hash())
+	}
+	if len(receipts) != 1 || receipts[0].GasUsedForL1 != 42 || receipts[0].CumulativeGasUsed != 21000 {
+		t.Fatalf("receipts mismatch: have %+v", receipts)
+	}
+	if td, err := e.InitialTD(); err != nil || td.Sign() != 0 {
+		t.Fatalf("initial td mismatch: have %v (err %v), want 0", td, err)
+	}
+}
+
 func TestEraFilename(t *testing.T) {
 	for i, tt := range []struct {
 		network  string
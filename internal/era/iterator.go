@@ -81,6 +81,17 @@ func (it *Iterator) Receipts() (types.Receipts, error) {
 	if it.inner.Receipts == nil {
 		return nil, errors.New("receipts must be non-nil")
 	}
+	if it.inner.ArbitrumReceipts {
+		var stored []*types.ReceiptForStorage
+		if err := rlp.Decode(it.inner.Receipts, &stored); err != nil {
+			return nil, err
+		}
+		receipts := make(types.Receipts, len(stored))
+		for i, receipt := range stored {
+			receipts[i] = (*types.Receipt)(receipt)
+		}
+		return receipts, nil
+	}
 	var receipts types.Receipts
 	err := rlp.Decode(it.inner.Receipts, &receipts)
 	return receipts, err
@@ -120,6 +131,10 @@ type RawIterator struct {
 	Body            io.Reader
 	Receipts        io.Reader
 	TotalDifficulty io.Reader
+
+	// Arbitrum: whether the receipts are in their storage encoding, rather
+	// than the consensus one
+	ArbitrumReceipts bool
 }
 
 // NewRawIterator returns a new RawIterator instance. Next must be immediately
@@ -161,7 +176,7 @@ func (it *RawIterator) Next() bool {
 		return true
 	}
 	off += n
-	if it.Receipts, n, it.err = newSnappyReader(it.e.s, TypeCompressedReceipts, off); it.err != nil {
+	if it.Receipts, n, it.ArbitrumReceipts, it.err = newReceiptsReader(it.e.s, off); it.err != nil {
 		it.clear()
 		return true
 	}
@@ -194,4 +209,5 @@ func (it *RawIterator) clear() {
 	it.Body = nil
 	it.Receipts = nil
 	it.TotalDifficulty = nil
+	it.ArbitrumReceipts = false
 }
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
 	"time"
 
@@ -51,7 +52,6 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/ethereum/go-ethereum/trie"
 )
 
 var errBlobTxNotSupported = errors.New("signing blob transactions not supported")
@@ -675,6 +675,10 @@ type AccountResult struct {
 	Nonce        hexutil.Uint64  `json:"nonce"`
 	StorageHash  common.Hash     `json:"storageHash"`
 	StorageProof []StorageResult `json:"storageProof"`
+
+	// Arbitrum: root the proofs are against, if it differs from the block's,
+	// e.g. for the pending and simulated states
+	StateRoot *common.Hash `json:"stateRoot,omitempty"`
 }
 
 type StorageResult struct {
@@ -699,9 +703,8 @@ func (n *proofList) Delete(key []byte) error {
 // GetProof returns the Merkle-proof for a given account and optionally some storage keys.
 func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
 	var (
-		keys         = make([]common.Hash, len(storageKeys))
-		keyLengths   = make([]int, len(storageKeys))
-		storageProof = make([]StorageResult, len(storageKeys))
+		keys       = make([]common.Hash, len(storageKeys))
+		keyLengths = make([]int, len(storageKeys))
 	)
 	// Deserialize all keys. This prevents state access on invalid input.
 	for i, hexKey := range storageKeys {
@@ -715,14 +718,33 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 	if statedb == nil || err != nil {
 		return nil, err
 	}
-	codeHash := statedb.GetCodeHash(address)
-	storageRoot := statedb.GetStorageRoot(address)
+	// The pending state may carry changes not committed to the tries yet, hash
+	// them in to prove against the resulting root.
+	root := statedb.IntermediateRoot(s.b.ChainConfig().IsEIP158(header.Number))
 
+	result, err := proveAccount(statedb, address, keys, keyLengths)
+	if err != nil {
+		return nil, err
+	}
+	if root != header.Root {
+		result.StateRoot = &root
+	}
+	return result, nil
+}
+
+// proveAccount generates the Merkle-proofs of an account and some of its storage
+// slots from the in-memory tries of the given state. The state must have been
+// hashed by IntermediateRoot, the proofs are against the root it returned.
+func proveAccount(statedb *state.StateDB, address common.Address, keys []common.Hash, keyLengths []int) (*AccountResult, error) {
+	var (
+		codeHash     = statedb.GetCodeHash(address)
+		storageRoot  = statedb.GetStorageRoot(address)
+		storageProof = make([]StorageResult, len(keys))
+	)
 	if len(keys) > 0 {
 		var storageTrie state.Trie
 		if storageRoot != types.EmptyRootHash && storageRoot != (common.Hash{}) {
-			id := trie.StorageTrieID(header.Root, crypto.Keccak256Hash(address.Bytes()), storageRoot)
-			st, err := trie.NewStateTrie(id, statedb.Database().TrieDB())
+			st, err := statedb.StorageTrie(address)
 			if err != nil {
 				return nil, err
 			}
@@ -752,11 +774,10 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 			storageProof[i] = StorageResult{outputKey, value, proof}
 		}
 	}
-	// Create the accountProof.
-	tr, err := trie.NewStateTrie(trie.StateTrieID(header.Root), statedb.Database().TrieDB())
-	if err != nil {
-		return nil, err
-	}
+	// Create the accountProof, from a copy of the account trie as the state may
+	// still be in use.
+	tr := statedb.Database().CopyTrie(statedb.GetTrie())
+
 	var accountProof proofList
 	if err := tr.Prove(crypto.Keccak256(address.Bytes()), &accountProof); err != nil {
 		return nil, err
@@ -938,36 +959,69 @@ func (s *BlockChainAPI) GetStorageAt(ctx context.Context, address common.Address
 }
 
 // GetBlockReceipts returns the block receipts for the given block hash or number or tag.
-func (s *BlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+// Arbitrum: the receipts may be requested in a compact encoding instead of JSON,
+// in which case they are returned as EncodedReceipts.
+func (s *BlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, encoding *ReceiptsEncoding) (interface{}, error) {
 	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
 	if block == nil || err != nil {
 		// When the block doesn't exist, the RPC method should return JSON null
 		// as per specification.
 		return nil, nil
 	}
-	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if encoding != nil && *encoding != ReceiptsEncodingJSON {
+		return s.encodedBlockReceipts(ctx, block, *encoding)
+	}
+	return s.blockReceipts(ctx, block)
+}
+
+// GetTransactionReceiptsByBlock returns all the transaction receipts of the given
+// block, including the Arbitrum specific fields, in a single response.
+func (s *BlockChainAPI) GetTransactionReceiptsByBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
-	txs := block.Transactions()
-	if len(txs) != len(receipts) {
-		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
+	if block == nil {
+		return nil, nil
 	}
+	return s.blockReceipts(ctx, block)
+}
 
+// blockReceipts marshals all the receipts of a block, retrieving the block level
+// data needed only once.
+func (s *BlockChainAPI) blockReceipts(ctx context.Context, block *types.Block) ([]map[string]interface{}, error) {
+	receipts, header, blockMetadata, err := s.blockReceiptsData(ctx, block)
+	if err != nil {
+		return nil, err
+	}
 	// Derive the sender.
 	signer := types.MakeSigner(s.b.ChainConfig(), block.Number(), block.Time())
 
+	txs := block.Transactions()
 	result := make([]map[string]interface{}, len(receipts))
 	for i, receipt := range receipts {
-		result[i], err = marshalReceipt(ctx, receipt, block.Hash(), block.NumberU64(), signer, txs[i], i, s.b)
-		if err != nil {
-			return nil, err
-		}
+		result[i] = marshalBlockReceipt(receipt, block.Hash(), block.NumberU64(), header, blockMetadata, signer, txs[i], i, s.b.ChainConfig())
 	}
-
 	return result, nil
 }
 
+// blockReceiptsData retrieves the receipts of a block, along with the block
+// level data needed to marshal them.
+func (s *BlockChainAPI) blockReceiptsData(ctx context.Context, block *types.Block) (types.Receipts, *types.Header, common.BlockMetadata, error) {
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if txs := block.Transactions(); len(txs) != len(receipts) {
+		return nil, nil, nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
+	}
+	header, blockMetadata, err := receiptBlockInfo(ctx, s.b, block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return receipts, header, blockMetadata, nil
+}
+
 // OverrideAccount indicates the overriding fields of account during the execution
 // of a message call.
 // Note, state and stateDiff can't be specified at the same time. If state is
@@ -1142,7 +1196,7 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 		return res, err
 	}
 
-	evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
+	evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true, SimulateBlobs: true}, &blockCtx)
 
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -1192,7 +1246,7 @@ func runScheduledTxes(ctx context.Context, b core.NodeInterfaceBackendAPI, state
 			result.UsedGas = 0
 		}
 		// make a new EVM for the scheduled Tx (an EVM must never be reused)
-		evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
+		evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true, SimulateBlobs: true}, &blockCtx)
 		go func() {
 			<-ctx.Done()
 			evm.Cancel()
@@ -1275,6 +1329,13 @@ func (s *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrO
 // there are unexpected failures. The gas limit is capped by both `args.Gas` (if non-nil &
 // non-zero) and `gasCap` (if non-zero).
 func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, gasCap uint64) (hexutil.Uint64, error) {
+	return doEstimateGas(ctx, b, args, blockNrOrHash, overrides, gasCap, nil)
+}
+
+// doEstimateGas runs DoEstimateGas, recording the state accessed by all the
+// executions of the estimation with the given tracer, if any. The estimation
+// is exact when recorded, not stopping within the allowed error ratio.
+func doEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, gasCap uint64, access *estimateAccessTracer) (hexutil.Uint64, error) {
 	// Retrieve the base state and mutate it with any overrides
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
@@ -1302,6 +1363,13 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 	// Arbitrum: this also appropriately recursively calls another args.ToMessage with increased gasCap by posterCostInL2Gas amount
 	call := args.ToMessage(header.BaseFee, gasCap, header, state, core.MessageGasEstimationMode)
 
+	// Arbitrum: record the state accessed, if requested
+	if access != nil {
+		access.addCall(call.From, call.To)
+		opts.Tracer = access.Hooks()
+		opts.ErrorRatio = 0
+	}
+
 	// Arbitrum: raise the gas cap to ignore L1 costs so that it's compute-only
 	if gasCap > 0 {
 		postingGas, err := core.RPCPostingGasHook(call, header, state)
@@ -1327,21 +1395,38 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 // value is capped by both `args.Gas` (if non-nil & non-zero) and the backend's RPCGasCap
 // configuration (if non-zero).
 // Note: Required blob gas is not computed in this method.
-func (s *BlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride) (hexutil.Uint64, error) {
+//
+// Arbitrum: if the state access is requested in the options, the estimation is
+// exact and reported along with the accounts, storage slots and code accessed,
+// and the context-dependent constructs executed.
+func (s *BlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride, opts *EstimateGasOptions) (*EstimateGasResult, error) {
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
-	res, err := DoEstimateGas(ctx, s.b, args, bNrOrHash, overrides, s.b.RPCGasCap())
+	var access *estimateAccessTracer
+	if opts != nil && opts.StateAccess {
+		access = newEstimateAccessTracer()
+	}
+	res, err := doEstimateGas(ctx, s.b, args, bNrOrHash, overrides, s.b.RPCGasCap(), access)
 	if client := fallbackClientFor(s.b, err); client != nil {
-		var res hexutil.Uint64
-		err := client.CallContext(ctx, &res, "eth_estimateGas", args, blockNrOrHash, overrides)
-		return res, err
+		params := []interface{}{args, blockNrOrHash, overrides}
+		if opts != nil {
+			params = append(params, opts)
+		}
+		var res EstimateGasResult
+		err := client.CallContext(ctx, &res, "eth_estimateGas", params...)
+		return &res, err
 	}
 	if err != nil {
 		gasUsedEthEstimateGasGauge.Inc(int64(res))
+		return nil, err
+	}
+	result := &EstimateGasResult{Gas: res}
+	if access != nil {
+		result.Access = access.result()
 	}
-	return res, err
+	return result, nil
 }
 
 // RPCMarshalHeader converts the given header to the RPC output .
@@ -1690,23 +1775,34 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 // It's the result of the `debug_createAccessList` RPC call.
 // It contains an error if the transaction itself failed.
 type accessListResult struct {
-	Accesslist *types.AccessList `json:"accessList"`
-	Error      string            `json:"error,omitempty"`
-	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+	Accesslist  *types.AccessList `json:"accessList"`
+	WasmModules []common.Hash     `json:"wasmModules,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	GasUsed     hexutil.Uint64    `json:"gasUsed"`
+}
+
+// AccessListOptions configures the generation of an access list.
+type AccessListOptions struct {
+	// Arbitrum: whether to report the module hashes of the Stylus programs the
+	// transaction loads
+	WasmModules bool `json:"wasmModules"`
 }
 
 // CreateAccessList creates an EIP-2930 type AccessList for the given transaction.
 // Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
-func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
+func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash, opts *AccessListOptions) (*accessListResult, error) {
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
-	acl, gasUsed, vmerr, err := AccessList(ctx, s.b, bNrOrHash, args)
+	acl, modules, gasUsed, vmerr, err := AccessListWithWasmModules(ctx, s.b, bNrOrHash, args)
 	if err != nil {
 		return nil, err
 	}
 	result := &accessListResult{Accesslist: &acl, GasUsed: hexutil.Uint64(gasUsed)}
+	if opts != nil && opts.WasmModules {
+		result.WasmModules = modules
+	}
 	if vmerr != nil {
 		result.Error = vmerr.Error()
 	}
@@ -1717,15 +1813,23 @@ func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionAr
 // If the accesslist creation fails an error is returned.
 // If the transaction itself fails, an vmErr is returned.
 func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args TransactionArgs) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
+	acl, _, gasUsed, vmErr, err = AccessListWithWasmModules(ctx, b, blockNrOrHash, args)
+	return acl, gasUsed, vmErr, err
+}
+
+// AccessListWithWasmModules creates an access list for the given transaction,
+// including the storage accessed by Stylus programs, along with the module
+// hashes of the Stylus programs the transaction loads.
+func AccessListWithWasmModules(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args TransactionArgs) (acl types.AccessList, modules []common.Hash, gasUsed uint64, vmErr error, err error) {
 	// Retrieve the execution context
 	db, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if db == nil || err != nil {
-		return nil, 0, nil, err
+		return nil, nil, 0, nil, err
 	}
 
 	// Ensure any missing fields are filled, extract the recipient and input data
 	if err := args.setDefaults(ctx, b, true); err != nil {
-		return nil, 0, nil, err
+		return nil, nil, 0, nil, err
 	}
 	var to common.Address
 	if args.To != nil {
@@ -1744,7 +1848,7 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 	}
 	for {
 		if err := ctx.Err(); err != nil {
-			return nil, 0, nil, err
+			return nil, nil, 0, nil, err
 		}
 		// Retrieve the current access list to expand
 		accessList := prevTracer.AccessList()
@@ -1752,6 +1856,8 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 
 		// Copy the original db so we don't modify it
 		statedb := db.Copy()
+		// Arbitrum: record the Stylus programs loaded
+		statedb.StartRecording()
 		// Set the accesslist to the last al
 		args.AccessList = &accessList
 		msg := args.ToMessage(header.BaseFee, b.RPCGasCap(), header, statedb, core.MessageEthcallMode)
@@ -1762,10 +1868,14 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 		vmenv := b.GetEVM(ctx, msg, statedb, header, &config, nil)
 		res, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.GasLimit))
 		if err != nil {
-			return nil, 0, nil, fmt.Errorf("failed to apply transaction: %v err: %v", args.ToTransaction().Hash(), err)
+			return nil, nil, 0, nil, fmt.Errorf("failed to apply transaction: %v err: %v", args.ToTransaction().Hash(), err)
 		}
 		if tracer.Equal(prevTracer) {
-			return accessList, res.UsedGas, res.Err, nil
+			for moduleHash := range statedb.UserWasms() {
+				modules = append(modules, moduleHash)
+			}
+			slices.SortFunc(modules, func(a, b common.Hash) int { return a.Cmp(b) })
+			return accessList, modules, res.UsedGas, res.Err, nil
 		}
 		prevTracer = tracer
 	}
@@ -1926,6 +2036,37 @@ func (s *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.
 
 // marshalReceipt marshals a transaction receipt into a JSON object.
 func marshalReceipt(ctx context.Context, receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, signer types.Signer, tx *types.Transaction, txIndex int, backend Backend) (map[string]interface{}, error) {
+	header, blockMetadata, err := receiptBlockInfo(ctx, backend, blockHash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return marshalBlockReceipt(receipt, blockHash, blockNumber, header, blockMetadata, signer, tx, txIndex, backend.ChainConfig()), nil
+}
+
+// receiptBlockInfo retrieves the block level data needed to marshal the receipts
+// of a block. Arbitrum: the header and block metadata are only needed, and thus
+// only retrieved, on Arbitrum chains.
+func receiptBlockInfo(ctx context.Context, backend Backend, blockHash common.Hash, blockNumber uint64) (*types.Header, common.BlockMetadata, error) {
+	if !backend.ChainConfig().IsArbitrum() {
+		return nil, nil, nil
+	}
+	header, err := backend.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("header %#x not found", blockHash)
+	}
+	blockMetadata, err := backend.BlockMetadataByNumber(blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, blockMetadata, nil
+}
+
+// marshalBlockReceipt marshals a transaction receipt into a JSON object, with
+// the block level data already retrieved.
+func marshalBlockReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, header *types.Header, blockMetadata common.BlockMetadata, signer types.Signer, tx *types.Transaction, txIndex int, config *params.ChainConfig) map[string]interface{} {
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
@@ -1963,39 +2104,40 @@ func marshalReceipt(ctx context.Context, receipt *types.Receipt, blockHash commo
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	if backend.ChainConfig().IsArbitrum() {
+	if config.IsArbitrum() {
 		fields["gasUsedForL1"] = hexutil.Uint64(receipt.GasUsedForL1)
-
-		header, err := backend.HeaderByHash(ctx, blockHash)
-		if err != nil {
-			return nil, err
-		}
-		if backend.ChainConfig().IsArbitrumNitro(header.Number) {
-			fields["effectiveGasPrice"] = hexutil.Uint64(header.BaseFee.Uint64())
-			fields["l1BlockNumber"] = hexutil.Uint64(types.DeserializeHeaderExtraInformation(header).L1BlockNumber)
-		} else {
-			inner := tx.GetInner()
-			arbTx, ok := inner.(*types.ArbitrumLegacyTxData)
-			if !ok {
-				log.Error("Expected transaction to contain arbitrum data", "txHash", tx.Hash())
-			} else {
-				fields["effectiveGasPrice"] = hexutil.Uint64(arbTx.EffectiveGasPrice)
-				fields["l1BlockNumber"] = hexutil.Uint64(arbTx.L1BlockNumber)
-			}
+		if receipt.L1Data != nil {
+			fields["l1Data"] = receipt.L1Data
 		}
 
-		blockMetadata, err := backend.BlockMetadataByNumber(blockNumber)
-		if err != nil {
-			return nil, err
+		if effectiveGasPrice, l1BlockNumber, ok := arbitrumReceiptPricing(tx, header, config); ok {
+			fields["effectiveGasPrice"] = hexutil.Uint64(effectiveGasPrice)
+			fields["l1BlockNumber"] = hexutil.Uint64(l1BlockNumber)
 		}
+
 		if blockMetadata != nil {
+			var err error
 			fields["timeboosted"], err = blockMetadata.IsTxTimeboosted(txIndex)
 			if err != nil {
 				log.Error("Error checking if a tx was timeboosted", "txIndex", txIndex, "txHash", tx.Hash(), "err", err)
 			}
 		}
 	}
-	return fields, nil
+	return fields
+}
+
+// arbitrumReceiptPricing returns the effective gas price and the L1 block number
+// reported in the receipt of an Arbitrum transaction, if known.
+func arbitrumReceiptPricing(tx *types.Transaction, header *types.Header, config *params.ChainConfig) (uint64, uint64, bool) {
+	if config.IsArbitrumNitro(header.Number) {
+		return header.BaseFee.Uint64(), types.DeserializeHeaderExtraInformation(header).L1BlockNumber, true
+	}
+	arbTx, ok := tx.GetInner().(*types.ArbitrumLegacyTxData)
+	if !ok {
+		log.Error("Expected transaction to contain arbitrum data", "txHash", tx.Hash())
+		return 0, 0, false
+	}
+	return arbTx.EffectiveGasPrice, arbTx.L1BlockNumber, true
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -2,45 +2,260 @@ package ethapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-func (s *BlockChainAPI) StylusGetAsm(ctx context.Context, codeHash string) (hexutil.Bytes, error) {
-	key, _, err := decodeHash(codeHash)
-	if err != nil {
+// maxActivatedProgramsLimit is the maximum number of programs returned by a
+// single stylus_getActivatedPrograms call.
+const maxActivatedProgramsLimit = 1024
+
+// StylusAPI provides an API to access the Stylus programs activated on the node.
+type StylusAPI struct {
+	b Backend
+}
+
+// NewStylusAPI creates a new Stylus API instance.
+func NewStylusAPI(b Backend) *StylusAPI {
+	return &StylusAPI{b}
+}
+
+// ActivatedProgram describes a Stylus program activated at a given block.
+// The Stylus version the module was activated with is only known with ArbOS,
+// the other activation parameters are served per code hash by
+// stylus_getActivationInfo.
+type ActivatedProgram struct {
+	ModuleHash    common.Hash                         `json:"moduleHash"`
+	StylusVersion *hexutil.Uint64                     `json:"stylusVersion,omitempty"`
+	AsmSizes      map[ethdb.WasmTarget]hexutil.Uint64 `json:"asmSizes"`
+}
+
+// ActivatedProgramsResult is a page of activated Stylus programs. Next is the
+// cursor to pass in order to retrieve the following page, nil if there is none.
+type ActivatedProgramsResult struct {
+	Programs []ActivatedProgram `json:"programs"`
+	Next     *common.Hash       `json:"next"`
+}
+
+// GetActivatedPrograms enumerates the Stylus programs activated at the given
+// block in ascending module hash order, starting at (and including) the given
+// cursor. For every program the module hash, the Stylus version and the sizes
+// of the asm stored for each target are returned.
+//
+// Without ArbOS, the activations can't be told apart by block, and all the
+// programs in the wasm store are listed without their version.
+func (api *StylusAPI) GetActivatedPrograms(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, cursor *common.Hash, limit *hexutil.Uint64) (*ActivatedProgramsResult, error) {
+	count := maxActivatedProgramsLimit
+	if limit != nil {
+		if *limit == 0 {
+			return nil, errors.New("limit must be positive")
+		}
+		if *limit < maxActivatedProgramsLimit {
+			count = int(*limit)
+		}
+	}
+	var start common.Hash
+	if cursor != nil {
+		start = *cursor
+	}
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
 		return nil, err
 	}
+	wasmStore := statedb.Database().WasmStore()
 
-	db, _, err := s.b.StateAndHeaderByNumber(ctx, 1)
-	if err != nil {
-		return nil, err
+	var (
+		targets  = rawdb.AllWasmTargets()
+		hashes   []common.Hash
+		versions map[common.Hash]uint16
+	)
+	if core.GetStylusModuleRefs != nil {
+		refs, err := core.GetStylusModuleRefs(statedb, header)
+		if err != nil {
+			return nil, err
+		}
+		for moduleHash := range refs {
+			if moduleHash.Cmp(start) >= 0 {
+				hashes = append(hashes, moduleHash)
+			}
+		}
+		if core.GetStylusModuleVersions != nil {
+			if versions, err = core.GetStylusModuleVersions(statedb, header); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Collect one more hash than requested from every target, so the cursor
+		// of the next page is known without another pass over the store.
+		found := make(map[common.Hash]struct{})
+		for _, target := range targets {
+			moduleHashes, err := rawdb.ReadActivatedModuleHashes(wasmStore, target, start, count+1)
+			if err != nil {
+				return nil, err
+			}
+			for _, moduleHash := range moduleHashes {
+				if _, ok := found[moduleHash]; !ok {
+					found[moduleHash] = struct{}{}
+					hashes = append(hashes, moduleHash)
+				}
+			}
+		}
 	}
+	slices.SortFunc(hashes, func(a, b common.Hash) int { return a.Cmp(b) })
 
-	asm, err := db.Database().ActivatedAsm(rawdb.LocalTarget(), key)
-	if err != nil {
-		return nil, err
+	result := &ActivatedProgramsResult{Programs: []ActivatedProgram{}}
+	if len(hashes) > count {
+		result.Next = &hashes[count]
+		hashes = hashes[:count]
+	}
+	for _, moduleHash := range hashes {
+		program := ActivatedProgram{
+			ModuleHash: moduleHash,
+			AsmSizes:   make(map[ethdb.WasmTarget]hexutil.Uint64),
+		}
+		if version, ok := versions[moduleHash]; ok {
+			stylusVersion := hexutil.Uint64(version)
+			program.StylusVersion = &stylusVersion
+		}
+		for _, target := range targets {
+			if asm := rawdb.ReadActivatedAsm(wasmStore, target, moduleHash); asm != nil {
+				program.AsmSizes[target] = hexutil.Uint64(len(asm))
+			}
+		}
+		result.Programs = append(result.Programs, program)
 	}
+	return result, nil
+}
 
-	return asm, nil
+// GetAsm returns the asm of the Stylus program with the given module hash
+// compiled for the local target, as activated in the state of the given block.
+func (api *StylusAPI) GetAsm(ctx context.Context, moduleHash common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	return api.activatedAsm(ctx, rawdb.LocalTarget(), moduleHash, blockNrOrHash)
 }
 
-func (s *BlockChainAPI) StylusGetModule(ctx context.Context, codeHash string) (hexutil.Bytes, error) {
-	key, _, err := decodeHash(codeHash)
-	if err != nil {
-		return nil, err
+// GetModule returns the wavm module of the Stylus program with the given module
+// hash, as activated in the state of the given block.
+func (api *StylusAPI) GetModule(ctx context.Context, moduleHash common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	return api.activatedAsm(ctx, rawdb.TargetWavm, moduleHash, blockNrOrHash)
+}
+
+// GetAsmForTarget returns the asm of the Stylus program with the given module
+// hash compiled for the given target, as activated in the state of the given
+// block (the latest one if omitted).
+func (api *StylusAPI) GetAsmForTarget(ctx context.Context, moduleHash common.Hash, target ethdb.WasmTarget, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	if !rawdb.IsSupportedWasmTarget(target) {
+		return nil, fmt.Errorf("unsupported target %q, want one of %v", target, rawdb.AllWasmTargets())
 	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	return api.activatedAsm(ctx, target, moduleHash, *blockNrOrHash)
+}
 
-	db, _, err := s.b.StateAndHeaderByNumber(ctx, 1)
-	if err != nil {
+// GetTargets returns the targets for which the wasm store holds asm of at
+// least one Stylus program.
+func (api *StylusAPI) GetTargets() ([]ethdb.WasmTarget, error) {
+	wasmStore, _ := api.b.ChainDb().WasmDataBase()
+
+	targets := []ethdb.WasmTarget{}
+	for _, target := range rawdb.AllWasmTargets() {
+		moduleHashes, err := rawdb.ReadActivatedModuleHashes(wasmStore, target, common.Hash{}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(moduleHashes) > 0 {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// activatedAsm retrieves the asm of a Stylus program for the given target from
+// the state of the given block, including programs activated by the block itself.
+// The wasm store is shared by all the blocks, so the program is first checked to
+// be activated in the state of the block.
+func (api *StylusAPI) activatedAsm(ctx context.Context, target ethdb.WasmTarget, moduleHash common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
 		return nil, err
 	}
+	// Without ArbOS, the activations can't be told apart by block
+	if core.GetStylusModuleRefs != nil {
+		refs, err := core.GetStylusModuleRefs(statedb, header)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := refs[moduleHash]; !ok {
+			return nil, &ProgramNotActivatedError{ModuleHash: moduleHash, Block: header.Number.Uint64()}
+		}
+	}
+	asm, err := statedb.TryGetActivatedAsm(target, moduleHash)
+	if err != nil {
+		return nil, &ProgramNotActivatedError{ModuleHash: moduleHash, Target: target, Block: header.Number.Uint64()}
+	}
+	return asm, nil
+}
+
+// ActivationInfo describes the activation of a Stylus program.
+type ActivationInfo struct {
+	CodeHash      common.Hash                         `json:"codeHash"`
+	ModuleHash    common.Hash                         `json:"moduleHash"`
+	StylusVersion hexutil.Uint64                      `json:"stylusVersion"`
+	InitCost      hexutil.Uint64                      `json:"initCost"`
+	Footprint     hexutil.Uint64                      `json:"footprint"`
+	AsmSizes      map[ethdb.WasmTarget]hexutil.Uint64 `json:"asmSizes"`
+}
 
-	asm, err := db.Database().ActivatedAsm(rawdb.TargetWavm, key)
+// GetActivationInfo returns the activation parameters of a Stylus program at
+// the given block, along with the sizes of the asm stored for each target. The
+// program is identified either by its code hash or by the address of a contract
+// running it.
+func (api *StylusAPI) GetActivationInfo(ctx context.Context, program hexutil.Bytes, blockNrOrHash rpc.BlockNumberOrHash) (*ActivationInfo, error) {
+	if core.GetStylusProgramInfo == nil {
+		return nil, errors.New("ArbOS not installed")
+	}
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	var codeHash common.Hash
+	switch len(program) {
+	case common.AddressLength:
+		codeHash = statedb.GetCodeHash(common.BytesToAddress(program))
+		if codeHash == (common.Hash{}) || codeHash == types.EmptyCodeHash {
+			return nil, fmt.Errorf("no contract at address %v", common.BytesToAddress(program))
+		}
+	case common.HashLength:
+		codeHash = common.BytesToHash(program)
+	default:
+		return nil, fmt.Errorf("invalid program identifier length %d, want address or code hash", len(program))
+	}
+	info, err := core.GetStylusProgramInfo(statedb, header, codeHash)
 	if err != nil {
 		return nil, err
 	}
-
-	return asm, nil
+	result := &ActivationInfo{
+		CodeHash:      codeHash,
+		ModuleHash:    info.ModuleHash,
+		StylusVersion: hexutil.Uint64(info.Version),
+		InitCost:      hexutil.Uint64(info.InitCost),
+		Footprint:     hexutil.Uint64(info.Footprint),
+		AsmSizes:      make(map[ethdb.WasmTarget]hexutil.Uint64),
+	}
+	for _, target := range rawdb.AllWasmTargets() {
+		if asm, err := statedb.TryGetActivatedAsm(target, info.ModuleHash); err == nil {
+			result.AsmSizes[target] = hexutil.Uint64(len(asm))
+		}
+	}
+	return result, nil
 }
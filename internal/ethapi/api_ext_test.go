@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func newStylusTestBackend(t *testing.T, programs int) *testBackend {
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{},
+	}
+	backend := newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {})
+
+	wasmStore, _ := backend.db.WasmDataBase()
+	for i := 1; i <= programs; i++ {
+		rawdb.WriteActivation(wasmStore, common.Hash{byte(i)}, map[ethdb.WasmTarget][]byte{
+			rawdb.TargetWavm:    bytes.Repeat([]byte{0x01}, i),
+			rawdb.LocalTarget(): bytes.Repeat([]byte{0x02}, 2*i),
+		})
+	}
+	return backend
+}
+
+func TestStylusGetAsm(t *testing.T) {
+	t.Parallel()
+
+	var (
+		api    = NewStylusAPI(newStylusTestBackend(t, 1))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	asm, err := api.GetAsm(context.Background(), common.Hash{1}, latest)
+	if err != nil {
+		t.Fatalf("failed to get asm: %v", err)
+	}
+	if !bytes.Equal(asm, []byte{0x02, 0x02}) {
+		t.Fatalf("asm mismatch: have %x, want 0202", asm)
+	}
+	module, err := api.GetModule(context.Background(), common.Hash{1}, latest)
+	if err != nil {
+		t.Fatalf("failed to get module: %v", err)
+	}
+	if !bytes.Equal(module, []byte{0x01}) {
+		t.Fatalf("module mismatch: have %x, want 01", module)
+	}
+	var notActivated *ProgramNotActivatedError
+	if _, err := api.GetAsm(context.Background(), common.Hash{2}, latest); !errors.As(err, &notActivated) {
+		t.Fatalf("expected not activated error, have %v", err)
+	}
+}
+
+// Tests that the asm of a program is only served at the blocks whose state has
+// it activated, the wasm store being shared by all the blocks.
+func TestStylusGetAsmNotActivated(t *testing.T) {
+	defer func(hook func(*state.StateDB, *types.Header) (map[common.Hash]uint64, error)) {
+		core.GetStylusModuleRefs = hook
+	}(core.GetStylusModuleRefs)
+
+	var (
+		api     = NewStylusAPI(newStylusTestBackend(t, 2))
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		genesis = rpc.BlockNumberOrHashWithNumber(0)
+	)
+	core.GetStylusModuleRefs = func(statedb *state.StateDB, header *types.Header) (map[common.Hash]uint64, error) {
+		if header.Number.Sign() == 0 {
+			return map[common.Hash]uint64{}, nil
+		}
+		return map[common.Hash]uint64{{1}: 1}, nil
+	}
+	if _, err := api.GetAsm(context.Background(), common.Hash{1}, latest); err != nil {
+		t.Fatalf("failed to get asm: %v", err)
+	}
+	var notActivated *ProgramNotActivatedError
+	if _, err := api.GetAsm(context.Background(), common.Hash{1}, genesis); !errors.As(err, &notActivated) || notActivated.Target != "" {
+		t.Fatalf("expected not activated at block error, have %v", err)
+	}
+	if _, err := api.GetModule(context.Background(), common.Hash{2}, latest); !errors.As(err, &notActivated) || notActivated.Block != 1 {
+		t.Fatalf("expected not activated at block error, have %v", err)
+	}
+}
+
+func TestStylusGetActivatedPrograms(t *testing.T) {
+	t.Parallel()
+
+	var (
+		api    = NewStylusAPI(newStylusTestBackend(t, 5))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		limit  = hexutil.Uint64(2)
+		cursor *common.Hash
+		seen   []common.Hash
+	)
+	for {
+		page, err := api.GetActivatedPrograms(context.Background(), latest, cursor, &limit)
+		if err != nil {
+			t.Fatalf("failed to get activated programs: %v", err)
+		}
+		for _, program := range page.Programs {
+			size := int(program.ModuleHash[0])
+			if have := program.AsmSizes[rawdb.TargetWavm]; int(have) != size {
+				t.Fatalf("program %v: wavm size mismatch: have %d, want %d", program.ModuleHash, have, size)
+			}
+			if have := program.AsmSizes[rawdb.LocalTarget()]; int(have) != 2*size {
+				t.Fatalf("program %v: asm size mismatch: have %d, want %d", program.ModuleHash, have, 2*size)
+			}
+			seen = append(seen, program.ModuleHash)
+		}
+		if page.Next == nil {
+			break
+		}
+		cursor = page.Next
+	}
+	if len(seen) != 5 {
+		t.Fatalf("program count mismatch: have %d, want 5", len(seen))
+	}
+	for i, hash := range seen {
+		if hash != (common.Hash{byte(i + 1)}) {
+			t.Fatalf("program %d mismatch: have %v", i, hash)
+		}
+	}
+}
+
+// Tests that the programs listed are the ones activated at the requested
+// block, along with their Stylus version.
+func TestStylusGetActivatedProgramsAtBlock(t *testing.T) {
+	defer func(refs func(*state.StateDB, *types.Header) (map[common.Hash]uint64, error), versions func(*state.StateDB, *types.Header) (map[common.Hash]uint16, error)) {
+		core.GetStylusModuleRefs, core.GetStylusModuleVersions = refs, versions
+	}(core.GetStylusModuleRefs, core.GetStylusModuleVersions)
+
+	var (
+		api     = NewStylusAPI(newStylusTestBackend(t, 3))
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		genesis = rpc.BlockNumberOrHashWithNumber(0)
+	)
+	core.GetStylusModuleRefs = func(statedb *state.StateDB, header *types.Header) (map[common.Hash]uint64, error) {
+		if header.Number.Sign() == 0 {
+			return map[common.Hash]uint64{{2}: 1}, nil
+		}
+		return map[common.Hash]uint64{{1}: 1, {2}: 2, {3}: 1}, nil
+	}
+	core.GetStylusModuleVersions = func(statedb *state.StateDB, header *types.Header) (map[common.Hash]uint16, error) {
+		return map[common.Hash]uint16{{1}: 1, {2}: 1, {3}: 2}, nil
+	}
+	page, err := api.GetActivatedPrograms(context.Background(), genesis, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get activated programs: %v", err)
+	}
+	if len(page.Programs) != 1 || page.Programs[0].ModuleHash != (common.Hash{2}) {
+		t.Fatalf("programs mismatch at genesis: have %v", page.Programs)
+	}
+	limit := hexutil.Uint64(2)
+	cursor := common.Hash{2}
+	page, err = api.GetActivatedPrograms(context.Background(), latest, &cursor, &limit)
+	if err != nil {
+		t.Fatalf("failed to get activated programs: %v", err)
+	}
+	if len(page.Programs) != 2 || page.Next != nil {
+		t.Fatalf("page mismatch: have %d programs, next %v", len(page.Programs), page.Next)
+	}
+	for i, version := range []uint64{1, 2} {
+		program := page.Programs[i]
+		if program.ModuleHash != (common.Hash{byte(i + 2)}) {
+			t.Fatalf("program %d mismatch: have %v", i, program.ModuleHash)
+		}
+		if program.StylusVersion == nil || uint64(*program.StylusVersion) != version {
+			t.Fatalf("program %v: version mismatch: have %v, want %d", program.ModuleHash, program.StylusVersion, version)
+		}
+	}
+}
+
+func TestStylusGetActivationInfo(t *testing.T) {
+	var (
+		api      = NewStylusAPI(newStylusTestBackend(t, 1))
+		latest   = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		codeHash = common.HexToHash("0xc0de")
+	)
+	if _, err := api.GetActivationInfo(context.Background(), codeHash.Bytes(), latest); err == nil {
+		t.Fatal("expected error without ArbOS")
+	}
+	core.GetStylusProgramInfo = func(statedb *state.StateDB, header *types.Header, hash common.Hash) (*core.StylusProgramInfo, error) {
+		if hash != codeHash {
+			return nil, errors.New("program not activated")
+		}
+		return &core.StylusProgramInfo{Version: 2, ModuleHash: common.Hash{1}, InitCost: 100, Footprint: 3}, nil
+	}
+	defer func() { core.GetStylusProgramInfo = nil }()
+
+	info, err := api.GetActivationInfo(context.Background(), codeHash.Bytes(), latest)
+	if err != nil {
+		t.Fatalf("failed to get activation info: %v", err)
+	}
+	want := &ActivationInfo{
+		CodeHash:      codeHash,
+		ModuleHash:    common.Hash{1},
+		StylusVersion: 2,
+		InitCost:      100,
+		Footprint:     3,
+		AsmSizes: map[ethdb.WasmTarget]hexutil.Uint64{
+			rawdb.TargetWavm:    1,
+			rawdb.LocalTarget(): 2,
+		},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Fatalf("activation info mismatch: have %+v, want %+v", info, want)
+	}
+	if _, err := api.GetActivationInfo(context.Background(), []byte{0x01}, latest); err == nil {
+		t.Fatal("expected error for malformed program identifier")
+	}
+}
+
+func TestStylusGetAsmForTarget(t *testing.T) {
+	t.Parallel()
+
+	api := NewStylusAPI(newStylusTestBackend(t, 1))
+
+	targets, err := api.GetTargets()
+	if err != nil {
+		t.Fatalf("failed to get targets: %v", err)
+	}
+	if want := []ethdb.WasmTarget{rawdb.TargetWavm, rawdb.LocalTarget()}; !reflect.DeepEqual(targets, want) {
+		t.Fatalf("targets mismatch: have %v, want %v", targets, want)
+	}
+	asm, err := api.GetAsmForTarget(context.Background(), common.Hash{1}, rawdb.TargetWavm, nil)
+	if err != nil {
+		t.Fatalf("failed to get asm: %v", err)
+	}
+	if !bytes.Equal(asm, []byte{0x01}) {
+		t.Fatalf("asm mismatch: have %x, want 01", asm)
+	}
+	if _, err := api.GetAsmForTarget(context.Background(), common.Hash{1}, "riscv", nil); err == nil {
+		t.Fatal("expected error for unsupported target")
+	}
+	var (
+		other        = rawdb.TargetArm64
+		notActivated *ProgramNotActivatedError
+	)
+	if rawdb.LocalTarget() == other {
+		other = rawdb.TargetAmd64
+	}
+	if _, err := api.GetAsmForTarget(context.Background(), common.Hash{1}, other, nil); !errors.As(err, &notActivated) {
+		t.Fatalf("expected not activated error, have %v", err)
+	}
+}
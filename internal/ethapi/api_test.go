@@ -551,7 +551,39 @@ func (b testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.StateAndHeaderByNumber(ctx, blockNr)
 	}
-	panic("only implemented for number")
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	stateDb, err := b.chain.StateAt(header.Root)
+	return stateDb, header, err
+}
+func (b testBackend) StateAtTransactionIndex(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error) {
+	parent := b.chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, errors.New("parent not found")
+	}
+	statedb, err := b.chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(b.chain.Config(), block.Number(), block.Time())
+	for idx, tx := range block.Transactions() {
+		if idx == txIndex {
+			return statedb, nil
+		}
+		msg, _ := core.TransactionToMessage(tx, signer, block.BaseFee(), core.MessageReplayMode)
+		vmenv := vm.NewEVM(core.NewEVMBlockContext(block.Header(), b.chain, nil), core.NewEVMTxContext(msg), statedb, b.chain.Config(), vm.Config{})
+		statedb.SetTxContext(tx.Hash(), idx)
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+			return nil, err
+		}
+		statedb.Finalise(vmenv.ChainConfig().IsEIP158(block.Number()))
+	}
+	return nil, fmt.Errorf("transaction index %d out of range", txIndex)
 }
 func (b testBackend) Pending() (*types.Block, types.Receipts, *state.StateDB) { panic("implement me") }
 func (b testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
@@ -621,6 +653,12 @@ func (b testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent)
 func (b testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription {
+	panic("implement me")
+}
+func (b testBackend) SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription {
+	panic("implement me")
+}
 func (b testBackend) BloomStatus() (uint64, uint64) { panic("implement me") }
 func (b testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	panic("implement me")
@@ -770,7 +808,7 @@ func TestEstimateGas(t *testing.T) {
 		},
 	}
 	for i, tc := range testSuite {
-		result, err := api.EstimateGas(context.Background(), tc.call, &rpc.BlockNumberOrHash{BlockNumber: &tc.blockNumber}, &tc.overrides)
+		result, err := api.EstimateGas(context.Background(), tc.call, &rpc.BlockNumberOrHash{BlockNumber: &tc.blockNumber}, &tc.overrides, nil)
 		if tc.expectErr != nil {
 			if err == nil {
 				t.Errorf("test %d: want error %v, have nothing", i, tc.expectErr)
@@ -785,8 +823,8 @@ func TestEstimateGas(t *testing.T) {
 			t.Errorf("test %d: want no error, have %v", i, err)
 			continue
 		}
-		if float64(result) > float64(tc.want)*(1+gasestimator.EstimateGasErrorRatio) {
-			t.Errorf("test %d, result mismatch, have\n%v\n, want\n%v\n", i, uint64(result), tc.want)
+		if float64(result.Gas) > float64(tc.want)*(1+gasestimator.EstimateGasErrorRatio) {
+			t.Errorf("test %d, result mismatch, have\n%v\n, want\n%v\n", i, uint64(result.Gas), tc.want)
 		}
 	}
 }
@@ -2040,7 +2078,7 @@ func TestRPCGetBlockReceipts(t *testing.T) {
 			result interface{}
 			err    error
 		)
-		result, err = api.GetBlockReceipts(context.Background(), tt.test)
+		result, err = api.GetBlockReceipts(context.Background(), tt.test, nil)
 		if err != nil {
 			t.Errorf("test %d: want no error, have %v", i, err)
 			continue
@@ -2049,6 +2087,48 @@ func TestRPCGetBlockReceipts(t *testing.T) {
 	}
 }
 
+func TestRPCGetTransactionReceiptsByBlock(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genBlocks  = 6
+		backend, _ = setupReceiptBackend(t, genBlocks)
+		api        = NewBlockChainAPI(backend)
+		txAPI      = NewTransactionAPI(backend, new(AddrLocker))
+		ctx        = context.Background()
+	)
+	for i := 0; i <= genBlocks; i++ {
+		block, err := backend.BlockByNumber(ctx, rpc.BlockNumber(i))
+		if err != nil {
+			t.Fatalf("failed to get block %d: %v", i, err)
+		}
+		receipts, err := api.GetTransactionReceiptsByBlock(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to get receipts: %v", i, err)
+		}
+		if len(receipts) != len(block.Transactions()) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", i, len(receipts), len(block.Transactions()))
+		}
+		// Every receipt must match the one returned by eth_getTransactionReceipt
+		for j, tx := range block.Transactions() {
+			want, err := txAPI.GetTransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				t.Fatalf("block %d tx %d: failed to get receipt: %v", i, j, err)
+			}
+			haveJSON, _ := json.Marshal(receipts[j])
+			wantJSON, _ := json.Marshal(want)
+			if !bytes.Equal(haveJSON, wantJSON) {
+				t.Fatalf("block %d tx %d: receipt mismatch:\nhave %s\nwant %s", i, j, haveJSON, wantJSON)
+			}
+		}
+	}
+	// Missing blocks are reported as null
+	receipts, err := api.GetTransactionReceiptsByBlock(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(genBlocks+1)))
+	if err != nil || receipts != nil {
+		t.Fatalf("missing block: have %v, %v, want nil, nil", receipts, err)
+	}
+}
+
 func testRPCResponseWithFile(t *testing.T, testid int, result interface{}, rpc string, file string) {
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
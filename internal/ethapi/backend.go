@@ -70,6 +70,9 @@ type Backend interface {
 	BlockMetadataByNumber(blockNum uint64) (common.BlockMetadata, error)
 	StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error)
 	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+	// Arbitrum: state of a block right before the execution of one of its
+	// transactions, from the cache of the intra-block states if enabled
+	StateAtTransactionIndex(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error)
 	Pending() (*types.Block, types.Receipts, *state.StateDB)
 	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
 	GetTd(ctx context.Context, hash common.Hash) *big.Int
@@ -99,6 +102,8 @@ type Backend interface {
 	GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error)
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription
+	SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
@@ -127,6 +132,9 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		}, {
 			Namespace: "personal",
 			Service:   NewPersonalAccountAPI(apiBackend, nonceLock),
+		}, {
+			Namespace: "stylus",
+			Service:   NewStylusAPI(apiBackend),
 		},
 	}
 }
@@ -0,0 +1,193 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+// maxBalanceHistorySamples is the maximum number of blocks sampled by a single
+// eth_getBalanceHistory call.
+const maxBalanceHistorySamples = 4096
+
+// maxBalanceHistoryStateSamples is the maximum number of blocks sampled by a
+// single eth_getBalanceHistory call when the flat state history doesn't cover
+// the range, as the state of every sampled block may need to be regenerated.
+const maxBalanceHistoryStateSamples = 16
+
+// BalanceHistoryEntry is the balance and nonce of an account as of a block.
+type BalanceHistoryEntry struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Balance     *hexutil.Big   `json:"balance"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+}
+
+// GetBalanceHistory returns the balance and nonce of an account sampled every
+// step blocks between fromBlock and toBlock, both inclusive. The series is
+// compact: a sample is only returned if it differs from the previous one, the
+// first sample always being returned.
+//
+// The account is read from the flat state history if it covers the range, and
+// otherwise from the state of every sampled block. As that state may have to be
+// regenerated by re-executing blocks, only a handful of blocks are sampled then.
+func (s *BlockChainAPI) GetBalanceHistory(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber, step *hexutil.Uint64) ([]BalanceHistoryEntry, error) {
+	from, err := s.resolveBlockNumber(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolveBlockNumber(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	stride := uint64(1)
+	if step != nil {
+		if *step == 0 {
+			return nil, errors.New("step must be positive")
+		}
+		stride = uint64(*step)
+	}
+	samples := (to-from)/stride + 1
+	if samples > maxBalanceHistorySamples {
+		return nil, fmt.Errorf("too many blocks sampled: %d, max %d", samples, maxBalanceHistorySamples)
+	}
+	flat := newFlatAccountReader(s.b, address, from, to)
+	if flat == nil && samples > maxBalanceHistoryStateSamples {
+		return nil, fmt.Errorf("too many blocks sampled outside the flat state history: %d, max %d", samples, maxBalanceHistoryStateSamples)
+	}
+	var (
+		history   = []BalanceHistoryEntry{}
+		prevBal   *uint256.Int
+		prevNonce uint64
+	)
+	for number := from; number <= to; number += stride {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var (
+			balance *uint256.Int
+			nonce   uint64
+		)
+		if flat != nil {
+			if balance, nonce, err = flat.read(ctx, number); err != nil {
+				return nil, err
+			}
+		} else {
+			state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(number))
+			if state == nil || err != nil {
+				return nil, fmt.Errorf("state of block %d unavailable: %w", number, err)
+			}
+			balance, nonce = state.GetBalance(address), state.GetNonce(address)
+			if err := state.Error(); err != nil {
+				return nil, err
+			}
+		}
+		if prevBal == nil || !balance.Eq(prevBal) || nonce != prevNonce {
+			history = append(history, BalanceHistoryEntry{
+				BlockNumber: hexutil.Uint64(number),
+				Balance:     (*hexutil.Big)(balance.ToBig()),
+				Nonce:       hexutil.Uint64(nonce),
+			})
+		}
+		prevBal, prevNonce = balance, nonce
+
+		// Guard against overflowing the block number
+		if number+stride < number {
+			break
+		}
+	}
+	return history, nil
+}
+
+// resolveBlockNumber resolves a block number, possibly a tag, to a concrete one.
+func (s *BlockChainAPI) resolveBlockNumber(ctx context.Context, number rpc.BlockNumber) (uint64, error) {
+	if number >= 0 {
+		return uint64(number), nil
+	}
+	header, err := s.b.HeaderByNumber(ctx, number)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("block %v not found", number)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// flatAccountReader reads an account after the blocks covered by the flat state
+// history, without opening their state.
+type flatAccountReader struct {
+	b       Backend
+	address common.Address
+	head    common.Hash // Hash of the newest indexed block
+
+	headBalance *uint256.Int // Account in the state of the newest indexed block, once read
+	headNonce   uint64
+}
+
+// newFlatAccountReader returns a reader of the account after the blocks of the
+// given range, or nil if the flat state history doesn't cover it.
+func newFlatAccountReader(b Backend, address common.Address, from, to uint64) *flatAccountReader {
+	db := b.ChainDb()
+	tail, head, hash, ok := rawdb.ReadFlatHistoryRange(db)
+	if !ok || from+1 < tail || to > head || rawdb.ReadCanonicalHash(db, head) != hash {
+		return nil
+	}
+	return &flatAccountReader{b: b, address: address, head: hash}
+}
+
+// read returns the balance and nonce of the account after the given block: the
+// original values recorded by the first block modifying the account afterwards,
+// or its values in the state of the newest indexed block if there is none.
+func (r *flatAccountReader) read(ctx context.Context, number uint64) (*uint256.Int, uint64, error) {
+	numbers, origins, err := rawdb.ReadFlatAccountHistory(r.b.ChainDb(), r.address, number, number)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(numbers) > 0 {
+		if len(origins[0]) == 0 {
+			return new(uint256.Int), 0, nil
+		}
+		account, err := types.FullAccount(origins[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		return account.Balance, account.Nonce, nil
+	}
+	if r.headBalance == nil {
+		state, _, err := r.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(r.head, false))
+		if state == nil || err != nil {
+			return nil, 0, fmt.Errorf("state of flat history head unavailable: %w", err)
+		}
+		r.headBalance, r.headNonce = state.GetBalance(r.address), state.GetNonce(r.address)
+		if err := state.Error(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return r.headBalance, r.headNonce, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestGetBalanceHistory(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	// Transfer 1000 wei to account 1 in every odd block (1, 3 and 5)
+	api := NewBlockChainAPI(newTestBackend(t, 6, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {
+		if i%2 == 1 {
+			return
+		}
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce, To: &accounts[1].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, accounts[0].key)
+		b.AddTx(tx)
+		nonce++
+	}))
+	type sample struct{ number, balance, nonce uint64 }
+	tests := []struct {
+		step *hexutil.Uint64
+		addr int
+		want []sample
+	}{
+		{
+			addr: 1,
+			want: []sample{{0, 0, 0}, {1, 1000, 0}, {3, 2000, 0}, {5, 3000, 0}},
+		},
+		{
+			step: (*hexutil.Uint64)(new(uint64)),
+		},
+		{
+			step: func() *hexutil.Uint64 { s := hexutil.Uint64(2); return &s }(),
+			addr: 1,
+			want: []sample{{0, 0, 0}, {2, 1000, 0}, {4, 2000, 0}, {6, 3000, 0}},
+		},
+	}
+	for i, tt := range tests {
+		history, err := api.GetBalanceHistory(context.Background(), accounts[tt.addr].addr, 0, rpc.LatestBlockNumber, tt.step)
+		if tt.want == nil {
+			if err == nil {
+				t.Fatalf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: failed to get history: %v", i, err)
+		}
+		if len(history) != len(tt.want) {
+			t.Fatalf("test %d: sample count mismatch: have %d, want %d", i, len(history), len(tt.want))
+		}
+		for j, want := range tt.want {
+			have := history[j]
+			if uint64(have.BlockNumber) != want.number || have.Balance.ToInt().Uint64() != want.balance || uint64(have.Nonce) != want.nonce {
+				t.Fatalf("test %d sample %d: have {%d %v %d}, want %v", i, j, have.BlockNumber, have.Balance, have.Nonce, want)
+			}
+		}
+	}
+	// The nonce of the sender is tracked as well
+	history, err := api.GetBalanceHistory(context.Background(), accounts[0].addr, 2, 3, nil)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].Nonce != 1 || history[1].Nonce != 2 {
+		t.Fatalf("unexpected sender history: %+v", history)
+	}
+	// Without flat state history, only a handful of blocks are sampled
+	if _, err := api.GetBalanceHistory(context.Background(), accounts[0].addr, 0, maxBalanceHistoryStateSamples, nil); err == nil {
+		t.Fatal("oversized range sampled from state")
+	}
+}
+
+// Tests that the balance history is served from the flat state history when it
+// covers the requested range.
+func TestGetBalanceHistoryFlat(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.HomesteadSigner{}
+		engine = ethash.NewFaker()
+	)
+	// Transfer 1000 wei to account 1 in every odd block (1, 3 and 5)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 6, func(i int, b *core.BlockGen) {
+		if i%2 == 1 {
+			return
+		}
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: b.TxNonce(accounts[0].addr), To: &accounts[1].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{TrieCleanLimit: 256, TrieDirtyDisabled: true, FlatHistory: true}, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	backend := &testBackend{db: db, chain: chain}
+	if newFlatAccountReader(backend, accounts[1].addr, 0, 6) == nil {
+		t.Fatal("flat history not covering the chain")
+	}
+	api := NewBlockChainAPI(backend)
+	for _, addr := range []int{0, 1} {
+		history, err := api.GetBalanceHistory(context.Background(), accounts[addr].addr, 0, rpc.LatestBlockNumber, nil)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		// Compare against the balances and nonces of the archived states
+		var want []BalanceHistoryEntry
+		for number := uint64(0); number <= 6; number++ {
+			statedb, _ := chain.StateAt(chain.GetHeaderByNumber(number).Root)
+			balance, nonce := statedb.GetBalance(accounts[addr].addr).ToBig(), statedb.GetNonce(accounts[addr].addr)
+			if n := len(want); n == 0 || want[n-1].Balance.ToInt().Cmp(balance) != 0 || uint64(want[n-1].Nonce) != nonce {
+				want = append(want, BalanceHistoryEntry{BlockNumber: hexutil.Uint64(number), Balance: (*hexutil.Big)(balance), Nonce: hexutil.Uint64(nonce)})
+			}
+		}
+		if !reflect.DeepEqual(history, want) {
+			t.Fatalf("account %d history mismatch:\nhave %+v\nwant %+v", addr, history, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxCallManyCalls is the maximum number of calls in a single eth_callMany bundle.
+const maxCallManyCalls = 1024
+
+// BundleCall is a single call of an eth_callMany bundle.
+type BundleCall struct {
+	Call           TransactionArgs `json:"call"`
+	StateOverrides *StateOverride  `json:"stateOverrides"` // applied right before the call
+	AllowRevert    bool            `json:"allowRevert"`    // whether the bundle continues if the call fails
+}
+
+// CallMany executes an ordered bundle of calls on top of the state of the given
+// block, each call seeing the state changes of the ones before it. The calls
+// are executed in a single block context, optionally overridden.
+//
+// Unless a call is allowed to revert, the bundle stops at the first failing
+// call; its result is the last one returned.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *BlockChainAPI) CallMany(ctx context.Context, calls []BundleCall, blockNrOrHash *rpc.BlockNumberOrHash, blockOverrides *BlockOverrides) ([]simCallResult, error) {
+	if len(calls) == 0 {
+		return nil, &invalidParamsError{message: "empty bundle"}
+	} else if len(calls) > maxCallManyCalls {
+		return nil, &clientLimitExceededError{message: fmt.Sprintf("too many calls: %d, max %d", len(calls), maxCallManyCalls)}
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	header = updateHeaderForPendingBlocks(*blockNrOrHash, header)
+
+	var cancel context.CancelFunc
+	timeout := s.b.RPCEVMTimeout()
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	blockCtx := core.NewEVMBlockContext(header, NewChainContext(ctx, s.b), nil)
+	blockOverrides.Apply(&blockCtx)
+
+	budget := s.b.RPCGasCap()
+	if budget == 0 {
+		budget = math.MaxUint64 / 2
+	}
+	results := make([]simCallResult, 0, len(calls))
+	for i, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := call.StateOverrides.Apply(statedb); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		args := call.Call
+		if args.Nonce == nil {
+			nonce := statedb.GetNonce(args.from())
+			args.Nonce = (*hexutil.Uint64)(&nonce)
+		}
+		if err := args.CallDefaults(budget, blockCtx.BaseFee, s.b.ChainConfig().ChainID); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		var (
+			txHash = args.ToTransaction().Hash()
+			msg    = args.ToMessage(blockCtx.BaseFee, budget, header, statedb, core.MessageEthcallMode)
+		)
+		statedb.SetTxContext(txHash, i)
+
+		// Arbitrum: support NodeInterface.sol by swapping out the message if needed
+		msg, result, err := core.InterceptRPCMessage(msg, ctx, statedb, header, s.b, &blockCtx)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		if result == nil {
+			evm := s.b.GetEVM(ctx, msg, statedb, header, &vm.Config{NoBaseFee: true}, &blockCtx)
+			go func() {
+				<-ctx.Done()
+				evm.Cancel()
+			}()
+			result, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+			if err := statedb.Error(); err != nil {
+				return nil, err
+			}
+			if evm.Cancelled() {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("call %d: %w (supplied gas %d)", i, err, msg.GasLimit)
+			}
+		}
+		if result.UsedGas > budget {
+			return nil, &clientLimitExceededError{message: "gas cap exceeded"}
+		}
+		budget -= result.UsedGas
+
+		// Finalise the call, the following ones see its changes as if it was a
+		// transaction of the same block
+		statedb.Finalise(true)
+		results = append(results, newSimCallResult(result, statedb.GetLogs(txHash, blockCtx.BlockNumber.Uint64(), header.Hash())))
+
+		if result.Failed() && !call.AllowRevert {
+			break
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCallMany(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender  = common.HexToAddress("0x1111")
+		counter = common.HexToAddress("0x2222") // increments slot 0 and returns it
+		revert  = common.HexToAddress("0x3333") // code provided by a state override
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:  {Balance: big.NewInt(params.Ether)},
+				counter: {Code: hexutil.MustDecode("0x6000546001018060005560005260206000f3")},
+			},
+		}
+		api        = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+		revertCode = hexutil.Bytes(hexutil.MustDecode("0x60006000fd"))
+	)
+	for _, allowRevert := range []bool{false, true} {
+		bundle := []BundleCall{
+			{Call: TransactionArgs{From: &sender, To: &counter}},
+			{Call: TransactionArgs{From: &sender, To: &counter}},
+			{
+				Call:           TransactionArgs{From: &sender, To: &revert},
+				StateOverrides: &StateOverride{revert: OverrideAccount{Code: &revertCode}},
+				AllowRevert:    allowRevert,
+			},
+			{Call: TransactionArgs{From: &sender, To: &counter}},
+		}
+		results, err := api.CallMany(context.Background(), bundle, nil, nil)
+		if err != nil {
+			t.Fatalf("bundle failed: %v", err)
+		}
+		want := 3
+		if allowRevert {
+			want = 4
+		}
+		if len(results) != want {
+			t.Fatalf("allowRevert=%v: result count mismatch: have %d, want %d", allowRevert, len(results), want)
+		}
+		// Calls must see the state changes of the preceding ones
+		for i, want := range []uint64{1, 2} {
+			if have := new(big.Int).SetBytes(results[i].ReturnValue).Uint64(); have != want {
+				t.Fatalf("call %d return value mismatch: have %d, want %d", i, have, want)
+			}
+		}
+		if results[2].Error == nil || results[2].Error.Code != errCodeReverted {
+			t.Fatalf("expected reverted call, have %+v", results[2])
+		}
+		if allowRevert {
+			if have := new(big.Int).SetBytes(results[3].ReturnValue).Uint64(); have != 3 {
+				t.Fatalf("call 3 return value mismatch: have %d, want 3", have)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxCodeBatchSize is the maximum number of accounts whose code is retrieved
+// by a single eth_getCodes call.
+const maxCodeBatchSize = 1024
+
+// CodeResult is the code of a single account returned by eth_getCodes. The
+// code itself is omitted if only the hashes and sizes were requested.
+type CodeResult struct {
+	Address  common.Address `json:"address"`
+	CodeHash common.Hash    `json:"codeHash"`
+	CodeSize hexutil.Uint64 `json:"codeSize"`
+	Code     *hexutil.Bytes `json:"code,omitempty"`
+}
+
+// GetCodes returns the code of many accounts at a single block. The state is
+// only resolved once and the accounts are loaded concurrently. If hashesOnly
+// is set, only the code hashes and sizes are returned, which is enough to
+// detect changes of e.g. proxy implementations without transferring the code.
+func (s *BlockChainAPI) GetCodes(ctx context.Context, addresses []common.Address, blockNrOrHash rpc.BlockNumberOrHash, hashesOnly *bool) ([]*CodeResult, error) {
+	if len(addresses) > maxCodeBatchSize {
+		return nil, fmt.Errorf("too many accounts requested: %d, max %d", len(addresses), maxCodeBatchSize)
+	}
+	statedb, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	statedb.EnableConcurrentReads()
+
+	var (
+		results = make([]*CodeResult, len(addresses))
+		workers errgroup.Group
+	)
+	workers.SetLimit(runtime.NumCPU())
+	for i, addr := range addresses {
+		result := &CodeResult{Address: addr}
+		results[i] = result
+
+		workers.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result.CodeHash = statedb.GetCodeHash(result.Address)
+			result.CodeSize = hexutil.Uint64(statedb.GetCodeSize(result.Address))
+			if hashesOnly == nil || !*hashesOnly {
+				code := hexutil.Bytes(statedb.GetCode(result.Address))
+				result.Code = &code
+			}
+			return nil
+		})
+	}
+	if err := workers.Wait(); err != nil {
+		return nil, err
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
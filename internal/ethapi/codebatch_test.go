@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestGetCodes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		alloc     = make(types.GenesisAlloc)
+		addresses []common.Address
+	)
+	for i := 0; i < 64; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		alloc[addr] = types.Account{Balance: common.Big1, Code: bytes.Repeat([]byte{byte(i)}, i+1)}
+		addresses = append(addresses, addr)
+	}
+	var (
+		eoa     = common.HexToAddress("0x1111")
+		missing = common.HexToAddress("0x3333")
+		genesis = &core.Genesis{Config: params.TestChainConfig, Alloc: alloc}
+	)
+	alloc[eoa] = types.Account{Balance: big.NewInt(params.Ether)}
+	addresses = append(addresses, eoa, missing)
+
+	var (
+		api    = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	results, err := api.GetCodes(context.Background(), addresses, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to get codes: %v", err)
+	}
+	for i, addr := range addresses {
+		want, _ := api.GetCode(context.Background(), addr, latest)
+		if have := results[i]; have.Address != addr || !bytes.Equal(*have.Code, want) || int(have.CodeSize) != len(want) {
+			t.Fatalf("code %d mismatch: have %x (%d bytes), want %x", i, *have.Code, have.CodeSize, want)
+		}
+		if len(want) > 0 && results[i].CodeHash != crypto.Keccak256Hash(want) {
+			t.Fatalf("code hash %d mismatch: have %x", i, results[i].CodeHash)
+		}
+	}
+	hashesOnly := true
+	results, err = api.GetCodes(context.Background(), addresses[:2], latest, &hashesOnly)
+	if err != nil {
+		t.Fatalf("failed to get code hashes: %v", err)
+	}
+	if results[1].Code != nil || results[1].CodeSize != 2 || results[1].CodeHash != crypto.Keccak256Hash([]byte{1, 1}) {
+		t.Fatalf("unexpected code hash result: %+v", results[1])
+	}
+	if _, err := api.GetCodes(context.Background(), make([]common.Address, maxCodeBatchSize+1), latest, nil); err == nil {
+		t.Fatal("oversized batch accepted")
+	}
+}
@@ -19,10 +19,35 @@ package ethapi
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// DBAPI provides raw read access to the chain database over the debug
+// namespace.
+//
+// Arbitrum: the API isn't part of GetAPIs, as Arbitrum nodes only expose their
+// database if explicitly enabled.
+type DBAPI struct {
+	b Backend
+}
+
+// NewDBAPI creates a new instance of DBAPI.
+func NewDBAPI(b Backend) *DBAPI {
+	return &DBAPI{b: b}
+}
+
+// GetDBAPIs returns the raw database access APIs.
+func GetDBAPIs(apiBackend Backend) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "debug",
+			Service:   NewDBAPI(apiBackend),
+		},
+	}
+}
+
 // DbGet returns the raw value of a key stored in the database.
-func (api *DebugAPI) DbGet(key string) (hexutil.Bytes, error) {
+func (api *DBAPI) DbGet(key string) (hexutil.Bytes, error) {
 	blob, err := common.ParseHexOrString(key)
 	if err != nil {
 		return nil, err
@@ -32,12 +57,12 @@ func (api *DebugAPI) DbGet(key string) (hexutil.Bytes, error) {
 
 // DbAncient retrieves an ancient binary blob from the append-only immutable files.
 // It is a mapping to the `AncientReaderOp.Ancient` method
-func (api *DebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error) {
+func (api *DBAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error) {
 	return api.b.ChainDb().Ancient(kind, number)
 }
 
 // DbAncients returns the ancient item numbers in the ancient store.
 // It is a mapping to the `AncientReaderOp.Ancients` method
-func (api *DebugAPI) DbAncients() (uint64, error) {
+func (api *DBAPI) DbAncients() (uint64, error) {
 	return api.b.ChainDb().Ancients()
 }
@@ -17,19 +17,26 @@
 package ethapi
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 // revertError is an API error that encompasses an EVM revert with JSON error
 // code and a binary data blob.
 type revertError struct {
 	error
-	reason string // revert reason hex encoded
+	reason  string      // revert reason hex encoded
+	decoded *RevertData // revert reason decoded
 }
 
 // ErrorCode returns the JSON error code for a revert.
@@ -38,9 +45,9 @@ func (e *revertError) ErrorCode() int {
 	return 3
 }
 
-// ErrorData returns the hex encoded revert reason.
+// ErrorData returns the structured revert reason.
 func (e *revertError) ErrorData() interface{} {
-	return e.reason
+	return e.decoded
 }
 
 // newRevertError creates a revertError instance with the provided revert data.
@@ -56,8 +63,9 @@ func newRevertError(revert []byte) *revertError {
 		}
 	}
 	return &revertError{
-		error:  err,
-		reason: hexutil.Encode(revert),
+		error:   err,
+		reason:  hexutil.Encode(revert),
+		decoded: DecodeRevert(revert),
 	}
 }
 
@@ -65,6 +73,98 @@ func NewRevertError(result *core.ExecutionResult) *revertError {
 	return newRevertError(result.Revert())
 }
 
+// Kinds of revert data.
+const (
+	RevertKindEmpty  = "empty"  // no revert data
+	RevertKindError  = "error"  // Solidity Error(string)
+	RevertKindPanic  = "panic"  // Solidity Panic(uint256)
+	RevertKindCustom = "custom" // custom error, identified by its selector
+	RevertKindText   = "text"   // plain UTF-8 text, as reverted with by e.g. Stylus programs
+	RevertKindRaw    = "raw"    // anything else
+)
+
+var (
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// RevertData is the structured form of the data a call reverted with, reported
+// in the data field of revert errors.
+type RevertData struct {
+	Kind      string         `json:"kind"`
+	Selector  *hexutil.Bytes `json:"selector,omitempty"`  // selector of the error, if any
+	Message   string         `json:"message,omitempty"`   // decoded reason, if the error is known
+	PanicCode *hexutil.Big   `json:"panicCode,omitempty"` // code of Solidity panics
+	Data      hexutil.Bytes  `json:"data"`                // raw revert data
+}
+
+// DecodeRevert decodes the data a call reverted with. Reverts which can not be
+// decoded are still reported along with their raw data.
+func DecodeRevert(revert []byte) *RevertData {
+	decoded := &RevertData{Kind: RevertKindRaw, Data: common.CopyBytes(revert)}
+	if len(revert) == 0 {
+		decoded.Kind = RevertKindEmpty
+		return decoded
+	}
+	if len(revert) >= 4 {
+		selector := hexutil.Bytes(common.CopyBytes(revert[:4]))
+		decoded.Selector = &selector
+	}
+	// Solidity errors are told apart by their selector first, so that no error
+	// is mistaken for text
+	if decoded.Selector != nil && (bytes.Equal(revert[:4], revertErrorSelector) || bytes.Equal(revert[:4], revertPanicSelector)) {
+		if reason, err := abi.UnpackRevert(revert); err == nil {
+			decoded.Message = reason
+			if bytes.Equal(revert[:4], revertPanicSelector) {
+				decoded.Kind = RevertKindPanic
+				decoded.PanicCode = (*hexutil.Big)(new(big.Int).SetBytes(revert[4:36]))
+			} else {
+				decoded.Kind = RevertKindError
+			}
+		}
+		return decoded
+	}
+	// Arbitrum: ArbOS knows the custom errors of its precompiles
+	if core.RenderRPCError != nil {
+		if arbErr := core.RenderRPCError(revert); arbErr != nil {
+			decoded.Kind = RevertKindCustom
+			decoded.Message = arbErr.Error()
+			return decoded
+		}
+	}
+	// Data shaped like an ABI encoded custom error is one, even if its selector
+	// and arguments happen to be printable
+	if decoded.Selector != nil && (len(revert)-4)%32 == 0 {
+		decoded.Kind = RevertKindCustom
+		return decoded
+	}
+	// Stylus programs may revert with plain text rather than ABI encoded errors
+	if isPrintableText(revert) {
+		decoded.Kind = RevertKindText
+		decoded.Selector = nil
+		decoded.Message = string(revert)
+		return decoded
+	}
+	if decoded.Selector != nil {
+		decoded.Kind = RevertKindCustom
+	}
+	return decoded
+}
+
+// isPrintableText reports whether the data is valid UTF-8 made of printable
+// characters only.
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // TxIndexingError is an API error that indicates the transaction indexing is not
 // fully finished yet with JSON error code and a binary data blob.
 type TxIndexingError struct{}
@@ -85,3 +185,80 @@ func (e *TxIndexingError) ErrorCode() int {
 
 // ErrorData returns the hex encoded revert reason.
 func (e *TxIndexingError) ErrorData() interface{} { return "transaction indexing is in progress" }
+
+// ProgramNotActivatedError is an API error that indicates the requested Stylus
+// program has no activated asm for the requested target at the requested block.
+type ProgramNotActivatedError struct {
+	ModuleHash common.Hash
+	Target     ethdb.WasmTarget // Target missing the asm, empty if the program isn't activated at the block
+	Block      uint64           // Block the program was requested at
+}
+
+// Error implement error interface, returning the error message.
+func (e *ProgramNotActivatedError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("stylus program %v not activated at block %d", e.ModuleHash, e.Block)
+	}
+	return fmt.Sprintf("stylus program %v not activated for target %v", e.ModuleHash, e.Target)
+}
+
+// ErrorCode returns the JSON error code for a missing program.
+// See: https://github.com/ethereum/wiki/wiki/JSON-RPC-Error-Codes-Improvement-Proposal
+func (e *ProgramNotActivatedError) ErrorCode() int {
+	return -32000
+}
+
+// ErrorData returns the module hash of the missing program.
+func (e *ProgramNotActivatedError) ErrorData() interface{} { return e.ModuleHash }
+
+const (
+	errCodeReverted              = -32000
+	errCodeVMError               = -32015
+	errCodeInvalidParams         = -32602
+	errCodeBlockGasLimitReached  = -38015
+	errCodeBlockNumberInvalid    = -38020
+	errCodeBlockTimestampInvalid = -38021
+	errCodeClientLimitExceeded   = -38026
+)
+
+// callError is the error of a single simulated call, reported as part of the
+// call result rather than failing the whole simulation.
+type callError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// invalidParamsError is returned when the parameters of a simulation are invalid.
+type invalidParamsError struct{ message string }
+
+func (e *invalidParamsError) Error() string  { return e.message }
+func (e *invalidParamsError) ErrorCode() int { return errCodeInvalidParams }
+
+// blockGasLimitReachedError is returned when the calls of a simulated block
+// exceed its gas limit.
+type blockGasLimitReachedError struct{ message string }
+
+func (e *blockGasLimitReachedError) Error() string  { return e.message }
+func (e *blockGasLimitReachedError) ErrorCode() int { return errCodeBlockGasLimitReached }
+
+// invalidBlockNumberError is returned when the block numbers of a simulation
+// are not strictly increasing.
+type invalidBlockNumberError struct{ message string }
+
+func (e *invalidBlockNumberError) Error() string  { return e.message }
+func (e *invalidBlockNumberError) ErrorCode() int { return errCodeBlockNumberInvalid }
+
+// invalidBlockTimestampError is returned when the block timestamps of a
+// simulation are decreasing.
+type invalidBlockTimestampError struct{ message string }
+
+func (e *invalidBlockTimestampError) Error() string  { return e.message }
+func (e *invalidBlockTimestampError) ErrorCode() int { return errCodeBlockTimestampInvalid }
+
+// clientLimitExceededError is returned when a simulation exceeds the limits
+// configured on the node.
+type clientLimitExceededError struct{ message string }
+
+func (e *clientLimitExceededError) Error() string  { return e.message }
+func (e *clientLimitExceededError) ErrorCode() int { return errCodeClientLimitExceeded }
@@ -0,0 +1,81 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		revert string
+		want   string
+	}{
+		{
+			revert: "0x",
+			want:   `{"kind":"empty","data":"0x"}`,
+		},
+		// Error("hello")
+		{
+			revert: "0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000568656c6c6f000000000000000000000000000000000000000000000000000000",
+			want:   `{"kind":"error","selector":"0x08c379a0","message":"hello","data":"0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000568656c6c6f000000000000000000000000000000000000000000000000000000"}`,
+		},
+		// Panic(0x11)
+		{
+			revert: "0x4e487b710000000000000000000000000000000000000000000000000000000000000011",
+			want:   `{"kind":"panic","selector":"0x4e487b71","message":"arithmetic underflow or overflow","panicCode":"0x11","data":"0x4e487b710000000000000000000000000000000000000000000000000000000000000011"}`,
+		},
+		// Custom error without arguments
+		{
+			revert: "0xdeadbeef",
+			want:   `{"kind":"custom","selector":"0xdeadbeef","data":"0xdeadbeef"}`,
+		},
+		// Custom error whose selector is printable
+		{
+			revert: "0x61626364",
+			want:   `{"kind":"custom","selector":"0x61626364","data":"0x61626364"}`,
+		},
+		// Malformed Error(string)
+		{
+			revert: "0x08c379a0414243",
+			want:   `{"kind":"raw","selector":"0x08c379a0","data":"0x08c379a0414243"}`,
+		},
+		// Plain text, e.g. a Stylus program reverting with a string
+		{
+			revert: "0x6e6f7420656e6f7567682066756e6473",
+			want:   `{"kind":"text","message":"not enough funds","data":"0x6e6f7420656e6f7567682066756e6473"}`,
+		},
+		{
+			revert: "0x0102",
+			want:   `{"kind":"raw","data":"0x0102"}`,
+		},
+	}
+	for i, tt := range tests {
+		have, err := json.Marshal(DecodeRevert(hexutil.MustDecode(tt.revert)))
+		if err != nil {
+			t.Fatalf("test %d: failed to marshal: %v", i, err)
+		}
+		if string(have) != tt.want {
+			t.Errorf("test %d: mismatch:\nhave %s\nwant %s", i, have, tt.want)
+		}
+	}
+}
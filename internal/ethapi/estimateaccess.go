@@ -0,0 +1,181 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+)
+
+// contextDependentOps are the opcodes whose result depends on the block the
+// transaction is included in rather than on the state, so that its execution
+// and gas used may differ from the estimated ones. GAS is left out, as it's
+// used by the compilers to forward the gas to every call.
+var contextDependentOps = map[vm.OpCode]bool{
+	vm.BASEFEE:     true,
+	vm.BLOBBASEFEE: true,
+	vm.GASPRICE:    true,
+	vm.COINBASE:    true,
+	vm.TIMESTAMP:   true,
+	vm.NUMBER:      true,
+	vm.DIFFICULTY:  true,
+	vm.GASLIMIT:    true,
+	vm.BLOCKHASH:   true,
+}
+
+// EstimateGasOptions configures the gas estimation of eth_estimateGas.
+type EstimateGasOptions struct {
+	// Arbitrum: whether to report the state accessed by the estimation, making
+	// it exact rather than within the allowed error ratio
+	StateAccess bool `json:"stateAccess"`
+}
+
+// EstimateGasAccess is the state accessed by the executions of a gas
+// estimation, and the constructs making the estimation fragile.
+type EstimateGasAccess struct {
+	AccessList       types.AccessList `json:"accessList"`                 // Accounts accessed, with the storage slots accessed
+	Code             []common.Address `json:"code"`                       // Accounts whose code was loaded
+	ContextDependent []string         `json:"contextDependent,omitempty"` // Context-dependent opcodes executed
+	Fragile          bool             `json:"fragile"`                    // Whether the estimate may not hold once included
+}
+
+// EstimateGasResult is the result of eth_estimateGas, marshalled as the gas
+// estimate alone unless the state access was requested.
+type EstimateGasResult struct {
+	Gas    hexutil.Uint64
+	Access *EstimateGasAccess
+}
+
+type estimateGasResultMarshaling struct {
+	Gas hexutil.Uint64 `json:"gas"`
+	*EstimateGasAccess
+}
+
+func (r EstimateGasResult) MarshalJSON() ([]byte, error) {
+	if r.Access == nil {
+		return json.Marshal(r.Gas)
+	}
+	return json.Marshal(estimateGasResultMarshaling{Gas: r.Gas, EstimateGasAccess: r.Access})
+}
+
+func (r *EstimateGasResult) UnmarshalJSON(input []byte) error {
+	if len(input) > 0 && input[0] == '"' {
+		r.Access = nil
+		return json.Unmarshal(input, &r.Gas)
+	}
+	dec := estimateGasResultMarshaling{EstimateGasAccess: new(EstimateGasAccess)}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	r.Gas, r.Access = dec.Gas, dec.EstimateGasAccess
+	return nil
+}
+
+// estimateAccessTracer records the state accessed by the executions of a gas
+// estimation, including the storage accessed by Stylus programs, and the
+// context-dependent opcodes executed.
+type estimateAccessTracer struct {
+	list     *logger.AccessListTracer
+	accounts map[common.Address]struct{} // Sender and recipient of the call
+	code     map[common.Address]struct{}
+	context  map[vm.OpCode]struct{}
+}
+
+func newEstimateAccessTracer() *estimateAccessTracer {
+	return &estimateAccessTracer{
+		list:     logger.NewAccessListTracer(nil, common.Address{}, common.Address{}, nil),
+		accounts: make(map[common.Address]struct{}),
+		code:     make(map[common.Address]struct{}),
+		context:  make(map[vm.OpCode]struct{}),
+	}
+}
+
+// addCall records the sender and recipient of the estimated call, which are
+// accessed regardless of the execution.
+func (t *estimateAccessTracer) addCall(from common.Address, to *common.Address) {
+	t.accounts[from] = struct{}{}
+	if to != nil {
+		t.accounts[*to] = struct{}{}
+	}
+}
+
+func (t *estimateAccessTracer) Hooks() *tracing.Hooks {
+	hooks := t.list.Hooks()
+	return &tracing.Hooks{
+		OnOpcode: func(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			hooks.OnOpcode(pc, opcode, gas, cost, scope, rData, depth, err)
+			op := vm.OpCode(opcode)
+			if contextDependentOps[op] {
+				t.context[op] = struct{}{}
+			}
+			if stack := scope.StackData(); (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE) && len(stack) >= 1 {
+				t.code[common.Address(stack[len(stack)-1].Bytes20())] = struct{}{}
+			}
+		},
+		OnEnter: func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+			hooks.OnEnter(depth, typ, from, to, input, gas, value)
+			if op := vm.OpCode(typ); op != vm.CREATE && op != vm.CREATE2 {
+				t.code[to] = struct{}{}
+			}
+		},
+		OnExit: hooks.OnExit,
+		CaptureStylusHostio: func(name string, args, outs []byte, startInk, endInk uint64) {
+			hooks.CaptureStylusHostio(name, args, outs, startInk, endInk)
+			switch name {
+			case "account_code", "account_code_size", "account_codehash":
+				if len(args) >= common.AddressLength {
+					t.code[common.BytesToAddress(args[:common.AddressLength])] = struct{}{}
+				}
+			}
+		},
+	}
+}
+
+// result returns the state accessed, sorted.
+func (t *estimateAccessTracer) result() *EstimateGasAccess {
+	access := &EstimateGasAccess{
+		AccessList: t.list.AccessList(),
+		Code:       make([]common.Address, 0, len(t.code)),
+	}
+	for addr := range t.accounts {
+		if !slices.ContainsFunc(access.AccessList, func(tuple types.AccessTuple) bool { return tuple.Address == addr }) {
+			access.AccessList = append(access.AccessList, types.AccessTuple{Address: addr, StorageKeys: []common.Hash{}})
+		}
+	}
+	slices.SortFunc(access.AccessList, func(a, b types.AccessTuple) int { return a.Address.Cmp(b.Address) })
+	for _, tuple := range access.AccessList {
+		slices.SortFunc(tuple.StorageKeys, func(a, b common.Hash) int { return a.Cmp(b) })
+	}
+	for addr := range t.code {
+		access.Code = append(access.Code, addr)
+	}
+	slices.SortFunc(access.Code, func(a, b common.Address) int { return a.Cmp(b) })
+	for op := range t.context {
+		access.ContextDependent = append(access.ContextDependent, op.String())
+	}
+	slices.Sort(access.ContextDependent)
+	access.Fragile = len(access.ContextDependent) > 0
+	return access
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestEstimateGasStateAccess(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender   = common.HexToAddress("0x1111")
+		contract = common.HexToAddress("0xc0de")
+		other    = common.HexToAddress("0x2222")
+		slot     = common.BigToHash(big.NewInt(7))
+	)
+	// PUSH1 7, SLOAD, POP, PUSH20 other, EXTCODESIZE, POP, BASEFEE, POP, STOP
+	code := []byte{byte(vm.PUSH1), 7, byte(vm.SLOAD), byte(vm.POP), byte(vm.PUSH20)}
+	code = append(code, other.Bytes()...)
+	code = append(code, byte(vm.EXTCODESIZE), byte(vm.POP), byte(vm.BASEFEE), byte(vm.POP), byte(vm.STOP))
+
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			sender:   {Balance: big.NewInt(params.Ether)},
+			contract: {Code: code},
+		},
+	}
+	var (
+		api    = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		args   = TransactionArgs{From: &sender, To: &contract}
+	)
+	// The plain estimation is reported as a quantity
+	plain, err := api.EstimateGas(context.Background(), args, &latest, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to estimate gas: %v", err)
+	}
+	if blob, _ := json.Marshal(plain); blob[0] != '"' {
+		t.Fatalf("plain estimation not marshalled as a quantity: %s", blob)
+	}
+	result, err := api.EstimateGas(context.Background(), args, &latest, nil, &EstimateGasOptions{StateAccess: true})
+	if err != nil {
+		t.Fatalf("failed to estimate gas with state access: %v", err)
+	}
+	if result.Gas < plain.Gas*(1000-15)/1000 || result.Gas > plain.Gas {
+		t.Fatalf("exact estimate %d out of the range of the plain one %d", result.Gas, plain.Gas)
+	}
+	want := &EstimateGasAccess{
+		AccessList: types.AccessList{
+			{Address: sender, StorageKeys: []common.Hash{}},
+			{Address: other, StorageKeys: []common.Hash{}},
+			{Address: contract, StorageKeys: []common.Hash{slot}},
+		},
+		Code:             []common.Address{other, contract},
+		ContextDependent: []string{"BASEFEE"},
+		Fragile:          true,
+	}
+	if !reflect.DeepEqual(result.Access, want) {
+		have, _ := json.Marshal(result.Access)
+		t.Fatalf("state access mismatch: have %s", have)
+	}
+	// The result round-trips through its JSON encoding
+	blob, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal result: %v", err)
+	}
+	var decoded EstimateGasResult
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, result) {
+		t.Fatalf("result mismatch after round-trip: have %+v, want %+v", decoded, result)
+	}
+}
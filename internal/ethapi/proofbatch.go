@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxProofBatchSize is the maximum number of accounts proven by a single
+// eth_getProofBatch call.
+const maxProofBatchSize = 1024
+
+// ProofRequest is a single account, and optionally some of its storage slots,
+// to be proven by eth_getProofBatch.
+type ProofRequest struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []string       `json:"storageKeys"`
+}
+
+// GetProofBatch returns the Merkle-proofs for many accounts, and optionally some
+// of their storage keys, against the state of a single block. The results are
+// equivalent to calling eth_getProof for every request, but the state is only
+// resolved once and the proofs are generated concurrently.
+func (s *BlockChainAPI) GetProofBatch(ctx context.Context, requests []ProofRequest, blockNrOrHash rpc.BlockNumberOrHash) ([]*AccountResult, error) {
+	if len(requests) > maxProofBatchSize {
+		return nil, fmt.Errorf("too many accounts requested: %d, max %d", len(requests), maxProofBatchSize)
+	}
+	// Deserialize all keys. This prevents state access on invalid input.
+	var (
+		keys       = make([][]common.Hash, len(requests))
+		keyLengths = make([][]int, len(requests))
+	)
+	for i, req := range requests {
+		keys[i] = make([]common.Hash, len(req.StorageKeys))
+		keyLengths[i] = make([]int, len(req.StorageKeys))
+		for j, hexKey := range req.StorageKeys {
+			var err error
+			keys[i][j], keyLengths[i][j], err = decodeHash(hexKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	// Resolve the account data and slot values upfront, the state is not safe
+	// for concurrent use.
+	results := make([]*AccountResult, len(requests))
+	for i, req := range requests {
+		results[i] = &AccountResult{
+			Address:      req.Address,
+			Balance:      (*hexutil.Big)(statedb.GetBalance(req.Address).ToBig()),
+			CodeHash:     statedb.GetCodeHash(req.Address),
+			Nonce:        hexutil.Uint64(statedb.GetNonce(req.Address)),
+			StorageHash:  statedb.GetStorageRoot(req.Address),
+			StorageProof: make([]StorageResult, len(keys[i])),
+		}
+		for j, key := range keys[i] {
+			// Output key encoding mirrors eth_getProof, see there for details.
+			outputKey := hexutil.Encode(key[:])
+			if keyLengths[i][j] != 32 {
+				outputKey = hexutil.EncodeBig(key.Big())
+			}
+			results[i].StorageProof[j] = StorageResult{
+				Key:   outputKey,
+				Value: (*hexutil.Big)(statedb.GetState(req.Address, key).Big()),
+				Proof: []string{},
+			}
+		}
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	// Open the account trie once and generate the proofs concurrently, each
+	// worker operating on its own copy of the trie.
+	accountTrie, err := trie.NewStateTrie(trie.StateTrieID(header.Root), statedb.Database().TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	var workers errgroup.Group
+	workers.SetLimit(runtime.NumCPU())
+
+	for i := range requests {
+		result, slots := results[i], keys[i]
+		tr := accountTrie.Copy()
+
+		workers.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var accountProof proofList
+			if err := tr.Prove(crypto.Keccak256(result.Address.Bytes()), &accountProof); err != nil {
+				return err
+			}
+			result.AccountProof = accountProof
+
+			if len(slots) == 0 || result.StorageHash == types.EmptyRootHash || result.StorageHash == (common.Hash{}) {
+				return nil
+			}
+			id := trie.StorageTrieID(header.Root, crypto.Keccak256Hash(result.Address.Bytes()), result.StorageHash)
+			storageTrie, err := trie.NewStateTrie(id, statedb.Database().TrieDB())
+			if err != nil {
+				return err
+			}
+			for j, key := range slots {
+				var proof proofList
+				if err := storageTrie.Prove(crypto.Keccak256(key.Bytes()), &proof); err != nil {
+					return err
+				}
+				result.StorageProof[j].Proof = proof
+			}
+			return nil
+		})
+	}
+	if err := workers.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestGetProofBatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		eoa      = common.HexToAddress("0x1111")
+		contract = common.HexToAddress("0x2222")
+		missing  = common.HexToAddress("0x3333")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				eoa: {Balance: big.NewInt(params.Ether)},
+				contract: {
+					Balance: common.Big1,
+					Code:    []byte{0x00},
+					Storage: map[common.Hash]common.Hash{
+						common.HexToHash("0x01"): common.HexToHash("0xaa"),
+						common.HexToHash("0x02"): common.HexToHash("0xbb"),
+					},
+				},
+			},
+		}
+		api    = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	requests := []ProofRequest{
+		{Address: eoa},
+		{Address: contract, StorageKeys: []string{"0x01", common.HexToHash("0x02").Hex(), "0x03"}},
+		{Address: missing, StorageKeys: []string{"0x01"}},
+	}
+	results, err := api.GetProofBatch(context.Background(), requests, latest)
+	if err != nil {
+		t.Fatalf("failed to get proofs: %v", err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(requests))
+	}
+	for i, req := range requests {
+		want, err := api.GetProof(context.Background(), req.Address, req.StorageKeys, latest)
+		if err != nil {
+			t.Fatalf("failed to get proof for %v: %v", req.Address, err)
+		}
+		have, _ := json.Marshal(results[i])
+		wantJSON, _ := json.Marshal(want)
+		if !bytes.Equal(have, wantJSON) {
+			t.Fatalf("proof %d mismatch:\nhave %s\nwant %s", i, have, wantJSON)
+		}
+	}
+	if _, err := api.GetProofBatch(context.Background(), []ProofRequest{{Address: eoa, StorageKeys: []string{"0xzz"}}}, latest); err == nil {
+		t.Fatal("expected error for invalid storage key")
+	}
+}
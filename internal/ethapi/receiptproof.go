@@ -0,0 +1,93 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// ReceiptProofResult is the result of an eth_getReceiptProof call. The proof
+// nodes prove the consensus encoding of the receipt at key rlp(transactionIndex)
+// in the receipt trie of the block.
+type ReceiptProofResult struct {
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	ReceiptsRoot     common.Hash    `json:"receiptsRoot"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Receipt          hexutil.Bytes  `json:"receipt"`
+	ReceiptProof     []string       `json:"receiptProof"`
+}
+
+// GetReceiptProof returns the Merkle proof of the receipt of the given
+// transaction against the receipts root of its block.
+func (s *TransactionAPI) GetReceiptProof(ctx context.Context, hash common.Hash) (*ReceiptProofResult, error) {
+	found, _, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, NewTxIndexingError() // transaction is not fully indexed
+	}
+	if !found {
+		return nil, nil // transaction is not existent or reachable
+	}
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	receipts, err := s.b.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= index {
+		return nil, nil
+	}
+	// Rebuild the receipt trie of the block and make sure it matches the header
+	tr := trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	if root := types.DeriveSha(receipts, tr); root != header.ReceiptHash {
+		return nil, fmt.Errorf("receipt root mismatch for block %#x: have %#x, want %#x", blockHash, root, header.ReceiptHash)
+	}
+	key, err := rlp.EncodeToBytes(index)
+	if err != nil {
+		return nil, err
+	}
+	var proof proofList
+	if err := tr.Prove(key, &proof); err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	receipts.EncodeIndex(int(index), &encoded)
+
+	return &ReceiptProofResult{
+		BlockHash:        blockHash,
+		BlockNumber:      hexutil.Uint64(blockNumber),
+		ReceiptsRoot:     header.ReceiptHash,
+		TransactionIndex: hexutil.Uint64(index),
+		Receipt:          encoded.Bytes(),
+		ReceiptProof:     proof,
+	}, nil
+}
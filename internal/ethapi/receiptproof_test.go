@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestGetReceiptProof(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.HomesteadSigner{}
+		txs    []common.Hash
+	)
+	// Put several transactions into a single block
+	backend := newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 5; nonce++ {
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce, To: &accounts[1].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, accounts[0].key)
+			b.AddTx(tx)
+			txs = append(txs, tx.Hash())
+		}
+	})
+	api := NewTransactionAPI(backend, new(AddrLocker))
+
+	for i, hash := range txs {
+		result, err := api.GetReceiptProof(context.Background(), hash)
+		if err != nil {
+			t.Fatalf("tx %d: failed to get receipt proof: %v", i, err)
+		}
+		if result.TransactionIndex != hexutil.Uint64(i) {
+			t.Fatalf("tx %d: index mismatch: have %d", i, result.TransactionIndex)
+		}
+		proofDb := memorydb.New()
+		for _, node := range result.ReceiptProof {
+			blob := hexutil.MustDecode(node)
+			proofDb.Put(crypto.Keccak256(blob), blob)
+		}
+		key, _ := rlp.EncodeToBytes(uint64(i))
+		value, err := trie.VerifyProof(result.ReceiptsRoot, key, proofDb)
+		if err != nil {
+			t.Fatalf("tx %d: invalid proof: %v", i, err)
+		}
+		if !bytes.Equal(value, result.Receipt) {
+			t.Fatalf("tx %d: proven receipt mismatch: have %x, want %x", i, value, result.Receipt)
+		}
+		receipt := new(types.Receipt)
+		if err := receipt.UnmarshalBinary(result.Receipt); err != nil {
+			t.Fatalf("tx %d: invalid receipt encoding: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/golang/snappy"
+)
+
+// ReceiptsEncoding selects how the receipts of eth_getBlockReceipts are encoded.
+type ReceiptsEncoding string
+
+const (
+	ReceiptsEncodingJSON       ReceiptsEncoding = "json"        // Standard JSON receipts (default)
+	ReceiptsEncodingJSONSnappy ReceiptsEncoding = "json-snappy" // Snappy compressed JSON receipts
+	ReceiptsEncodingSSZ        ReceiptsEncoding = "ssz"         // SSZ encoded receipts, see encodeReceiptsSSZ
+	ReceiptsEncodingSSZSnappy  ReceiptsEncoding = "ssz-snappy"  // Snappy compressed SSZ encoded receipts
+)
+
+// EncodedReceipts holds the receipts of a block in a compact encoding. The data
+// is serialized as base64 rather than hex, as the point is to save bandwidth.
+type EncodedReceipts struct {
+	BlockHash   common.Hash      `json:"blockHash"`
+	BlockNumber hexutil.Uint64   `json:"blockNumber"`
+	Encoding    ReceiptsEncoding `json:"encoding"`
+	Data        []byte           `json:"data"`
+}
+
+// encodedBlockReceipts returns the receipts of the block in the given encoding.
+func (s *BlockChainAPI) encodedBlockReceipts(ctx context.Context, block *types.Block, encoding ReceiptsEncoding) (*EncodedReceipts, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch encoding {
+	case ReceiptsEncodingJSONSnappy:
+		var receipts []map[string]interface{}
+		if receipts, err = s.blockReceipts(ctx, block); err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(receipts); err != nil {
+			return nil, err
+		}
+	case ReceiptsEncodingSSZ, ReceiptsEncodingSSZSnappy:
+		receipts, header, blockMetadata, err := s.blockReceiptsData(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		signer := types.MakeSigner(s.b.ChainConfig(), block.Number(), block.Time())
+		data = encodeReceiptsSSZ(receipts, block.Transactions(), header, blockMetadata, signer, s.b.ChainConfig())
+	default:
+		return nil, fmt.Errorf("unknown receipts encoding %q", encoding)
+	}
+	if encoding == ReceiptsEncodingJSONSnappy || encoding == ReceiptsEncodingSSZSnappy {
+		data = snappy.Encode(nil, data)
+	}
+	return &EncodedReceipts{
+		BlockHash:   block.Hash(),
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		Encoding:    encoding,
+		Data:        data,
+	}, nil
+}
+
+const (
+	sszOffsetSize       = 4
+	sszReceiptFixedSize = 32 + 20 + 20 + 20 + 1 + 1 + 8 + 8 + 32 + 8 + 8 + 1 + sszOffsetSize
+	sszLogFixedSize     = 20 + sszOffsetSize + sszOffsetSize
+)
+
+// encodeReceiptsSSZ encodes the receipts of a block as an SSZ List[Receipt]:
+//
+//	Receipt = Container(
+//	    transactionHash: Bytes32, from: Bytes20, to: Bytes20, contractAddress: Bytes20,
+//	    type: uint8, status: uint8, gasUsed: uint64, cumulativeGasUsed: uint64,
+//	    effectiveGasPrice: uint256, gasUsedForL1: uint64, l1BlockNumber: uint64,
+//	    timeboosted: boolean, logs: List[Log])
+//	Log = Container(address: Bytes20, topics: List[Bytes32, 4], data: ByteList)
+//
+// A zero to or contract address means none. The fields derivable from the block,
+// like the block hash and number, the transaction and log indices and the
+// bloom, are omitted.
+func encodeReceiptsSSZ(receipts types.Receipts, txs types.Transactions, header *types.Header, blockMetadata common.BlockMetadata, signer types.Signer, config *params.ChainConfig) []byte {
+	encoded := make([][]byte, len(receipts))
+	for i, receipt := range receipts {
+		encoded[i] = encodeReceiptSSZ(receipt, txs[i], i, header, blockMetadata, signer, config)
+	}
+	return encodeListSSZ(nil, encoded)
+}
+
+// encodeReceiptSSZ encodes a single receipt as an SSZ Receipt container.
+func encodeReceiptSSZ(receipt *types.Receipt, tx *types.Transaction, txIndex int, header *types.Header, blockMetadata common.BlockMetadata, signer types.Signer, config *params.ChainConfig) []byte {
+	from, _ := types.Sender(signer, tx)
+	var to common.Address
+	if tx.To() != nil {
+		to = *tx.To()
+	}
+	effectiveGasPrice := receipt.EffectiveGasPrice
+	var l1BlockNumber uint64
+	var timeboosted bool
+	if config.IsArbitrum() {
+		if price, number, ok := arbitrumReceiptPricing(tx, header, config); ok {
+			effectiveGasPrice, l1BlockNumber = new(big.Int).SetUint64(price), number
+		}
+		if blockMetadata != nil {
+			timeboosted, _ = blockMetadata.IsTxTimeboosted(txIndex)
+		}
+	}
+	buf := make([]byte, 0, sszReceiptFixedSize)
+	buf = append(buf, tx.Hash().Bytes()...)
+	buf = append(buf, from.Bytes()...)
+	buf = append(buf, to.Bytes()...)
+	buf = append(buf, receipt.ContractAddress.Bytes()...)
+	buf = append(buf, tx.Type(), byte(receipt.Status))
+	buf = binary.LittleEndian.AppendUint64(buf, receipt.GasUsed)
+	buf = binary.LittleEndian.AppendUint64(buf, receipt.CumulativeGasUsed)
+	buf = appendUint256SSZ(buf, effectiveGasPrice)
+	buf = binary.LittleEndian.AppendUint64(buf, receipt.GasUsedForL1)
+	buf = binary.LittleEndian.AppendUint64(buf, l1BlockNumber)
+	if timeboosted {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, sszReceiptFixedSize)
+
+	logs := make([][]byte, len(receipt.Logs))
+	for i, log := range receipt.Logs {
+		logs[i] = encodeLogSSZ(log)
+	}
+	return encodeListSSZ(buf, logs)
+}
+
+// encodeLogSSZ encodes a single log as an SSZ Log container.
+func encodeLogSSZ(log *types.Log) []byte {
+	buf := make([]byte, 0, sszLogFixedSize+len(log.Topics)*common.HashLength+len(log.Data))
+	buf = append(buf, log.Address.Bytes()...)
+	buf = binary.LittleEndian.AppendUint32(buf, sszLogFixedSize)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(sszLogFixedSize+len(log.Topics)*common.HashLength))
+	for _, topic := range log.Topics {
+		buf = append(buf, topic.Bytes()...)
+	}
+	return append(buf, log.Data...)
+}
+
+// encodeListSSZ appends the SSZ list of the given variable size elements: the
+// offsets of the elements, followed by the elements.
+func encodeListSSZ(buf []byte, elems [][]byte) []byte {
+	offset := len(elems) * sszOffsetSize
+	for _, elem := range elems {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+		offset += len(elem)
+	}
+	for _, elem := range elems {
+		buf = append(buf, elem...)
+	}
+	return buf
+}
+
+// appendUint256SSZ appends the given value as an SSZ uint256, little endian.
+func appendUint256SSZ(buf []byte, value *big.Int) []byte {
+	var word [32]byte
+	if value != nil {
+		value.FillBytes(word[:])
+	}
+	for i := len(word) - 1; i >= 0; i-- {
+		buf = append(buf, word[i])
+	}
+	return buf
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/snappy"
+)
+
+// decodeListSSZ splits an SSZ list of variable size elements.
+func decodeListSSZ(t *testing.T, data []byte) [][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	count := int(binary.LittleEndian.Uint32(data)) / sszOffsetSize
+	elems := make([][]byte, count)
+	for i := 0; i < count; i++ {
+		start, end := int(binary.LittleEndian.Uint32(data[i*sszOffsetSize:])), len(data)
+		if i+1 < count {
+			end = int(binary.LittleEndian.Uint32(data[(i+1)*sszOffsetSize:]))
+		}
+		if start > end || end > len(data) {
+			t.Fatalf("invalid ssz offsets %d..%d of %d", start, end, len(data))
+		}
+		elems[i] = data[start:end]
+	}
+	return elems
+}
+
+func TestRPCGetBlockReceiptsEncoded(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genBlocks  = 6
+		backend, _ = setupReceiptBackend(t, genBlocks)
+		api        = NewBlockChainAPI(backend)
+		ctx        = context.Background()
+	)
+	for i := 0; i <= genBlocks; i++ {
+		number := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(i))
+		block, _ := backend.BlockByNumber(ctx, rpc.BlockNumber(i))
+		receipts, _ := backend.GetReceipts(ctx, block.Hash())
+
+		// Compressed JSON receipts match the plain ones
+		plain, err := api.GetBlockReceipts(ctx, number, nil)
+		if err != nil {
+			t.Fatalf("block %d: failed to get receipts: %v", i, err)
+		}
+		encoding := ReceiptsEncodingJSONSnappy
+		res, err := api.GetBlockReceipts(ctx, number, &encoding)
+		if err != nil {
+			t.Fatalf("block %d: failed to get compressed receipts: %v", i, err)
+		}
+		have, err := snappy.Decode(nil, res.(*EncodedReceipts).Data)
+		if err != nil {
+			t.Fatalf("block %d: failed to decompress receipts: %v", i, err)
+		}
+		want, _ := json.Marshal(plain)
+		if !bytes.Equal(have, want) {
+			t.Fatalf("block %d: compressed receipts mismatch:\nhave %s\nwant %s", i, have, want)
+		}
+		// SSZ receipts hold the receipt fields and logs
+		encoding = ReceiptsEncodingSSZ
+		res, err = api.GetBlockReceipts(ctx, number, &encoding)
+		if err != nil {
+			t.Fatalf("block %d: failed to get ssz receipts: %v", i, err)
+		}
+		encoded := decodeListSSZ(t, res.(*EncodedReceipts).Data)
+		if len(encoded) != len(receipts) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", i, len(encoded), len(receipts))
+		}
+		for j, receipt := range receipts {
+			fixed := encoded[j][:sszReceiptFixedSize]
+			if hash := common.BytesToHash(fixed[:32]); hash != block.Transactions()[j].Hash() {
+				t.Errorf("block %d receipt %d: tx hash mismatch: have %x", i, j, hash)
+			}
+			if gas := binary.LittleEndian.Uint64(fixed[94:]); gas != receipt.GasUsed {
+				t.Errorf("block %d receipt %d: gas used mismatch: have %d, want %d", i, j, gas, receipt.GasUsed)
+			}
+			logs := decodeListSSZ(t, encoded[j][sszReceiptFixedSize:])
+			if len(logs) != len(receipt.Logs) {
+				t.Fatalf("block %d receipt %d: log count mismatch: have %d, want %d", i, j, len(logs), len(receipt.Logs))
+			}
+			for k, log := range receipt.Logs {
+				if addr := common.BytesToAddress(logs[k][:20]); addr != log.Address {
+					t.Errorf("block %d receipt %d log %d: address mismatch: have %x", i, j, k, addr)
+				}
+				if data := logs[k][sszLogFixedSize+len(log.Topics)*common.HashLength:]; !bytes.Equal(data, log.Data) {
+					t.Errorf("block %d receipt %d log %d: data mismatch: have %x", i, j, k, data)
+				}
+			}
+		}
+	}
+	// Unknown encodings are rejected
+	encoding := ReceiptsEncoding("xml")
+	if _, err := api.GetBlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(1), &encoding); err == nil {
+		t.Error("unknown encoding accepted")
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks, including the ones
+	// filling gaps between requested block numbers, a simulation may produce.
+	maxSimulateBlocks = 256
+
+	// simTimestampIncrement is the default increment between block timestamps
+	// if the caller does not override them.
+	simTimestampIncrement = 1
+)
+
+// simBlock is a batch of calls to be simulated sequentially in a single block.
+type simBlock struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides *StateOverride
+	Calls          []TransactionArgs
+
+	// Arbitrum: accounts and slots to prove against the state after the block
+	Proofs []ProofRequest
+}
+
+// simOpts are the inputs to eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls        []simBlock
+	Validation             bool
+	ReturnFullTransactions bool
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+// simChainContext resolves the headers of the simulated blocks on top of the
+// canonical chain, so that BLOCKHASH works across simulated blocks.
+type simChainContext struct {
+	*ChainContext
+	headers map[uint64]*types.Header
+}
+
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[number]; ok && header.Hash() == hash {
+		return header
+	}
+	return c.ChainContext.GetHeader(hash, number)
+}
+
+// simulator is a stateful object that simulates a series of blocks on top of
+// a base state. It is not safe for concurrent use.
+type simulator struct {
+	b        Backend
+	state    *state.StateDB
+	base     *types.Header
+	chain    *simChainContext
+	budget   uint64 // gas left for all the remaining calls
+	validate bool
+	fullTx   bool
+}
+
+// SimulateV1 executes series of transactions on top of a base state. The
+// transactions are packed into blocks, each of which may override the header
+// fields and the state it is executed on. The results of the calls, including
+// their logs, are returned along with the resulting blocks.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &clientLimitExceededError{message: "too many blocks"}
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	var cancel context.CancelFunc
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	budget := s.b.RPCGasCap()
+	if budget == 0 {
+		budget = math.MaxUint64 / 2
+	}
+	sim := &simulator{
+		b:     s.b,
+		state: statedb,
+		base:  base,
+		chain: &simChainContext{
+			ChainContext: NewChainContext(ctx, s.b),
+			headers:      make(map[uint64]*types.Header),
+		},
+		budget:   budget,
+		validate: opts.Validation,
+		fullTx:   opts.ReturnFullTransactions,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
+// execute runs the simulation of a series of blocks.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[string]interface{}, error) {
+	blocks, err := sim.sanitizeChain(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]map[string]interface{}, len(blocks))
+		parent  = sim.base
+	)
+	for bi, block := range blocks {
+		result, callResults, err := sim.processBlock(ctx, &block, parent)
+		if err != nil {
+			return nil, err
+		}
+		enc := RPCMarshalBlock(result, true, sim.fullTx, sim.b.ChainConfig())
+		enc["calls"] = callResults
+		if len(block.Proofs) > 0 {
+			proofs, err := sim.prove(block.Proofs)
+			if err != nil {
+				return nil, err
+			}
+			enc["proofs"] = proofs
+		}
+		results[bi] = enc
+
+		parent = result.Header()
+		sim.chain.headers[parent.Number.Uint64()] = parent
+	}
+	return results, nil
+}
+
+// sanitizeChain checks the requested block numbers and timestamps, filling in
+// the defaults and inserting empty blocks where the requested numbers have
+// gaps between them.
+func (sim *simulator) sanitizeChain(blocks []simBlock) ([]simBlock, error) {
+	var (
+		res        = make([]simBlock, 0, len(blocks))
+		prevNumber = new(big.Int).Set(sim.base.Number)
+		prevTime   = sim.base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(BlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			n := new(big.Int).Add(prevNumber, common.Big1)
+			block.BlockOverrides.Number = (*hexutil.Big)(n)
+		}
+		number := block.BlockOverrides.Number.ToInt()
+		if number.Cmp(prevNumber) <= 0 {
+			return nil, &invalidBlockNumberError{message: fmt.Sprintf("block numbers must be in order: %d <= %d", number, prevNumber)}
+		}
+		if total := new(big.Int).Sub(number, sim.base.Number); total.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, &clientLimitExceededError{message: "too many blocks"}
+		}
+		// Insert empty blocks to fill the gap, if any
+		for gap := new(big.Int).Sub(number, prevNumber); gap.Cmp(common.Big1) > 0; gap.Sub(gap, common.Big1) {
+			n := new(big.Int).Add(prevNumber, common.Big1)
+			t := prevTime + simTimestampIncrement
+			res = append(res, simBlock{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(n), Time: (*hexutil.Uint64)(&t)}})
+			prevNumber, prevTime = n, t
+		}
+		if block.BlockOverrides.Time == nil {
+			t := prevTime + simTimestampIncrement
+			block.BlockOverrides.Time = (*hexutil.Uint64)(&t)
+		}
+		// Arbitrum: consecutive blocks may share a timestamp
+		if t := uint64(*block.BlockOverrides.Time); t < prevTime {
+			return nil, &invalidBlockTimestampError{message: fmt.Sprintf("block timestamps must be in order: %d < %d", t, prevTime)}
+		}
+		prevNumber, prevTime = number, uint64(*block.BlockOverrides.Time)
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeader derives the header of a simulated block from its parent and the
+// requested overrides. Arbitrum specific fields stored in the extra data and
+// mix digest are inherited from the parent, keeping the ArbOS version.
+func (sim *simulator) makeHeader(parent *types.Header, overrides *BlockOverrides) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: parent.Difficulty,
+		Number:     overrides.Number.ToInt(),
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(*overrides.Time),
+		Extra:      common.CopyBytes(parent.Extra),
+		MixDigest:  parent.MixDigest,
+		BaseFee:    parent.BaseFee,
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = overrides.Difficulty.ToInt()
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.BaseFee != nil {
+		header.BaseFee = overrides.BaseFee.ToInt()
+	}
+	return header
+}
+
+// processBlock executes the calls of a single simulated block on top of the
+// simulation state, returning the resulting block and the call results.
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, parent *types.Header) (*types.Block, []simCallResult, error) {
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, nil, err
+	}
+	var (
+		header      = sim.makeHeader(parent, block.BlockOverrides)
+		config      = sim.b.ChainConfig()
+		blockCtx    = core.NewEVMBlockContext(header, sim.chain, nil)
+		gp          = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas     uint64
+		txes        = make([]*types.Transaction, len(block.Calls))
+		receipts    = make([]*types.Receipt, len(block.Calls))
+		callResults = make([]simCallResult, len(block.Calls))
+	)
+	block.BlockOverrides.Apply(&blockCtx)
+
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if err := sim.sanitizeCall(&call, header, usedGas); err != nil {
+			return nil, nil, err
+		}
+		var (
+			tx  = call.ToTransaction()
+			msg = call.ToMessage(header.BaseFee, sim.budget, header, sim.state, core.MessageEthcallMode)
+		)
+		msg.SkipAccountChecks = !sim.validate
+		txes[i] = tx
+
+		sim.state.SetTxContext(tx.Hash(), i)
+		evm := sim.b.GetEVM(ctx, msg, sim.state, header, &vm.Config{NoBaseFee: !sim.validate}, &blockCtx)
+		go func() {
+			<-ctx.Done()
+			evm.Cancel()
+		}()
+		receipt, result, err := core.ApplyTransactionWithEVM(msg, config, gp, sim.state, header.Number, common.Hash{}, tx, &usedGas, evm, nil)
+		if err := sim.state.Error(); err != nil {
+			return nil, nil, err
+		}
+		if evm.Cancelled() {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", sim.b.RPCEVMTimeout())
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("call %d in block %v: %w", i, header.Number, err)
+		}
+		if result.UsedGas > sim.budget {
+			return nil, nil, &clientLimitExceededError{message: "gas cap exceeded"}
+		}
+		sim.budget -= result.UsedGas
+		receipts[i] = receipt
+		callResults[i] = newSimCallResult(result, receipt.Logs)
+	}
+	header.GasUsed = usedGas
+	header.Root = sim.state.IntermediateRoot(config.IsEIP158(header.Number))
+
+	result := types.NewBlock(header, &types.Body{Transactions: txes}, receipts, trie.NewStackTrie(nil))
+
+	// The block hash is only known now, fill it into the logs
+	blockHash := result.Hash()
+	for _, receipt := range receipts {
+		receipt.BlockHash = blockHash
+		for _, log := range receipt.Logs {
+			log.BlockHash = blockHash
+		}
+	}
+	return result, callResults, nil
+}
+
+// prove generates the EIP-1186 proofs of the requested accounts and slots
+// against the simulation state, as hashed at the end of the last block.
+func (sim *simulator) prove(requests []ProofRequest) ([]*AccountResult, error) {
+	if len(requests) > maxProofBatchSize {
+		return nil, &clientLimitExceededError{message: fmt.Sprintf("too many accounts to prove: %d, max %d", len(requests), maxProofBatchSize)}
+	}
+	results := make([]*AccountResult, len(requests))
+	for i, req := range requests {
+		var (
+			keys       = make([]common.Hash, len(req.StorageKeys))
+			keyLengths = make([]int, len(req.StorageKeys))
+		)
+		for j, hexKey := range req.StorageKeys {
+			var err error
+			if keys[j], keyLengths[j], err = decodeHash(hexKey); err != nil {
+				return nil, &invalidParamsError{message: err.Error()}
+			}
+		}
+		result, err := proveAccount(sim.state, req.Address, keys, keyLengths)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// newSimCallResult assembles the result of a simulated call, reporting a
+// failed execution as part of the result.
+func newSimCallResult(result *core.ExecutionResult, logs []*types.Log) simCallResult {
+	callRes := simCallResult{
+		ReturnValue: result.Return(),
+		Logs:        logs,
+		GasUsed:     hexutil.Uint64(result.UsedGas),
+		Status:      hexutil.Uint64(types.ReceiptStatusSuccessful),
+	}
+	if callRes.Logs == nil {
+		callRes.Logs = []*types.Log{}
+	}
+	if result.Failed() {
+		callRes.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+		if errors.Is(result.Err, vm.ErrExecutionReverted) {
+			revertErr := newRevertError(result.Revert())
+			callRes.Error = &callError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.reason}
+		} else {
+			callRes.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+		}
+	}
+	return callRes
+}
+
+// sanitizeCall fills in the defaults of a simulated call. Unless validation is
+// requested, the nonce defaults to the sender's current one so that every
+// simulated transaction has a distinct hash.
+func (sim *simulator) sanitizeCall(call *TransactionArgs, header *types.Header, usedGas uint64) error {
+	if call.Nonce == nil {
+		nonce := sim.state.GetNonce(call.from())
+		call.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	if call.Gas == nil {
+		remaining := header.GasLimit - usedGas
+		gas := min(remaining, sim.budget)
+		call.Gas = (*hexutil.Uint64)(&gas)
+	}
+	if usedGas+uint64(*call.Gas) > header.GasLimit {
+		return &blockGasLimitReachedError{fmt.Sprintf("block gas limit reached: %d >= %d", usedGas, header.GasLimit)}
+	}
+	return call.CallDefaults(sim.budget, header.BaseFee, sim.b.ChainConfig().ChainID)
+}
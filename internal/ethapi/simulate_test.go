@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender  = common.HexToAddress("0x1111")
+		logger  = common.HexToAddress("0x2222") // emits a log and returns 42
+		number  = common.HexToAddress("0x3333") // returns the block number
+		revert  = common.HexToAddress("0x4444") // code provided by a state override
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				logger: {Code: hexutil.MustDecode("0x602a60005260206000a060206000f3")},
+				number: {Code: hexutil.MustDecode("0x4360005260206000f3")},
+			},
+		}
+		api = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+	)
+	revertCode := hexutil.Bytes(hexutil.MustDecode("0x60006000fd"))
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{
+				Calls: []TransactionArgs{{From: &sender, To: &logger}},
+			},
+			{
+				BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(4))},
+				StateOverrides: &StateOverride{revert: OverrideAccount{Code: &revertCode}},
+				Calls:          []TransactionArgs{{From: &sender, To: &number}, {From: &sender, To: &revert}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	// The gap between blocks 2 and 4 must be filled
+	if len(results) != 3 {
+		t.Fatalf("block count mismatch: have %d, want 3", len(results))
+	}
+	for i, result := range results {
+		if have, want := result["number"].(*hexutil.Big).ToInt().Uint64(), uint64(i+2); have != want {
+			t.Fatalf("block %d number mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	calls := results[0]["calls"].([]simCallResult)
+	if len(calls) != 1 || uint64(calls[0].Status) != types.ReceiptStatusSuccessful {
+		t.Fatalf("unexpected first block calls: %+v", calls)
+	}
+	if have := new(big.Int).SetBytes(calls[0].ReturnValue); have.Uint64() != 42 {
+		t.Fatalf("return value mismatch: have %v, want 42", have)
+	}
+	if len(calls[0].Logs) != 1 || calls[0].Logs[0].BlockHash != results[0]["hash"].(common.Hash) {
+		t.Fatalf("unexpected logs: %+v", calls[0].Logs)
+	}
+	calls = results[2]["calls"].([]simCallResult)
+	if len(calls) != 2 {
+		t.Fatalf("call count mismatch: have %d, want 2", len(calls))
+	}
+	if have := new(big.Int).SetBytes(calls[0].ReturnValue); have.Uint64() != 4 {
+		t.Fatalf("block number mismatch: have %v, want 4", have)
+	}
+	if uint64(calls[1].Status) != types.ReceiptStatusFailed || calls[1].Error == nil || calls[1].Error.Code != errCodeReverted {
+		t.Fatalf("expected reverted call, have %+v", calls[1])
+	}
+
+	// Block numbers must be strictly increasing
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(3))}},
+			{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(3))}},
+		},
+	}, nil)
+	var numberErr *invalidBlockNumberError
+	if !errors.As(err, &numberErr) {
+		t.Fatalf("expected invalid block number error, have %v", err)
+	}
+}
+
+func TestSimulateV1Proofs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender    = common.HexToAddress("0x1111")
+		recipient = common.HexToAddress("0x2222")
+		contract  = common.HexToAddress("0x3333")
+		slot      = common.HexToHash("0x01")
+		genesis   = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: []byte{0x00}, Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0xaa")}},
+			},
+		}
+		api = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+	)
+	value := (*hexutil.Big)(big.NewInt(1000))
+	diff := map[common.Hash]common.Hash{slot: common.HexToHash("0xbb")}
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{
+			StateOverrides: &StateOverride{contract: OverrideAccount{StateDiff: &diff}},
+			Calls:          []TransactionArgs{{From: &sender, To: &recipient, Value: value}},
+			Proofs:         []ProofRequest{{Address: recipient}, {Address: contract, StorageKeys: []string{"0x01"}}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	var (
+		root   = results[0]["stateRoot"].(common.Hash)
+		proofs = results[0]["proofs"].([]*AccountResult)
+	)
+	verify := func(root common.Hash, key []byte, nodes []string) []byte {
+		t.Helper()
+		proofDb := memorydb.New()
+		for _, node := range nodes {
+			blob := hexutil.MustDecode(node)
+			proofDb.Put(crypto.Keccak256(blob), blob)
+		}
+		value, err := trie.VerifyProof(root, key, proofDb)
+		if err != nil {
+			t.Fatalf("invalid proof: %v", err)
+		}
+		return value
+	}
+	// The recipient only exists in the simulated state
+	if proofs[0].Balance.ToInt().Cmp(value.ToInt()) != 0 {
+		t.Fatalf("recipient balance mismatch: have %v, want %v", proofs[0].Balance, value)
+	}
+	if verify(root, crypto.Keccak256(recipient.Bytes()), proofs[0].AccountProof) == nil {
+		t.Fatal("recipient not proven")
+	}
+	// The overridden slot must be proven against the simulated storage root
+	verify(root, crypto.Keccak256(contract.Bytes()), proofs[1].AccountProof)
+	enc := verify(proofs[1].StorageHash, crypto.Keccak256(slot.Bytes()), proofs[1].StorageProof[0].Proof)
+	var stored []byte
+	if err := rlp.DecodeBytes(enc, &stored); err != nil {
+		t.Fatalf("invalid slot encoding: %v", err)
+	}
+	if have := common.BytesToHash(stored); have != common.HexToHash("0xbb") {
+		t.Fatalf("proven slot mismatch: have %x, want 0xbb", have)
+	}
+
+	// Invalid keys are rejected
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{Proofs: []ProofRequest{{Address: contract, StorageKeys: []string{"0xzz"}}}}},
+	}, nil)
+	var paramsErr *invalidParamsError
+	if !errors.As(err, &paramsErr) {
+		t.Fatalf("expected invalid params error, have %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// stateAtTransaction returns the state right before the execution of the given
+// transaction, materialized by replaying its block up to the transaction.
+func stateAtTransaction(ctx context.Context, b Backend, txHash common.Hash) (*state.StateDB, *types.Header, error) {
+	found, _, blockHash, _, index, err := b.GetTransaction(ctx, txHash)
+	if err != nil {
+		return nil, nil, NewTxIndexingError()
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("transaction %#x not found", txHash)
+	}
+	block, err := b.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if block == nil {
+		return nil, nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	statedb, err := b.StateAtTransactionIndex(ctx, block, int(index))
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, block.Header(), nil
+}
+
+// GetBalanceAtTransaction returns the balance of the given account right before
+// the execution of the given transaction.
+func (api *DebugAPI) GetBalanceAtTransaction(ctx context.Context, txHash common.Hash, address common.Address) (*hexutil.Big, error) {
+	statedb, _, err := stateAtTransaction(ctx, api.b, txHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(statedb.GetBalance(address).ToBig()), statedb.Error()
+}
+
+// GetStorageAtTransaction returns the value of the given storage slot right
+// before the execution of the given transaction.
+func (api *DebugAPI) GetStorageAtTransaction(ctx context.Context, txHash common.Hash, address common.Address, hexKey string) (hexutil.Bytes, error) {
+	key, _, err := decodeHash(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode storage key: %s", err)
+	}
+	statedb, _, err := stateAtTransaction(ctx, api.b, txHash)
+	if err != nil {
+		return nil, err
+	}
+	res := statedb.GetState(address, key)
+	return res[:], statedb.Error()
+}
+
+// GetProofAtTransaction returns the Merkle-proof of the given account, and
+// optionally some storage keys, right before the execution of the given
+// transaction. The proofs are against the intermediate state root, reported
+// along with them.
+func (api *DebugAPI) GetProofAtTransaction(ctx context.Context, txHash common.Hash, address common.Address, storageKeys []string) (*AccountResult, error) {
+	var (
+		keys       = make([]common.Hash, len(storageKeys))
+		keyLengths = make([]int, len(storageKeys))
+	)
+	// Deserialize all keys. This prevents state access on invalid input.
+	for i, hexKey := range storageKeys {
+		var err error
+		keys[i], keyLengths[i], err = decodeHash(hexKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	statedb, header, err := stateAtTransaction(ctx, api.b, txHash)
+	if err != nil {
+		return nil, err
+	}
+	root := statedb.IntermediateRoot(api.b.ChainConfig().IsEIP158(header.Number))
+
+	result, err := proveAccount(statedb, address, keys, keyLengths)
+	if err != nil {
+		return nil, err
+	}
+	result.StateRoot = &root
+	return result, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestStateAtTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0x1111")
+		contract  = common.HexToAddress("0x2222")
+		signer    = types.LatestSigner(params.TestChainConfig)
+		// PUSH1 0, CALLDATALOAD, PUSH1 32, CALLDATALOAD, SSTORE, STOP
+		storer  = []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.SSTORE), byte(vm.STOP)}
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: storer},
+			},
+		}
+		txs []common.Hash
+	)
+	backend := newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {
+		data := append(common.HexToHash("0x05").Bytes(), common.HexToHash("0x01").Bytes()...)
+		store, _ := types.SignTx(types.NewTransaction(0, contract, nil, 100000, b.BaseFee(), data), signer, key)
+		b.AddTx(store)
+		transfer, _ := types.SignTx(types.NewTransaction(1, recipient, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(transfer)
+		txs = append(txs, store.Hash(), transfer.Hash())
+	})
+	api := NewDebugAPI(backend)
+
+	// The state before the store doesn't have the value, the one before the
+	// transfer does
+	value, err := api.GetStorageAtTransaction(context.Background(), txs[0], contract, "0x01")
+	if err != nil {
+		t.Fatalf("failed to get storage: %v", err)
+	}
+	if common.BytesToHash(value) != (common.Hash{}) {
+		t.Fatalf("storage before the store mismatch: have %x, want zero", value)
+	}
+	value, err = api.GetStorageAtTransaction(context.Background(), txs[1], contract, "0x01")
+	if err != nil {
+		t.Fatalf("failed to get storage: %v", err)
+	}
+	if have, want := common.BytesToHash(value), common.HexToHash("0x05"); have != want {
+		t.Fatalf("storage before the transfer mismatch: have %x, want %x", have, want)
+	}
+	balance, err := api.GetBalanceAtTransaction(context.Background(), txs[1], recipient)
+	if err != nil {
+		t.Fatalf("failed to get balance: %v", err)
+	}
+	if balance.ToInt().Sign() != 0 {
+		t.Fatalf("balance before the transfer mismatch: have %v, want 0", balance)
+	}
+	// The proofs are against the intermediate root
+	proof, err := api.GetProofAtTransaction(context.Background(), txs[1], contract, []string{"0x01"})
+	if err != nil {
+		t.Fatalf("failed to get proof: %v", err)
+	}
+	if proof.StateRoot == nil || *proof.StateRoot == backend.chain.CurrentBlock().Root {
+		t.Fatalf("proof root mismatch: have %v", proof.StateRoot)
+	}
+	if len(proof.StorageProof) != 1 || proof.StorageProof[0].Value.ToInt().Int64() != 5 || len(proof.StorageProof[0].Proof) == 0 {
+		t.Fatalf("storage proof mismatch: have %+v", proof.StorageProof)
+	}
+	if _, err := api.GetBalanceAtTransaction(context.Background(), common.Hash{1}, recipient); err == nil {
+		t.Fatal("state of an unknown transaction resolved")
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockStateDiff is the set of account-level changes applied by a block.
+type BlockStateDiff struct {
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	ParentRoot  common.Hash    `json:"parentRoot"`
+
+	// Arbitrum: total balance change across all accounts not explained by
+	// deposits, withdrawals or burns
+	UnexpectedBalanceDelta *hexutil.Big `json:"unexpectedBalanceDelta"`
+
+	Accounts map[common.Address]*AccountDiff `json:"accounts"`
+}
+
+// AccountDiff holds the changes applied to a single account. Fields of the
+// account which were not changed are omitted.
+type AccountDiff struct {
+	Created bool `json:"created,omitempty"`
+	Deleted bool `json:"deleted,omitempty"`
+
+	Balance *BalanceDiff                 `json:"balance,omitempty"`
+	Nonce   *NonceDiff                   `json:"nonce,omitempty"`
+	Code    *CodeDiff                    `json:"code,omitempty"`
+	Storage map[common.Hash]*StorageDiff `json:"storage,omitempty"`
+
+	// Individual balance changes in execution order, both as reported by the
+	// state (with geth's reasons) and as reported by ArbOS (with its purposes)
+	BalanceChanges    []BalanceChange `json:"balanceChanges,omitempty"`
+	ArbitrumTransfers []BalanceChange `json:"arbitrumTransfers,omitempty"`
+}
+
+// BalanceDiff is the balance of an account before and after the block.
+type BalanceDiff struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+// NonceDiff is the nonce of an account before and after the block.
+type NonceDiff struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+// CodeDiff is the code hash of an account before and after the block, along
+// with the new code.
+type CodeDiff struct {
+	From common.Hash   `json:"from"`
+	To   common.Hash   `json:"to"`
+	Code hexutil.Bytes `json:"code"`
+}
+
+// StorageDiff is the value of a storage slot before and after the block.
+type StorageDiff struct {
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// BalanceChange is a single signed balance change and the reason behind it.
+type BalanceChange struct {
+	Reason string       `json:"reason"`
+	Delta  *hexutil.Big `json:"delta"`
+}
+
+// stateDiffRecorder tracks the accounts and slots touched while executing a
+// block, along with the reasons of all balance changes.
+type stateDiffRecorder struct {
+	touched           map[common.Address]map[common.Hash]struct{}
+	balanceChanges    map[common.Address][]BalanceChange
+	arbitrumTransfers map[common.Address][]BalanceChange
+}
+
+func newStateDiffRecorder() *stateDiffRecorder {
+	return &stateDiffRecorder{
+		touched:           make(map[common.Address]map[common.Hash]struct{}),
+		balanceChanges:    make(map[common.Address][]BalanceChange),
+		arbitrumTransfers: make(map[common.Address][]BalanceChange),
+	}
+}
+
+func (r *stateDiffRecorder) hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnBalanceChange: func(addr common.Address, prev, next *big.Int, reason tracing.BalanceChangeReason) {
+			r.touch(addr)
+			r.balanceChanges[addr] = append(r.balanceChanges[addr], BalanceChange{
+				Reason: reason.String(),
+				Delta:  (*hexutil.Big)(new(big.Int).Sub(next, prev)),
+			})
+		},
+		OnNonceChange: func(addr common.Address, prev, next uint64) {
+			r.touch(addr)
+		},
+		OnCodeChange: func(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+			r.touch(addr)
+		},
+		OnStorageChange: func(addr common.Address, slot common.Hash, prev, next common.Hash) {
+			r.touch(addr)[slot] = struct{}{}
+		},
+		CaptureArbitrumTransfer: func(from, to *common.Address, value *big.Int, before bool, purpose string) {
+			if value.Sign() == 0 {
+				return
+			}
+			if from != nil {
+				r.touch(*from)
+				r.arbitrumTransfers[*from] = append(r.arbitrumTransfers[*from], BalanceChange{
+					Reason: purpose,
+					Delta:  (*hexutil.Big)(new(big.Int).Neg(value)),
+				})
+			}
+			if to != nil {
+				r.touch(*to)
+				r.arbitrumTransfers[*to] = append(r.arbitrumTransfers[*to], BalanceChange{
+					Reason: purpose,
+					Delta:  (*hexutil.Big)(new(big.Int).Set(value)),
+				})
+			}
+		},
+	}
+}
+
+func (r *stateDiffRecorder) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := r.touched[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		r.touched[addr] = slots
+	}
+	return slots
+}
+
+// diff compares the touched accounts between the pre and the post state,
+// returning the accounts which actually changed.
+func (r *stateDiffRecorder) diff(pre, post *state.StateDB) map[common.Address]*AccountDiff {
+	accounts := make(map[common.Address]*AccountDiff)
+	for addr, slots := range r.touched {
+		var (
+			diff      = new(AccountDiff)
+			changed   bool
+			preExist  = pre.Exist(addr)
+			postExist = post.Exist(addr)
+		)
+		if !preExist && !postExist {
+			continue
+		}
+		diff.Created = !preExist && postExist
+		diff.Deleted = preExist && !postExist
+
+		if from, to := pre.GetBalance(addr), post.GetBalance(addr); !from.Eq(to) {
+			diff.Balance = &BalanceDiff{From: (*hexutil.Big)(from.ToBig()), To: (*hexutil.Big)(to.ToBig())}
+			changed = true
+		}
+		if from, to := pre.GetNonce(addr), post.GetNonce(addr); from != to {
+			diff.Nonce = &NonceDiff{From: hexutil.Uint64(from), To: hexutil.Uint64(to)}
+			changed = true
+		}
+		if from, to := codeHash(pre, addr), codeHash(post, addr); from != to {
+			diff.Code = &CodeDiff{From: from, To: to, Code: post.GetCode(addr)}
+			changed = true
+		}
+		for slot := range slots {
+			if from, to := pre.GetState(addr, slot), post.GetState(addr, slot); from != to {
+				if diff.Storage == nil {
+					diff.Storage = make(map[common.Hash]*StorageDiff)
+				}
+				diff.Storage[slot] = &StorageDiff{From: from, To: to}
+				changed = true
+			}
+		}
+		if !changed && !diff.Created && !diff.Deleted {
+			continue
+		}
+		diff.BalanceChanges = r.balanceChanges[addr]
+		diff.ArbitrumTransfers = r.arbitrumTransfers[addr]
+		accounts[addr] = diff
+	}
+	return accounts
+}
+
+// codeHash returns the code hash of an account, the empty code hash if the
+// account does not exist.
+func codeHash(statedb *state.StateDB, addr common.Address) common.Hash {
+	if hash := statedb.GetCodeHash(addr); hash != (common.Hash{}) {
+		return hash
+	}
+	return types.EmptyCodeHash
+}
+
+// StateDiff re-executes the given block on top of its parent state and returns
+// the per-account balance, nonce, code and storage changes it applied.
+//
+// Note, rewards credited by the consensus engine when finalizing the block are
+// not part of the diff; Arbitrum has none.
+func (api *DebugAPI) StateDiff(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockStateDiff, error) {
+	block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis block has no parent state")
+	}
+	statedb, parent, err := api.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(block.ParentHash(), false))
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	var (
+		pre       = statedb.Copy()
+		recorder  = newStateDiffRecorder()
+		hooks     = recorder.hooks()
+		header    = block.Header()
+		config    = api.b.ChainConfig()
+		signer    = types.MakeSigner(config, block.Number(), block.Time())
+		blockCtx  = core.NewEVMBlockContext(header, NewChainContext(ctx, api.b), nil)
+		vmenv     = vm.NewEVM(blockCtx, vm.TxContext{}, statedb, config, vm.Config{Tracer: hooks})
+		gp        = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas   uint64
+		blockHash = block.Hash()
+	)
+	statedb.SetLogger(hooks)
+	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
+		core.ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
+	}
+	for i, tx := range block.Transactions() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		msg, err := core.TransactionToMessage(tx, signer, block.BaseFee(), core.MessageReplayMode)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+		if _, _, err := core.ApplyTransactionWithEVM(msg, config, gp, statedb, block.Number(), blockHash, tx, &usedGas, vmenv, nil); err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+	}
+	return &BlockStateDiff{
+		BlockHash:              blockHash,
+		BlockNumber:            hexutil.Uint64(block.NumberU64()),
+		ParentRoot:             parent.Root,
+		UnexpectedBalanceDelta: (*hexutil.Big)(statedb.GetUnexpectedBalanceDelta()),
+		Accounts:               recorder.diff(pre, statedb),
+	}, nil
+}
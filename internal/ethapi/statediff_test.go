@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestStateDiff(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _   = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		receiver = common.HexToAddress("0x1111")
+		contract = common.HexToAddress("0x2222")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// PUSH1 1 PUSH1 0 SSTORE
+				contract: {Balance: common.Big0, Code: common.FromHex("0x6001600055")},
+			},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	backend := newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &receiver, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, key)
+		b.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 1, To: &contract, Gas: 50000, GasPrice: b.BaseFee()}), signer, key)
+		b.AddTx(tx)
+	})
+	api := NewDebugAPI(backend)
+
+	if _, err := api.StateDiff(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Fatal("expected error for genesis block")
+	}
+	diff, err := api.StateDiff(context.Background(), rpc.BlockNumberOrHashWithNumber(1))
+	if err != nil {
+		t.Fatalf("failed to compute state diff: %v", err)
+	}
+	if diff.BlockNumber != 1 {
+		t.Fatalf("block number mismatch: have %d, want 1", diff.BlockNumber)
+	}
+	// The receiver was created by the transfer
+	if acc := diff.Accounts[receiver]; acc == nil || !acc.Created || acc.Balance == nil || acc.Balance.To.ToInt().Int64() != 1000 {
+		t.Fatalf("receiver diff mismatch: %+v", acc)
+	} else if len(acc.BalanceChanges) != 1 || acc.BalanceChanges[0].Reason != "Transfer" {
+		t.Fatalf("receiver balance changes mismatch: %+v", acc.BalanceChanges)
+	}
+	// The sender paid for both transactions
+	if acc := diff.Accounts[sender]; acc == nil || acc.Nonce == nil || acc.Nonce.From != 0 || acc.Nonce.To != 2 {
+		t.Fatalf("sender diff mismatch: %+v", acc)
+	}
+	// The contract wrote its slot
+	acc := diff.Accounts[contract]
+	if acc == nil || acc.Balance != nil || acc.Code != nil {
+		t.Fatalf("contract diff mismatch: %+v", acc)
+	}
+	if slot := acc.Storage[common.Hash{}]; slot == nil || slot.From != (common.Hash{}) || slot.To != common.BigToHash(common.Big1) {
+		t.Fatalf("contract storage diff mismatch: %+v", acc.Storage)
+	}
+}
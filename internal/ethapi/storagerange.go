@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// defaultStorageRangeChunkSize is the number of slots streamed in a single
+	// storage range notification if the subscriber does not specify one.
+	defaultStorageRangeChunkSize = 256
+
+	// maxStorageRangeChunkSize is the maximum number of slots streamed in a
+	// single storage range notification.
+	maxStorageRangeChunkSize = 4096
+)
+
+// StorageRangeOptions configures a storage range subscription.
+type StorageRangeOptions struct {
+	Start     *common.Hash    `json:"start"`     // hashed slot key to start at (inclusive)
+	ChunkSize *hexutil.Uint64 `json:"chunkSize"` // number of slots per notification
+	Proofs    bool            `json:"proofs"`    // whether to attach a Merkle proof to every slot
+}
+
+// StorageRangeEntry is a single storage slot streamed to the subscriber.
+type StorageRangeEntry struct {
+	Hash  common.Hash  `json:"hash"`            // hashed slot key
+	Key   *common.Hash `json:"key"`             // slot key preimage, nil if unknown
+	Value common.Hash  `json:"value"`           // slot value
+	Proof []string     `json:"proof,omitempty"` // Merkle proof of the slot against the storage root
+}
+
+// StorageRangeChunk is a single storage range notification. The last chunk of
+// the stream has no next key; if the stream was aborted, it carries the error.
+type StorageRangeChunk struct {
+	StorageHash common.Hash         `json:"storageHash"`
+	Entries     []StorageRangeEntry `json:"entries"`
+	NextKey     *common.Hash        `json:"nextKey"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// StorageRange creates a subscription streaming the whole storage of an
+// account at the given block in chunks, ordered by hashed slot key. It is the
+// streaming counterpart of debug_storageRangeAt, feasible for contracts whose
+// storage is too large to be retrieved in a single call.
+func (s *BlockChainAPI) StorageRange(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash, options *StorageRangeOptions) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if options == nil {
+		options = new(StorageRangeOptions)
+	}
+	chunkSize := defaultStorageRangeChunkSize
+	if options.ChunkSize != nil {
+		if *options.ChunkSize == 0 {
+			return nil, errors.New("chunk size must be positive")
+		}
+		chunkSize = int(min(uint64(*options.ChunkSize), maxStorageRangeChunkSize))
+	}
+	var start common.Hash
+	if options.Start != nil {
+		start = *options.Start
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	storageRoot := statedb.GetStorageRoot(address)
+
+	var tr *trie.StateTrie
+	if storageRoot != types.EmptyRootHash && storageRoot != (common.Hash{}) {
+		id := trie.StorageTrieID(header.Root, crypto.Keccak256Hash(address.Bytes()), storageRoot)
+		if tr, err = trie.NewStateTrie(id, statedb.Database().TrieDB()); err != nil {
+			return nil, err
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		// Keep the state referenced until the stream is done, it may pin the
+		// trie nodes being iterated
+		defer runtime.KeepAlive(statedb)
+
+		next := &start
+		for next != nil {
+			select {
+			case <-rpcSub.Err():
+				return
+			default:
+			}
+			chunk := &StorageRangeChunk{StorageHash: storageRoot, Entries: []StorageRangeEntry{}}
+			if tr != nil {
+				entries, nextKey, err := storageRangeChunk(tr, *next, chunkSize, options.Proofs)
+				if err != nil {
+					log.Debug("Storage range stream aborted", "address", address, "err", err)
+					chunk.Error = err.Error()
+				} else {
+					chunk.Entries, chunk.NextKey = entries, nextKey
+				}
+			}
+			if err := notifier.Notify(rpcSub.ID, chunk); err != nil {
+				return
+			}
+			next = chunk.NextKey
+		}
+	}()
+	return rpcSub, nil
+}
+
+// storageRangeChunk retrieves up to count slots of a storage trie starting at
+// the given hashed key, returning the hashed key of the following slot if any.
+func storageRangeChunk(tr *trie.StateTrie, start common.Hash, count int, proofs bool) ([]StorageRangeEntry, *common.Hash, error) {
+	nodeIt, err := tr.NodeIterator(start[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		it      = trie.NewIterator(nodeIt)
+		entries = make([]StorageRangeEntry, 0, count)
+	)
+	for len(entries) < count && it.Next() {
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		entry := StorageRangeEntry{
+			Hash:  common.BytesToHash(it.Key),
+			Value: common.BytesToHash(content),
+		}
+		if preimage := tr.GetKey(it.Key); preimage != nil {
+			key := common.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		if proofs {
+			var proof proofList
+			if err := tr.Prove(it.Key, &proof); err != nil {
+				return nil, nil, err
+			}
+			entry.Proof = proof
+		}
+		entries = append(entries, entry)
+	}
+	if it.Err != nil {
+		return nil, nil, it.Err
+	}
+	if !it.Next() {
+		return entries, nil, it.Err
+	}
+	next := common.BytesToHash(it.Key)
+	return entries, &next, nil
+}
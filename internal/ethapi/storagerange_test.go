@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestStorageRangeChunk(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db    = state.NewDatabase(rawdb.NewMemoryDatabase())
+		addr  = common.HexToAddress("0x1")
+		slots = 100
+	)
+	want := make(map[common.Hash]common.Hash)
+	statedb, _ := state.New(types.EmptyRootHash, db, nil)
+	for i := 1; i <= slots; i++ {
+		key, value := common.BigToHash(big.NewInt(int64(i))), common.BytesToHash([]byte{byte(i)})
+		statedb.SetState(addr, key, value)
+		want[crypto.Keccak256Hash(key.Bytes())] = value
+	}
+	root, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	statedb, _ = state.New(root, db, nil)
+	storageRoot := statedb.GetStorageRoot(addr)
+
+	tr, err := trie.NewStateTrie(trie.StorageTrieID(root, crypto.Keccak256Hash(addr.Bytes()), storageRoot), db.TrieDB())
+	if err != nil {
+		t.Fatalf("failed to open storage trie: %v", err)
+	}
+	var (
+		next   = &common.Hash{}
+		seen   = 0
+		chunks = 0
+		last   common.Hash
+	)
+	for next != nil {
+		var entries []StorageRangeEntry
+		entries, next, err = storageRangeChunk(tr, *next, 30, true)
+		if err != nil {
+			t.Fatalf("failed to retrieve chunk: %v", err)
+		}
+		for _, entry := range entries {
+			if seen > 0 && entry.Hash.Cmp(last) <= 0 {
+				t.Fatalf("entries out of order: %v after %v", entry.Hash, last)
+			}
+			last = entry.Hash
+
+			proof := memorydb.New()
+			for _, node := range entry.Proof {
+				blob := hexutil.MustDecode(node)
+				proof.Put(crypto.Keccak256(blob), blob)
+			}
+			if _, err := trie.VerifyProof(storageRoot, entry.Hash[:], proof); err != nil {
+				t.Fatalf("invalid proof for slot %v: %v", entry.Hash, err)
+			}
+			if want[entry.Hash] != entry.Value {
+				t.Fatalf("slot %v value mismatch: have %v, want %v", entry.Hash, entry.Value, want[entry.Hash])
+			}
+			seen++
+		}
+		chunks++
+	}
+	if seen != slots {
+		t.Fatalf("slot count mismatch: have %d, want %d", seen, slots)
+	}
+	if chunks != 4 {
+		t.Fatalf("chunk count mismatch: have %d, want 4", chunks)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// maxStorageRangeProofLimit is the maximum number of slots proven by a single
+// eth_getStorageRangeProof call.
+const maxStorageRangeProofLimit = 4096
+
+// StorageRangeProofResult is a contiguous range of the storage slots of an
+// account, ordered by hashed slot key, with a single proof of the whole range.
+type StorageRangeProofResult struct {
+	Address      common.Address      `json:"address"`
+	AccountProof []string            `json:"accountProof"`
+	StorageHash  common.Hash         `json:"storageHash"`
+	Entries      []StorageRangeEntry `json:"entries"`
+	Proof        []string            `json:"proof"`   // Edge proofs of the range, deduplicated
+	NextKey      *common.Hash        `json:"nextKey"` // Hashed slot key following the range, nil if none
+
+	// Arbitrum: root the proofs are against, if it differs from the block's,
+	// e.g. for the pending state
+	StateRoot *common.Hash `json:"stateRoot,omitempty"`
+}
+
+// GetStorageRangeProof returns up to limit storage slots of an account from the
+// given hashed slot key onwards, along with the Merkle-proof of the account and
+// a single compact proof of the range: the union of the proofs of the start key
+// and of the last slot. The range verifies as a trie range proof starting at
+// the start key, with the RLP encoding of the trimmed slot values as the leaf
+// values, against the storage hash. It is meant for exporting large mappings
+// with far fewer proof nodes than proving every slot with eth_getProof.
+func (s *BlockChainAPI) GetStorageRangeProof(ctx context.Context, address common.Address, startKey common.Hash, limit hexutil.Uint64, blockNrOrHash rpc.BlockNumberOrHash) (*StorageRangeProofResult, error) {
+	if limit == 0 || limit > maxStorageRangeProofLimit {
+		return nil, fmt.Errorf("invalid limit: %d, must be within 1 and %d", limit, maxStorageRangeProofLimit)
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	// The pending state may carry changes not committed to the tries yet, hash
+	// them in to prove against the resulting root.
+	root := statedb.IntermediateRoot(s.b.ChainConfig().IsEIP158(header.Number))
+
+	account, err := proveAccount(statedb, address, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	proof := trienode.NewProofSet()
+	storage, err := statedb.ProveStorageRange(address, startKey, int(limit), proof)
+	if err != nil {
+		return nil, err
+	}
+	result := &StorageRangeProofResult{
+		Address:      address,
+		AccountProof: account.AccountProof,
+		StorageHash:  account.StorageHash,
+		Entries:      make([]StorageRangeEntry, len(storage.Hashes)),
+		Proof:        make([]string, 0, proof.KeyCount()),
+		NextKey:      storage.Next,
+	}
+	for i, hash := range storage.Hashes {
+		_, content, _, err := rlp.Split(storage.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		result.Entries[i] = StorageRangeEntry{Hash: hash, Key: storage.Keys[i], Value: common.BytesToHash(content)}
+	}
+	for _, node := range proof.List() {
+		result.Proof = append(result.Proof, hexutil.Encode(node))
+	}
+	if root != header.Root {
+		result.StateRoot = &root
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+func TestGetStorageRangeProof(t *testing.T) {
+	t.Parallel()
+
+	var (
+		contract = common.HexToAddress("0x2222")
+		storage  = make(map[common.Hash]common.Hash)
+		hashed   = make(map[common.Hash]common.Hash)
+	)
+	for i := 0; i < 50; i++ {
+		key, value := common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+1)))
+		storage[key], hashed[crypto.Keccak256Hash(key[:])] = value, value
+	}
+	var (
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				contract: {Balance: common.Big1, Code: []byte{0x00}, Storage: storage},
+			},
+		}
+		api    = NewBlockChainAPI(newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	var (
+		start common.Hash
+		seen  int
+	)
+	for {
+		result, err := api.GetStorageRangeProof(context.Background(), contract, start, 20, latest)
+		if err != nil {
+			t.Fatalf("failed to get storage range proof: %v", err)
+		}
+		proof := trienode.NewProofSet()
+		for _, node := range result.Proof {
+			blob := hexutil.MustDecode(node)
+			proof.Put(crypto.Keccak256(blob), blob)
+		}
+		var (
+			keys   = make([][]byte, len(result.Entries))
+			values = make([][]byte, len(result.Entries))
+		)
+		for i, entry := range result.Entries {
+			if hashed[entry.Hash] != entry.Value {
+				t.Fatalf("slot %v value mismatch: have %v, want %v", entry.Hash, entry.Value, hashed[entry.Hash])
+			}
+			keys[i] = common.CopyBytes(entry.Hash[:])
+			values[i], _ = rlp.EncodeToBytes(common.TrimLeftZeroes(entry.Value[:]))
+		}
+		more, err := trie.VerifyRangeProof(result.StorageHash, start[:], keys, values, proof)
+		if err != nil {
+			t.Fatalf("failed to verify range from %v: %v", start, err)
+		}
+		if more != (result.NextKey != nil) {
+			t.Fatalf("continuation mismatch: more %v, next %v", more, result.NextKey)
+		}
+		seen += len(result.Entries)
+		if result.NextKey == nil {
+			break
+		}
+		start = *result.NextKey
+	}
+	if seen != len(storage) {
+		t.Fatalf("slot count mismatch: have %d, want %d", seen, len(storage))
+	}
+	if _, err := api.GetStorageRangeProof(context.Background(), contract, common.Hash{}, 0, latest); err == nil {
+		t.Fatal("expected error for zero limit")
+	}
+}
@@ -363,6 +363,9 @@ func (b *backendMock) StateAndHeaderByNumber(ctx context.Context, number rpc.Blo
 func (b *backendMock) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
 	return nil, nil, nil
 }
+func (b *backendMock) StateAtTransactionIndex(ctx context.Context, block *types.Block, txIndex int) (*state.StateDB, error) {
+	return nil, nil
+}
 func (b *backendMock) Pending() (*types.Block, types.Receipts, *state.StateDB) { return nil, nil, nil }
 func (b *backendMock) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	return nil, nil
@@ -404,6 +407,12 @@ func (b *backendMock) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 func (b *backendMock) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return nil
 }
+func (b *backendMock) SubscribeStateChangesEvent(ch chan<- core.StateChangesEvent) event.Subscription {
+	return nil
+}
+func (b *backendMock) SubscribeAccountLifecycleEvent(ch chan<- core.AccountLifecycleEvent) event.Subscription {
+	return nil
+}
 
 func (b *backendMock) Engine() consensus.Engine { return nil }
 
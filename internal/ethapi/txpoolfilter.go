@@ -0,0 +1,127 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxTxPoolFilterAddresses bounds the number of senders or recipients a
+// txpool_contentFiltered request may list.
+const maxTxPoolFilterAddresses = 1024
+
+// TxPoolFilter selects transaction pool entries. Empty address sets and unset
+// bounds match every transaction. Fee bounds apply to the fee cap, which is the
+// gas price for legacy transactions.
+type TxPoolFilter struct {
+	From     []common.Address `json:"from"`
+	To       []common.Address `json:"to"`
+	MinNonce *hexutil.Uint64  `json:"minNonce"`
+	MaxNonce *hexutil.Uint64  `json:"maxNonce"`
+	MinFee   *hexutil.Big     `json:"minFee"`
+	MaxFee   *hexutil.Big     `json:"maxFee"`
+}
+
+func (f *TxPoolFilter) validate() error {
+	if len(f.From) > maxTxPoolFilterAddresses || len(f.To) > maxTxPoolFilterAddresses {
+		return fmt.Errorf("too many addresses in filter, max %d", maxTxPoolFilterAddresses)
+	}
+	if f.MinNonce != nil && f.MaxNonce != nil && *f.MinNonce > *f.MaxNonce {
+		return errors.New("minNonce is greater than maxNonce")
+	}
+	if f.MinFee != nil && f.MaxFee != nil && f.MinFee.ToInt().Cmp(f.MaxFee.ToInt()) > 0 {
+		return errors.New("minFee is greater than maxFee")
+	}
+	return nil
+}
+
+// matches checks the per-transaction criteria of the filter. The senders are
+// matched when collecting the pool content.
+func (f *TxPoolFilter) matches(tx *types.Transaction, recipients map[common.Address]struct{}) bool {
+	if len(recipients) > 0 {
+		if tx.To() == nil {
+			return false
+		}
+		if _, ok := recipients[*tx.To()]; !ok {
+			return false
+		}
+	}
+	if f.MinNonce != nil && tx.Nonce() < uint64(*f.MinNonce) {
+		return false
+	}
+	if f.MaxNonce != nil && tx.Nonce() > uint64(*f.MaxNonce) {
+		return false
+	}
+	if f.MinFee != nil && tx.GasFeeCapIntCmp(f.MinFee.ToInt()) < 0 {
+		return false
+	}
+	if f.MaxFee != nil && tx.GasFeeCapIntCmp(f.MaxFee.ToInt()) > 0 {
+		return false
+	}
+	return true
+}
+
+// ContentFiltered returns the pending and queued transactions of the pool
+// matching the given filter. If senders are given, only their transactions are
+// retrieved from the pool instead of the whole content.
+func (s *TxPoolAPI) ContentFiltered(filter TxPoolFilter) (map[string]map[string]map[string]*RPCTransaction, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	var pending, queue map[common.Address][]*types.Transaction
+	if len(filter.From) > 0 {
+		pending = make(map[common.Address][]*types.Transaction, len(filter.From))
+		queue = make(map[common.Address][]*types.Transaction, len(filter.From))
+		for _, addr := range filter.From {
+			pending[addr], queue[addr] = s.b.TxPoolContentFrom(addr)
+		}
+	} else {
+		pending, queue = s.b.TxPoolContent()
+	}
+	recipients := make(map[common.Address]struct{}, len(filter.To))
+	for _, addr := range filter.To {
+		recipients[addr] = struct{}{}
+	}
+	content := map[string]map[string]map[string]*RPCTransaction{
+		"pending": make(map[string]map[string]*RPCTransaction),
+		"queued":  make(map[string]map[string]*RPCTransaction),
+	}
+	curHeader := s.b.CurrentHeader()
+	for kind, txsets := range map[string]map[common.Address][]*types.Transaction{"pending": pending, "queued": queue} {
+		for account, txs := range txsets {
+			var dump map[string]*RPCTransaction
+			for _, tx := range txs {
+				if !filter.matches(tx, recipients) {
+					continue
+				}
+				if dump == nil {
+					dump = make(map[string]*RPCTransaction)
+				}
+				dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			}
+			if dump != nil {
+				content[kind][account.Hex()] = dump
+			}
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txPoolBackendMock serves a fixed transaction pool content.
+type txPoolBackendMock struct {
+	*backendMock
+	pending map[common.Address][]*types.Transaction
+	queued  map[common.Address][]*types.Transaction
+}
+
+func (b *txPoolBackendMock) TxPoolContent() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	return b.pending, b.queued
+}
+
+func (b *txPoolBackendMock) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
+	return b.pending[addr], b.queued[addr]
+}
+
+func TestTxPoolContentFiltered(t *testing.T) {
+	t.Parallel()
+
+	var (
+		alice = common.HexToAddress("0xa")
+		bob   = common.HexToAddress("0xb")
+		carol = common.HexToAddress("0xc")
+	)
+	newTx := func(nonce uint64, to *common.Address, price int64) *types.Transaction {
+		return types.NewTx(&types.LegacyTx{Nonce: nonce, To: to, GasPrice: big.NewInt(price), Gas: 21000, V: big.NewInt(0), R: big.NewInt(0), S: big.NewInt(0)})
+	}
+	api := NewTxPoolAPI(&txPoolBackendMock{
+		backendMock: newBackendMock(),
+		pending: map[common.Address][]*types.Transaction{
+			alice: {newTx(0, &bob, 10), newTx(1, &carol, 20), newTx(2, nil, 30)},
+			bob:   {newTx(0, &alice, 40)},
+		},
+		queued: map[common.Address][]*types.Transaction{
+			alice: {newTx(5, &bob, 50)},
+		},
+	})
+	u64 := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+	fee := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+
+	tests := []struct {
+		filter TxPoolFilter
+		want   map[string][]string // kind -> sender:nonce
+		fail   bool
+	}{
+		{
+			filter: TxPoolFilter{},
+			want:   map[string][]string{"pending": {"a:0", "a:1", "a:2", "b:0"}, "queued": {"a:5"}},
+		},
+		{
+			filter: TxPoolFilter{From: []common.Address{bob}},
+			want:   map[string][]string{"pending": {"b:0"}},
+		},
+		{
+			filter: TxPoolFilter{To: []common.Address{bob}},
+			want:   map[string][]string{"pending": {"a:0"}, "queued": {"a:5"}},
+		},
+		{
+			filter: TxPoolFilter{From: []common.Address{alice}, MinNonce: u64(1), MaxNonce: u64(2)},
+			want:   map[string][]string{"pending": {"a:1", "a:2"}},
+		},
+		{
+			filter: TxPoolFilter{MinFee: fee(20), MaxFee: fee(40)},
+			want:   map[string][]string{"pending": {"a:1", "a:2", "b:0"}},
+		},
+		{
+			filter: TxPoolFilter{MinNonce: u64(2), MaxNonce: u64(1)},
+			fail:   true,
+		},
+	}
+	for i, tt := range tests {
+		content, err := api.ContentFiltered(tt.filter)
+		if tt.fail {
+			if err == nil {
+				t.Fatalf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		for _, kind := range []string{"pending", "queued"} {
+			var have []string
+			for account, txs := range content[kind] {
+				for _, tx := range txs {
+					have = append(have, strings.ToLower(account[len(account)-1:])+":"+tx.Nonce.String()[2:])
+				}
+			}
+			sort.Strings(have)
+			if len(have) != len(tt.want[kind]) {
+				t.Fatalf("test %d %s: have %v, want %v", i, kind, have, tt.want[kind])
+			}
+			for j := range have {
+				if have[j] != tt.want[kind][j] {
+					t.Fatalf("test %d %s: have %v, want %v", i, kind, have, tt.want[kind])
+				}
+			}
+		}
+	}
+}
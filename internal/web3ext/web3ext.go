@@ -153,6 +153,51 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'snapshotProgress',
+			call: 'admin_snapshotProgress',
+		}),
+		new web3._extend.Method({
+			name: 'setSnapshotRateLimit',
+			call: 'admin_setSnapshotRateLimit',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'setSnapServing',
+			call: 'admin_setSnapServing',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'verifySnapshotRange',
+			call: 'admin_verifySnapshotRange',
+			params: 4,
+			inputFormatter: [null, web3._extend.utils.fromDecimal, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'startSnapshotVerifier',
+			call: 'admin_startSnapshotVerifier',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'stopSnapshotVerifier',
+			call: 'admin_stopSnapshotVerifier',
+		}),
+		new web3._extend.Method({
+			name: 'snapshotVerifierStatus',
+			call: 'admin_snapshotVerifierStatus',
+		}),
+		new web3._extend.Method({
+			name: 'recompileStylusTargets',
+			call: 'admin_recompileStylusTargets',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stylusRecompileStatus',
+			call: 'admin_stylusRecompileStatus',
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -270,6 +315,22 @@ web3._extend({
 			name: 'chaindbCompact',
 			call: 'debug_chaindbCompact',
 		}),
+		new web3._extend.Method({
+			name: 'wasmStoreStats',
+			call: 'debug_wasmStoreStats',
+		}),
+		new web3._extend.Method({
+			name: 'collectWasmStore',
+			call: 'debug_collectWasmStore',
+		}),
+		new web3._extend.Method({
+			name: 'compactWasmStore',
+			call: 'debug_compactWasmStore',
+		}),
+		new web3._extend.Method({
+			name: 'recentWasms',
+			call: 'debug_recentWasms',
+		}),
 		new web3._extend.Method({
 			name: 'verbosity',
 			call: 'debug_verbosity',
@@ -425,6 +486,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceBlockToFile',
+			call: 'debug_traceBlockToFile',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 		new web3._extend.Method({
 			name: 'traceTransaction',
 			call: 'debug_traceTransaction',
@@ -41,6 +41,10 @@ const (
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
 )
 
+// Arbitrum: WasmDatabaseName is the name of the wasm store database, relocated by
+// the WasmDir setting.
+const WasmDatabaseName = "wasm"
+
 // Config represents a small collection of configuration values to fine tune the
 // P2P network layer of a protocol stack. These values can be further extended by
 // all registered services.
@@ -64,6 +68,17 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// Arbitrum: WasmDir is the file system folder of the wasm store, holding the
+	// compiled Stylus programs, so that it can be placed on a separate device from
+	// the chain data. Relative paths are resolved against the instance directory.
+	// If empty, the wasm store is kept in the instance directory.
+	WasmDir string `toml:",omitempty"`
+
+	// Arbitrum: WasmAncientDir is the file system folder of the freezer holding
+	// the asm of the cold Stylus programs, resolved like WasmDir. If empty, the
+	// wasm store is opened without a freezer.
+	WasmAncientDir string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
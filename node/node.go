@@ -774,7 +774,7 @@ func (n *Node) OpenDatabaseWithExtraOptions(name string, cache, handles int, nam
 	} else {
 		db, err = rawdb.Open(rawdb.OpenOptions{
 			Type:               n.config.DBEngine,
-			Directory:          n.ResolvePath(name),
+			Directory:          n.ResolveDatabase(name),
 			Namespace:          namespace,
 			Cache:              cache,
 			Handles:            handles,
@@ -811,7 +811,7 @@ func (n *Node) OpenDatabaseWithFreezerWithExtraOptions(name string, cache, handl
 	} else {
 		db, err = rawdb.Open(rawdb.OpenOptions{
 			Type:               n.config.DBEngine,
-			Directory:          n.ResolvePath(name),
+			Directory:          n.ResolveDatabase(name),
 			AncientsDirectory:  n.ResolveAncient(name, ancient),
 			Namespace:          namespace,
 			Cache:              cache,
@@ -831,11 +831,38 @@ func (n *Node) ResolvePath(x string) string {
 	return n.config.ResolvePath(x)
 }
 
+// Arbitrum: ResolveDatabase returns the absolute path of the database with the
+// given name, which is in the instance directory unless it's the wasm store and
+// a wasm directory is configured.
+func (n *Node) ResolveDatabase(name string) string {
+	if name == WasmDatabaseName && n.config.WasmDir != "" {
+		return n.ResolvePath(n.config.WasmDir)
+	}
+	return n.ResolvePath(name)
+}
+
+// Arbitrum: OpenWasmDatabase opens the wasm store (or creates one if no previous
+// can be found) from the configured wasm directory, with its own cache and file
+// handle allowance. If a wasm ancient directory is configured, the freezer of the
+// cold programs is attached to it.
+func (n *Node) OpenWasmDatabase(cache, handles int, namespace string, readonly bool, pebbleExtraOptions *pebble.ExtraOptions) (ethdb.KeyValueStore, error) {
+	db, err := n.OpenDatabaseWithExtraOptions(WasmDatabaseName, cache, handles, namespace, readonly, pebbleExtraOptions)
+	if err != nil || n.config.DataDir == "" || n.config.WasmAncientDir == "" {
+		return db, err
+	}
+	store, err := rawdb.NewWasmStoreWithFreezer(db, n.ResolvePath(n.config.WasmAncientDir), readonly)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
 // ResolveAncient returns the absolute path of the root ancient directory.
 func (n *Node) ResolveAncient(name string, ancient string) string {
 	switch {
 	case ancient == "":
-		ancient = filepath.Join(n.ResolvePath(name), "ancient")
+		ancient = filepath.Join(n.ResolveDatabase(name), "ancient")
 	case !filepath.IsAbs(ancient):
 		ancient = n.ResolvePath(ancient)
 	}
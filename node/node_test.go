@@ -22,6 +22,8 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
@@ -167,6 +169,38 @@ func TestNodeCloseClosesDB(t *testing.T) {
 	}
 }
 
+// This test checks that the wasm store is opened from the configured wasm
+// directory, along with its freezer.
+func TestNodeOpenWasmDatabase(t *testing.T) {
+	config := testNodeConfig()
+	config.DataDir = t.TempDir()
+	config.WasmDir = filepath.Join(t.TempDir(), "wasm")
+	config.WasmAncientDir = filepath.Join(t.TempDir(), "wasm-ancient")
+	stack, err := New(config)
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	defer stack.Close()
+
+	if have := stack.ResolveDatabase(WasmDatabaseName); have != config.WasmDir {
+		t.Fatalf("wasm store path mismatch: have %s, want %s", have, config.WasmDir)
+	}
+	if have, want := stack.ResolveDatabase("chaindata"), stack.ResolvePath("chaindata"); have != want {
+		t.Fatalf("chain database path mismatch: have %s, want %s", have, want)
+	}
+	db, err := stack.OpenWasmDatabase(0, 0, "", false, nil)
+	if err != nil {
+		t.Fatal("can't open wasm store:", err)
+	}
+	defer db.Close()
+
+	for _, dir := range []string{config.WasmDir, config.WasmAncientDir} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Fatalf("directory %s not created: %v", dir, err)
+		}
+	}
+}
+
 // This test checks that OpenDatabase can be used from within a Lifecycle Start method.
 func TestNodeOpenDatabaseFromLifecycleStart(t *testing.T) {
 	stack, _ := New(testNodeConfig())
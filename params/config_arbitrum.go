@@ -17,7 +17,9 @@
 package params
 
 import (
+	"maps"
 	"math/big"
+	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -53,6 +55,58 @@ type ArbitrumChainParams struct {
 	GenesisBlockNum           uint64
 	MaxCodeSize               uint64 `json:"MaxCodeSize,omitempty"`     // Maximum bytecode to permit for a contract. 0 value implies params.DefaultMaxCodeSize
 	MaxInitCodeSize           uint64 `json:"MaxInitCodeSize,omitempty"` // Maximum initcode to permit in a creation transaction and create instructions. 0 value implies params.DefaultMaxInitCodeSize
+
+	RecentWasms *RecentWasmsConfig `json:"RecentWasms,omitempty"` // Policy of the recently used Stylus programs cache. nil value implies the legacy per-call behavior
+	NonceKeys   *NonceKeysConfig   `json:"NonceKeys,omitempty"`   // Activation of the independent nonce lanes of the accounts besides their nonce (2D nonces). nil value implies no lanes
+	WasmPages   *WasmPagesConfig   `json:"WasmPages,omitempty"`   // Ceilings of the wasm pages opened by Stylus programs. nil value implies no ceiling
+	WarmAccess  *WarmAccessConfig  `json:"WarmAccess,omitempty"`  // Accounts and storage slots warm from the start of every transaction, on top of the precompiles
+	OpcodeGas   *OpcodeGasConfig   `json:"OpcodeGas,omitempty"`   // Constant gas of the opcodes, replacing the one of the fork on top of any dynamic gas
+
+	EIPActivations map[int]uint64 `json:"EIPActivations,omitempty"` // ArbOS versions activating EIPs independently of their fork, by EIP number. Unscheduled EIPs follow their fork
+}
+
+// NonceKeysConfig schedules the nonce lanes of the accounts, keyed by a nonce
+// key alongside their regular nonce.
+type NonceKeysConfig struct {
+	ArbosVersion uint64 `json:"arbosVersion,omitempty"` // ArbOS version from which the accounts have nonce lanes. 0 value implies from genesis
+}
+
+// OpcodeGasConfig overrides the constant gas of opcodes, from the given ArbOS
+// version on.
+type OpcodeGasConfig struct {
+	ArbosVersion uint64            `json:"arbosVersion,omitempty"` // ArbOS version from which the overrides apply. 0 value implies from genesis
+	Gas          map[string]uint64 `json:"gas,omitempty"`          // Constant gas of the opcodes by name
+}
+
+// WarmAccessConfig declares the accounts and storage slots warm from the start
+// of every transaction, from the given ArbOS version on.
+type WarmAccessConfig struct {
+	ArbosVersion uint64            `json:"arbosVersion,omitempty"` // ArbOS version from which the accounts are warm. 0 value implies from genesis
+	Accounts     []WarmAccessTuple `json:"accounts,omitempty"`     // Accounts and storage slots warm
+}
+
+// WarmAccessTuple is an account, and some of its storage slots, added to the
+// access list of every transaction after Berlin, making their first access as
+// cheap as their following ones.
+type WarmAccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys,omitempty"`
+}
+
+// WasmPagesConfig bounds the wasm memory opened by the Stylus programs, on top
+// of the memory pricing of ArbOS.
+type WasmPagesConfig struct {
+	ArbosVersion  uint64 `json:"arbosVersion,omitempty"`  // ArbOS version from which the ceilings apply. 0 value implies from genesis
+	MaxTxPages    uint16 `json:"maxTxPages,omitempty"`    // Number of pages open at once in a transaction. 0 value implies no ceiling
+	MaxBlockPages uint64 `json:"maxBlockPages,omitempty"` // Number of pages opened across the transactions of a block. 0 value implies no ceiling
+}
+
+// RecentWasmsConfig is the policy of the cache of the Stylus programs recently
+// used within a block, which discounts their repeated calls.
+type RecentWasmsConfig struct {
+	ArbosVersion uint64 `json:"arbosVersion,omitempty"` // ArbOS version from which the policy applies. 0 value implies from genesis
+	Capacity     uint16 `json:"capacity,omitempty"`     // Number of programs retained. 0 value implies the ArbOS block cache size
+	MaxAge       uint64 `json:"maxAge,omitempty"`       // Number of program calls after which an unused entry decays. 0 value disables decay
 }
 
 func (c *ChainConfig) IsArbitrum() bool {
@@ -77,10 +131,63 @@ func (c *ChainConfig) MaxInitCodeSize() uint64 {
 	return c.ArbitrumChainParams.MaxInitCodeSize
 }
 
+// NonceKeysEnabled returns whether accounts have keyed nonces, independent of
+// their regular nonce, at the given ArbOS version.
+func (c *ChainConfig) NonceKeysEnabled(currentArbosVersion uint64) bool {
+	lanes := c.ArbitrumChainParams.NonceKeys
+	return c.IsArbitrum() && lanes != nil && currentArbosVersion >= lanes.ArbosVersion
+}
+
 func (c *ChainConfig) DebugMode() bool {
 	return c.ArbitrumChainParams.AllowDebugPrecompiles
 }
 
+// ScheduledEIP returns whether the given EIP is active at the given ArbOS
+// version, if the chain schedules it independently of its fork.
+func (c *ChainConfig) ScheduledEIP(eip int, currentArbosVersion uint64) (active bool, scheduled bool) {
+	if !c.IsArbitrum() {
+		return false, false
+	}
+	version, scheduled := c.ArbitrumChainParams.EIPActivations[eip]
+	return scheduled && currentArbosVersion >= version, scheduled
+}
+
+// RecentWasmsPolicy returns the policy of the recent programs cache at the given
+// ArbOS version, nil if the legacy per-call behavior applies.
+func (c *ChainConfig) RecentWasmsPolicy(currentArbosVersion uint64) *RecentWasmsConfig {
+	if policy := c.ArbitrumChainParams.RecentWasms; policy != nil && currentArbosVersion >= policy.ArbosVersion {
+		return policy
+	}
+	return nil
+}
+
+// WasmPagesCeilings returns the ceilings of the wasm pages at the given ArbOS
+// version, nil if the pages are unbounded.
+func (c *ChainConfig) WasmPagesCeilings(currentArbosVersion uint64) *WasmPagesConfig {
+	if ceilings := c.ArbitrumChainParams.WasmPages; ceilings != nil && currentArbosVersion >= ceilings.ArbosVersion {
+		return ceilings
+	}
+	return nil
+}
+
+// WarmAccessTuples returns the accounts and storage slots warm from the start of
+// every transaction at the given ArbOS version.
+func (c *ChainConfig) WarmAccessTuples(currentArbosVersion uint64) []WarmAccessTuple {
+	if warm := c.ArbitrumChainParams.WarmAccess; warm != nil && currentArbosVersion >= warm.ArbosVersion {
+		return warm.Accounts
+	}
+	return nil
+}
+
+// OpcodeGasOverrides returns the constant gas of the opcodes overridden at the
+// given ArbOS version, by name.
+func (c *ChainConfig) OpcodeGasOverrides(currentArbosVersion uint64) map[string]uint64 {
+	if overrides := c.ArbitrumChainParams.OpcodeGas; overrides != nil && currentArbosVersion >= overrides.ArbosVersion {
+		return overrides.Gas
+	}
+	return nil
+}
+
 func (c *ChainConfig) checkArbitrumCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
 	if c.IsArbitrum() != newcfg.IsArbitrum() {
 		// This difference applies to the entire chain, so report that the genesis block is where the difference appears.
@@ -94,6 +201,30 @@ func (c *ChainConfig) checkArbitrumCompatible(newcfg *ChainConfig, head *big.Int
 	if cArb.GenesisBlockNum != newArb.GenesisBlockNum {
 		return newBlockCompatError("genesisblocknum", new(big.Int).SetUint64(cArb.GenesisBlockNum), new(big.Int).SetUint64(newArb.GenesisBlockNum))
 	}
+	if !maps.Equal(cArb.EIPActivations, newArb.EIPActivations) {
+		// The EIP activations are scheduled by ArbOS versions rather than blocks.
+		return newBlockCompatError("eipActivations", common.Big0, common.Big0)
+	}
+	if !reflect.DeepEqual(cArb.NonceKeys, newArb.NonceKeys) {
+		// So are the keyed nonces.
+		return newBlockCompatError("nonceKeys", common.Big0, common.Big0)
+	}
+	if !reflect.DeepEqual(cArb.OpcodeGas, newArb.OpcodeGas) {
+		// So are the opcode gas overrides.
+		return newBlockCompatError("opcodeGas", common.Big0, common.Big0)
+	}
+	if !reflect.DeepEqual(cArb.RecentWasms, newArb.RecentWasms) {
+		// Nor is the recent programs cache policy, as it changes the Stylus gas.
+		return newBlockCompatError("recentWasms", common.Big0, common.Big0)
+	}
+	if !reflect.DeepEqual(cArb.WasmPages, newArb.WasmPages) {
+		// Nor are the wasm pages ceilings.
+		return newBlockCompatError("wasmPages", common.Big0, common.Big0)
+	}
+	if !reflect.DeepEqual(cArb.WarmAccess, newArb.WarmAccess) {
+		// Nor are the accounts warm by default, as they change the access gas.
+		return newBlockCompatError("warmAccess", common.Big0, common.Big0)
+	}
 	return nil
 }
 
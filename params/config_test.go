@@ -142,6 +142,24 @@ func TestConfigRules(t *testing.T) {
 	}
 }
 
+func TestNonceKeysEnabled(t *testing.T) {
+	c := &ChainConfig{ArbitrumChainParams: ArbitrumChainParams{EnableArbOS: true}}
+	if c.NonceKeysEnabled(ArbosVersion_32) {
+		t.Error("nonce keys enabled without activation")
+	}
+	c.ArbitrumChainParams.NonceKeys = &NonceKeysConfig{ArbosVersion: ArbosVersion_32}
+	if c.NonceKeysEnabled(ArbosVersion_31) {
+		t.Error("nonce keys enabled before their ArbOS version")
+	}
+	if !c.NonceKeysEnabled(ArbosVersion_32) {
+		t.Error("nonce keys not enabled from their ArbOS version")
+	}
+	c.ArbitrumChainParams.EnableArbOS = false
+	if c.NonceKeysEnabled(ArbosVersion_32) {
+		t.Error("nonce keys enabled on a non-Arbitrum chain")
+	}
+}
+
 func TestTimestampCompatError(t *testing.T) {
 	require.Equal(t, new(ConfigCompatError).Error(), "")
 
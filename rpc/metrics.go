@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/metrics"
@@ -46,3 +47,35 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Nanoseconds())
 }
+
+// Arbitrum: the moving average of the serving time of the RPC calls and the
+// time of the last one, tracked regardless of the metrics being enabled so that
+// background work can back off while the calls are slow.
+var (
+	serveTimeAverage atomic.Int64 // Nanoseconds
+	serveTimeLast    atomic.Int64 // Unix nanoseconds
+)
+
+// serveTimeWeight is the inverse of the weight of a call in the moving average.
+const serveTimeWeight = 16
+
+// trackServeTime accounts the serving time of a call in the moving average.
+func trackServeTime(elapsed time.Duration) {
+	for {
+		old := serveTimeAverage.Load()
+		if serveTimeAverage.CompareAndSwap(old, old+(int64(elapsed)-old)/serveTimeWeight) {
+			break
+		}
+	}
+	serveTimeLast.Store(time.Now().UnixNano())
+}
+
+// ServeTimeStats returns the moving average of the serving time of the recent
+// RPC calls, and the time the last one was served at, zero if none was.
+func ServeTimeStats() (time.Duration, time.Time) {
+	last := serveTimeLast.Load()
+	if last == 0 {
+		return 0, time.Time{}
+	}
+	return time.Duration(serveTimeAverage.Load()), time.Unix(0, last)
+}
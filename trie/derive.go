@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// deriveParallelThreshold is the number of list items from which the leaves of
+// a derived root are encoded concurrently.
+const deriveParallelThreshold = 256
+
+// DeriveTxRoot computes the transaction root of a block holding the given
+// transactions.
+func DeriveTxRoot(txs []*types.Transaction) common.Hash {
+	return DeriveRoot(types.Transactions(txs))
+}
+
+// DeriveReceiptRoot computes the receipt root of a block with the given
+// receipts.
+func DeriveReceiptRoot(receipts []*types.Receipt) common.Hash {
+	return DeriveRoot(types.Receipts(receipts))
+}
+
+// DeriveWithdrawalRoot computes the withdrawal root of a block holding the
+// given withdrawals.
+func DeriveWithdrawalRoot(withdrawals []*types.Withdrawal) common.Hash {
+	return DeriveRoot(types.Withdrawals(withdrawals))
+}
+
+// DeriveRoot computes the root of the trie mapping the RLP-encoded indices of
+// the list items to their consensus encoding, with a stack trie. It's the same
+// root as types.DeriveSha's, but the leaves of large lists are encoded
+// concurrently.
+func DeriveRoot(list types.DerivableList) common.Hash {
+	var (
+		n      = list.Len()
+		values = encodeDerivableList(list)
+		hasher = NewStackTrie(nil)
+		key    []byte
+	)
+	// The stack trie requires the keys to be inserted in increasing order,
+	// which places the index 0 after the single byte encoded ones.
+	insert := func(i int) {
+		key = rlp.AppendUint64(key[:0], uint64(i))
+		hasher.Update(key, values[i])
+	}
+	for i := 1; i < n && i <= 0x7f; i++ {
+		insert(i)
+	}
+	if n > 0 {
+		insert(0)
+	}
+	for i := 0x80; i < n; i++ {
+		insert(i)
+	}
+	return hasher.Hash()
+}
+
+// encodeDerivableList returns the encoding of every item of the list, split
+// among the available cores if the list is large enough.
+func encodeDerivableList(list types.DerivableList) [][]byte {
+	var (
+		n       = list.Len()
+		values  = make([][]byte, n)
+		workers = 1
+	)
+	if n >= deriveParallelThreshold {
+		workers = min(runtime.NumCPU(), n/deriveParallelThreshold+1)
+	}
+	encode := func(from, to int) {
+		var buf bytes.Buffer
+		for i := from; i < to; i++ {
+			buf.Reset()
+			list.EncodeIndex(i, &buf)
+			values[i] = common.CopyBytes(buf.Bytes())
+		}
+	}
+	if workers <= 1 {
+		encode(0, n)
+		return values
+	}
+	var (
+		wg    sync.WaitGroup
+		chunk = (n + workers - 1) / workers
+	)
+	for from := 0; from < n; from += chunk {
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			encode(from, to)
+		}(from, min(from+chunk, n))
+	}
+	wg.Wait()
+	return values
+}
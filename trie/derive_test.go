@@ -0,0 +1,60 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the derived roots match the ones of DeriveSha, across the list
+// sizes crossing the key ordering boundaries and the parallel encoding.
+func TestDeriveRoot(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 0x7f, 0x80, 0x81, deriveParallelThreshold, 1000} {
+		var (
+			txs         = make([]*types.Transaction, n)
+			receipts    = make([]*types.Receipt, n)
+			withdrawals = make([]*types.Withdrawal, n)
+		)
+		for i := 0; i < n; i++ {
+			to := common.BigToAddress(big.NewInt(int64(i)))
+			txs[i] = types.NewTx(&types.DynamicFeeTx{Nonce: uint64(i), To: &to, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), Value: big.NewInt(int64(i))})
+			receipts[i] = &types.Receipt{
+				Type:              types.DynamicFeeTxType,
+				Status:            types.ReceiptStatusSuccessful,
+				CumulativeGasUsed: uint64(21000 * (i + 1)),
+				Logs:              []*types.Log{{Address: to, Data: []byte{byte(i)}}},
+			}
+			withdrawals[i] = &types.Withdrawal{Index: uint64(i), Validator: uint64(i), Address: to, Amount: uint64(i)}
+		}
+		if have, want := DeriveTxRoot(txs), types.DeriveSha(types.Transactions(txs), NewStackTrie(nil)); have != want {
+			t.Errorf("%d transactions: root mismatch: have %x, want %x", n, have, want)
+		}
+		if have, want := DeriveReceiptRoot(receipts), types.DeriveSha(types.Receipts(receipts), NewStackTrie(nil)); have != want {
+			t.Errorf("%d receipts: root mismatch: have %x, want %x", n, have, want)
+		}
+		if have, want := DeriveWithdrawalRoot(withdrawals), types.DeriveSha(types.Withdrawals(withdrawals), NewStackTrie(nil)); have != want {
+			t.Errorf("%d withdrawals: root mismatch: have %x, want %x", n, have, want)
+		}
+	}
+	if root := DeriveTxRoot(nil); root != types.EmptyTxsHash {
+		t.Errorf("empty root mismatch: have %x, want %x", root, types.EmptyTxsHash)
+	}
+}
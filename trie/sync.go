@@ -240,7 +240,14 @@ type Sync struct {
 
 // NewSync creates a new trie data download scheduler.
 func NewSync(root common.Hash, database ethdb.KeyValueReader, callback LeafCallback, scheme string) *Sync {
-	ts := &Sync{
+	ts := newSync(database, scheme)
+	ts.AddSubTrie(root, nil, common.Hash{}, nil, callback)
+	return ts
+}
+
+// newSync creates a trie data download scheduler with nothing scheduled.
+func newSync(database ethdb.KeyValueReader, scheme string) *Sync {
+	return &Sync{
 		scheme:   scheme,
 		database: database,
 		membatch: newSyncMemBatch(scheme),
@@ -249,8 +256,6 @@ func NewSync(root common.Hash, database ethdb.KeyValueReader, callback LeafCallb
 		queue:    prque.New[int64, any](nil), // Ugh, can contain both string and hash, whyyy
 		fetches:  make(map[int]int),
 	}
-	ts.AddSubTrie(root, nil, common.Hash{}, nil, callback)
-	return ts
 }
 
 // AddSubTrie registers a new trie to the sync code, rooted at the designated
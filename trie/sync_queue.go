@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// SyncQueue is the exported state of a trie sync scheduler: the trie nodes and
+// codes pending retrieval, along with the retrieved trie nodes waiting for their
+// subtries to complete. It allows resuming an interrupted sync without fetching
+// again the nodes above the pending ones.
+//
+// The nodes and codes retrieved and committed by the scheduler are not part of
+// the queue, they must have been flushed to the database before exporting it.
+type SyncQueue struct {
+	Nodes []*SyncQueueNode `json:"nodes"` // Trie node requests, parents before their children
+	Codes []*SyncQueueCode `json:"codes"` // Code requests
+}
+
+// SyncQueueNode is a trie node request of an exported sync queue.
+type SyncQueueNode struct {
+	Hash   common.Hash `json:"hash"`
+	Path   []byte      `json:"path"`
+	Data   []byte      `json:"data,omitempty"` // Content of the node if retrieved, awaiting its subtries
+	Parent int         `json:"parent"`         // Index of the parent node request, -1 if none
+}
+
+// SyncQueueCode is a code request of an exported sync queue.
+type SyncQueueCode struct {
+	Hash    common.Hash `json:"hash"`
+	Path    []byte      `json:"path"`
+	Parents []int       `json:"parents,omitempty"` // Indices of the parent node requests
+}
+
+// Export returns the pending requests of the scheduler, including the ones in
+// flight, to resume the sync with RestoreSync.
+func (s *Sync) Export() *SyncQueue {
+	reqs := make([]*nodeRequest, 0, len(s.nodeReqs))
+	for _, req := range s.nodeReqs {
+		reqs = append(reqs, req)
+	}
+	// The paths of the parents are always shorter than the ones of their
+	// children, sorting by length places the parents first.
+	slices.SortFunc(reqs, func(a, b *nodeRequest) int {
+		if len(a.path) != len(b.path) {
+			return len(a.path) - len(b.path)
+		}
+		return bytes.Compare(a.path, b.path)
+	})
+	var (
+		queue = &SyncQueue{
+			Nodes: make([]*SyncQueueNode, len(reqs)),
+			Codes: make([]*SyncQueueCode, 0, len(s.codeReqs)),
+		}
+		index = make(map[*nodeRequest]int, len(reqs))
+	)
+	for i, req := range reqs {
+		index[req] = i
+	}
+	for i, req := range reqs {
+		node := &SyncQueueNode{
+			Hash:   req.hash,
+			Path:   common.CopyBytes(req.path),
+			Data:   common.CopyBytes(req.data),
+			Parent: -1,
+		}
+		if req.parent != nil {
+			node.Parent = index[req.parent]
+		}
+		queue.Nodes[i] = node
+	}
+	for _, req := range s.codeReqs {
+		code := &SyncQueueCode{Hash: req.hash, Path: common.CopyBytes(req.path)}
+		for _, parent := range req.parents {
+			code.Parents = append(code.Parents, index[parent])
+		}
+		queue.Codes = append(queue.Codes, code)
+	}
+	slices.SortFunc(queue.Codes, func(a, b *SyncQueueCode) int { return a.Hash.Cmp(b.Hash) })
+	return queue
+}
+
+// RestoreSync recreates a trie data download scheduler from the requests of an
+// exported sync queue. The callback is invoked on the leaves of the top trie,
+// and subCallback on the leaves of the layered tries.
+func RestoreSync(queue *SyncQueue, database ethdb.KeyValueReader, callback LeafCallback, subCallback LeafCallback, scheme string) (*Sync, error) {
+	s := newSync(database, scheme)
+
+	reqs := make([]*nodeRequest, len(queue.Nodes))
+	for i, node := range queue.Nodes {
+		req := &nodeRequest{
+			hash:     node.Hash,
+			path:     common.CopyBytes(node.Path),
+			data:     common.CopyBytes(node.Data),
+			callback: callback,
+		}
+		if len(req.path) >= 2*common.HashLength {
+			req.callback = subCallback
+		}
+		if node.Parent >= 0 {
+			if node.Parent >= i {
+				return nil, fmt.Errorf("node request %x scheduled before its parent", node.Path)
+			}
+			req.parent = reqs[node.Parent]
+			req.parent.deps++
+		}
+		reqs[i] = req
+	}
+	for _, code := range queue.Codes {
+		req := &codeRequest{hash: code.Hash, path: common.CopyBytes(code.Path)}
+		for _, parent := range code.Parents {
+			if parent < 0 || parent >= len(reqs) {
+				return nil, fmt.Errorf("code request %x with unknown parent %d", code.Hash, parent)
+			}
+			req.parents = append(req.parents, reqs[parent])
+			reqs[parent].deps++
+		}
+		s.scheduleCodeRequest(req)
+	}
+	// Queue the node requests not retrieved yet, and track the retrieved ones
+	// as fetched already so their commits balance the fetch counters.
+	for _, req := range reqs {
+		if req.data == nil {
+			s.scheduleNodeRequest(req)
+			continue
+		}
+		s.nodeReqs[string(req.path)] = req
+		s.fetches[len(req.path)]++
+	}
+	// Retrieved nodes may not be left waiting on nothing, commit them
+	for _, req := range reqs {
+		if req.data != nil && req.deps == 0 {
+			if _, ok := s.nodeReqs[string(req.path)]; ok {
+				if err := s.commitNodeRequest(req); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return s, nil
+}
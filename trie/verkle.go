@@ -96,6 +96,11 @@ func (t *VerkleTrie) GetAccount(addr common.Address) (*types.StateAccount, error
 	if values == nil {
 		return nil, nil
 	}
+	// Deleted accounts are overwritten with zeroes, while live ones always carry
+	// a code hash.
+	if common.BytesToHash(values[utils.CodeKeccakLeafKey]) == (common.Hash{}) {
+		return nil, nil
+	}
 	// Decode nonce in little-endian
 	if len(values[utils.NonceLeafKey]) > 0 {
 		acc.Nonce = binary.LittleEndian.Uint64(values[utils.NonceLeafKey])
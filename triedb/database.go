@@ -333,6 +333,17 @@ func (db *Database) Journal(root common.Hash) error {
 	return pdb.Journal(root)
 }
 
+// Layers returns the layers of the layer tree kept in memory, the disk layer
+// first. It's only supported by path-based database and will return an error
+// for others.
+func (db *Database) Layers() ([]pathdb.LayerInfo, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return pdb.Layers(), nil
+}
+
 // SetBufferSize sets the node buffer size to the provided value(in bytes).
 // It's only supported by path-based database and will return an error for
 // others.
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -90,10 +91,11 @@ type layer interface {
 
 // Config contains the settings for database.
 type Config struct {
-	StateHistory   uint64 // Number of recent blocks to maintain state history for
-	CleanCacheSize int    // Maximum memory allowance (in bytes) for caching clean nodes
-	DirtyCacheSize int    // Maximum memory allowance (in bytes) for caching dirty nodes
-	ReadOnly       bool   // Flag whether the database is opened in read only mode.
+	StateHistory      uint64 // Number of recent blocks to maintain state history for
+	StateHistoryBytes uint64 // Maximum size (in bytes) of the state history to maintain, 0 for unlimited
+	CleanCacheSize    int    // Maximum memory allowance (in bytes) for caching clean nodes
+	DirtyCacheSize    int    // Maximum memory allowance (in bytes) for caching dirty nodes
+	ReadOnly          bool   // Flag whether the database is opened in read only mode.
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -455,6 +457,36 @@ func (db *Database) Size() (diffs common.StorageSize, nodes common.StorageSize)
 	return diffs, nodes
 }
 
+// LayerInfo describes a layer of the layer tree kept in memory.
+type LayerInfo struct {
+	Root    common.Hash        // Root of the state represented by the layer
+	Parent  common.Hash        // Root of the parent layer, zero for the disk layer
+	StateID uint64             // State id of the layer
+	Block   uint64             // Number of the block of the layer, zero for the disk layer
+	Size    common.StorageSize // Memory used by the diff layer or the disk layer's node buffer
+	Disk    bool               // Whether the layer is the disk layer
+}
+
+// Layers returns the layers of the layer tree, the disk layer first followed by
+// the diff layers ordered by state id.
+func (db *Database) Layers() []LayerInfo {
+	var layers []LayerInfo
+	db.tree.forEach(func(layer layer) {
+		info := LayerInfo{Root: layer.rootHash(), StateID: layer.stateID()}
+		switch l := layer.(type) {
+		case *diffLayer:
+			info.Parent, info.Block, info.Size = l.parentLayer().rootHash(), l.block, common.StorageSize(l.memory)
+		case *diskLayer:
+			info.Size, info.Disk = l.size(), true
+		}
+		layers = append(layers, info)
+	})
+	sort.Slice(layers, func(i, j int) bool {
+		return layers[i].StateID < layers[j].StateID
+	})
+	return layers
+}
+
 // Initialized returns an indicator if the state data is already
 // initialized in path-based scheme.
 func (db *Database) Initialized(genesisRoot common.Hash) bool {
@@ -637,6 +637,34 @@ func TestTailTruncateHistory(t *testing.T) {
 	}
 }
 
+func TestTailTruncateHistoryBytes(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Any history exceeds the allowance, only the latest one is retained
+	tester.db.config.StateHistoryBytes = 1
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to cap database, err: %v", err)
+	}
+	head, err := tester.db.freezer.Ancients()
+	if err != nil {
+		t.Fatalf("Failed to obtain freezer head")
+	}
+	tail, err := tester.db.freezer.Tail()
+	if err != nil {
+		t.Fatalf("Failed to obtain freezer tail")
+	}
+	if head != uint64(len(tester.roots)) || tail != head-1 {
+		t.Fatalf("Unexpected history range, tail: %d, head: %d", tail, head)
+	}
+}
+
 // copyAccounts returns a deep-copied account set of the provided one.
 func copyAccounts(set map[common.Hash][]byte) map[common.Hash][]byte {
 	copied := make(map[common.Hash][]byte, len(set))
@@ -657,3 +685,31 @@ func copyStorages(set map[common.Hash]map[common.Hash][]byte) map[common.Hash]ma
 	}
 	return copied
 }
+
+func TestLayers(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	layers := tester.db.Layers()
+	if len(layers) != tester.db.tree.len() {
+		t.Fatalf("Layer count mismatch, want %d, got %d", tester.db.tree.len(), len(layers))
+	}
+	bottom := tester.db.tree.bottom()
+	if !layers[0].Disk || layers[0].Root != bottom.rootHash() || layers[0].StateID != bottom.stateID() {
+		t.Fatalf("Disk layer is invalid: %+v", layers[0])
+	}
+	for i := 1; i < len(layers); i++ {
+		if layers[i].Disk || layers[i].Parent != layers[i-1].Root || layers[i].StateID != layers[i-1].StateID+1 {
+			t.Fatalf("Diff layer %d is invalid: %+v", i, layers[i])
+		}
+	}
+	if layers[len(layers)-1].Root != tester.lastHash() {
+		t.Fatal("Layer tree structure is invalid")
+	}
+}
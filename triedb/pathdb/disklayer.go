@@ -18,6 +18,7 @@ package pathdb
 
 import (
 	"fmt"
+	"math"
 	"sync"
 
 	"github.com/VictoriaMetrics/fastcache"
@@ -179,6 +180,23 @@ func (dl *diskLayer) commit(bottom *diffLayer, force bool) (*diskLayer, error) {
 			overflow = true
 			oldest = bottom.stateID() - limit + 1 // track the id of history **after truncation**
 		}
+		// Arbitrum: also bound the history by size, dropping the number of
+		// oldest histories the excess amounts to on average
+		if maxBytes := dl.db.config.StateHistoryBytes; maxBytes != 0 {
+			size, err := rawdb.ReadStateHistorySize(dl.db.freezer)
+			if err != nil {
+				return nil, err
+			}
+			if items := bottom.stateID() - tail; size > maxBytes && items > 1 {
+				drop := uint64(math.Ceil(float64(size-maxBytes) / float64(size) * float64(items)))
+				if drop >= items {
+					drop = items - 1 // always retain the latest history
+				}
+				if tailID := tail + drop + 1; !overflow || tailID > oldest {
+					overflow, oldest = true, tailID
+				}
+			}
+		}
 	}
 	// Mark the diskLayer as stale before applying any mutations on top.
 	dl.stale = true
@@ -49,12 +49,14 @@ func sanitizeRange(start, end uint64, freezer ethdb.AncientReader) (uint64, uint
 	if err != nil {
 		return 0, 0, err
 	}
-	last := head - 1
+	// The histories are indexed from one, so the number of items is the id
+	// of the last one.
+	last := head
 	if end != 0 && end < last {
 		last = end
 	}
 	// Make sure the range is valid
-	if first >= last {
+	if first > last {
 		return 0, 0, fmt.Errorf("range is invalid, first: %d, last: %d", first, last)
 	}
 	return first, last, nil
@@ -137,7 +139,7 @@ func historyRange(freezer ethdb.AncientReader) (uint64, uint64, error) {
 	if err != nil {
 		return 0, 0, err
 	}
-	last := head - 1
+	last := head
 
 	fh, err := readHistory(freezer, first)
 	if err != nil {